@@ -1,9 +1,15 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 
+	"fyne.io/fyne/v2"
 	fyneapp "fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/lang"
 
 	"io.github.sandwichdoge.vibesandfolders/internal/app"
 	"io.github.sandwichdoge.vibesandfolders/internal/ui"
@@ -13,49 +19,147 @@ func main() {
 	myApp := fyneapp.NewWithID("io.github.sandwichdoge.vibesandfolders")
 
 	logger := app.NewLogger(true)
-	config := app.LoadConfig(myApp, logger)
+	config := app.LoadConfig(myApp.Storage().RootURI().Path(), logger)
+
+	// Each subsystem logs at its own level to the console and/or a file
+	if err := logger.Configure(config.Logging, app.LogFilePath(config, myApp.Storage().RootURI().Path())); err != nil {
+		logger.Error("Failed to configure logging: %v", err)
+	}
+	httpLogger := logger.For(app.LogSubsystemHTTP)
+	indexLogger := logger.For(app.LogSubsystemIndex)
+	executionLogger := logger.For(app.LogSubsystemExecution)
+	uiLogger := logger.For(app.LogSubsystemUI)
+
+	// Closing the window stops background jobs at a safe point, then closes what was opened below
+	// in reverse order
+	shutdown := app.NewShutdownCoordinator(logger)
+	shutdown.OnShutdown("log file", logger.Close)
+
+	// Only one instance may open the index; later launches hand their folder to it and exit
+	launch := app.InstanceRequest{Directory: launchDirectory()}
+	instance, err := app.ClaimInstance(filepath.Join(myApp.Storage().RootURI().Path(), "instance.sock"), launch, logger)
+	if errors.Is(err, app.ErrInstanceRunning) {
+		logger.Info("Handed over to the running instance")
+		logger.Close()
+		return
+	}
+	if errors.Is(err, app.ErrInstanceNotResponding) {
+		// Starting anyway would open the index alongside the running instance
+		logger.Error("%v", err)
+		logger.Close()
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to check for a running instance: %v", err)
+	} else {
+		shutdown.OnShutdown("instance socket", instance.Close)
+	}
 
 	// Set default IndexDBPath if not configured
 	if config.IndexDBPath == "" {
 		config.IndexDBPath = filepath.Join(myApp.Storage().RootURI().Path(), "index.db")
-		app.SaveConfig(myApp, config, logger)
+		app.SaveConfig(myApp.Storage().RootURI().Path(), config, logger)
 	}
 
-	validator := app.NewValidator()
-	httpClient := app.NewHTTPClient(logger)
-
-	aiService := app.NewOpenAIService(config, httpClient, logger)
-	fileService := app.NewFileService(validator, logger)
-
-	// Set ignore patterns from config
-	fileService.SetIgnorePatterns(config.IgnorePatterns)
+	// The services shared with pkg/organizer; the rest of main adds the desktop app's background jobs
+	engine, err := app.NewEngine(app.EngineOptions{
+		Config:        config,
+		Logger:        logger,
+		DataDir:       myApp.Storage().RootURI().Path(),
+		AppVersion:    myApp.Metadata().Version,
+		SystemLocale:  lang.SystemLocale().String(),
+		IndexOptional: true,
+		Shutdown:      shutdown,
+	})
+	if err != nil {
+		logger.Error("Failed to start: %v", err)
+		shutdown.Shutdown(app.DefaultShutdownTimeout)
+		return
+	}
+	httpClient := engine.HTTPClient
+	indexService := engine.Index
+	orchestrator := engine.Orchestrator
+	pendingPlans := engine.PendingPlans
 
-	// Initialize IndexService
-	indexService := app.NewIndexService(logger)
-	if err := indexService.Initialize(config.IndexDBPath); err != nil {
-		logger.Error("Failed to initialize index service: %v", err)
-		// Continue without indexing
-		indexService = nil
-	} else {
-		// Set ignore patterns for indexing
-		indexService.SetIgnorePatterns(config.IgnorePatterns)
+	// Periodically drop index entries for deleted files, not only when their directory is analyzed
+	var indexJanitor *app.IndexJanitor
+	if indexService != nil {
+		indexJanitor = app.NewIndexJanitor(indexService, config, indexLogger)
+		indexJanitor.SetSummaryHandler(func(summary app.JanitorSummary) {
+			myApp.SendNotification(fyne.NewNotification("Index cleanup", summary.String()))
+		})
+		indexJanitor.Start()
+		shutdown.OnShutdown("index janitor", func() error {
+			indexJanitor.Stop()
+			return nil
+		})
 	}
 
-	// Initialize DeepAnalysisService (for file analysis)
-	var deepAnalysisService *app.DeepAnalysisService
-	var indexOrchestrator *app.IndexDirectoryOrchestrator
+	// Record the files of shared folders periodically, to compare what changed between dates
 	if indexService != nil {
-		deepAnalysisService = app.NewDeepAnalysisService(config, httpClient, indexService, logger)
-		// Initialize IndexDirectoryOrchestrator for orchestrating indexing operations
-		indexOrchestrator = app.NewIndexDirectoryOrchestrator(indexService, deepAnalysisService, logger)
+		snapshotter := app.NewDirectorySnapshotter(indexService, config, indexLogger)
+		snapshotter.Start()
+		shutdown.OnShutdown("directory snapshots", func() error {
+			snapshotter.Stop()
+			return nil
+		})
+	}
+
+	// Keep the index current for watched folders, so analyses of them rescan only what changed
+	if watchedFolders := app.ParseWatchedFolders(config.WatchedFolders); indexService != nil && len(watchedFolders) > 0 {
+		watcher, err := app.NewIndexWatcher(indexService, indexLogger)
+		if err != nil {
+			indexLogger.Error("%v", err)
+		} else {
+			shutdown.OnShutdown("folder watcher", watcher.Close)
+			for _, folder := range watchedFolders {
+				if err := watcher.Watch(folder); err != nil {
+					indexLogger.Error("%v", err)
+				}
+			}
+		}
 	}
 
-	orchestrator := app.NewOrchestrator(aiService, fileService, validator, logger, indexOrchestrator, indexService)
+	// Folders organized unattended on a schedule; confident plans are applied, the rest held back
+	autoApplier := app.NewAutoApplier(orchestrator, config, executionLogger)
+	autoApplier.SetReviewQueue(pendingPlans)
+	autoApplier.SetNotifier(app.NewNotifier(config, executionLogger))
+	jobScheduler := app.NewJobScheduler(autoApplier, config, filepath.Join(myApp.Storage().RootURI().Path(), "scheduled_jobs.json"), executionLogger)
+	jobScheduler.SetOutcomeHandler(func(job app.AutomatedJob, outcome *app.AutoApplyOutcome, err error) {
+		switch {
+		case err != nil:
+			myApp.SendNotification(fyne.NewNotification("Scheduled job failed", err.Error()))
+		case outcome.Execution != nil:
+			myApp.SendNotification(fyne.NewNotification("Scheduled job: "+job.Name, fmt.Sprintf("Moved %d files", outcome.Execution.SuccessCount)))
+		case outcome.Queued:
+			myApp.SendNotification(fyne.NewNotification("Scheduled job: "+job.Name, fmt.Sprintf("%d operations wait for review (%s)", len(outcome.Operations), outcome.Decision.Reason)))
+		}
+	})
+	jobScheduler.Start()
+	shutdown.OnShutdown("scheduled jobs", func() error {
+		jobScheduler.Stop()
+		return nil
+	})
 
-	mainWindow := ui.NewMainWindow(myApp, orchestrator, config, logger, httpClient)
+	mainWindow := ui.NewMainWindow(myApp, orchestrator, config, uiLogger, httpClient)
+	mainWindow.SetShutdown(shutdown)
+	if launch.Directory != "" {
+		mainWindow.SetDirectory(launch.Directory)
+	}
+	if instance != nil {
+		instance.SetHandler(func(request app.InstanceRequest) {
+			fyne.Do(func() {
+				if request.Directory != "" {
+					mainWindow.SetDirectory(request.Directory)
+				}
+				mainWindow.Raise()
+			})
+		})
+	}
+	mainWindow.SetUpdateChecker(app.NewUpdateChecker(config, myApp.Metadata().Version, filepath.Join(myApp.Storage().RootURI().Path(), "updates"), httpLogger))
 
 	if config.APIKey == app.DefaultAPIKey || config.Endpoint == "" {
-		configWindow := ui.NewConfigWindow(myApp, config, logger, httpClient)
+		configWindow := ui.NewConfigWindow(myApp, config, uiLogger, httpClient)
 		configWindow.Show(
 			func() {
 				mainWindow.Show()
@@ -68,8 +172,20 @@ func main() {
 		mainWindow.ShowAndRun()
 	}
 
-	// Close indexService on exit
-	if indexService != nil {
-		indexService.Close()
+	// Already done if the main window was closed; this covers quitting from the menu or the setup window
+	shutdown.Shutdown(app.DefaultShutdownTimeout)
+}
+
+// launchDirectory is the folder given on the command line, made absolute so another instance can use it
+func launchDirectory() string {
+	for _, arg := range os.Args[1:] {
+		if strings.HasPrefix(arg, "-") {
+			continue // e.g. -psn_ from macOS launches
+		}
+		if abs, err := filepath.Abs(arg); err == nil {
+			return abs
+		}
+		return arg
 	}
+	return ""
 }
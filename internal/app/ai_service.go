@@ -3,10 +3,10 @@ package app
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"path/filepath"
 	"strings"
 )
 
@@ -28,11 +28,25 @@ func NewOpenAIService(config *Config, httpClient *HTTPClient, logger *Logger) *O
 	}
 }
 
+// WithVariant returns a copy of the service that uses systemPrompt and model instead of the
+// configured ones. Empty values keep the configured ones.
+func (s *OpenAIService) WithVariant(systemPrompt, model string) AIService {
+	config := *s.config
+	if systemPrompt != "" {
+		config.SystemPrompt = systemPrompt
+	}
+	if model != "" {
+		config.Model = model
+	}
+	return &OpenAIService{config: &config, httpClient: s.httpClient, logger: s.logger}
+}
+
 type OpenAIRequest struct {
-	Model     string    `json:"model"`
-	Messages  []Message `json:"messages"`
-	MaxTokens int       `json:"max_tokens,omitempty"`
-	Stream    bool      `json:"stream"` // Enable streaming
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Temperature *float64  `json:"temperature,omitempty"`
+	Stream      bool      `json:"stream"` // Enable streaming
 }
 
 type Message struct {
@@ -50,7 +64,7 @@ type OpenAIStreamResponse struct {
 	} `json:"choices"`
 }
 
-func (s *OpenAIService) GetSuggestions(structure, userPrompt, basePath string, onOperation OperationCallback) ([]FileOperation, error) {
+func (s *OpenAIService) GetSuggestions(ctx context.Context, structure, userPrompt, basePath string, onOperation OperationCallback) ([]FileOperation, error) {
 	systemPrompt := s.config.SystemPrompt
 	fullPrompt := s.buildUserPrompt(basePath, structure, userPrompt)
 
@@ -60,8 +74,9 @@ func (s *OpenAIService) GetSuggestions(structure, userPrompt, basePath string, o
 			{Role: "system", Content: systemPrompt},
 			{Role: "user", Content: fullPrompt},
 		},
-		MaxTokens: defaultMaxTokens,
-		Stream:    true,
+		MaxTokens:   s.config.planMaxTokens(),
+		Temperature: s.config.Temperature,
+		Stream:      true,
 	}
 
 	// Log the final prompt being sent
@@ -75,7 +90,7 @@ func (s *OpenAIService) GetSuggestions(structure, userPrompt, basePath string, o
 		"X-Title":       "VibesAndFolders",
 	}
 
-	streamBody, err := s.httpClient.PostStream(s.config.Endpoint, headers, reqBody)
+	streamBody, err := s.httpClient.PostStream(ctx, s.config.Endpoint, headers, reqBody)
 	if err != nil {
 		return nil, err
 	}
@@ -188,8 +203,19 @@ func (s *OpenAIService) parseSingleOperation(jsonLine, basePath string) (FileOpe
 	}
 
 	// Sanitize paths
-	op.From = filepath.Clean(filepath.Join(basePath, op.From))
-	op.To = filepath.Clean(filepath.Join(basePath, op.To))
+	from, err := JoinStoragePath(basePath, op.From)
+	if err != nil {
+		return op, err
+	}
+	to, err := JoinStoragePath(basePath, op.To)
+	if err != nil {
+		return op, err
+	}
+	op.From, op.To = from, to
+
+	op = s.applyNamingConvention(basePath, op)
+	op = s.applyFolderEmoji(basePath, op)
+	op = s.sanitizeDestination(basePath, op)
 
 	if op.From == op.To {
 		return op, fmt.Errorf("source and destination are identical")
@@ -199,5 +225,12 @@ func (s *OpenAIService) parseSingleOperation(jsonLine, basePath string) (FileOpe
 }
 
 func (s *OpenAIService) buildUserPrompt(basePath, structure, userPrompt string) string {
+	if s.config.StructureFormat == StructureFormatJSON {
+		jsonStructure, err := FormatStructureAsJSON(structure)
+		if err == nil {
+			return fmt.Sprintf("Base directory: %s\n\nDirectory structure (JSON tree; folder keys end with \"/\", file sizes are in bytes):\n%s\n\nUser instructions: %s", basePath, jsonStructure, userPrompt)
+		}
+		s.logger.Error("Failed to format structure as JSON, sending text instead: %v", err)
+	}
 	return fmt.Sprintf("Base directory: %s\n\nDirectory structure:\n%s\n\nUser instructions: %s", basePath, structure, userPrompt)
 }
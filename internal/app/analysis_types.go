@@ -0,0 +1,26 @@
+package app
+
+import "errors"
+
+var ErrAnalysisDisabled = errors.New("deep analysis is disabled for this file type")
+
+// AnalysisFileTypes are the file types returned by DetermineFileType, in the order they are
+// listed in the configuration window
+var AnalysisFileTypes = []string{
+	"text", "code", "pdf", "document", "excel", "csv", "powerpoint", "notebook", "image", "video", "audio", "other",
+}
+
+// AnalysisTypeEnabled reports whether deep analysis may send files of fileType to the model
+func (c *Config) AnalysisTypeEnabled(fileType string) bool {
+	for _, disabled := range c.DisabledAnalysisTypes {
+		if disabled == fileType {
+			return false
+		}
+	}
+	return true
+}
+
+// AnalysisEnabledFor reports whether deep analysis may run on filePath
+func (c *Config) AnalysisEnabledFor(filePath string) bool {
+	return c.AnalysisTypeEnabled(DetermineFileType(filePath))
+}
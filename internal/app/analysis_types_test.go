@@ -0,0 +1,40 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDisabledAnalysisTypes(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"main.go", "notes.txt", "photo.png"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := &Config{DisabledAnalysisTypes: []string{"code", "image"}}
+	logger := NewLogger(false)
+
+	indexService := NewIndexService(logger)
+	if err := indexService.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	indexService.SetAnalysisFilter(config.AnalysisEnabledFor)
+
+	changes, err := indexService.ScanDirectoryChanges(dir, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(changes.NewFiles) != 1 || filepath.Base(changes.NewFiles[0]) != "notes.txt" {
+		t.Errorf("expected only notes.txt to be scheduled for indexing, got %v", changes.NewFiles)
+	}
+
+	das := NewDeepAnalysisService(config, NewHTTPClient(config, logger), indexService, logger)
+	if _, err := das.AnalyzeFile(context.Background(), filepath.Join(dir, "main.go")); !errors.Is(err, ErrAnalysisDisabled) {
+		t.Errorf("expected ErrAnalysisDisabled for source code, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const pluginTimeout = 2 * time.Minute
+
+// PluginResult is what an analyzer plugin returns for a file.
+// Plugins either describe the file themselves, or extract text content that is then
+// summarized by the configured LLM with the text analysis prompt.
+type PluginResult struct {
+	Description string `json:"description,omitempty"`
+	Content     string `json:"content,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// AnalyzerPlugin handles deep analysis for formats DeepAnalysisService doesn't understand natively
+// (CAD drawings, DICOM scans, camera RAW, ...). Descriptions land in the same index as built-in analyzers.
+type AnalyzerPlugin interface {
+	Name() string
+	Extensions() []string // Lowercase, including the dot (e.g. ".dwg")
+	Analyze(filePath string) (PluginResult, error)
+}
+
+// pluginRequest is written as JSON to an external plugin's stdin
+type pluginRequest struct {
+	FilePath string `json:"file_path"`
+	FileName string `json:"file_name"`
+	FileSize int64  `json:"file_size"`
+}
+
+// ExternalAnalyzerPlugin runs a user-provided command for each file, speaking JSON over stdio:
+// it receives a pluginRequest on stdin and must print a PluginResult to stdout.
+type ExternalAnalyzerPlugin struct {
+	command    string
+	extensions []string
+}
+
+func NewExternalAnalyzerPlugin(command string, extensions []string) *ExternalAnalyzerPlugin {
+	return &ExternalAnalyzerPlugin{
+		command:    command,
+		extensions: extensions,
+	}
+}
+
+func (p *ExternalAnalyzerPlugin) Name() string {
+	return p.command
+}
+
+func (p *ExternalAnalyzerPlugin) Extensions() []string {
+	return p.extensions
+}
+
+func (p *ExternalAnalyzerPlugin) Analyze(filePath string) (PluginResult, error) {
+	var result PluginResult
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return result, err
+	}
+
+	input, err := json.Marshal(pluginRequest{
+		FilePath: filePath,
+		FileName: filepath.Base(filePath),
+		FileSize: info.Size(),
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to marshal plugin request: %w", err)
+	}
+
+	output, err := runCommand(p.command, input, pluginTimeout)
+	if err != nil {
+		return result, err
+	}
+
+	if err := json.Unmarshal(output, &result); err != nil {
+		return result, fmt.Errorf("plugin returned invalid JSON: %w (output: %s)", err, truncate(string(output), 200))
+	}
+	if result.Error != "" {
+		return result, fmt.Errorf("plugin error: %s", result.Error)
+	}
+	if strings.TrimSpace(result.Description) == "" && strings.TrimSpace(result.Content) == "" {
+		return result, fmt.Errorf("plugin returned neither description nor content")
+	}
+	return result, nil
+}
+
+// ParseAnalyzerPlugins parses the plugin configuration: one plugin per line in the form
+// ".ext1,.ext2: command --args". Blank lines and lines starting with # are ignored.
+func ParseAnalyzerPlugins(spec string) ([]AnalyzerPlugin, error) {
+	var plugins []AnalyzerPlugin
+
+	for i, line := range strings.Split(spec, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		extPart, command, found := strings.Cut(line, ":")
+		// Allow Windows drive letters in the command by requiring the extension list to start with a dot
+		if !found || !strings.HasPrefix(strings.TrimSpace(extPart), ".") {
+			return nil, fmt.Errorf("line %d: expected \".ext1,.ext2: command\"", i+1)
+		}
+
+		var extensions []string
+		for _, ext := range strings.Split(extPart, ",") {
+			ext = strings.ToLower(strings.TrimSpace(ext))
+			if ext == "" {
+				continue
+			}
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			extensions = append(extensions, ext)
+		}
+
+		command = strings.TrimSpace(command)
+		if _, err := splitCommandLine(command); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		plugins = append(plugins, NewExternalAnalyzerPlugin(command, extensions))
+	}
+
+	return plugins, nil
+}
@@ -0,0 +1,185 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// helperCommand is a command line running this test binary as the helper process named mode
+func helperCommand(t *testing.T, mode string) string {
+	t.Setenv("VAF_TEST_HELPER", mode)
+	return `"` + os.Args[0] + `" -test.run=TestHelperProcess --`
+}
+
+// TestHelperProcess isn't a real test: it stands in for plugins and hooks run by helperCommand
+func TestHelperProcess(t *testing.T) {
+	mode := os.Getenv("VAF_TEST_HELPER")
+	if mode == "" {
+		return
+	}
+	switch mode {
+	case "plugin":
+		var request pluginRequest
+		if err := json.NewDecoder(os.Stdin).Decode(&request); err != nil {
+			fmt.Fprintf(os.Stderr, "bad request: %v", err)
+			os.Exit(2)
+		}
+		json.NewEncoder(os.Stdout).Encode(PluginResult{
+			Description: fmt.Sprintf("%s, %d bytes", request.FileName, request.FileSize),
+		})
+	case "plugin-error":
+		json.NewEncoder(os.Stdout).Encode(PluginResult{Error: "unsupported drawing version"})
+	case "plugin-garbage":
+		fmt.Println("not json")
+	case "hook-record":
+		// Keeps each event's payload for the test to inspect
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			os.Exit(2)
+		}
+		var payload HookPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			fmt.Fprintf(os.Stderr, "bad payload: %v", err)
+			os.Exit(2)
+		}
+		if err := os.WriteFile(filepath.Join(os.Getenv("VAF_TEST_HOOK_DIR"), string(payload.Event)+".json"), data, 0644); err != nil {
+			os.Exit(2)
+		}
+	case "hook-refuse":
+		fmt.Fprint(os.Stderr, "backup is still running")
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestParseAnalyzerPlugins(t *testing.T) {
+	tests := []struct {
+		name           string
+		spec           string
+		wantCommands   []string
+		wantExtensions [][]string
+		wantErr        bool
+	}{
+		{
+			name:           "one plugin per line",
+			spec:           ".dwg,.DXF: cad-describe --json\n\n# comment\n.dcm: dicom-info",
+			wantCommands:   []string{"cad-describe --json", "dicom-info"},
+			wantExtensions: [][]string{{".dwg", ".dxf"}, {".dcm"}},
+		},
+		{
+			name:           "extensions without dots",
+			spec:           ".cr2, nef ,: raw-tool",
+			wantCommands:   []string{"raw-tool"},
+			wantExtensions: [][]string{{".cr2", ".nef"}},
+		},
+		{
+			name:           "windows path in the command",
+			spec:           `.dwg: "C:\Program Files\CAD\describe.exe" --json`,
+			wantCommands:   []string{`"C:\Program Files\CAD\describe.exe" --json`},
+			wantExtensions: [][]string{{".dwg"}},
+		},
+		{name: "empty spec", spec: "  \n# nothing\n"},
+		{name: "missing extensions", spec: "cad-describe --json", wantErr: true},
+		{name: "drive letter mistaken for extensions", spec: `C:\tools\describe.exe`, wantErr: true},
+		{name: "empty command", spec: ".dwg:  ", wantErr: true},
+		{name: "unterminated quote", spec: `.dwg: "cad describe`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugins, err := ParseAnalyzerPlugins(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAnalyzerPlugins() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(plugins) != len(tt.wantCommands) {
+				t.Fatalf("got %d plugins, want %d", len(plugins), len(tt.wantCommands))
+			}
+			for i, plugin := range plugins {
+				if plugin.Name() != tt.wantCommands[i] {
+					t.Errorf("plugin %d command = %q, want %q", i, plugin.Name(), tt.wantCommands[i])
+				}
+				if !reflect.DeepEqual(plugin.Extensions(), tt.wantExtensions[i]) {
+					t.Errorf("plugin %d extensions = %v, want %v", i, plugin.Extensions(), tt.wantExtensions[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		cmdline string
+		want    []string
+		wantErr error
+	}{
+		{cmdline: "tool --flag value", want: []string{"tool", "--flag", "value"}},
+		{cmdline: "  tool \t --flag  ", want: []string{"tool", "--flag"}},
+		{cmdline: `"C:\Program Files\tool.exe" --flag`, want: []string{`C:\Program Files\tool.exe`, "--flag"}},
+		{cmdline: `tool 'single quoted' "double quoted"`, want: []string{"tool", "single quoted", "double quoted"}},
+		{cmdline: `tool "it's" 'say "hi"'`, want: []string{"tool", "it's", `say "hi"`}},
+		{cmdline: `tool --name="a b"c`, want: []string{"tool", "--name=a bc"}},
+		{cmdline: `tool ""`, want: []string{"tool", ""}},
+		// Backslashes are kept as they are, so Windows paths need no escaping
+		{cmdline: `C:\tools\run.exe a\ b`, want: []string{`C:\tools\run.exe`, `a\`, "b"}},
+		{cmdline: "", wantErr: ErrEmptyCommand},
+		{cmdline: "   ", wantErr: ErrEmptyCommand},
+		{cmdline: `tool "open`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cmdline, func(t *testing.T) {
+			got, err := splitCommandLine(tt.cmdline)
+			if tt.want == nil {
+				if err == nil || (tt.wantErr != nil && err != tt.wantErr) {
+					t.Fatalf("splitCommandLine() = %q, %v; want error %v", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitCommandLine() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExternalAnalyzerPlugin(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "floor plan.dwg")
+	if err := os.WriteFile(file, []byte("drawing"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		mode            string
+		wantDescription string
+		wantErr         string
+	}{
+		{mode: "plugin", wantDescription: "floor plan.dwg, 7 bytes"},
+		{mode: "plugin-error", wantErr: "unsupported drawing version"},
+		{mode: "plugin-garbage", wantErr: "invalid JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			plugin := NewExternalAnalyzerPlugin(helperCommand(t, tt.mode), []string{".dwg"})
+			result, err := plugin.Analyze(file)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Analyze() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Analyze() error = %v", err)
+			}
+			if result.Description != tt.wantDescription {
+				t.Errorf("description = %q, want %q", result.Description, tt.wantDescription)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Audit log actions
+const (
+	AuditExecute        = "execute"          // Summary of one plan execution
+	AuditMove           = "move"             // A file or folder moved by an execution
+	AuditMoveFailed     = "move_failed"      // An operation of an execution that failed
+	AuditIndexAdd       = "index_add"        // A file was (re)indexed
+	AuditIndexUpdate    = "index_update"     // A file's description was updated
+	AuditIndexMove      = "index_move"       // An entry followed its file to a new path
+	AuditIndexRemove    = "index_remove"     // An entry was removed
+	AuditIndexDeleteDir = "index_delete_dir" // All entries under a folder were deleted
+	AuditPurge          = "purge"            // Stored data about some paths was purged on request
+)
+
+// auditBusyTimeout lets writers on other machines finish when the log lives on a shared folder
+const auditBusyTimeout = 5 * time.Second
+
+// AuditEntry is one row of the audit log
+type AuditEntry struct {
+	ID         int64
+	Time       time.Time
+	Host       string
+	User       string
+	AppVersion string
+	Action     string
+	Path       string
+	Detail     string
+}
+
+// AuditLog records who changed what and when, in an append-only SQLite table. Several machines
+// may share one log, so each entry carries the host, OS user and app version that wrote it.
+type AuditLog struct {
+	db         *sql.DB
+	host       string
+	user       string
+	appVersion string
+	logger     *Logger
+	now        func() time.Time
+}
+
+// OpenAuditLog opens (or creates) the audit log database at path
+func OpenAuditLog(path, appVersion string, logger *Logger) (*AuditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	// One connection, so the busy timeout below applies to every statement
+	db.SetMaxOpenConns(1)
+
+	schema := `
+	PRAGMA busy_timeout = ` + strconv.Itoa(int(auditBusyTimeout/time.Millisecond)) + `;
+
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		recorded_at INTEGER NOT NULL,
+		host TEXT NOT NULL,
+		os_user TEXT NOT NULL,
+		app_version TEXT NOT NULL,
+		action TEXT NOT NULL,
+		path TEXT NOT NULL,
+		detail TEXT
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_audit_recorded_at ON audit_log(recorded_at);
+
+	CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+	BEGIN SELECT RAISE(ABORT, 'audit log is append-only'); END;
+
+	CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+	BEGIN SELECT RAISE(ABORT, 'audit log is append-only'); END;
+	`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create audit log schema: %w", err)
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	userName := "unknown"
+	if current, err := user.Current(); err == nil {
+		userName = current.Username
+	}
+	if appVersion == "" {
+		appVersion = "dev"
+	}
+
+	logger.Info("Audit log opened at %s", path)
+	return &AuditLog{
+		db:         db,
+		host:       host,
+		user:       userName,
+		appVersion: appVersion + " (" + runtime.GOOS + ")",
+		logger:     logger,
+		now:        time.Now,
+	}, nil
+}
+
+// Record appends an entry. Failures are logged rather than returned, so auditing never blocks
+// the change it describes. Safe to call on a nil log.
+func (a *AuditLog) Record(action, path, detail string) {
+	if a == nil {
+		return
+	}
+	_, err := a.db.Exec(`
+		INSERT INTO audit_log (recorded_at, host, os_user, app_version, action, path, detail)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, a.now().UnixMilli(), a.host, a.user, a.appVersion, action, path, detail)
+	if err != nil {
+		a.logger.Error("Failed to write audit log entry (%s %s): %v", action, path, err)
+	}
+}
+
+// RecordExecution appends a summary of an execution and one entry per operation
+func (a *AuditLog) RecordExecution(basePath string, result ExecutionResult) {
+	if a == nil {
+		return
+	}
+	for _, opResult := range result.Operations {
+		if opResult.Success {
+			a.Record(AuditMove, opResult.Operation.To, "from "+opResult.Operation.From)
+		} else {
+			a.Record(AuditMoveFailed, opResult.Operation.From, fmt.Sprintf("to %s: %v", opResult.Operation.To, opResult.Error))
+		}
+	}
+	a.Record(AuditExecute, basePath, fmt.Sprintf("%d succeeded, %d failed", result.SuccessCount, result.FailCount))
+}
+
+// Entries returns the entries recorded at or after since, oldest first
+func (a *AuditLog) Entries(since time.Time) ([]AuditEntry, error) {
+	rows, err := a.db.Query(`
+		SELECT id, recorded_at, host, os_user, app_version, action, path, COALESCE(detail, '')
+		FROM audit_log WHERE recorded_at >= ? ORDER BY id
+	`, since.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var entry AuditEntry
+		var recordedAt int64
+		if err := rows.Scan(&entry.ID, &recordedAt, &entry.Host, &entry.User, &entry.AppVersion, &entry.Action, &entry.Path, &entry.Detail); err != nil {
+			return nil, fmt.Errorf("failed to read audit log: %w", err)
+		}
+		entry.Time = time.UnixMilli(recordedAt)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// CountReferences returns how many entries name a path that matches, either as their path or
+// as the source of a move
+func (a *AuditLog) CountReferences(match func(path string) bool) (int, error) {
+	if a == nil {
+		return 0, nil
+	}
+	rows, err := a.db.Query("SELECT path, COALESCE(detail, '') FROM audit_log")
+	if err != nil {
+		return 0, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var path, detail string
+		if err := rows.Scan(&path, &detail); err != nil {
+			return count, fmt.Errorf("failed to read audit log: %w", err)
+		}
+		if (path != "" && match(path)) || (strings.HasPrefix(detail, "from ") && match(strings.TrimPrefix(detail, "from "))) {
+			count++
+		}
+	}
+	return count, rows.Err()
+}
+
+// ExportCSV writes the whole log as CSV, one row per entry with UTC timestamps
+func (a *AuditLog) ExportCSV(w io.Writer) error {
+	entries, err := a.Entries(time.Time{})
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"id", "time", "host", "user", "app_version", "action", "path", "detail"})
+	for _, entry := range entries {
+		writer.Write([]string{
+			strconv.FormatInt(entry.ID, 10),
+			entry.Time.UTC().Format(time.RFC3339),
+			entry.Host,
+			entry.User,
+			entry.AppVersion,
+			entry.Action,
+			entry.Path,
+			entry.Detail,
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Close closes the audit log database
+func (a *AuditLog) Close() error {
+	if a == nil {
+		return nil
+	}
+	return a.db.Close()
+}
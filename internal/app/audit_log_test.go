@@ -0,0 +1,82 @@
+package app
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAuditLog(t *testing.T) {
+	dir := t.TempDir()
+	audit, err := OpenAuditLog(filepath.Join(dir, "audit.db"), "1.2.3", NewLogger(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer audit.Close()
+	audit.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	audit.RecordExecution("/share", ExecutionResult{
+		SuccessCount: 1,
+		FailCount:    1,
+		Operations: []OperationResult{
+			{Operation: FileOperation{From: "/share/a.txt", To: "/share/docs/a.txt"}, Success: true},
+			{Operation: FileOperation{From: "/share/b.txt", To: "/share/docs/b.txt"}, Error: errors.New("denied")},
+		},
+	})
+
+	indexService := NewIndexService(NewLogger(false))
+	if err := indexService.Initialize(filepath.Join(dir, "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer indexService.Close()
+	indexService.SetAuditLog(audit)
+	if err := indexService.IndexFile("/share/docs/a.txt", "notes", "text", 1, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := indexService.RemoveFile("/share/docs/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := audit.Entries(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantActions := []string{AuditMove, AuditMoveFailed, AuditExecute, AuditIndexAdd, AuditIndexRemove}
+	if len(entries) != len(wantActions) {
+		t.Fatalf("got %d entries, want %d: %+v", len(entries), len(wantActions), entries)
+	}
+	for i, want := range wantActions {
+		if entries[i].Action != want {
+			t.Errorf("entry %d action = %q, want %q", i, entries[i].Action, want)
+		}
+		if entries[i].Host == "" || entries[i].User == "" || entries[i].AppVersion == "" {
+			t.Errorf("entry %d lacks attribution: %+v", i, entries[i])
+		}
+	}
+
+	// Entries can't be rewritten or removed
+	if _, err := audit.db.Exec("UPDATE audit_log SET os_user = 'someone else'"); err == nil {
+		t.Error("updating the audit log should fail")
+	}
+	if _, err := audit.db.Exec("DELETE FROM audit_log"); err == nil {
+		t.Error("deleting from the audit log should fail")
+	}
+
+	var buf bytes.Buffer
+	if err := audit.ExportCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(wantActions)+1 {
+		t.Fatalf("CSV has %d rows, want %d", len(rows), len(wantActions)+1)
+	}
+	if got := rows[1]; got[1] != "2024-05-01T12:00:00Z" || got[5] != AuditMove || got[6] != "/share/docs/a.txt" || got[7] != "from /share/a.txt" {
+		t.Errorf("first CSV row = %v", got)
+	}
+}
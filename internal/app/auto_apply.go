@@ -0,0 +1,190 @@
+package app
+
+import (
+	"fmt"
+	"time"
+)
+
+// autoApplyInstruction asks the model to rate each operation so automated runs can be gated on it
+const autoApplyInstruction = `
+
+Add a "confidence" field to every line: a number from 0 to 1 saying how sure you are the move is what the user wants, e.g. {"from": "a.pdf", "to": "docs/a.pdf", "confidence": 0.95}.`
+
+// AutomatedJob is an organization run started without the user at the screen, e.g. by a
+// folder watcher or a schedule
+type AutomatedJob struct {
+	Name       string // Identifies the job in logs and the review queue
+	Request    AnalysisRequest
+	CleanEmpty bool
+}
+
+// PendingPlan is a plan from an automated job that was held back for manual review
+type PendingPlan struct {
+	ID         string          `json:"id"` // Assigned when the plan is queued
+	JobName    string          `json:"job_name"`
+	BasePath   string          `json:"base_path"`
+	Operations []FileOperation `json:"operations"`
+	Reason     string          `json:"reason"`              // Why it wasn't applied automatically
+	Conflicts  []PlanConflict  `json:"conflicts,omitempty"` // Operations dropped because other plans ran first
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// ReviewQueue receives plans that need the user's approval before they are executed
+type ReviewQueue interface {
+	Enqueue(plan PendingPlan) error
+}
+
+// AutoApplyDecision is the verdict on whether a plan may run unattended
+type AutoApplyDecision struct {
+	Apply  bool
+	Reason string
+}
+
+// AutoApplyDecision decides whether operations may be executed without review. Every operation
+// must meet the confidence threshold (operations without a confidence never do) and the plan
+// must not exceed the operation limit.
+func (c *Config) AutoApplyDecision(operations []FileOperation) AutoApplyDecision {
+	if !c.AutoApply {
+		return AutoApplyDecision{Reason: "auto-apply is off"}
+	}
+	if c.AutoApplyMaxOperations > 0 && len(operations) > c.AutoApplyMaxOperations {
+		return AutoApplyDecision{Reason: fmt.Sprintf("%d operations exceed the auto-apply limit of %d", len(operations), c.AutoApplyMaxOperations)}
+	}
+
+	uncertain, flagged := 0, 0
+	for _, op := range operations {
+		if op.Confidence < c.AutoApplyMinConfidence {
+			uncertain++
+		}
+		if op.Flag != "" {
+			flagged++
+		}
+	}
+	if flagged > 0 {
+		return AutoApplyDecision{Reason: fmt.Sprintf("the plan review flagged %d of %d operations", flagged, len(operations))}
+	}
+	if uncertain > 0 {
+		return AutoApplyDecision{Reason: fmt.Sprintf("%d of %d operations are below the %.0f%% confidence threshold", uncertain, len(operations), c.AutoApplyMinConfidence*100)}
+	}
+	return AutoApplyDecision{Apply: true}
+}
+
+// AutoApplyOutcome reports what an automated job did with its plan
+type AutoApplyOutcome struct {
+	Operations []FileOperation
+	Decision   AutoApplyDecision
+	Queued     bool
+	Execution  *ExecutionResult // Set when the plan was applied
+}
+
+// AutoApplier runs automated jobs, applying confident plans right away and queueing the rest
+// for review. Thresholds are read from the config on every run.
+type AutoApplier struct {
+	orchestrator *Orchestrator
+	config       *Config
+	logger       *Logger
+	queue        ReviewQueue
+	notifier     *Notifier
+}
+
+func NewAutoApplier(orchestrator *Orchestrator, config *Config, logger *Logger) *AutoApplier {
+	return &AutoApplier{
+		orchestrator: orchestrator,
+		config:       config,
+		logger:       logger,
+	}
+}
+
+// SetReviewQueue sets where plans go that can't be applied automatically
+func (a *AutoApplier) SetReviewQueue(queue ReviewQueue) {
+	a.queue = queue
+}
+
+// SetNotifier sets where the summary of every run is sent
+func (a *AutoApplier) SetNotifier(notifier *Notifier) {
+	a.notifier = notifier
+}
+
+// Run analyzes the job's directory and either executes the plan or queues it for review, then
+// sends the run's summary to the notifier
+func (a *AutoApplier) Run(job AutomatedJob) (*AutoApplyOutcome, error) {
+	startedAt := time.Now()
+	outcome, err := a.run(job)
+	if a.notifier != nil {
+		a.notifier.Notify(NewRunSummary(job, outcome, err, startedAt))
+	}
+	return outcome, err
+}
+
+func (a *AutoApplier) run(job AutomatedJob) (*AutoApplyOutcome, error) {
+	req := job.Request
+	req.ExplainMoves = req.ExplainMoves || a.config.ExplainMoves
+	req.SelfCritique = req.SelfCritique || a.config.SelfCritique
+	req.SplitCompanions = req.SplitCompanions || a.config.SplitCompanions
+	if req.Constraints == "" {
+		req.Constraints = a.config.ConstraintsFor(req.DirectoryPath)
+	}
+	if req.Pipeline == "" {
+		req.Pipeline = a.config.PipelineFor(req.DirectoryPath)
+	}
+	if a.config.AutoApply {
+		req.UserPrompt += autoApplyInstruction
+	}
+
+	analysis := a.orchestrator.AnalyzeDirectory(req, nil)
+	if analysis.Error != nil {
+		return nil, fmt.Errorf("automated job %q failed: %w", job.Name, analysis.Error)
+	}
+
+	outcome := &AutoApplyOutcome{Operations: analysis.Operations}
+	if len(analysis.Operations) == 0 {
+		a.logger.Info("Automated job %q: nothing to organize", job.Name)
+		return outcome, nil
+	}
+
+	outcome.Decision = a.config.AutoApplyDecision(analysis.Operations)
+	if _, quarantined := Quarantine(req.DirectoryPath, analysis.Operations); outcome.Decision.Apply && len(quarantined) > 0 {
+		// Nobody is there to confirm risky operations one by one
+		outcome.Decision = AutoApplyDecision{Reason: fmt.Sprintf("%d of %d operations are quarantined (%s)", len(quarantined), len(analysis.Operations), quarantined[0].Reasons[0])}
+	}
+	if outcome.Decision.Apply {
+		a.logger.Info("Automated job %q: applying %d operations", job.Name, len(analysis.Operations))
+		throttleDelay, throttleBatch := a.config.ThrottleFor(req.DirectoryPath)
+		result := a.orchestrator.ExecuteOrganization(ExecutionRequest{
+			Operations:  analysis.Operations,
+			BasePath:    req.DirectoryPath,
+			CleanEmpty:  job.CleanEmpty,
+			Parallelism: a.config.ParallelMoves,
+			PlannedAt:   analysis.PlannedAt,
+			Staged:      a.config.StagedExecution,
+
+			// Nobody is there to close programs, so files in use are skipped
+			CheckOpenFiles: a.config.CheckOpenFiles,
+
+			AuditPermissions: a.config.AuditPermissions,
+			RewriteShortcuts: a.config.RewriteShortcuts,
+			UpdateReferences: a.config.UpdateReferences,
+
+			ThrottleDelay: throttleDelay,
+			ThrottleBatch: throttleBatch,
+		})
+		outcome.Execution = &result
+		return outcome, nil
+	}
+
+	a.logger.Info("Automated job %q: holding %d operations for review (%s)", job.Name, len(analysis.Operations), outcome.Decision.Reason)
+	if a.queue == nil {
+		return outcome, nil
+	}
+	if err := a.queue.Enqueue(PendingPlan{
+		JobName:    job.Name,
+		BasePath:   req.DirectoryPath,
+		Operations: analysis.Operations,
+		Reason:     outcome.Decision.Reason,
+		CreatedAt:  time.Now(),
+	}); err != nil {
+		return outcome, fmt.Errorf("failed to queue plan for review: %w", err)
+	}
+	outcome.Queued = true
+	return outcome, nil
+}
@@ -0,0 +1,194 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAutoApplyDecision(t *testing.T) {
+	confident := []FileOperation{
+		{From: "a.pdf", To: "docs/a.pdf", Confidence: 0.95},
+		{From: "b.jpg", To: "photos/b.jpg", Confidence: 0.9},
+	}
+
+	tests := []struct {
+		name       string
+		config     Config
+		operations []FileOperation
+		wantApply  bool
+		wantReason string
+	}{
+		{
+			name:       "off",
+			config:     Config{AutoApplyMinConfidence: 0.9, AutoApplyMaxOperations: 20},
+			operations: confident,
+			wantReason: "auto-apply is off",
+		},
+		{
+			name:       "confident and small",
+			config:     Config{AutoApply: true, AutoApplyMinConfidence: 0.9, AutoApplyMaxOperations: 20},
+			operations: confident,
+			wantApply:  true,
+		},
+		{
+			name:       "too many operations",
+			config:     Config{AutoApply: true, AutoApplyMinConfidence: 0.9, AutoApplyMaxOperations: 1},
+			operations: confident,
+			wantReason: "2 operations exceed the auto-apply limit of 1",
+		},
+		{
+			name:       "one uncertain operation",
+			config:     Config{AutoApply: true, AutoApplyMinConfidence: 0.9, AutoApplyMaxOperations: 20},
+			operations: append([]FileOperation{{From: "c.txt", To: "misc/c.txt", Confidence: 0.5}}, confident...),
+			wantReason: "1 of 3 operations are below the 90% confidence threshold",
+		},
+		{
+			name:       "confidence not reported",
+			config:     Config{AutoApply: true, AutoApplyMinConfidence: 0.9, AutoApplyMaxOperations: 20},
+			operations: []FileOperation{{From: "a.pdf", To: "docs/a.pdf"}},
+			wantReason: "1 of 1 operations are below the 90% confidence threshold",
+		},
+		{
+			name:       "flagged by plan review",
+			config:     Config{AutoApply: true, AutoApplyMinConfidence: 0.9, AutoApplyMaxOperations: 20},
+			operations: append([]FileOperation{{From: "c.txt", To: "misc/c.txt", Confidence: 0.99, Flag: "unclear"}}, confident...),
+			wantReason: "the plan review flagged 1 of 3 operations",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.config.AutoApplyDecision(tt.operations)
+			if got.Apply != tt.wantApply || got.Reason != tt.wantReason {
+				t.Errorf("AutoApplyDecision() = %+v, want apply=%v reason=%q", got, tt.wantApply, tt.wantReason)
+			}
+		})
+	}
+}
+
+// stubAIService streams a fixed plan and remembers the prompt it was given
+type stubAIService struct {
+	operations []FileOperation
+	lastPrompt string
+}
+
+func (s *stubAIService) GetSuggestions(ctx context.Context, structure, userPrompt, basePath string, onOperation OperationCallback) ([]FileOperation, error) {
+	s.lastPrompt = userPrompt
+	ops := make([]FileOperation, len(s.operations))
+	for i, op := range s.operations {
+		ops[i] = FileOperation{From: filepath.Join(basePath, op.From), To: filepath.Join(basePath, op.To), Confidence: op.Confidence}
+		if onOperation != nil {
+			onOperation(ops[i])
+		}
+	}
+	return ops, nil
+}
+
+type recordingReviewQueue struct {
+	plans []PendingPlan
+}
+
+func (q *recordingReviewQueue) Enqueue(plan PendingPlan) error {
+	q.plans = append(q.plans, plan)
+	return nil
+}
+
+func TestAutoApplierRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		confidence float64
+		to         string // Defaults to finance/invoice.pdf
+		wantMoved  bool
+		wantQueued bool
+		wantStatus string
+	}{
+		{name: "confident plan is applied", confidence: 0.97, wantMoved: true, wantStatus: RunStatusApplied},
+		{name: "uncertain plan is queued", confidence: 0.6, wantQueued: true, wantStatus: RunStatusQueued},
+		{name: "plan leaving the folder is queued", confidence: 0.97, to: "../elsewhere/invoice.pdf", wantQueued: true, wantStatus: RunStatusQueued},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "invoice.pdf"), []byte("pdf"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var summaries []RunSummary
+			webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var summary RunSummary
+				if err := json.NewDecoder(r.Body).Decode(&summary); err != nil {
+					t.Errorf("webhook body: %v", err)
+				}
+				summaries = append(summaries, summary)
+			}))
+			defer webhook.Close()
+
+			config := &Config{AutoApply: true, AutoApplyMinConfidence: 0.9, AutoApplyMaxOperations: 20, ParallelMoves: 1, NotifyWebhookURL: webhook.URL}
+			logger := NewLogger(false)
+			validator := NewValidator()
+			to := tt.to
+			if to == "" {
+				to = "finance/invoice.pdf"
+			}
+			ai := &stubAIService{operations: []FileOperation{{From: "invoice.pdf", To: to, Confidence: tt.confidence}}}
+			orchestrator := NewOrchestrator(ai, NewFileService(validator, logger), validator, logger, nil, nil, NewHookRunner(config, logger))
+
+			queue := &recordingReviewQueue{}
+			applier := NewAutoApplier(orchestrator, config, logger)
+			applier.SetReviewQueue(queue)
+			applier.SetNotifier(NewNotifier(config, logger))
+
+			outcome, err := applier.Run(AutomatedJob{Name: "Downloads", Request: AnalysisRequest{DirectoryPath: dir, UserPrompt: "Sort by type"}})
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if !strings.Contains(ai.lastPrompt, `"confidence"`) {
+				t.Errorf("prompt does not ask for confidence: %q", ai.lastPrompt)
+			}
+
+			_, statErr := os.Stat(filepath.Join(dir, "finance", "invoice.pdf"))
+			if moved := statErr == nil; moved != tt.wantMoved {
+				t.Errorf("file moved = %v, want %v", moved, tt.wantMoved)
+			}
+			if outcome.Queued != tt.wantQueued {
+				t.Errorf("queued = %v, want %v", outcome.Queued, tt.wantQueued)
+			}
+			if tt.wantQueued && (len(queue.plans) != 1 || queue.plans[0].JobName != "Downloads") {
+				t.Errorf("expected one queued plan from the Downloads job, got %+v", queue.plans)
+			}
+			if len(summaries) != 1 || summaries[0].Status != tt.wantStatus || summaries[0].Job != "Downloads" || summaries[0].Planned != 1 {
+				t.Errorf("run summaries = %+v, want one with status %s", summaries, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAutoApplierRunKeepsOtherRunsBudget(t *testing.T) {
+	config := &Config{RunBudget: 1, PriceInputPerMillion: 1, ParallelMoves: 1}
+	logger := NewLogger(false)
+	budget := NewBudgetGuard(config, "", logger)
+	// A manual run the user refused to let go over its ceiling
+	manual := budget.BeginRun()
+	if err := budget.Authorize(manual, 2_000_000, 0); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("Authorize() = %v, want ErrBudgetExceeded", err)
+	}
+
+	validator := NewValidator()
+	orchestrator := NewOrchestrator(&stubAIService{}, NewFileService(validator, logger), validator, logger, nil, nil, NewHookRunner(config, logger))
+	orchestrator.SetBudget(budget)
+	applier := NewAutoApplier(orchestrator, config, logger)
+	if _, err := applier.Run(AutomatedJob{Name: "Downloads", Request: AnalysisRequest{DirectoryPath: t.TempDir(), UserPrompt: "Sort by type"}}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if err := budget.Authorize(manual, 1000, 0); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Authorize() for the manual run after a scheduled run = %v, want ErrBudgetExceeded", err)
+	}
+}
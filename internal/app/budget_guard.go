@@ -0,0 +1,247 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrBudgetExceeded = errors.New("spending budget exceeded")
+
+// BudgetScope names the ceiling that would be exceeded
+type BudgetScope string
+
+const (
+	BudgetScopeRun   BudgetScope = "run"
+	BudgetScopeMonth BudgetScope = "month"
+)
+
+// BudgetConfirmHandler is asked whether to continue once a request would push the estimated
+// spend past a ceiling. It blocks the requesting goroutine until the user answers.
+type BudgetConfirmHandler func(scope BudgetScope, spent, limit float64) bool
+
+// monthlyUsage is persisted so the monthly ceiling survives restarts
+type monthlyUsage struct {
+	Month        string  `json:"month"` // "2006-01"
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+}
+
+// BudgetRun is the spend of one analysis or indexing run, checked against the per-run ceiling.
+// Runs are independent, so a scheduled job starting mid-run leaves a manual run's spend and
+// answers alone. Requests join a run through their context; see WithBudgetRun.
+type BudgetRun struct {
+	// Guarded by the BudgetGuard's mu
+	cost float64
+
+	// Approval covers the rest of the run; a refusal stops further requests in the run
+	approved bool
+	denied   bool
+}
+
+type budgetRunKey struct{}
+
+// WithBudgetRun returns a context whose LLM requests count toward run
+func WithBudgetRun(ctx context.Context, run *BudgetRun) context.Context {
+	return context.WithValue(ctx, budgetRunKey{}, run)
+}
+
+// budgetRunFrom returns the run ctx belongs to, or nil for requests outside a run
+func budgetRunFrom(ctx context.Context) *BudgetRun {
+	run, _ := ctx.Value(budgetRunKey{}).(*BudgetRun)
+	return run
+}
+
+// BudgetGuard tracks LLM token usage, prices it with the configured rates and pauses
+// requests for confirmation when the per-run or per-month ceiling would be exceeded.
+// The guard is inactive while no prices are configured.
+type BudgetGuard struct {
+	config    *Config
+	logger    *Logger
+	usagePath string
+
+	mu      sync.Mutex
+	usage   monthlyUsage
+	confirm BudgetConfirmHandler
+
+	// Approval covers the rest of the month
+	monthApproved string
+
+	// promptMu serializes confirmation prompts so concurrent requests ask only once
+	promptMu sync.Mutex
+
+	now func() time.Time
+}
+
+// NewBudgetGuard creates a guard that persists monthly usage to usagePath (empty disables persistence)
+func NewBudgetGuard(config *Config, usagePath string, logger *Logger) *BudgetGuard {
+	bg := &BudgetGuard{
+		config:    config,
+		logger:    logger,
+		usagePath: usagePath,
+		now:       time.Now,
+	}
+	bg.loadUsage()
+	return bg
+}
+
+// SetConfirmHandler sets the callback used to ask whether to continue past a ceiling
+func (bg *BudgetGuard) SetConfirmHandler(handler BudgetConfirmHandler) {
+	if bg == nil {
+		return
+	}
+	bg.mu.Lock()
+	defer bg.mu.Unlock()
+	bg.confirm = handler
+}
+
+// BeginRun starts a run with no spend and no approval or refusal yet
+func (bg *BudgetGuard) BeginRun() *BudgetRun {
+	return &BudgetRun{}
+}
+
+// Spend returns the estimated spend of the current month
+func (bg *BudgetGuard) Spend() float64 {
+	if bg == nil {
+		return 0
+	}
+	bg.mu.Lock()
+	defer bg.mu.Unlock()
+	bg.rollMonth()
+	return bg.usage.Cost
+}
+
+func (bg *BudgetGuard) enabled() bool {
+	return bg.config.PriceInputPerMillion > 0 || bg.config.PriceOutputPerMillion > 0
+}
+
+func (bg *BudgetGuard) cost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*bg.config.PriceInputPerMillion +
+		float64(outputTokens)*bg.config.PriceOutputPerMillion) / 1_000_000
+}
+
+// exceeded returns the first ceiling that a request of run costing estimate would cross. Requests
+// outside a run (nil) only count toward the month. Caller must hold bg.mu.
+func (bg *BudgetGuard) exceeded(run *BudgetRun, estimate float64) (BudgetScope, float64, float64, bool) {
+	if run != nil && bg.config.RunBudget > 0 && !run.approved && run.cost+estimate > bg.config.RunBudget {
+		return BudgetScopeRun, run.cost, bg.config.RunBudget, true
+	}
+	if bg.config.MonthlyBudget > 0 && bg.monthApproved != bg.usage.Month && bg.usage.Cost+estimate > bg.config.MonthlyBudget {
+		return BudgetScopeMonth, bg.usage.Cost, bg.config.MonthlyBudget, true
+	}
+	return "", 0, 0, false
+}
+
+// Authorize checks a request's estimated token usage against the ceilings, asking for confirmation
+// when one would be exceeded. It returns ErrBudgetExceeded if the request must not be sent.
+// run is the run the request belongs to, or nil.
+func (bg *BudgetGuard) Authorize(run *BudgetRun, inputTokens, maxOutputTokens int) error {
+	if bg == nil || !bg.enabled() {
+		return nil
+	}
+
+	bg.promptMu.Lock()
+	defer bg.promptMu.Unlock()
+
+	for {
+		bg.mu.Lock()
+		bg.rollMonth()
+		if run != nil && run.denied {
+			bg.mu.Unlock()
+			return ErrBudgetExceeded
+		}
+		scope, spent, limit, over := bg.exceeded(run, bg.cost(inputTokens, maxOutputTokens))
+		confirm := bg.confirm
+		bg.mu.Unlock()
+
+		if !over {
+			return nil
+		}
+
+		bg.logger.Info("Estimated %s spend $%.4f would exceed the $%.2f budget, asking for confirmation", scope, spent, limit)
+		approved := confirm != nil && confirm(scope, spent, limit)
+
+		bg.mu.Lock()
+		if !approved {
+			if run != nil {
+				run.denied = true
+			}
+			bg.mu.Unlock()
+			bg.logger.Info("Budget ceiling not raised, stopping LLM requests for this run")
+			return fmt.Errorf("%w: %s spend $%.4f of $%.2f", ErrBudgetExceeded, scope, spent, limit)
+		}
+		if scope == BudgetScopeRun {
+			run.approved = true
+		} else {
+			bg.monthApproved = bg.usage.Month
+		}
+		bg.mu.Unlock()
+		// Re-check: approving the run ceiling may still leave the monthly one to confirm
+	}
+}
+
+// Record adds the tokens used by a completed request to the totals of run (nil for none) and the month
+func (bg *BudgetGuard) Record(run *BudgetRun, inputTokens, outputTokens int) {
+	if bg == nil {
+		return
+	}
+
+	bg.mu.Lock()
+	bg.rollMonth()
+	cost := bg.cost(inputTokens, outputTokens)
+	if run != nil {
+		run.cost += cost
+	}
+	bg.usage.InputTokens += inputTokens
+	bg.usage.OutputTokens += outputTokens
+	bg.usage.Cost += cost
+	usage := bg.usage
+	bg.mu.Unlock()
+
+	bg.logger.Debug("LLM usage: %d input, %d output tokens (~$%.4f)", inputTokens, outputTokens, cost)
+	bg.saveUsage(usage)
+}
+
+// rollMonth starts a fresh monthly total when the calendar month changes. Caller must hold bg.mu.
+func (bg *BudgetGuard) rollMonth() {
+	month := bg.now().Format("2006-01")
+	if bg.usage.Month != month {
+		bg.usage = monthlyUsage{Month: month}
+	}
+}
+
+func (bg *BudgetGuard) loadUsage() {
+	if bg.usagePath == "" {
+		return
+	}
+	data, err := os.ReadFile(bg.usagePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			bg.logger.Error("Failed to read usage file: %v", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &bg.usage); err != nil {
+		bg.logger.Error("Failed to parse usage file: %v", err)
+		bg.usage = monthlyUsage{}
+	}
+}
+
+func (bg *BudgetGuard) saveUsage(usage monthlyUsage) {
+	if bg.usagePath == "" {
+		return
+	}
+	data, err := json.MarshalIndent(usage, "", "  ")
+	if err != nil {
+		bg.logger.Error("Failed to marshal usage: %v", err)
+		return
+	}
+	if err := os.WriteFile(bg.usagePath, data, 0644); err != nil {
+		bg.logger.Error("Failed to write usage file: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestBudgetGuard_AsksOncePerRunAndPersistsMonthlyUsage(t *testing.T) {
+	config := &Config{
+		PriceInputPerMillion:  1,
+		PriceOutputPerMillion: 1,
+		RunBudget:             1,
+	}
+	usagePath := filepath.Join(t.TempDir(), "usage.json")
+	bg := NewBudgetGuard(config, usagePath, NewLogger(false))
+
+	prompts := 0
+	answer := true
+	bg.SetConfirmHandler(func(scope BudgetScope, spent, limit float64) bool {
+		prompts++
+		if scope != BudgetScopeRun {
+			t.Errorf("expected run scope, got %s", scope)
+		}
+		return answer
+	})
+
+	run := bg.BeginRun()
+	if err := bg.Authorize(run, 500_000, 0); err != nil {
+		t.Fatalf("expected request within budget, got %v", err)
+	}
+	bg.Record(run, 500_000, 400_000) // $0.90
+
+	// $0.90 + $0.20 crosses the $1 run budget: approved once, then not asked again this run
+	if err := bg.Authorize(run, 200_000, 0); err != nil {
+		t.Fatalf("expected approval, got %v", err)
+	}
+	if err := bg.Authorize(run, 200_000, 0); err != nil {
+		t.Fatalf("expected approval to cover the run, got %v", err)
+	}
+	if prompts != 1 {
+		t.Errorf("expected 1 prompt, got %d", prompts)
+	}
+
+	// A new run starts from zero; a refusal stops the rest of the run without prompting again
+	run = bg.BeginRun()
+	bg.Record(run, 900_000, 0)
+	answer = false
+	if err := bg.Authorize(run, 200_000, 0); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+	}
+	if err := bg.Authorize(run, 1, 0); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected refusal to persist for the run, got %v", err)
+	}
+	if prompts != 2 {
+		t.Errorf("expected 2 prompts, got %d", prompts)
+	}
+
+	// Monthly usage survives a restart
+	month := NewBudgetGuard(config, usagePath, NewLogger(false)).Spend()
+	if month < 1.79 || month > 1.81 {
+		t.Errorf("expected persisted monthly spend of $1.80, got $%.4f", month)
+	}
+}
+
+func TestBudgetGuard_RunsAreIndependent(t *testing.T) {
+	config := &Config{PriceInputPerMillion: 1, RunBudget: 1}
+	bg := NewBudgetGuard(config, "", NewLogger(false))
+	prompts := 0
+	bg.SetConfirmHandler(func(scope BudgetScope, spent, limit float64) bool {
+		prompts++
+		return false
+	})
+
+	manual := bg.BeginRun()
+	bg.Record(manual, 900_000, 0)
+	if err := bg.Authorize(manual, 200_000, 0); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+	}
+
+	// A scheduled run starting mid-run has its own ceiling and leaves the refusal in place
+	scheduled := bg.BeginRun()
+	if err := bg.Authorize(scheduled, 200_000, 0); err != nil {
+		t.Fatalf("expected the new run within budget, got %v", err)
+	}
+	bg.Record(scheduled, 200_000, 0)
+	if err := bg.Authorize(manual, 1, 0); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected the refusal to hold for the manual run, got %v", err)
+	}
+	// Requests outside a run only count toward the month
+	if err := bg.Authorize(nil, 2_000_000, 0); err != nil {
+		t.Errorf("expected no run ceiling outside a run, got %v", err)
+	}
+	if prompts != 1 {
+		t.Errorf("expected 1 prompt, got %d", prompts)
+	}
+}
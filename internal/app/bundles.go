@@ -0,0 +1,67 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// opaqueBundles is whether folders with bundle extensions are kept whole. Only macOS has bundles;
+// elsewhere a folder named *.app or *.key is an ordinary folder.
+var opaqueBundles = runtime.GOOS == "darwin"
+
+// maxBundleSizeEntries bounds how much of a bundle is read to size it, so libraries holding
+// hundreds of thousands of files don't slow down every scan
+const maxBundleSizeEntries = 1000
+
+// bundleExtensions are folder extensions macOS presents as single documents or applications.
+// Their internals must stay exactly as they are, so on macOS they're treated as opaque files everywhere.
+var bundleExtensions = map[string]bool{
+	".app": true, ".appex": true, ".bundle": true, ".framework": true, ".plugin": true,
+	".kext": true, ".prefpane": true, ".qlgenerator": true, ".mdimporter": true, ".saver": true,
+	".photoslibrary": true, ".musiclibrary": true, ".tvlibrary": true, ".imovielibrary": true,
+	".fcpbundle": true, ".logicx": true, ".band": true, ".lrlibrary": true, ".lrdata": true,
+	".pages": true, ".numbers": true, ".key": true, ".rtfd": true, ".scriv": true,
+	".xcodeproj": true, ".xcworkspace": true, ".playground": true, ".dsym": true,
+	".pkg": true, ".mpkg": true, ".sparsebundle": true, ".wdgt": true,
+}
+
+// isBundleDir reports whether info describes a folder that is an application bundle or document package
+func isBundleDir(info os.FileInfo) bool {
+	return opaqueBundles && info.IsDir() && bundleExtensions[strings.ToLower(filepath.Ext(info.Name()))]
+}
+
+// containingBundle returns the outermost bundle path lies inside of, judged by the names of its parent folders
+func containingBundle(path string) (string, bool) {
+	if !opaqueBundles {
+		return "", false
+	}
+	parts := strings.Split(filepath.ToSlash(filepath.Clean(path)), "/")
+	for i, part := range parts[:len(parts)-1] {
+		if bundleExtensions[strings.ToLower(filepath.Ext(part))] {
+			return filepath.FromSlash(strings.Join(parts[:i+1], "/")), true
+		}
+	}
+	return "", false
+}
+
+// bundleSize adds up the sizes of the files inside a bundle. Only the first maxBundleSizeEntries
+// entries are read, so the size of a very large bundle is a lower bound.
+func bundleSize(path string) int64 {
+	var size int64
+	entries := 0
+	filepath.WalkDir(path, func(_ string, d os.DirEntry, err error) error {
+		if entries++; entries > maxBundleSizeEntries {
+			return filepath.SkipAll
+		}
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
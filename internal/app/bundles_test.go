@@ -0,0 +1,132 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useOpaqueBundles sets whether bundles are kept whole for the duration of the test
+func useOpaqueBundles(t *testing.T, on bool) {
+	previous := opaqueBundles
+	opaqueBundles = on
+	t.Cleanup(func() { opaqueBundles = previous })
+}
+
+func TestBundlesAreOpaque(t *testing.T) {
+	useOpaqueBundles(t, true)
+	dir := t.TempDir()
+	files := map[string]string{
+		"Editor.app/Contents/Info.plist":   "plist",
+		"Editor.app/Contents/MacOS/editor": "binary",
+		"Trip.photoslibrary/database/a.db": "db",
+		"notes/todo.txt":                   "todo",
+	}
+	for rel, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Bundles keep empty folders of their own
+	if err := os.MkdirAll(filepath.Join(dir, "Editor.app", "Contents", "Resources"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := NewFileService(NewValidator(), NewLogger(false))
+
+	structure, err := fs.GetDirectoryStructure(dir, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Editor.app (11 bytes)\n", "Trip.photoslibrary (2 bytes)\n", "notes/todo.txt (4 bytes)\n"} {
+		if !strings.Contains(structure, want) {
+			t.Errorf("structure missing %q:\n%s", want, structure)
+		}
+	}
+	if strings.Contains(structure, "Contents") {
+		t.Errorf("structure lists bundle internals:\n%s", structure)
+	}
+
+	if count, err := fs.CountFiles(dir); err != nil || count != 3 {
+		t.Errorf("CountFiles() = %d, %v; want 3", count, err)
+	}
+
+	if _, err := fs.CleanEmptyDirectories(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Editor.app", "Contents", "Resources")); err != nil {
+		t.Errorf("empty folder inside bundle was removed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		op      FileOperation
+		wantErr error
+	}{
+		{
+			name: "moving a whole bundle",
+			op:   FileOperation{From: filepath.Join(dir, "Editor.app"), To: filepath.Join(dir, "Apps", "Editor.app")},
+		},
+		{
+			name:    "moving out of a bundle",
+			op:      FileOperation{From: filepath.Join(dir, "Editor.app", "Contents", "Info.plist"), To: filepath.Join(dir, "Info.plist")},
+			wantErr: ErrInsideBundle,
+		},
+		{
+			name:    "moving into a bundle",
+			op:      FileOperation{From: filepath.Join(dir, "notes", "todo.txt"), To: filepath.Join(dir, "Trip.photoslibrary", "todo.txt")},
+			wantErr: ErrInsideBundle,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := fs.validator.ValidateFileOperation(tt.op); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateFileOperation() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBundlesAreOrdinaryFoldersOutsideMacOS(t *testing.T) {
+	useOpaqueBundles(t, false)
+	dir := t.TempDir()
+	plist := filepath.Join(dir, "Slides.key", "Contents", "Info.plist")
+	if err := os.MkdirAll(filepath.Dir(plist), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(plist, []byte("plist"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := NewFileService(NewValidator(), NewLogger(false))
+	structure, err := fs.GetDirectoryStructure(dir, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(structure, "Slides.key/Contents/Info.plist (5 bytes)") {
+		t.Errorf("structure doesn't list the folder's files:\n%s", structure)
+	}
+	op := FileOperation{From: plist, To: filepath.Join(dir, "Info.plist")}
+	if err := fs.validator.ValidateFileOperation(op); err != nil {
+		t.Errorf("ValidateFileOperation() = %v, want nil", err)
+	}
+}
+
+func TestBundleSizeStopsEarly(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < maxBundleSizeEntries+10; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.dat", i)), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if size := bundleSize(dir); size >= maxBundleSizeEntries {
+		t.Errorf("bundleSize() = %d, want fewer than %d bytes counted", size, maxBundleSizeEntries)
+	}
+}
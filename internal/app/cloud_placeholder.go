@@ -0,0 +1,58 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// ErrCloudPlaceholder is returned when a file's content lives only in the cloud and downloading it is turned off
+var ErrCloudPlaceholder = errors.New("file is online-only")
+
+const (
+	// Windows marks Files On-Demand (OneDrive) and Smart Sync (Dropbox) placeholders with these attributes
+	windowsFileAttributeOffline            = 0x1000
+	windowsFileAttributeRecallOnOpen       = 0x40000
+	windowsFileAttributeRecallOnDataAccess = 0x400000
+
+	// macOS File Provider (iCloud, and OneDrive/Dropbox on ~/Library/CloudStorage) flags evicted files as dataless
+	darwinFlagDataless = 0x40000000
+)
+
+// IsCloudPlaceholder reports whether the file at path is an online-only placeholder whose
+// content the sync client would download on first read. Folders are never placeholders here.
+func IsCloudPlaceholder(path string, info os.FileInfo) bool {
+	if info == nil || info.IsDir() {
+		return false
+	}
+	if isICloudStub(path) {
+		return true
+	}
+	if info.Sys() == nil {
+		return false
+	}
+	sys := reflect.Indirect(reflect.ValueOf(info.Sys()))
+	if sys.Kind() != reflect.Struct {
+		return false
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		attrs := sys.FieldByName("FileAttributes")
+		return attrs.IsValid() && attrs.CanUint() &&
+			attrs.Uint()&(windowsFileAttributeOffline|windowsFileAttributeRecallOnOpen|windowsFileAttributeRecallOnDataAccess) != 0
+	case "darwin":
+		flags := sys.FieldByName("Flags")
+		return flags.IsValid() && flags.CanUint() && flags.Uint()&darwinFlagDataless != 0
+	}
+	return false
+}
+
+// isICloudStub recognizes the ".Name.ext.icloud" stand-ins older iCloud Drive versions leave for evicted files
+func isICloudStub(path string) bool {
+	name := filepath.Base(path)
+	return strings.HasPrefix(name, ".") && strings.HasSuffix(name, ".icloud") && len(name) > len("..icloud")
+}
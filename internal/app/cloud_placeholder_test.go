@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsCloudPlaceholder(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{".Report.pdf.icloud", true},
+		{".icloud", false},
+		{"Report.pdf", false},
+		{"notes.icloud", false},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, []byte("stub"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := IsCloudPlaceholder(path, info); got != tt.want {
+			t.Errorf("IsCloudPlaceholder(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsCloudPlaceholder(dir, info) {
+		t.Error("folders should never be reported as placeholders")
+	}
+}
+
+func TestAnalyzeFileSkipsPlaceholders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".notes.txt.icloud")
+	if err := os.WriteFile(path, []byte("stub"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Even with downloads allowed, an iCloud stub holds no content worth describing
+	config := &Config{DownloadPlaceholders: true}
+	logger := NewLogger(false)
+	das := NewDeepAnalysisService(config, NewHTTPClient(config, logger), nil, logger)
+	if _, err := das.AnalyzeFile(context.Background(), path); !errors.Is(err, ErrCloudPlaceholder) {
+		t.Errorf("expected ErrCloudPlaceholder, got %v", err)
+	}
+}
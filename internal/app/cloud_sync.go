@@ -0,0 +1,109 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Cloud sync providers recognized by DetectSyncRoot
+const (
+	SyncProviderDropbox     = "Dropbox"
+	SyncProviderOneDrive    = "OneDrive"
+	SyncProviderGoogleDrive = "Google Drive"
+	SyncProviderICloud      = "iCloud Drive"
+)
+
+const (
+	defaultSyncThrottleDelayMs   = 500
+	defaultSyncThrottleBatchSize = 20
+)
+
+// DetectSyncRoot reports whether path is inside a folder kept in sync by a cloud storage
+// client, returning the provider and the sync root. It goes by the clients' default folder
+// names and marker files, so renamed sync folders may not be recognized.
+func DetectSyncRoot(path string) (provider, root string, ok bool) {
+	if IsObjectStoragePath(path) {
+		return "", "", false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", "", false
+	}
+
+	// The Windows client publishes its folders
+	for _, env := range []string{"OneDrive", "OneDriveConsumer", "OneDriveCommercial"} {
+		if dir := os.Getenv(env); dir != "" && isSubPath(filepath.Clean(dir), abs) {
+			return SyncProviderOneDrive, filepath.Clean(dir), true
+		}
+	}
+
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		if provider := syncProviderOf(dir); provider != "" {
+			return provider, dir, true
+		}
+		if filepath.Dir(dir) == dir {
+			return "", "", false
+		}
+	}
+}
+
+// syncProviderOf recognizes a sync root by its name, its parent or the client's marker files
+func syncProviderOf(dir string) string {
+	name := filepath.Base(dir)
+	parent := filepath.Base(filepath.Dir(dir))
+
+	// macOS File Provider locations: ~/Library/CloudStorage/Dropbox, GoogleDrive-me@example.com, OneDrive-Personal
+	if parent == "CloudStorage" {
+		switch {
+		case strings.HasPrefix(name, "Dropbox"):
+			return SyncProviderDropbox
+		case strings.HasPrefix(name, "OneDrive"):
+			return SyncProviderOneDrive
+		case strings.HasPrefix(name, "GoogleDrive"):
+			return SyncProviderGoogleDrive
+		}
+	}
+
+	switch {
+	case name == "Dropbox" || strings.HasPrefix(name, "Dropbox ("):
+		return SyncProviderDropbox
+	case name == "OneDrive" || strings.HasPrefix(name, "OneDrive - "):
+		return SyncProviderOneDrive
+	case name == "Google Drive" || name == "My Drive":
+		return SyncProviderGoogleDrive
+	case name == "iCloud Drive" || name == "com~apple~CloudDocs":
+		return SyncProviderICloud
+	}
+
+	if exists(filepath.Join(dir, ".dropbox")) || exists(filepath.Join(dir, ".dropbox.cache")) {
+		return SyncProviderDropbox
+	}
+	if exists(filepath.Join(dir, ".tmp.drivedownload")) {
+		return SyncProviderGoogleDrive
+	}
+	return ""
+}
+
+// ThrottleFor returns the pause and batch size to use when executing in basePath. Both are zero
+// unless throttling is on and basePath is in a cloud-synced folder.
+func (c *Config) ThrottleFor(basePath string) (time.Duration, int) {
+	if !c.ThrottleSyncedFolders {
+		return 0, 0
+	}
+	if _, _, ok := DetectSyncRoot(basePath); !ok {
+		return 0, 0
+	}
+	return time.Duration(c.SyncThrottleDelayMs) * time.Millisecond, c.SyncThrottleBatchSize
+}
+
+func isSubPath(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
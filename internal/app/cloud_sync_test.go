@@ -0,0 +1,96 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDetectSyncRoot(t *testing.T) {
+	t.Setenv("OneDrive", "")
+	t.Setenv("OneDriveConsumer", "")
+	t.Setenv("OneDriveCommercial", "")
+
+	root := t.TempDir()
+	mkdir := func(rel string) string {
+		dir := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+	marked := mkdir("custom-sync")
+	if err := os.WriteFile(filepath.Join(marked, ".dropbox"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		wantProvider string
+		wantRoot     string
+	}{
+		{"dropbox folder", mkdir("Dropbox/Photos"), SyncProviderDropbox, filepath.Join(root, "Dropbox")},
+		{"dropbox marker", mkdir("custom-sync/Work"), SyncProviderDropbox, marked},
+		{"business onedrive", mkdir("OneDrive - Contoso/Docs"), SyncProviderOneDrive, filepath.Join(root, "OneDrive - Contoso")},
+		{"macOS file provider", mkdir("Library/CloudStorage/GoogleDrive-me@example.com/My Drive/x"), SyncProviderGoogleDrive, filepath.Join(root, "Library", "CloudStorage", "GoogleDrive-me@example.com", "My Drive")},
+		{"not synced", mkdir("Documents/Dropboxes"), "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, syncRoot, ok := DetectSyncRoot(tt.path)
+			if provider != tt.wantProvider || syncRoot != tt.wantRoot || ok != (tt.wantProvider != "") {
+				t.Errorf("DetectSyncRoot() = %q, %q, %v; want %q, %q", provider, syncRoot, ok, tt.wantProvider, tt.wantRoot)
+			}
+		})
+	}
+
+	t.Setenv("OneDrive", filepath.Join(root, "Documents"))
+	if provider, _, _ := DetectSyncRoot(filepath.Join(root, "Documents", "Dropboxes")); provider != SyncProviderOneDrive {
+		t.Errorf("Expected the OneDrive environment variable to mark a sync root, got %q", provider)
+	}
+}
+
+func TestThrottleFor(t *testing.T) {
+	t.Setenv("OneDrive", "")
+	synced := filepath.Join(t.TempDir(), "Dropbox")
+	plain := t.TempDir()
+
+	config := &Config{SyncThrottleDelayMs: 250, SyncThrottleBatchSize: 5}
+	if delay, _ := config.ThrottleFor(synced); delay != 0 {
+		t.Errorf("Expected no throttling while it is off, got %v", delay)
+	}
+
+	config.ThrottleSyncedFolders = true
+	if delay, batch := config.ThrottleFor(synced); delay != 250*time.Millisecond || batch != 5 {
+		t.Errorf("ThrottleFor(synced) = %v, %d", delay, batch)
+	}
+	if delay, _ := config.ThrottleFor(plain); delay != 0 {
+		t.Errorf("Expected no throttling outside sync folders, got %v", delay)
+	}
+}
+
+func TestExecuteOperationsThrottled(t *testing.T) {
+	dir := t.TempDir()
+	var ops []FileOperation
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		ops = append(ops, FileOperation{From: filepath.Join(dir, name), To: filepath.Join(dir, "sorted", name)})
+	}
+
+	logger := NewLogger(false)
+	fs := NewFileService(NewValidator(), logger)
+	start := time.Now()
+	result, err := fs.ExecuteOperations(ops, dir, ExecutionOptions{Parallelism: 4, ThrottleDelay: 30 * time.Millisecond, ThrottleBatch: 2})
+	if err != nil || result.SuccessCount != 3 {
+		t.Fatalf("got %d successes, err %v", result.SuccessCount, err)
+	}
+	// One pause between the first batch of two and the last move
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("Expected a pause between batches, finished in %v", elapsed)
+	}
+}
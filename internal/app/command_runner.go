@@ -0,0 +1,92 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+var ErrEmptyCommand = errors.New("command cannot be empty")
+
+// splitCommandLine splits a command line into arguments, honoring single and double quotes
+// so paths with spaces can be configured (e.g. "C:\Program Files\tool.exe" --flag)
+func splitCommandLine(cmdline string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+
+	for _, r := range cmdline {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in command: %s", cmdline)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	if len(args) == 0 {
+		return nil, ErrEmptyCommand
+	}
+	return args, nil
+}
+
+// runCommand runs cmdline with stdin piped in and returns stdout.
+// extraArgs are appended after the configured arguments.
+func runCommand(cmdline string, stdin []byte, timeout time.Duration, extraArgs ...string) ([]byte, error) {
+	args, err := splitCommandLine(cmdline)
+	if err != nil {
+		return nil, err
+	}
+	return runArgs(append(args, extraArgs...), stdin, timeout)
+}
+
+// runArgs runs an already split argument list with stdin piped in and returns stdout
+func runArgs(args []string, stdin []byte, timeout time.Duration) ([]byte, error) {
+	if len(args) == 0 {
+		return nil, ErrEmptyCommand
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("%s timed out after %s", args[0], timeout)
+		}
+		return nil, fmt.Errorf("%s failed: %w (stderr: %s)", args[0], err, truncate(strings.TrimSpace(stderr.String()), 500))
+	}
+	return stdout.Bytes(), nil
+}
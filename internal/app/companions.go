@@ -0,0 +1,211 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// companionExtensions are files that belong to another file with the same name, like subtitles
+// next to a movie or an XMP sidecar next to a photo. Subtitles may carry a language tag
+// (movie.en.srt).
+var companionExtensions = map[string]bool{
+	".xmp": true, ".aae": true, ".thm": true,
+	".srt": true, ".sub": true, ".idx": true, ".ass": true, ".ssa": true, ".vtt": true, ".smi": true,
+	".nfo": true, ".lrc": true, ".cue": true,
+}
+
+// rawPreviewExtensions are the images cameras save next to RAW photos (see rawExtensions)
+var rawPreviewExtensions = map[string]bool{".jpg": true, ".jpeg": true, ".heic": true, ".heif": true}
+
+// companionDir holds the bound groups of one folder
+type companionDir struct {
+	stemLen map[string]int      // File name -> length of the name part it shares with its group
+	groups  map[string][]string // Lowercased shared name -> file names in the group
+}
+
+func isCompanionName(name string) bool {
+	return companionExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// readCompanionDir groups the files of dir by name. A group is bound when it has a companion and
+// a file it belongs to, or a RAW photo and its preview; files like report.pdf and report.docx
+// stay independent. Sidecars named after the whole file name (photo.jpg.xmp) are left out, since
+// they're carried along when their file moves, and so are files scanned rejects.
+func readCompanionDir(dir string, scanned func(path string) bool) *companionDir {
+	cd := &companionDir{stemLen: make(map[string]int), groups: make(map[string][]string)}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return cd
+	}
+
+	stems := make(map[string]bool)
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(dir, name)
+		if !entry.Type().IsRegular() || isSidecarFile(path) || scanned != nil && !scanned(path) {
+			continue
+		}
+		names = append(names, name)
+		if stem := strings.TrimSuffix(name, filepath.Ext(name)); stem != "" && !isCompanionName(name) {
+			cd.stemLen[name] = len(stem)
+			stems[strings.ToLower(stem)] = true
+		}
+	}
+	// A companion belongs to the file with the longest name it starts with
+	for _, name := range names {
+		if !isCompanionName(name) {
+			continue
+		}
+		for i := len(name) - 1; i > 0; i-- {
+			if name[i] == '.' && stems[strings.ToLower(name[:i])] {
+				cd.stemLen[name] = i
+				break
+			}
+		}
+	}
+
+	members := make(map[string][]string)
+	for _, name := range names {
+		if n, ok := cd.stemLen[name]; ok {
+			key := strings.ToLower(name[:n])
+			members[key] = append(members[key], name)
+		}
+	}
+	for key, group := range members {
+		var companion, primary, raw, preview bool
+		for _, name := range group {
+			ext := strings.ToLower(filepath.Ext(name))
+			companion = companion || companionExtensions[ext]
+			primary = primary || !companionExtensions[ext]
+			raw = raw || slices.Contains(rawExtensions, ext)
+			preview = preview || rawPreviewExtensions[ext]
+		}
+		if companion && primary || raw && preview {
+			cd.groups[key] = group
+		}
+	}
+	return cd
+}
+
+// ResolveCompanions keeps bound groups of companion files together: when the plan moves one of
+// them, the others go to the same folder, renamed the same way. Companions the plan didn't
+// mention are added, and those it sent elsewhere are redirected; both are also returned on their own.
+// Only files scanned accepts belong to groups (nil accepts all). When check rejects a move of any
+// member, the whole group stays where it is, and its planned operations are returned as rejected
+// with the reason in Flag.
+func ResolveCompanions(operations []FileOperation, scanned func(path string) bool, check func(op FileOperation) error) (resolved, companions, rejected []FileOperation) {
+	resolved = append([]FileOperation{}, operations...)
+	planned := make(map[string]int, len(resolved))
+	for i, op := range resolved {
+		planned[filepath.Clean(op.From)] = i
+	}
+	dirs := make(map[string]*companionDir)
+	placed := make(map[string]bool)
+	changed := make(map[int]bool)
+	dropped := make(map[int]string)
+
+	// Files a companion belongs to lead their group, so a subtitle the model moved on its own
+	// follows the movie rather than the other way round
+	for _, leadCompanions := range []bool{false, true} {
+		for i := 0; i < len(operations); i++ {
+			op := resolved[i]
+			from := filepath.Clean(op.From)
+			name := filepath.Base(from)
+			if placed[from] || isCompanionName(name) != leadCompanions || IsObjectStoragePath(from) || IsObjectStoragePath(op.To) {
+				continue
+			}
+			dir := filepath.Dir(from)
+			if dirs[dir] == nil {
+				dirs[dir] = readCompanionDir(dir, scanned)
+			}
+			cd := dirs[dir]
+			n, ok := cd.stemLen[name]
+			if !ok {
+				continue
+			}
+			group := cd.groups[strings.ToLower(name[:n])]
+			if len(group) == 0 {
+				continue
+			}
+			placed[from] = true
+
+			// A renamed file renames its companions: photo.jpg -> 2024-05 photo.jpg takes photo.xmp to 2024-05 photo.xmp
+			newName := filepath.Base(op.To)
+			newStem := strings.TrimSuffix(newName, filepath.Ext(newName))
+			if k := len(newName) - len(name[n:]); k >= 0 && strings.EqualFold(newName[k:], name[n:]) {
+				newStem = newName[:k]
+			}
+			reason := fmt.Sprintf("Kept with %s", name)
+			targets := make(map[string]string, len(group))
+			var refusal string
+			for _, member := range group {
+				path := filepath.Join(dir, member)
+				if path == from || placed[path] {
+					continue
+				}
+				targets[path] = filepath.Join(filepath.Dir(op.To), newStem+member[cd.stemLen[member]:])
+				if check == nil || refusal != "" {
+					continue
+				}
+				if err := check(FileOperation{From: path, To: targets[path]}); err != nil {
+					refusal = fmt.Sprintf("companion %s can't follow: %v", member, err)
+				}
+			}
+
+			if refusal != "" {
+				// The group stays together where it is
+				dropped[i] = refusal
+				for path := range targets {
+					placed[path] = true
+					if j, ok := planned[path]; ok {
+						dropped[j] = refusal
+					}
+				}
+				continue
+			}
+			for _, member := range group {
+				path := filepath.Join(dir, member)
+				target, ok := targets[path]
+				if !ok {
+					continue
+				}
+				placed[path] = true
+				if j, ok := planned[path]; ok {
+					if filepath.Clean(resolved[j].To) != target {
+						// The model's reason was for where it wanted the file to go
+						resolved[j].To = target
+						resolved[j].Reason = reason
+						changed[j] = true
+					}
+					continue
+				}
+				planned[path] = len(resolved)
+				changed[len(resolved)] = true
+				resolved = append(resolved, FileOperation{
+					From:       path,
+					To:         target,
+					Confidence: op.Confidence,
+					Reason:     reason,
+				})
+			}
+		}
+	}
+
+	kept := resolved[:0]
+	for i, op := range resolved {
+		if refusal, ok := dropped[i]; ok {
+			op.Flag = refusal
+			rejected = append(rejected, op)
+			continue
+		}
+		if changed[i] {
+			companions = append(companions, op)
+		}
+		kept = append(kept, op)
+	}
+	return kept, companions, rejected
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveCompanions(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"photo.CR2", "photo.jpg", "photo.xmp",
+		"movie.mkv", "movie.srt", "movie.en.srt", "movie.part2.mkv", "movie.part2.srt",
+		"report.pdf", "report.docx",
+		"IMG_1.jpg", "IMG_1.jpg.xmp",
+		"lonely.srt", "notes.txt",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := func(name string) string { return filepath.Join(dir, filepath.FromSlash(name)) }
+
+	operations := []FileOperation{
+		{From: p("movie.srt"), To: p("Subtitles/movie.srt"), Reason: "Subtitles together"},
+		{From: p("photo.jpg"), To: p("Photos/2024/photo.jpg"), Confidence: 0.9},
+		{From: p("movie.mkv"), To: p("Movies/Film (1999).mkv")},
+		{From: p("report.pdf"), To: p("Documents/report.pdf")},
+		{From: p("IMG_1.jpg"), To: p("Photos/IMG_1.jpg")},
+		{From: p("lonely.srt"), To: p("Subtitles/lonely.srt")},
+		{From: p("movie.part2.srt"), To: p("Subtitles/movie.part2.srt")},
+	}
+	resolved, companions, rejected := ResolveCompanions(operations, nil, nil)
+	if len(rejected) != 0 {
+		t.Errorf("rejected = %v, want none", rejected)
+	}
+
+	want := map[string]string{
+		"movie.srt":       "Movies/Film (1999).srt",
+		"photo.jpg":       "Photos/2024/photo.jpg",
+		"movie.mkv":       "Movies/Film (1999).mkv",
+		"report.pdf":      "Documents/report.pdf",
+		"IMG_1.jpg":       "Photos/IMG_1.jpg",
+		"lonely.srt":      "Subtitles/lonely.srt",
+		"movie.part2.srt": "Subtitles/movie.part2.srt",
+		"photo.CR2":       "Photos/2024/photo.CR2",
+		"photo.xmp":       "Photos/2024/photo.xmp",
+		"movie.en.srt":    "Movies/Film (1999).en.srt",
+		"movie.part2.mkv": "Subtitles/movie.part2.mkv",
+	}
+	if len(resolved) != len(want) {
+		t.Errorf("resolved %d operations, want %d: %v", len(resolved), len(want), resolved)
+	}
+	for _, op := range resolved {
+		rel, _ := filepath.Rel(dir, op.From)
+		if to, ok := want[filepath.ToSlash(rel)]; !ok || op.To != p(to) {
+			t.Errorf("%s → %s, want %s", rel, op.To, to)
+		}
+	}
+	// Everything but the operations that stayed as planned
+	if len(companions) != 5 {
+		t.Errorf("companions = %d, want 5: %v", len(companions), companions)
+	}
+	for _, op := range companions {
+		if op.Reason == "" || op.Reason == "Subtitles together" {
+			t.Errorf("companion %s has reason %q", op.From, op.Reason)
+		}
+		if filepath.Base(op.From) == "photo.CR2" && op.Confidence != 0.9 {
+			t.Errorf("added companion confidence = %v, want the leader's", op.Confidence)
+		}
+	}
+	if operations[0].To != p("Subtitles/movie.srt") {
+		t.Error("ResolveCompanions modified its input")
+	}
+}
+
+func TestResolveCompanionsChecksEveryMember(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"movie.mkv", "movie.srt", "clip.mp4", "clip.nfo", "clip.srt", "photo.jpg", "photo.xmp"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := func(name string) string { return filepath.Join(dir, filepath.FromSlash(name)) }
+	constraints := ParseConstraints("never touch *.srt")
+	validator := NewPlanValidator(constraints)
+
+	operations := []FileOperation{
+		{From: p("movie.mkv"), To: p("Movies/movie.mkv")},
+		{From: p("clip.nfo"), To: p("Info/clip.nfo")},
+		{From: p("photo.jpg"), To: p("Photos/photo.jpg")},
+	}
+	resolved, _, rejected := ResolveCompanions(operations,
+		func(path string) bool { return filepath.Base(path) != "photo.xmp" }, // Not scanned, e.g. ignored
+		func(op FileOperation) error { return validator.Check(dir, op) })
+
+	// movie.srt and clip.srt may not be touched, so their groups stay; photo.xmp isn't part of the plan
+	if len(resolved) != 1 || resolved[0].From != p("photo.jpg") || resolved[0].To != p("Photos/photo.jpg") {
+		t.Errorf("resolved = %v, want only the photo", resolved)
+	}
+	if len(rejected) != 2 {
+		t.Fatalf("rejected = %v, want the movie and the clip info", rejected)
+	}
+	for _, op := range rejected {
+		if !strings.Contains(op.Flag, ".srt can't follow") {
+			t.Errorf("rejected %s with flag %q", op.From, op.Flag)
+		}
+	}
+}
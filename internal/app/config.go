@@ -2,14 +2,17 @@ package app
 
 import (
 	"encoding/json"
-	"io"
-
-	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/storage"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 )
 
 const (
-	configFileName = "config.json"
+	configFileName      = "config.json"
+	configBackupSuffix  = ".bak"
+	configCorruptSuffix = ".corrupt"
+	defaultLogFileName  = "vibesandfolders.log"
 
 	// Default values
 	defaultEndpoint     = "https://openrouter.ai/api/v1/chat/completions"
@@ -62,6 +65,8 @@ RULES:
 	defaultIgnorePatterns = `# Ignore patterns (one per line, similar to .gitignore)
 # Use * for wildcards, ** for recursive matching
 # Lines starting with # are comments
+# Start a pattern with ! to re-include what an earlier one ignored,
+# or with / to only match at the top of the folder being organized
 
 # Version control
 .git/
@@ -74,6 +79,44 @@ vendor/
 # Build outputs
 build/
 dist/`
+
+	defaultParallelMoves = 4
+
+	defaultWalkWorkers   = 4
+	defaultWalkBatchSize = 64
+
+	defaultIndexJanitorIntervalHours = 24
+	defaultSnapshotIntervalHours     = 24
+	defaultScheduledJobIntervalHours = 24
+
+	defaultDeepAnalysisConfirmCalls = 50
+
+	defaultImageMaxDimension = 2048
+
+	defaultAutoApplyMinConfidence = 0.9
+	defaultAutoApplyMaxOperations = 20
+
+	defaultTranscriptionModel      = "whisper-1"
+	defaultTranscriptionMaxSizeMB  = 25 // OpenAI's upload limit
+	defaultTranscriptionMaxMinutes = 30
+
+	// Formats for the directory listing sent to the model
+	StructureFormatText = "text"
+	StructureFormatJSON = "json"
+
+	// When PDFs are analyzed from rendered page images rather than their text
+	PDFPageImagesAuto   = "auto" // PDFs with little text, such as slides and scans
+	PDFPageImagesAlways = "always"
+	PDFPageImagesNever  = "never"
+
+	defaultPDFPageImageCount = 3
+
+	// Whether moved files are compared by content hash before and after execution
+	HashVerificationOff    = "off"
+	HashVerificationSample = "sample"
+	HashVerificationAll    = "all"
+
+	defaultHashSampleSize = 20
 )
 
 type Config struct {
@@ -85,95 +128,343 @@ type Config struct {
 	TextAnalysisPrompt  string `json:"text_analysis_prompt"`
 	ImageAnalysisPrompt string `json:"image_analysis_prompt"`
 	EnableDeepAnalysis  bool   `json:"enable_deep_analysis"`
+	DescriptionMaxWords int    `json:"description_max_words"` // 0 keeps the analysis prompts' own limits
+	DescriptionLanguage string `json:"description_language"`  // e.g. "German"; empty lets the model choose
 	IndexDBPath         string `json:"index_db_path"`
-	IgnorePatterns      string `json:"ignore_patterns"` // Multiline string with one pattern per line
+	ParallelMoves       int    `json:"parallel_moves"`   // Concurrent moves during execution; 1 is sequential
+	StructureFormat     string `json:"structure_format"` // StructureFormatText or StructureFormatJSON
+	IgnorePatterns      string `json:"ignore_patterns"`  // Multiline string with one pattern per line
+	AnalyzerPlugins     string `json:"analyzer_plugins"` // Multiline ".ext1,.ext2: command" entries
+	RateLimits          string `json:"rate_limits"`      // Multiline "host: requests/min, tokens/min" entries
+
+	// Directories read at once while scanning, and how many listings may be read ahead of the scan.
+	// More workers help on network filesystems where every directory read is a round trip.
+	WalkWorkers   int `json:"walk_workers"`
+	WalkBatchSize int `json:"walk_batch_size"`
+
+	// Skips re-scanning folder trees whose folders haven't changed since the last scan, which makes
+	// repeat analyses of static archives fast. Files edited in place go unnoticed until their folder changes.
+	SkipUnchangedFolders bool `json:"skip_unchanged_folders"`
+
+	// Requests in flight per provider at once; planning requests may use one more (see RequestQueue)
+	MaxConcurrentRequests int `json:"max_concurrent_requests"`
+
+	// Multiline ".ext1,.ext2: type" entries that extend or override the built-in extension table
+	FileTypeMappings string `json:"file_type_mappings"`
+
+	// Asks before deep analysis sends more than this many files to the model; 0 never asks
+	DeepAnalysisConfirmCalls int `json:"deep_analysis_confirm_calls"`
+
+	// Longest edge, in pixels, of images sent for deep analysis; larger ones are downscaled
+	ImageMaxDimension int `json:"image_max_dimension"`
+
+	// When PDFs are sent as page images (PDFPageImagesAuto, Always or Never), and how many pages
+	PDFPageImages     string `json:"pdf_page_images"`
+	PDFPageImageCount int    `json:"pdf_page_image_count"`
+
+	// Sampling parameters for plan requests, usually set by a ModelPreset.
+	// A nil Temperature leaves it to the provider; MaxTokens 0 uses the built-in limit.
+	Temperature *float64 `json:"temperature,omitempty"`
+	MaxTokens   int      `json:"max_tokens"`
+
+	// Folder on a shared drive used to exchange index descriptions with other machines.
+	// Paths are matched relative to its parent folder.
+	IndexSyncDir string `json:"index_sync_dir"`
+
+	// Folders, one per line, whose changes update the index as they happen (see IndexWatcher)
+	WatchedFolders string `json:"watched_folders"`
+
+	// Asks lsof (macOS/Linux) whether files are open in other programs before moving them;
+	// Windows always detects files locked by other programs
+	CheckOpenFiles bool `json:"check_open_files"`
+
+	// Leaves dotfiles and files marked hidden or system on Windows out of scans, on top of IgnorePatterns
+	IgnoreHiddenFiles bool `json:"ignore_hidden_files"`
+
+	// Starts analyses right after the scan instead of showing its summary and asking first
+	SkipScanSummary bool `json:"skip_scan_summary"`
+
+	// Plans only the files that are new or modified since the folder was last indexed (needs deep analysis)
+	PlanChangedFilesOnly bool `json:"plan_changed_files_only"`
+
+	// Encrypts descriptions in the index database with a key kept in the system keychain
+	EncryptIndex bool `json:"encrypt_index"`
+
+	// How the app looks for new releases: UpdateModeOff, UpdateModeNotify or UpdateModeDownload
+	UpdateMode string `json:"update_mode"`
+
+	// SQLite database recording every execution and index change with host, user and app version.
+	// Empty uses the app's data folder.
+	AuditLogPath string `json:"audit_log_path"`
+
+	// Locale for dates and sizes shown in the app and in reports, e.g. "de-DE"; empty follows the system
+	Locale string `json:"locale"`
+
+	// Level and destinations (console, LogFilePath) of log lines, keyed by subsystem (LogSubsystemHTTP, ...).
+	// An empty LogFilePath uses the app's data folder.
+	Logging     map[string]LogSettings `json:"logging,omitempty"`
+	LogFilePath string                 `json:"log_file_path"`
+
+	// Asks the model to explain each move, for plan review reports
+	ExplainMoves bool `json:"explain_moves"`
+
+	// Second pass where the model reviews its plan and drops or flags suspect operations
+	SelfCritique bool `json:"self_critique"`
+
+	// Plans from index statistics and model-written classification rules instead of sending the
+	// structure, so folders too large for the context window can be organized
+	IndexRules bool `json:"index_rules"`
+
+	// Plans only files modified since the last successful execution in the folder, so what was
+	// organized then stays where it is
+	NewFilesOnly bool `json:"new_files_only"`
+
+	// Rules plans must obey, one per line, keyed by directory (see ParseConstraints)
+	DirectoryConstraints map[string]string `json:"directory_constraints,omitempty"`
+
+	// Rule pre-pass and normalization post-pass around the model, keyed by directory (see ParsePipeline)
+	DirectoryPipelines map[string]string `json:"directory_pipelines,omitempty"`
+
+	// Folders recently organized or picked, most recent first
+	RecentFolders []string `json:"recent_folders,omitempty"`
+
+	// Slow down execution in Dropbox/OneDrive/Google Drive/iCloud folders so sync clients keep up
+	ThrottleSyncedFolders bool `json:"throttle_synced_folders"`
+	SyncThrottleDelayMs   int  `json:"sync_throttle_delay_ms"`   // Pause after each batch
+	SyncThrottleBatchSize int  `json:"sync_throttle_batch_size"` // Moves between pauses
+
+	// Lets deep analysis download online-only OneDrive/Dropbox/iCloud files; otherwise they are left unanalyzed
+	DownloadPlaceholders bool `json:"download_placeholders"`
+
+	// Naming convention applied to new folders in plans: NamingStyleNone, NamingStyleKebab, ...
+	FolderNamingStyle     string `json:"folder_naming_style"`
+	NormalizeDatePrefixes bool   `json:"normalize_date_prefixes"` // Rewrite leading dates as YYYY-MM-DD
+
+	// Marks new folders by category (see ParseFolderLabelRules): an emoji before their name in
+	// plans, and a Finder color (macOS) or desktop.ini icon (Windows) when execution creates them
+	FolderEmojiPrefixes bool   `json:"folder_emoji_prefixes"`
+	FolderColorLabels   bool   `json:"folder_color_labels"`
+	FolderLabelRules    string `json:"folder_label_rules"`
+
+	// Reports moved files and folders whose permissions differ afterwards (e.g. on shared Samba folders)
+	AuditPermissions bool `json:"audit_permissions"`
+
+	// Points Windows shortcuts, macOS aliases and symlinks under the folder at files that moved
+	RewriteShortcuts bool `json:"rewrite_shortcuts"`
+
+	// Rewrites paths to moved files in M3U playlists, Markdown notes and XMP sidecars under the folder
+	UpdateReferences bool `json:"update_references"`
+
+	// Lets plans move companion files (subtitles, XMP sidecars, RAW+JPEG pairs) apart; by default
+	// they follow the file they belong to
+	SplitCompanions bool `json:"split_companions"`
+
+	// Executes plans all or nothing through a staging folder instead of move by move
+	StagedExecution bool `json:"staged_execution"`
+
+	// Compares content hashes of a random sample (or all) of the moved files before and after
+	// execution (HashVerificationOff, Sample or All), to catch corruption on flaky network filesystems
+	HashVerification string `json:"hash_verification"`
+	HashSampleSize   int    `json:"hash_sample_size"`
+
+	// Writes each indexed file's description next to it (SidecarFormatJSON or SidecarFormatXMP); empty disables it
+	SidecarFormat string `json:"sidecar_format"`
+
+	// Automated jobs (watchers, schedules) execute plans without review only when every operation
+	// is at least this confident and the plan has at most this many operations; others wait for review
+	AutoApply              bool    `json:"auto_apply"`
+	AutoApplyMinConfidence float64 `json:"auto_apply_min_confidence"`
+	AutoApplyMaxOperations int     `json:"auto_apply_max_operations"`
+
+	// Folders organized unattended every ScheduledJobIntervalHours (0 disables it), one job per
+	// line (see ParseScheduledJobs); their plans are applied or held back as AutoApply decides
+	ScheduledJobs             string `json:"scheduled_jobs"`
+	ScheduledJobIntervalHours int    `json:"scheduled_job_interval_hours"`
+
+	// Where automated jobs send their run summary (see RunSummary): a webhook receiving it as a
+	// JSON POST, and/or an email sent through an SMTP server given as host:port
+	NotifyWebhookURL   string `json:"notify_webhook_url"`
+	NotifySMTPServer   string `json:"notify_smtp_server"`
+	NotifySMTPUser     string `json:"notify_smtp_user"`
+	NotifySMTPPassword string `json:"notify_smtp_password"`
+	NotifyEmailFrom    string `json:"notify_email_from"` // Empty uses NotifySMTPUser
+	NotifyEmailTo      string `json:"notify_email_to"`   // Comma-separated
+
+	// Hours between background removals of index entries for deleted files; 0 disables it
+	IndexJanitorIntervalHours int `json:"index_janitor_interval_hours"`
+
+	// Folders, one per line, whose files are recorded with sizes and hashes every
+	// SnapshotIntervalHours (0 disables it), to compare what changed between two dates
+	SnapshotFolders       string `json:"snapshot_folders"`
+	SnapshotIntervalHours int    `json:"snapshot_interval_hours"`
+
+	// Sends every file to the model even when it already carries a description (XMP, EXIF, PDF Subject)
+	IgnoreEmbeddedDescriptions bool `json:"ignore_embedded_descriptions"`
+
+	// File types (see AnalysisFileTypes) that deep analysis never sends to the model
+	DisabledAnalysisTypes []string `json:"disabled_analysis_types"`
+
+	// Converter for legacy .doc/.ppt files, e.g. "soffice --headless --convert-to {format} --outdir {outdir} {input}"
+	LegacyConverterCommand string `json:"legacy_converter_command"`
+
+	// Spend tracking: prices in USD per million tokens, budgets in USD (0 = no ceiling)
+	PriceInputPerMillion  float64 `json:"price_input_per_million"`
+	PriceOutputPerMillion float64 `json:"price_output_per_million"`
+	RunBudget             float64 `json:"run_budget"`
+	MonthlyBudget         float64 `json:"monthly_budget"`
+
+	// Hook commands receive a JSON payload on stdin; a failing pre-* hook blocks that step
+	HookPreAnalysis string `json:"hook_pre_analysis"`
+	HookPreExecute  string `json:"hook_pre_execute"`
+	HookPostExecute string `json:"hook_post_execute"`
+
+	// Audio transcription via a Whisper-compatible endpoint (e.g. https://api.openai.com/v1/audio/transcriptions).
+	// Recordings are only uploaded when TranscribeAudio is enabled and they are within the limits.
+	TranscribeAudio         bool   `json:"transcribe_audio"`
+	TranscriptionEndpoint   string `json:"transcription_endpoint"`
+	TranscriptionAPIKey     string `json:"transcription_api_key"` // Empty reuses APIKey
+	TranscriptionModel      string `json:"transcription_model"`
+	TranscriptionMaxSizeMB  int    `json:"transcription_max_size_mb"`
+	TranscriptionMaxMinutes int    `json:"transcription_max_minutes"`
+
+	// Object storage (s3:// paths); endpoint may point at any S3-compatible service
+	S3Endpoint     string `json:"s3_endpoint"`
+	S3Region       string `json:"s3_region"`
+	S3AccessKey    string `json:"s3_access_key"`
+	S3SecretKey    string `json:"s3_secret_key"`
+	S3UsePathStyle bool   `json:"s3_use_path_style"`
+
+	// Hash of the shipped default each prompt was last edited against, keyed by the prompt's JSON key
+	PromptBaselines map[string]string `json:"prompt_baselines,omitempty"`
 }
 
-// LoadConfig loads configuration from app storage
-func LoadConfig(a fyne.App, logger *Logger) *Config {
-	config := &Config{}
+// LoadConfig loads configuration from the app's data folder. A config file that can't be read is
+// replaced by its backup rather than by defaults, so custom prompts and settings survive a
+// crash during a save.
+func LoadConfig(dataDir string, logger *Logger) *Config {
+	return loadConfigFile(configFilePath(dataDir), logger)
+}
 
-	// Get config URI from app's storage root
-	rootURI := a.Storage().RootURI()
-	configURI, err := storage.Child(rootURI, configFileName)
-	if err != nil {
-		logger.Info("Error creating config URI: %v. Using defaults.", err)
-		loadDefaults(config)
+func loadConfigFile(path string, logger *Logger) *Config {
+	config, err := readConfigFile(path)
+	if err == nil {
+		// Fill in any missing fields with defaults (for backward compatibility)
+		applyDefaults(config)
+		logger.Info("Configuration loaded successfully.")
 		return config
 	}
 
-	exists, err := storage.Exists(configURI)
-	if err != nil {
-		logger.Info("Error checking config existence: %v. Using defaults.", err)
-		loadDefaults(config)
-		return config
+	exists := !errors.Is(err, os.ErrNotExist)
+	if exists {
+		logger.Error("Config file is damaged: %v", err)
+		// Keep the damaged file for inspection; saving would otherwise rotate it into the backup
+		if err := os.Rename(path, path+configCorruptSuffix); err != nil {
+			logger.Error("Failed to set aside damaged config: %v", err)
+		}
 	}
 
-	if !exists {
-		logger.Info("No config file found. Creating with defaults.")
-		loadDefaults(config)
-		SaveConfig(a, config, logger)
-		return config
+	if backup, backupErr := readConfigFile(path + configBackupSuffix); backupErr == nil {
+		logger.Info("Restored configuration from backup.")
+		applyDefaults(backup)
+		saveConfigFile(path, backup, logger)
+		return backup
+	} else if !errors.Is(backupErr, os.ErrNotExist) {
+		logger.Error("Config backup is damaged too: %v", backupErr)
 	}
 
-	// Read config file
-	rc, err := storage.Reader(configURI)
-	if err != nil {
-		logger.Info("Error opening config file: %v. Using defaults.", err)
-		loadDefaults(config)
-		return config
+	config = &Config{}
+	loadDefaults(config)
+	if exists {
+		logger.Info("Using defaults. The damaged config was kept as %s.", path+configCorruptSuffix)
+	} else {
+		logger.Info("No config file found. Creating with defaults.")
 	}
-	defer rc.Close()
+	saveConfigFile(path, config, logger)
+	return config
+}
+
+// SaveConfig saves configuration to the app's data folder, keeping the previous version as a backup
+func SaveConfig(dataDir string, config *Config, logger *Logger) {
+	saveConfigFile(configFilePath(dataDir), config, logger)
+}
 
-	data, err := io.ReadAll(rc)
+func saveConfigFile(path string, config *Config, logger *Logger) {
+	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
-		logger.Info("Error reading config file: %v. Using defaults.", err)
-		loadDefaults(config)
-		return config
+		logger.Info("Error marshaling config: %v", err)
+		return
 	}
 
-	if err := json.Unmarshal(data, config); err != nil {
-		logger.Info("Error parsing config JSON: %v. Using defaults.", err)
-		loadDefaults(config)
-		return config
+	if err := writeConfigFile(path, data); err != nil {
+		logger.Error("Error writing config file: %v", err)
+		return
 	}
 
-	// Fill in any missing fields with defaults (for backward compatibility)
-	applyDefaults(config)
+	logger.Info("Configuration saved.")
+}
 
-	logger.Info("Configuration loaded successfully.")
-	return config
+func configFilePath(dataDir string) string {
+	return filepath.Join(dataDir, configFileName)
 }
 
-// SaveConfig saves configuration to app storage
-func SaveConfig(a fyne.App, config *Config, logger *Logger) {
-	data, err := json.MarshalIndent(config, "", "  ")
+// readConfigFile parses a config file without applying defaults
+func readConfigFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		logger.Info("Error marshaling config: %v", err)
-		return
+		return nil, err
+	}
+	config := &Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
 	}
+	return config, nil
+}
 
-	rootURI := a.Storage().RootURI()
-	configURI, err := storage.Child(rootURI, configFileName)
-	if err != nil {
-		logger.Info("Error creating config URI for saving: %v", err)
-		return
+// writeConfigFile replaces the config at path atomically. The current file becomes the backup
+// first, but only if it parses, so a damaged file never overwrites a good backup.
+func writeConfigFile(path string, data []byte) error {
+	if previous, err := os.ReadFile(path); err == nil && json.Valid(previous) {
+		if err := writeFileAtomic(path+configBackupSuffix, previous); err != nil {
+			return fmt.Errorf("failed to back up config: %w", err)
+		}
 	}
+	return writeFileAtomic(path, data)
+}
 
-	// Write config file (creates if doesn't exist)
-	wc, err := storage.Writer(configURI)
+// writeFileAtomic writes data to a temp file next to path, syncs it and renames it into place,
+// so readers see either the old or the new contents, never a partial write
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
 	if err != nil {
-		logger.Info("Error opening config file for writing: %v", err)
-		return
+		return err
 	}
-	defer wc.Close()
+	defer os.Remove(tmp.Name())
 
-	if _, err := wc.Write(data); err != nil {
-		logger.Info("Error writing config file: %v", err)
-		return
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
 	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
 
-	logger.Info("Configuration saved.")
+// DefaultConfig returns the settings a fresh install starts with
+func DefaultConfig() *Config {
+	config := &Config{}
+	loadDefaults(config)
+	return config
 }
 
 func loadDefaults(config *Config) {
@@ -184,9 +475,35 @@ func loadDefaults(config *Config) {
 	config.PDFAnalysisPrompt = defaultPDFAnalysisPrompt
 	config.TextAnalysisPrompt = defaultTextAnalysisPrompt
 	config.ImageAnalysisPrompt = defaultImageAnalysisPrompt
+	upgradePrompts(config)
 	config.EnableDeepAnalysis = false
+	config.DeepAnalysisConfirmCalls = defaultDeepAnalysisConfirmCalls
+	config.ImageMaxDimension = defaultImageMaxDimension
+	config.PDFPageImages = PDFPageImagesAuto
+	config.PDFPageImageCount = defaultPDFPageImageCount
+	config.HashVerification = HashVerificationOff
+	config.HashSampleSize = defaultHashSampleSize
 	config.IndexDBPath = "" // Will be set to app storage path at runtime
 	config.IgnorePatterns = defaultIgnorePatterns
+	config.FolderLabelRules = defaultFolderLabelRules
+	config.ParallelMoves = defaultParallelMoves
+	config.WalkWorkers = defaultWalkWorkers
+	config.WalkBatchSize = defaultWalkBatchSize
+	config.StructureFormat = StructureFormatText
+	config.MaxConcurrentRequests = defaultMaxConcurrentRequests
+	config.IndexJanitorIntervalHours = defaultIndexJanitorIntervalHours
+	config.SnapshotIntervalHours = defaultSnapshotIntervalHours
+	config.ScheduledJobIntervalHours = defaultScheduledJobIntervalHours
+	config.AutoApplyMinConfidence = defaultAutoApplyMinConfidence
+	config.AutoApplyMaxOperations = defaultAutoApplyMaxOperations
+	config.UpdateMode = UpdateModeOff
+	config.SyncThrottleDelayMs = defaultSyncThrottleDelayMs
+	config.SyncThrottleBatchSize = defaultSyncThrottleBatchSize
+	config.TranscriptionModel = defaultTranscriptionModel
+	config.TranscriptionMaxSizeMB = defaultTranscriptionMaxSizeMB
+	config.TranscriptionMaxMinutes = defaultTranscriptionMaxMinutes
+	config.Logging = make(map[string]LogSettings)
+	applyLoggingDefaults(config)
 }
 
 // applyDefaults fills in any empty fields with default values
@@ -213,7 +530,88 @@ func applyDefaults(config *Config) {
 	if config.ImageAnalysisPrompt == "" {
 		config.ImageAnalysisPrompt = defaultImageAnalysisPrompt
 	}
+	upgradePrompts(config)
 	if config.IgnorePatterns == "" {
 		config.IgnorePatterns = defaultIgnorePatterns
 	}
+	if config.FolderLabelRules == "" {
+		config.FolderLabelRules = defaultFolderLabelRules
+	}
+	if config.ParallelMoves <= 0 {
+		// Configs from before parallel moves keep executing sequentially until the user opts in
+		config.ParallelMoves = 1
+	}
+	if config.WalkWorkers <= 0 {
+		config.WalkWorkers = defaultWalkWorkers
+	}
+	if config.WalkBatchSize <= 0 {
+		config.WalkBatchSize = defaultWalkBatchSize
+	}
+	if config.StructureFormat == "" {
+		config.StructureFormat = StructureFormatText
+	}
+	if config.ImageMaxDimension <= 0 {
+		config.ImageMaxDimension = defaultImageMaxDimension
+	}
+	if config.PDFPageImages == "" {
+		config.PDFPageImages = PDFPageImagesAuto
+	}
+	if config.PDFPageImageCount <= 0 {
+		config.PDFPageImageCount = defaultPDFPageImageCount
+	}
+	if config.HashVerification == "" {
+		config.HashVerification = HashVerificationOff
+	}
+	if config.HashSampleSize <= 0 {
+		config.HashSampleSize = defaultHashSampleSize
+	}
+	if config.MaxConcurrentRequests <= 0 {
+		config.MaxConcurrentRequests = defaultMaxConcurrentRequests
+	}
+	if config.AutoApplyMinConfidence <= 0 || config.AutoApplyMinConfidence > 1 {
+		config.AutoApplyMinConfidence = defaultAutoApplyMinConfidence
+	}
+	if config.AutoApplyMaxOperations <= 0 {
+		config.AutoApplyMaxOperations = defaultAutoApplyMaxOperations
+	}
+	if config.UpdateMode == "" {
+		config.UpdateMode = UpdateModeOff // Nothing contacts GitHub until the user opts in
+	}
+	if config.SyncThrottleDelayMs <= 0 {
+		config.SyncThrottleDelayMs = defaultSyncThrottleDelayMs
+	}
+	if config.SyncThrottleBatchSize <= 0 {
+		config.SyncThrottleBatchSize = defaultSyncThrottleBatchSize
+	}
+	if config.TranscriptionModel == "" {
+		config.TranscriptionModel = defaultTranscriptionModel
+	}
+	if config.TranscriptionMaxSizeMB <= 0 {
+		config.TranscriptionMaxSizeMB = defaultTranscriptionMaxSizeMB
+	}
+	if config.TranscriptionMaxMinutes <= 0 {
+		config.TranscriptionMaxMinutes = defaultTranscriptionMaxMinutes
+	}
+	applyLoggingDefaults(config)
+}
+
+// LogFilePath is where subsystems set to log to a file write, in dataDir unless configured
+func LogFilePath(config *Config, dataDir string) string {
+	if config.LogFilePath != "" {
+		return config.LogFilePath
+	}
+	return filepath.Join(dataDir, defaultLogFileName)
+}
+
+// applyLoggingDefaults lets subsystems without valid settings log everything to the console, as
+// the app did before logging was configurable
+func applyLoggingDefaults(config *Config) {
+	if config.Logging == nil {
+		config.Logging = make(map[string]LogSettings)
+	}
+	for _, subsystem := range LogSubsystems {
+		if _, err := ParseLogLevel(config.Logging[subsystem].Level); err != nil {
+			config.Logging[subsystem] = LogSettings{Level: LogLevelDebug.String(), Console: true}
+		}
+	}
 }
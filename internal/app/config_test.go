@@ -0,0 +1,132 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveConfigKeepsBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), configFileName)
+	logger := NewLogger(false)
+
+	saveConfigFile(path, &Config{Model: "first"}, logger)
+	saveConfigFile(path, &Config{Model: "second"}, logger)
+
+	current, err := readConfigFile(path)
+	if err != nil || current.Model != "second" {
+		t.Fatalf("Expected current config to be saved, got %+v, %v", current, err)
+	}
+	backup, err := readConfigFile(path + configBackupSuffix)
+	if err != nil || backup.Model != "first" {
+		t.Fatalf("Expected previous config as backup, got %+v, %v", backup, err)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 2 {
+		t.Errorf("Expected only the config and its backup, found %d files", len(entries))
+	}
+}
+
+func TestLoadConfigRecovery(t *testing.T) {
+	tests := []struct {
+		name        string
+		current     string // Empty means the file doesn't exist
+		backup      string
+		wantPrompt  string
+		wantCorrupt bool
+	}{
+		{
+			name:       "valid config",
+			current:    `{"system_prompt": "mine"}`,
+			backup:     `{"system_prompt": "older"}`,
+			wantPrompt: "mine",
+		},
+		{
+			name:        "truncated config restores backup",
+			current:     `{"system_prompt": "mi`,
+			backup:      `{"system_prompt": "older"}`,
+			wantPrompt:  "older",
+			wantCorrupt: true,
+		},
+		{
+			name:       "missing config restores backup",
+			backup:     `{"system_prompt": "older"}`,
+			wantPrompt: "older",
+		},
+		{
+			name:        "no usable backup falls back to defaults",
+			current:     `not json`,
+			wantPrompt:  defaultSystemPrompt,
+			wantCorrupt: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), configFileName)
+			if tt.current != "" {
+				os.WriteFile(path, []byte(tt.current), 0644)
+			}
+			if tt.backup != "" {
+				os.WriteFile(path+configBackupSuffix, []byte(tt.backup), 0644)
+			}
+
+			config := loadConfigFile(path, NewLogger(false))
+			if config.SystemPrompt != tt.wantPrompt {
+				t.Errorf("SystemPrompt = %q, want %q", config.SystemPrompt, tt.wantPrompt)
+			}
+			if config.Model == "" {
+				t.Error("Expected defaults to fill in missing fields")
+			}
+
+			if _, err := readConfigFile(path); err != nil {
+				t.Errorf("Expected a readable config after loading: %v", err)
+			}
+			_, err := os.Stat(path + configCorruptSuffix)
+			if gotCorrupt := err == nil; gotCorrupt != tt.wantCorrupt {
+				t.Errorf("Damaged config kept = %v, want %v", gotCorrupt, tt.wantCorrupt)
+			}
+			if tt.wantCorrupt && tt.backup != "" {
+				if backup, err := readConfigFile(path + configBackupSuffix); err != nil || backup.SystemPrompt != "older" {
+					t.Errorf("Expected the backup to survive recovery, got %+v, %v", backup, err)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateChecksAreOffByDefault(t *testing.T) {
+	logger := NewLogger(false)
+	fresh := loadConfigFile(filepath.Join(t.TempDir(), configFileName), logger)
+
+	// A config saved before update checks existed has no update mode yet
+	path := filepath.Join(t.TempDir(), configFileName)
+	if err := os.WriteFile(path, []byte(`{"system_prompt": "mine"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	older := loadConfigFile(path, logger)
+
+	for name, config := range map[string]*Config{"new config": fresh, "older config": older} {
+		if config.UpdateMode != UpdateModeOff || NewUpdateChecker(config, "1.0.0", "", logger).Enabled() {
+			t.Errorf("%s: update mode %q, want update checks off", name, config.UpdateMode)
+		}
+	}
+}
+
+func TestOlderConfigsKeepSequentialMoves(t *testing.T) {
+	logger := NewLogger(false)
+	fresh := loadConfigFile(filepath.Join(t.TempDir(), configFileName), logger)
+	if fresh.ParallelMoves != defaultParallelMoves {
+		t.Errorf("new config: parallel moves %d, want %d", fresh.ParallelMoves, defaultParallelMoves)
+	}
+
+	// A config saved before parallel moves existed executed one move at a time
+	path := filepath.Join(t.TempDir(), configFileName)
+	if err := os.WriteFile(path, []byte(`{"system_prompt": "mine"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if older := loadConfigFile(path, logger); older.ParallelMoves != 1 {
+		t.Errorf("older config: parallel moves %d, want 1", older.ParallelMoves)
+	}
+}
@@ -0,0 +1,233 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Correction kinds
+const (
+	CorrectionRejected = "rejected" // The plan was rejected before it ran
+	CorrectionReverted = "reverted" // The move ran and was rolled back
+)
+
+const (
+	// maxCorrectionsPerDirectory keeps the oldest corrections from crowding the prompt forever
+	maxCorrectionsPerDirectory = 100
+	// maxCorrectionsInPrompt bounds how many individual moves are listed as examples
+	maxCorrectionsInPrompt = 30
+	// correctionRuleThreshold is how many corrected moves out of one folder make it a rule
+	correctionRuleThreshold = 3
+)
+
+// Correction is a move the user turned down in a directory, remembered as a negative example
+type Correction struct {
+	From string    `json:"from"` // Relative to the directory, with forward slashes
+	To   string    `json:"to"`
+	Kind string    `json:"kind"`
+	At   time.Time `json:"at"`
+}
+
+// CorrectionStore remembers rejected and reverted moves per directory so later analyses of the
+// same directory can steer the model away from them
+type CorrectionStore struct {
+	path   string
+	logger *Logger
+
+	mu          sync.Mutex
+	corrections map[string][]Correction // Directory -> corrections, oldest first
+}
+
+// NewCorrectionStore creates a store persisted to path (empty keeps corrections in memory only)
+func NewCorrectionStore(path string, logger *Logger) *CorrectionStore {
+	s := &CorrectionStore{
+		path:        path,
+		logger:      logger,
+		corrections: make(map[string][]Correction),
+	}
+	s.load()
+	return s
+}
+
+// Record remembers operations in basePath that the user rejected or reverted
+func (s *CorrectionStore) Record(basePath string, operations []FileOperation, kind string) {
+	if s == nil || len(operations) == 0 {
+		return
+	}
+
+	key := directoryKey(basePath)
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing := s.corrections[key]
+	for _, op := range operations {
+		correction := Correction{From: relativeSlashPath(basePath, op.From), To: relativeSlashPath(basePath, op.To), Kind: kind, At: now}
+		// A move corrected again replaces its older entry
+		for i, c := range existing {
+			if c.From == correction.From && c.To == correction.To {
+				existing = append(existing[:i], existing[i+1:]...)
+				break
+			}
+		}
+		existing = append(existing, correction)
+	}
+	if len(existing) > maxCorrectionsPerDirectory {
+		existing = existing[len(existing)-maxCorrectionsPerDirectory:]
+	}
+	s.corrections[key] = existing
+
+	s.logger.Info("Remembered %d %s operations for %s", len(operations), kind, basePath)
+	if err := s.saveLocked(); err != nil {
+		s.logger.Error("%v", err)
+	}
+}
+
+// List returns the corrections for basePath, oldest first
+func (s *CorrectionStore) List(basePath string) []Correction {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	corrections := s.corrections[directoryKey(basePath)]
+	result := make([]Correction, len(corrections))
+	copy(result, corrections)
+	return result
+}
+
+// Clear forgets the corrections for basePath
+func (s *CorrectionStore) Clear(basePath string) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.corrections, directoryKey(basePath))
+	return s.saveLocked()
+}
+
+// Purge forgets corrections whose source or destination matches and returns how many were removed
+func (s *CorrectionStore) Purge(match func(path string) bool) (int, error) {
+	if s == nil {
+		return 0, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for key, corrections := range s.corrections {
+		if match(key) {
+			removed += len(corrections)
+			delete(s.corrections, key)
+			continue
+		}
+		kept := corrections[:0]
+		for _, c := range corrections {
+			from, _ := JoinStoragePath(key, c.From)
+			to, _ := JoinStoragePath(key, c.To)
+			if match(from) || match(to) {
+				removed++
+				continue
+			}
+			kept = append(kept, c)
+		}
+		if len(kept) == 0 {
+			delete(s.corrections, key)
+		} else {
+			s.corrections[key] = kept
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, s.saveLocked()
+}
+
+// PromptContext describes past corrections for basePath as instructions for the model. Folders
+// the user kept pulling files back into become rules; the most recent moves are listed as
+// examples. It returns "" when there is nothing to say.
+func (s *CorrectionStore) PromptContext(basePath string) string {
+	corrections := s.List(basePath)
+	if len(corrections) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n\nThe user turned down these moves in this directory before. Do not suggest them again, and avoid similar ones.")
+
+	bySource := make(map[string]int)
+	for _, c := range corrections {
+		if dir := path.Dir(c.From); dir != "." {
+			bySource[dir]++
+		}
+	}
+	var rules []string
+	for dir, count := range bySource {
+		if count >= correctionRuleThreshold {
+			rules = append(rules, dir)
+		}
+	}
+	sort.Strings(rules)
+	for _, dir := range rules {
+		fmt.Fprintf(&sb, "\n- Never move anything out of %s/ (%d such moves were turned down)", dir, bySource[dir])
+	}
+
+	if len(corrections) > maxCorrectionsInPrompt {
+		corrections = corrections[len(corrections)-maxCorrectionsInPrompt:]
+	}
+	for _, c := range corrections {
+		fmt.Fprintf(&sb, "\n- %s -> %s (%s)", c.From, c.To, c.Kind)
+	}
+	return sb.String()
+}
+
+func directoryKey(basePath string) string {
+	if IsObjectStoragePath(basePath) {
+		return strings.TrimSuffix(basePath, "/")
+	}
+	return filepath.Clean(basePath)
+}
+
+func relativeSlashPath(basePath, p string) string {
+	return filepath.ToSlash(relativeToBase(basePath, p))
+}
+
+func (s *CorrectionStore) load() {
+	if s.path == "" {
+		return
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.logger.Error("Failed to read past corrections: %v", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &s.corrections); err != nil {
+		s.logger.Error("Failed to parse past corrections, starting without them: %v", err)
+		s.corrections = make(map[string][]Correction)
+	}
+}
+
+// saveLocked persists the corrections. Caller must hold s.mu.
+func (s *CorrectionStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.corrections, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save past corrections: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCorrectionStorePersistsAndDeduplicates(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "corrections.json")
+	base := filepath.Join(t.TempDir(), "inbox")
+	logger := NewLogger(false)
+
+	store := NewCorrectionStore(storePath, logger)
+	op := FileOperation{From: filepath.Join(base, "Scans", "a.pdf"), To: filepath.Join(base, "Docs", "a.pdf")}
+	store.Record(base, []FileOperation{op}, CorrectionRejected)
+	store.Record(base+string(filepath.Separator), []FileOperation{op}, CorrectionReverted)
+
+	reloaded := NewCorrectionStore(storePath, logger)
+	corrections := reloaded.List(base)
+	if len(corrections) != 1 {
+		t.Fatalf("got %d corrections, want 1: %+v", len(corrections), corrections)
+	}
+	if corrections[0].From != "Scans/a.pdf" || corrections[0].To != "Docs/a.pdf" || corrections[0].Kind != CorrectionReverted {
+		t.Errorf("unexpected correction: %+v", corrections[0])
+	}
+
+	if err := reloaded.Clear(base); err != nil {
+		t.Fatal(err)
+	}
+	if got := NewCorrectionStore(storePath, logger).List(base); len(got) != 0 {
+		t.Errorf("Expected no corrections after Clear, got %+v", got)
+	}
+}
+
+func TestCorrectionPromptContext(t *testing.T) {
+	base := t.TempDir()
+	store := NewCorrectionStore("", NewLogger(false))
+
+	if got := store.PromptContext(base); got != "" {
+		t.Errorf("Expected no context without corrections, got %q", got)
+	}
+
+	var ops []FileOperation
+	for _, name := range []string{"a.pdf", "b.pdf", "c.pdf"} {
+		ops = append(ops, FileOperation{From: filepath.Join(base, "Scans", name), To: filepath.Join(base, "Docs", name)})
+	}
+	ops = append(ops, FileOperation{From: filepath.Join(base, "notes.txt"), To: filepath.Join(base, "Text", "notes.txt")})
+	store.Record(base, ops, CorrectionReverted)
+
+	context := store.PromptContext(base)
+	if !strings.Contains(context, "Never move anything out of Scans/") {
+		t.Errorf("Expected a rule for Scans, got %q", context)
+	}
+	if !strings.Contains(context, "notes.txt -> Text/notes.txt (reverted)") {
+		t.Errorf("Expected the individual move as an example, got %q", context)
+	}
+	if strings.Contains(context, "out of ./") {
+		t.Errorf("Root-level files should not become a rule, got %q", context)
+	}
+
+	var nilStore *CorrectionStore
+	if nilStore.PromptContext(base) != "" {
+		t.Error("Expected a nil store to add nothing")
+	}
+}
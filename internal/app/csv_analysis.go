@@ -0,0 +1,143 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	csvSampleRows    = 20 // Data rows sent to the LLM
+	csvMaxCellLength = 60 // Longer cells are truncated in the sample
+	csvMaxColumns    = 50 // Wider tables only list their first columns
+	csvSniffBytes    = 4096
+)
+
+// sniffCSVDelimiter picks the most frequent candidate delimiter in the first line
+func sniffCSVDelimiter(firstLine string) rune {
+	best, bestCount := ',', 0
+	for _, candidate := range []rune{',', ';', '\t', '|'} {
+		if count := strings.Count(firstLine, string(candidate)); count > bestCount {
+			best, bestCount = candidate, count
+		}
+	}
+	return best
+}
+
+// csvSample is the header and first rows of a delimited file
+type csvSample struct {
+	Header        []string
+	Rows          [][]string
+	Delimiter     rune
+	EstimatedRows int64 // Extrapolated from file size; exact when the whole file was read
+	Complete      bool  // True if the sample covers the whole file
+}
+
+// readCSVSample streams the header and up to maxRows rows, never reading the whole file
+func readCSVSample(filePath string, maxRows int) (*csvSample, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(file)
+	sample := &csvSample{Delimiter: '\t'}
+	if strings.ToLower(filepath.Ext(filePath)) != ".tsv" {
+		peek, _ := reader.Peek(csvSniffBytes)
+		firstLine, _, _ := strings.Cut(string(peek), "\n")
+		sample.Delimiter = sniffCSVDelimiter(firstLine)
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.Comma = sample.Delimiter
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+
+	sample.Header, err = csvReader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("file is empty")
+		}
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	sample.Header[0] = strings.TrimPrefix(sample.Header[0], "\ufeff") // UTF-8 BOM
+
+	for len(sample.Rows) < maxRows {
+		row, err := csvReader.Read()
+		if err == io.EOF {
+			sample.Complete = true
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read row %d: %w", len(sample.Rows)+1, err)
+		}
+		sample.Rows = append(sample.Rows, row)
+	}
+
+	sample.EstimatedRows = int64(len(sample.Rows))
+	if offset := csvReader.InputOffset(); !sample.Complete && offset > 0 && len(sample.Rows) > 0 {
+		// Extrapolate from the average size of the rows read so far (header included)
+		bytesPerRow := float64(offset) / float64(len(sample.Rows)+1)
+		sample.EstimatedRows = int64(float64(info.Size()) / bytesPerRow)
+	}
+	return sample, nil
+}
+
+// formatCSVSample renders a sample as compact text for the LLM
+func formatCSVSample(fileName string, sample *csvSample) string {
+	var builder strings.Builder
+
+	rows := fmt.Sprintf("%d", sample.EstimatedRows)
+	if !sample.Complete {
+		rows = fmt.Sprintf("~%d (estimated)", sample.EstimatedRows)
+	}
+	header := sample.Header
+	if len(header) > csvMaxColumns {
+		header = header[:csvMaxColumns]
+	}
+
+	builder.WriteString(fmt.Sprintf("Delimited data file: %s\nColumns: %d\nRows: %s\n\n", fileName, len(sample.Header), rows))
+	builder.WriteString("Header: " + strings.Join(header, " | ") + "\n\nSample rows:\n")
+	for _, row := range sample.Rows {
+		cells := make([]string, 0, min(len(row), csvMaxColumns))
+		for i, cell := range row {
+			if i >= csvMaxColumns {
+				break
+			}
+			if runes := []rune(cell); len(runes) > csvMaxCellLength {
+				cell = string(runes[:csvMaxCellLength]) + "..."
+			}
+			cells = append(cells, cell)
+		}
+		builder.WriteString(strings.Join(cells, " | ") + "\n")
+	}
+	return builder.String()
+}
+
+// analyzeCSVFile describes a CSV/TSV dataset from its header and a sample of rows
+func (das *DeepAnalysisService) analyzeCSVFile(ctx context.Context, filePath string) (string, error) {
+	sample, err := readCSVSample(filePath, csvSampleRows)
+	if err != nil {
+		return "", fmt.Errorf("failed to read delimited file: %w", err)
+	}
+
+	content := formatCSVSample(filepath.Base(filePath), sample)
+	das.logger.Debug("Sampled %d rows of %s (%d columns)", len(sample.Rows), filePath, len(sample.Header))
+
+	description, err := das.analyzeContentWithLLM(ctx, content, "csv", filepath.Base(filePath))
+	if err != nil {
+		return "", fmt.Errorf("CSV analysis failed: %w", err)
+	}
+	return description, nil
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadCSVSample(t *testing.T) {
+	dir := t.TempDir()
+
+	var builder strings.Builder
+	builder.WriteString("\ufeffid;name;city\n")
+	for i := 0; i < 1000; i++ {
+		builder.WriteString(fmt.Sprintf("%d;person %d;\"Paris; France\"\n", i, i))
+	}
+	large := filepath.Join(dir, "people.csv")
+	if err := os.WriteFile(large, []byte(builder.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sample, err := readCSVSample(large, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sample.Delimiter != ';' {
+		t.Errorf("expected ';' delimiter, got %q", sample.Delimiter)
+	}
+	if strings.Join(sample.Header, ",") != "id,name,city" {
+		t.Errorf("unexpected header: %v", sample.Header)
+	}
+	if len(sample.Rows) != 5 || sample.Rows[0][2] != "Paris; France" {
+		t.Errorf("unexpected rows: %v", sample.Rows)
+	}
+	if sample.Complete || sample.EstimatedRows < 500 || sample.EstimatedRows > 1500 {
+		t.Errorf("expected an estimate near 1000 rows, got %d (complete: %v)", sample.EstimatedRows, sample.Complete)
+	}
+
+	small := filepath.Join(dir, "small.tsv")
+	if err := os.WriteFile(small, []byte("a,b\tc\n1\t2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sample, err = readCSVSample(small, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sample.Header) != 2 || !sample.Complete || sample.EstimatedRows != 1 {
+		t.Errorf("expected tab-delimited 2 columns, 1 row, got %+v", sample)
+	}
+}
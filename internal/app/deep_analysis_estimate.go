@@ -0,0 +1,82 @@
+package app
+
+import "os"
+
+// Rough input sizes of a deep analysis request, in tokens, on top of the system prompt
+const (
+	estimatedImageTokens      = 1000 // Vision models bill a downscaled image at about this much
+	estimatedRequestOverhead  = 50   // File name, content type and instructions
+	estimatedTextContentLimit = 2000 / 4
+	estimatedLongContentLimit = 8000 / 4 // Documents, PDFs, spreadsheets and transcripts
+)
+
+// DeepAnalysisEstimate is the expected size of indexing a list of files, shown before deep
+// analysis starts. Token counts are upper bounds: content is truncated before it is sent.
+type DeepAnalysisEstimate struct {
+	Files        int
+	Calls        int // Files sent to the model; others get a description from their metadata
+	Bytes        int64
+	InputTokens  int
+	OutputTokens int
+	Cost         float64 // USD at the configured prices; 0 when no prices are set
+}
+
+// EstimateDeepAnalysis estimates the model calls, tokens and cost of analyzing files
+func EstimateDeepAnalysis(files []string, config *Config) DeepAnalysisEstimate {
+	estimate := DeepAnalysisEstimate{Files: len(files)}
+
+	outputTokens := 150
+	if config.DescriptionMaxWords > 0 {
+		outputTokens = config.DescriptionMaxWords * tokensPerDescriptionWord
+	}
+
+	for _, filePath := range files {
+		var size int64
+		if info, err := os.Stat(filePath); err == nil {
+			size = info.Size()
+		}
+		estimate.Bytes += size
+
+		var contentTokens int
+		var prompt string
+		switch DetermineFileType(filePath) {
+		case "text", "code":
+			contentTokens = min(estimateTokens(int(size)), estimatedTextContentLimit)
+			prompt = config.TextAnalysisPrompt
+		case "pdf":
+			contentTokens = min(estimateTokens(int(size)), estimatedLongContentLimit)
+			if config.PDFPageImages == PDFPageImagesAlways {
+				contentTokens = estimatedImageTokens * max(config.PDFPageImageCount, 1)
+			}
+			prompt = config.PDFAnalysisPrompt
+		case "document", "excel", "csv", "powerpoint", "notebook":
+			contentTokens = min(estimateTokens(int(size)), estimatedLongContentLimit)
+			prompt = config.TextAnalysisPrompt
+		case "image":
+			contentTokens = estimatedImageTokens
+			prompt = config.ImageAnalysisPrompt
+		case "audio", "video":
+			if !config.TranscribeAudio {
+				continue
+			}
+			contentTokens = estimatedLongContentLimit
+			prompt = config.TextAnalysisPrompt
+		default:
+			continue
+		}
+
+		estimate.Calls++
+		estimate.InputTokens += estimateTokens(len(prompt)) + estimatedRequestOverhead + contentTokens
+		estimate.OutputTokens += outputTokens
+	}
+
+	estimate.Cost = (float64(estimate.InputTokens)*config.PriceInputPerMillion +
+		float64(estimate.OutputTokens)*config.PriceOutputPerMillion) / 1_000_000
+	return estimate
+}
+
+// NeedsConfirmation reports whether the estimate is over the configured threshold for asking
+// before deep analysis starts
+func (e DeepAnalysisEstimate) NeedsConfirmation(config *Config) bool {
+	return config.DeepAnalysisConfirmCalls > 0 && e.Calls > config.DeepAnalysisConfirmCalls
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEstimateDeepAnalysis(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]int{
+		"notes.txt":  400,   // 101 tokens of content
+		"long.md":    40000, // Truncated to 500 tokens
+		"photo.jpg":  1 << 20,
+		"song.mp3":   1 << 20, // Not sent unless audio is transcribed
+		"data.bin":   10,      // Described from metadata only
+		"report.pdf": 100000,  // Truncated to 2000 tokens
+	}
+	var paths []string
+	for name, size := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	config := &Config{
+		TextAnalysisPrompt:    strings.Repeat("a", 400), // 101 tokens
+		PDFAnalysisPrompt:     strings.Repeat("p", 400),
+		ImageAnalysisPrompt:   strings.Repeat("i", 400),
+		PriceInputPerMillion:  1,
+		PriceOutputPerMillion: 2,
+	}
+	estimate := EstimateDeepAnalysis(paths, config)
+
+	perCall := 101 + estimatedRequestOverhead
+	wantInput := 4*perCall + 101 + 500 + estimatedImageTokens + 2000
+	if estimate.Files != 6 || estimate.Calls != 4 {
+		t.Errorf("expected 6 files and 4 calls, got %d and %d", estimate.Files, estimate.Calls)
+	}
+	if estimate.InputTokens != wantInput || estimate.OutputTokens != 4*150 {
+		t.Errorf("expected %d input and %d output tokens, got %d and %d", wantInput, 4*150, estimate.InputTokens, estimate.OutputTokens)
+	}
+	if want := float64(wantInput+2*4*150) / 1_000_000; estimate.Cost != want {
+		t.Errorf("expected cost %f, got %f", want, estimate.Cost)
+	}
+
+	config.TranscribeAudio = true
+	config.DescriptionMaxWords = 10
+	estimate = EstimateDeepAnalysis(paths, config)
+	if estimate.Calls != 5 || estimate.OutputTokens != 5*10*tokensPerDescriptionWord {
+		t.Errorf("expected 5 calls with shorter descriptions, got %d calls and %d output tokens", estimate.Calls, estimate.OutputTokens)
+	}
+
+	tests := []struct {
+		threshold int
+		want      bool
+	}{
+		{0, false},
+		{4, true},
+		{5, false},
+	}
+	for _, tt := range tests {
+		config.DeepAnalysisConfirmCalls = tt.threshold
+		if got := estimate.NeedsConfirmation(config); got != tt.want {
+			t.Errorf("threshold %d: expected %v, got %v", tt.threshold, tt.want, got)
+		}
+	}
+}
+
+func TestAnalyzeDirectory_DeclinedDeepAnalysisSkipsIndexing(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logger := NewLogger(false)
+	indexService := NewIndexService(logger)
+	if err := indexService.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer indexService.Close()
+
+	for _, confirm := range []bool{false, true} {
+		analyzer := &scriptedAnalyzer{}
+		validator := NewValidator()
+		orchestrator := NewOrchestrator(&stubAIService{}, NewFileService(validator, logger), validator, logger,
+			NewIndexDirectoryOrchestrator(indexService, analyzer, logger), indexService, NewHookRunner(&Config{}, logger))
+
+		var asked []string
+		result := orchestrator.AnalyzeDirectory(AnalysisRequest{
+			DirectoryPath:      dir,
+			UserPrompt:         "Sort",
+			MaxDepth:           1,
+			EnableDeepAnalysis: true,
+			ConfirmDeepAnalysis: func(files []string) bool {
+				asked = files
+				return confirm
+			},
+		}, nil)
+		if result.Error != nil {
+			t.Fatal(result.Error)
+		}
+
+		if len(asked) != 2 {
+			t.Errorf("confirm %v: expected to be asked about 2 files, got %v", confirm, asked)
+		}
+		if want := map[bool]int{false: 0, true: 2}[confirm]; len(analyzer.analyzed) != want {
+			t.Errorf("confirm %v: expected %d files analyzed, got %v", confirm, want, analyzer.analyzed)
+		}
+	}
+}
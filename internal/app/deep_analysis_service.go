@@ -2,14 +2,18 @@ package app
 
 import (
 	"archive/zip"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/gen2brain/go-fitz"
 	"github.com/nguyenthenguyen/docx"
@@ -17,13 +21,15 @@ import (
 )
 
 const (
-	maxTextFileSize       = 50 * 1024        // 50KB for text files
-	maxImageFileSize      = 5 * 1024 * 1024  // 5MB for images
-	maxPDFFileSize        = 50 * 1024 * 1024 // 50MB for PDFs
-	maxExcelFileSize      = 50 * 1024 * 1024 // 50MB for Excel files
-	maxDocFileSize        = 50 * 1024 * 1024 // 50MB for Word documents
-	maxPowerPointFileSize = 50 * 1024 * 1024 // 50MB for PowerPoint files
-	maxExcelRows          = 100              // Max rows per sheet to process
+	maxTextFileSize       = 50 * 1024         // 50KB for text files
+	maxImageFileSize      = 200 * 1024 * 1024 // 200MB for images, which are downscaled before upload
+	maxPDFFileSize        = 50 * 1024 * 1024  // 50MB for PDFs
+	maxExcelFileSize      = 50 * 1024 * 1024  // 50MB for Excel files
+	maxDocFileSize        = 50 * 1024 * 1024  // 50MB for Word documents
+	maxPowerPointFileSize = 50 * 1024 * 1024  // 50MB for PowerPoint files
+	maxExcelRows          = 100               // Max rows per sheet to process
+
+	tokensPerDescriptionWord = 4 // max_tokens headroom per configured description word
 )
 
 // DeepAnalysisService handles multimodal file analysis
@@ -32,6 +38,11 @@ type DeepAnalysisService struct {
 	httpClient   *HTTPClient
 	indexService IndexService
 	logger       *Logger
+
+	pluginMu      sync.Mutex
+	plugins       []AnalyzerPlugin // Registered in-process plugins
+	configPlugins []AnalyzerPlugin // Parsed from config.AnalyzerPlugins
+	pluginSpec    string           // The config text configPlugins was parsed from
 }
 
 func NewDeepAnalysisService(config *Config, httpClient *HTTPClient, indexService IndexService, logger *Logger) *DeepAnalysisService {
@@ -43,30 +54,119 @@ func NewDeepAnalysisService(config *Config, httpClient *HTTPClient, indexService
 	}
 }
 
+// RegisterPlugin adds an in-process analyzer plugin. Plugins take precedence over built-in analyzers.
+func (das *DeepAnalysisService) RegisterPlugin(plugin AnalyzerPlugin) {
+	das.pluginMu.Lock()
+	defer das.pluginMu.Unlock()
+	das.plugins = append(das.plugins, plugin)
+}
+
+// findPlugin returns the plugin handling filePath's extension, re-reading the configured
+// external plugins whenever the config text has changed
+func (das *DeepAnalysisService) findPlugin(filePath string) AnalyzerPlugin {
+	das.pluginMu.Lock()
+	defer das.pluginMu.Unlock()
+
+	if das.config.AnalyzerPlugins != das.pluginSpec {
+		plugins, err := ParseAnalyzerPlugins(das.config.AnalyzerPlugins)
+		if err != nil {
+			das.logger.Error("Invalid analyzer plugin configuration: %v", err)
+		}
+		das.configPlugins = plugins
+		das.pluginSpec = das.config.AnalyzerPlugins
+	}
+
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if ext == "" {
+		return nil
+	}
+	for _, group := range [][]AnalyzerPlugin{das.plugins, das.configPlugins} {
+		for _, plugin := range group {
+			for _, pluginExt := range plugin.Extensions() {
+				if pluginExt == ext {
+					return plugin
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// analyzeWithPlugin delegates to a plugin, summarizing extracted content with the LLM when needed
+func (das *DeepAnalysisService) analyzeWithPlugin(ctx context.Context, plugin AnalyzerPlugin, filePath string) (string, error) {
+	das.logger.Debug("Analyzing %s with plugin %s", filePath, plugin.Name())
+
+	result, err := plugin.Analyze(filePath)
+	if err != nil {
+		return "", fmt.Errorf("plugin %s failed: %w", plugin.Name(), err)
+	}
+
+	if description := strings.TrimSpace(result.Description); description != "" {
+		return description, nil
+	}
+
+	contentType := strings.TrimPrefix(strings.ToLower(filepath.Ext(filePath)), ".")
+	description, err := das.analyzeContentWithLLM(ctx, result.Content, contentType, filepath.Base(filePath))
+	if err != nil {
+		return "", fmt.Errorf("plugin content analysis failed: %w", err)
+	}
+	return description, nil
+}
+
 // AnalyzeFile analyzes a single file and returns a description
-func (das *DeepAnalysisService) AnalyzeFile(filePath string) (string, error) {
+func (das *DeepAnalysisService) AnalyzeFile(ctx context.Context, filePath string) (string, error) {
 	fileType := DetermineFileType(filePath)
+	if !das.config.AnalysisTypeEnabled(fileType) {
+		return "", fmt.Errorf("%w: %s", ErrAnalysisDisabled, fileType)
+	}
+
+	// Reading a placeholder makes the sync client fetch the whole file, so only do it on purpose
+	if info, err := os.Stat(filePath); err == nil && IsCloudPlaceholder(filePath, info) {
+		if !das.config.DownloadPlaceholders || isICloudStub(filePath) {
+			return "", fmt.Errorf("%w: %s", ErrCloudPlaceholder, filePath)
+		}
+		das.logger.Info("Downloading online-only file %s (%d bytes) for analysis", filePath, info.Size())
+	}
+
+	if plugin := das.findPlugin(filePath); plugin != nil {
+		return das.analyzeWithPlugin(ctx, plugin, filePath)
+	}
+
+	if !das.config.IgnoreEmbeddedDescriptions {
+		if description, source := das.embeddedDescription(filePath, fileType); description != "" {
+			das.logger.Info("Using %s description of %s instead of analyzing it", source, filePath)
+			return description, nil
+		}
+	}
 
 	switch fileType {
-	case "text":
-		return das.analyzeTextFile(filePath)
+	case "text", "code":
+		return das.analyzeTextFile(ctx, filePath)
 	case "image":
-		return das.analyzeImageFile(filePath)
+		return das.analyzeImageFile(ctx, filePath)
 	case "pdf":
-		return das.analyzePDFFile(filePath)
+		return das.analyzePDFFile(ctx, filePath)
 	case "excel":
-		return das.analyzeExcelFile(filePath)
+		return das.analyzeExcelFile(ctx, filePath)
+	case "csv":
+		return das.analyzeCSVFile(ctx, filePath)
+	case "notebook":
+		return das.analyzeNotebookFile(ctx, filePath)
+	case "video":
+		return das.analyzeVideoFile(ctx, filePath)
+	case "audio":
+		return das.analyzeAudioFile(ctx, filePath)
 	case "document":
-		return das.analyzeDocFile(filePath)
+		return das.analyzeDocFile(ctx, filePath)
 	case "powerpoint":
-		return das.analyzePowerPointFile(filePath)
+		return das.analyzePowerPointFile(ctx, filePath)
 	default:
-		return das.analyzeGenericFile(filePath)
+		return das.analyzeGenericFile(ctx, filePath)
 	}
 }
 
 // analyzeTextFile reads and analyzes text content
-func (das *DeepAnalysisService) analyzeTextFile(filePath string) (string, error) {
+func (das *DeepAnalysisService) analyzeTextFile(ctx context.Context, filePath string) (string, error) {
 	info, err := os.Stat(filePath)
 	if err != nil {
 		return "", err
@@ -82,8 +182,18 @@ func (das *DeepAnalysisService) analyzeTextFile(filePath string) (string, error)
 		return "", err
 	}
 
+	// Binary data behind a text extension would only get a made-up description
+	text, ok := decodeText(content)
+	if !ok {
+		das.logger.Debug("Not sending %s to the model: its content is binary", filePath)
+		return fmt.Sprintf("binary file: %s (%d bytes)", filepath.Base(filePath), info.Size()), nil
+	}
+	if ext := strings.ToLower(filepath.Ext(filePath)); ext == ".md" || ext == ".markdown" {
+		text = formatMarkdownForAnalysis(text)
+	}
+
 	// Use LLM to analyze the text content
-	description, err := das.analyzeContentWithLLM(string(content), "text", filepath.Base(filePath))
+	description, err := das.analyzeContentWithLLM(ctx, text, "text", filepath.Base(filePath))
 	if err != nil {
 		das.logger.Debug("Failed to analyze text file %s: %v", filePath, err)
 		return "", fmt.Errorf("text analysis failed: %w", err)
@@ -93,7 +203,7 @@ func (das *DeepAnalysisService) analyzeTextFile(filePath string) (string, error)
 }
 
 // analyzeImageFile analyzes image using multimodal LLM
-func (das *DeepAnalysisService) analyzeImageFile(filePath string) (string, error) {
+func (das *DeepAnalysisService) analyzeImageFile(ctx context.Context, filePath string) (string, error) {
 	info, err := os.Stat(filePath)
 	if err != nil {
 		return "", err
@@ -104,19 +214,22 @@ func (das *DeepAnalysisService) analyzeImageFile(filePath string) (string, error
 		return "", fmt.Errorf("image file too large (%d bytes)", info.Size())
 	}
 
-	// Read and encode image to base64
-	imageData, err := os.ReadFile(filePath)
+	// Convert formats models reject (HEIC, RAW) and downscale large photos, then encode to base64
+	imageData, err := prepareImage(filePath, das.imageMaxDimension())
 	if err != nil {
 		return "", err
 	}
 
 	base64Image := base64.StdEncoding.EncodeToString(imageData)
 
-	// Determine MIME type
+	// Determine MIME type, trusting the content over a wrong or missing extension
 	mimeType := das.getMimeType(filePath)
+	if sniffed := http.DetectContentType(imageData); strings.HasPrefix(sniffed, "image/") {
+		mimeType = sniffed
+	}
 
 	// Use multimodal LLM to analyze the image
-	description, err := das.analyzeImageWithLLM(base64Image, mimeType, filepath.Base(filePath))
+	description, err := das.analyzeImageWithLLM(ctx, base64Image, mimeType, filepath.Base(filePath))
 	if err != nil {
 		das.logger.Debug("Failed to analyze image file %s: %v", filePath, err)
 		// Return error so the file won't be indexed
@@ -128,7 +241,7 @@ func (das *DeepAnalysisService) analyzeImageFile(filePath string) (string, error
 }
 
 // analyzeDocFile extracts text from Word documents and analyzes them
-func (das *DeepAnalysisService) analyzeDocFile(filePath string) (string, error) {
+func (das *DeepAnalysisService) analyzeDocFile(ctx context.Context, filePath string) (string, error) {
 	info, err := os.Stat(filePath)
 	if err != nil {
 		return "", err
@@ -141,11 +254,20 @@ func (das *DeepAnalysisService) analyzeDocFile(filePath string) (string, error)
 
 	ext := strings.ToLower(filepath.Ext(filePath))
 
-	// Only .docx is supported (modern Word format)
-	// .doc (legacy binary format) requires platform-specific tools
+	switch ext {
+	case ".odt":
+		return das.analyzeOpenDocumentFile(ctx, filePath, "word")
+	case ".rtf":
+		return das.analyzeRTFFile(ctx, filePath)
+	}
+
+	// .docx is parsed natively; .doc (legacy binary format) needs an external converter
 	if ext == ".doc" {
+		if strings.TrimSpace(das.config.LegacyConverterCommand) != "" {
+			return das.analyzeLegacyFile(ctx, filePath, "docx", "word")
+		}
 		das.logger.Debug("Legacy .doc format not supported, skipping: %s", filePath)
-		return "", fmt.Errorf("legacy .doc format not supported")
+		return "", fmt.Errorf("legacy .doc format not supported (configure a converter command to analyze it)")
 	}
 
 	// Open .docx file
@@ -167,7 +289,7 @@ func (das *DeepAnalysisService) analyzeDocFile(filePath string) (string, error)
 	}
 
 	// Use LLM to analyze the Word document content
-	description, err := das.analyzeContentWithLLM(text, "word", filepath.Base(filePath))
+	description, err := das.analyzeContentWithLLM(ctx, text, "word", filepath.Base(filePath))
 	if err != nil {
 		das.logger.Debug("Failed to analyze Word document %s: %v", filePath, err)
 		return "", fmt.Errorf("Word document analysis failed: %w", err)
@@ -177,7 +299,7 @@ func (das *DeepAnalysisService) analyzeDocFile(filePath string) (string, error)
 }
 
 // analyzeExcelFile extracts text from Excel sheets and analyzes them
-func (das *DeepAnalysisService) analyzeExcelFile(filePath string) (string, error) {
+func (das *DeepAnalysisService) analyzeExcelFile(ctx context.Context, filePath string) (string, error) {
 	info, err := os.Stat(filePath)
 	if err != nil {
 		return "", err
@@ -188,6 +310,10 @@ func (das *DeepAnalysisService) analyzeExcelFile(filePath string) (string, error
 		return "", fmt.Errorf("Excel file too large (%d bytes)", info.Size())
 	}
 
+	if strings.ToLower(filepath.Ext(filePath)) == ".ods" {
+		return das.analyzeOpenDocumentFile(ctx, filePath, "excel")
+	}
+
 	// Open Excel file
 	f, err := excelize.OpenFile(filePath)
 	if err != nil {
@@ -241,7 +367,7 @@ func (das *DeepAnalysisService) analyzeExcelFile(filePath string) (string, error
 	content := contentBuilder.String()
 
 	// Use LLM to analyze the Excel content
-	description, err := das.analyzeContentWithLLM(content, "excel", filepath.Base(filePath))
+	description, err := das.analyzeContentWithLLM(ctx, content, "excel", filepath.Base(filePath))
 	if err != nil {
 		das.logger.Debug("Failed to analyze Excel file %s: %v", filePath, err)
 		return "", fmt.Errorf("Excel analysis failed: %w", err)
@@ -251,7 +377,7 @@ func (das *DeepAnalysisService) analyzeExcelFile(filePath string) (string, error
 }
 
 // analyzePowerPointFile extracts text from PowerPoint slides and analyzes them
-func (das *DeepAnalysisService) analyzePowerPointFile(filePath string) (string, error) {
+func (das *DeepAnalysisService) analyzePowerPointFile(ctx context.Context, filePath string) (string, error) {
 	info, err := os.Stat(filePath)
 	if err != nil {
 		return "", err
@@ -264,11 +390,17 @@ func (das *DeepAnalysisService) analyzePowerPointFile(filePath string) (string,
 
 	ext := strings.ToLower(filepath.Ext(filePath))
 
-	// Only .pptx is supported (modern PowerPoint format)
-	// .ppt (legacy binary format) requires platform-specific tools
+	if ext == ".odp" {
+		return das.analyzeOpenDocumentFile(ctx, filePath, "powerpoint")
+	}
+
+	// .pptx is parsed natively; .ppt (legacy binary format) needs an external converter
 	if ext == ".ppt" {
+		if strings.TrimSpace(das.config.LegacyConverterCommand) != "" {
+			return das.analyzeLegacyFile(ctx, filePath, "pptx", "powerpoint")
+		}
 		das.logger.Debug("Legacy .ppt format not supported, skipping: %s", filePath)
-		return "", fmt.Errorf("legacy .ppt format not supported")
+		return "", fmt.Errorf("legacy .ppt format not supported (configure a converter command to analyze it)")
 	}
 
 	// Open .pptx file as a ZIP archive
@@ -327,7 +459,7 @@ func (das *DeepAnalysisService) analyzePowerPointFile(filePath string) (string,
 	das.logger.Debug("Total content length being sent to LLM: %d characters", len(content))
 
 	// Use LLM to analyze the PowerPoint content
-	description, err := das.analyzeContentWithLLM(content, "powerpoint", filepath.Base(filePath))
+	description, err := das.analyzeContentWithLLM(ctx, content, "powerpoint", filepath.Base(filePath))
 	if err != nil {
 		das.logger.Debug("Failed to analyze PowerPoint file %s: %v", filePath, err)
 		return "", fmt.Errorf("PowerPoint analysis failed: %w", err)
@@ -337,7 +469,7 @@ func (das *DeepAnalysisService) analyzePowerPointFile(filePath string) (string,
 }
 
 // analyzePDFFile extracts text from PDF and analyzes it
-func (das *DeepAnalysisService) analyzePDFFile(filePath string) (string, error) {
+func (das *DeepAnalysisService) analyzePDFFile(ctx context.Context, filePath string) (string, error) {
 	info, err := os.Stat(filePath)
 	if err != nil {
 		return "", err
@@ -375,6 +507,18 @@ func (das *DeepAnalysisService) analyzePDFFile(filePath string) (string, error)
 
 	extractedText := strings.TrimSpace(textBuilder.String())
 
+	// Slides, scans and posters say more in their pages than in their text
+	if das.wantsPageImages(extractedText, totalPages) {
+		description, err := das.analyzePDFPages(ctx, doc, filePath, extractedText)
+		if err == nil {
+			return description, nil
+		}
+		if extractedText == "" {
+			return "", fmt.Errorf("PDF page image analysis failed (model may not support vision): %w", err)
+		}
+		das.logger.Debug("Analyzing the text of %s instead of its pages: %v", filePath, err)
+	}
+
 	if extractedText == "" {
 		return "", fmt.Errorf("PDF file with %d pages has no extractable text", totalPages)
 	}
@@ -386,7 +530,7 @@ func (das *DeepAnalysisService) analyzePDFFile(filePath string) (string, error)
 		filepath.Base(filePath), totalPages, extractedText)
 
 	// Use LLM to analyze the PDF text content
-	description, err := das.analyzeContentWithLLM(content, "pdf", filepath.Base(filePath))
+	description, err := das.analyzeContentWithLLM(ctx, content, "pdf", filepath.Base(filePath))
 	if err != nil {
 		das.logger.Debug("Failed to analyze PDF file %s: %v", filePath, err)
 		return "", fmt.Errorf("PDF analysis failed: %w", err)
@@ -396,7 +540,7 @@ func (das *DeepAnalysisService) analyzePDFFile(filePath string) (string, error)
 }
 
 // analyzeGenericFile provides basic file information
-func (das *DeepAnalysisService) analyzeGenericFile(filePath string) (string, error) {
+func (das *DeepAnalysisService) analyzeGenericFile(ctx context.Context, filePath string) (string, error) {
 	info, err := os.Stat(filePath)
 	if err != nil {
 		return "", err
@@ -407,17 +551,18 @@ func (das *DeepAnalysisService) analyzeGenericFile(filePath string) (string, err
 }
 
 // analyzeContentWithLLM sends text content to LLM for analysis
-func (das *DeepAnalysisService) analyzeContentWithLLM(content, contentType, fileName string) (string, error) {
+func (das *DeepAnalysisService) analyzeContentWithLLM(ctx context.Context, content, contentType, fileName string) (string, error) {
 	// Use appropriate system prompt based on content type
 	systemPrompt := das.config.TextAnalysisPrompt
 	if contentType == "pdf" {
 		systemPrompt = das.config.PDFAnalysisPrompt
 	}
+	systemPrompt += das.descriptionInstructions()
 
-	// Use larger truncation limit for structured documents (PowerPoint, Excel, Word, PDF)
-	// to give LLM more context
+	// Use larger truncation limit for structured documents (PowerPoint, Excel, Word, PDF, CSV samples,
+	// notebooks, audio/video transcripts) to give LLM more context
 	truncateLimit := 2000
-	if contentType == "powerpoint" || contentType == "excel" || contentType == "word" || contentType == "pdf" {
+	if contentType == "powerpoint" || contentType == "excel" || contentType == "word" || contentType == "pdf" || contentType == "csv" || contentType == "notebook" || contentType == "transcript" {
 		truncateLimit = 8000
 	}
 
@@ -433,7 +578,7 @@ func (das *DeepAnalysisService) analyzeContentWithLLM(content, contentType, file
 			{Role: "system", Content: systemPrompt},
 			{Role: "user", Content: userPrompt},
 		},
-		MaxTokens: 150,
+		MaxTokens: das.descriptionMaxTokens(150),
 		Stream:    false,
 	}
 
@@ -443,7 +588,7 @@ func (das *DeepAnalysisService) analyzeContentWithLLM(content, contentType, file
 		"X-Title":       "VibesAndFolders",
 	}
 
-	body, err := das.httpClient.Post(das.config.Endpoint, headers, reqBody)
+	body, err := das.httpClient.Post(ctx, das.config.Endpoint, headers, reqBody)
 	if err != nil {
 		return "", err
 	}
@@ -468,11 +613,31 @@ func (das *DeepAnalysisService) analyzeContentWithLLM(content, contentType, file
 }
 
 // analyzeImageWithLLM sends image to multimodal LLM for analysis
-func (das *DeepAnalysisService) analyzeImageWithLLM(base64Image, mimeType, fileName string) (string, error) {
-	systemPrompt := das.config.ImageAnalysisPrompt
-
-	// Create multimodal message with image
+func (das *DeepAnalysisService) analyzeImageWithLLM(ctx context.Context, base64Image, mimeType, fileName string) (string, error) {
 	userText := fmt.Sprintf("Image: %s\n\nDescribe only what is clearly visible:", fileName)
+	imageURL := fmt.Sprintf("data:%s;base64,%s", mimeType, base64Image)
+	return das.analyzeImagesWithLLM(ctx, das.config.ImageAnalysisPrompt, userText, []string{imageURL})
+}
+
+// analyzeImagesWithLLM sends one or more images (as data URLs) with a text message to the multimodal LLM
+func (das *DeepAnalysisService) analyzeImagesWithLLM(ctx context.Context, prompt, userText string, imageURLs []string) (string, error) {
+	systemPrompt := prompt + das.descriptionInstructions()
+
+	// Create multimodal message with the images
+	content := []map[string]interface{}{
+		{
+			"type": "text",
+			"text": userText,
+		},
+	}
+	for _, url := range imageURLs {
+		content = append(content, map[string]interface{}{
+			"type": "image_url",
+			"image_url": map[string]string{
+				"url": url,
+			},
+		})
+	}
 	reqBody := map[string]interface{}{
 		"model": das.config.Model,
 		"messages": []map[string]interface{}{
@@ -481,22 +646,11 @@ func (das *DeepAnalysisService) analyzeImageWithLLM(base64Image, mimeType, fileN
 				"content": systemPrompt,
 			},
 			{
-				"role": "user",
-				"content": []map[string]interface{}{
-					{
-						"type": "text",
-						"text": userText,
-					},
-					{
-						"type": "image_url",
-						"image_url": map[string]string{
-							"url": fmt.Sprintf("data:%s;base64,%s", mimeType, base64Image),
-						},
-					},
-				},
+				"role":    "user",
+				"content": content,
 			},
 		},
-		"max_tokens":  200,
+		"max_tokens":  das.descriptionMaxTokens(200),
 		"temperature": 0.3, // Lower temperature for more factual responses
 	}
 
@@ -506,7 +660,7 @@ func (das *DeepAnalysisService) analyzeImageWithLLM(base64Image, mimeType, fileN
 		"X-Title":       "VibesAndFolders",
 	}
 
-	body, err := das.httpClient.Post(das.config.Endpoint, headers, reqBody)
+	body, err := das.httpClient.Post(ctx, das.config.Endpoint, headers, reqBody)
 	if err != nil {
 		return "", err
 	}
@@ -537,6 +691,39 @@ func (das *DeepAnalysisService) analyzeImageWithLLM(base64Image, mimeType, fileN
 	return "", fmt.Errorf("no response from LLM")
 }
 
+// descriptionInstructions returns the configured length and language requirements to append to
+// an analysis system prompt, or "" when neither is configured
+func (das *DeepAnalysisService) descriptionInstructions() string {
+	var instructions []string
+	if das.config.DescriptionMaxWords > 0 {
+		instructions = append(instructions, fmt.Sprintf("Keep the description under %d words.", das.config.DescriptionMaxWords))
+	}
+	if language := strings.TrimSpace(das.config.DescriptionLanguage); language != "" {
+		instructions = append(instructions, fmt.Sprintf("Write the description in %s, regardless of the language of these instructions.", language))
+	}
+	if len(instructions) == 0 {
+		return ""
+	}
+	return "\n\n" + strings.Join(instructions, "\n")
+}
+
+// descriptionMaxTokens sizes max_tokens for the configured description length.
+// Many non-English scripts need several tokens per word, so the budget is generous.
+func (das *DeepAnalysisService) descriptionMaxTokens(defaultTokens int) int {
+	if das.config.DescriptionMaxWords <= 0 {
+		return defaultTokens
+	}
+	return max(64, das.config.DescriptionMaxWords*tokensPerDescriptionWord)
+}
+
+// imageMaxDimension is the longest edge, in pixels, of images sent to the model
+func (das *DeepAnalysisService) imageMaxDimension() int {
+	if das.config.ImageMaxDimension > 0 {
+		return das.config.ImageMaxDimension
+	}
+	return defaultImageMaxDimension
+}
+
 // truncateContent truncates content to a maximum length
 func (das *DeepAnalysisService) truncateContent(content string, maxLen int) string {
 	if len(content) <= maxLen {
@@ -609,29 +796,27 @@ func (das *DeepAnalysisService) getMimeType(filePath string) string {
 	}
 }
 
-// DetermineFileType determines the type of file based on extension
+// DetermineFileType determines the type of a file from its content, falling back to its extension
+// when the content doesn't settle it (see sniffFileType)
 func DetermineFileType(filePath string) string {
+	byExtension := fileTypeByExtension(filePath)
+	if sniffed := sniffFileType(filePath); overridesExtension(sniffed, byExtension) {
+		return sniffed
+	}
+	return byExtension
+}
+
+// fileTypeByExtension determines the type of file based on extension, using the configured
+// mappings before the built-in ones (see FileTypeMappings)
+func fileTypeByExtension(filePath string) string {
 	ext := strings.ToLower(filepath.Ext(filePath))
-	switch ext {
-	case ".txt", ".md", ".json", ".xml", ".yaml", ".yml", ".toml", ".ini", ".cfg", ".conf":
-		return "text"
-	case ".go", ".py", ".js", ".ts", ".java", ".c", ".cpp", ".h", ".hpp", ".rs", ".rb", ".php", ".sh", ".bash":
-		return "text"
-	case ".jpg", ".jpeg", ".png", ".gif", ".bmp", ".svg", ".webp", ".ico":
-		return "image"
-	case ".mp4", ".avi", ".mkv", ".mov", ".wmv", ".flv", ".webm":
-		return "video"
-	case ".mp3", ".wav", ".flac", ".aac", ".ogg", ".wma", ".m4a":
-		return "audio"
-	case ".pdf":
-		return "pdf"
-	case ".xls", ".xlsx":
-		return "excel"
-	case ".doc", ".docx":
-		return "document"
-	case ".ppt", ".pptx":
-		return "powerpoint"
-	default:
-		return "other"
+	if fileType, ok := fileTypeMappings.lookup(ext); ok {
+		return fileType
+	}
+	for _, mapping := range builtinFileTypes {
+		if slices.Contains(mapping.Extensions, ext) {
+			return mapping.FileType
+		}
 	}
+	return "other"
 }
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var ErrNearDuplicateFolder = errors.New("nearly the same folder already exists")
+
+// folderNameKey folds case and separators, so "Tax Returns", "tax-returns" and "tax_returns" are
+// recognized as the same folder
+func folderNameKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '-' || r == '_' || r == '.' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, name)
+}
+
+// folderMergeKey is folderNameKey that also ignores a plural ending, so "Photo" and "Photos" or
+// "Category" and "Categories" are taken for the same folder
+func folderMergeKey(name string) string {
+	key := folderNameKey(name)
+	switch {
+	case strings.HasSuffix(key, "ies") && len(key) > 4:
+		return strings.TrimSuffix(key, "ies") + "y"
+	case strings.HasSuffix(key, "s") && !strings.HasSuffix(key, "ss") && len(key) > 3:
+		return strings.TrimSuffix(key, "s")
+	}
+	return key
+}
+
+// folderPathKey applies a folder name key to every level of rel
+func folderPathKey(rel string, key func(string) string) string {
+	parts := strings.Split(rel, "/")
+	for i, part := range parts {
+		parts[i] = key(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// knownFolders lists the scanned folders and the folders the plan's destinations are in, except
+// those of operation skip (or -1), relative with forward slashes
+func knownFolders(basePath string, grounding *StructureGrounding, plan []FileOperation, skip int) (scanned, planned []string) {
+	seen := make(map[string]bool)
+	if grounding != nil {
+		for folder := range grounding.folders {
+			seen[folder] = true
+			scanned = append(scanned, folder)
+		}
+	}
+	for i, op := range plan {
+		if i == skip || EscapesBase(basePath, op.To) {
+			continue
+		}
+		for dir := path.Dir(relativeSlashPath(basePath, op.To)); dir != "." && !seen[dir]; dir = path.Dir(dir) {
+			seen[dir] = true
+			planned = append(planned, dir)
+		}
+	}
+	sort.Strings(scanned)
+	sort.Strings(planned)
+	return scanned, planned
+}
+
+// SuggestDestinations completes a destination folder typed relative to basePath from the scanned
+// folders and the folders other operations of the plan (except skip, or -1) move files into.
+// Case and separators are ignored, so "tax-" also offers "Tax Returns/". Scanned folders come
+// first; every suggestion ends with a slash.
+func SuggestDestinations(basePath string, grounding *StructureGrounding, plan []FileOperation, skip int, typed string, limit int) []string {
+	typed = strings.TrimPrefix(strings.ReplaceAll(typed, "\\", "/"), "./")
+	lower, key := strings.ToLower(typed), folderPathKey(typed, folderNameKey)
+
+	scanned, planned := knownFolders(basePath, grounding, plan, skip)
+	var suggestions []string
+	for _, folder := range append(scanned, planned...) {
+		if strings.HasPrefix(strings.ToLower(folder), lower) || strings.HasPrefix(folderPathKey(folder, folderNameKey), key) {
+			suggestions = append(suggestions, folder+"/")
+		}
+		if limit > 0 && len(suggestions) == limit {
+			break
+		}
+	}
+	return suggestions
+}
+
+// checkNearDuplicate rejects a destination whose new folders differ from a scanned or planned
+// folder only in case, separators or a plural ending, naming the folder to use instead
+func checkNearDuplicate(basePath string, grounding *StructureGrounding, plan []FileOperation, skip int, op FileOperation) error {
+	scanned, planned := knownFolders(basePath, grounding, plan, skip)
+	exact := make(map[string]bool, len(scanned)+len(planned))
+	byKey := make(map[string]string, len(scanned)+len(planned))
+	for _, folder := range append(scanned, planned...) {
+		exact[folder] = true
+		if _, ok := byKey[folderPathKey(folder, folderMergeKey)]; !ok {
+			byKey[folderPathKey(folder, folderMergeKey)] = folder
+		}
+	}
+
+	dir := path.Dir(relativeSlashPath(basePath, op.To))
+	if dir == "." {
+		return nil
+	}
+	parts := strings.Split(dir, "/")
+	for i := range parts {
+		prefix := strings.Join(parts[:i+1], "/")
+		if exact[prefix] {
+			continue
+		}
+		if existing, ok := byKey[folderPathKey(prefix, folderMergeKey)]; ok {
+			return fmt.Errorf("%w: %s (use %s)", ErrNearDuplicateFolder, prefix, existing)
+		}
+		// Nothing below a new folder can be known either
+		return nil
+	}
+	return nil
+}
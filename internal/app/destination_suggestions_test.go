@@ -0,0 +1,70 @@
+package app
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSuggestDestinations(t *testing.T) {
+	base := t.TempDir()
+	g := NewStructureGrounding("Invoices/\nInvoices/2023/\nTax Returns/\nphotos/\na.pdf (1 bytes)\n")
+	plan := []FileOperation{
+		{From: filepath.Join(base, "a.pdf"), To: filepath.Join(base, "Invoices", "2024", "a.pdf")},
+		{From: filepath.Join(base, "b.jpg"), To: filepath.Join(base, "photos", "Holiday", "b.jpg")},
+	}
+
+	tests := []struct {
+		name  string
+		typed string
+		skip  int
+		want  string
+	}{
+		{"case insensitive", "inv", -1, "Invoices/, Invoices/2023/, Invoices/2024/"},
+		{"separators ignored", "tax-ret", -1, "Tax Returns/"},
+		{"planned folders after scanned ones", "photos/", -1, "photos/Holiday/"},
+		{"own destination left out", "Invoices/", 0, "Invoices/2023/"},
+		{"nothing typed", "", -1, "Invoices/, Invoices/2023/, Tax Returns/, photos/, Invoices/2024/, photos/Holiday/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(SuggestDestinations(base, g, plan, tt.skip, tt.typed, 0), ", ")
+			if got != tt.want {
+				t.Errorf("SuggestDestinations(%q) = %s, want %s", tt.typed, got, tt.want)
+			}
+		})
+	}
+
+	if got := SuggestDestinations(base, g, plan, -1, "", 2); len(got) != 2 {
+		t.Errorf("limit ignored: %v", got)
+	}
+}
+
+func TestCheckNearDuplicate(t *testing.T) {
+	base := t.TempDir()
+	g := NewStructureGrounding("Invoices/\nTax Returns/\n")
+	plan := []FileOperation{{From: filepath.Join(base, "a.pdf"), To: filepath.Join(base, "Receipts", "a.pdf")}}
+
+	tests := []struct {
+		to      string
+		wantErr bool
+	}{
+		{"Invoices/b.pdf", false},
+		{"Invoices/2024/b.pdf", false},
+		{"invoices/b.pdf", true},
+		{"tax_returns/b.pdf", true},
+		{"receipts/b.pdf", true},
+		{"Invoice/b.pdf", true},
+		{"Receipts/b.pdf", false},
+		{"Statements/b.pdf", false},
+		{"b.pdf", false},
+	}
+	for _, tt := range tests {
+		op := FileOperation{From: filepath.Join(base, "b.pdf"), To: filepath.Join(base, filepath.FromSlash(tt.to))}
+		err := checkNearDuplicate(base, g, plan, -1, op)
+		if got := errors.Is(err, ErrNearDuplicateFolder); got != tt.wantErr {
+			t.Errorf("checkNearDuplicate(%s) = %v, want near-duplicate %v", tt.to, err, tt.wantErr)
+		}
+	}
+}
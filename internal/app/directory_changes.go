@@ -0,0 +1,13 @@
+package app
+
+// Changed lists the new and modified files, the ones a routine maintenance run needs to plan
+func (c *DirectoryChanges) Changed() []string {
+	changed := make([]string, 0, len(c.NewFiles)+len(c.ModifiedFiles))
+	changed = append(changed, c.NewFiles...)
+	return append(changed, c.ModifiedFiles...)
+}
+
+// FirstRun reports whether nothing under the directory was indexed before, so every file is new
+func (c *DirectoryChanges) FirstRun() bool {
+	return len(c.ModifiedFiles) == 0 && len(c.UnchangedFiles) == 0 && len(c.DeletedFiles) == 0
+}
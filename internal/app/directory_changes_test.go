@@ -0,0 +1,30 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDirectoryChanges(t *testing.T) {
+	tests := []struct {
+		name         string
+		changes      DirectoryChanges
+		wantChanged  string
+		wantFirstRun bool
+	}{
+		{"nothing indexed yet", DirectoryChanges{NewFiles: []string{"/d/a", "/d/b"}}, "/d/a,/d/b", true},
+		{"new and modified", DirectoryChanges{NewFiles: []string{"/d/a"}, ModifiedFiles: []string{"/d/b"}, UnchangedFiles: []string{"/d/c"}}, "/d/a,/d/b", false},
+		{"only deletions", DirectoryChanges{DeletedFiles: []string{"/d/a"}}, "", false},
+		{"empty folder", DirectoryChanges{}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(tt.changes.Changed(), ","); got != tt.wantChanged {
+				t.Errorf("Changed() = %q, want %q", got, tt.wantChanged)
+			}
+			if got := tt.changes.FirstRun(); got != tt.wantFirstRun {
+				t.Errorf("FirstRun() = %v, want %v", got, tt.wantFirstRun)
+			}
+		})
+	}
+}
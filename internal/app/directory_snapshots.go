@@ -0,0 +1,474 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrSnapshotsUnavailable = errors.New("directory snapshots need the index database")
+
+// maxSnapshotsPerFolder bounds the snapshots kept for a folder; older ones are deleted
+const maxSnapshotsPerFolder = 100
+
+// Snapshots record which files a folder held at a point in time, with their sizes and content
+// hashes, so what appeared, disappeared or moved in a shared folder can be traced later
+const directorySnapshotSchema = `
+	CREATE TABLE IF NOT EXISTS directory_snapshots (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		dir_path TEXT NOT NULL,
+		taken_at INTEGER NOT NULL,
+		file_count INTEGER NOT NULL,
+		total_size INTEGER NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_directory_snapshots_dir ON directory_snapshots(dir_path, taken_at);
+
+	CREATE TABLE IF NOT EXISTS snapshot_files (
+		snapshot_id INTEGER NOT NULL,
+		rel_path TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		mod_time INTEGER NOT NULL,
+		hash TEXT NOT NULL,
+		PRIMARY KEY (snapshot_id, rel_path)
+	);
+`
+
+// DirectorySnapshot is one recorded state of a folder
+type DirectorySnapshot struct {
+	ID        int64
+	DirPath   string
+	TakenAt   time.Time
+	Files     int
+	TotalSize int64
+}
+
+// SnapshotFile is a file as a snapshot recorded it
+type SnapshotFile struct {
+	Path    string // Relative to the snapshot's folder, with forward slashes
+	Size    int64
+	ModTime int64  // Nanoseconds
+	Hash    string // SHA-256 of the content; empty if the file couldn't be read
+}
+
+// DirectorySnapshotStore takes and reads directory snapshots; the index service implements it
+type DirectorySnapshotStore interface {
+	TakeDirectorySnapshot(dirPath string) (DirectorySnapshot, error)
+	DirectorySnapshots(dirPath string) ([]DirectorySnapshot, error) // Newest first
+	SnapshotFiles(id int64) ([]SnapshotFile, error)
+}
+
+// TakeDirectorySnapshot records every file under dirPath, honoring the ignore patterns. Files
+// whose size and modification time match the previous snapshot keep its hash instead of being
+// read again.
+func (is *DefaultIndexService) TakeDirectorySnapshot(dirPath string) (DirectorySnapshot, error) {
+	dirPath = filepath.Clean(dirPath)
+	snapshot := DirectorySnapshot{DirPath: dirPath, TakenAt: time.Now()}
+
+	previous := make(map[string]SnapshotFile)
+	if snapshots, err := is.DirectorySnapshots(dirPath); err == nil && len(snapshots) > 0 {
+		files, _ := is.SnapshotFiles(snapshots[0].ID)
+		for _, f := range files {
+			previous[f.Path] = f
+		}
+	}
+
+	var files []SnapshotFile
+	err := walkTree(dirPath, is.walk, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if p == dirPath {
+				return err
+			}
+			is.logger.Debug("Snapshot skips %s: %v", p, err)
+			return nil
+		}
+		if p == dirPath {
+			return nil
+		}
+		rel := relativeSlashPath(dirPath, p)
+		if is.ignoreMatcher != nil && is.ignoreMatcher.ShouldIgnoreFile(rel, info) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isBundleDir(info) {
+			return filepath.SkipDir
+		}
+		if !info.Mode().IsRegular() || isSidecarFile(p) {
+			return nil
+		}
+
+		file := SnapshotFile{Path: rel, Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+		if prev, ok := previous[rel]; ok && prev.Size == file.Size && prev.ModTime == file.ModTime && prev.Hash != "" {
+			file.Hash = prev.Hash
+		} else if file.Hash, err = hashFile(p); err != nil {
+			is.logger.Debug("Snapshot can't hash %s: %v", p, err)
+		}
+		files = append(files, file)
+		snapshot.TotalSize += file.Size
+		return nil
+	})
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to scan %s: %w", dirPath, err)
+	}
+	snapshot.Files = len(files)
+
+	tx, err := is.db.Begin()
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(`
+		INSERT INTO directory_snapshots (dir_path, taken_at, file_count, total_size) VALUES (?, ?, ?, ?)
+	`, dirPath, snapshot.TakenAt.UnixMilli(), snapshot.Files, snapshot.TotalSize)
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	if snapshot.ID, err = res.LastInsertId(); err != nil {
+		return snapshot, fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	stmt, err := tx.Prepare("INSERT INTO snapshot_files (snapshot_id, rel_path, size, mod_time, hash) VALUES (?, ?, ?, ?, ?)")
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	defer stmt.Close()
+	for _, f := range files {
+		if _, err := stmt.Exec(snapshot.ID, f.Path, f.Size, f.ModTime, f.Hash); err != nil {
+			return snapshot, fmt.Errorf("failed to save snapshot: %w", err)
+		}
+	}
+
+	// Keep the newest snapshots only
+	_, err = tx.Exec(`
+		DELETE FROM snapshot_files WHERE snapshot_id IN (
+			SELECT id FROM directory_snapshots WHERE dir_path = ? ORDER BY taken_at DESC, id DESC LIMIT -1 OFFSET ?
+		)
+	`, dirPath, maxSnapshotsPerFolder)
+	if err == nil {
+		_, err = tx.Exec(`
+			DELETE FROM directory_snapshots WHERE id IN (
+				SELECT id FROM directory_snapshots WHERE dir_path = ? ORDER BY taken_at DESC, id DESC LIMIT -1 OFFSET ?
+			)
+		`, dirPath, maxSnapshotsPerFolder)
+	}
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to prune snapshots: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return snapshot, fmt.Errorf("failed to save snapshot: %w", err)
+	}
+
+	is.logger.Info("Snapshot of %s: %d files, %d bytes", dirPath, snapshot.Files, snapshot.TotalSize)
+	return snapshot, nil
+}
+
+func (is *DefaultIndexService) DirectorySnapshots(dirPath string) ([]DirectorySnapshot, error) {
+	rows, err := is.db.Query(`
+		SELECT id, dir_path, taken_at, file_count, total_size FROM directory_snapshots
+		WHERE dir_path = ? ORDER BY taken_at DESC, id DESC
+	`, filepath.Clean(dirPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []DirectorySnapshot
+	for rows.Next() {
+		var s DirectorySnapshot
+		var takenAt int64
+		if err := rows.Scan(&s.ID, &s.DirPath, &takenAt, &s.Files, &s.TotalSize); err != nil {
+			return nil, fmt.Errorf("failed to read snapshot: %w", err)
+		}
+		s.TakenAt = time.UnixMilli(takenAt)
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, rows.Err()
+}
+
+func (is *DefaultIndexService) SnapshotFiles(id int64) ([]SnapshotFile, error) {
+	rows, err := is.db.Query("SELECT rel_path, size, mod_time, hash FROM snapshot_files WHERE snapshot_id = ? ORDER BY rel_path", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	defer rows.Close()
+
+	var files []SnapshotFile
+	for rows.Next() {
+		var f SnapshotFile
+		if err := rows.Scan(&f.Path, &f.Size, &f.ModTime, &f.Hash); err != nil {
+			return nil, fmt.Errorf("failed to read snapshot: %w", err)
+		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+// PurgeSnapshotFiles deletes the snapshot entries of files under paths matching match and
+// returns how many were deleted
+func (is *DefaultIndexService) PurgeSnapshotFiles(match func(path string) bool) (int, error) {
+	rows, err := is.db.Query(`
+		SELECT f.snapshot_id, f.rel_path, s.dir_path FROM snapshot_files f
+		JOIN directory_snapshots s ON s.id = f.snapshot_id
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read snapshots: %w", err)
+	}
+	type entry struct {
+		id  int64
+		rel string
+	}
+	var matched []entry
+	for rows.Next() {
+		var e entry
+		var dirPath string
+		if err := rows.Scan(&e.id, &e.rel, &dirPath); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to read snapshots: %w", err)
+		}
+		if p, err := JoinStoragePath(dirPath, e.rel); err == nil && match(p) {
+			matched = append(matched, e)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read snapshots: %w", err)
+	}
+
+	for i, e := range matched {
+		if _, err := is.db.Exec("DELETE FROM snapshot_files WHERE snapshot_id = ? AND rel_path = ?", e.id, e.rel); err != nil {
+			return i, fmt.Errorf("failed to purge snapshots: %w", err)
+		}
+	}
+	return len(matched), nil
+}
+
+// SnapshotMove is a file that disappeared from one path and appeared at another with the same content
+type SnapshotMove struct {
+	From SnapshotFile
+	To   SnapshotFile
+}
+
+// SnapshotComparison is what changed in a folder between two snapshots
+type SnapshotComparison struct {
+	Older, Newer DirectorySnapshot
+	Appeared     []SnapshotFile
+	Disappeared  []SnapshotFile
+	Moved        []SnapshotMove
+	Modified     []SnapshotFile // Same path, different content; as in the newer snapshot
+}
+
+// Empty reports whether the folder held the same files both times
+func (c SnapshotComparison) Empty() bool {
+	return len(c.Appeared) == 0 && len(c.Disappeared) == 0 && len(c.Moved) == 0 && len(c.Modified) == 0
+}
+
+// String lists the changes, one per line
+func (c SnapshotComparison) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %s → %s\n", c.Older.DirPath, c.Older.TakenAt.Format(time.DateTime), c.Newer.TakenAt.Format(time.DateTime))
+	fmt.Fprintf(&sb, "%d appeared, %d disappeared, %d moved, %d modified\n", len(c.Appeared), len(c.Disappeared), len(c.Moved), len(c.Modified))
+	for _, f := range c.Appeared {
+		fmt.Fprintf(&sb, "+ %s (%d bytes)\n", f.Path, f.Size)
+	}
+	for _, f := range c.Disappeared {
+		fmt.Fprintf(&sb, "- %s (%d bytes)\n", f.Path, f.Size)
+	}
+	for _, m := range c.Moved {
+		fmt.Fprintf(&sb, "→ %s → %s\n", m.From.Path, m.To.Path)
+	}
+	for _, f := range c.Modified {
+		fmt.Fprintf(&sb, "~ %s (%d bytes)\n", f.Path, f.Size)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// CompareSnapshotFiles diffs two snapshots of a folder. A file that disappeared and one that
+// appeared with the same size and hash are reported as a move.
+func CompareSnapshotFiles(older, newer []SnapshotFile) SnapshotComparison {
+	var c SnapshotComparison
+	before := make(map[string]SnapshotFile, len(older))
+	for _, f := range older {
+		before[f.Path] = f
+	}
+	after := make(map[string]SnapshotFile, len(newer))
+	for _, f := range newer {
+		after[f.Path] = f
+	}
+
+	for _, f := range newer {
+		prev, ok := before[f.Path]
+		switch {
+		case !ok:
+			c.Appeared = append(c.Appeared, f)
+		case prev.Size != f.Size || prev.Hash != f.Hash:
+			c.Modified = append(c.Modified, f)
+		}
+	}
+	gone := make(map[string][]SnapshotFile) // Content key -> disappeared files, in path order
+	for _, f := range older {
+		if _, ok := after[f.Path]; !ok {
+			if f.Hash != "" {
+				key := fmt.Sprintf("%d:%s", f.Size, f.Hash)
+				gone[key] = append(gone[key], f)
+			}
+			c.Disappeared = append(c.Disappeared, f)
+		}
+	}
+
+	// Pair moves in path order, so identical copies are matched predictably
+	moved := make(map[string]bool)
+	var appeared []SnapshotFile
+	for _, f := range c.Appeared {
+		key := fmt.Sprintf("%d:%s", f.Size, f.Hash)
+		if candidates := gone[key]; f.Hash != "" && len(candidates) > 0 {
+			c.Moved = append(c.Moved, SnapshotMove{From: candidates[0], To: f})
+			moved[candidates[0].Path] = true
+			gone[key] = candidates[1:]
+			continue
+		}
+		appeared = append(appeared, f)
+	}
+	c.Appeared = appeared
+	var disappeared []SnapshotFile
+	for _, f := range c.Disappeared {
+		if !moved[f.Path] {
+			disappeared = append(disappeared, f)
+		}
+	}
+	c.Disappeared = disappeared
+	return c
+}
+
+// snapshotStore returns the index service as a snapshot store
+func (o *Orchestrator) snapshotStore() (DirectorySnapshotStore, error) {
+	store, ok := o.indexService.(DirectorySnapshotStore)
+	if is, isDefault := store.(*DefaultIndexService); !ok || (isDefault && is == nil) {
+		return nil, ErrSnapshotsUnavailable
+	}
+	return store, nil
+}
+
+// TakeDirectorySnapshot records the current state of dirPath
+func (o *Orchestrator) TakeDirectorySnapshot(dirPath string) (DirectorySnapshot, error) {
+	store, err := o.snapshotStore()
+	if err != nil {
+		return DirectorySnapshot{}, err
+	}
+	return store.TakeDirectorySnapshot(dirPath)
+}
+
+// DirectorySnapshots lists the snapshots of dirPath, newest first
+func (o *Orchestrator) DirectorySnapshots(dirPath string) ([]DirectorySnapshot, error) {
+	store, err := o.snapshotStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.DirectorySnapshots(dirPath)
+}
+
+// CompareDirectorySnapshots diffs two snapshots; which one is older doesn't matter
+func (o *Orchestrator) CompareDirectorySnapshots(a, b DirectorySnapshot) (SnapshotComparison, error) {
+	store, err := o.snapshotStore()
+	if err != nil {
+		return SnapshotComparison{}, err
+	}
+	if b.TakenAt.Before(a.TakenAt) {
+		a, b = b, a
+	}
+	older, err := store.SnapshotFiles(a.ID)
+	if err != nil {
+		return SnapshotComparison{}, err
+	}
+	newer, err := store.SnapshotFiles(b.ID)
+	if err != nil {
+		return SnapshotComparison{}, err
+	}
+	comparison := CompareSnapshotFiles(older, newer)
+	comparison.Older, comparison.Newer = a, b
+	return comparison, nil
+}
+
+// DirectorySnapshotter takes a snapshot of each configured folder when its last one is older
+// than the configured interval. Both settings are read from the config on every check.
+type DirectorySnapshotter struct {
+	store  DirectorySnapshotStore
+	config *Config
+	logger *Logger
+
+	mu   sync.Mutex
+	stop chan struct{}
+}
+
+func NewDirectorySnapshotter(store DirectorySnapshotStore, config *Config, logger *Logger) *DirectorySnapshotter {
+	return &DirectorySnapshotter{store: store, config: config, logger: logger}
+}
+
+// Start checks for due snapshots in the background until Stop is called
+func (s *DirectorySnapshotter) Start() {
+	s.mu.Lock()
+	if s.stop != nil {
+		s.mu.Unlock()
+		return
+	}
+	s.stop = make(chan struct{})
+	stop := s.stop
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(janitorCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				s.RunOnce(now)
+			}
+		}
+	}()
+}
+
+// Stop ends the background loop
+func (s *DirectorySnapshotter) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// RunOnce snapshots the configured folders that are due at now and returns how many it took.
+// Unreachable folders are skipped, so an unplugged drive doesn't record everything as deleted.
+func (s *DirectorySnapshotter) RunOnce(now time.Time) int {
+	interval := time.Duration(s.config.SnapshotIntervalHours) * time.Hour
+	if interval <= 0 {
+		return 0
+	}
+	taken := 0
+	for _, folder := range ParseWatchedFolders(s.config.SnapshotFolders) {
+		snapshots, err := s.store.DirectorySnapshots(folder)
+		if err != nil {
+			s.logger.Error("%v", err)
+			continue
+		}
+		if len(snapshots) > 0 && now.Sub(snapshots[0].TakenAt) < interval {
+			continue
+		}
+		if err := CheckPathReachable(folder, 0); err != nil {
+			s.logger.Info("Skipping snapshot of %s: %v", folder, err)
+			continue
+		}
+		if _, err := s.store.TakeDirectorySnapshot(folder); err != nil {
+			s.logger.Error("Snapshot of %s failed: %v", folder, err)
+			continue
+		}
+		taken++
+	}
+	return taken
+}
@@ -0,0 +1,152 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompareSnapshotFiles(t *testing.T) {
+	older := []SnapshotFile{
+		{Path: "a.txt", Size: 1, Hash: "h1"},
+		{Path: "b.txt", Size: 2, Hash: "h2"},
+		{Path: "copy1.txt", Size: 3, Hash: "h3"},
+		{Path: "copy2.txt", Size: 3, Hash: "h3"},
+		{Path: "gone.txt", Size: 4, Hash: "h4"},
+		{Path: "unreadable.txt", Size: 5},
+	}
+	newer := []SnapshotFile{
+		{Path: "a.txt", Size: 1, Hash: "h1"},
+		{Path: "b.txt", Size: 2, Hash: "h2b"},
+		{Path: "Archive/copy1.txt", Size: 3, Hash: "h3"},
+		{Path: "copy2.txt", Size: 3, Hash: "h3"},
+		{Path: "new.txt", Size: 6, Hash: "h6"},
+		{Path: "Moved/unreadable.txt", Size: 5},
+	}
+	c := CompareSnapshotFiles(older, newer)
+
+	paths := func(files []SnapshotFile) string {
+		var p []string
+		for _, f := range files {
+			p = append(p, f.Path)
+		}
+		return strings.Join(p, ",")
+	}
+	if got := paths(c.Appeared); got != "new.txt,Moved/unreadable.txt" {
+		t.Errorf("Appeared = %s", got)
+	}
+	if got := paths(c.Disappeared); got != "gone.txt,unreadable.txt" {
+		t.Errorf("Disappeared = %s", got)
+	}
+	if got := paths(c.Modified); got != "b.txt" {
+		t.Errorf("Modified = %s", got)
+	}
+	if len(c.Moved) != 1 || c.Moved[0].From.Path != "copy1.txt" || c.Moved[0].To.Path != "Archive/copy1.txt" {
+		t.Errorf("Moved = %+v", c.Moved)
+	}
+	if c.Empty() || !CompareSnapshotFiles(older, older).Empty() {
+		t.Error("Empty() is wrong")
+	}
+}
+
+func TestDirectorySnapshots(t *testing.T) {
+	dir := t.TempDir()
+	logger := NewLogger(false)
+	is := NewIndexService(logger)
+	if err := is.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer is.Close()
+	is.SetIgnorePatterns("*.tmp")
+
+	write := func(name, content string) {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("report.pdf", "report")
+	write("notes.txt", "notes")
+	write("old.doc", "old")
+	write("skip.tmp", "ignored")
+
+	validator := NewValidator()
+	orchestrator := NewOrchestrator(&stubAIService{}, NewFileService(validator, logger), validator, logger, nil, is, NewHookRunner(&Config{}, logger))
+	first, err := orchestrator.TakeDirectorySnapshot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Files != 3 {
+		t.Errorf("first snapshot has %d files, want 3 (ignored files left out)", first.Files)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "Docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, "report.pdf"), filepath.Join(dir, "Docs", "report.pdf")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "old.doc")); err != nil {
+		t.Fatal(err)
+	}
+	write("notes.txt", "edited notes")
+	write("new.png", "png")
+
+	if _, err := orchestrator.TakeDirectorySnapshot(dir); err != nil {
+		t.Fatal(err)
+	}
+	snapshots, err := orchestrator.DirectorySnapshots(dir)
+	if err != nil || len(snapshots) != 2 || snapshots[1].ID != first.ID {
+		t.Fatalf("DirectorySnapshots() = %+v, %v; want both, newest first", snapshots, err)
+	}
+
+	// Either order compares older to newer
+	c, err := orchestrator.CompareDirectorySnapshots(snapshots[0], snapshots[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := c.String()
+	for _, want := range []string{"1 appeared, 1 disappeared, 1 moved, 1 modified", "+ new.png", "- old.doc", "→ report.pdf → Docs/report.pdf", "~ notes.txt"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("comparison lacks %q:\n%s", want, got)
+		}
+	}
+
+	purged, err := is.PurgeSnapshotFiles(func(p string) bool { return strings.HasSuffix(p, "report.pdf") })
+	if err != nil || purged != 2 {
+		t.Errorf("PurgeSnapshotFiles() = %d, %v; want both entries of the report", purged, err)
+	}
+}
+
+func TestDirectorySnapshotterRunOnce(t *testing.T) {
+	logger := NewLogger(false)
+	is := NewIndexService(logger)
+	if err := is.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer is.Close()
+
+	dir := t.TempDir()
+	config := &Config{SnapshotFolders: dir + "\n" + filepath.Join(dir, "missing") + "\n", SnapshotIntervalHours: 1}
+	snapshotter := NewDirectorySnapshotter(is, config, logger)
+
+	now := time.Now()
+	if taken := snapshotter.RunOnce(now); taken != 1 {
+		t.Errorf("first run took %d snapshots, want 1 (the missing folder skipped)", taken)
+	}
+	if taken := snapshotter.RunOnce(now.Add(30 * time.Minute)); taken != 0 {
+		t.Errorf("run before the interval took %d snapshots", taken)
+	}
+	if taken := snapshotter.RunOnce(now.Add(2 * time.Hour)); taken != 1 {
+		t.Errorf("run after the interval took %d snapshots, want 1", taken)
+	}
+	config.SnapshotIntervalHours = 0
+	if taken := snapshotter.RunOnce(now.Add(4 * time.Hour)); taken != 0 {
+		t.Errorf("disabled snapshotter took %d snapshots", taken)
+	}
+}
@@ -0,0 +1,216 @@
+package app
+
+import (
+	"bytes"
+	"encoding/binary"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gen2brain/go-fitz"
+)
+
+const (
+	embeddedXMPScanBytes   = 512 * 1024 // XMP packets sit near the start of image files
+	exifImageDescriptionID = 0x010E
+	minEmbeddedDescription = 4 // Shorter values are never meaningful descriptions
+)
+
+var (
+	xmpDescriptionElement   = regexp.MustCompile(`(?s)<dc:description>.*?<rdf:li[^>]*>(.*?)</rdf:li>`)
+	xmpDescriptionAttribute = regexp.MustCompile(`dc:description="([^"]*)"`)
+)
+
+// placeholderDescriptions are values cameras and scanners fill in on their own
+var placeholderDescriptions = map[string]bool{
+	"olympus digital camera": true,
+	"sony dsc":               true,
+	"digital camera":         true,
+	"default":                true,
+	"image":                  true,
+	"untitled":               true,
+	"description":            true,
+}
+
+// embeddedDescription returns a description the file already carries and where it was found.
+// It returns an empty description when there is none worth using.
+func (das *DeepAnalysisService) embeddedDescription(filePath, fileType string) (string, string) {
+	// Sidecars written by photo managers (and by our own sidecar export) apply to every file type
+	for _, sidecarPath := range []string{filePath + sidecarXMPSuffix, strings.TrimSuffix(filePath, filepath.Ext(filePath)) + sidecarXMPSuffix} {
+		if data, err := os.ReadFile(sidecarPath); err == nil {
+			if description := usableDescription(parseXMPDescription(data)); description != "" {
+				return description, "XMP sidecar"
+			}
+		}
+	}
+
+	switch fileType {
+	case "image":
+		if description := usableDescription(readEmbeddedXMPDescription(filePath)); description != "" {
+			return description, "XMP"
+		}
+		if description := usableDescription(readEXIFImageDescription(filePath)); description != "" {
+			return description, "EXIF ImageDescription"
+		}
+	case "pdf":
+		if description := usableDescription(readPDFSubject(filePath)); description != "" {
+			return description, "PDF Subject"
+		}
+	}
+	return "", ""
+}
+
+// usableDescription trims value and drops empty or camera-generated placeholders
+func usableDescription(value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	if len(value) < minEmbeddedDescription || placeholderDescriptions[strings.ToLower(value)] {
+		return ""
+	}
+	return value
+}
+
+// parseXMPDescription extracts dc:description from an XMP packet, in either element or attribute form
+func parseXMPDescription(data []byte) string {
+	if m := xmpDescriptionElement.FindSubmatch(data); m != nil {
+		return html.UnescapeString(string(m[1]))
+	}
+	if m := xmpDescriptionAttribute.FindSubmatch(data); m != nil {
+		return html.UnescapeString(string(m[1]))
+	}
+	return ""
+}
+
+// readEmbeddedXMPDescription looks for an XMP packet stored as plain text inside the file (JPEG, PNG, TIFF, WebP)
+func readEmbeddedXMPDescription(filePath string) string {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, embeddedXMPScanBytes))
+	if err != nil {
+		return ""
+	}
+	start := bytes.Index(data, []byte("<x:xmpmeta"))
+	if start < 0 {
+		return ""
+	}
+	end := bytes.Index(data[start:], []byte("</x:xmpmeta>"))
+	if end < 0 {
+		return ""
+	}
+	return parseXMPDescription(data[start : start+end])
+}
+
+// readEXIFImageDescription reads the ImageDescription tag of a JPEG file
+func readEXIFImageDescription(filePath string) string {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	exif := jpegEXIFSegment(file)
+	if exif == nil {
+		return ""
+	}
+	return tiffImageDescription(bytes.NewReader(exif))
+}
+
+// jpegEXIFSegment returns the TIFF data of a JPEG's Exif APP1 segment, or nil if there is none
+func jpegEXIFSegment(r io.Reader) []byte {
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil || soi != [2]byte{0xFF, 0xD8} {
+		return nil
+	}
+
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil || header[0] != 0xFF {
+			return nil
+		}
+		marker := header[1]
+		length := int(binary.BigEndian.Uint16(header[2:])) - 2
+		if marker == 0xDA || length < 0 { // Start of scan: no metadata follows
+			return nil
+		}
+
+		segment := make([]byte, length)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return nil
+		}
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+	}
+}
+
+// tiffImageDescription reads the ImageDescription ASCII tag from IFD0 of TIFF-structured data
+func tiffImageDescription(r io.ReaderAt) string {
+	header := make([]byte, 8)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return ""
+	}
+
+	var order binary.ByteOrder
+	switch string(header[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return ""
+	}
+	if order.Uint16(header[2:]) != 42 {
+		return ""
+	}
+
+	ifdOffset := int64(order.Uint32(header[4:]))
+	countBytes := make([]byte, 2)
+	if _, err := r.ReadAt(countBytes, ifdOffset); err != nil {
+		return ""
+	}
+
+	entry := make([]byte, 12)
+	for i := int64(0); i < int64(order.Uint16(countBytes)); i++ {
+		if _, err := r.ReadAt(entry, ifdOffset+2+i*12); err != nil {
+			return ""
+		}
+		if order.Uint16(entry[0:]) != exifImageDescriptionID || order.Uint16(entry[2:]) != 2 { // 2 = ASCII
+			continue
+		}
+
+		count := order.Uint32(entry[4:])
+		if count > 64*1024 {
+			return ""
+		}
+		value := entry[8:12]
+		if count > 4 {
+			value = make([]byte, count)
+			if _, err := r.ReadAt(value, int64(order.Uint32(entry[8:]))); err != nil {
+				return ""
+			}
+		} else {
+			value = value[:count]
+		}
+		text, _, _ := strings.Cut(string(value), "\x00")
+		return text
+	}
+	return ""
+}
+
+// readPDFSubject returns the Subject entry of a PDF's document information
+func readPDFSubject(filePath string) string {
+	doc, err := fitz.New(filePath)
+	if err != nil {
+		return ""
+	}
+	defer doc.Close()
+
+	subject, _, _ := strings.Cut(doc.Metadata()["subject"], "\x00")
+	return subject
+}
@@ -0,0 +1,149 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// buildTIFFWithDescription returns TIFF data whose IFD0 holds only an ImageDescription tag
+func buildTIFFWithDescription(order binary.ByteOrder, description string) []byte {
+	var buf bytes.Buffer
+	if order == binary.LittleEndian {
+		buf.WriteString("II")
+	} else {
+		buf.WriteString("MM")
+	}
+	value := append([]byte(description), 0)
+	binary.Write(&buf, order, uint16(42))
+	binary.Write(&buf, order, uint32(8))          // IFD0 offset
+	binary.Write(&buf, order, uint16(1))          // Entry count
+	binary.Write(&buf, order, uint16(0x010E))     // ImageDescription
+	binary.Write(&buf, order, uint16(2))          // ASCII
+	binary.Write(&buf, order, uint32(len(value))) // Count
+	binary.Write(&buf, order, uint32(8+2+12+4))   // Value offset, after the IFD
+	binary.Write(&buf, order, uint32(0))          // No next IFD
+	buf.Write(value)
+	return buf.Bytes()
+}
+
+// buildJPEGWithEXIF wraps TIFF data in a minimal JPEG with an APP0 and an Exif APP1 segment
+func buildJPEGWithEXIF(tiff []byte) []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xFF, 0xD8})
+	buf.Write([]byte{0xFF, 0xE0, 0x00, 0x07, 'J', 'F', 'I', 'F', 0x00})
+	exif := append([]byte("Exif\x00\x00"), tiff...)
+	buf.Write([]byte{0xFF, 0xE1})
+	binary.Write(&buf, binary.BigEndian, uint16(len(exif)+2))
+	buf.Write(exif)
+	buf.Write([]byte{0xFF, 0xDA, 0x00, 0x02, 0xFF, 0xD9})
+	return buf.Bytes()
+}
+
+func TestEmbeddedDescription(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		content    []byte
+		sidecar    string // Written to file + ".xmp" when set
+		want       string
+		wantSource string
+	}{
+		{
+			name:       "EXIF little endian",
+			file:       "beach.jpg",
+			content:    buildJPEGWithEXIF(buildTIFFWithDescription(binary.LittleEndian, "Sunset over the pier")),
+			want:       "Sunset over the pier",
+			wantSource: "EXIF ImageDescription",
+		},
+		{
+			name:       "EXIF big endian",
+			file:       "scan.jpeg",
+			content:    buildJPEGWithEXIF(buildTIFFWithDescription(binary.BigEndian, "Signed lease agreement")),
+			want:       "Signed lease agreement",
+			wantSource: "EXIF ImageDescription",
+		},
+		{
+			name:    "camera placeholder ignored",
+			file:    "dsc.jpg",
+			content: buildJPEGWithEXIF(buildTIFFWithDescription(binary.LittleEndian, "OLYMPUS DIGITAL CAMERA         ")),
+		},
+		{
+			name:       "embedded XMP",
+			file:       "logo.png",
+			content:    []byte("\x89PNG....iTXtXML:com.adobe.xmp<x:xmpmeta><rdf:Description dc:description=\"Company logo &amp; wordmark\"/></x:xmpmeta>"),
+			want:       "Company logo & wordmark",
+			wantSource: "XMP",
+		},
+		{
+			name:       "XMP sidecar for any file type",
+			file:       "notes.txt",
+			content:    []byte("meeting notes"),
+			sidecar:    `<x:xmpmeta><dc:description><rdf:Alt><rdf:li xml:lang="x-default">Notes from the kickoff meeting</rdf:li></rdf:Alt></dc:description></x:xmpmeta>`,
+			want:       "Notes from the kickoff meeting",
+			wantSource: "XMP sidecar",
+		},
+		{
+			name:    "no metadata",
+			file:    "plain.jpg",
+			content: buildJPEGWithEXIF(buildTIFFWithDescription(binary.LittleEndian, "")),
+		},
+	}
+
+	das := NewDeepAnalysisService(&Config{}, nil, nil, NewLogger(false))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(filePath, tt.content, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.sidecar != "" {
+				if err := os.WriteFile(filePath+".xmp", []byte(tt.sidecar), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, source := das.embeddedDescription(filePath, DetermineFileType(filePath))
+			if got != tt.want || source != tt.wantSource {
+				t.Errorf("embeddedDescription() = %q (%s), want %q (%s)", got, source, tt.want, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestAnalyzeFileUsesEmbeddedDescription(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"choices": [{"message": {"content": "Photo of a beach at sunset."}}]}`))
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "beach.jpg")
+	if err := os.WriteFile(filePath, buildJPEGWithEXIF(buildTIFFWithDescription(binary.LittleEndian, "Sunset over the pier")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := &Config{Endpoint: server.URL}
+	logger := NewLogger(false)
+	das := NewDeepAnalysisService(config, NewHTTPClient(config, logger), nil, logger)
+
+	description, err := das.AnalyzeFile(context.Background(), filePath)
+	if err != nil || description != "Sunset over the pier" || requests != 0 {
+		t.Fatalf("expected embedded description without a model call, got %q (err: %v, requests: %d)", description, err, requests)
+	}
+
+	config.IgnoreEmbeddedDescriptions = true
+	description, err = das.AnalyzeFile(context.Background(), filePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requests != 1 || description != "Photo of a beach at sunset." {
+		t.Errorf("expected the model to be asked when embedded descriptions are ignored, got %q (requests: %d)", description, requests)
+	}
+}
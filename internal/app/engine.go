@@ -0,0 +1,178 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// EngineOptions configures NewEngine
+type EngineOptions struct {
+	Config *Config
+	Logger *Logger
+
+	// Folder for the index, its encryption key, the audit log and usage records. Empty runs
+	// without them. Config.IndexDBPath and Config.AuditLogPath override where those are kept.
+	DataDir string
+
+	AppVersion   string // Recorded with each audit log entry
+	SystemLocale string // Dates and sizes follow it while Config.Locale is empty
+
+	// Keep going without an index that can't be opened, and with an unencrypted one when
+	// encryption can't be set up, as the desktop app does. Otherwise NewEngine fails.
+	IndexOptional bool
+
+	Shutdown *ShutdownCoordinator // Nil creates one
+}
+
+// Engine is the organization engine wired up from one config: the services the desktop app and
+// pkg/organizer share. Background jobs (index cleanup, snapshots, schedules) are left to the caller.
+type Engine struct {
+	Config            *Config
+	HTTPClient        *HTTPClient
+	Index             *DefaultIndexService // Nil without an index
+	IndexOrchestrator *IndexDirectoryOrchestrator
+	Orchestrator      *Orchestrator
+	PendingPlans      *PendingPlanStore // Nil without a data folder
+	Shutdown          *ShutdownCoordinator
+}
+
+// NewEngine creates the engine's services for opts.Config. Everything it opens is closed by
+// the engine's ShutdownCoordinator.
+func NewEngine(opts EngineOptions) (*Engine, error) {
+	config := opts.Config
+	logger := opts.Logger
+	if logger == nil {
+		logger = NewLogger(false)
+	}
+	httpLogger := logger.For(LogSubsystemHTTP)
+	indexLogger := logger.For(LogSubsystemIndex)
+	executionLogger := logger.For(LogSubsystemExecution)
+
+	shutdown := opts.Shutdown
+	if shutdown == nil {
+		shutdown = NewShutdownCoordinator(logger)
+	}
+	e := &Engine{Config: config, Shutdown: shutdown}
+
+	// File types come from the extension table the user may have extended
+	UseFileTypeMappings(config)
+	// Dates and sizes follow the system locale unless one is configured
+	UseLocale(config, opts.SystemLocale)
+
+	validator := NewValidator()
+	e.HTTPClient = NewHTTPClient(config, httpLogger)
+	if opts.DataDir != "" {
+		e.HTTPClient.SetBudgetGuard(NewBudgetGuard(config, filepath.Join(opts.DataDir, "usage.json"), httpLogger))
+	}
+	aiService := NewOpenAIService(config, e.HTTPClient, httpLogger)
+
+	fileService := NewFileService(validator, executionLogger)
+	fileService.SetIgnorePatterns(config.IgnorePatterns)
+	fileService.SetIgnoreHidden(config.IgnoreHiddenFiles)
+	fileService.SetWalkConcurrency(config.WalkWorkers, config.WalkBatchSize)
+	fileService.SetFolderLabeler(NewFolderLabeler(config, executionLogger))
+
+	// Route s3:// paths to the object storage backend
+	objectFileService := NewObjectStorageFileService(NewS3Backend(config, httpLogger), executionLogger)
+	objectFileService.SetIgnorePatterns(config.IgnorePatterns)
+	objectFileService.SetIgnoreHidden(config.IgnoreHiddenFiles)
+	routedFileService := NewRoutingFileService(fileService)
+	routedFileService.Register("s3", objectFileService)
+
+	if opts.DataDir != "" {
+		index, err := openEngineIndex(config, opts.DataDir, indexLogger)
+		if err != nil && !opts.IndexOptional {
+			if index != nil {
+				index.Close()
+			}
+			return nil, err
+		}
+		if err != nil {
+			// Continue without indexing
+			indexLogger.Error("%v", err)
+		}
+		if index != nil {
+			shutdown.OnShutdown("index database", index.Close)
+			e.Index = index
+		}
+	}
+
+	if e.Index != nil {
+		deepAnalysis := NewDeepAnalysisService(config, e.HTTPClient, e.Index, indexLogger)
+		e.IndexOrchestrator = NewIndexDirectoryOrchestrator(e.Index, deepAnalysis, indexLogger)
+		// Optionally keep descriptions next to the files too, for other tools
+		e.IndexOrchestrator.SetSidecarWriter(NewSidecarWriter(config, indexLogger))
+	}
+
+	hooks := NewHookRunner(config, executionLogger)
+	if e.Index != nil {
+		e.Orchestrator = NewOrchestrator(aiService, routedFileService, validator, logger, e.IndexOrchestrator, e.Index, hooks)
+	} else {
+		// A nil *DefaultIndexService in the interface would look like an index to the orchestrator
+		e.Orchestrator = NewOrchestrator(aiService, routedFileService, validator, logger, nil, nil, hooks)
+	}
+	e.Orchestrator.SetShutdown(shutdown)
+	e.Orchestrator.SetBudget(e.HTTPClient.Budget())
+
+	// Append-only record of executions and index changes, for compliance on shared folders
+	auditLogPath := config.AuditLogPath
+	if auditLogPath == "" && opts.DataDir != "" {
+		auditLogPath = filepath.Join(opts.DataDir, "audit.db")
+	}
+	if auditLogPath != "" {
+		auditLog, err := OpenAuditLog(auditLogPath, opts.AppVersion, logger)
+		if err != nil {
+			logger.Error("Failed to open audit log: %v", err)
+		}
+		shutdown.OnShutdown("audit log", auditLog.Close)
+		if e.Index != nil {
+			e.Index.SetAuditLog(auditLog)
+		}
+		e.Orchestrator.SetAuditLog(auditLog)
+	}
+
+	if opts.DataDir == "" {
+		return e, nil
+	}
+
+	// Counts for the local statistics page; never reported anywhere
+	metrics, err := OpenUsageMetrics(filepath.Join(opts.DataDir, "metrics.db"), logger)
+	if err != nil {
+		logger.Error("Failed to open usage metrics: %v", err)
+	}
+	e.Orchestrator.SetMetrics(metrics)
+	shutdown.OnShutdown("usage metrics", metrics.Close)
+	// Plans from automated jobs that weren't confident enough to apply on their own
+	e.PendingPlans = NewPendingPlanStore(filepath.Join(opts.DataDir, "pending_plans.json"), logger)
+	e.Orchestrator.SetPendingPlans(e.PendingPlans)
+	// Rejected and rolled back moves, fed back to the model as negative examples
+	e.Orchestrator.SetCorrections(NewCorrectionStore(filepath.Join(opts.DataDir, "corrections.json"), logger))
+	if e.Index != nil {
+		e.Orchestrator.SetIndexSync(NewIndexSyncService(e.Index, config, indexLogger))
+	}
+	return e, nil
+}
+
+// openEngineIndex opens the index and brings its encryption in line with config. When only the
+// encryption fails the index is returned along with the error.
+func openEngineIndex(config *Config, dataDir string, logger *Logger) (*DefaultIndexService, error) {
+	dbPath := config.IndexDBPath
+	if dbPath == "" {
+		dbPath = filepath.Join(dataDir, "index.db")
+	}
+	index := NewIndexService(logger)
+	if err := index.Initialize(dbPath); err != nil {
+		return nil, fmt.Errorf("failed to open index: %w", err)
+	}
+	index.SetIgnorePatterns(config.IgnorePatterns)
+	index.SetIgnoreHidden(config.IgnoreHiddenFiles)
+	index.SetWalkConcurrency(config.WalkWorkers, config.WalkBatchSize)
+	index.SetSkipUnchangedFolders(config.SkipUnchangedFolders)
+	// Skip file types with deep analysis turned off
+	index.SetAnalysisFilter(config.AnalysisEnabledFor)
+	// Descriptions of private documents are sensitive too; keep them encrypted if asked
+	if err := index.ConfigureEncryption(config.EncryptIndex, NewKeychain(filepath.Join(dataDir, "keys"))); err != nil {
+		return index, fmt.Errorf("failed to set up index encryption: %w", err)
+	}
+	return index, nil
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrExecutionStopped marks operations that were never attempted because the user stopped the run
+var ErrExecutionStopped = errors.New("not attempted: execution was stopped")
+
+// ExecutionControl lets the user pause, resume or stop an execution. It is only consulted between
+// operations, so a move that has started always finishes and the results stay in plan order.
+type ExecutionControl struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	paused  bool
+	stopped bool
+}
+
+func NewExecutionControl() *ExecutionControl {
+	c := &ExecutionControl{}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Pause holds the execution before its next operation until Resume or Stop
+func (c *ExecutionControl) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paused = true
+}
+
+func (c *ExecutionControl) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paused = false
+	c.cond.Broadcast()
+}
+
+// Stop ends the execution before its next operation, including while it is paused
+func (c *ExecutionControl) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = true
+	c.cond.Broadcast()
+}
+
+func (c *ExecutionControl) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused && !c.stopped
+}
+
+// proceed blocks while the execution is paused and reports whether the next operation may start.
+// A nil control never pauses.
+func (c *ExecutionControl) proceed() bool {
+	if c == nil {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.paused && !c.stopped {
+		c.cond.Wait()
+	}
+	return !c.stopped
+}
+
+// Remaining returns the operations a stopped execution never attempted, in plan order, so they
+// can be executed later
+func (r ExecutionResult) Remaining() []FileOperation {
+	var remaining []FileOperation
+	for _, opResult := range r.Operations {
+		if errors.Is(opResult.Error, ErrExecutionStopped) {
+			remaining = append(remaining, opResult.Operation)
+		}
+	}
+	return remaining
+}
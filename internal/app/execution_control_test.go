@@ -0,0 +1,127 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeControlFixture(t *testing.T, dir string) []FileOperation {
+	t.Helper()
+	var ops []FileOperation
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		ops = append(ops, FileOperation{From: filepath.Join(dir, name), To: filepath.Join(dir, "sorted", name)})
+	}
+	return ops
+}
+
+func TestExecutionStoppedBeforeStart(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ExecutionOptions
+	}{
+		{"sequential", ExecutionOptions{}},
+		{"parallel", ExecutionOptions{Parallelism: 4}},
+		{"staged", ExecutionOptions{Staged: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			ops := writeControlFixture(t, dir)
+			control := NewExecutionControl()
+			control.Stop()
+			tt.opts.Control = control
+
+			fs := NewFileService(NewValidator(), NewLogger(false))
+			result, err := fs.ExecuteOperations(ops, dir, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !result.Stopped || result.SuccessCount != 0 || len(result.Remaining()) != len(ops) {
+				t.Fatalf("expected nothing to run, got stopped=%v successes=%d remaining=%d", result.Stopped, result.SuccessCount, len(result.Remaining()))
+			}
+			for i, op := range result.Remaining() {
+				if op != ops[i] {
+					t.Errorf("remaining[%d] = %v, want %v", i, op, ops[i])
+				}
+				if _, err := os.Stat(op.From); err != nil {
+					t.Errorf("%s should not have moved: %v", op.From, err)
+				}
+			}
+		})
+	}
+}
+
+func TestExecutionStoppedMidRun(t *testing.T) {
+	dir := t.TempDir()
+	ops := writeControlFixture(t, dir)
+	if err := os.Mkdir(filepath.Join(dir, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	control := NewExecutionControl()
+
+	// The throttle pause after the first move gives the stop time to land between operations
+	go func() {
+		for {
+			if _, err := os.Stat(ops[0].To); err == nil {
+				control.Stop()
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	fs := NewFileService(NewValidator(), NewLogger(false))
+	result, err := fs.ExecuteOperations(ops, dir, ExecutionOptions{CleanEmpty: true, ThrottleDelay: 200 * time.Millisecond, ThrottleBatch: 1, Control: control})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Stopped || result.SuccessCount != 1 || len(result.Remaining()) != 2 {
+		t.Fatalf("expected one move before the stop, got stopped=%v successes=%d remaining=%d", result.Stopped, result.SuccessCount, len(result.Remaining()))
+	}
+	if !result.Operations[0].Success || result.Remaining()[0] != ops[1] {
+		t.Errorf("results are not in plan order: %+v", result.Operations)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "empty")); err != nil {
+		t.Errorf("empty folders should be kept while the run can be resumed: %v", err)
+	}
+	if result.FinalFileCount != result.InitialFileCount {
+		t.Errorf("file count changed from %d to %d", result.InitialFileCount, result.FinalFileCount)
+	}
+}
+
+func TestExecutionPauseAndResume(t *testing.T) {
+	dir := t.TempDir()
+	ops := writeControlFixture(t, dir)
+	control := NewExecutionControl()
+	control.Pause()
+	if !control.Paused() {
+		t.Fatal("expected the control to be paused")
+	}
+
+	done := make(chan ExecutionResult)
+	go func() {
+		fs := NewFileService(NewValidator(), NewLogger(false))
+		result, _ := fs.ExecuteOperations(ops, dir, ExecutionOptions{Control: control})
+		done <- result
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("execution finished while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if _, err := os.Stat(ops[0].From); err != nil {
+		t.Fatalf("a paused execution moved %s", ops[0].From)
+	}
+
+	control.Resume()
+	result := <-done
+	if result.Stopped || result.SuccessCount != len(ops) {
+		t.Errorf("expected every operation to run after resuming, got %d successes", result.SuccessCount)
+	}
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var ErrNoNewFiles = errors.New("no files were added or changed since the last run")
+
+// The execution history records when plans were executed in a folder, so later analyses can
+// leave alone what was organized then
+const executionHistorySchema = `
+	CREATE TABLE IF NOT EXISTS execution_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		dir_path TEXT NOT NULL,
+		started_at INTEGER NOT NULL,
+		succeeded INTEGER NOT NULL,
+		failed INTEGER NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_execution_history_dir ON execution_history(dir_path, started_at);
+`
+
+// ExecutionHistoryStore persists when plans were executed in a folder. The index service
+// implements it; both methods are safe to call on a nil service.
+type ExecutionHistoryStore interface {
+	RecordExecution(dirPath string, startedAt time.Time, succeeded, failed int) error
+	LastSuccessfulExecution(dirPath string) (time.Time, error) // Zero if there was none
+}
+
+func (is *DefaultIndexService) RecordExecution(dirPath string, startedAt time.Time, succeeded, failed int) error {
+	if is == nil {
+		return nil
+	}
+	_, err := is.db.Exec(`
+		INSERT INTO execution_history (dir_path, started_at, succeeded, failed) VALUES (?, ?, ?, ?)
+	`, filepath.Clean(dirPath), startedAt.UnixMilli(), succeeded, failed)
+	if err != nil {
+		return fmt.Errorf("failed to record execution: %w", err)
+	}
+	return nil
+}
+
+// LastSuccessfulExecution returns when the last execution that moved files without failures started
+func (is *DefaultIndexService) LastSuccessfulExecution(dirPath string) (time.Time, error) {
+	if is == nil {
+		return time.Time{}, nil
+	}
+	var startedAt sql.NullInt64
+	err := is.db.QueryRow(`
+		SELECT MAX(started_at) FROM execution_history WHERE dir_path = ? AND succeeded > 0 AND failed = 0
+	`, filepath.Clean(dirPath)).Scan(&startedAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read execution history: %w", err)
+	}
+	if !startedAt.Valid {
+		return time.Time{}, nil
+	}
+	return time.UnixMilli(startedAt.Int64), nil
+}
+
+// recordExecution adds an execution to the history, if the index keeps one
+func (o *Orchestrator) recordExecution(basePath string, startedAt time.Time, result ExecutionResult) {
+	history, ok := o.indexService.(ExecutionHistoryStore)
+	if !ok {
+		return
+	}
+	if err := history.RecordExecution(basePath, startedAt, result.SuccessCount, result.FailCount); err != nil {
+		o.logger.Error("%v", err)
+	}
+}
+
+// lastExecution returns when the last successful execution in dirPath started, or the zero time
+// if there was none and every file counts as new
+func (o *Orchestrator) lastExecution(dirPath string) time.Time {
+	history, ok := o.indexService.(ExecutionHistoryStore)
+	if !ok {
+		return time.Time{}
+	}
+	since, err := history.LastSuccessfulExecution(dirPath)
+	if err != nil {
+		o.logger.Error("%v", err)
+	}
+	return since
+}
+
+// restrictToNewFiles leaves only the files of a text structure modified after since. Files that
+// were organized by then keep their places, since the model never sees them.
+func restrictToNewFiles(basePath, structure string, since time.Time) (string, int) {
+	var newer []string
+	for _, line := range strings.Split(structure, "\n") {
+		m := structureFileLine.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		p, err := JoinStoragePath(basePath, m[1])
+		if err != nil {
+			continue
+		}
+		if info, err := os.Stat(p); err == nil && info.ModTime().After(since) {
+			newer = append(newer, p)
+		}
+	}
+	return restrictStructure(basePath, structure, newer), len(newer)
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLastSuccessfulExecution(t *testing.T) {
+	is := NewIndexService(NewLogger(false))
+	if err := is.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer is.Close()
+
+	dir := filepath.Join(t.TempDir(), "Downloads")
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := []struct {
+		dir               string
+		at                time.Time
+		succeeded, failed int
+	}{
+		{dir, first, 3, 0},
+		{dir, first.Add(time.Hour), 2, 1},     // Partly failed
+		{dir, first.Add(2 * time.Hour), 0, 0}, // Moved nothing
+		{dir + "/Sub", first.Add(3 * time.Hour), 1, 0},
+	}
+	for _, r := range records {
+		if err := is.RecordExecution(r.dir, r.at, r.succeeded, r.failed); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got, err := is.LastSuccessfulExecution(dir + "/"); err != nil || !got.Equal(first) {
+		t.Errorf("LastSuccessfulExecution() = %v, %v; want %v", got, err, first)
+	}
+	if got, _ := is.LastSuccessfulExecution(filepath.Join(dir, "Other")); !got.IsZero() {
+		t.Errorf("LastSuccessfulExecution() of a folder never executed = %v, want zero", got)
+	}
+}
+
+func TestAnalyzeNewFilesOnly(t *testing.T) {
+	dir := t.TempDir()
+	logger := NewLogger(false)
+	indexService := NewIndexService(logger)
+	if err := indexService.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer indexService.Close()
+
+	old := time.Now().Add(-48 * time.Hour)
+	for _, name := range []string{"a.txt", "b.txt"} {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	validator := NewValidator()
+	ai := &selectingAIService{}
+	orchestrator := NewOrchestrator(ai, NewFileService(validator, logger), validator, logger, nil, indexService, NewHookRunner(&Config{}, logger))
+	req := AnalysisRequest{DirectoryPath: dir, UserPrompt: "Sort", NewFilesOnly: true}
+
+	// Never executed here, so every file is new
+	if result := orchestrator.AnalyzeDirectory(req, nil); result.Error != nil || !result.NewSince.IsZero() || !strings.Contains(ai.lastStructure, "b.txt") {
+		t.Fatalf("first run: %v, shown %q", result.Error, ai.lastStructure)
+	}
+
+	execution := orchestrator.ExecuteOrganization(ExecutionRequest{
+		BasePath:   dir,
+		Operations: []FileOperation{{From: filepath.Join(dir, "a.txt"), To: filepath.Join(dir, "Sorted", "a.txt")}},
+	})
+	if execution.SuccessCount != 1 {
+		t.Fatalf("execution = %+v", execution)
+	}
+
+	if result := orchestrator.AnalyzeDirectory(req, nil); !errors.Is(result.Error, ErrNoNewFiles) {
+		t.Fatalf("nothing new: %v, want ErrNoNewFiles", result.Error)
+	}
+
+	newer := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(dir, "b.txt"), newer, newer); err != nil {
+		t.Fatal(err)
+	}
+	result := orchestrator.AnalyzeDirectory(req, nil)
+	if result.Error != nil || result.NewSince.IsZero() {
+		t.Fatalf("after a change: %v (since %v)", result.Error, result.NewSince)
+	}
+	if !strings.Contains(ai.lastStructure, "b.txt") || strings.Contains(ai.lastStructure, "a.txt") || !strings.Contains(ai.lastStructure, "Sorted/") {
+		t.Errorf("model was shown %q, want only b.txt and the folders", ai.lastStructure)
+	}
+}
@@ -0,0 +1,204 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var ErrFileInUse = errors.New("file is in use by another program")
+
+const (
+	lsofTimeout   = 30 * time.Second
+	lsofBatchSize = 200 // Paths per lsof invocation, well below argument length limits
+
+	// Windows error codes for files opened by another process without sharing
+	windowsSharingViolation syscall.Errno = 32
+	windowsLockViolation    syscall.Errno = 33
+)
+
+// LockedFile is a file another program holds open
+type LockedFile struct {
+	Path    string
+	Process string // Program holding it, when known
+}
+
+func (f LockedFile) String() string {
+	if f.Process == "" {
+		return f.Path
+	}
+	return fmt.Sprintf("%s (open in %s)", f.Path, f.Process)
+}
+
+// LockedDecision is the user's answer when files to be moved are in use
+type LockedDecision int
+
+const (
+	LockedSkip LockedDecision = iota // Execute everything else; the locked files' operations fail
+	LockedRetry
+	LockedAbort
+)
+
+// LockedFilesHandler is consulted before execution when files to be moved are in use.
+// It may block (e.g. while a dialog is shown) until the user has closed the programs.
+type LockedFilesHandler func(files []LockedFile) LockedDecision
+
+// isSharingViolation reports whether err is Windows refusing access to a file another process has open
+func isSharingViolation(err error) bool {
+	if runtime.GOOS != "windows" {
+		return false
+	}
+	var errno syscall.Errno
+	return errors.As(err, &errno) && (errno == windowsSharingViolation || errno == windowsLockViolation)
+}
+
+// findLockedFiles returns the sources of operations that another program holds open. On Windows
+// every regular file is opened for writing, which fails while another process denies sharing; on
+// other systems locks are advisory, so lsof is asked instead when useLsof is set and it's installed.
+func (fs *DefaultFileService) findLockedFiles(operations []FileOperation, useLsof bool) []LockedFile {
+	var paths []string
+	for _, op := range operations {
+		if info, err := os.Lstat(op.From); err == nil && info.Mode().IsRegular() {
+			paths = append(paths, op.From)
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		var locked []LockedFile
+		for _, path := range paths {
+			file, err := os.OpenFile(path, os.O_RDWR, 0)
+			if err == nil {
+				file.Close()
+				continue
+			}
+			if isSharingViolation(err) {
+				locked = append(locked, LockedFile{Path: path})
+			}
+		}
+		return locked
+	}
+
+	if !useLsof {
+		return nil
+	}
+	lsof, err := exec.LookPath("lsof")
+	if err != nil {
+		fs.logger.Debug("lsof not found; skipping open file check")
+		return nil
+	}
+
+	var locked []LockedFile
+	for start := 0; start < len(paths); start += lsofBatchSize {
+		batch := paths[start:min(start+lsofBatchSize, len(paths))]
+		found, err := lsofOpenFiles(lsof, batch)
+		if err != nil {
+			fs.logger.Error("Open file check failed: %v", err)
+			return locked
+		}
+		locked = append(locked, found...)
+	}
+	return locked
+}
+
+// lsofOpenFiles asks lsof which of paths are open. lsof exits with status 1 when none are,
+// so its output is used whatever the exit status.
+func lsofOpenFiles(lsof string, paths []string) ([]LockedFile, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), lsofTimeout)
+	defer cancel()
+
+	// -F pcn: machine-readable process id, command and file name fields
+	cmd := exec.CommandContext(ctx, lsof, append([]string{"-w", "-F", "pcn", "--"}, paths...)...)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("lsof timed out after %s", lsofTimeout)
+		}
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, err
+		}
+	}
+	return parseLsofOutput(stdout.Bytes(), paths), nil
+}
+
+// parseLsofOutput turns lsof -F pcn output into one entry per open path, naming the first program found
+func parseLsofOutput(output []byte, paths []string) []LockedFile {
+	wanted := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		wanted[filepath.Clean(path)] = true
+	}
+
+	var locked []LockedFile
+	seen := make(map[string]bool)
+	command := ""
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		switch line[0] {
+		case 'p':
+			command = ""
+		case 'c':
+			command = line[1:]
+		case 'n':
+			name := filepath.Clean(strings.TrimSuffix(line[1:], " (deleted)"))
+			if wanted[name] && !seen[name] {
+				seen[name] = true
+				locked = append(locked, LockedFile{Path: name, Process: command})
+			}
+		}
+	}
+	return locked
+}
+
+// preflightLocks checks the sources of operations for files in use and lets onLocked decide what
+// to do. It returns the operations to execute, those skipped because their file is locked, and
+// whether execution should stop altogether; then the operations are all returned, to be reported
+// as not run.
+func (fs *DefaultFileService) preflightLocks(operations []FileOperation, opts ExecutionOptions) ([]FileOperation, []OperationResult, bool) {
+	for {
+		locked := fs.findLockedFiles(operations, opts.CheckOpenFiles)
+		if len(locked) == 0 {
+			return operations, nil, false
+		}
+		fs.logger.Info("%d files to be moved are in use by other programs", len(locked))
+
+		decision := LockedSkip
+		if opts.OnLocked != nil {
+			decision = opts.OnLocked(locked)
+		}
+		switch decision {
+		case LockedRetry:
+			continue
+		case LockedAbort:
+			return operations, nil, true
+		}
+
+		lockedPaths := make(map[string]LockedFile, len(locked))
+		for _, file := range locked {
+			lockedPaths[filepath.Clean(file.Path)] = file
+		}
+		var remaining []FileOperation
+		var skipped []OperationResult
+		for _, op := range operations {
+			if file, ok := lockedPaths[filepath.Clean(op.From)]; ok {
+				skipped = append(skipped, OperationResult{Operation: op, Error: fmt.Errorf("%w: %s", ErrFileInUse, file)})
+				continue
+			}
+			remaining = append(remaining, op)
+		}
+		return remaining, skipped, false
+	}
+}
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestParseLsofOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		paths  []string
+		want   []LockedFile
+	}{
+		{
+			name:   "nothing open",
+			output: "",
+			paths:  []string{"/d/a.txt"},
+			want:   nil,
+		},
+		{
+			name:   "single file",
+			output: "p123\ncvim\nn/d/a.txt\n",
+			paths:  []string{"/d/a.txt", "/d/b.txt"},
+			want:   []LockedFile{{Path: "/d/a.txt", Process: "vim"}},
+		},
+		{
+			name:   "first program wins",
+			output: "p1\ncvim\nn/d/a.txt\np2\ncless\nn/d/a.txt\nn/d/b.txt\n",
+			paths:  []string{"/d/a.txt", "/d/b.txt"},
+			want: []LockedFile{
+				{Path: "/d/a.txt", Process: "vim"},
+				{Path: "/d/b.txt", Process: "less"},
+			},
+		},
+		{
+			name:   "unrequested and deleted names",
+			output: "p1\ncsoffice\nn/d/other.txt\nn/d/a.txt (deleted)\n",
+			paths:  []string{"/d/a.txt"},
+			want:   []LockedFile{{Path: "/d/a.txt", Process: "soffice"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseLsofOutput([]byte(tt.output), tt.paths)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLsofOutput() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteOperationsAbortOnLockedFiles(t *testing.T) {
+	if _, err := exec.LookPath("lsof"); err != nil || runtime.GOOS == "windows" {
+		t.Skip("needs lsof")
+	}
+	dir := t.TempDir()
+	operations := []FileOperation{
+		{From: filepath.Join(dir, "open.txt"), To: filepath.Join(dir, "docs", "open.txt")},
+		{From: filepath.Join(dir, "closed.txt"), To: filepath.Join(dir, "docs", "closed.txt")},
+	}
+	for _, op := range operations {
+		if err := os.WriteFile(op.From, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	open, err := os.Open(operations[0].From)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer open.Close()
+
+	logger := NewLogger(false)
+	fs := NewFileService(NewValidator(), logger)
+	asked := 0
+	result, err := fs.ExecuteOperations(operations, dir, ExecutionOptions{
+		Parallelism:    1,
+		CheckOpenFiles: true,
+		OnLocked: func(files []LockedFile) LockedDecision {
+			asked++
+			return LockedAbort
+		},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteOperations() error = %v", err)
+	}
+	if asked != 1 {
+		t.Fatalf("OnLocked asked %d times, want 1 (lsof didn't see the open file?)", asked)
+	}
+	if result.SuccessCount != 0 || !reflect.DeepEqual(result.Remaining(), operations) {
+		t.Errorf("aborted execution = %+v, want every operation left to resume", result)
+	}
+	if _, err := os.Stat(operations[1].From); err != nil {
+		t.Errorf("a file was moved after aborting: %v", err)
+	}
+}
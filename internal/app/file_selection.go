@@ -0,0 +1,162 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var ErrSelectionUnsupported = errors.New("the AI service can't select files")
+
+const fileSelectionSystemPrompt = `You select files for a file organization assistant.
+The user describes which files they want organized. Pick every listed file that matches the description, using names, types, sizes and descriptions when present.
+Output one JSON object per line for each matching file, with its path exactly as listed:
+{"path": "Desktop/Screenshot 2024-05-01.png"}
+Output nothing else: no markdown, no commentary. If no file matches, output nothing.`
+
+// selectionPrompt matches prompts that are about part of a folder, e.g. "only organize the screenshots"
+var selectionPrompt = regexp.MustCompile(`(?i)^\s*(?:please\s+)?(?:only|just)\s+(?:organi[sz]e|sort|tidy|clean|move|file)\b`)
+
+// IsSelectionPrompt reports whether the prompt limits itself to some of the files, so they should
+// be selected and confirmed before planning
+func IsSelectionPrompt(prompt string) bool {
+	return selectionPrompt.MatchString(prompt)
+}
+
+// FileSelection is the set of files a query picked out of a directory
+type FileSelection struct {
+	Files        []string // Absolute paths of listed files
+	Hallucinated []string // Paths the model returned that weren't listed
+	Error        error
+}
+
+// SelectFiles asks the model which files of the structure match query. Paths are returned as
+// the model wrote them, relative to basePath.
+func (s *OpenAIService) SelectFiles(ctx context.Context, structure, query, basePath string) ([]string, error) {
+	reqBody := OpenAIRequest{
+		Model: s.config.Model,
+		Messages: []Message{
+			{Role: "system", Content: fileSelectionSystemPrompt},
+			{Role: "user", Content: s.buildUserPrompt(basePath, structure, query)},
+		},
+		MaxTokens:   s.config.planMaxTokens(),
+		Temperature: s.config.Temperature,
+		Stream:      false,
+	}
+
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", s.config.APIKey),
+		"HTTP-Referer":  "https://github.com/sandwichdoge/vibesandfolders",
+		"X-Title":       "VibesAndFolders",
+	}
+
+	s.logger.Info("Asking %s to select files for %q", s.config.Model, query)
+	body, err := s.httpClient.Post(ctx, s.config.Endpoint, headers, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("no response from LLM")
+	}
+
+	return parseSelection(response.Choices[0].Message.Content), nil
+}
+
+// parseSelection reads one path per line, skipping anything that isn't a path object
+func parseSelection(content string) []string {
+	var paths []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), ","))
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var selected struct {
+			Path string `json:"path"`
+		}
+		if err := json.Unmarshal([]byte(line), &selected); err != nil || selected.Path == "" {
+			continue
+		}
+		paths = append(paths, selected.Path)
+	}
+	return paths
+}
+
+// SelectFiles picks the files of req.DirectoryPath that req.UserPrompt is about. With deep
+// analysis the model sees the index descriptions too. Pass the confirmed files as
+// AnalysisRequest.OnlyFiles to plan just those.
+func (o *Orchestrator) SelectFiles(req AnalysisRequest) FileSelection {
+	var selection FileSelection
+	selector, ok := o.aiService.(FileSelector)
+	if !ok {
+		selection.Error = ErrSelectionUnsupported
+		return selection
+	}
+
+	ctx := o.budgetContext(req)
+	structure, err := o.prepareStructure(ctx, &req)
+	if err != nil {
+		selection.Error = err
+		return selection
+	}
+	if len(req.OnlyFiles) > 0 {
+		structure = restrictStructure(req.DirectoryPath, structure, req.OnlyFiles)
+	}
+	if since := o.lastExecution(req.DirectoryPath); req.NewFilesOnly && !since.IsZero() {
+		structure, _ = restrictToNewFiles(req.DirectoryPath, structure, since)
+	}
+
+	paths, err := selector.SelectFiles(ctx, structure, req.UserPrompt, req.DirectoryPath)
+	if err != nil {
+		selection.Error = fmt.Errorf("failed to select files: %w", err)
+		return selection
+	}
+
+	grounding := NewStructureGrounding(structure)
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		rel := strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(p, "\\", "/")), "/")
+		switch {
+		case seen[rel]:
+		case grounding.paths[rel] && !grounding.IsFolder(rel):
+			p, _ := JoinStoragePath(req.DirectoryPath, rel)
+			selection.Files = append(selection.Files, p)
+		default:
+			selection.Hallucinated = append(selection.Hallucinated, p)
+		}
+		seen[rel] = true
+	}
+	o.logger.Info("Selected %d files (%d unknown paths dropped)", len(selection.Files), len(selection.Hallucinated))
+	return selection
+}
+
+// restrictStructure leaves only the listed files in a text structure. Folders stay, so the
+// model can still move the files into them.
+func restrictStructure(basePath, structure string, files []string) string {
+	keep := make(map[string]bool, len(files))
+	for _, f := range files {
+		keep[relativeSlashPath(basePath, f)] = true
+	}
+	var kept []string
+	for _, line := range strings.Split(structure, "\n") {
+		if m := structureFileLine.FindStringSubmatch(strings.TrimSpace(line)); m != nil && !keep[m[1]] {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
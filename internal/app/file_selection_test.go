@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsSelectionPrompt(t *testing.T) {
+	tests := []struct {
+		prompt string
+		want   bool
+	}{
+		{"only organize the screenshots", true},
+		{"Just sort the PDFs by year", true},
+		{"Please only tidy up the invoices", true},
+		{"Organize everything by type", false},
+		{"Put only images into Photos", false},
+	}
+	for _, tt := range tests {
+		if got := IsSelectionPrompt(tt.prompt); got != tt.want {
+			t.Errorf("IsSelectionPrompt(%q) = %v, want %v", tt.prompt, got, tt.want)
+		}
+	}
+}
+
+func TestParseSelection(t *testing.T) {
+	content := "Here you go:\n{\"path\": \"shots/a.png\"},\n{\"path\": \"\"}\n{broken\n{\"path\": \"b.png\"}"
+	if got := strings.Join(parseSelection(content), ", "); got != "shots/a.png, b.png" {
+		t.Errorf("parseSelection() = %s", got)
+	}
+}
+
+// selectingAIService selects a fixed list of paths and remembers the structure it planned from
+type selectingAIService struct {
+	stubAIService
+	selected      []string
+	lastStructure string
+}
+
+func (s *selectingAIService) SelectFiles(_ context.Context, structure, query, basePath string) ([]string, error) {
+	return s.selected, nil
+}
+
+func (s *selectingAIService) GetSuggestions(ctx context.Context, structure, userPrompt, basePath string, onOperation OperationCallback) ([]FileOperation, error) {
+	s.lastStructure = structure
+	return s.stubAIService.GetSuggestions(ctx, structure, userPrompt, basePath, onOperation)
+}
+
+func TestSelectFilesThenPlanSelection(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"Screenshot 1.png", "Screenshot 2.png", "report.pdf"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logger := NewLogger(false)
+	validator := NewValidator()
+	ai := &selectingAIService{
+		selected: []string{"Screenshot 1.png", "./Screenshot 2.png", "Screenshot 1.png", "Screenshot 3.png"},
+		stubAIService: stubAIService{operations: []FileOperation{
+			{From: "Screenshot 1.png", To: "Screenshots/Screenshot 1.png"},
+			{From: "report.pdf", To: "Documents/report.pdf"}, // Not selected, so never shown to the model
+		}},
+	}
+	orchestrator := NewOrchestrator(ai, NewFileService(validator, logger), validator, logger, nil, nil, NewHookRunner(&Config{}, logger))
+
+	req := AnalysisRequest{DirectoryPath: dir, UserPrompt: "only organize the screenshots", MaxDepth: 1}
+	selection := orchestrator.SelectFiles(req)
+	if selection.Error != nil {
+		t.Fatal(selection.Error)
+	}
+	if len(selection.Files) != 2 || len(selection.Hallucinated) != 1 {
+		t.Fatalf("selected %v, dropped %v; want both screenshots and the made-up one dropped", selection.Files, selection.Hallucinated)
+	}
+
+	req.OnlyFiles = selection.Files
+	result := orchestrator.AnalyzeDirectory(req, nil)
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	if strings.Contains(ai.lastStructure, "report.pdf") || !strings.Contains(ai.lastStructure, "Screenshot 2.png") {
+		t.Errorf("model was shown %q", ai.lastStructure)
+	}
+	if len(result.Operations) != 1 || len(result.Hallucinated) != 1 {
+		t.Errorf("got %d operations and %d hallucinated, want 1 each", len(result.Operations), len(result.Hallucinated))
+	}
+}
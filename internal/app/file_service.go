@@ -1,65 +1,97 @@
 package app
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type DefaultFileService struct {
 	validator      *Validator
 	logger         *Logger
 	ignoreMatcher  *IgnorePatternMatcher
+	structureCache *StructureCache
+	walk           walkOptions
+	folderLabeler  *FolderLabeler
 }
 
 func NewFileService(validator *Validator, logger *Logger) *DefaultFileService {
 	return &DefaultFileService{
-		validator:     validator,
-		logger:        logger,
-		ignoreMatcher: nil, // Will be set when needed
+		validator:      validator,
+		logger:         logger,
+		ignoreMatcher:  nil, // Will be set when needed
+		structureCache: NewStructureCache(),
 	}
 }
 
+// SetFolderLabeler marks the folders ExecuteOperation creates; nil leaves them unmarked
+func (fs *DefaultFileService) SetFolderLabeler(labeler *FolderLabeler) {
+	fs.folderLabeler = labeler
+}
+
 // SetIgnorePatterns configures the ignore pattern matcher
 func (fs *DefaultFileService) SetIgnorePatterns(patterns string) {
-	if patterns == "" {
+	ignoreHidden := fs.ignoreMatcher.IgnoresHidden()
+	if patterns == "" && !ignoreHidden {
 		fs.ignoreMatcher = nil
+		fs.structureCache.Invalidate("")
 		return
 	}
 	fs.ignoreMatcher = NewIgnorePatternMatcher(patterns, fs.logger)
+	fs.ignoreMatcher.SetIgnoreHidden(ignoreHidden)
+	fs.structureCache.Invalidate("")
 }
 
-func (fs *DefaultFileService) CountFiles(rootPath string) (int, error) {
-	count := 0
-	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+// SetIgnoreHidden skips dotfiles and files marked hidden on Windows, on top of the ignore patterns
+func (fs *DefaultFileService) SetIgnoreHidden(ignore bool) {
+	if fs.ignoreMatcher == nil {
+		fs.ignoreMatcher = NewIgnorePatternMatcher("", fs.logger)
+	}
+	fs.ignoreMatcher.SetIgnoreHidden(ignore)
+	fs.structureCache.Invalidate("")
+}
 
-		// Check if path should be ignored
-		if fs.ignoreMatcher != nil && path != rootPath {
-			relPath, err := filepath.Rel(rootPath, path)
-			if err == nil && fs.ignoreMatcher.ShouldIgnore(relPath, info.IsDir()) {
-				if info.IsDir() {
-					return filepath.SkipDir
-				}
-				return nil
-			}
-		}
+// SetWalkConcurrency reads up to workers directories at once while walking a tree, at most batch
+// listings ahead of the walk; workers <= 1 walks one directory at a time
+func (fs *DefaultFileService) SetWalkConcurrency(workers, batch int) {
+	fs.walk = walkOptions{workers: workers, batch: batch}
+}
 
-		if !info.IsDir() {
-			count++
-		}
-		return nil
-	})
-	return count, err
+// InvalidateStructureCache forces the next GetDirectoryStructure under path to walk the tree again
+func (fs *DefaultFileService) InvalidateStructureCache(path string) {
+	fs.structureCache.Invalidate(path)
+}
+
+func (fs *DefaultFileService) CountFiles(rootPath string) (int, error) {
+	files, err := fs.listFiles(rootPath)
+	return len(files), err
 }
 
-func (fs *DefaultFileService) GetDirectoryStructure(rootPath string, maxDepth int) (string, error) {
+// GetDirectoryStructure walks rootPath and lists its contents one entry per line.
+// onProgress (optional) receives periodic updates during the walk.
+func (fs *DefaultFileService) GetDirectoryStructure(rootPath string, maxDepth int, onProgress ScanProgressCallback) (string, error) {
+	if structure, files, ok := fs.structureCache.Get(rootPath, maxDepth); ok {
+		fs.logger.Debug("Using cached directory structure for %s (depth: %d)", rootPath, maxDepth)
+		progress := newScanProgressReporter(onProgress)
+		for _, relPath := range files {
+			progress.fileScanned(relPath)
+		}
+		progress.finish()
+		return structure, nil
+	}
+
 	var builder strings.Builder
-	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+	dirMtimes := make(map[string]time.Time)
+	var files []string
+	progress := newScanProgressReporter(onProgress)
+	placeholders := 0
+	err := walkTree(rootPath, fs.walk, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -76,7 +108,7 @@ func (fs *DefaultFileService) GetDirectoryStructure(rootPath string, maxDepth in
 		relPath = filepath.ToSlash(relPath)
 
 		// Check if path should be ignored
-		if fs.ignoreMatcher != nil && fs.ignoreMatcher.ShouldIgnore(relPath, info.IsDir()) {
+		if fs.ignoreMatcher != nil && fs.ignoreMatcher.ShouldIgnoreFile(relPath, info) {
 			if info.IsDir() {
 				// Show the ignored directory name (for context) but skip its contents
 				builder.WriteString(fmt.Sprintf("%s/\n", relPath))
@@ -86,6 +118,11 @@ func (fs *DefaultFileService) GetDirectoryStructure(rootPath string, maxDepth in
 			return nil
 		}
 
+		// Sidecars travel with their files, so the model never needs to see them
+		if !info.IsDir() && isSidecarFile(path) {
+			return nil
+		}
+
 		currentDepth := len(strings.Split(relPath, "/"))
 
 		if maxDepth > 0 && currentDepth > maxDepth {
@@ -95,25 +132,115 @@ func (fs *DefaultFileService) GetDirectoryStructure(rootPath string, maxDepth in
 			return nil
 		}
 
-		if info.IsDir() {
+		if isBundleDir(info) {
+			// Apps and document packages are listed as files so the model never rearranges their insides
+			builder.WriteString(fmt.Sprintf("%s (%d bytes)\n", relPath, bundleSize(path)))
+			dirMtimes[path] = info.ModTime()
+			files = append(files, relPath)
+			progress.fileScanned(relPath)
+			return filepath.SkipDir
+		} else if info.IsDir() {
+			dirMtimes[path] = info.ModTime()
 			builder.WriteString(fmt.Sprintf("%s/\n", relPath))
 		} else {
+			if IsCloudPlaceholder(path, info) {
+				placeholders++
+			}
+			files = append(files, relPath)
 			builder.WriteString(fmt.Sprintf("%s (%d bytes)\n", relPath, info.Size()))
+			progress.fileScanned(relPath)
 		}
 
 		return nil
 	})
+	progress.finish()
+
+	if placeholders > 0 {
+		fs.logger.Info("%d files under %s are online-only; deep analysis won't download them unless allowed in settings", placeholders, rootPath)
+	}
+
+	if err == nil {
+		if info, statErr := os.Stat(rootPath); statErr == nil {
+			dirMtimes[filepath.Clean(rootPath)] = info.ModTime()
+			fs.structureCache.Put(rootPath, maxDepth, builder.String(), dirMtimes, files)
+		}
+	}
 
 	return builder.String(), err
 }
 
+// structureFileLine matches a file line of the text structure: "path (123 bytes)", optionally
+// enriched with an index description as "path [description] (123 bytes)"
+var structureFileLine = regexp.MustCompile(`^(.+?)(?: \[(.*)\])? \((\d+) bytes\)$`)
+
+// structureFile is a file node of the JSON structure tree
+type structureFile struct {
+	Size        int64  `json:"size"`
+	Description string `json:"description,omitempty"`
+}
+
+// FormatStructureAsJSON converts the text structure produced by GetDirectoryStructure (enriched or not)
+// into a compact JSON tree. Folders are objects keyed by name with a trailing "/", files map to
+// {"size": N} plus their description when one is indexed, e.g.
+//
+//	{"docs/":{"report.pdf":{"size":1024,"description":"Quarterly report"}},"notes.txt":{"size":12}}
+func FormatStructureAsJSON(structure string) (string, error) {
+	root := make(map[string]interface{})
+
+	for _, line := range strings.Split(structure, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		var parts []string
+		var leaf interface{}
+		if strings.HasSuffix(line, "/") {
+			parts = strings.Split(strings.TrimSuffix(line, "/"), "/")
+		} else if m := structureFileLine.FindStringSubmatch(line); m != nil {
+			size, _ := strconv.ParseInt(m[3], 10, 64)
+			parts = strings.Split(m[1], "/")
+			leaf = structureFile{Size: size, Description: m[2]}
+		} else {
+			return "", fmt.Errorf("unrecognized structure line: %s", line)
+		}
+
+		// Walk (and create) the parent folders
+		node := root
+		for _, dir := range parts[:len(parts)-1] {
+			child, ok := node[dir+"/"].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				node[dir+"/"] = child
+			}
+			node = child
+		}
+
+		name := parts[len(parts)-1]
+		if leaf != nil {
+			node[name] = leaf
+		} else if _, ok := node[name+"/"]; !ok {
+			node[name+"/"] = make(map[string]interface{})
+		}
+	}
+
+	data, err := json.Marshal(root)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
 func (fs *DefaultFileService) CleanEmptyDirectories(rootPath string) (int, error) {
 	var dirs []string
 
-	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+	err := walkTree(rootPath, fs.walk, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+		if isBundleDir(info) && path != rootPath {
+			return filepath.SkipDir // Empty folders inside bundles are part of them
+		}
 		if info.IsDir() && path != rootPath {
 			dirs = append(dirs, path)
 		}
@@ -255,38 +382,115 @@ func (fs *DefaultFileService) commonAncestorOfTwo(path1, path2 string) string {
 	return result
 }
 
-func (fs *DefaultFileService) ExecuteOperations(operations []FileOperation, basePath string, cleanEmpty bool) (ExecutionResult, error) {
+func (fs *DefaultFileService) ExecuteOperations(operations []FileOperation, basePath string, opts ExecutionOptions) (ExecutionResult, error) {
 	result := ExecutionResult{
 		Operations: make([]OperationResult, 0, len(operations)),
 	}
 
+	if isNetwork, fsType := IsNetworkMount(basePath); isNetwork {
+		fs.logger.Info("Target %s is on a network mount (%s); will pause if it goes offline", basePath, fsType)
+	}
+
 	// Determine all paths that need verification (basePath + any external destinations)
 	verificationPaths := fs.determineVerificationScope(operations, basePath)
 
-	// Count files across all verification paths before execution
+	// List files across all verification paths before execution
 	initialCount := 0
+	var filesBefore []string
 	for _, path := range verificationPaths {
-		count, err := fs.CountFiles(path)
+		files, err := fs.listFiles(path)
 		if err != nil {
 			result.VerificationError = fmt.Errorf("integrity check failed for %s: %w", path, err)
 			return result, result.VerificationError
 		}
-		initialCount += count
+		initialCount += len(files)
+		filesBefore = append(filesBefore, files...)
 	}
 	result.InitialFileCount = initialCount
 
-	for _, op := range operations {
-		opResult := fs.ExecuteOperation(op)
-		result.Operations = append(result.Operations, opResult)
+	// Directory mtimes may have a coarse resolution; never trust the cache after our own moves
+	defer fs.structureCache.Invalidate("")
 
-		if opResult.Success {
-			result.SuccessCount++
-		} else {
-			result.FailCount++
+	operations, skipped, cancelled := fs.preflightLocks(operations, opts)
+	if cancelled {
+		// Nothing was moved; like a stop, the whole plan can be resumed once the files are closed
+		fs.stopRemaining(&result, operations)
+		result.VerificationError = fmt.Errorf("execution cancelled: %w", ErrFileInUse)
+		result.FinalFileCount = result.InitialFileCount
+		return result, nil
+	}
+	for _, opResult := range skipped {
+		fs.recordResult(&result, opResult)
+	}
+
+	// Fail fast on read-only or full locations instead of midway through the plan
+	if err := fs.validator.ValidateWritable(operations); err != nil {
+		fs.logger.Error("Preflight check failed: %v", err)
+		fs.abortRemaining(&result, operations, err)
+		result.VerificationError = fmt.Errorf("nothing was moved: %w", err)
+		result.FinalFileCount = result.InitialFileCount
+		return result, nil
+	}
+
+	var permissionsBefore map[string][]permissionState
+	if opts.AuditPermissions {
+		permissionsBefore = snapshotPermissions(operations)
+	}
+	var hashesBefore []hashedFile
+	if opts.HashSample != 0 {
+		hashesBefore = snapshotHashes(operations, opts.HashSample, nil)
+		fs.logger.Info("Hashed %d moved files to verify their content afterwards", len(hashesBefore))
+	}
+
+	if opts.Staged {
+		fs.executeStaged(&result, operations, basePath, opts.Control, heartbeatFor(opts))
+	} else if opts.Parallelism > 1 && len(operations) > 1 && opts.ThrottleDelay == 0 {
+		fs.executeParallel(&result, operations, basePath, opts)
+	} else {
+		batch := max(opts.ThrottleBatch, 1)
+		if opts.ThrottleDelay > 0 {
+			fs.logger.Info("Throttling: pausing %v after every %d moves", opts.ThrottleDelay, batch)
+		}
+		for i, op := range operations {
+			if opts.ThrottleDelay > 0 && i > 0 && i%batch == 0 {
+				time.Sleep(opts.ThrottleDelay)
+			}
+			if !opts.Control.proceed() {
+				fs.stopRemaining(&result, operations[i:])
+				break
+			}
+			opResult, ok := fs.executeWithOfflinePause(op, basePath, opts.OnOffline, heartbeatFor(opts))
+			if !ok {
+				fs.abortRemaining(&result, operations[i:], ErrPathOffline)
+				break
+			}
+			fs.recordResult(&result, opResult)
+		}
+	}
+
+	if opts.AuditPermissions {
+		result.PermissionChanges = auditPermissions(result.Operations, permissionsBefore)
+		if len(result.PermissionChanges) > 0 {
+			fs.logger.Info("%d moved items have different permissions or owners than before", len(result.PermissionChanges))
+		}
+	}
+
+	if result.Aborted {
+		// Skip cleanup and recount; the location can't be trusted right now
+		result.VerificationError = fmt.Errorf("execution aborted: %w", ErrPathOffline)
+		result.FinalFileCount = result.InitialFileCount
+		return result, nil
+	}
+
+	// A stopped run may be resumed, so leave the folders as they are until it is finished
+	if len(hashesBefore) > 0 {
+		result.HashesVerified, result.HashMismatches = verifyHashes(result.Operations, hashesBefore)
+		for _, mismatch := range result.HashMismatches {
+			fs.logger.Error("Content check failed for %s", mismatch.String())
 		}
 	}
 
-	if cleanEmpty {
+	if opts.CleanEmpty && !result.Stopped {
 		cleaned, err := fs.CleanEmptyDirectories(basePath)
 		if err != nil {
 			fs.logger.Error("Failed to clean empty directories: %v", err)
@@ -295,20 +499,89 @@ func (fs *DefaultFileService) ExecuteOperations(operations []FileOperation, base
 		}
 	}
 
-	// Count files across all verification paths after execution
+	// List files across all verification paths after execution
 	finalCount := 0
+	var filesAfter []string
 	for _, path := range verificationPaths {
-		count, err := fs.CountFiles(path)
+		files, err := fs.listFiles(path)
 		if err != nil {
 			result.VerificationError = fmt.Errorf("post-execution count failed for %s: %w", path, err)
 		}
-		finalCount += count
+		finalCount += len(files)
+		filesAfter = append(filesAfter, files...)
 	}
 	result.FinalFileCount = finalCount
 
+	// Compare file by file with where the successful operations should have put things
+	if result.VerificationError == nil {
+		result.Layout = diffLayouts(expectedLayout(filesBefore, result.Operations), filesAfter)
+		if !result.Layout.Empty() {
+			fs.logger.Error("Folder differs from the plan: %d unexpected and %d missing files", len(result.Layout.Unexpected), len(result.Layout.Missing))
+			result.VerificationError = fmt.Errorf("%w: %d unexpected and %d missing files", ErrLayoutMismatch, len(result.Layout.Unexpected), len(result.Layout.Missing))
+		}
+	}
+	if len(result.HashMismatches) > 0 && result.VerificationError == nil {
+		result.VerificationError = fmt.Errorf("%w: %d of %d checked files", ErrContentChanged, len(result.HashMismatches), result.HashesVerified)
+	}
+
 	return result, nil
 }
 
+// executeWithOfflinePause runs op and, when a failure turns out to be the whole base path dropping
+// off the network, pauses via onOffline instead of failing every remaining operation in a row.
+// It returns false if the user chose to abort.
+func (fs *DefaultFileService) executeWithOfflinePause(op FileOperation, basePath string, onOffline OfflineHandler, beat heartbeat) (OperationResult, bool) {
+	for {
+		opResult := beat.run(fs.logger, op, func() OperationResult { return fs.ExecuteOperation(op) })
+		if opResult.Success {
+			return opResult, true
+		}
+
+		err := CheckPathReachable(basePath, defaultReachabilityTimeout)
+		if err == nil {
+			return opResult, true
+		}
+		fs.logger.Error("Base path %s is unreachable: %v", basePath, err)
+		if onOffline == nil || onOffline(basePath, err) != OfflineRetry {
+			return opResult, false
+		}
+		fs.logger.Info("Retrying operation after offline pause: %s", op.From)
+	}
+}
+
+func (fs *DefaultFileService) recordResult(result *ExecutionResult, opResult OperationResult) {
+	result.Operations = append(result.Operations, opResult)
+	if opResult.Success {
+		result.SuccessCount++
+	} else {
+		result.FailCount++
+	}
+}
+
+// abortRemaining marks every not-yet-executed operation as failed with reason and flags the result as aborted
+func (fs *DefaultFileService) abortRemaining(result *ExecutionResult, remaining []FileOperation, reason error) {
+	for _, op := range remaining {
+		result.Operations = append(result.Operations, OperationResult{
+			Operation: op,
+			Success:   false,
+			Error:     reason,
+		})
+		result.FailCount++
+	}
+	result.Aborted = true
+	fs.logger.Info("Execution aborted with %d operations not attempted: %v", len(remaining), reason)
+}
+
+// stopRemaining records every not-yet-executed operation as not attempted, so a stopped run can be
+// resumed with ExecutionResult.Remaining or rolled back from its successful operations
+func (fs *DefaultFileService) stopRemaining(result *ExecutionResult, remaining []FileOperation) {
+	for _, op := range remaining {
+		fs.recordResult(result, OperationResult{Operation: op, Error: ErrExecutionStopped})
+	}
+	result.Stopped = true
+	fs.logger.Info("Execution stopped with %d operations not attempted", len(remaining))
+}
+
 func (fs *DefaultFileService) ExecuteOperation(op FileOperation) OperationResult {
 	result := OperationResult{
 		Operation: op,
@@ -349,6 +622,7 @@ func (fs *DefaultFileService) ExecuteOperation(op FileOperation) OperationResult
 
 	// Store the created directories in the result
 	result.CreatedDirs = createdDirs
+	fs.folderLabeler.Label(createdDirs)
 
 	// Check if source is a symlink using Lstat (doesn't follow symlinks)
 	fileInfo, err := os.Lstat(op.From)
@@ -421,10 +695,17 @@ func (fs *DefaultFileService) ExecuteOperation(op FileOperation) OperationResult
 
 	// For regular files and directories, use os.Rename
 	if err := os.Rename(op.From, op.To); err != nil {
+		if isSharingViolation(err) {
+			err = fmt.Errorf("%w: %v", ErrFileInUse, err)
+		}
 		result.Error = err
 		return result
 	}
 
+	if !fileInfo.IsDir() {
+		moveSidecars(op.From, op.To, fs.logger)
+	}
+
 	result.Success = true
 	fs.logger.Debug("Successfully moved: %s -> %s", op.From, op.To)
 	return result
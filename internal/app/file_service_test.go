@@ -1,10 +1,13 @@
 package app
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDetermineVerificationScope(t *testing.T) {
@@ -144,7 +147,7 @@ func TestExecuteOperations_WithParentMoveVerification(t *testing.T) {
 	}
 
 	// Execute operations with subfolder as basePath
-	result, err := fs.ExecuteOperations(operations, subfolder, false)
+	result, err := fs.ExecuteOperations(operations, subfolder, ExecutionOptions{})
 
 	if err != nil {
 		t.Fatalf("ExecuteOperations() returned error: %v", err)
@@ -229,7 +232,7 @@ func TestExecuteOperations_InternalMoveVerification(t *testing.T) {
 	}
 
 	// Execute operations with subfolder as basePath
-	result, err := fs.ExecuteOperations(operations, subfolder, false)
+	result, err := fs.ExecuteOperations(operations, subfolder, ExecutionOptions{})
 
 	if err != nil {
 		t.Fatalf("ExecuteOperations() returned error: %v", err)
@@ -249,3 +252,141 @@ func TestExecuteOperations_InternalMoveVerification(t *testing.T) {
 		t.Errorf("InitialFileCount = %d, want 2", result.InitialFileCount)
 	}
 }
+
+func TestExecuteOperations_ParallelKeepsDependentOrder(t *testing.T) {
+	baseDir := t.TempDir()
+	for i := 0; i < 20; i++ {
+		if err := os.WriteFile(filepath.Join(baseDir, fmt.Sprintf("file%02d.txt", i)), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Sibling moves into "sorted" may run concurrently, but the final rename of "sorted"
+	// conflicts with all of them and must wait until they are done
+	var ops []FileOperation
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("file%02d.txt", i)
+		ops = append(ops, FileOperation{From: filepath.Join(baseDir, name), To: filepath.Join(baseDir, "sorted", name)})
+	}
+	ops = append(ops, FileOperation{From: filepath.Join(baseDir, "sorted"), To: filepath.Join(baseDir, "archive")})
+
+	fs := NewFileService(NewValidator(), NewLogger(false))
+	result, err := fs.ExecuteOperations(ops, baseDir, ExecutionOptions{Parallelism: 8})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.FailCount != 0 || result.SuccessCount != len(ops) {
+		t.Fatalf("expected all %d operations to succeed, got %d ok / %d failed", len(ops), result.SuccessCount, result.FailCount)
+	}
+	entries, err := os.ReadDir(filepath.Join(baseDir, "archive"))
+	if err != nil || len(entries) != 20 {
+		t.Fatalf("expected 20 files in archive, got %d (err: %v)", len(entries), err)
+	}
+	for i, opResult := range result.Operations {
+		if opResult.Operation != ops[i] {
+			t.Fatalf("results not in plan order at %d", i)
+		}
+	}
+}
+
+func TestOperationsConflict(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b FileOperation
+		want bool
+	}{
+		{"same destination folder", FileOperation{From: "/b/x.txt", To: "/b/d/x.txt"}, FileOperation{From: "/b/y.txt", To: "/b/d/y.txt"}, true},
+		{"nested destination folders", FileOperation{From: "/b/x.txt", To: "/b/d/e/x.txt"}, FileOperation{From: "/b/y.txt", To: "/b/d/y.txt"}, true},
+		{"different destination folders", FileOperation{From: "/b/x.txt", To: "/b/d/x.txt"}, FileOperation{From: "/b/y.txt", To: "/b/e/y.txt"}, false},
+		{"chained rename", FileOperation{From: "/b/a", To: "/b/c"}, FileOperation{From: "/b/c", To: "/b/d"}, true},
+		{"move into moved folder", FileOperation{From: "/b/x.txt", To: "/b/d/x.txt"}, FileOperation{From: "/b/d", To: "/b/e"}, true},
+		{"prefix but not parent", FileOperation{From: "/b/doc", To: "/b/z/doc"}, FileOperation{From: "/b/docs.txt", To: "/b/y/docs.txt"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := operationsConflict(tt.a, tt.b); got != tt.want {
+				t.Errorf("operationsConflict() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDirectoryStructure_CacheInvalidatedByDirectoryChange(t *testing.T) {
+	baseDir := t.TempDir()
+	subDir := filepath.Join(baseDir, "docs")
+	if err := os.Mkdir(subDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(subDir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := NewFileService(NewValidator(), NewLogger(false))
+	first, err := fs.GetDirectoryStructure(baseDir, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := fs.structureCache.Get(baseDir, 0); !ok {
+		t.Fatal("expected structure to be cached")
+	}
+
+	// Adding a file changes the parent directory's mtime; bump it explicitly in case of coarse timestamps
+	if err := os.WriteFile(filepath.Join(subDir, "b.txt"), []byte("b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(subDir, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := fs.GetDirectoryStructure(baseDir, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second == first || !strings.Contains(second, "docs/b.txt") {
+		t.Errorf("expected a fresh walk to include the new file, got:\n%s", second)
+	}
+}
+
+func TestGetDirectoryStructure_CacheHitReportsProgress(t *testing.T) {
+	baseDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(baseDir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fs := NewFileService(NewValidator(), NewLogger(false))
+	var walked, cached ScanProgress
+	first, err := fs.GetDirectoryStructure(baseDir, 0, func(p ScanProgress) { walked = p })
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := fs.GetDirectoryStructure(baseDir, 0, func(p ScanProgress) { cached = p })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second != first {
+		t.Fatalf("expected the cached structure, got:\n%s", second)
+	}
+	if !cached.Done || cached.FilesScanned != walked.FilesScanned || cached.FilesScanned != 3 {
+		t.Errorf("cache hit reported %+v, walk reported %+v", cached, walked)
+	}
+}
+
+func TestFormatStructureAsJSON(t *testing.T) {
+	structure := "docs/\ndocs/report.pdf [Quarterly (Q3) report] (1024 bytes)\nnode_modules/\nnotes.txt (12 bytes)\n"
+
+	got, err := FormatStructureAsJSON(structure)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"docs/":{"report.pdf":{"size":1024,"description":"Quarterly (Q3) report"}},"node_modules/":{},"notes.txt":{"size":12}}`
+	if got != want {
+		t.Errorf("FormatStructureAsJSON() =\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := FormatStructureAsJSON("not a structure line"); err == nil {
+		t.Error("expected error for unrecognized line")
+	}
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// FileTypeMapping assigns file extensions to one of the AnalysisFileTypes
+type FileTypeMapping struct {
+	Extensions []string // Lowercase, with the leading dot
+	FileType   string
+}
+
+// builtinFileTypes is the extension table used when no configured mapping covers an extension
+var builtinFileTypes = []FileTypeMapping{
+	{[]string{".txt", ".md", ".markdown", ".json", ".xml", ".yaml", ".yml", ".toml", ".ini", ".cfg", ".conf"}, "text"},
+	{[]string{".go", ".py", ".js", ".ts", ".java", ".c", ".cpp", ".h", ".hpp", ".rs", ".rb", ".php", ".sh", ".bash"}, "code"},
+	{[]string{".jpg", ".jpeg", ".png", ".gif", ".bmp", ".svg", ".webp", ".ico"}, "image"},
+	{append(slices.Clone(heifExtensions), rawExtensions...), "image"}, // Converted before analysis
+	{[]string{".mp4", ".avi", ".mkv", ".mov", ".wmv", ".flv", ".webm"}, "video"},
+	{[]string{".mp3", ".wav", ".flac", ".aac", ".ogg", ".wma", ".m4a"}, "audio"},
+	{[]string{".pdf"}, "pdf"},
+	{[]string{".xls", ".xlsx", ".ods"}, "excel"},
+	{[]string{".csv", ".tsv"}, "csv"},
+	{[]string{".ipynb"}, "notebook"},
+	{[]string{".doc", ".docx", ".odt", ".rtf"}, "document"},
+	{[]string{".ppt", ".pptx", ".odp"}, "powerpoint"},
+}
+
+// BuiltinFileTypes returns the built-in extension table, for display
+func BuiltinFileTypes() []FileTypeMapping {
+	return slices.Clone(builtinFileTypes)
+}
+
+// ParseFileTypeMappings parses the file type configuration: one mapping per line in the form
+// ".ext1,.ext2: type" (e.g. ".heic, .dng: image"), where type is one of AnalysisFileTypes.
+// Blank lines and lines starting with # are ignored.
+func ParseFileTypeMappings(spec string) (map[string]string, error) {
+	mappings := make(map[string]string)
+
+	for i, line := range strings.Split(spec, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		extPart, fileType, found := strings.Cut(line, ":")
+		fileType = strings.ToLower(strings.TrimSpace(fileType))
+		if !found || strings.TrimSpace(extPart) == "" {
+			return nil, fmt.Errorf("line %d: expected \".ext1,.ext2: type\"", i+1)
+		}
+		if !slices.Contains(AnalysisFileTypes, fileType) {
+			return nil, fmt.Errorf("line %d: unknown file type %q (expected one of %s)", i+1, fileType, strings.Join(AnalysisFileTypes, ", "))
+		}
+
+		for _, ext := range strings.Split(extPart, ",") {
+			ext = strings.ToLower(strings.TrimSpace(ext))
+			if ext == "" {
+				continue
+			}
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			mappings[ext] = fileType
+		}
+	}
+
+	return mappings, nil
+}
+
+// fileTypeTable holds the configured mappings for DetermineFileType, which has no config of its
+// own. They are re-read whenever the config text changes.
+type fileTypeTable struct {
+	mu       sync.Mutex
+	config   *Config
+	spec     string
+	mappings map[string]string
+}
+
+var fileTypeMappings = &fileTypeTable{}
+
+// UseFileTypeMappings makes DetermineFileType apply the mappings configured in config
+func UseFileTypeMappings(config *Config) {
+	fileTypeMappings.mu.Lock()
+	defer fileTypeMappings.mu.Unlock()
+	fileTypeMappings.config = config
+	fileTypeMappings.spec = ""
+	fileTypeMappings.mappings = nil
+}
+
+func (t *fileTypeTable) lookup(ext string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.config == nil {
+		return "", false
+	}
+
+	if t.config.FileTypeMappings != t.spec || t.mappings == nil {
+		// Invalid lines are rejected when the config is saved; keep the last good table otherwise
+		if mappings, err := ParseFileTypeMappings(t.config.FileTypeMappings); err == nil {
+			t.mappings = mappings
+		}
+		t.spec = t.config.FileTypeMappings
+	}
+
+	fileType, ok := t.mappings[ext]
+	return fileType, ok
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseFileTypeMappings(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"empty", "", map[string]string{}, false},
+		{"several extensions", ".heic, .DNG: image\n# GPS tracks\ngpx: Text", map[string]string{".heic": "image", ".dng": "image", ".gpx": "text"}, false},
+		{"unknown type", ".heic: photo", nil, true},
+		{"missing type", ".heic", nil, true},
+		{"missing extensions", ": image", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFileTypeMappings(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for ext, fileType := range tt.want {
+				if got[ext] != fileType {
+					t.Errorf("%s: expected %s, got %s", ext, fileType, got[ext])
+				}
+			}
+		})
+	}
+}
+
+func TestDetermineFileType_ConfiguredMappings(t *testing.T) {
+	config := &Config{FileTypeMappings: ".kra: image\n.cfg: code"}
+	UseFileTypeMappings(config)
+	t.Cleanup(func() { UseFileTypeMappings(nil) })
+
+	dir := t.TempDir() // The files don't exist, so only extensions count
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"sketch.KRA", "image"},
+		{"app.cfg", "code"}, // Overrides the built-in table
+		{"notes.txt", "text"},
+		{"track.gpx", "other"},
+		{"IMG_0001.HEIC", "image"}, // Built in, converted before analysis
+	}
+	for _, tt := range tests {
+		if got := DetermineFileType(filepath.Join(dir, tt.file)); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.file, tt.want, got)
+		}
+	}
+
+	// Edits to the config apply without calling UseFileTypeMappings again
+	config.FileTypeMappings = ".gpx: text"
+	if got := DetermineFileType(filepath.Join(dir, "track.gpx")); got != "text" {
+		t.Errorf("expected the edited mapping to apply, got %s", got)
+	}
+	if got := DetermineFileType(filepath.Join(dir, "sketch.kra")); got != "other" {
+		t.Errorf("expected the removed mapping to no longer apply, got %s", got)
+	}
+}
@@ -0,0 +1,121 @@
+package app
+
+import (
+	"archive/zip"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Groups of file types that share a container format, so content sniffing can't tell them apart
+// and the extension decides
+var fileTypeFamilies = map[string]string{
+	"audio": "media", "video": "media", // MP4 and WebM carry either
+	"text": "text", "code": "text", "csv": "text", "notebook": "text",
+	"document": "office", "excel": "office", "powerpoint": "office",
+}
+
+// sniffFileType determines a file's type from its first bytes (magic numbers), or returns "" when
+// the file can't be read or its content is inconclusive. Online-only cloud files are not read,
+// since that would download them.
+func sniffFileType(filePath string) string {
+	info, err := os.Stat(filePath)
+	if err != nil || !info.Mode().IsRegular() || info.Size() == 0 || IsCloudPlaceholder(filePath, info) {
+		return ""
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	head := make([]byte, 512) // All DetectContentType looks at
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return ""
+	}
+	contentType := http.DetectContentType(head[:n])
+
+	switch {
+	case strings.HasPrefix(contentType, "image/"):
+		return "image"
+	case strings.HasPrefix(contentType, "video/"):
+		return "video"
+	case strings.HasPrefix(contentType, "audio/"), contentType == "application/ogg":
+		return "audio"
+	case contentType == "application/pdf":
+		return "pdf"
+	case contentType == "application/zip":
+		return sniffZipType(filePath)
+	case strings.HasPrefix(contentType, "text/"):
+		return "text"
+	default:
+		return ""
+	}
+}
+
+// sniffZipType recognizes Office Open XML and OpenDocument files, which are zip archives
+func sniffZipType(filePath string) string {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return ""
+	}
+	defer archive.Close()
+
+	for _, f := range archive.File {
+		switch {
+		case strings.HasPrefix(f.Name, "word/"):
+			return "document"
+		case strings.HasPrefix(f.Name, "xl/"):
+			return "excel"
+		case strings.HasPrefix(f.Name, "ppt/"):
+			return "powerpoint"
+		case f.Name == "mimetype":
+			return openDocumentMimeType(f)
+		}
+	}
+	return ""
+}
+
+func openDocumentMimeType(f *zip.File) string {
+	rc, err := f.Open()
+	if err != nil {
+		return ""
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, 100))
+	if err != nil {
+		return ""
+	}
+
+	switch strings.TrimSpace(string(data)) {
+	case "application/vnd.oasis.opendocument.text":
+		return "document"
+	case "application/vnd.oasis.opendocument.spreadsheet":
+		return "excel"
+	case "application/vnd.oasis.opendocument.presentation":
+		return "powerpoint"
+	default:
+		return ""
+	}
+}
+
+// overridesExtension reports whether a sniffed type should replace the type the extension implies.
+// Text is only trusted for files whose extension says nothing (e.g. no extension or .html), since
+// many binary-looking formats are also valid text; binary formats are trusted unless they belong
+// to the same family as the extension's type.
+func overridesExtension(sniffed, byExtension string) bool {
+	switch {
+	case sniffed == "" || sniffed == byExtension:
+		return false
+	case byExtension == "other":
+		return true
+	case sniffed == "text":
+		return false
+	default:
+		family, ok := fileTypeFamilies[sniffed]
+		return !ok || family != fileTypeFamilies[byExtension]
+	}
+}
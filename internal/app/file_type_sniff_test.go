@@ -0,0 +1,71 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func zipWith(t *testing.T, names ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range names {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name == "mimetype" {
+			f.Write([]byte("application/vnd.oasis.opendocument.spreadsheet"))
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetermineFileType_SniffsContent(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	pdf := []byte("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	mp4 := []byte("\x00\x00\x00\x20ftypisom\x00\x00\x02\x00isomiso2mp41")
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    string
+	}{
+		{"photo.txt", png, "image"}, // Mislabeled
+		{"scan", pdf, "pdf"},        // No extension
+		{"README", []byte("Read me first"), "text"},
+		{"page.html", []byte("<!DOCTYPE html><html></html>"), "text"},
+		{"report", zipWith(t, "[Content_Types].xml", "word/document.xml"), "document"},
+		{"budget", zipWith(t, "mimetype", "content.xml"), "excel"},
+		{"archive.bin", zipWith(t, "a.txt"), "other"},
+		{"song.m4a", mp4, "audio"},                  // Same container as video; the extension decides
+		{"main.go", []byte("package main"), "code"}, // Text never overrides a text-family extension
+		{"logo.svg", []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"), "image"},
+		{"notes.md", png, "image"},
+		{"empty.txt", nil, "text"},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, tt.content, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if got := DetermineFileType(path); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	// Paths that can't be read are typed by extension
+	if got := DetermineFileType(filepath.Join(dir, "missing.pdf")); got != "pdf" {
+		t.Errorf("expected pdf for a missing file, got %s", got)
+	}
+}
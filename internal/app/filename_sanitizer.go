@@ -0,0 +1,125 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxNameBytes is the longest file or folder name most filesystems accept
+const maxNameBytes = 255
+
+// windowsReservedNames can't be used as file names on Windows, with or without an extension
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// SanitizeName makes a single file or folder name valid on Windows, macOS and Linux. It
+// returns the new name and a description of each change, or no changes if it was fine.
+func SanitizeName(name string) (string, []string) {
+	var changes []string
+
+	var sb strings.Builder
+	replaced := 0
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`<>:"/\|?*`, r) || r == utf8.RuneError {
+			sb.WriteRune('_')
+			replaced++
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	sanitized := sb.String()
+	if replaced > 0 {
+		changes = append(changes, fmt.Sprintf("replaced %d invalid character(s)", replaced))
+	}
+
+	if trimmed := strings.TrimLeft(strings.TrimRight(sanitized, ". "), " "); trimmed != sanitized {
+		sanitized = trimmed
+		changes = append(changes, "removed leading spaces or trailing dots and spaces")
+	}
+
+	stem, ext := sanitized, ""
+	if i := strings.Index(sanitized, "."); i > 0 {
+		stem, ext = sanitized[:i], sanitized[i:]
+	}
+	if windowsReservedNames[strings.ToUpper(stem)] {
+		sanitized = stem + "_" + ext
+		changes = append(changes, fmt.Sprintf("%q is reserved on Windows", stem))
+	}
+
+	if len(sanitized) > maxNameBytes {
+		sanitized = truncateName(sanitized, maxNameBytes)
+		changes = append(changes, fmt.Sprintf("shortened to %d bytes", maxNameBytes))
+	}
+
+	if sanitized == "" {
+		sanitized = "_"
+		changes = append(changes, "name was empty")
+	}
+	return sanitized, changes
+}
+
+// truncateName shortens name to at most limit bytes, keeping the extension and whole runes
+func truncateName(name string, limit int) string {
+	ext := path.Ext(name)
+	if len(ext) >= limit/2 {
+		ext = ""
+	}
+	stem := strings.TrimSuffix(name, ext)
+	budget := limit - len(ext)
+	for len(stem) > budget {
+		_, size := utf8.DecodeLastRuneInString(stem)
+		stem = stem[:len(stem)-size]
+	}
+	return strings.TrimRight(stem, ". ") + ext
+}
+
+// sanitizeDestination sanitizes every new name in op.To and records what changed in
+// op.Adjusted. Paths outside basePath are left alone; the validator rejects them.
+func (s *OpenAIService) sanitizeDestination(basePath string, op FileOperation) FileOperation {
+	rel := relativeSlashPath(basePath, op.To)
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+		return op
+	}
+
+	parts := strings.Split(rel, "/")
+	var adjustments []string
+	// What already exists keeps its name, so the file goes into the existing "Notes: 2020" rather
+	// than a new "Notes_ 2020" next to it
+	checkDisk := !IsObjectStoragePath(basePath)
+	current := basePath
+	for i, part := range parts {
+		if checkDisk {
+			candidate := filepath.Join(current, part)
+			if _, err := os.Lstat(candidate); err == nil {
+				current = candidate
+				continue
+			}
+			checkDisk = false
+		}
+		sanitized, changes := SanitizeName(part)
+		if len(changes) == 0 {
+			continue
+		}
+		parts[i] = sanitized
+		adjustments = append(adjustments, fmt.Sprintf("%q -> %q (%s)", part, sanitized, strings.Join(changes, ", ")))
+	}
+	if len(adjustments) == 0 {
+		return op
+	}
+
+	to, err := JoinStoragePath(basePath, strings.Join(parts, "/"))
+	if err != nil {
+		return op
+	}
+	op.To = to
+	op.Adjusted = strings.Join(adjustments, "; ")
+	s.logger.Info("Adjusted destination %s: %s", rel, op.Adjusted)
+	return op
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeName(t *testing.T) {
+	tests := []struct {
+		name        string
+		want        string
+		wantChanged bool
+	}{
+		{"report.pdf", "report.pdf", false},
+		{".gitignore", ".gitignore", false},
+		{`Q1: "Draft"?.docx`, "Q1_ _Draft__.docx", true},
+		{"notes. ", "notes", true},
+		{"CON", "CON_", true},
+		{"nul.txt", "nul_.txt", true},
+		{"console.log", "console.log", false},
+		{"tab\there", "tab_here", true},
+		{"...", "_", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changes := SanitizeName(tt.name)
+			if got != tt.want || (len(changes) > 0) != tt.wantChanged {
+				t.Errorf("SanitizeName(%q) = %q, %v; want %q, changed %v", tt.name, got, changes, tt.want, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestSanitizeNameTruncatesLongNames(t *testing.T) {
+	long := strings.Repeat("é", 200) + ".jpeg"
+	got, changes := SanitizeName(long)
+	if len(got) > maxNameBytes || !utf8.ValidString(got) || !strings.HasSuffix(got, ".jpeg") {
+		t.Errorf("got %d bytes %q", len(got), got)
+	}
+	if len(changes) != 1 {
+		t.Errorf("changes = %v", changes)
+	}
+}
+
+func TestParseSingleOperationSanitizesDestination(t *testing.T) {
+	base := t.TempDir()
+	service := NewOpenAIService(&Config{}, nil, NewLogger(false))
+
+	op, err := service.parseSingleOperation(`{"from": "a.txt", "to": "AUX/Plans: 2024./a.txt"}`, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(base, "AUX_", "Plans_ 2024", "a.txt")
+	if op.To != want {
+		t.Errorf("To = %q, want %q", op.To, want)
+	}
+	if !strings.Contains(op.Adjusted, "reserved on Windows") || !strings.Contains(op.Adjusted, "invalid character") {
+		t.Errorf("Adjusted = %q", op.Adjusted)
+	}
+}
+
+func TestSanitizeDestinationKeepsExistingFolders(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the folder name can't exist on Windows")
+	}
+	base := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(base, "Notes: 2020"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	service := NewOpenAIService(&Config{}, nil, NewLogger(false))
+
+	tests := []struct {
+		to   string
+		want string
+	}{
+		{"Notes: 2020/a.txt", "Notes: 2020/a.txt"},
+		{"Notes: 2020/Plans: 2024/a.txt", "Notes: 2020/Plans_ 2024/a.txt"},
+		{"Notes: 2021/a.txt", "Notes_ 2021/a.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.to, func(t *testing.T) {
+			op := service.sanitizeDestination(base, FileOperation{From: filepath.Join(base, "a.txt"), To: filepath.Join(base, filepath.FromSlash(tt.to))})
+			if want := filepath.Join(base, filepath.FromSlash(tt.want)); op.To != want {
+				t.Errorf("To = %q, want %q", op.To, want)
+			}
+		})
+	}
+}
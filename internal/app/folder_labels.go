@@ -0,0 +1,236 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// folderIconFile holds a folder's Windows icon
+const folderIconFile = "desktop.ini"
+
+// Finder's label colors, by AppleScript label index
+var folderLabelColors = map[string]int{
+	"orange": 1, "red": 2, "yellow": 3, "blue": 4, "purple": 5, "green": 6, "gray": 7, "grey": 7,
+}
+
+const defaultFolderLabelRules = `# One rule per line: folder names: emoji [color] [Windows icon]
+Documents, Docs, Papers: 📄 blue %SystemRoot%\system32\imageres.dll,-112
+Photos, Pictures, Images: 📷 green %SystemRoot%\system32\imageres.dll,-113
+Music, Audio: 🎵 purple %SystemRoot%\system32\imageres.dll,-108
+Videos, Movies: 🎬 red %SystemRoot%\system32\imageres.dll,-189
+Downloads: 📥 gray %SystemRoot%\system32\imageres.dll,-184
+Archives, Backups: 📦 orange
+Invoices, Receipts, Finance: 💰 yellow
+Code, Projects: 💻 gray`
+
+// FolderLabel is how folders of one category are marked
+type FolderLabel struct {
+	Names []string // Folder names of the category, matched ignoring case and any emoji prefix
+	Emoji string
+	Color string // A key of folderLabelColors; empty for none
+	Icon  string // Windows icon resource for desktop.ini; empty for none
+}
+
+// ParseFolderLabelRules parses the folder label configuration: one rule per line in the form
+// "Photos, Pictures: 📷 green %SystemRoot%\system32\imageres.dll,-113", where the color and icon
+// are optional. Blank lines and lines starting with # are ignored.
+func ParseFolderLabelRules(spec string) ([]FolderLabel, error) {
+	var labels []FolderLabel
+	for i, line := range strings.Split(spec, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		namePart, labelPart, found := strings.Cut(line, ":")
+		fields := strings.Fields(labelPart)
+		if !found || len(fields) == 0 {
+			return nil, fmt.Errorf("line %d: expected \"Folder, Other folder: emoji [color] [icon]\"", i+1)
+		}
+		label := FolderLabel{Emoji: fields[0]}
+		for _, name := range strings.Split(namePart, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				label.Names = append(label.Names, name)
+			}
+		}
+		if len(label.Names) == 0 {
+			return nil, fmt.Errorf("line %d: no folder names", i+1)
+		}
+		if len(fields) > 1 {
+			color := strings.ToLower(fields[1])
+			if _, ok := folderLabelColors[color]; !ok {
+				return nil, fmt.Errorf("line %d: unknown color %q (expected red, orange, yellow, green, blue, purple or gray)", i+1, fields[1])
+			}
+			label.Color = color
+		}
+		if len(fields) > 2 {
+			label.Icon = strings.Join(fields[2:], " ")
+		}
+		labels = append(labels, label)
+	}
+	return labels, nil
+}
+
+// stripLabelPrefix removes a leading emoji and the spaces after it
+func stripLabelPrefix(name string) string {
+	return strings.TrimLeftFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// matchFolderLabel returns the label for a folder name, or false if no category matches
+func matchFolderLabel(labels []FolderLabel, name string) (FolderLabel, bool) {
+	name = stripLabelPrefix(name)
+	for _, label := range labels {
+		for _, candidate := range label.Names {
+			if strings.EqualFold(name, candidate) {
+				return label, true
+			}
+		}
+	}
+	return FolderLabel{}, false
+}
+
+// labelNewFolders puts the category emoji before the folders of op.To that don't exist yet.
+// Like the naming convention, existing folders keep their names.
+func labelNewFolders(basePath string, op FileOperation, labels []FolderLabel) FileOperation {
+	rel := relativeSlashPath(basePath, op.To)
+	parts := strings.Split(rel, "/")
+	if len(labels) == 0 || len(parts) < 2 || parts[0] == ".." {
+		return op
+	}
+
+	checkDisk := !IsObjectStoragePath(basePath)
+	current := basePath
+	for i, folder := range parts[:len(parts)-1] {
+		if checkDisk {
+			candidate := filepath.Join(current, folder)
+			if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+				current = candidate
+				continue
+			}
+			checkDisk = false
+		}
+		label, ok := matchFolderLabel(labels, folder)
+		if ok && stripLabelPrefix(folder) == folder {
+			parts[i] = label.Emoji + " " + folder
+		}
+	}
+
+	if to, err := JoinStoragePath(basePath, strings.Join(parts, "/")); err == nil {
+		op.To = to
+	}
+	return op
+}
+
+// applyFolderEmoji labels the new folders of op.To when emoji prefixes are on
+func (s *OpenAIService) applyFolderEmoji(basePath string, op FileOperation) FileOperation {
+	if !s.config.FolderEmojiPrefixes {
+		return op
+	}
+	labels, err := ParseFolderLabelRules(s.config.FolderLabelRules)
+	if err != nil {
+		s.logger.Error("Folder labels: %v", err)
+		return op
+	}
+	return labelNewFolders(basePath, op, labels)
+}
+
+// FolderLabeler marks the folders an execution creates with their category's Finder color on
+// macOS or desktop.ini icon on Windows. The settings are read from the config on every call.
+type FolderLabeler struct {
+	config *Config
+	logger *Logger
+}
+
+func NewFolderLabeler(config *Config, logger *Logger) *FolderLabeler {
+	return &FolderLabeler{config: config, logger: logger}
+}
+
+// Label marks the given new folders. Failures are logged; the folders are usable either way.
+func (fl *FolderLabeler) Label(dirs []string) {
+	if fl == nil || !fl.config.FolderColorLabels || len(dirs) == 0 {
+		return
+	}
+	labels, err := ParseFolderLabelRules(fl.config.FolderLabelRules)
+	if err != nil {
+		fl.logger.Error("Folder labels: %v", err)
+		return
+	}
+	for _, dir := range dirs {
+		label, ok := matchFolderLabel(labels, filepath.Base(dir))
+		if !ok {
+			continue
+		}
+		var err error
+		switch runtime.GOOS {
+		case "darwin":
+			err = setFinderLabel(dir, label.Color)
+		case "windows":
+			err = setFolderIcon(dir, label.Icon)
+		}
+		if err != nil {
+			fl.logger.Error("Failed to label folder %s: %v", dir, err)
+		}
+	}
+}
+
+func setFinderLabel(dir, color string) error {
+	index, ok := folderLabelColors[color]
+	if !ok {
+		return nil
+	}
+	const script = `on run argv
+	tell application "Finder" to set label index of (POSIX file (item 1 of argv) as alias) to (item 2 of argv as integer)
+end run`
+	if out, err := exec.Command("osascript", "-e", script, dir, strconv.Itoa(index)).CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// setFolderIcon writes a desktop.ini; Explorer only reads it from read-only or system folders
+func setFolderIcon(dir, icon string) error {
+	if icon == "" {
+		return nil
+	}
+	ini := filepath.Join(dir, folderIconFile)
+	if exists(ini) {
+		return nil
+	}
+	content := "[.ShellClassInfo]\r\nIconResource=" + icon + "\r\n"
+	if err := os.WriteFile(ini, []byte(content), 0644); err != nil {
+		return err
+	}
+	if out, err := exec.Command("attrib", "+h", "+s", ini).CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	if out, err := exec.Command("attrib", "+r", dir).CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// RemoveCreatedFolder removes a folder created by an execution if it's empty again, apart from
+// the desktop.ini its icon was written to
+func RemoveCreatedFolder(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 1 && strings.EqualFold(entries[0].Name(), folderIconFile) {
+		ini := filepath.Join(dir, entries[0].Name())
+		os.Chmod(ini, 0644)
+		if err := os.Remove(ini); err != nil {
+			return err
+		}
+		os.Chmod(dir, 0755)
+	}
+	return os.Remove(dir)
+}
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseFolderLabelRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []FolderLabel
+		wantErr bool
+	}{
+		{
+			name: "emoji, color and icon",
+			spec: "# comment\n\nPhotos, Pictures: 📷 Green %SystemRoot%\\system32\\imageres.dll,-113\nCode: 💻",
+			want: []FolderLabel{
+				{Names: []string{"Photos", "Pictures"}, Emoji: "📷", Color: "green", Icon: `%SystemRoot%\system32\imageres.dll,-113`},
+				{Names: []string{"Code"}, Emoji: "💻"},
+			},
+		},
+		{name: "default rules", spec: defaultFolderLabelRules},
+		{name: "unknown color", spec: "Photos: 📷 teal", wantErr: true},
+		{name: "missing colon", spec: "Photos 📷", wantErr: true},
+		{name: "missing emoji", spec: "Photos:", wantErr: true},
+		{name: "missing names", spec: " , : 📷", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFolderLabelRules(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want == nil {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d labels, want %d: %v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if got[i].Emoji != tt.want[i].Emoji || got[i].Color != tt.want[i].Color || got[i].Icon != tt.want[i].Icon ||
+					len(got[i].Names) != len(tt.want[i].Names) {
+					t.Errorf("label %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLabelNewFolders(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "Photos"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	labels, err := ParseFolderLabelRules(defaultFolderLabelRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := func(name string) string { return filepath.Join(dir, filepath.FromSlash(name)) }
+
+	tests := []struct {
+		to   string
+		want string
+	}{
+		{"documents/a.pdf", "📄 documents/a.pdf"},
+		{"Photos/a.jpg", "Photos/a.jpg"}, // Existing folders keep their names
+		{"Archive 2024/Music/a.mp3", "Archive 2024/🎵 Music/a.mp3"},
+		{"📄 Documents/a.pdf", "📄 Documents/a.pdf"},
+		{"Videos.mp4", "Videos.mp4"}, // Only folders are labeled
+	}
+	for _, tt := range tests {
+		t.Run(tt.to, func(t *testing.T) {
+			op := labelNewFolders(dir, FileOperation{From: p("a"), To: p(tt.to)}, labels)
+			if op.To != p(tt.want) {
+				t.Errorf("To = %s, want %s", op.To, p(tt.want))
+			}
+		})
+	}
+}
+
+func TestRemoveCreatedFolder(t *testing.T) {
+	dir := t.TempDir()
+	labeled := filepath.Join(dir, "Photos")
+	if err := os.MkdirAll(labeled, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(labeled, folderIconFile), []byte("[.ShellClassInfo]\r\n"), 0444); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveCreatedFolder(labeled); err != nil || exists(labeled) {
+		t.Errorf("labeled folder not removed: %v", err)
+	}
+
+	used := filepath.Join(dir, "Music")
+	if err := os.MkdirAll(used, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(used, "song.mp3"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveCreatedFolder(used); err == nil || !exists(used) {
+		t.Error("folder with files was removed")
+	}
+
+	// Labeling is off without a labeler or the setting
+	var labeler *FolderLabeler
+	labeler.Label([]string{used})
+	NewFolderLabeler(&Config{}, NewLogger(false)).Label([]string{used})
+}
@@ -0,0 +1,259 @@
+package app
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Folder signatures let ScanDirectoryChanges skip subtrees that haven't changed since they were
+// last scanned. A folder's modification time changes whenever an entry in it is added, removed or
+// renamed, so a subtree whose folders all kept their recorded times, and whose indexed files are
+// all still in the index, holds the same files as before. Only folders whose files were all indexed
+// and unchanged at the time are recorded. Files edited in place without their folder changing are
+// missed, which is why skipping is opt-in (see SetSkipUnchangedFolders).
+const folderSignatureSchema = `
+	CREATE TABLE IF NOT EXISTS folder_signatures (
+		dir_path TEXT PRIMARY KEY,
+		mod_time INTEGER NOT NULL,
+		scan_key TEXT NOT NULL,
+		files INTEGER NOT NULL,
+		subdirs TEXT NOT NULL,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+`
+
+// folderSignature is what a folder looked like when its files were last all indexed and unchanged
+type folderSignature struct {
+	ModTime int64    // Nanoseconds
+	Files   int      // Indexed files directly inside
+	Subdirs []string // Names of the folders directly inside that were scanned
+}
+
+// SetSkipUnchangedFolders makes ScanDirectoryChanges trust folder modification times and skip
+// subtrees whose folders haven't changed since the last scan. Files edited in place without
+// their folder changing are then not noticed until something else in the folder changes.
+func (is *DefaultIndexService) SetSkipUnchangedFolders(skip bool) {
+	is.skipUnchanged = skip
+}
+
+// SetWatched tells ScanDirectoryChanges whether an IndexWatcher reports every change under root.
+// Folder signatures under a watched root are trusted even with skipping off, since the watcher
+// invalidates the folders of files edited in place. Signatures recorded before the change are
+// dropped either way: while nothing was watching, files may have been edited unnoticed.
+func (is *DefaultIndexService) SetWatched(root string, watched bool) {
+	root = filepath.Clean(root)
+	is.watchMu.Lock()
+	if is.watched == nil {
+		is.watched = make(map[string]bool)
+	}
+	if watched {
+		is.watched[root] = true
+	} else {
+		delete(is.watched, root)
+	}
+	is.watchMu.Unlock()
+	is.forgetFolderSignatures(root)
+}
+
+// InvalidateFolder drops the signature of dir, so the next scan looks at its files again
+func (is *DefaultIndexService) InvalidateFolder(dir string) {
+	if _, err := is.db.Exec("DELETE FROM folder_signatures WHERE dir_path = ?", filepath.Clean(dir)); err != nil {
+		is.logger.Debug("Failed to invalidate folder signature for %s: %v", dir, err)
+	}
+}
+
+// watching reports whether dirPath is under a watched root
+func (is *DefaultIndexService) watching(dirPath string) bool {
+	is.watchMu.Lock()
+	defer is.watchMu.Unlock()
+	for root := range is.watched {
+		if isSubPath(root, dirPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// folderScanKey identifies the settings a scan ran with; signatures recorded under other ignore
+// settings don't describe what a scan would see now
+func (is *DefaultIndexService) folderScanKey() string {
+	sum := sha256.Sum256([]byte(is.ignoreSpec + "\x00" + strconv.FormatBool(is.ignoreMatcher.IgnoresHidden())))
+	return hex.EncodeToString(sum[:8])
+}
+
+// folderScan tracks one ScanDirectoryChanges run: which subtrees can be skipped, and what the
+// scanned folders looked like so they can be recorded afterwards
+type folderScan struct {
+	is        *DefaultIndexService
+	root      string
+	maxDepth  int
+	key       string
+	recorded  map[string]folderSignature
+	indexed   map[string][]string // Indexed files by folder
+	unchanged map[string]bool     // Memoized results of subtreeUnchanged
+	seen      map[string]*folderSeen
+}
+
+// folderSeen is a folder as the current scan found it
+type folderSeen struct {
+	depth   int
+	modTime int64
+	files   int
+	clean   bool // Every file inside is indexed and unchanged
+	subdirs []string
+}
+
+func (is *DefaultIndexService) newFolderScan(root string, maxDepth int, indexed map[string]IndexedFile) *folderScan {
+	scan := &folderScan{
+		is:        is,
+		root:      filepath.Clean(root),
+		maxDepth:  maxDepth,
+		key:       is.folderScanKey(),
+		recorded:  make(map[string]folderSignature),
+		indexed:   make(map[string][]string),
+		unchanged: make(map[string]bool),
+		seen:      make(map[string]*folderSeen),
+	}
+	for path := range indexed {
+		dir := filepath.Dir(path)
+		scan.indexed[dir] = append(scan.indexed[dir], path)
+	}
+	if err := scan.load(); err != nil {
+		is.logger.Debug("Failed to load folder signatures for %s: %v", root, err)
+	}
+	return scan
+}
+
+func (scan *folderScan) load() error {
+	rows, err := scan.is.db.Query(`
+		SELECT dir_path, mod_time, files, subdirs FROM folder_signatures
+		WHERE (dir_path = ? OR dir_path LIKE ?) AND scan_key = ?
+	`, scan.root, strings.TrimSuffix(scan.root, string(filepath.Separator))+string(filepath.Separator)+"%", scan.key)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var dir, subdirs string
+		var sig folderSignature
+		if err := rows.Scan(&dir, &sig.ModTime, &sig.Files, &subdirs); err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(subdirs), &sig.Subdirs); err != nil {
+			continue
+		}
+		scan.recorded[dir] = sig
+	}
+	return rows.Err()
+}
+
+// needsContents reports whether files directly in a folder at depth are within the scan
+func (scan *folderScan) needsContents(depth int) bool {
+	return scan.maxDepth == 0 || depth < scan.maxDepth
+}
+
+// subtreeUnchanged reports whether dir, at depth below the scan root, still holds exactly the
+// files it held when it was recorded, down to the scan's depth
+func (scan *folderScan) subtreeUnchanged(dir string, depth int) bool {
+	if unchanged, ok := scan.unchanged[dir]; ok {
+		return unchanged
+	}
+	unchanged := scan.folderUnchanged(dir)
+	if unchanged && scan.needsContents(depth+1) {
+		for _, sub := range scan.recorded[dir].Subdirs {
+			if !scan.subtreeUnchanged(filepath.Join(dir, sub), depth+1) {
+				unchanged = false
+				break
+			}
+		}
+	}
+	scan.unchanged[dir] = unchanged
+	return unchanged
+}
+
+func (scan *folderScan) folderUnchanged(dir string) bool {
+	sig, ok := scan.recorded[dir]
+	if !ok || len(scan.indexed[dir]) != sig.Files {
+		return false
+	}
+	info, err := os.Lstat(dir)
+	return err == nil && info.IsDir() && info.ModTime().UnixNano() == sig.ModTime
+}
+
+// indexedFiles returns the indexed files of an unchanged subtree within the scan's depth
+func (scan *folderScan) indexedFiles(dir string, depth int) []string {
+	files := append([]string(nil), scan.indexed[dir]...)
+	if scan.needsContents(depth + 1) {
+		for _, sub := range scan.recorded[dir].Subdirs {
+			files = append(files, scan.indexedFiles(filepath.Join(dir, sub), depth+1)...)
+		}
+	}
+	return files
+}
+
+// enter notes a folder the scan reached, and reports whether its subtree is unchanged and can be skipped
+func (scan *folderScan) enter(path string, depth int, info os.FileInfo) bool {
+	path = filepath.Clean(path)
+	if parent := scan.seen[filepath.Dir(path)]; parent != nil && path != scan.root {
+		parent.subdirs = append(parent.subdirs, filepath.Base(path))
+	}
+	if scan.subtreeUnchanged(path, depth) {
+		return true
+	}
+	scan.seen[path] = &folderSeen{depth: depth, modTime: info.ModTime().UnixNano(), clean: true}
+	return false
+}
+
+// sawFile notes a file the scan found; clean means it is indexed and unchanged
+func (scan *folderScan) sawFile(path string, clean bool) {
+	if folder := scan.seen[filepath.Dir(path)]; folder != nil {
+		folder.files++
+		folder.clean = folder.clean && clean
+	}
+}
+
+// save records the folders whose contents were fully scanned and all indexed and unchanged, and
+// forgets the others. Skipped subtrees keep their records.
+func (scan *folderScan) save() error {
+	tx, err := scan.is.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for dir, folder := range scan.seen {
+		if !folder.clean || !scan.needsContents(folder.depth) {
+			if _, err := tx.Exec("DELETE FROM folder_signatures WHERE dir_path = ?", dir); err != nil {
+				return err
+			}
+			continue
+		}
+		subdirs, err := json.Marshal(append([]string{}, folder.subdirs...))
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`
+			INSERT OR REPLACE INTO folder_signatures (dir_path, mod_time, scan_key, files, subdirs, updated_at)
+			VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		`, dir, folder.modTime, scan.key, folder.files, string(subdirs)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// forgetFolderSignatures drops the records under dirPath, so they can't go stale while skipping is off
+func (is *DefaultIndexService) forgetFolderSignatures(dirPath string) {
+	root := filepath.Clean(dirPath)
+	_, err := is.db.Exec("DELETE FROM folder_signatures WHERE dir_path = ? OR dir_path LIKE ?",
+		root, strings.TrimSuffix(root, string(filepath.Separator))+string(filepath.Separator)+"%")
+	if err != nil && err != sql.ErrNoRows {
+		is.logger.Debug("Failed to clear folder signatures for %s: %v", dirPath, err)
+	}
+}
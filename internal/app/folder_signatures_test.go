@@ -0,0 +1,138 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func indexTree(t *testing.T, is *DefaultIndexService, root string) {
+	t.Helper()
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return is.IndexFile(path, "test", "text", info.Size(), info.ModTime())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func relPaths(root string, paths []string) string {
+	rels := make([]string, 0, len(paths))
+	for _, path := range paths {
+		rel, _ := filepath.Rel(root, path)
+		rels = append(rels, filepath.ToSlash(rel))
+	}
+	sort.Strings(rels)
+	return strings.Join(rels, ",")
+}
+
+func TestScanSkipsUnchangedFolders(t *testing.T) {
+	root := writeWalkFixture(t)
+	is := NewIndexService(NewLogger(false))
+	if err := is.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer is.Close()
+	is.SetIgnorePatterns("node_modules/")
+	is.SetSkipUnchangedFolders(true)
+	indexTree(t, is, root)
+
+	scan := func() *DirectoryChanges {
+		t.Helper()
+		changes, err := is.ScanDirectoryChanges(root, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return changes
+	}
+	first := scan()
+	if len(first.NewFiles)+len(first.ModifiedFiles)+len(first.DeletedFiles) != 0 {
+		t.Fatalf("first scan found changes: %+v", first)
+	}
+
+	// Edited in place, b/d keeps its modification time, so the file isn't looked at
+	edited := filepath.Join(root, "b", "d", "e.txt")
+	if err := os.WriteFile(edited, []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(edited, later, later); err != nil {
+		t.Fatal(err)
+	}
+	second := scan()
+	if len(second.ModifiedFiles) != 0 {
+		t.Errorf("unchanged folder was rescanned: modified %v", second.ModifiedFiles)
+	}
+	if got, want := relPaths(root, second.UnchangedFiles), relPaths(root, first.UnchangedFiles); got != want {
+		t.Errorf("skipped folders reported unchanged files\n%s\nwant\n%s", got, want)
+	}
+
+	// Adding a file changes its folder, which is scanned again
+	added := filepath.Join(root, "photos", "2024", "new.jpg")
+	if err := os.WriteFile(added, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	third := scan()
+	if got := relPaths(root, third.NewFiles); got != "photos/2024/new.jpg" {
+		t.Errorf("new files = %s, want photos/2024/new.jpg", got)
+	}
+	if len(third.ModifiedFiles) != 0 {
+		t.Errorf("b/d was rescanned: modified %v", third.ModifiedFiles)
+	}
+
+	// A folder whose index entries changed is scanned again, and so is everything with skipping off
+	if err := is.RemoveFile(filepath.Join(root, "b", "d", "f.txt")); err != nil {
+		t.Fatal(err)
+	}
+	fourth := scan()
+	if got := relPaths(root, fourth.NewFiles); got != "b/d/f.txt,photos/2024/new.jpg" {
+		t.Errorf("new files = %s, want b/d/f.txt and photos/2024/new.jpg", got)
+	}
+	if got := relPaths(root, fourth.ModifiedFiles); got != "b/d/e.txt" {
+		t.Errorf("modified files = %s, want b/d/e.txt", got)
+	}
+}
+
+func TestScanForgetsSignaturesWhenSkippingIsOff(t *testing.T) {
+	root := writeWalkFixture(t)
+	is := NewIndexService(NewLogger(false))
+	if err := is.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer is.Close()
+	is.SetSkipUnchangedFolders(true)
+	indexTree(t, is, root)
+	if _, err := is.ScanDirectoryChanges(root, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	edited := filepath.Join(root, "a.txt")
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(edited, later, later); err != nil {
+		t.Fatal(err)
+	}
+	is.SetSkipUnchangedFolders(false)
+	for i := 0; i < 2; i++ {
+		changes, err := is.ScanDirectoryChanges(root, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := relPaths(root, changes.ModifiedFiles); got != "a.txt" {
+			t.Errorf("scan %d: modified files = %s, want a.txt", i, got)
+		}
+		// Records made before skipping was turned off aren't trusted when it is turned back on
+		is.SetSkipUnchangedFolders(true)
+	}
+}
@@ -0,0 +1,156 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+)
+
+var ErrContentChanged = errors.New("moved files differ from the originals")
+
+// HashMismatch is a moved file whose content is not what it was before the move, or that could
+// not be read back at its destination
+type HashMismatch struct {
+	Path   string // Location after the move
+	Before string // SHA-256 at the original location
+	After  string // Empty if the file couldn't be read
+	Err    error
+}
+
+func (m HashMismatch) String() string {
+	if m.Err != nil {
+		return fmt.Sprintf("%s: %v", m.Path, m.Err)
+	}
+	return fmt.Sprintf("%s: %.12s → %.12s", m.Path, m.Before, m.After)
+}
+
+// hashedFile is a file picked for verification, with where the move will put it
+type hashedFile struct {
+	op   FileOperation
+	from string
+	to   string
+	hash string
+}
+
+// snapshotHashes hashes a random sample of sampleSize regular files moved by operations, or all
+// of them when sampleSize is negative. Moved folders contribute the files inside them. A nil rng
+// uses the global source.
+// Renames don't touch file contents, so this mainly catches copies across devices and network
+// filesystems that silently corrupt data.
+func snapshotHashes(operations []FileOperation, sampleSize int, rng *rand.Rand) []hashedFile {
+	pick := rand.IntN
+	if rng != nil {
+		pick = rng.IntN
+	}
+	var sample []hashedFile
+	seen := 0
+	consider := func(file hashedFile) {
+		seen++
+		switch {
+		case sampleSize < 0 || len(sample) < sampleSize:
+			sample = append(sample, file)
+		case sampleSize > 0:
+			// Reservoir sampling keeps every file equally likely without listing them all first
+			if i := pick(seen); i < sampleSize {
+				sample[i] = file
+			}
+		}
+	}
+
+	for _, op := range operations {
+		info, err := os.Lstat(op.From)
+		if err != nil {
+			continue
+		}
+		if info.Mode().IsRegular() {
+			consider(hashedFile{op: op, from: op.From, to: op.To})
+			continue
+		}
+		if !info.IsDir() {
+			continue
+		}
+		filepath.WalkDir(op.From, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(op.From, path)
+			if err != nil {
+				return nil
+			}
+			consider(hashedFile{op: op, from: path, to: filepath.Join(op.To, rel)})
+			return nil
+		})
+	}
+
+	hashed := sample[:0]
+	for _, file := range sample {
+		hash, err := hashFile(file.from)
+		if err != nil {
+			continue
+		}
+		file.hash = hash
+		hashed = append(hashed, file)
+	}
+	return hashed
+}
+
+// verifyHashes hashes the sampled files whose operations succeeded at their new locations and
+// returns how many were checked and the ones that differ
+func verifyHashes(results []OperationResult, sample []hashedFile) (int, []HashMismatch) {
+	succeeded := make(map[FileOperation]bool, len(results))
+	for _, opResult := range results {
+		if opResult.Success {
+			succeeded[opResult.Operation] = true
+		}
+	}
+
+	checked := 0
+	var mismatches []HashMismatch
+	for _, file := range sample {
+		if !succeeded[file.op] {
+			continue
+		}
+		checked++
+		hash, err := hashFile(file.to)
+		if err != nil {
+			mismatches = append(mismatches, HashMismatch{Path: file.to, Before: file.hash, Err: err})
+		} else if hash != file.hash {
+			mismatches = append(mismatches, HashMismatch{Path: file.to, Before: file.hash, After: hash})
+		}
+	}
+	return checked, mismatches
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// HashSample returns how many moved files executions should verify by content hash: 0 for none,
+// negative for all
+func (c *Config) HashSample() int {
+	switch c.HashVerification {
+	case HashVerificationAll:
+		return -1
+	case HashVerificationSample:
+		if c.HashSampleSize > 0 {
+			return c.HashSampleSize
+		}
+		return defaultHashSampleSize
+	}
+	return 0
+}
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"errors"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeHashFixture(t *testing.T, dir string) []FileOperation {
+	t.Helper()
+	files := map[string]string{"a.txt": "a", "b.txt": "b", "c.txt": "c", "album/1.jpg": "one", "album/2.jpg": "two"}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return []FileOperation{
+		{From: filepath.Join(dir, "a.txt"), To: filepath.Join(dir, "sorted", "a.txt")},
+		{From: filepath.Join(dir, "b.txt"), To: filepath.Join(dir, "sorted", "b.txt")},
+		{From: filepath.Join(dir, "c.txt"), To: filepath.Join(dir, "sorted", "c.txt")},
+		{From: filepath.Join(dir, "album"), To: filepath.Join(dir, "Photos", "album")},
+	}
+}
+
+func TestSnapshotHashes(t *testing.T) {
+	dir := t.TempDir()
+	ops := writeHashFixture(t, dir)
+
+	tests := []struct {
+		sampleSize int
+		want       int
+	}{
+		{-1, 5}, // Every file, including the two inside the moved folder
+		{3, 3},
+		{10, 5},
+	}
+	for _, tt := range tests {
+		sample := snapshotHashes(ops, tt.sampleSize, rand.New(rand.NewPCG(1, 2)))
+		if len(sample) != tt.want {
+			t.Errorf("sample size %d: got %d files, want %d", tt.sampleSize, len(sample), tt.want)
+		}
+		for _, file := range sample {
+			if file.hash == "" || file.to == "" {
+				t.Errorf("incomplete sample entry %+v", file)
+			}
+		}
+	}
+}
+
+func TestVerifyHashes(t *testing.T) {
+	dir := t.TempDir()
+	ops := writeHashFixture(t, dir)
+	sample := snapshotHashes(ops, -1, nil)
+
+	var results []OperationResult
+	for i, op := range ops {
+		if i == 1 {
+			results = append(results, OperationResult{Operation: op, Error: errors.New("failed")}) // Not checked
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(op.To), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(op.From, op.To); err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, OperationResult{Operation: op, Success: true})
+	}
+	// Silent corruption on the way, and a file that vanished
+	if err := os.WriteFile(filepath.Join(dir, "sorted", "c.txt"), []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "Photos", "album", "2.jpg")); err != nil {
+		t.Fatal(err)
+	}
+
+	checked, mismatches := verifyHashes(results, sample)
+	if checked != 4 {
+		t.Errorf("checked %d files, want 4", checked)
+	}
+	if len(mismatches) != 2 {
+		t.Fatalf("expected 2 mismatches, got %v", mismatches)
+	}
+	byPath := map[string]HashMismatch{}
+	for _, m := range mismatches {
+		byPath[m.Path] = m
+	}
+	if m := byPath[filepath.Join(dir, "sorted", "c.txt")]; m.After == "" || m.After == m.Before {
+		t.Errorf("expected a changed hash for c.txt, got %+v", m)
+	}
+	if m := byPath[filepath.Join(dir, "Photos", "album", "2.jpg")]; m.Err == nil {
+		t.Errorf("expected a read error for the missing file, got %+v", m)
+	}
+}
+
+func TestExecuteOperationsVerifiesHashes(t *testing.T) {
+	dir := t.TempDir()
+	ops := writeHashFixture(t, dir)
+
+	fs := NewFileService(NewValidator(), NewLogger(false))
+	result, err := fs.ExecuteOperations(ops, dir, ExecutionOptions{HashSample: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.HashesVerified != 5 || len(result.HashMismatches) != 0 || result.VerificationError != nil {
+		t.Errorf("expected 5 matching files, got %d verified, mismatches %v, error %v", result.HashesVerified, result.HashMismatches, result.VerificationError)
+	}
+}
+
+func TestConfigHashSample(t *testing.T) {
+	tests := []struct {
+		mode string
+		size int
+		want int
+	}{
+		{HashVerificationOff, 50, 0},
+		{"", 0, 0},
+		{HashVerificationSample, 50, 50},
+		{HashVerificationSample, 0, defaultHashSampleSize},
+		{HashVerificationAll, 50, -1},
+	}
+	for _, tt := range tests {
+		c := &Config{HashVerification: tt.mode, HashSampleSize: tt.size}
+		if got := c.HashSample(); got != tt.want {
+			t.Errorf("HashSample() with %q/%d = %d, want %d", tt.mode, tt.size, got, tt.want)
+		}
+	}
+}
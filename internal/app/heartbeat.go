@@ -0,0 +1,66 @@
+package app
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultHeartbeatInterval is how long a single move may run before it is reported as still going
+const defaultHeartbeatInterval = 5 * time.Second
+
+// HeartbeatHandler is told that op has been running for elapsed and hasn't finished yet. It is
+// called from a background goroutine, never after the operation's result was returned.
+type HeartbeatHandler func(op FileOperation, elapsed time.Duration)
+
+// heartbeat is what executors need to report long-running operations
+type heartbeat struct {
+	interval time.Duration
+	onBeat   HeartbeatHandler
+}
+
+func heartbeatFor(opts ExecutionOptions) heartbeat {
+	interval := opts.HeartbeatInterval
+	if interval <= 0 {
+		interval = defaultHeartbeatInterval
+	}
+	return heartbeat{interval: interval, onBeat: opts.OnHeartbeat}
+}
+
+// run executes fn for op and, for as long as it takes, logs a line and calls onBeat every interval
+// so a slow copy over the network can be told apart from a hang
+func (h heartbeat) run(logger *Logger, op FileOperation, fn func() OperationResult) OperationResult {
+	if h.interval <= 0 {
+		return fn()
+	}
+
+	start := time.Now()
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				elapsed := time.Since(start)
+				logger.Info("Still moving %s -> %s (%v so far)", op.From, op.To, elapsed.Truncate(time.Second))
+				if h.onBeat != nil {
+					h.onBeat(op, elapsed)
+				}
+			}
+		}
+	}()
+
+	result := fn()
+	close(done)
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed >= h.interval {
+		logger.Info("Finished %s after %v (success: %v)", op.From, elapsed.Truncate(time.Second), result.Success)
+	}
+	return result
+}
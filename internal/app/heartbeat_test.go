@@ -0,0 +1,65 @@
+package app
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHeartbeat(t *testing.T) {
+	op := FileOperation{From: "/src/big.iso", To: "/dst/big.iso"}
+	tests := []struct {
+		name      string
+		interval  time.Duration
+		duration  time.Duration
+		wantBeats bool
+	}{
+		{"fast move is quiet", 200 * time.Millisecond, 0, false},
+		{"slow move beats", 10 * time.Millisecond, 80 * time.Millisecond, true},
+		{"disabled", 0, 30 * time.Millisecond, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var beats, afterReturn atomic.Int32
+			var returned atomic.Bool
+			beat := heartbeat{interval: tt.interval, onBeat: func(got FileOperation, elapsed time.Duration) {
+				if got != op {
+					t.Errorf("heartbeat for %v, want %v", got, op)
+				}
+				if elapsed < tt.interval {
+					t.Errorf("elapsed %v is shorter than the interval %v", elapsed, tt.interval)
+				}
+				if returned.Load() {
+					afterReturn.Add(1)
+				}
+				beats.Add(1)
+			}}
+
+			result := beat.run(NewLogger(false), op, func() OperationResult {
+				time.Sleep(tt.duration)
+				return OperationResult{Operation: op, Success: true}
+			})
+			returned.Store(true)
+
+			if !result.Success {
+				t.Error("result of the operation was lost")
+			}
+			if got := beats.Load() > 0; got != tt.wantBeats {
+				t.Errorf("got %d heartbeats, want some: %v", beats.Load(), tt.wantBeats)
+			}
+			time.Sleep(3 * tt.interval)
+			if afterReturn.Load() > 0 {
+				t.Errorf("%d heartbeats after the operation returned", afterReturn.Load())
+			}
+		})
+	}
+}
+
+func TestHeartbeatFor(t *testing.T) {
+	if got := heartbeatFor(ExecutionOptions{}).interval; got != defaultHeartbeatInterval {
+		t.Errorf("default interval = %v, want %v", got, defaultHeartbeatInterval)
+	}
+	if got := heartbeatFor(ExecutionOptions{HeartbeatInterval: time.Second}).interval; got != time.Second {
+		t.Errorf("interval = %v, want 1s", got)
+	}
+}
@@ -0,0 +1,130 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const hookTimeout = 60 * time.Second
+
+// HookEvent identifies the point in the workflow where a hook runs
+type HookEvent string
+
+const (
+	HookPreAnalysis HookEvent = "pre-analysis"
+	HookPreExecute  HookEvent = "pre-execute"
+	HookPostExecute HookEvent = "post-execute"
+)
+
+// HookPayload is piped as JSON to the hook command's stdin
+type HookPayload struct {
+	Event      HookEvent         `json:"event"`
+	BasePath   string            `json:"base_path"`
+	UserPrompt string            `json:"user_prompt,omitempty"`
+	MaxDepth   int               `json:"max_depth,omitempty"`
+	Operations []FileOperation   `json:"operations,omitempty"`
+	Result     *HookResultReport `json:"result,omitempty"`
+}
+
+// HookResultReport is a JSON-friendly view of an ExecutionResult
+type HookResultReport struct {
+	SuccessCount      int                   `json:"success_count"`
+	FailCount         int                   `json:"fail_count"`
+	InitialFileCount  int                   `json:"initial_file_count"`
+	FinalFileCount    int                   `json:"final_file_count"`
+	CleanedDirs       int                   `json:"cleaned_dirs"`
+	Aborted           bool                  `json:"aborted"`
+	VerificationError string                `json:"verification_error,omitempty"`
+	Operations        []HookOperationReport `json:"operations"`
+}
+
+type HookOperationReport struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// NewHookResultReport converts an ExecutionResult into its JSON-friendly form
+func NewHookResultReport(result ExecutionResult) *HookResultReport {
+	report := &HookResultReport{
+		SuccessCount:     result.SuccessCount,
+		FailCount:        result.FailCount,
+		InitialFileCount: result.InitialFileCount,
+		FinalFileCount:   result.FinalFileCount,
+		CleanedDirs:      result.CleanedDirs,
+		Aborted:          result.Aborted,
+		Operations:       make([]HookOperationReport, 0, len(result.Operations)),
+	}
+	if result.VerificationError != nil {
+		report.VerificationError = result.VerificationError.Error()
+	}
+	for _, opResult := range result.Operations {
+		opReport := HookOperationReport{
+			From:    opResult.Operation.From,
+			To:      opResult.Operation.To,
+			Success: opResult.Success,
+		}
+		if opResult.Error != nil {
+			opReport.Error = opResult.Error.Error()
+		}
+		report.Operations = append(report.Operations, opReport)
+	}
+	return report
+}
+
+// HookRunner runs the user-configured commands for each HookEvent.
+// Pre-* hooks act as gates: a non-zero exit status blocks the step.
+type HookRunner struct {
+	config *Config
+	logger *Logger
+}
+
+func NewHookRunner(config *Config, logger *Logger) *HookRunner {
+	return &HookRunner{
+		config: config,
+		logger: logger,
+	}
+}
+
+// commandFor returns the configured command for event, or "" if none is set
+func (h *HookRunner) commandFor(event HookEvent) string {
+	switch event {
+	case HookPreAnalysis:
+		return strings.TrimSpace(h.config.HookPreAnalysis)
+	case HookPreExecute:
+		return strings.TrimSpace(h.config.HookPreExecute)
+	case HookPostExecute:
+		return strings.TrimSpace(h.config.HookPostExecute)
+	default:
+		return ""
+	}
+}
+
+// Run executes the hook for payload.Event, if one is configured
+func (h *HookRunner) Run(payload HookPayload) error {
+	if h == nil {
+		return nil
+	}
+	command := h.commandFor(payload.Event)
+	if command == "" {
+		return nil
+	}
+
+	input, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal hook payload: %w", err)
+	}
+
+	h.logger.Info("Running %s hook: %s", payload.Event, command)
+	output, err := runCommand(command, input, hookTimeout)
+	if err != nil {
+		return fmt.Errorf("%s hook: %w", payload.Event, err)
+	}
+	if trimmed := strings.TrimSpace(string(output)); trimmed != "" {
+		h.logger.Debug("%s hook output: %s", payload.Event, trimmed)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHookRunnerExecutePayloads(t *testing.T) {
+	dir := t.TempDir()
+	from := filepath.Join(dir, "invoice.pdf")
+	to := filepath.Join(dir, "finance", "invoice.pdf")
+	if err := os.WriteFile(from, []byte("pdf"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hookDir := t.TempDir()
+	t.Setenv("VAF_TEST_HOOK_DIR", hookDir)
+	command := helperCommand(t, "hook-record")
+
+	config := &Config{HookPreExecute: command, HookPostExecute: command, ParallelMoves: 1}
+	logger := NewLogger(false)
+	validator := NewValidator()
+	orchestrator := NewOrchestrator(&stubAIService{}, NewFileService(validator, logger), validator, logger, nil, nil, NewHookRunner(config, logger))
+
+	ops := []FileOperation{{From: from, To: to}}
+	result := orchestrator.ExecuteOrganization(ExecutionRequest{Operations: ops, BasePath: dir})
+	if result.SuccessCount != 1 {
+		t.Fatalf("execution failed: %+v", result)
+	}
+
+	readPayload := func(event HookEvent) HookPayload {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(hookDir, string(event)+".json"))
+		if err != nil {
+			t.Fatalf("%s hook didn't run: %v", event, err)
+		}
+		var payload HookPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			t.Fatal(err)
+		}
+		return payload
+	}
+
+	pre := readPayload(HookPreExecute)
+	if pre.Event != HookPreExecute || pre.BasePath != dir || len(pre.Operations) != 1 || pre.Operations[0].To != to {
+		t.Errorf("unexpected pre-execute payload: %+v", pre)
+	}
+	if pre.Result != nil {
+		t.Errorf("pre-execute payload has a result: %+v", pre.Result)
+	}
+
+	post := readPayload(HookPostExecute)
+	if post.Event != HookPostExecute || post.BasePath != dir || len(post.Operations) != 1 {
+		t.Errorf("unexpected post-execute payload: %+v", post)
+	}
+	if post.Result == nil || post.Result.SuccessCount != 1 || len(post.Result.Operations) != 1 || !post.Result.Operations[0].Success {
+		t.Errorf("post-execute payload doesn't report the move: %+v", post.Result)
+	}
+}
+
+func TestHookRunnerPreExecuteBlocks(t *testing.T) {
+	dir := t.TempDir()
+	from := filepath.Join(dir, "invoice.pdf")
+	if err := os.WriteFile(from, []byte("pdf"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := &Config{HookPreExecute: helperCommand(t, "hook-refuse"), ParallelMoves: 1}
+	logger := NewLogger(false)
+	validator := NewValidator()
+	orchestrator := NewOrchestrator(&stubAIService{}, NewFileService(validator, logger), validator, logger, nil, nil, NewHookRunner(config, logger))
+
+	ops := []FileOperation{{From: from, To: filepath.Join(dir, "finance", "invoice.pdf")}}
+	result := orchestrator.ExecuteOrganization(ExecutionRequest{Operations: ops, BasePath: dir})
+
+	if !result.Aborted || result.SuccessCount != 0 || result.FailCount != 1 {
+		t.Errorf("expected the execution to be blocked, got %+v", result)
+	}
+	if result.VerificationError == nil || !strings.Contains(result.VerificationError.Error(), "backup is still running") {
+		t.Errorf("expected the hook's message in the error, got %v", result.VerificationError)
+	}
+	if _, err := os.Stat(from); err != nil {
+		t.Errorf("blocked execution moved the file: %v", err)
+	}
+}
@@ -2,34 +2,104 @@ package app
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 )
 
+const (
+	// Indexing runs many deep analysis requests against the same API host at once; Go's default
+	// of 2 idle connections per host makes most of them open a fresh TLS connection
+	maxIdleConnsPerHost = 32
+	maxIdleConns        = 100
+	idleConnTimeout     = 90 * time.Second
+	tlsHandshakeTimeout = 15 * time.Second
+	dialTimeout         = 30 * time.Second
+)
+
+// sharedTransport pools connections for every HTTP client in the app
+var sharedTransport = newTransport()
+
+func newTransport() *http.Transport {
+	dialer := &net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          maxIdleConns,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		IdleConnTimeout:       idleConnTimeout,
+		TLSHandshakeTimeout:   tlsHandshakeTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// HTTPClient is shared by the organization, deep analysis and verification requests and is
+// safe for concurrent use
 type HTTPClient struct {
-	client *http.Client
-	logger *Logger
+	client  *http.Client
+	logger  *Logger
+	queue   *RequestQueue
+	limiter *RateLimiter
+	budget  *BudgetGuard
 }
 
-func NewHTTPClient(logger *Logger) *HTTPClient {
+func NewHTTPClient(config *Config, logger *Logger) *HTTPClient {
 	return &HTTPClient{
-		client: &http.Client{},
-		logger: logger,
+		client:  &http.Client{Transport: sharedTransport},
+		logger:  logger,
+		queue:   NewRequestQueue(config, logger),
+		limiter: NewRateLimiter(config, logger),
 	}
 }
 
+// SetBudgetGuard enables spend tracking and budget ceilings for all requests
+func (c *HTTPClient) SetBudgetGuard(budget *BudgetGuard) {
+	c.budget = budget
+}
+
+// Budget returns the budget guard, or nil if none is set
+func (c *HTTPClient) Budget() *BudgetGuard {
+	return c.budget
+}
+
 // PostStream sends a request and returns the response body for streaming.
-// The caller is responsible for closing the body.
-func (c *HTTPClient) PostStream(url string, headers map[string]string, body interface{}) (io.ReadCloser, error) {
+// The caller is responsible for closing the body. Spend counts toward the budget run in ctx.
+// Streaming requests are interactive (organization planning) and take priority in the request
+// queue and under rate limiting.
+func (c *HTTPClient) PostStream(ctx context.Context, url string, headers map[string]string, body interface{}) (io.ReadCloser, error) {
 	jsonData, err := json.Marshal(body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	release := c.queue.Acquire(url, true)
+	respBody, err := c.postStream(ctx, url, headers, jsonData)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &releasingBody{ReadCloser: respBody, release: release}, nil
+}
+
+func (c *HTTPClient) postStream(ctx context.Context, url string, headers map[string]string, jsonData []byte) (io.ReadCloser, error) {
+	inputTokens := estimateRequestTokens(jsonData)
+	c.limiter.Wait(url, inputTokens, true)
+	run := budgetRunFrom(ctx)
+	if err := c.budget.Authorize(run, inputTokens, requestMaxTokens(jsonData)); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -52,17 +122,33 @@ func (c *HTTPClient) PostStream(url string, headers map[string]string, body inte
 		return nil, fmt.Errorf("API error: %s - Body: %s", resp.Status, string(bodyBytes))
 	}
 
-	return resp.Body, nil
+	if c.budget == nil {
+		return resp.Body, nil
+	}
+	return &usageTrackingReader{body: resp.Body, budget: c.budget, run: run, inputTokens: inputTokens}, nil
 }
 
-// Post sends a POST request and returns the full response body
-func (c *HTTPClient) Post(url string, headers map[string]string, body interface{}) ([]byte, error) {
+// Post sends a POST request and returns the full response body. Spend counts toward the budget
+// run in ctx.
+// Post is used for background work such as deep analysis, which yields to interactive requests
+// in the request queue and when rate limited.
+func (c *HTTPClient) Post(ctx context.Context, url string, headers map[string]string, body interface{}) ([]byte, error) {
 	jsonData, err := json.Marshal(body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	release := c.queue.Acquire(url, false)
+	defer release()
+
+	inputTokens := estimateRequestTokens(jsonData)
+	c.limiter.Wait(url, inputTokens, false)
+	run := budgetRunFrom(ctx)
+	if err := c.budget.Authorize(run, inputTokens, requestMaxTokens(jsonData)); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -87,6 +173,79 @@ func (c *HTTPClient) Post(url string, headers map[string]string, body interface{
 		return nil, fmt.Errorf("API error: %s - Body: %s", resp.Status, string(bodyBytes))
 	}
 
+	if c.budget != nil {
+		input, output := responseUsage(bodyBytes, inputTokens)
+		c.budget.Record(run, input, output)
+	}
+
+	return bodyBytes, nil
+}
+
+// PostMultipart uploads a file as multipart/form-data along with plain form fields and returns the
+// full response body. The file is streamed rather than loaded into memory. Uploads are background
+// requests for rate limiting; they carry no chat tokens, so the budget guard is not consulted.
+func (c *HTTPClient) PostMultipart(url string, headers map[string]string, fields map[string]string, fileField, filePath string) ([]byte, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	release := c.queue.Acquire(url, false)
+	defer release()
+	c.limiter.Wait(url, 1, false)
+
+	pipeReader, pipeWriter := io.Pipe()
+	writer := multipart.NewWriter(pipeWriter)
+	go func() {
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := writer.WriteField(name, fields[name]); err != nil {
+				pipeWriter.CloseWithError(err)
+				return
+			}
+		}
+		part, err := writer.CreateFormFile(fileField, filepath.Base(filePath))
+		if err == nil {
+			_, err = io.Copy(part, file)
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		pipeWriter.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequest("POST", url, pipeReader)
+	if err != nil {
+		pipeReader.Close()
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		pipeReader.Close()
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error: %s - Body: %s", resp.Status, string(bodyBytes))
+	}
+
 	return bodyBytes, nil
 }
 
@@ -134,7 +293,7 @@ func (c *HTTPClient) VerifyMultimodalCapability(endpoint, apiKey, model string)
 	}
 
 	// Try to send the multimodal request
-	_, err := c.Post(endpoint, headers, reqBody)
+	_, err := c.Post(context.Background(), endpoint, headers, reqBody)
 	if err != nil {
 		// Check if the error indicates lack of multimodal support
 		errStr := err.Error()
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPClient_ReusesConnectionsUnderConcurrency(t *testing.T) {
+	var connections atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond) // Keep each burst's requests overlapping
+		w.Write([]byte(`{"choices": []}`))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	config := &Config{}
+	loadDefaults(config)
+	client := NewHTTPClient(config, NewLogger(false))
+
+	// Indexing sends requests in bursts, so the whole burst's connections go idle between them
+	const workers = 16
+	const bursts = 3
+	for range bursts {
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := client.Post(context.Background(), server.URL, nil, map[string]string{"model": "test"}); err != nil {
+					errs <- err
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Fatal(err)
+		}
+	}
+
+	// Idle connections are kept for the next burst instead of being closed
+	if got := connections.Load(); got > workers {
+		t.Errorf("expected at most %d connections for %d requests, got %d", workers, workers*bursts, got)
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	transport := newTransport()
+	if transport.MaxIdleConnsPerHost != maxIdleConnsPerHost || !transport.ForceAttemptHTTP2 {
+		t.Errorf("expected pooled HTTP/2 transport, got %+v", transport)
+	}
+	if transport.TLSHandshakeTimeout != tlsHandshakeTimeout || transport.Proxy == nil {
+		t.Error("expected a handshake timeout and proxy support")
+	}
+}
@@ -1,16 +1,65 @@
 package app
 
 import (
+	"fmt"
+	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"runtime"
 	"strings"
 
 	"github.com/bmatcuk/doublestar/v4"
 )
 
-// IgnorePatternMatcher handles file/directory ignore patterns
+// IgnorePatternMatcher handles file/directory ignore patterns. Patterns follow .gitignore:
+// the last matching pattern wins, "!pattern" re-includes what earlier patterns ignored, a
+// leading "/" anchors a pattern to the base directory and a trailing "/" matches folders only.
+// Patterns without any other "/" match names at any level. As in git, nothing inside an
+// ignored folder can be re-included.
 type IgnorePatternMatcher struct {
-	patterns []string
-	logger   *Logger
+	patterns     []string
+	rules        []ignoreRule
+	ignoreHidden bool // Also ignore dotfiles and (on Windows) files with the hidden or system attribute
+	logger       *Logger
+}
+
+// ignoreRule is one parsed line of the ignore patterns
+type ignoreRule struct {
+	glob     string // Pattern without "!", leading "/" and trailing "/"
+	negate   bool   // "!pattern": re-include matches
+	dirOnly  bool   // "pattern/": only match folders
+	floating bool   // No "/" in the pattern: match the name at any level
+}
+
+// parseIgnoreRule parses a non-empty, non-comment pattern line
+func parseIgnoreRule(line string) ignoreRule {
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:] // Escaped literal "!" or "#"
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	anchored := strings.HasPrefix(line, "/")
+	rule.glob = strings.TrimPrefix(line, "/")
+	rule.floating = !anchored && !strings.Contains(rule.glob, "/")
+	return rule
+}
+
+// matches reports whether the rule applies to path itself (not to its parents)
+func (r ignoreRule) matches(relPath string, isDir bool) (bool, error) {
+	if r.dirOnly && !isDir {
+		return false, nil
+	}
+	if r.floating {
+		return doublestar.Match(r.glob, path.Base(relPath))
+	}
+	return doublestar.Match(r.glob, relPath)
 }
 
 // NewIgnorePatternMatcher creates a new pattern matcher from a multiline string
@@ -26,11 +75,12 @@ func NewIgnorePatternMatcher(patternsText string, logger *Logger) *IgnorePattern
 		line = strings.TrimSpace(line)
 
 		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") {
+		if line == "" || strings.HasPrefix(line, "#") || line == "!" || line == "/" {
 			continue
 		}
 
 		matcher.patterns = append(matcher.patterns, line)
+		matcher.rules = append(matcher.rules, parseIgnoreRule(line))
 	}
 
 	if logger != nil {
@@ -43,70 +93,133 @@ func NewIgnorePatternMatcher(patternsText string, logger *Logger) *IgnorePattern
 // ShouldIgnore checks if a path should be ignored based on the patterns
 // path should be relative to the base directory and use forward slashes
 func (m *IgnorePatternMatcher) ShouldIgnore(path string, isDir bool) bool {
-	if len(m.patterns) == 0 {
-		return false
+	return m.Explain(path, isDir).Ignored
+}
+
+// IgnoreExplanation tells whether a path is ignored and which pattern decided it
+type IgnoreExplanation struct {
+	Path        string
+	Ignored     bool
+	Pattern     string // Pattern that decided, as written; empty if none matched
+	MatchedPath string // Path the pattern matched: Path itself or one of its parent folders
+	Hidden      bool   // Ignored as a hidden file rather than by a pattern
+}
+
+func (e IgnoreExplanation) String() string {
+	switch {
+	case e.Hidden:
+		return fmt.Sprintf("Ignored: %s is hidden", e.Path)
+	case e.Ignored && e.MatchedPath != e.Path:
+		return fmt.Sprintf("Ignored: its folder %s/ matches %q", e.MatchedPath, e.Pattern)
+	case e.Ignored:
+		return fmt.Sprintf("Ignored by %q", e.Pattern)
+	case e.Pattern != "":
+		return fmt.Sprintf("Included: re-included by %q", e.Pattern)
 	}
+	return "Included: no pattern matches"
+}
 
+// Explain works out whether path is ignored like ShouldIgnore, and reports why
+func (m *IgnorePatternMatcher) Explain(path string, isDir bool) IgnoreExplanation {
 	// Normalize path to use forward slashes
 	path = filepath.ToSlash(path)
-
-	for _, pattern := range m.patterns {
-		// Check if pattern is meant for directories only (ends with /)
-		isDirPattern := strings.HasSuffix(pattern, "/")
-		patternWithoutSlash := strings.TrimSuffix(pattern, "/")
-
-		if isDirPattern {
-			// Directory-specific pattern
-			// Check if this directory matches at the root level
-			if isDir && (path == patternWithoutSlash || strings.HasPrefix(path, patternWithoutSlash+"/")) {
-				return true
+	explanation := IgnoreExplanation{Path: path}
+
+	if m.ignoreHidden {
+		for _, part := range strings.Split(path, "/") {
+			if isHiddenName(part) {
+				explanation.Ignored = true
+				explanation.Hidden = true
+				return explanation
 			}
+		}
+	}
 
-			// Check if this is a file inside an ignored directory (at root level)
-			if !isDir && strings.HasPrefix(path, patternWithoutSlash+"/") {
-				return true
-			}
+	if len(m.rules) == 0 {
+		return explanation
+	}
 
-			// If pattern doesn't contain wildcards, also check if it matches the directory name at ANY level
-			// For example, ".git/" should match both ".git" and "backup/.git"
-			if !strings.Contains(patternWithoutSlash, "*") && !strings.Contains(patternWithoutSlash, "?") {
-				pathParts := strings.Split(path, "/")
-				for i, part := range pathParts {
-					if part == patternWithoutSlash {
-						// This directory matches the pattern
-						if isDir && i == len(pathParts)-1 {
-							// This is the directory itself
-							return true
-						}
-						// This is a file inside the ignored directory (or the directory is an ancestor)
-						return true
-					}
-				}
-			}
+	// Files inside an ignored folder stay ignored, whatever later patterns say about them
+	parts := strings.Split(path, "/")
+	for i := 1; i < len(parts); i++ {
+		parent := strings.Join(parts[:i], "/")
+		if rule := m.lastMatch(parent, true); rule >= 0 && !m.rules[rule].negate {
+			explanation.Ignored = true
+			explanation.Pattern = m.patterns[rule]
+			explanation.MatchedPath = parent
+			return explanation
+		}
+	}
 
-			// Also try glob matching for directory patterns with wildcards
-			matched, err := doublestar.Match(patternWithoutSlash, path)
-			if err == nil && matched {
-				return true
-			}
-		} else {
-			// Regular pattern (not directory-specific)
-			// Use doublestar for glob matching
-			matched, err := doublestar.Match(pattern, path)
-			if err != nil {
-				if m.logger != nil {
-					m.logger.Debug("Error matching pattern %s: %v", pattern, err)
-				}
-				continue
-			}
+	if rule := m.lastMatch(path, isDir); rule >= 0 {
+		explanation.Ignored = !m.rules[rule].negate
+		explanation.Pattern = m.patterns[rule]
+		explanation.MatchedPath = path
+	}
+	return explanation
+}
 
-			if matched {
-				return true
+// lastMatch returns the index of the last rule matching path, or -1 if none does
+func (m *IgnorePatternMatcher) lastMatch(path string, isDir bool) int {
+	for i := len(m.rules) - 1; i >= 0; i-- {
+		matched, err := m.rules[i].matches(path, isDir)
+		if err != nil {
+			if m.logger != nil {
+				m.logger.Debug("Error matching pattern %s: %v", m.patterns[i], err)
 			}
+			continue
+		}
+		if matched {
+			return i
 		}
 	}
+	return -1
+}
+
+// ShouldIgnoreFile is ShouldIgnore for an entry found while walking the disk, which can
+// also recognize files hidden by attribute rather than by name
+func (m *IgnorePatternMatcher) ShouldIgnoreFile(path string, info os.FileInfo) bool {
+	if m.ignoreHidden && hasHiddenAttribute(info) {
+		return true
+	}
+	return m.ShouldIgnore(path, info.IsDir())
+}
+
+// SetIgnoreHidden turns ignoring hidden files on or off
+func (m *IgnorePatternMatcher) SetIgnoreHidden(ignore bool) {
+	m.ignoreHidden = ignore
+}
 
-	return false
+// IgnoresHidden reports whether hidden files are ignored
+func (m *IgnorePatternMatcher) IgnoresHidden() bool {
+	return m != nil && m.ignoreHidden
+}
+
+// isHiddenName reports whether a path element is a dotfile or dot-folder
+func isHiddenName(name string) bool {
+	return strings.HasPrefix(name, ".") && name != "." && name != ".."
+}
+
+const (
+	windowsFileAttributeHidden = 0x2
+	windowsFileAttributeSystem = 0x4
+)
+
+// hasHiddenAttribute reports whether Windows marks a file hidden or as a system file. The
+// attributes live in a Windows-only type, so they're looked up by field name.
+func hasHiddenAttribute(info os.FileInfo) bool {
+	if runtime.GOOS != "windows" || info.Sys() == nil {
+		return false
+	}
+	sys := reflect.Indirect(reflect.ValueOf(info.Sys()))
+	if sys.Kind() != reflect.Struct {
+		return false
+	}
+	attrs := sys.FieldByName("FileAttributes")
+	if !attrs.IsValid() || !attrs.CanUint() {
+		return false
+	}
+	return attrs.Uint()&(windowsFileAttributeHidden|windowsFileAttributeSystem) != 0
 }
 
 // GetPatterns returns the list of active patterns
@@ -208,3 +208,99 @@ func TestIgnorePatternMatcher_EmptyPatterns(t *testing.T) {
 		t.Error("Empty patterns should not ignore anything")
 	}
 }
+
+func TestIgnorePatternMatcher_IgnoreHidden(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns string
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{name: "dotfile", path: ".DS_Store", expected: true},
+		{name: "dot folder", path: ".cache", isDir: true, expected: true},
+		{name: "file inside dot folder", path: "project/.idea/workspace.xml", expected: true},
+		{name: "regular file", path: "docs/report.pdf", expected: false},
+		{name: "dots inside names are not hidden", path: "v1.2/notes..txt", expected: false},
+		{name: "patterns still apply", patterns: "*.log", path: "debug.log", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher := NewIgnorePatternMatcher(tt.patterns, nil)
+			matcher.SetIgnoreHidden(true)
+			if result := matcher.ShouldIgnore(tt.path, tt.isDir); result != tt.expected {
+				t.Errorf("ShouldIgnore(%q, %v) = %v, expected %v", tt.path, tt.isDir, result, tt.expected)
+			}
+		})
+	}
+
+	// Off by default, so existing setups keep seeing their dotfiles
+	if NewIgnorePatternMatcher("", nil).ShouldIgnore(".DS_Store", false) {
+		t.Error("Hidden files should not be ignored unless enabled")
+	}
+}
+
+func TestIgnorePatternMatcher_NegationAndAnchoring(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns string
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{name: "negation re-includes a file", patterns: "*.log\n!important.log", path: "important.log", expected: false},
+		{name: "negation leaves other matches ignored", patterns: "*.log\n!important.log", path: "debug.log", expected: true},
+		{name: "last matching pattern wins", patterns: "!important.log\n*.log", path: "important.log", expected: true},
+		{name: "names match at any level", patterns: "*.log", path: "app/logs/debug.log", expected: true},
+		{name: "negation at any level", patterns: "*.log\n!important.log", path: "app/important.log", expected: false},
+		{name: "cannot re-include inside an ignored folder", patterns: "build/\n!build/keep.txt", path: "build/keep.txt", expected: true},
+		{name: "re-include a folder's contents via a glob", patterns: "logs/*\n!logs/keep.txt", path: "logs/keep.txt", expected: false},
+		{name: "anchored pattern matches at the root", patterns: "/todo.txt", path: "todo.txt", expected: true},
+		{name: "anchored pattern ignores nested names", patterns: "/todo.txt", path: "notes/todo.txt", expected: false},
+		{name: "anchored folder pattern", patterns: "/build/", path: "build", isDir: true, expected: true},
+		{name: "anchored folder pattern ignores nested folders", patterns: "/build/", path: "src/build", isDir: true, expected: false},
+		{name: "anchored folder pattern ignores its contents", patterns: "/build/", path: "build/out/app.exe", expected: true},
+		{name: "pattern with a slash is anchored", patterns: "docs/*.tmp", path: "src/docs/a.tmp", expected: false},
+		{name: "escaped exclamation mark is literal", patterns: `\!readme.txt`, path: "!readme.txt", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher := NewIgnorePatternMatcher(tt.patterns, nil)
+			if result := matcher.ShouldIgnore(tt.path, tt.isDir); result != tt.expected {
+				t.Errorf("ShouldIgnore(%q, %v) = %v, want %v", tt.path, tt.isDir, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIgnorePatternMatcher_Explain(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		isDir       bool
+		wantIgnored bool
+		wantPattern string
+		wantMatched string
+		wantString  string
+	}{
+		{name: "no match", path: "notes.txt", wantString: "Included: no pattern matches"},
+		{name: "direct match", path: "debug.log", wantIgnored: true, wantPattern: "*.log", wantMatched: "debug.log", wantString: `Ignored by "*.log"`},
+		{name: "re-included", path: "important.log", wantPattern: "!important.log", wantMatched: "important.log", wantString: `Included: re-included by "!important.log"`},
+		{name: "inside ignored folder", path: "build/keep.txt", wantIgnored: true, wantPattern: "build/", wantMatched: "build", wantString: `Ignored: its folder build/ matches "build/"`},
+	}
+
+	matcher := NewIgnorePatternMatcher("*.log\n!important.log\nbuild/\n!build/keep.txt", nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matcher.Explain(tt.path, tt.isDir)
+			if got.Ignored != tt.wantIgnored || got.Pattern != tt.wantPattern || got.MatchedPath != tt.wantMatched {
+				t.Errorf("Explain(%q) = %+v, want ignored=%v pattern=%q matched=%q", tt.path, got, tt.wantIgnored, tt.wantPattern, tt.wantMatched)
+			}
+			if got.String() != tt.wantString {
+				t.Errorf("String() = %q, want %q", got.String(), tt.wantString)
+			}
+		})
+	}
+}
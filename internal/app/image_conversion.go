@@ -0,0 +1,280 @@
+package app
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+var ErrNoImageConverter = errors.New("no converter found for this image format (install libheif or ImageMagick)")
+
+// Image formats vision models don't accept, converted to JPEG before upload
+var (
+	heifExtensions = []string{".heic", ".heif", ".avif"}
+	rawExtensions  = []string{".dng", ".cr2", ".cr3", ".nef", ".arw", ".orf", ".rw2", ".raf", ".pef", ".srw"}
+)
+
+const (
+	maxImageUploadSize    = 5 * 1024 * 1024 // Larger images are re-encoded before upload
+	convertedJPEGQuality  = 85
+	imageConverterTimeout = 60 * time.Second
+)
+
+// needsImageConversion reports whether filePath is in a format that must be converted before
+// it is sent to a vision model
+func needsImageConversion(filePath string) bool {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	return slices.Contains(heifExtensions, ext) || slices.Contains(rawExtensions, ext)
+}
+
+// prepareImage returns the image to send to a vision model for filePath. HEIF, AVIF and camera RAW
+// images are converted to JPEG: RAW files use the largest preview embedded by the camera, other
+// formats (and RAW files without a usable preview) go through sips on macOS, heif-convert or
+// ImageMagick. Images larger than maxDimension or maxImageUploadSize are then downscaled.
+func prepareImage(filePath string, maxDimension int) ([]byte, error) {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	var data []byte
+	if !slices.Contains(heifExtensions, ext) {
+		var err error
+		if data, err = os.ReadFile(filePath); err != nil {
+			return nil, err
+		}
+		if slices.Contains(rawExtensions, ext) {
+			data = rawPreview(data)
+		}
+	}
+
+	if data == nil {
+		converted, err := convertWithExternalTool(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %s to JPEG: %w", filepath.Base(filePath), err)
+		}
+		// The converters have already turned the pixels upright
+		return downscaleImage(converted, maxDimension, false)
+	}
+	return downscaleImage(data, maxDimension, true)
+}
+
+// downscaleImage returns data as it is when it's small enough to upload, or else re-encoded as a
+// JPEG whose longest edge is at most maxDimension. Re-encoding drops the EXIF orientation, so with
+// orient set the pixels are turned upright first.
+func downscaleImage(data []byte, maxDimension int, orient bool) ([]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		// Formats Go can't decode are left for the model to try
+		if len(data) > maxImageUploadSize {
+			return nil, fmt.Errorf("image file too large (%d bytes) and its format can't be downscaled", len(data))
+		}
+		return data, nil
+	}
+	if len(data) <= maxImageUploadSize && max(config.Width, config.Height) <= maxDimension {
+		return data, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	img = scaleImage(img, maxDimension)
+	if orient {
+		img = applyOrientation(img, exifOrientation(data))
+	}
+	return encodeJPEG(img)
+}
+
+// rawPreview returns the largest JPEG preview embedded in a camera RAW file, or nil if it has none.
+// Previews are found by their start-of-image marker, which avoids parsing each vendor's container.
+func rawPreview(data []byte) []byte {
+	soi := []byte{0xFF, 0xD8, 0xFF}
+	best, bestPixels := -1, 0
+	for offset := 0; ; offset++ {
+		i := bytes.Index(data[offset:], soi)
+		if i < 0 {
+			break
+		}
+		offset += i
+		// Lossless JPEG raw data and false matches fail here without decoding any pixels
+		config, err := jpeg.DecodeConfig(bytes.NewReader(data[offset:]))
+		if err == nil && config.Width*config.Height > bestPixels {
+			best, bestPixels = offset, config.Width*config.Height
+		}
+	}
+	if best < 0 {
+		return nil
+	}
+	return data[best:]
+}
+
+// convertWithExternalTool converts filePath to JPEG with the first converter found on this system
+func convertWithExternalTool(filePath string) ([]byte, error) {
+	tmpDir, err := os.MkdirTemp("", "vaf-convert-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	out := filepath.Join(tmpDir, "converted.jpg")
+
+	var converters [][]string
+	if runtime.GOOS == "darwin" {
+		converters = append(converters, []string{"sips", "-s", "format", "jpeg", filePath, "--out", out})
+	}
+	if slices.Contains(heifExtensions, strings.ToLower(filepath.Ext(filePath))) {
+		converters = append(converters, []string{"heif-convert", filePath, out})
+	}
+	converters = append(converters, []string{"magick", filePath + "[0]", "-auto-orient", out})
+	if runtime.GOOS != "windows" { // Windows has an unrelated convert.exe
+		converters = append(converters, []string{"convert", filePath + "[0]", "-auto-orient", out})
+	}
+
+	var lastErr error
+	for _, args := range converters {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		if _, err := runArgs(args, nil, imageConverterTimeout); err != nil {
+			lastErr = err
+			continue
+		}
+		if data, err := os.ReadFile(out); err == nil {
+			return data, nil
+		}
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, ErrNoImageConverter
+}
+
+// scaleImage scales img down so its longest edge is at most maxDimension
+func scaleImage(img image.Image, maxDimension int) image.Image {
+	bounds := img.Bounds()
+	longest := max(bounds.Dx(), bounds.Dy())
+	if longest <= maxDimension {
+		return img
+	}
+	width := max(bounds.Dx()*maxDimension/longest, 1)
+	height := max(bounds.Dy()*maxDimension/longest, 1)
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.BiLinear.Scale(scaled, scaled.Bounds(), img, bounds, draw.Src, nil)
+	return scaled
+}
+
+// exifOrientation returns the EXIF orientation (1-8) of a JPEG, or 1 if it has none
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || i+2+length > len(data) { // Image data starts; no more metadata
+			break
+		}
+		if segment := data[i+4 : i+2+length]; marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// tiffOrientation reads the orientation tag from the first IFD of a TIFF header
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for e := range entries {
+		entry := ifd + 2 + e*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if orientation := int(order.Uint16(tiff[entry+8:])); orientation >= 1 && orientation <= 8 {
+				return orientation
+			}
+			break
+		}
+	}
+	return 1
+}
+
+// applyOrientation turns img upright according to an EXIF orientation
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	dstW, dstH := w, h
+	if orientation >= 5 { // Rotated a quarter turn
+		dstW, dstH = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := range h {
+		for x := range w {
+			var dx, dy int
+			switch orientation {
+			case 2: // Mirrored left to right
+				dx, dy = w-1-x, y
+			case 3: // Half a turn
+				dx, dy = w-1-x, h-1-y
+			case 4: // Mirrored top to bottom
+				dx, dy = x, h-1-y
+			case 5: // Transposed
+				dx, dy = y, x
+			case 6: // A quarter turn clockwise
+				dx, dy = h-1-y, x
+			case 7: // Transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // A quarter turn anticlockwise
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// encodeJPEG encodes img as JPEG, flattening any transparency onto white
+func encodeJPEG(img image.Image) ([]byte, error) {
+	flat := image.NewRGBA(img.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: convertedJPEGQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode JPEG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
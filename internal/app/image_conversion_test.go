@@ -0,0 +1,225 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func jpegOfSize(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := range width {
+		img.Set(x, 0, color.RGBA{R: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// fakeRAW builds a TIFF-like file with a thumbnail and a larger preview between sensor data
+func fakeRAW(t *testing.T) []byte {
+	var raw bytes.Buffer
+	raw.WriteString("II*\x00\x08\x00\x00\x00")
+	raw.Write(bytes.Repeat([]byte{0x12, 0x34}, 500))
+	raw.Write(jpegOfSize(t, 16, 12))
+	raw.Write([]byte{0xFF, 0xD8, 0xFF, 0x00, 0x01}) // A false marker in sensor data
+	raw.Write(bytes.Repeat([]byte{0x56}, 500))
+	raw.Write(jpegOfSize(t, 320, 240))
+	raw.Write(bytes.Repeat([]byte{0x78}, 500))
+	return raw.Bytes()
+}
+
+// withOrientation inserts an EXIF segment with the given orientation after the JPEG's SOI marker
+func withOrientation(data []byte, orientation byte) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08" + // Big-endian header, IFD at offset 8
+		"\x00\x01" + // One entry
+		"\x01\x12\x00\x03\x00\x00\x00\x01\x00" + string([]byte{orientation}) + "\x00\x00" +
+		"\x00\x00\x00\x00") // No next IFD
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, byte((len(segment) + 2) >> 8), byte(len(segment) + 2)}
+	out := append([]byte{}, data[:2]...)
+	out = append(out, app1...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func jpegSize(t *testing.T, data []byte) (int, int) {
+	t.Helper()
+	config, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("expected a JPEG: %v", err)
+	}
+	return config.Width, config.Height
+}
+
+func TestRawPreview(t *testing.T) {
+	preview := rawPreview(fakeRAW(t))
+	if preview == nil {
+		t.Fatal("expected a preview")
+	}
+	if w, h := jpegSize(t, preview); w != 320 || h != 240 {
+		t.Errorf("expected the 320x240 preview, got %dx%d", w, h)
+	}
+
+	if rawPreview(bytes.Repeat([]byte{0xAB}, 1000)) != nil {
+		t.Error("expected no preview in a file without one")
+	}
+}
+
+func TestExifOrientation(t *testing.T) {
+	plain := jpegOfSize(t, 8, 8)
+	if got := exifOrientation(plain); got != 1 {
+		t.Errorf("expected 1 without EXIF, got %d", got)
+	}
+	for _, orientation := range []byte{3, 6, 8} {
+		if got := exifOrientation(withOrientation(plain, orientation)); got != int(orientation) {
+			t.Errorf("expected %d, got %d", orientation, got)
+		}
+	}
+	if got := exifOrientation([]byte("not a jpeg")); got != 1 {
+		t.Errorf("expected 1 for other data, got %d", got)
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// A 2x1 image with a red left pixel
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+
+	tests := []struct {
+		orientation int
+		red         image.Point
+	}{
+		{1, image.Pt(0, 0)},
+		{3, image.Pt(1, 0)},
+		{6, image.Pt(0, 0)}, // Left edge turns clockwise to the top
+		{8, image.Pt(0, 1)}, // Left edge turns anticlockwise to the bottom
+	}
+	for _, tt := range tests {
+		got := applyOrientation(img, tt.orientation)
+		if r, _, _, _ := got.At(tt.red.X, tt.red.Y).RGBA(); r == 0 {
+			t.Errorf("orientation %d: expected the red pixel at %v", tt.orientation, tt.red)
+		}
+	}
+}
+
+func TestDownscaleImage(t *testing.T) {
+	var png400 bytes.Buffer
+	if err := png.Encode(&png400, image.NewRGBA(image.Rect(0, 0, 400, 300))); err != nil {
+		t.Fatal(err)
+	}
+	small := jpegOfSize(t, 80, 60)
+
+	tests := []struct {
+		name         string
+		data         []byte
+		orient       bool
+		wantW, wantH int
+		unchanged    bool
+	}{
+		{"landscape", jpegOfSize(t, 400, 300), true, 100, 75, false},
+		{"portrait", jpegOfSize(t, 300, 400), true, 75, 100, false},
+		{"png becomes jpeg", png400.Bytes(), true, 100, 75, false},
+		{"small enough", small, true, 80, 60, true},
+		{"turned upright", withOrientation(jpegOfSize(t, 400, 200), 6), true, 50, 100, false},
+		{"already upright", withOrientation(jpegOfSize(t, 400, 200), 6), false, 100, 50, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := downscaleImage(tt.data, 100, tt.orient)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.unchanged && !bytes.Equal(got, tt.data) {
+				t.Error("expected the image to be sent as it is")
+			}
+			if w, h := jpegSize(t, got); w != tt.wantW || h != tt.wantH {
+				t.Errorf("expected %dx%d, got %dx%d", tt.wantW, tt.wantH, w, h)
+			}
+		})
+	}
+
+	// Formats that can't be decoded are sent as they are while they're small
+	unknown := []byte("\x00\x00\x00\x18ftypheic")
+	if got, err := downscaleImage(unknown, 100, true); err != nil || !bytes.Equal(got, unknown) {
+		t.Errorf("expected an undecodable image to be passed through, got err %v", err)
+	}
+	if _, err := downscaleImage(make([]byte, maxImageUploadSize+1), 100, true); err == nil {
+		t.Error("expected an error for a large image that can't be downscaled")
+	}
+}
+
+func TestAnalyzeFile_ConvertsRAWToJPEG(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "IMG_0042.DNG")
+	if err := os.WriteFile(path, fakeRAW(t), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var sentJPEG bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sentJPEG = strings.Contains(string(body), "data:image/jpeg;base64,/9j/")
+		w.Write([]byte(`{"choices": [{"message": {"content": "A photo with a red line."}}]}`))
+	}))
+	defer server.Close()
+
+	config := &Config{Endpoint: server.URL}
+	logger := NewLogger(false)
+	das := NewDeepAnalysisService(config, NewHTTPClient(config, logger), nil, logger)
+	description, err := das.AnalyzeFile(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sentJPEG || description != "A photo with a red line." {
+		t.Errorf("expected the preview to be sent as JPEG, got %q (JPEG sent: %v)", description, sentJPEG)
+	}
+}
+
+func TestAnalyzeFile_DownscalesLargeImages(t *testing.T) {
+	var photo bytes.Buffer
+	if err := png.Encode(&photo, image.NewRGBA(image.Rect(0, 0, 640, 480))); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "scan.png")
+	if err := os.WriteFile(path, photo.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var sent []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if _, data, ok := strings.Cut(string(body), "data:image/jpeg;base64,"); ok {
+			data, _, _ = strings.Cut(data, `"`)
+			sent, _ = base64.StdEncoding.DecodeString(data)
+		}
+		w.Write([]byte(`{"choices": [{"message": {"content": "A blank scan."}}]}`))
+	}))
+	defer server.Close()
+
+	config := &Config{Endpoint: server.URL, ImageMaxDimension: 320}
+	logger := NewLogger(false)
+	das := NewDeepAnalysisService(config, NewHTTPClient(config, logger), nil, logger)
+	if _, err := das.AnalyzeFile(context.Background(), path); err != nil {
+		t.Fatal(err)
+	}
+	if sent == nil {
+		t.Fatal("expected the image to be sent as JPEG")
+	}
+	if w, h := jpegSize(t, sent); w != 320 || h != 240 {
+		t.Errorf("expected the image downscaled to 320x240, got %dx%d", w, h)
+	}
+}
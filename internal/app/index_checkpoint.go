@@ -0,0 +1,157 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// IndexCheckpoint is the work list of an indexing run and how far it got. It is kept until the run
+// finishes, so a run interrupted by a crash or quit picks up after the last processed file
+// instead of rescanning the directory and retrying files that failed analysis.
+type IndexCheckpoint struct {
+	DirPath       string
+	MaxDepth      int
+	NewFiles      []string
+	ModifiedFiles []string
+	DeletedFiles  []string
+	LastPath      string // Last processed file; empty before the first
+}
+
+// Files returns the files to index in processing order: new files, then modified ones
+func (cp *IndexCheckpoint) Files() []string {
+	return append(slices.Clone(cp.NewFiles), cp.ModifiedFiles...)
+}
+
+// Done returns how many files were processed before the interruption
+func (cp *IndexCheckpoint) Done() int {
+	if cp.LastPath == "" {
+		return 0
+	}
+	return slices.Index(cp.Files(), cp.LastPath) + 1
+}
+
+// IndexCheckpointStore persists indexing checkpoints, one per directory
+type IndexCheckpointStore interface {
+	LoadIndexCheckpoint(dirPath string) (*IndexCheckpoint, error) // nil if there is none
+	SaveIndexCheckpoint(checkpoint *IndexCheckpoint) error
+	AdvanceIndexCheckpoint(dirPath, lastPath string) error
+	ClearIndexCheckpoint(dirPath string) error
+}
+
+const indexCheckpointSchema = `
+	CREATE TABLE IF NOT EXISTS index_checkpoints (
+		dir_path TEXT PRIMARY KEY,
+		max_depth INTEGER NOT NULL,
+		new_files TEXT NOT NULL,
+		modified_files TEXT NOT NULL,
+		deleted_files TEXT NOT NULL,
+		last_path TEXT NOT NULL DEFAULT '',
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+`
+
+func (is *DefaultIndexService) LoadIndexCheckpoint(dirPath string) (*IndexCheckpoint, error) {
+	checkpoint := &IndexCheckpoint{DirPath: dirPath}
+	var newFiles, modifiedFiles, deletedFiles string
+	err := is.db.QueryRow(`
+		SELECT max_depth, new_files, modified_files, deleted_files, last_path
+		FROM index_checkpoints WHERE dir_path = ?
+	`, dirPath).Scan(&checkpoint.MaxDepth, &newFiles, &modifiedFiles, &deletedFiles, &checkpoint.LastPath)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load indexing checkpoint: %w", err)
+	}
+
+	for _, list := range []struct {
+		data string
+		dest *[]string
+	}{
+		{newFiles, &checkpoint.NewFiles},
+		{modifiedFiles, &checkpoint.ModifiedFiles},
+		{deletedFiles, &checkpoint.DeletedFiles},
+	} {
+		if err := json.Unmarshal([]byte(list.data), list.dest); err != nil {
+			return nil, fmt.Errorf("failed to decode indexing checkpoint: %w", err)
+		}
+	}
+	return checkpoint, nil
+}
+
+func (is *DefaultIndexService) SaveIndexCheckpoint(checkpoint *IndexCheckpoint) error {
+	lists := make([]string, 0, 3)
+	for _, files := range [][]string{checkpoint.NewFiles, checkpoint.ModifiedFiles, checkpoint.DeletedFiles} {
+		data, err := json.Marshal(files)
+		if err != nil {
+			return err
+		}
+		lists = append(lists, string(data))
+	}
+
+	_, err := is.db.Exec(`
+		INSERT INTO index_checkpoints (dir_path, max_depth, new_files, modified_files, deleted_files, last_path, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(dir_path) DO UPDATE SET
+			max_depth = excluded.max_depth,
+			new_files = excluded.new_files,
+			modified_files = excluded.modified_files,
+			deleted_files = excluded.deleted_files,
+			last_path = excluded.last_path,
+			updated_at = CURRENT_TIMESTAMP
+	`, checkpoint.DirPath, checkpoint.MaxDepth, lists[0], lists[1], lists[2], checkpoint.LastPath)
+	if err != nil {
+		return fmt.Errorf("failed to save indexing checkpoint: %w", err)
+	}
+	return nil
+}
+
+func (is *DefaultIndexService) AdvanceIndexCheckpoint(dirPath, lastPath string) error {
+	_, err := is.db.Exec(`
+		UPDATE index_checkpoints SET last_path = ?, updated_at = CURRENT_TIMESTAMP WHERE dir_path = ?
+	`, lastPath, dirPath)
+	return err
+}
+
+func (is *DefaultIndexService) ClearIndexCheckpoint(dirPath string) error {
+	_, err := is.db.Exec("DELETE FROM index_checkpoints WHERE dir_path = ?", dirPath)
+	return err
+}
+
+// purgeIndexCheckpoints drops the checkpoints of directories that contain or lie inside matching
+// paths; those directories are simply rescanned next time
+func (is *DefaultIndexService) purgeIndexCheckpoints(match func(path string) bool) error {
+	rows, err := is.db.Query("SELECT dir_path FROM index_checkpoints")
+	if err != nil {
+		return err
+	}
+	var dirs []string
+	for rows.Next() {
+		var dirPath string
+		if err := rows.Scan(&dirPath); err != nil {
+			rows.Close()
+			return err
+		}
+		dirs = append(dirs, dirPath)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, dirPath := range dirs {
+		checkpoint, err := is.LoadIndexCheckpoint(dirPath)
+		if err != nil || checkpoint == nil {
+			continue
+		}
+		files := append(checkpoint.Files(), checkpoint.DeletedFiles...)
+		if match(dirPath) || slices.ContainsFunc(files, match) {
+			if err := is.ClearIndexCheckpoint(dirPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
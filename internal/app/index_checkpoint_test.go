@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// scriptedAnalyzer records the files it is asked about and fails on the ones listed in errs
+type scriptedAnalyzer struct {
+	analyzed []string
+	errs     map[string]error
+}
+
+func (a *scriptedAnalyzer) AnalyzeFile(_ context.Context, filePath string) (string, error) {
+	a.analyzed = append(a.analyzed, filepath.Base(filePath))
+	if err := a.errs[filepath.Base(filePath)]; err != nil {
+		return "", err
+	}
+	return "description of " + filepath.Base(filePath), nil
+}
+
+func TestIndexDirectory_ResumesFromCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt", "e.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logger := NewLogger(false)
+	indexService := NewIndexService(logger)
+	if err := indexService.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer indexService.Close()
+
+	// The first run fails to analyze b.txt and is cut off at d.txt
+	interrupted := &scriptedAnalyzer{errs: map[string]error{
+		"b.txt": errors.New("model refused"),
+		"d.txt": ErrBudgetExceeded,
+	}}
+	err := NewIndexDirectoryOrchestrator(indexService, interrupted, logger).IndexDirectory(context.Background(), dir, 1, nil)
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected the run to stop on the budget, got %v", err)
+	}
+
+	checkpoint, err := indexService.LoadIndexCheckpoint(dir)
+	if err != nil || checkpoint == nil {
+		t.Fatalf("expected a checkpoint, got %v (%v)", checkpoint, err)
+	}
+	if filepath.Base(checkpoint.LastPath) != "c.txt" || checkpoint.Done() != 3 {
+		t.Errorf("expected progress after c.txt, got %s (%d done)", checkpoint.LastPath, checkpoint.Done())
+	}
+
+	// The second run continues with d.txt without retrying b.txt
+	resumed := &scriptedAnalyzer{}
+	var progress []int
+	err = NewIndexDirectoryOrchestrator(indexService, resumed, logger).IndexDirectory(context.Background(), dir, 1, func(current, total int, fileName string) {
+		progress = append(progress, current)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resumed.analyzed) != 2 || resumed.analyzed[0] != "d.txt" || resumed.analyzed[1] != "e.txt" {
+		t.Errorf("expected only d.txt and e.txt to be analyzed, got %v", resumed.analyzed)
+	}
+	if len(progress) != 2 || progress[0] != 4 {
+		t.Errorf("expected progress to continue at 4 of 5, got %v", progress)
+	}
+
+	if checkpoint, _ := indexService.LoadIndexCheckpoint(dir); checkpoint != nil {
+		t.Error("expected the checkpoint to be cleared after a complete run")
+	}
+
+	// Without a checkpoint, the next run scans again and retries b.txt
+	rescan := &scriptedAnalyzer{}
+	if err := NewIndexDirectoryOrchestrator(indexService, rescan, logger).IndexDirectory(context.Background(), dir, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(rescan.analyzed) != 1 || rescan.analyzed[0] != "b.txt" {
+		t.Errorf("expected a fresh scan to retry b.txt, got %v", rescan.analyzed)
+	}
+}
+
+func TestIndexDirectory_IgnoresCheckpointForOtherDepth(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := NewLogger(false)
+	indexService := NewIndexService(logger)
+	if err := indexService.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer indexService.Close()
+
+	stale := &IndexCheckpoint{DirPath: dir, MaxDepth: 5, NewFiles: []string{filepath.Join(dir, "gone.txt")}}
+	if err := indexService.SaveIndexCheckpoint(stale); err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer := &scriptedAnalyzer{}
+	if err := NewIndexDirectoryOrchestrator(indexService, analyzer, logger).IndexDirectory(context.Background(), dir, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(analyzer.analyzed) != 1 || analyzer.analyzed[0] != "a.txt" {
+		t.Errorf("expected a fresh scan, got %v", analyzer.analyzed)
+	}
+}
@@ -0,0 +1,322 @@
+package app
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrWrongIndexKey       = errors.New("the index was encrypted with a different key")
+	ErrIndexKeyUnavailable = errors.New("the index encryption key could not be read from the keychain")
+)
+
+const (
+	// indexKeyAccount names the index passphrase in the keychain
+	indexKeyAccount = "index-encryption"
+
+	encryptedDescriptionPrefix = "enc1:"
+	indexKeyIterations         = 600000
+	indexKeyCheckValue         = "vibesandfolders-index"
+
+	metaDescriptionSalt  = "description_salt"
+	metaDescriptionCheck = "description_check"
+)
+
+// descriptionCipher encrypts descriptions with AES-256-GCM under a key derived from a passphrase
+type descriptionCipher struct {
+	aead cipher.AEAD
+}
+
+func newDescriptionCipher(passphrase string, salt []byte) (*descriptionCipher, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, indexKeyIterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &descriptionCipher{aead: aead}, nil
+}
+
+// seal encrypts text into "enc1:" followed by the base64 of nonce and ciphertext
+func (c *descriptionCipher) seal(text string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(text), nil)
+	return encryptedDescriptionPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (c *descriptionCipher) open(stored string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(stored, encryptedDescriptionPrefix))
+	if err != nil {
+		return "", err
+	}
+	if len(data) < c.aead.NonceSize() {
+		return "", errors.New("encrypted description is truncated")
+	}
+	nonce, sealed := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	text, err := c.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(text), nil
+}
+
+// ConfigureEncryption brings the description column in line with the setting: when enabled,
+// descriptions are encrypted with a passphrase kept in keys (created on first use); when
+// disabled, descriptions encrypted earlier are decrypted again.
+func (is *DefaultIndexService) ConfigureEncryption(enabled bool, keys SecretStore) error {
+	encrypted, err := is.IsEncrypted()
+	if err != nil {
+		return err
+	}
+	if !enabled && !encrypted {
+		return nil
+	}
+	is.requireEncryption = enabled
+
+	passphrase, err := keys.Get(indexKeyAccount)
+	if errors.Is(err, ErrSecretNotFound) && enabled && !encrypted {
+		if passphrase, err = generatePassphrase(); err == nil {
+			err = keys.Set(indexKeyAccount, passphrase)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrIndexKeyUnavailable, err)
+	}
+
+	if enabled {
+		return is.EnableEncryption(passphrase)
+	}
+	return is.DisableEncryption(passphrase)
+}
+
+// IsEncrypted reports whether descriptions in the index have been encrypted
+func (is *DefaultIndexService) IsEncrypted() (bool, error) {
+	_, found, err := is.indexMeta(metaDescriptionCheck)
+	return found, err
+}
+
+// EnableEncryption encrypts new descriptions with passphrase from now on and encrypts the ones
+// already stored. An index encrypted before must be opened with the same passphrase.
+func (is *DefaultIndexService) EnableEncryption(passphrase string) error {
+	c, err := is.unlockDescriptions(passphrase, true)
+	if err != nil {
+		return err
+	}
+
+	count, err := is.rewriteDescriptions(func(description string) (string, bool, error) {
+		if description == "" || strings.HasPrefix(description, encryptedDescriptionPrefix) {
+			return "", false, nil
+		}
+		sealed, err := c.seal(description)
+		return sealed, true, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encrypt existing descriptions: %w", err)
+	}
+
+	is.cipher = c
+	if count > 0 {
+		is.logger.Info("Encrypted %d existing descriptions in the index", count)
+	}
+	return nil
+}
+
+// DisableEncryption decrypts every stored description and forgets the key check
+func (is *DefaultIndexService) DisableEncryption(passphrase string) error {
+	c, err := is.unlockDescriptions(passphrase, false)
+	if err != nil {
+		return err
+	}
+
+	count, err := is.rewriteDescriptions(func(description string) (string, bool, error) {
+		if !strings.HasPrefix(description, encryptedDescriptionPrefix) {
+			return "", false, nil
+		}
+		text, err := c.open(description)
+		return text, true, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to decrypt descriptions: %w", err)
+	}
+	if _, err := is.db.Exec("DELETE FROM index_meta WHERE key IN (?, ?)", metaDescriptionSalt, metaDescriptionCheck); err != nil {
+		return err
+	}
+
+	is.cipher = nil
+	is.logger.Info("Decrypted %d descriptions in the index", count)
+	return nil
+}
+
+// unlockDescriptions derives the cipher for passphrase and verifies it against the stored check
+// value. With create set, an index that was never encrypted gets a fresh salt and check value.
+func (is *DefaultIndexService) unlockDescriptions(passphrase string, create bool) (*descriptionCipher, error) {
+	check, encrypted, err := is.indexMeta(metaDescriptionCheck)
+	if err != nil {
+		return nil, err
+	}
+	if !encrypted && !create {
+		return nil, nil
+	}
+
+	var salt []byte
+	if encrypted {
+		encodedSalt, _, err := is.indexMeta(metaDescriptionSalt)
+		if err != nil {
+			return nil, err
+		}
+		if salt, err = base64.StdEncoding.DecodeString(encodedSalt); err != nil {
+			return nil, fmt.Errorf("invalid index salt: %w", err)
+		}
+	} else {
+		salt = make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+	}
+
+	c, err := newDescriptionCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if encrypted {
+		if value, err := c.open(check); err != nil || value != indexKeyCheckValue {
+			return nil, ErrWrongIndexKey
+		}
+		return c, nil
+	}
+
+	sealedCheck, err := c.seal(indexKeyCheckValue)
+	if err != nil {
+		return nil, err
+	}
+	if err := is.setIndexMeta(metaDescriptionSalt, base64.StdEncoding.EncodeToString(salt)); err != nil {
+		return nil, err
+	}
+	if err := is.setIndexMeta(metaDescriptionCheck, sealedCheck); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// rewriteDescriptions passes every stored description through transform in one transaction
+// and returns how many were changed
+func (is *DefaultIndexService) rewriteDescriptions(transform func(description string) (string, bool, error)) (int, error) {
+	rows, err := is.db.Query("SELECT id, description FROM indexed_files WHERE description IS NOT NULL")
+	if err != nil {
+		return 0, err
+	}
+	updates := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var description string
+		if err := rows.Scan(&id, &description); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		changed, ok, err := transform(description)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if ok {
+			updates[id] = changed
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(updates) == 0 {
+		return 0, nil
+	}
+
+	tx, err := is.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	for id, description := range updates {
+		if _, err := tx.Exec("UPDATE indexed_files SET description = ? WHERE id = ?", description, id); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+	return len(updates), tx.Commit()
+}
+
+// sealDescription encrypts description for storage when encryption is on
+func (is *DefaultIndexService) sealDescription(description string) (string, error) {
+	if description == "" {
+		return description, nil
+	}
+	if is.cipher == nil {
+		if is.requireEncryption {
+			return "", ErrIndexKeyUnavailable
+		}
+		return description, nil
+	}
+	return is.cipher.seal(description)
+}
+
+// openDescription decrypts a stored description. Without the key, encrypted descriptions read as
+// empty, which callers already treat as a file that has no description yet.
+func (is *DefaultIndexService) openDescription(stored string) string {
+	if !strings.HasPrefix(stored, encryptedDescriptionPrefix) {
+		return stored
+	}
+	if is.cipher == nil {
+		return ""
+	}
+	text, err := is.cipher.open(stored)
+	if err != nil {
+		is.logger.Debug("Failed to decrypt index description: %v", err)
+		return ""
+	}
+	return text
+}
+
+func (is *DefaultIndexService) indexMeta(key string) (string, bool, error) {
+	var value string
+	err := is.db.QueryRow("SELECT value FROM index_meta WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+func (is *DefaultIndexService) setIndexMeta(key, value string) error {
+	_, err := is.db.Exec(`
+		INSERT INTO index_meta (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`, key, value)
+	return err
+}
+
+// generatePassphrase creates a random passphrase for a new encrypted index
+func generatePassphrase() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memorySecretStore is an in-memory SecretStore for tests
+type memorySecretStore map[string]string
+
+func (m memorySecretStore) Get(account string) (string, error) {
+	secret, ok := m[account]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return secret, nil
+}
+
+func (m memorySecretStore) Set(account, secret string) error {
+	m[account] = secret
+	return nil
+}
+
+func openTestIndex(t *testing.T, dbPath string) *DefaultIndexService {
+	t.Helper()
+	is := NewIndexService(NewLogger(false))
+	if err := is.Initialize(dbPath); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { is.Close() })
+	return is
+}
+
+func rawDescription(t *testing.T, is *DefaultIndexService, filePath string) string {
+	t.Helper()
+	var description string
+	if err := is.db.QueryRow("SELECT description FROM indexed_files WHERE file_path = ?", filePath).Scan(&description); err != nil {
+		t.Fatal(err)
+	}
+	return description
+}
+
+func TestIndexEncryption(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "index.db")
+	keys := memorySecretStore{}
+	now := time.Now()
+
+	is := openTestIndex(t, dbPath)
+	if err := is.IndexFile("/docs/old.txt", "Indexed before encryption", "text", 10, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := is.ConfigureEncryption(true, keys); err != nil {
+		t.Fatalf("ConfigureEncryption: %v", err)
+	}
+	if keys[indexKeyAccount] == "" {
+		t.Fatal("expected a passphrase to be stored in the keychain")
+	}
+	if err := is.IndexFile("/docs/tax.pdf", "2023 tax return for Jane Doe", "pdf", 20, now); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/docs/old.txt", "/docs/tax.pdf"} {
+		if raw := rawDescription(t, is, path); !strings.HasPrefix(raw, encryptedDescriptionPrefix) {
+			t.Errorf("description of %s stored in plaintext: %q", path, raw)
+		}
+	}
+	file, err := is.GetIndexedFile("/docs/tax.pdf")
+	if err != nil || file == nil || file.Description != "2023 tax return for Jane Doe" {
+		t.Fatalf("expected decrypted description, got %+v (%v)", file, err)
+	}
+	is.Close()
+
+	// Reopening with a different key must not unlock the index
+	reopened := openTestIndex(t, dbPath)
+	if err := reopened.ConfigureEncryption(true, memorySecretStore{indexKeyAccount: "wrong"}); !errors.Is(err, ErrWrongIndexKey) {
+		t.Errorf("expected ErrWrongIndexKey, got %v", err)
+	}
+	if file, _ := reopened.GetIndexedFile("/docs/tax.pdf"); file == nil || file.Description != "" {
+		t.Errorf("expected locked descriptions to read as empty, got %+v", file)
+	}
+	if err := reopened.IndexFile("/docs/new.txt", "secret", "text", 1, now); !errors.Is(err, ErrIndexKeyUnavailable) {
+		t.Errorf("expected plaintext writes to be refused while locked, got %v", err)
+	}
+
+	// Turning encryption off with the right key decrypts everything again
+	if err := reopened.ConfigureEncryption(false, keys); err != nil {
+		t.Fatalf("ConfigureEncryption(false): %v", err)
+	}
+	if raw := rawDescription(t, reopened, "/docs/tax.pdf"); raw != "2023 tax return for Jane Doe" {
+		t.Errorf("expected plaintext after disabling encryption, got %q", raw)
+	}
+	if encrypted, err := reopened.IsEncrypted(); err != nil || encrypted {
+		t.Errorf("expected the index to be marked unencrypted, got %v (%v)", encrypted, err)
+	}
+}
@@ -0,0 +1,85 @@
+package app
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// indexExportRow is one file in an index export
+type indexExportRow struct {
+	Path          string    `json:"path"`
+	Type          string    `json:"type"`
+	Size          int64     `json:"size"`
+	Description   string    `json:"description"`
+	LastModified  time.Time `json:"last_modified"`
+	IndexedAt     time.Time `json:"indexed_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	AnalysisError string    `json:"analysis_error,omitempty"`
+}
+
+func newIndexExportRow(file IndexedFile) indexExportRow {
+	return indexExportRow{
+		Path:          file.FilePath,
+		Type:          file.FileType,
+		Size:          file.FileSize,
+		Description:   file.Description,
+		LastModified:  file.LastModified.UTC(),
+		IndexedAt:     file.IndexedAt.UTC(),
+		UpdatedAt:     file.UpdatedAt.UTC(),
+		AnalysisError: file.AnalysisError,
+	}
+}
+
+// ExportIndexedFiles writes files as JSON when fileName ends in .json, and as CSV otherwise
+func ExportIndexedFiles(w io.Writer, files []IndexedFile, fileName string) error {
+	if strings.EqualFold(filepath.Ext(fileName), ".json") {
+		return ExportIndexedFilesJSON(w, files)
+	}
+	return ExportIndexedFilesCSV(w, files)
+}
+
+// ExportIndexedFilesCSV writes one row per file with UTC timestamps, for spreadsheets
+func ExportIndexedFilesCSV(w io.Writer, files []IndexedFile) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"path", "type", "size", "description", "last_modified", "indexed_at", "updated_at", "analysis_error"})
+	for _, file := range files {
+		row := newIndexExportRow(file)
+		writer.Write([]string{
+			spreadsheetText(row.Path),
+			row.Type,
+			strconv.FormatInt(row.Size, 10),
+			spreadsheetText(row.Description),
+			row.LastModified.Format(time.RFC3339),
+			row.IndexedAt.Format(time.RFC3339),
+			row.UpdatedAt.Format(time.RFC3339),
+			spreadsheetText(row.AnalysisError),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ExportIndexedFilesJSON writes the files as an indented JSON array
+func ExportIndexedFilesJSON(w io.Writer, files []IndexedFile) error {
+	rows := make([]indexExportRow, len(files))
+	for i, file := range files {
+		rows[i] = newIndexExportRow(file)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rows)
+}
+
+// spreadsheetText keeps a spreadsheet from running a cell as a formula. Descriptions are written
+// by the model from file contents, so they can't be trusted to be plain text.
+func spreadsheetText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestExportIndexedFiles(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 9, 30, 0, 0, time.FixedZone("CET", 3600))
+	files := []IndexedFile{
+		{FilePath: "/docs/report.pdf", FileType: "pdf", FileSize: 2048, Description: "Quarterly report, Q1", LastModified: modified, IndexedAt: modified, UpdatedAt: modified},
+		{FilePath: "/docs/sheet.csv", FileType: "csv", FileSize: 10, Description: "=HYPERLINK(\"http://example.com\")", LastModified: modified},
+		{FilePath: "/docs/clip.mp4", FileType: "video", FileSize: 99, AnalysisError: "too long", LastModified: modified},
+	}
+
+	var csvOut bytes.Buffer
+	if err := ExportIndexedFiles(&csvOut, files, "inventory.csv"); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&csvOut).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 || records[0][0] != "path" {
+		t.Fatalf("expected a header and 3 rows, got %v", records)
+	}
+	if got := records[1]; got[0] != "/docs/report.pdf" || got[2] != "2048" || got[3] != "Quarterly report, Q1" || got[4] != "2024-03-01T08:30:00Z" {
+		t.Errorf("unexpected row %v", got)
+	}
+	if got := records[2][3]; got != "'=HYPERLINK(\"http://example.com\")" {
+		t.Errorf("expected the formula to be neutralized, got %q", got)
+	}
+	if got := records[3][7]; got != "too long" {
+		t.Errorf("expected the analysis error, got %q", got)
+	}
+
+	var jsonOut bytes.Buffer
+	if err := ExportIndexedFiles(&jsonOut, files, "inventory.JSON"); err != nil {
+		t.Fatal(err)
+	}
+	var rows []map[string]any
+	if err := json.Unmarshal(jsonOut.Bytes(), &rows); err != nil {
+		t.Fatalf("expected JSON, got %v", err)
+	}
+	if len(rows) != 3 || rows[1]["description"] != files[1].Description || rows[0]["size"] != float64(2048) {
+		t.Errorf("unexpected JSON rows %v", rows)
+	}
+	if _, ok := rows[0]["analysis_error"]; ok {
+		t.Error("expected no analysis_error for a described file")
+	}
+}
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// janitorCheckInterval is how often the janitor re-reads the configured interval to see if a run is due
+const janitorCheckInterval = time.Minute
+
+// JanitorSummary reports the outcome of one background index cleanup
+type JanitorSummary struct {
+	Orphaned int // Entries whose file no longer exists
+	Removed  int
+	Skipped  int // Orphans kept because their folder is unreachable (e.g. an unplugged drive)
+}
+
+func (s JanitorSummary) String() string {
+	summary := fmt.Sprintf("Removed %d of %d orphaned index entries", s.Removed, s.Orphaned)
+	if s.Skipped > 0 {
+		summary += fmt.Sprintf(" (%d kept because their folder is unreachable)", s.Skipped)
+	}
+	return summary
+}
+
+// IndexJanitor periodically removes index entries for files that no longer exist, so directories
+// that are rarely re-analyzed don't accumulate stale entries. The interval is read from the config
+// on every check; zero disables the janitor.
+type IndexJanitor struct {
+	indexService IndexService
+	config       *Config
+	logger       *Logger
+
+	mu        sync.Mutex
+	onSummary func(JanitorSummary)
+	stop      chan struct{}
+	lastRun   time.Time
+}
+
+func NewIndexJanitor(indexService IndexService, config *Config, logger *Logger) *IndexJanitor {
+	return &IndexJanitor{
+		indexService: indexService,
+		config:       config,
+		logger:       logger,
+	}
+}
+
+// SetSummaryHandler sets the callback invoked after a run that found orphaned entries
+func (j *IndexJanitor) SetSummaryHandler(handler func(JanitorSummary)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.onSummary = handler
+}
+
+// Start runs the janitor in the background until Stop is called. The first run happens
+// one interval after start, since analysis already cleans the directory it works on.
+func (j *IndexJanitor) Start() {
+	j.mu.Lock()
+	if j.stop != nil {
+		j.mu.Unlock()
+		return
+	}
+	j.stop = make(chan struct{})
+	j.lastRun = time.Now()
+	stop := j.stop
+	j.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(janitorCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				if j.due(now) {
+					j.runAndReport()
+				}
+			}
+		}
+	}()
+}
+
+// Stop ends the background loop
+func (j *IndexJanitor) Stop() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.stop != nil {
+		close(j.stop)
+		j.stop = nil
+	}
+}
+
+func (j *IndexJanitor) due(now time.Time) bool {
+	interval := time.Duration(j.config.IndexJanitorIntervalHours) * time.Hour
+	if interval <= 0 {
+		return false
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return now.Sub(j.lastRun) >= interval
+}
+
+func (j *IndexJanitor) runAndReport() {
+	j.mu.Lock()
+	j.lastRun = time.Now()
+	j.mu.Unlock()
+
+	summary, err := j.RunOnce()
+	if err != nil {
+		j.logger.Error("Index janitor failed: %v", err)
+		return
+	}
+
+	j.mu.Lock()
+	onSummary := j.onSummary
+	j.mu.Unlock()
+	if summary.Orphaned > 0 && onSummary != nil {
+		onSummary(summary)
+	}
+}
+
+// RunOnce checks the whole index for orphaned entries and removes those whose folder is still
+// reachable. When the folder itself is gone or unresponsive the drive may just be disconnected,
+// so those entries are kept for the next analysis of the directory to sort out.
+func (j *IndexJanitor) RunOnce() (JanitorSummary, error) {
+	var summary JanitorSummary
+
+	orphaned, err := j.indexService.ValidateIndex()
+	if err != nil {
+		return summary, fmt.Errorf("failed to validate index: %w", err)
+	}
+	summary.Orphaned = len(orphaned)
+
+	reachable := make(map[string]bool)
+	for _, filePath := range orphaned {
+		dir := filepath.Dir(filePath)
+		ok, checked := reachable[dir]
+		if !checked {
+			ok = CheckPathReachable(dir, 0) == nil
+			reachable[dir] = ok
+		}
+		if !ok {
+			summary.Skipped++
+			continue
+		}
+
+		if err := j.indexService.RemoveFile(filePath); err != nil {
+			j.logger.Error("Failed to remove orphaned entry %s: %v", filePath, err)
+			continue
+		}
+		summary.Removed++
+		j.logger.Debug("Removed orphaned entry: %s", filePath)
+	}
+
+	j.logger.Info("Index janitor: %s", summary)
+	return summary, nil
+}
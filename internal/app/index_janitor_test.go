@@ -0,0 +1,48 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIndexJanitorRunOnce(t *testing.T) {
+	dir := t.TempDir()
+	indexService := NewIndexService(NewLogger(false))
+	if err := indexService.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer indexService.Close()
+
+	kept := filepath.Join(dir, "kept.txt")
+	if err := os.WriteFile(kept, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	deleted := filepath.Join(dir, "deleted.txt")
+	unreachable := filepath.Join(dir, "unplugged-drive", "photo.jpg")
+	for _, path := range []string{kept, deleted, unreachable} {
+		if err := indexService.IndexFile(path, "description", "text", 1, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	janitor := NewIndexJanitor(indexService, &Config{}, NewLogger(false))
+	summary, err := janitor.RunOnce()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary != (JanitorSummary{Orphaned: 2, Removed: 1, Skipped: 1}) {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	for path, wantIndexed := range map[string]bool{kept: true, deleted: false, unreachable: true} {
+		indexed, err := indexService.IsFileIndexed(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if indexed != wantIndexed {
+			t.Errorf("%s: expected indexed=%v, got %v", filepath.Base(path), wantIndexed, indexed)
+		}
+	}
+}
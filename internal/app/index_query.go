@@ -0,0 +1,204 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Analysis statuses an IndexQuery can filter on
+const (
+	IndexStatusAny       = ""
+	IndexStatusDescribed = "described" // Has a description
+	IndexStatusFailed    = "failed"    // The last analysis failed; the file may still have an older description
+)
+
+// IndexQuery selects the indexed files under DirPath. Zero values don't filter.
+type IndexQuery struct {
+	DirPath       string
+	FileTypes     []string
+	IndexedAfter  time.Time
+	IndexedBefore time.Time
+	Status        string
+}
+
+// IndexQuerier filters indexed files in the database, and remembers files whose analysis failed
+// so they can be listed alongside the indexed ones
+type IndexQuerier interface {
+	QueryIndexedFiles(query IndexQuery) ([]IndexedFile, error)
+	CountIndexedFileTypes(dirPath string) (map[string]int, error)
+	RecordAnalysisFailure(filePath, fileType string, fileSize int64, lastModified time.Time, reason error) error
+	ClearAnalysisFailure(filePath string) error
+}
+
+const analysisFailureSchema = `
+	CREATE TABLE IF NOT EXISTS analysis_failures (
+		file_path TEXT PRIMARY KEY,
+		file_type TEXT NOT NULL,
+		file_size INTEGER NOT NULL,
+		last_modified INTEGER NOT NULL,
+		error TEXT NOT NULL,
+		failed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+`
+
+// indexedFilesView lists indexed files with the error of their last failed analysis, plus the
+// files that failed without ever being indexed
+const indexedFilesView = `(
+	SELECT i.id, i.file_path, i.description, i.file_type, i.file_size, i.last_modified, i.indexed_at,
+		i.updated_at, i.symlink_target, f.error AS analysis_error
+	FROM indexed_files i LEFT JOIN analysis_failures f ON f.file_path = i.file_path
+	UNION ALL
+	SELECT 0, f.file_path, '', f.file_type, f.file_size, f.last_modified, f.failed_at, f.failed_at, NULL, f.error
+	FROM analysis_failures f WHERE f.file_path NOT IN (SELECT file_path FROM indexed_files)
+)`
+
+// underDirectory returns a condition and its arguments matching the files under dirPath
+func underDirectory(dirPath string) (string, []interface{}) {
+	dirPath = filepath.Clean(dirPath)
+	pattern := dirPath
+	if !strings.HasSuffix(pattern, string(filepath.Separator)) {
+		pattern += string(filepath.Separator)
+	}
+	return "(file_path LIKE ? OR file_path = ?)", []interface{}{pattern + "%", dirPath}
+}
+
+// QueryIndexedFiles returns the files under query.DirPath that match its filters, sorted by path.
+// Files that failed analysis and were never indexed are included with an empty description.
+func (is *DefaultIndexService) QueryIndexedFiles(query IndexQuery) ([]IndexedFile, error) {
+	condition, args := underDirectory(query.DirPath)
+	conditions := []string{condition}
+	if len(query.FileTypes) > 0 {
+		conditions = append(conditions, "file_type IN (?"+strings.Repeat(", ?", len(query.FileTypes)-1)+")")
+		for _, fileType := range query.FileTypes {
+			args = append(args, fileType)
+		}
+	}
+	// Timestamps are compared as instants; stored ones carry the offset they were written with
+	if !query.IndexedAfter.IsZero() {
+		conditions = append(conditions, "julianday(indexed_at) >= julianday(?)")
+		args = append(args, query.IndexedAfter.UTC())
+	}
+	if !query.IndexedBefore.IsZero() {
+		conditions = append(conditions, "julianday(indexed_at) < julianday(?)")
+		args = append(args, query.IndexedBefore.UTC())
+	}
+	switch query.Status {
+	case IndexStatusDescribed:
+		conditions = append(conditions, "description IS NOT NULL AND description != ''")
+	case IndexStatusFailed:
+		conditions = append(conditions, "analysis_error IS NOT NULL")
+	}
+
+	rows, err := is.db.Query(`
+		SELECT id, file_path, description, file_type, file_size, last_modified, indexed_at, updated_at, symlink_target, analysis_error
+		FROM `+indexedFilesView+`
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY file_path
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexed files: %w", err)
+	}
+	defer rows.Close()
+
+	var files []IndexedFile
+	for rows.Next() {
+		var file IndexedFile
+		var description, symlinkTarget, analysisError sql.NullString
+		var lastModUnix int64
+		err := rows.Scan(
+			&file.ID, &file.FilePath, &description, &file.FileType, &file.FileSize, &lastModUnix,
+			&file.IndexedAt, &file.UpdatedAt, &symlinkTarget, &analysisError,
+		)
+		if err != nil {
+			return nil, err
+		}
+		file.Description = is.openDescription(description.String)
+		file.LastModified = time.Unix(lastModUnix, 0)
+		file.SymlinkTarget = symlinkTarget.String
+		file.AnalysisError = analysisError.String
+		files = append(files, file)
+	}
+	return files, rows.Err()
+}
+
+// CountIndexedFileTypes returns how many files under dirPath QueryIndexedFiles would list, by type
+func (is *DefaultIndexService) CountIndexedFileTypes(dirPath string) (map[string]int, error) {
+	condition, args := underDirectory(dirPath)
+	rows, err := is.db.Query("SELECT file_type, COUNT(*) FROM "+indexedFilesView+" WHERE "+condition+" GROUP BY file_type", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count indexed files: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var fileType string
+		var count int
+		if err := rows.Scan(&fileType, &count); err != nil {
+			return nil, err
+		}
+		counts[fileType] = count
+	}
+	return counts, rows.Err()
+}
+
+// RecordAnalysisFailure remembers that analyzing filePath failed, replacing any earlier failure
+func (is *DefaultIndexService) RecordAnalysisFailure(filePath, fileType string, fileSize int64, lastModified time.Time, reason error) error {
+	_, err := is.db.Exec(`
+		INSERT INTO analysis_failures (file_path, file_type, file_size, last_modified, error, failed_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(file_path) DO UPDATE SET
+			file_type = excluded.file_type,
+			file_size = excluded.file_size,
+			last_modified = excluded.last_modified,
+			error = excluded.error,
+			failed_at = excluded.failed_at
+	`, filePath, fileType, fileSize, lastModified.Unix(), reason.Error(), time.Now())
+	return err
+}
+
+// ClearAnalysisFailure forgets a failure, after the file was analyzed or removed from the index
+func (is *DefaultIndexService) ClearAnalysisFailure(filePath string) error {
+	_, err := is.db.Exec("DELETE FROM analysis_failures WHERE file_path = ?", filePath)
+	return err
+}
+
+// moveAnalysisFailure keeps a failure with its file when the file is moved
+func (is *DefaultIndexService) moveAnalysisFailure(oldPath, newPath string) {
+	if _, err := is.db.Exec("UPDATE OR REPLACE analysis_failures SET file_path = ? WHERE file_path = ?", newPath, oldPath); err != nil {
+		is.logger.Error("Failed to move analysis failure of %s: %v", oldPath, err)
+	}
+}
+
+// purgeAnalysisFailures forgets the failures of files whose path matches
+func (is *DefaultIndexService) purgeAnalysisFailures(match func(path string) bool) error {
+	rows, err := is.db.Query("SELECT file_path FROM analysis_failures")
+	if err != nil {
+		return err
+	}
+	var purged []string
+	for rows.Next() {
+		var filePath string
+		if err := rows.Scan(&filePath); err != nil {
+			rows.Close()
+			return err
+		}
+		if match(filePath) {
+			purged = append(purged, filePath)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, filePath := range purged {
+		if err := is.ClearAnalysisFailure(filePath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
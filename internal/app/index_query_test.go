@@ -0,0 +1,138 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestQueryIndexedFiles(t *testing.T) {
+	logger := NewLogger(false)
+	indexService := NewIndexService(logger)
+	if err := indexService.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer indexService.Close()
+
+	dir := filepath.Join(t.TempDir(), "docs")
+	entries := []struct {
+		name, description, fileType string
+	}{
+		{"notes.txt", "Meeting notes", "text"},
+		{"photo.jpg", "A beach at sunset", "image"},
+		{"scan.pdf", "", "pdf"},
+		{"report.pdf", "Quarterly report", "pdf"},
+	}
+	for _, e := range entries {
+		if err := indexService.IndexFile(filepath.Join(dir, e.name), e.description, e.fileType, 10, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A sibling folder sharing the prefix stays out of queries
+	if err := indexService.IndexFile(dir+"2/other.txt", "Elsewhere", "text", 10, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	// One failure for a file that was never indexed, one for a re-analysis of an indexed file
+	if err := indexService.RecordAnalysisFailure(filepath.Join(dir, "video.mp4"), "video", 10, time.Now(), errors.New("too long")); err != nil {
+		t.Fatal(err)
+	}
+	if err := indexService.RecordAnalysisFailure(filepath.Join(dir, "photo.jpg"), "image", 10, time.Now(), errors.New("model refused")); err != nil {
+		t.Fatal(err)
+	}
+
+	hourAgo, inAnHour := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	tests := []struct {
+		name  string
+		query IndexQuery
+		want  []string
+	}{
+		{"everything", IndexQuery{}, []string{"notes.txt", "photo.jpg", "report.pdf", "scan.pdf", "video.mp4"}},
+		{"one type", IndexQuery{FileTypes: []string{"pdf"}}, []string{"report.pdf", "scan.pdf"}},
+		{"several types", IndexQuery{FileTypes: []string{"text", "video"}}, []string{"notes.txt", "video.mp4"}},
+		{"described", IndexQuery{Status: IndexStatusDescribed}, []string{"notes.txt", "photo.jpg", "report.pdf"}},
+		{"failed", IndexQuery{Status: IndexStatusFailed}, []string{"photo.jpg", "video.mp4"}},
+		{"failed images", IndexQuery{Status: IndexStatusFailed, FileTypes: []string{"image"}}, []string{"photo.jpg"}},
+		{"indexed in range", IndexQuery{IndexedAfter: hourAgo, IndexedBefore: inAnHour}, []string{"notes.txt", "photo.jpg", "report.pdf", "scan.pdf", "video.mp4"}},
+		{"indexed later", IndexQuery{IndexedAfter: inAnHour}, nil},
+		{"indexed earlier", IndexQuery{IndexedBefore: hourAgo}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.query.DirPath = dir
+			files, err := indexService.QueryIndexedFiles(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, file := range files {
+				got = append(got, filepath.Base(file.FilePath))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	counts, err := indexService.CountIndexedFileTypes(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 4 || counts["pdf"] != 2 || counts["video"] != 1 || counts["text"] != 1 {
+		t.Errorf("unexpected counts %v", counts)
+	}
+
+	// Failed files keep their older description and carry the reason
+	files, err := indexService.QueryIndexedFiles(IndexQuery{DirPath: dir, FileTypes: []string{"image"}})
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected the photo, got %v (%v)", files, err)
+	}
+	if files[0].Description != "A beach at sunset" || files[0].AnalysisError != "model refused" {
+		t.Errorf("unexpected photo entry: %+v", files[0])
+	}
+
+	// Removing an entry forgets its failure
+	if err := indexService.RemoveFile(filepath.Join(dir, "photo.jpg")); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := indexService.QueryIndexedFiles(IndexQuery{DirPath: dir, Status: IndexStatusFailed}); len(files) != 1 {
+		t.Errorf("expected only the video to have failed, got %d files", len(files))
+	}
+}
+
+func TestIndexDirectory_RecordsAnalysisFailures(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logger := NewLogger(false)
+	indexService := NewIndexService(logger)
+	if err := indexService.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer indexService.Close()
+
+	analyzer := &scriptedAnalyzer{errs: map[string]error{"b.txt": errors.New("model refused")}}
+	if err := NewIndexDirectoryOrchestrator(indexService, analyzer, logger).IndexDirectory(context.Background(), dir, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	failed, err := indexService.QueryIndexedFiles(IndexQuery{DirPath: dir, Status: IndexStatusFailed})
+	if err != nil || len(failed) != 1 || filepath.Base(failed[0].FilePath) != "b.txt" {
+		t.Fatalf("expected b.txt to be recorded as failed, got %v (%v)", failed, err)
+	}
+
+	// A successful re-analysis clears the failure
+	b := filepath.Join(dir, "b.txt")
+	if _, err := NewIndexDirectoryOrchestrator(indexService, &scriptedAnalyzer{}, logger).ReanalyzeFiles(context.Background(), []string{b}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if failed, _ := indexService.QueryIndexedFiles(IndexQuery{DirPath: dir, Status: IndexStatusFailed}); len(failed) != 0 {
+		t.Errorf("expected no failures after re-analysis, got %v", failed)
+	}
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReanalyzeFiles_ReplacesUnchangedDescriptions(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	logger := NewLogger(false)
+	indexService := NewIndexService(logger)
+	if err := indexService.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer indexService.Close()
+	for _, path := range paths {
+		info, _ := os.Stat(path)
+		if err := indexService.IndexFile(path, "old description", "text", info.Size(), info.ModTime()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Nothing changed on disk, so a normal run would skip every file
+	analyzer := &scriptedAnalyzer{errs: map[string]error{"b.txt": errors.New("model refused")}}
+	var progress []int
+	failed, err := NewIndexDirectoryOrchestrator(indexService, analyzer, logger).ReanalyzeFiles(context.Background(), paths, func(current, total int, fileName string) {
+		progress = append(progress, current)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(analyzer.analyzed) != 3 || len(progress) != 3 {
+		t.Errorf("expected all 3 files analyzed with progress, got %v and %v", analyzer.analyzed, progress)
+	}
+	if len(failed) != 1 || failed[paths[1]] == nil {
+		t.Errorf("expected b.txt to fail, got %v", failed)
+	}
+
+	// Successful files were rewritten; the failed one keeps its description
+	for _, path := range paths {
+		file, err := indexService.GetIndexedFile(path)
+		if err != nil || file == nil {
+			t.Fatalf("expected %s in the index, got %v", path, err)
+		}
+		want := "description of " + filepath.Base(path)
+		if path == paths[1] {
+			want = "old description"
+		}
+		if file.Description != want {
+			t.Errorf("%s: expected %q, got %q", filepath.Base(path), want, file.Description)
+		}
+	}
+
+	// The budget stops the run
+	budget := &scriptedAnalyzer{errs: map[string]error{"a.txt": ErrBudgetExceeded}}
+	if _, err := NewIndexDirectoryOrchestrator(indexService, budget, logger).ReanalyzeFiles(context.Background(), paths, nil); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected the budget error, got %v", err)
+	}
+	if len(budget.analyzed) != 1 {
+		t.Errorf("expected the run to stop at the first file, got %v", budget.analyzed)
+	}
+}
@@ -0,0 +1,428 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+var (
+	ErrRulesUnsupported = errors.New("the AI service can't write classification rules")
+	ErrIndexRequired    = errors.New("planning with index rules needs deep analysis and an index")
+	ErrNoIndexedFiles   = errors.New("no indexed files in this folder")
+)
+
+// Limits of the statistics sent to the model in index rules mode
+const (
+	statsTopExtensions = 40
+	statsTopFolders    = 30
+	statsTopWords      = 80
+	statsExamples      = 3 // Descriptions shown per file type
+)
+
+const classificationRulesSystemPrompt = `You organize very large folders without seeing every file. You get statistics about the files in a folder, taken from an index of AI-written file descriptions, plus the user's instructions.
+Write classification rules that a program applies to every file, in order; the first matching rule decides where a file goes and files no rule matches stay where they are.
+Output one JSON object per line, and nothing else (no markdown, no commentary):
+{"to": "Photos/{year}", "type": "image"}
+{"to": "Finance/Invoices", "keywords": ["invoice", "receipt"]}
+{"to": "Installers", "extensions": [".dmg", ".exe", ".msi"]}
+{"to": "Screenshots", "name": "Screenshot*"}
+Fields, all optional except "to"; a rule matches when all of its fields match:
+- "type": one of the file types in the statistics
+- "extensions": file extensions, with the dot
+- "keywords": matches when any keyword appears in the file's name or description
+- "name": a glob on the file name
+- "to": destination folder relative to the base directory; may use {year} and {month} of the modification date, {type} and {ext}
+Put specific rules before general ones.`
+
+// ClassificationRule maps the indexed files it matches to a destination folder
+type ClassificationRule struct {
+	To         string   `json:"to"`
+	FileType   string   `json:"type,omitempty"`
+	Extensions []string `json:"extensions,omitempty"`
+	Keywords   []string `json:"keywords,omitempty"`
+	Name       string   `json:"name,omitempty"`
+}
+
+// String describes the rule the way it is shown next to the operations it planned
+func (r ClassificationRule) String() string {
+	var conditions []string
+	if r.FileType != "" {
+		conditions = append(conditions, "type "+r.FileType)
+	}
+	if len(r.Extensions) > 0 {
+		conditions = append(conditions, strings.Join(r.Extensions, "/"))
+	}
+	if len(r.Keywords) > 0 {
+		conditions = append(conditions, "mentions "+strings.Join(r.Keywords, "/"))
+	}
+	if r.Name != "" {
+		conditions = append(conditions, "named "+r.Name)
+	}
+	if len(conditions) == 0 {
+		conditions = append(conditions, "everything else")
+	}
+	return strings.Join(conditions, ", ") + " → " + r.To
+}
+
+// matches reports whether the indexed file (rel is relative, with forward slashes) fits the rule
+func (r ClassificationRule) matches(rel string, file IndexedFile) bool {
+	name := path.Base(rel)
+	if r.FileType != "" && !strings.EqualFold(r.FileType, file.FileType) {
+		return false
+	}
+	if len(r.Extensions) > 0 {
+		ext := strings.ToLower(path.Ext(name))
+		found := false
+		for _, e := range r.Extensions {
+			if strings.ToLower("."+strings.TrimPrefix(e, ".")) == ext {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(r.Keywords) > 0 {
+		text := strings.ToLower(name + " " + file.Description)
+		found := false
+		for _, keyword := range r.Keywords {
+			if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" && strings.Contains(text, keyword) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if r.Name != "" {
+		if ok, _ := doublestar.Match(strings.ToLower(r.Name), strings.ToLower(name)); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// destination fills the placeholders of To for file, or returns "" if To leaves the base directory
+func (r ClassificationRule) destination(rel string, file IndexedFile) string {
+	ext := strings.TrimPrefix(strings.ToLower(path.Ext(rel)), ".")
+	if ext == "" {
+		ext = "no extension"
+	}
+	to := strings.NewReplacer(
+		"{year}", strconv.Itoa(file.LastModified.Year()),
+		"{month}", fmt.Sprintf("%02d", file.LastModified.Month()),
+		"{type}", file.FileType,
+		"{ext}", ext,
+	).Replace(strings.ReplaceAll(r.To, "\\", "/"))
+	to = path.Clean(strings.Trim(to, "/"))
+	if to == ".." || strings.HasPrefix(to, "../") {
+		return ""
+	}
+	return to
+}
+
+// parseClassificationRules reads one rule per line, skipping anything that isn't a rule
+func parseClassificationRules(content string) []ClassificationRule {
+	var rules []ClassificationRule
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), ","))
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var rule ClassificationRule
+		if err := json.Unmarshal([]byte(line), &rule); err != nil || strings.TrimSpace(rule.To) == "" {
+			continue
+		}
+		if rule.Name != "" && !doublestar.ValidatePattern(rule.Name) {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// indexStatistics summarizes indexed files (relative paths as keys) for the model: counts by
+// type, extension, year and top-level folder, the most common description words, and a few
+// example descriptions per type
+func indexStatistics(files map[string]IndexedFile) string {
+	byType := make(map[string]int)
+	byExt := make(map[string]int)
+	byYear := make(map[string]int)
+	byFolder := make(map[string]int)
+	words := make(map[string]int)
+	examples := make(map[string][]string)
+	var totalSize int64
+
+	rels := make([]string, 0, len(files))
+	for rel := range files {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+
+	for _, rel := range rels {
+		file := files[rel]
+		totalSize += file.FileSize
+		byType[file.FileType]++
+		ext := strings.ToLower(path.Ext(rel))
+		if ext == "" {
+			ext = "(none)"
+		}
+		byExt[ext]++
+		byYear[strconv.Itoa(file.LastModified.Year())]++
+		folder := "(top level)"
+		if i := strings.Index(rel, "/"); i >= 0 {
+			folder = rel[:i]
+		}
+		byFolder[folder]++
+
+		seen := make(map[string]bool)
+		for _, word := range strings.FieldsFunc(strings.ToLower(file.Description), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if len(word) >= 4 && !commonWords[word] && !seen[word] {
+				seen[word] = true
+				words[word]++
+			}
+		}
+		if file.Description != "" && len(examples[file.FileType]) < statsExamples {
+			examples[file.FileType] = append(examples[file.FileType], fmt.Sprintf("%s: %s", rel, truncateRunes(file.Description, 160)))
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Indexed files: %d (%d bytes)\n", len(files), totalSize)
+	writeCounts(&sb, "By type", byType, 0)
+	writeCounts(&sb, "By extension", byExt, statsTopExtensions)
+	writeCounts(&sb, "By year modified", byYear, 0)
+	writeCounts(&sb, "By top-level folder", byFolder, statsTopFolders)
+	writeCounts(&sb, "Common words in descriptions (files mentioning them)", words, statsTopWords)
+	sb.WriteString("Example descriptions:\n")
+	for _, fileType := range AnalysisFileTypes {
+		for _, example := range examples[fileType] {
+			fmt.Fprintf(&sb, "- [%s] %s\n", fileType, example)
+		}
+	}
+	return sb.String()
+}
+
+// writeCounts writes the largest counts first as "name (count)", at most limit of them (0 writes all)
+func writeCounts(sb *strings.Builder, title string, counts map[string]int, limit int) {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s (%d)", key, counts[key])
+	}
+	fmt.Fprintf(sb, "%s: %s\n", title, strings.Join(parts, ", "))
+}
+
+func truncateRunes(s string, limit int) string {
+	runes := []rune(strings.Join(strings.Fields(s), " "))
+	if len(runes) <= limit {
+		return string(runes)
+	}
+	return string(runes[:limit]) + "…"
+}
+
+// commonWords are left out of the description word counts
+var commonWords = map[string]bool{
+	"this": true, "that": true, "with": true, "from": true, "file": true, "contains": true, "containing": true,
+	"which": true, "there": true, "their": true, "about": true, "into": true, "shows": true, "showing": true,
+	"image": true, "document": true, "appears": true, "includes": true, "including": true, "other": true,
+	"some": true, "have": true, "been": true, "were": true, "also": true, "text": true, "used": true,
+}
+
+// WriteClassificationRules asks the model for rules that sort files described by stats
+func (s *OpenAIService) WriteClassificationRules(ctx context.Context, stats, userPrompt, basePath string) ([]ClassificationRule, error) {
+	reqBody := OpenAIRequest{
+		Model: s.config.Model,
+		Messages: []Message{
+			{Role: "system", Content: classificationRulesSystemPrompt},
+			{Role: "user", Content: fmt.Sprintf("Base directory: %s\n\nStatistics:\n%s\nUser instructions: %s", basePath, stats, userPrompt)},
+		},
+		MaxTokens:   s.config.planMaxTokens(),
+		Temperature: s.config.Temperature,
+		Stream:      false,
+	}
+
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", s.config.APIKey),
+		"HTTP-Referer":  "https://github.com/sandwichdoge/vibesandfolders",
+		"X-Title":       "VibesAndFolders",
+	}
+
+	s.logger.Info("Asking %s for classification rules", s.config.Model)
+	body, err := s.httpClient.Post(ctx, s.config.Endpoint, headers, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("no response from LLM")
+	}
+
+	return parseClassificationRules(response.Choices[0].Message.Content), nil
+}
+
+// analyzeWithIndexRules plans from index statistics instead of the structure: the model writes
+// classification rules and every indexed file is mapped to a destination locally, so folders
+// far larger than any context window can be organized
+func (o *Orchestrator) analyzeWithIndexRules(ctx context.Context, req AnalysisRequest, onOperation OperationCallback) AnalysisResult {
+	result := AnalysisResult{PlannedAt: o.executionMark()}
+	defer func() { o.metrics.RecordAnalysis(result) }()
+
+	writer, ok := o.aiService.(RuleWriter)
+	if !ok {
+		result.Error = ErrRulesUnsupported
+		return result
+	}
+	if !req.EnableDeepAnalysis || o.indexService == nil || IsObjectStoragePath(req.DirectoryPath) {
+		result.Error = ErrIndexRequired
+		return result
+	}
+	if err := o.prepareIndex(ctx, &req); err != nil {
+		result.Error = err
+		return result
+	}
+
+	indexed, err := o.indexService.GetIndexedFilesInDirectory(req.DirectoryPath)
+	if err != nil {
+		result.Error = fmt.Errorf("failed to read index: %w", err)
+		return result
+	}
+	only := make(map[string]bool, len(req.OnlyFiles))
+	for _, p := range req.OnlyFiles {
+		only[filepath.Clean(p)] = true
+	}
+	if req.NewFilesOnly {
+		result.NewSince = o.lastExecution(req.DirectoryPath)
+	}
+	files := make(map[string]IndexedFile, len(indexed))
+	for _, file := range indexed {
+		rel := relativeSlashPath(req.DirectoryPath, file.FilePath)
+		if rel == "." || (req.MaxDepth > 0 && strings.Count(rel, "/") >= req.MaxDepth) {
+			continue
+		}
+		if len(only) > 0 && !only[filepath.Clean(file.FilePath)] {
+			continue
+		}
+		if !file.LastModified.After(result.NewSince) {
+			continue
+		}
+		files[rel] = file
+	}
+	if len(files) == 0 && !result.NewSince.IsZero() {
+		result.Error = ErrNoNewFiles
+		return result
+	}
+	if len(files) == 0 {
+		result.Error = ErrNoIndexedFiles
+		return result
+	}
+
+	result.Structure = indexStatistics(files)
+	result.Fingerprint = o.fingerprint(req.DirectoryPath, req.MaxDepth)
+
+	constraints := ParseConstraints(req.Constraints)
+	userPrompt := req.UserPrompt + o.corrections.PromptContext(req.DirectoryPath) + constraints.PromptText()
+	rules, err := writer.WriteClassificationRules(ctx, result.Structure, userPrompt, req.DirectoryPath)
+	if err != nil {
+		result.Error = fmt.Errorf("failed to get classification rules: %w", err)
+		return result
+	}
+	result.Rules = rules
+	o.logger.Info("Applying %d classification rules to %d indexed files", len(rules), len(files))
+
+	rels := make([]string, 0, len(files))
+	for rel := range files {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+
+	planValidator := NewPlanValidator(constraints)
+	merger := newFolderMerger(NewStructureGrounding(""))
+	taken := make(map[string]bool)
+	var operations []FileOperation
+	for _, rel := range rels {
+		file := files[rel]
+		for _, rule := range rules {
+			if !rule.matches(rel, file) {
+				continue
+			}
+			folder := rule.destination(rel, file)
+			if folder == "" || strings.EqualFold(folder, path.Dir(rel)) {
+				break
+			}
+			to, err := JoinStoragePath(req.DirectoryPath, folder+"/"+path.Base(rel))
+			if err != nil {
+				o.logger.Debug("Leaving %s in place: %v", rel, err)
+				break
+			}
+			op := merger.apply(req.DirectoryPath, FileOperation{
+				From:       file.FilePath,
+				To:         to,
+				Confidence: 1,
+				Reason:     ruleReasonPrefix + rule.String(),
+			})
+			if taken[op.To] {
+				o.logger.Debug("Leaving %s in place, another file already goes to %s", rel, op.To)
+				break
+			}
+			// The index can lag behind the disk
+			if _, err := os.Lstat(filepath.Clean(file.FilePath)); err != nil {
+				break
+			}
+			if err := planValidator.Check(req.DirectoryPath, op); err != nil {
+				op.Flag = err.Error()
+				result.Rejected = append(result.Rejected, op)
+				break
+			}
+			taken[op.To] = true
+			operations = append(operations, op)
+			if onOperation != nil {
+				onOperation(op)
+			}
+			break
+		}
+	}
+
+	result.Operations = operations
+	result.FromRules = len(operations)
+	result.Merged = merger.result()
+	o.logger.Info("Analysis complete: %d operations from %d rules", len(operations), len(rules))
+	return result
+}
@@ -0,0 +1,143 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseClassificationRules(t *testing.T) {
+	content := "```\n" +
+		`{"to": "Photos/{year}", "type": "image"},` + "\n" +
+		`{"type": "pdf"}` + "\n" +
+		`{"to": "Bad", "name": "["}` + "\n" +
+		`{"to": "Finance", "keywords": ["invoice"]}` + "\n```"
+	rules := parseClassificationRules(content)
+	if len(rules) != 2 || rules[0].FileType != "image" || rules[1].Keywords[0] != "invoice" {
+		t.Errorf("parseClassificationRules() = %+v", rules)
+	}
+}
+
+func TestClassificationRuleMatches(t *testing.T) {
+	modified := time.Date(2023, time.March, 5, 0, 0, 0, 0, time.UTC)
+	invoice := IndexedFile{FileType: "pdf", Description: "An INVOICE from ACME Corp", LastModified: modified}
+
+	tests := []struct {
+		name   string
+		rule   ClassificationRule
+		rel    string
+		file   IndexedFile
+		wantOK bool
+		wantTo string
+	}{
+		{"keyword in description", ClassificationRule{To: "Finance/{year}-{month}", Keywords: []string{"invoice"}}, "scan1.pdf", invoice, true, "Finance/2023-03"},
+		{"keyword in name", ClassificationRule{To: "Finance", Keywords: []string{"receipt"}}, "receipt-01.pdf", invoice, true, "Finance"},
+		{"type and extension", ClassificationRule{To: "{type}/{ext}", FileType: "pdf", Extensions: []string{"PDF"}}, "a/scan1.pdf", invoice, true, "pdf/pdf"},
+		{"wrong type", ClassificationRule{To: "Photos", FileType: "image"}, "scan1.pdf", invoice, false, ""},
+		{"name glob ignores case", ClassificationRule{To: "Scans", Name: "scan*"}, "SCAN1.pdf", invoice, true, "Scans"},
+		{"destination outside base", ClassificationRule{To: "../elsewhere"}, "scan1.pdf", invoice, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.matches(tt.rel, tt.file); got != tt.wantOK {
+				t.Fatalf("matches() = %v, want %v", got, tt.wantOK)
+			}
+			if tt.wantOK {
+				if got := tt.rule.destination(tt.rel, tt.file); got != tt.wantTo {
+					t.Errorf("destination() = %q, want %q", got, tt.wantTo)
+				}
+			}
+		})
+	}
+}
+
+// ruleWritingAIService answers with fixed classification rules and remembers what it was shown
+type ruleWritingAIService struct {
+	stubAIService
+	rules     []ClassificationRule
+	lastStats string
+}
+
+func (s *ruleWritingAIService) WriteClassificationRules(_ context.Context, stats, userPrompt, basePath string) ([]ClassificationRule, error) {
+	s.lastStats = stats
+	return s.rules, nil
+}
+
+func TestAnalyzeWithIndexRules(t *testing.T) {
+	dir := t.TempDir()
+	logger := NewLogger(false)
+	indexService := NewIndexService(logger)
+	if err := indexService.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer indexService.Close()
+
+	files := []struct{ name, description, fileType string }{
+		{"IMG_1.jpg", "A beach at sunset", "image"},
+		{"IMG_2.jpg", "Family dinner", "image"},
+		{"scan.pdf", "Invoice from the electricity company", "pdf"},
+		{"sub/IMG_1.jpg", "Another beach", "image"}, // Same name as the first photo
+		{"notes.txt", "Shopping list", "text"},
+	}
+	for _, f := range files {
+		p := filepath.Join(dir, filepath.FromSlash(f.name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := indexService.IndexFile(p, f.description, f.fileType, 1, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Indexed but deleted since
+	if err := indexService.IndexFile(filepath.Join(dir, "gone.jpg"), "Old photo", "image", 1, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	ai := &ruleWritingAIService{rules: []ClassificationRule{
+		{To: "Bills", Keywords: []string{"invoice"}},
+		{To: "Photos/{year}", FileType: "image"},
+	}}
+	validator := NewValidator()
+	config := &Config{}
+	config.SetConstraints(dir, "never touch IMG_2.jpg")
+	orchestrator := NewOrchestrator(ai, NewFileService(validator, logger), validator, logger, nil, indexService, NewHookRunner(config, logger))
+
+	req := AnalysisRequest{DirectoryPath: dir, UserPrompt: "Sort", EnableDeepAnalysis: true, IndexRules: true, Constraints: config.ConstraintsFor(dir)}
+	var streamed int
+	result := orchestrator.AnalyzeDirectory(req, func(FileOperation) { streamed++ })
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+
+	var got []string
+	for _, op := range result.Operations {
+		got = append(got, relativeSlashPath(dir, op.From)+" -> "+relativeSlashPath(dir, op.To))
+	}
+	want := "IMG_1.jpg -> Photos/2022/IMG_1.jpg, scan.pdf -> Bills/scan.pdf"
+	if strings.Join(got, ", ") != want || streamed != 2 || result.FromRules != 2 {
+		t.Errorf("plan = %v (%d streamed), want %s", got, streamed, want)
+	}
+	if len(result.Rejected) != 1 {
+		t.Errorf("Rejected = %+v, want the protected photo", result.Rejected)
+	}
+	for _, stat := range []string{"Indexed files: 6", "image (4)", "beach (2)"} {
+		if !strings.Contains(ai.lastStats, stat) {
+			t.Errorf("statistics lack %q:\n%s", stat, ai.lastStats)
+		}
+	}
+	if strings.Contains(ai.lastStats, "notes.txt (") {
+		t.Errorf("statistics list files like a structure:\n%s", ai.lastStats)
+	}
+
+	req.EnableDeepAnalysis = false
+	if result := orchestrator.AnalyzeDirectory(req, nil); !errors.Is(result.Error, ErrIndexRequired) {
+		t.Errorf("without deep analysis: %v, want ErrIndexRequired", result.Error)
+	}
+}
@@ -1,11 +1,14 @@
 package app
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -17,12 +20,13 @@ type IndexedFile struct {
 	ID            int64
 	FilePath      string
 	Description   string
-	FileType      string // "text", "image", "video", "audio", "other"
+	FileType      string // One of AnalysisFileTypes
 	FileSize      int64
 	LastModified  time.Time
 	IndexedAt     time.Time
 	UpdatedAt     time.Time
 	SymlinkTarget string // For symlinks, stores the target path
+	AnalysisError string // Why the last analysis failed; only set by IndexQuerier.QueryIndexedFiles
 }
 
 // IndexService handles file indexing and tracking
@@ -41,6 +45,8 @@ type IndexService interface {
 	// Add or update file index
 	IndexFile(filePath, description, fileType string, fileSize int64, lastModified time.Time) error
 	IndexFileWithSymlink(filePath, description, fileType string, fileSize int64, lastModified time.Time, symlinkTarget string) error
+	// Add or update a description written elsewhere, keeping the time it was written
+	ImportFile(filePath, description, fileType string, fileSize int64, lastModified, updatedAt time.Time) error
 	UpdateFileIndex(filePath, description string, lastModified time.Time) error
 
 	// Update file path in index (for moves/renames) without re-analyzing
@@ -92,22 +98,69 @@ type DefaultIndexService struct {
 	tx            *sql.Tx
 	logger        *Logger
 	ignoreMatcher *IgnorePatternMatcher
+
+	// analysisFilter reports whether a file may be deep analyzed; others are never reported as new or modified
+	analysisFilter func(filePath string) bool
+
+	// volumeResolver identifies the removable volume a path is on, so entries follow a drive to a new mount point
+	volumeResolver func(path string) (volumeInfo, bool)
+
+	audit *AuditLog // Optional; records every change to the index
+
+	cipher            *descriptionCipher // Set when descriptions are encrypted at rest (see EnableEncryption)
+	requireEncryption bool               // Refuse to store plaintext descriptions when the key couldn't be unlocked
+
+	walk          walkOptions // See SetWalkConcurrency
+	ignoreSpec    string      // The patterns given to SetIgnorePatterns
+	skipUnchanged bool        // See SetSkipUnchangedFolders
+
+	watchMu sync.Mutex
+	watched map[string]bool // Roots an IndexWatcher reports changes for (see SetWatched)
 }
 
 func NewIndexService(logger *Logger) *DefaultIndexService {
 	return &DefaultIndexService{
-		logger:        logger,
-		ignoreMatcher: nil,
+		logger:         logger,
+		ignoreMatcher:  nil,
+		volumeResolver: volumeFor,
 	}
 }
 
 // SetIgnorePatterns configures the ignore pattern matcher for indexing
 func (is *DefaultIndexService) SetIgnorePatterns(patterns string) {
-	if patterns == "" {
+	is.ignoreSpec = patterns
+	ignoreHidden := is.ignoreMatcher.IgnoresHidden()
+	if patterns == "" && !ignoreHidden {
 		is.ignoreMatcher = nil
 		return
 	}
 	is.ignoreMatcher = NewIgnorePatternMatcher(patterns, is.logger)
+	is.ignoreMatcher.SetIgnoreHidden(ignoreHidden)
+}
+
+// SetIgnoreHidden skips dotfiles and files marked hidden on Windows when scanning for indexing
+func (is *DefaultIndexService) SetIgnoreHidden(ignore bool) {
+	if is.ignoreMatcher == nil {
+		is.ignoreMatcher = NewIgnorePatternMatcher("", is.logger)
+	}
+	is.ignoreMatcher.SetIgnoreHidden(ignore)
+}
+
+// SetWalkConcurrency reads up to workers directories at once when scanning for changes, at most
+// batch listings ahead of the scan; workers <= 1 scans one directory at a time
+func (is *DefaultIndexService) SetWalkConcurrency(workers, batch int) {
+	is.walk = walkOptions{workers: workers, batch: batch}
+}
+
+// SetAuditLog records every index change in audit
+func (is *DefaultIndexService) SetAuditLog(audit *AuditLog) {
+	is.audit = audit
+}
+
+// SetAnalysisFilter limits which files the directory scan reports for (re)indexing.
+// Files that are already indexed keep their entries.
+func (is *DefaultIndexService) SetAnalysisFilter(filter func(filePath string) bool) {
+	is.analysisFilter = filter
 }
 
 func (is *DefaultIndexService) Initialize(dbPath string) error {
@@ -141,17 +194,172 @@ func (is *DefaultIndexService) Initialize(dbPath string) error {
 	CREATE INDEX IF NOT EXISTS idx_file_path ON indexed_files(file_path);
 	CREATE INDEX IF NOT EXISTS idx_file_type ON indexed_files(file_type);
 	CREATE INDEX IF NOT EXISTS idx_updated_at ON indexed_files(updated_at);
+
+	CREATE TABLE IF NOT EXISTS index_meta (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);
 	`
 
 	if _, err := db.Exec(schema); err != nil {
 		return fmt.Errorf("failed to create schema: %w", err)
 	}
+	if _, err := db.Exec(indexCheckpointSchema); err != nil {
+		return fmt.Errorf("failed to create schema: %w", err)
+	}
+	if _, err := db.Exec(analysisFailureSchema); err != nil {
+		return fmt.Errorf("failed to create schema: %w", err)
+	}
+	if _, err := db.Exec(folderSignatureSchema); err != nil {
+		return fmt.Errorf("failed to create schema: %w", err)
+	}
+	if _, err := db.Exec(executionHistorySchema); err != nil {
+		return fmt.Errorf("failed to create schema: %w", err)
+	}
+	if _, err := db.Exec(directorySnapshotSchema); err != nil {
+		return fmt.Errorf("failed to create schema: %w", err)
+	}
+
+	if err := is.migrateSchema(); err != nil {
+		return fmt.Errorf("failed to migrate schema: %w", err)
+	}
 
 	is.logger.Info("Index database initialized at %s", dbPath)
 	return nil
 }
 
+// migrateSchema adds columns introduced after the initial schema to existing databases
+func (is *DefaultIndexService) migrateSchema() error {
+	rows, err := is.db.Query("PRAGMA table_info(indexed_files)")
+	if err != nil {
+		return err
+	}
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		columns[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// volume_id/volume_path locate files on removable drives independently of where they are mounted
+	for _, column := range []string{"volume_id", "volume_path"} {
+		if !columns[column] {
+			if _, err := is.db.Exec("ALTER TABLE indexed_files ADD COLUMN " + column + " TEXT"); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = is.db.Exec("CREATE INDEX IF NOT EXISTS idx_volume ON indexed_files(volume_id)")
+	return err
+}
+
+// volumeColumns returns the volume_id and volume_path values for filePath (NULL when not on a removable volume)
+func (is *DefaultIndexService) volumeColumns(filePath string) (interface{}, interface{}) {
+	vol, ok := is.volumeResolver(filePath)
+	if !ok {
+		return nil, nil
+	}
+	volumePath, ok := vol.volumeRelativePath(filePath)
+	if !ok {
+		return nil, nil
+	}
+	return vol.ID, volumePath
+}
+
+// likePrefix returns a LIKE pattern, to be used with ESCAPE '\', matching everything starting with prefix
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix) + "%"
+}
+
+// rebaseVolume moves entries of the volume holding dirPath to its current mount point, e.g. after
+// an external drive comes back as F: instead of E:, and records the volume of older entries under dirPath.
+// Without this, every entry of a remounted drive would look orphaned and be re-analyzed.
+func (is *DefaultIndexService) rebaseVolume(dirPath string) {
+	vol, ok := is.volumeResolver(dirPath)
+	if !ok {
+		return
+	}
+
+	mountPrefix := filepath.Clean(vol.MountPoint)
+	if !strings.HasSuffix(mountPrefix, string(filepath.Separator)) {
+		mountPrefix += string(filepath.Separator)
+	}
+
+	rows, err := is.db.Query(`
+		SELECT file_path, volume_path FROM indexed_files
+		WHERE volume_id = ? AND volume_path IS NOT NULL AND file_path NOT LIKE ? ESCAPE '\'
+	`, vol.ID, likePrefix(mountPrefix))
+	if err != nil {
+		is.logger.Error("Failed to look up entries for volume %s: %v", vol.ID, err)
+		return
+	}
+	moved := make(map[string]string)
+	for rows.Next() {
+		var filePath, volumePath string
+		if err := rows.Scan(&filePath, &volumePath); err == nil {
+			moved[filePath] = vol.absolutePath(volumePath)
+		}
+	}
+	rows.Close()
+
+	rebased := 0
+	for oldPath, newPath := range moved {
+		if _, err := is.db.Exec("UPDATE indexed_files SET file_path = ? WHERE file_path = ?", newPath, oldPath); err != nil {
+			// The file was already indexed again at the new mount point; the old entry is stale
+			if _, err := is.db.Exec("DELETE FROM indexed_files WHERE file_path = ?", oldPath); err != nil {
+				is.logger.Error("Failed to rebase index entry %s: %v", oldPath, err)
+			}
+			continue
+		}
+		rebased++
+	}
+	if rebased > 0 {
+		is.audit.Record(AuditIndexMove, vol.MountPoint, fmt.Sprintf("%d entries of volume %s followed it to a new mount point", rebased, vol.ID))
+		is.logger.Info("Volume %s is now mounted at %s, moved %d index entries", vol.ID, vol.MountPoint, rebased)
+	}
+
+	// Entries indexed before volumes were tracked
+	legacy, err := is.db.Query(`
+		SELECT file_path FROM indexed_files WHERE volume_id IS NULL AND file_path LIKE ? ESCAPE '\'
+	`, likePrefix(mountPrefix))
+	if err != nil {
+		is.logger.Error("Failed to look up untracked entries on volume %s: %v", vol.ID, err)
+		return
+	}
+	var untracked []string
+	for legacy.Next() {
+		var filePath string
+		if err := legacy.Scan(&filePath); err == nil {
+			untracked = append(untracked, filePath)
+		}
+	}
+	legacy.Close()
+
+	for _, filePath := range untracked {
+		if volumePath, ok := vol.volumeRelativePath(filePath); ok {
+			if _, err := is.db.Exec("UPDATE indexed_files SET volume_id = ?, volume_path = ? WHERE file_path = ?", vol.ID, volumePath, filePath); err != nil {
+				is.logger.Error("Failed to record volume for %s: %v", filePath, err)
+			}
+		}
+	}
+}
+
 func (is *DefaultIndexService) Close() error {
+	// A transaction left open by a job that didn't stop in time is abandoned, not half committed
+	if is.tx != nil {
+		if err := is.RollbackTransaction(); err != nil {
+			is.logger.Error("Failed to roll back index transaction on close: %v", err)
+		}
+	}
 	if is.db != nil {
 		return is.db.Close()
 	}
@@ -185,13 +393,26 @@ func (is *DefaultIndexService) NeedsReindexing(filePath string) (bool, error) {
 
 	// Get stored modification time
 	var storedModTime int64
-	err = is.db.QueryRow("SELECT last_modified FROM indexed_files WHERE file_path = ?", filePath).Scan(&storedModTime)
+	var updatedAt time.Time
+	err = is.db.QueryRow("SELECT last_modified, updated_at FROM indexed_files WHERE file_path = ?", filePath).Scan(&storedModTime, &updatedAt)
 	if err != nil {
 		return false, err
 	}
 
 	// If modification times differ, needs reindexing
-	return currentModTime != storedModTime, nil
+	if currentModTime != storedModTime {
+		return true, nil
+	}
+
+	// A subtitle sidecar added or edited after the video was described changes its description
+	if DetermineFileType(filePath) == "video" {
+		if sidecar := findSubtitleSidecar(filePath); sidecar != "" {
+			if sidecarInfo, err := os.Stat(sidecar); err == nil && sidecarInfo.ModTime().After(updatedAt) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
 }
 
 func (is *DefaultIndexService) GetIndexedFile(filePath string) (*IndexedFile, error) {
@@ -211,6 +432,7 @@ func (is *DefaultIndexService) GetIndexedFile(filePath string) (*IndexedFile, er
 	if err != nil {
 		return nil, err
 	}
+	file.Description = is.openDescription(file.Description)
 	file.LastModified = time.Unix(lastModUnix, 0)
 	if symlinkTarget.Valid {
 		file.SymlinkTarget = symlinkTarget.String
@@ -223,6 +445,14 @@ func (is *DefaultIndexService) IndexFile(filePath, description, fileType string,
 }
 
 func (is *DefaultIndexService) IndexFileWithSymlink(filePath, description, fileType string, fileSize int64, lastModified time.Time, symlinkTarget string) error {
+	return is.indexFile(filePath, description, fileType, fileSize, lastModified, symlinkTarget, time.Now())
+}
+
+func (is *DefaultIndexService) ImportFile(filePath, description, fileType string, fileSize int64, lastModified, updatedAt time.Time) error {
+	return is.indexFile(filePath, description, fileType, fileSize, lastModified, "", updatedAt)
+}
+
+func (is *DefaultIndexService) indexFile(filePath, description, fileType string, fileSize int64, lastModified time.Time, symlinkTarget string, updatedAt time.Time) error {
 	var symlinkTargetVal interface{}
 	if symlinkTarget == "" {
 		symlinkTargetVal = nil
@@ -230,26 +460,45 @@ func (is *DefaultIndexService) IndexFileWithSymlink(filePath, description, fileT
 		symlinkTargetVal = symlinkTarget
 	}
 
-	_, err := is.db.Exec(`
-		INSERT INTO indexed_files (file_path, description, file_type, file_size, last_modified, indexed_at, updated_at, symlink_target)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	volumeID, volumePath := is.volumeColumns(filePath)
+
+	stored, err := is.sealDescription(description)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt description: %w", err)
+	}
+
+	_, err = is.db.Exec(`
+		INSERT INTO indexed_files (file_path, description, file_type, file_size, last_modified, indexed_at, updated_at, symlink_target, volume_id, volume_path)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(file_path) DO UPDATE SET
 			description = excluded.description,
 			file_type = excluded.file_type,
 			file_size = excluded.file_size,
 			last_modified = excluded.last_modified,
 			updated_at = excluded.updated_at,
-			symlink_target = excluded.symlink_target
-	`, filePath, description, fileType, fileSize, lastModified.Unix(), time.Now(), time.Now(), symlinkTargetVal)
+			symlink_target = excluded.symlink_target,
+			volume_id = excluded.volume_id,
+			volume_path = excluded.volume_path
+	`, filePath, stored, fileType, fileSize, lastModified.Unix(), time.Now(), updatedAt, symlinkTargetVal, volumeID, volumePath)
+	if err == nil {
+		is.audit.Record(AuditIndexAdd, filePath, fileType)
+	}
 	return err
 }
 
 func (is *DefaultIndexService) UpdateFileIndex(filePath, description string, lastModified time.Time) error {
-	_, err := is.db.Exec(`
+	stored, err := is.sealDescription(description)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt description: %w", err)
+	}
+	_, err = is.db.Exec(`
 		UPDATE indexed_files
 		SET description = ?, last_modified = ?, updated_at = ?
 		WHERE file_path = ?
-	`, description, lastModified.Unix(), time.Now(), filePath)
+	`, stored, lastModified.Unix(), time.Now(), filePath)
+	if err == nil {
+		is.audit.Record(AuditIndexUpdate, filePath, "")
+	}
 	return err
 }
 
@@ -286,16 +535,26 @@ func (is *DefaultIndexService) UpdateFilePathWithSymlink(oldPath, newPath, newSy
 		symlinkTargetVal = newSymlinkTarget
 	}
 
+	volumeID, volumePath := is.volumeColumns(newPath)
+
 	_, err = is.db.Exec(`
 		UPDATE indexed_files
-		SET file_path = ?, file_size = ?, last_modified = ?, updated_at = ?, symlink_target = ?
+		SET file_path = ?, file_size = ?, last_modified = ?, updated_at = ?, symlink_target = ?, volume_id = ?, volume_path = ?
 		WHERE file_path = ?
-	`, newPath, fileInfo.Size(), fileInfo.ModTime().Unix(), time.Now(), symlinkTargetVal, oldPath)
+	`, newPath, fileInfo.Size(), fileInfo.ModTime().Unix(), time.Now(), symlinkTargetVal, volumeID, volumePath, oldPath)
+	if err == nil {
+		is.audit.Record(AuditIndexMove, newPath, "from "+oldPath)
+		is.moveAnalysisFailure(oldPath, newPath)
+	}
 	return err
 }
 
 func (is *DefaultIndexService) RemoveFile(filePath string) error {
 	_, err := is.db.Exec("DELETE FROM indexed_files WHERE file_path = ?", filePath)
+	if err == nil {
+		is.audit.Record(AuditIndexRemove, filePath, "")
+		err = is.ClearAnalysisFailure(filePath)
+	}
 	return err
 }
 
@@ -330,6 +589,7 @@ func (is *DefaultIndexService) GetIndexedFilesInDirectory(dirPath string) ([]Ind
 		if err != nil {
 			return nil, err
 		}
+		file.Description = is.openDescription(file.Description)
 		file.LastModified = time.Unix(lastModUnix, 0)
 		if symlinkTarget.Valid {
 			file.SymlinkTarget = symlinkTarget.String
@@ -347,6 +607,9 @@ func (is *DefaultIndexService) ScanDirectoryChanges(dirPath string, maxDepth int
 		UnchangedFiles: make([]string, 0),
 	}
 
+	// Pick up entries recorded while this drive was mounted elsewhere
+	is.rebaseVolume(dirPath)
+
 	// Get all indexed files in this directory
 	indexedFiles, err := is.GetIndexedFilesInDirectory(dirPath)
 	if err != nil {
@@ -363,7 +626,23 @@ func (is *DefaultIndexService) ScanDirectoryChanges(dirPath string, maxDepth int
 	currentFiles := make(map[string]bool)
 	baseDepth := strings.Count(filepath.Clean(dirPath), string(filepath.Separator))
 
-	err = filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+	var folders *folderScan
+	if is.skipUnchanged || is.watching(dirPath) {
+		folders = is.newFolderScan(dirPath, maxDepth, indexedMap)
+	} else {
+		is.forgetFolderSignatures(dirPath)
+	}
+	// An unchanged subtree holds the files the index has for it
+	skipSubtree := func(dir string, depth int) {
+		for _, path := range folders.indexedFiles(dir, depth) {
+			currentFiles[path] = true
+			if is.analysisFilter == nil || is.analysisFilter(path) {
+				changes.UnchangedFiles = append(changes.UnchangedFiles, path)
+			}
+		}
+	}
+
+	err = walkTree(dirPath, is.walk, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -373,7 +652,7 @@ func (is *DefaultIndexService) ScanDirectoryChanges(dirPath string, maxDepth int
 			relPath, err := filepath.Rel(dirPath, path)
 			if err == nil {
 				relPath = filepath.ToSlash(relPath)
-				if is.ignoreMatcher.ShouldIgnore(relPath, info.IsDir()) {
+				if is.ignoreMatcher.ShouldIgnoreFile(relPath, info) {
 					if info.IsDir() {
 						return filepath.SkipDir
 					}
@@ -393,17 +672,38 @@ func (is *DefaultIndexService) ScanDirectoryChanges(dirPath string, maxDepth int
 			return nil
 		}
 
-		// Skip directories
-		if info.IsDir() {
+		// Bundles are opaque; their internals are not files of their own
+		if isBundleDir(info) && path != dirPath {
+			return filepath.SkipDir
+		}
+
+		if info.IsDir() && folders != nil && folders.enter(path, currentDepth, info) {
+			is.logger.Debug("Skipping unchanged folder %s", path)
+			skipSubtree(filepath.Clean(path), currentDepth)
+			return filepath.SkipDir
+		}
+
+		// Skip directories and metadata sidecars
+		if info.IsDir() || isSidecarFile(path) {
 			return nil
 		}
 
 		currentFiles[path] = true
 
+		if is.analysisFilter != nil && !is.analysisFilter(path) {
+			if folders != nil {
+				folders.sawFile(path, false)
+			}
+			return nil
+		}
+
 		// Check if file is indexed
 		if _, exists := indexedMap[path]; exists {
 			// File exists in index, check if modified
 			needsReindex, err := is.NeedsReindexing(path)
+			if folders != nil {
+				folders.sawFile(path, err == nil && !needsReindex)
+			}
 			if err != nil {
 				is.logger.Debug("Error checking if file needs reindexing: %v", err)
 				return nil
@@ -414,6 +714,9 @@ func (is *DefaultIndexService) ScanDirectoryChanges(dirPath string, maxDepth int
 				changes.UnchangedFiles = append(changes.UnchangedFiles, path)
 			}
 		} else {
+			if folders != nil {
+				folders.sawFile(path, false)
+			}
 			// New file
 			changes.NewFiles = append(changes.NewFiles, path)
 		}
@@ -424,6 +727,11 @@ func (is *DefaultIndexService) ScanDirectoryChanges(dirPath string, maxDepth int
 	if err != nil {
 		return nil, err
 	}
+	if folders != nil {
+		if err := folders.save(); err != nil {
+			is.logger.Debug("Failed to record folder signatures for %s: %v", dirPath, err)
+		}
+	}
 
 	// Check for deleted files (only consider files within the current scan depth)
 	for path := range indexedMap {
@@ -581,6 +889,9 @@ func (is *DefaultIndexService) ValidateIndex() ([]string, error) {
 
 // RemoveOrphanedEntries removes index entries for files that no longer exist
 func (is *DefaultIndexService) RemoveOrphanedEntries(dirPath string) (int, error) {
+	// Entries from a remounted drive aren't orphans, they just need their new path
+	is.rebaseVolume(dirPath)
+
 	// Get all indexed files in directory
 	indexedFiles, err := is.GetIndexedFilesInDirectory(dirPath)
 	if err != nil {
@@ -617,26 +928,85 @@ func (is *DefaultIndexService) DeleteDirectoryIndex(dirPath string) (int, error)
 	if err != nil {
 		return 0, fmt.Errorf("failed to delete index entries: %w", err)
 	}
+	if _, err := is.db.Exec("DELETE FROM analysis_failures WHERE file_path LIKE ? OR file_path = ?", pattern, filepath.Clean(dirPath)); err != nil {
+		return 0, fmt.Errorf("failed to delete analysis failures: %w", err)
+	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return 0, fmt.Errorf("failed to get rows affected: %w", err)
 	}
 
+	is.audit.Record(AuditIndexDeleteDir, dirPath, fmt.Sprintf("%d entries", rowsAffected))
 	is.logger.Info("Deleted %d index entries from %s", rowsAffected, dirPath)
 	return int(rowsAffected), nil
 }
 
+// PurgeMatching deletes the entries whose path matches and returns their paths. The database is
+// vacuumed afterwards so the deleted descriptions don't linger in free pages.
+func (is *DefaultIndexService) PurgeMatching(match func(path string) bool) ([]string, error) {
+	if err := is.purgeIndexCheckpoints(match); err != nil {
+		return nil, fmt.Errorf("failed to purge indexing checkpoints: %w", err)
+	}
+	if err := is.purgeAnalysisFailures(match); err != nil {
+		return nil, fmt.Errorf("failed to purge analysis failures: %w", err)
+	}
+
+	rows, err := is.db.Query("SELECT file_path FROM indexed_files")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexed files: %w", err)
+	}
+	var purged []string
+	for rows.Next() {
+		var filePath string
+		if err := rows.Scan(&filePath); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if match(filePath) {
+			purged = append(purged, filePath)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(purged) == 0 {
+		return nil, nil
+	}
+
+	tx, err := is.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	for _, filePath := range purged {
+		if _, err := tx.Exec("DELETE FROM indexed_files WHERE file_path = ?", filePath); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to delete index entry: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if _, err := is.db.Exec("VACUUM"); err != nil {
+		is.logger.Error("Failed to vacuum index after purge: %v", err)
+	}
+	return purged, nil
+}
+
 // IndexDirectoryOrchestrator handles high-level indexing orchestration
 type IndexDirectoryOrchestrator struct {
 	indexService IndexService
 	analyzer     FileAnalyzer
 	logger       *Logger
+	sidecars     *SidecarWriter
+	shutdown     *ShutdownCoordinator
 }
 
 // FileAnalyzer defines the interface for analyzing files
 type FileAnalyzer interface {
-	AnalyzeFile(filePath string) (string, error)
+	AnalyzeFile(ctx context.Context, filePath string) (string, error)
 }
 
 func NewIndexDirectoryOrchestrator(indexService IndexService, analyzer FileAnalyzer, logger *Logger) *IndexDirectoryOrchestrator {
@@ -647,63 +1017,134 @@ func NewIndexDirectoryOrchestrator(indexService IndexService, analyzer FileAnaly
 	}
 }
 
-// IndexDirectory scans and indexes all files in a directory
-func (ido *IndexDirectoryOrchestrator) IndexDirectory(dirPath string, maxDepth int, onProgress func(current, total int, fileName string)) error {
-	// First, scan for changes
-	changes, err := ido.indexService.ScanDirectoryChanges(dirPath, maxDepth)
-	if err != nil {
-		return fmt.Errorf("failed to scan directory changes: %w", err)
-	}
+// SetSidecarWriter enables writing descriptions to sidecar files next to indexed files
+func (ido *IndexDirectoryOrchestrator) SetSidecarWriter(sidecars *SidecarWriter) {
+	ido.sidecars = sidecars
+}
 
-	// Calculate total files to process
-	totalFiles := len(changes.NewFiles) + len(changes.ModifiedFiles)
-	if totalFiles == 0 {
-		ido.logger.Info("No files need indexing in %s", dirPath)
-		return nil
-	}
+// SetShutdown stops indexing after the current file when the app exits; the checkpoint is kept
+func (ido *IndexDirectoryOrchestrator) SetShutdown(shutdown *ShutdownCoordinator) {
+	ido.shutdown = shutdown
+}
 
-	ido.logger.Info("Indexing directory: %s (%d new, %d modified, %d deleted)",
-		dirPath, len(changes.NewFiles), len(changes.ModifiedFiles), len(changes.DeletedFiles))
+// IndexDirectory scans and indexes all files in a directory. When the index service keeps
+// checkpoints, an interrupted run is resumed after its last processed file instead. Analysis
+// spend counts toward the budget run in ctx.
+func (ido *IndexDirectoryOrchestrator) IndexDirectory(ctx context.Context, dirPath string, maxDepth int, onProgress func(current, total int, fileName string)) error {
+	job, err := ido.shutdown.Begin("indexing "+dirPath, JobIndexing, nil)
+	if err != nil {
+		return err
+	}
+	defer job.Done()
 
-	currentFile := 0
+	checkpoints, _ := ido.indexService.(IndexCheckpointStore)
+	checkpoint := ido.resumableCheckpoint(checkpoints, dirPath, maxDepth)
 
-	// Process new files
-	for _, filePath := range changes.NewFiles {
-		currentFile++
-		if onProgress != nil {
-			onProgress(currentFile, totalFiles, filePath)
+	if checkpoint == nil {
+		// First, scan for changes
+		changes, err := ido.indexService.ScanDirectoryChanges(dirPath, maxDepth)
+		if err != nil {
+			return fmt.Errorf("failed to scan directory changes: %w", err)
+		}
+		checkpoint = &IndexCheckpoint{
+			DirPath:       dirPath,
+			MaxDepth:      maxDepth,
+			NewFiles:      changes.NewFiles,
+			ModifiedFiles: changes.ModifiedFiles,
+			DeletedFiles:  changes.DeletedFiles,
+		}
+		if len(checkpoint.Files()) == 0 {
+			ido.logger.Info("No files need indexing in %s", dirPath)
+			ido.clearCheckpoint(checkpoints, dirPath) // Left by a run with another depth
+			return nil
 		}
 
-		if err := ido.indexFile(filePath); err != nil {
-			ido.logger.Error("Failed to index new file %s: %v", filePath, err)
+		ido.logger.Info("Indexing directory: %s (%d new, %d modified, %d deleted)",
+			dirPath, len(changes.NewFiles), len(changes.ModifiedFiles), len(changes.DeletedFiles))
+
+		if checkpoints != nil {
+			if err := checkpoints.SaveIndexCheckpoint(checkpoint); err != nil {
+				ido.logger.Error("Indexing of %s won't be resumable: %v", dirPath, err)
+				checkpoints = nil
+			}
 		}
 	}
 
-	// Process modified files
-	for _, filePath := range changes.ModifiedFiles {
-		currentFile++
+	files := checkpoint.Files()
+	totalFiles := len(files)
+
+	for currentFile := checkpoint.Done() + 1; currentFile <= totalFiles; currentFile++ {
+		filePath := files[currentFile-1]
+		if ido.shutdown.ShuttingDown() {
+			return fmt.Errorf("%w: indexing of %s stopped after %d of %d files", ErrShuttingDown, dirPath, currentFile-1, totalFiles)
+		}
 		if onProgress != nil {
 			onProgress(currentFile, totalFiles, filePath)
 		}
 
-		if err := ido.indexFile(filePath); err != nil {
-			ido.logger.Error("Failed to reindex modified file %s: %v", filePath, err)
+		if err := ido.indexFile(ctx, filePath); err != nil {
+			if errors.Is(err, ErrBudgetExceeded) {
+				return fmt.Errorf("indexing stopped after %d of %d files: %w", currentFile-1, totalFiles, err)
+			}
+			if currentFile <= len(checkpoint.NewFiles) {
+				ido.logger.Error("Failed to index new file %s: %v", filePath, err)
+			} else {
+				ido.logger.Error("Failed to reindex modified file %s: %v", filePath, err)
+			}
+		}
+
+		if checkpoints != nil {
+			if err := checkpoints.AdvanceIndexCheckpoint(dirPath, filePath); err != nil {
+				ido.logger.Error("Failed to record indexing progress for %s: %v", dirPath, err)
+			}
 		}
 	}
 
 	// Remove deleted files from index
-	for _, filePath := range changes.DeletedFiles {
+	for _, filePath := range checkpoint.DeletedFiles {
 		if err := ido.indexService.RemoveFile(filePath); err != nil {
 			ido.logger.Error("Failed to remove deleted file from index %s: %v", filePath, err)
 		}
 	}
 
+	ido.clearCheckpoint(checkpoints, dirPath)
 	ido.logger.Info("Directory indexing complete for %s", dirPath)
 	return nil
 }
 
+func (ido *IndexDirectoryOrchestrator) clearCheckpoint(checkpoints IndexCheckpointStore, dirPath string) {
+	if checkpoints == nil {
+		return
+	}
+	if err := checkpoints.ClearIndexCheckpoint(dirPath); err != nil {
+		ido.logger.Error("Failed to clear indexing checkpoint for %s: %v", dirPath, err)
+	}
+}
+
+// resumableCheckpoint returns the checkpoint left by an interrupted run over the same directory
+// and depth, or nil to start a fresh scan
+func (ido *IndexDirectoryOrchestrator) resumableCheckpoint(checkpoints IndexCheckpointStore, dirPath string, maxDepth int) *IndexCheckpoint {
+	if checkpoints == nil {
+		return nil
+	}
+	checkpoint, err := checkpoints.LoadIndexCheckpoint(dirPath)
+	if err != nil {
+		ido.logger.Error("Ignoring indexing checkpoint for %s: %v", dirPath, err)
+		return nil
+	}
+	if checkpoint == nil || checkpoint.MaxDepth != maxDepth {
+		return nil
+	}
+	if checkpoint.LastPath != "" && checkpoint.Done() == 0 {
+		return nil // The recorded position is not in the work list
+	}
+
+	ido.logger.Info("Resuming indexing of %s after %d of %d files", dirPath, checkpoint.Done(), len(checkpoint.Files()))
+	return checkpoint
+}
+
 // indexFile indexes a single file
-func (ido *IndexDirectoryOrchestrator) indexFile(filePath string) error {
+func (ido *IndexDirectoryOrchestrator) indexFile(ctx context.Context, filePath string) error {
 	// Get file info
 	info, err := os.Stat(filePath)
 	if err != nil {
@@ -714,31 +1155,105 @@ func (ido *IndexDirectoryOrchestrator) indexFile(filePath string) error {
 	fileType := DetermineFileType(filePath)
 
 	// Analyze file to get description
-	description, err := ido.analyzer.AnalyzeFile(filePath)
+	description, err := ido.analyzer.AnalyzeFile(ctx, filePath)
+	if errors.Is(err, ErrBudgetExceeded) {
+		return err
+	}
+	if errors.Is(err, ErrCloudPlaceholder) {
+		ido.logger.Info("Skipping online-only file %s; make it available offline or allow downloads to index it", filePath)
+		return nil
+	}
 	if err != nil {
 		// Skip indexing if analysis fails for any file type
 		// This allows re-analysis when a more capable model is configured
 		// or when the file becomes accessible/processable
 		ido.logger.Debug("Skipping file %s due to analysis failure: %v", filePath, err)
+		ido.recordAnalysisFailure(filePath, fileType, info, err)
 		return nil
 	}
 
+	return ido.storeDescription(filePath, description, fileType, info)
+}
+
+// storeDescription records a file's description in the index and its sidecar
+func (ido *IndexDirectoryOrchestrator) storeDescription(filePath, description, fileType string, info os.FileInfo) error {
 	// Store in index with modification time
 	if err := ido.indexService.IndexFile(filePath, description, fileType, info.Size(), info.ModTime()); err != nil {
 		return fmt.Errorf("failed to store file in index: %w", err)
 	}
+	if querier, ok := ido.indexService.(IndexQuerier); ok {
+		if err := querier.ClearAnalysisFailure(filePath); err != nil {
+			ido.logger.Error("Failed to clear analysis failure of %s: %v", filePath, err)
+		}
+	}
+
+	// The index is the source of truth; a sidecar that can't be written isn't worth failing over
+	if err := ido.sidecars.Write(filePath, description, fileType); err != nil {
+		ido.logger.Error("Failed to write sidecar for %s: %v", filePath, err)
+	}
 
 	ido.logger.Debug("Indexed: %s - %s", filePath, description)
 	return nil
 }
 
+// recordAnalysisFailure remembers a failed analysis so the file can be found in the index
+// window. Files of types excluded from analysis haven't failed.
+func (ido *IndexDirectoryOrchestrator) recordAnalysisFailure(filePath, fileType string, info os.FileInfo, reason error) {
+	querier, ok := ido.indexService.(IndexQuerier)
+	if !ok || errors.Is(reason, ErrAnalysisDisabled) {
+		return
+	}
+	if err := querier.RecordAnalysisFailure(filePath, fileType, info.Size(), info.ModTime(), reason); err != nil {
+		ido.logger.Error("Failed to record analysis failure of %s: %v", filePath, err)
+	}
+}
+
+// ReanalyzeFiles describes the given files again, whether or not they changed since they were
+// indexed, and replaces their descriptions. Files that fail keep their old description and are
+// returned with their errors; running out of the budget of the run in ctx stops it.
+func (ido *IndexDirectoryOrchestrator) ReanalyzeFiles(ctx context.Context, filePaths []string, onProgress func(current, total int, fileName string)) (map[string]error, error) {
+	failed := make(map[string]error)
+	for i, filePath := range filePaths {
+		if onProgress != nil {
+			onProgress(i+1, len(filePaths), filePath)
+		}
+
+		info, err := os.Stat(filePath)
+		if err != nil {
+			failed[filePath] = err
+			continue
+		}
+		fileType := DetermineFileType(filePath)
+		description, err := ido.analyzer.AnalyzeFile(ctx, filePath)
+		if errors.Is(err, ErrBudgetExceeded) {
+			return failed, fmt.Errorf("re-analysis stopped after %d of %d files: %w", i, len(filePaths), err)
+		}
+		if err == nil {
+			err = ido.storeDescription(filePath, description, fileType, info)
+		} else {
+			ido.recordAnalysisFailure(filePath, fileType, info, err)
+		}
+		if err != nil {
+			ido.logger.Error("Failed to re-analyze %s: %v", filePath, err)
+			failed[filePath] = err
+		}
+	}
+	ido.logger.Info("Re-analyzed %d of %d files", len(filePaths)-len(failed), len(filePaths))
+	return failed, nil
+}
+
 // UpdateIndexAfterOperations updates the index smartly after file operations
 // It only updates paths for known files and indexes new files
 // Returns an error if any critical index operation fails
-func (ido *IndexDirectoryOrchestrator) UpdateIndexAfterOperations(operations []FileOperation) error {
+func (ido *IndexDirectoryOrchestrator) UpdateIndexAfterOperations(ctx context.Context, operations []FileOperation) error {
 	var errors []error
 
 	for _, op := range operations {
+		// Object keys are never indexed: deep analysis is off for object storage
+		if IsObjectStoragePath(op.To) {
+			continue
+		}
+
 		// Check if the old path was indexed
 		indexed, err := ido.indexService.IsFileIndexed(op.From)
 		if err != nil {
@@ -757,7 +1272,7 @@ func (ido *IndexDirectoryOrchestrator) UpdateIndexAfterOperations(operations []F
 			}
 		} else {
 			// File wasn't indexed before, index it now at the new location
-			if err := ido.indexFile(op.To); err != nil {
+			if err := ido.indexFile(ctx, op.To); err != nil {
 				ido.logger.Error("Failed to index new file %s: %v", op.To, err)
 				errors = append(errors, fmt.Errorf("failed to index new file %s: %w", op.To, err))
 			} else {
@@ -800,7 +1315,7 @@ type RepairIndexResult struct {
 
 // RepairIndex performs a comprehensive index repair for a directory
 // It removes orphaned entries, reindexes missing files, and updates stale entries
-func (ido *IndexDirectoryOrchestrator) RepairIndex(dirPath string, maxDepth int) (*RepairIndexResult, error) {
+func (ido *IndexDirectoryOrchestrator) RepairIndex(ctx context.Context, dirPath string, maxDepth int) (*RepairIndexResult, error) {
 	result := &RepairIndexResult{
 		Errors: make([]string, 0),
 	}
@@ -825,7 +1340,7 @@ func (ido *IndexDirectoryOrchestrator) RepairIndex(dirPath string, maxDepth int)
 
 	// Reindex new files
 	for _, filePath := range changes.NewFiles {
-		if err := ido.indexFile(filePath); err != nil {
+		if err := ido.indexFile(ctx, filePath); err != nil {
 			result.Errors = append(result.Errors, fmt.Sprintf("Failed to index %s: %v", filePath, err))
 		} else {
 			result.MissingReindexed++
@@ -834,7 +1349,7 @@ func (ido *IndexDirectoryOrchestrator) RepairIndex(dirPath string, maxDepth int)
 
 	// Update modified files
 	for _, filePath := range changes.ModifiedFiles {
-		if err := ido.indexFile(filePath); err != nil {
+		if err := ido.indexFile(ctx, filePath); err != nil {
 			result.Errors = append(result.Errors, fmt.Sprintf("Failed to reindex %s: %v", filePath, err))
 		} else {
 			result.StaleUpdated++
@@ -0,0 +1,401 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	indexSyncSuffix      = ".vafsync.json"
+	indexSyncStateSuffix = ".state.json"
+	quickHashChunk       = 64 * 1024 // Bytes hashed from each end of a file
+
+	// Bundles are deleted by the machine that wrote them once they're this old. A machine that
+	// hasn't synced for longer analyzes those files itself.
+	indexSyncRetention = 90 * 24 * time.Hour
+)
+
+var ErrIndexSyncNotConfigured = errors.New("no index sync folder is configured")
+
+var unsafeMachineChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// IndexSyncEntry is one description shared between machines. Paths are relative to the sync root
+// (the folder containing the sync folder) so each machine can mount the share wherever it likes.
+type IndexSyncEntry struct {
+	Path         string    `json:"path"`
+	Description  string    `json:"description"`
+	FileType     string    `json:"file_type"`
+	FileSize     int64     `json:"file_size"`
+	LastModified int64     `json:"last_modified"` // Unix seconds
+	Hash         string    `json:"hash"`
+	IndexedAt    time.Time `json:"indexed_at"`
+}
+
+// IndexSyncBundle is a delta exported by one machine
+type IndexSyncBundle struct {
+	Machine    string           `json:"machine"`
+	ExportedAt time.Time        `json:"exported_at"`
+	Entries    []IndexSyncEntry `json:"entries"`
+}
+
+// indexSyncState is kept per machine in the sync folder
+type indexSyncState struct {
+	LastExport time.Time       `json:"last_export"`
+	Imported   map[string]bool `json:"imported"` // Bundle file names already merged
+}
+
+// IndexSyncResult summarizes a sync
+type IndexSyncResult struct {
+	Exported int // Entries written for other machines
+	Imported int // Descriptions taken over from other machines
+	Skipped  int // Entries for files that are missing, differ, or have a newer local description
+}
+
+// IndexSyncService shares index descriptions between machines through a folder on a shared drive.
+// Each sync writes the entries changed since the previous sync as a delta bundle and merges the
+// other machines' bundles: an entry is taken over when the local file has the same size and mtime,
+// or the same content hash, and the local description is missing or older.
+type IndexSyncService struct {
+	indexService IndexService
+	config       *Config
+	logger       *Logger
+	machine      string
+	now          func() time.Time
+}
+
+func NewIndexSyncService(indexService IndexService, config *Config, logger *Logger) *IndexSyncService {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "unknown"
+	}
+	return &IndexSyncService{
+		indexService: indexService,
+		config:       config,
+		logger:       logger,
+		machine:      unsafeMachineChars.ReplaceAllString(hostname, "_"),
+		now:          time.Now,
+	}
+}
+
+// quickFileHash hashes the size and the first and last chunks of a file. Reading whole files
+// over a network share would defeat the point of syncing instead of re-analyzing.
+func quickFileHash(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d:", info.Size())
+	if _, err := io.CopyN(hash, file, quickHashChunk); err != nil && err != io.EOF {
+		return "", err
+	}
+	if info.Size() > 2*quickHashChunk {
+		if _, err := file.Seek(-quickHashChunk, io.SeekEnd); err != nil {
+			return "", err
+		}
+		if _, err := io.Copy(hash, file); err != nil {
+			return "", err
+		}
+	} else if info.Size() > quickHashChunk {
+		if _, err := io.Copy(hash, file); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Sync exports local changes and merges other machines' descriptions
+func (s *IndexSyncService) Sync() (IndexSyncResult, error) {
+	var result IndexSyncResult
+
+	syncDir := strings.TrimSpace(s.config.IndexSyncDir)
+	if syncDir == "" {
+		return result, ErrIndexSyncNotConfigured
+	}
+	if err := CheckPathReachable(syncDir, 0); err != nil {
+		return result, fmt.Errorf("sync folder %s: %w", syncDir, err)
+	}
+	syncRoot := filepath.Dir(filepath.Clean(syncDir))
+
+	state := s.loadState(syncDir)
+	started := s.now()
+
+	exported, err := s.export(syncDir, syncRoot, state.LastExport, started)
+	if err != nil {
+		return result, err
+	}
+	result.Exported = exported
+	state.LastExport = started
+
+	bundles, err := filepath.Glob(filepath.Join(syncDir, "*"+indexSyncSuffix))
+	if err != nil {
+		return result, err
+	}
+	sort.Strings(bundles) // Oldest first within a machine; file names start with the machine and timestamp
+	present := make(map[string]bool, len(bundles))
+	for _, bundlePath := range bundles {
+		name := filepath.Base(bundlePath)
+		machine, exportedAt, ok := parseBundleName(name)
+		if ok && machine == s.machine {
+			if started.Sub(exportedAt) > indexSyncRetention {
+				if err := os.Remove(bundlePath); err != nil {
+					s.logger.Error("Failed to remove old index sync bundle %s: %v", name, err)
+				}
+			}
+			continue
+		}
+		present[name] = true
+		if state.Imported[name] {
+			continue
+		}
+		imported, skipped, err := s.importBundle(bundlePath, syncRoot, started)
+		if err != nil {
+			s.logger.Error("Failed to import index sync bundle %s: %v", name, err)
+			continue
+		}
+		result.Imported += imported
+		result.Skipped += skipped
+		state.Imported[name] = true
+	}
+	for name := range state.Imported {
+		if !present[name] {
+			delete(state.Imported, name) // Pruned by the machine that wrote it
+		}
+	}
+
+	if err := s.saveState(syncDir, state); err != nil {
+		return result, err
+	}
+	s.logger.Info("Index sync: exported %d, imported %d, skipped %d entries", result.Exported, result.Imported, result.Skipped)
+	return result, nil
+}
+
+// export writes the entries under syncRoot that changed since the last export
+func (s *IndexSyncService) export(syncDir, syncRoot string, since, now time.Time) (int, error) {
+	files, err := s.indexService.GetIndexedFilesInDirectory(syncRoot)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read index: %w", err)
+	}
+
+	bundle := IndexSyncBundle{Machine: s.machine, ExportedAt: now}
+	for _, file := range files {
+		if !file.UpdatedAt.After(since) || file.Description == "" {
+			continue
+		}
+		rel, err := filepath.Rel(syncRoot, file.FilePath)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		hash, err := quickFileHash(file.FilePath)
+		if err != nil {
+			continue // Deleted since it was indexed
+		}
+		bundle.Entries = append(bundle.Entries, IndexSyncEntry{
+			Path:         filepath.ToSlash(rel),
+			Description:  file.Description,
+			FileType:     file.FileType,
+			FileSize:     file.FileSize,
+			LastModified: file.LastModified.Unix(),
+			Hash:         hash,
+			IndexedAt:    file.UpdatedAt,
+		})
+	}
+	if len(bundle.Entries) == 0 {
+		return 0, nil
+	}
+
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		return 0, err
+	}
+	name := fmt.Sprintf("%s-%d%s", s.machine, now.UnixNano(), indexSyncSuffix)
+	if err := os.WriteFile(filepath.Join(syncDir, name), data, 0644); err != nil {
+		return 0, fmt.Errorf("failed to write sync bundle: %w", err)
+	}
+	return len(bundle.Entries), nil
+}
+
+// parseBundleName splits a bundle file name into the machine that wrote it and when
+func parseBundleName(name string) (string, time.Time, bool) {
+	stem, ok := strings.CutSuffix(name, indexSyncSuffix)
+	if !ok {
+		return "", time.Time{}, false
+	}
+	i := strings.LastIndex(stem, "-")
+	if i <= 0 {
+		return "", time.Time{}, false
+	}
+	nanos, err := strconv.ParseInt(stem[i+1:], 10, 64)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return stem[:i], time.Unix(0, nanos), true
+}
+
+// importBundle merges another machine's delta into the local index. Entries keep the time they
+// were written, so they aren't exported again as local changes; a time after syncStarted (clocks
+// differ between machines) is brought back to it for the same reason.
+func (s *IndexSyncService) importBundle(bundlePath, syncRoot string, syncStarted time.Time) (imported, skipped int, err error) {
+	data, err := os.ReadFile(bundlePath)
+	if err != nil {
+		return 0, 0, err
+	}
+	var bundle IndexSyncBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return 0, 0, err
+	}
+
+	for _, entry := range bundle.Entries {
+		localPath := filepath.Join(syncRoot, filepath.FromSlash(entry.Path))
+		if !s.entryMatchesLocal(entry, localPath) {
+			skipped++
+			continue
+		}
+
+		existing, err := s.indexService.GetIndexedFile(localPath)
+		if err != nil {
+			return imported, skipped, err
+		}
+		if existing != nil && existing.Description == entry.Description {
+			continue // Our own description coming back from a machine that imported it
+		}
+		if existing != nil && existing.Description != "" && !existing.UpdatedAt.Before(entry.IndexedAt) {
+			skipped++
+			continue
+		}
+
+		info, err := os.Stat(localPath)
+		if err != nil {
+			skipped++
+			continue
+		}
+		updatedAt := entry.IndexedAt
+		if updatedAt.After(syncStarted) {
+			updatedAt = syncStarted
+		}
+		// Store the local mtime so the next scan doesn't consider the file modified
+		if err := s.indexService.ImportFile(localPath, entry.Description, entry.FileType, info.Size(), info.ModTime(), updatedAt); err != nil {
+			return imported, skipped, err
+		}
+		imported++
+	}
+	return imported, skipped, nil
+}
+
+// Purge removes entries for matching paths from every bundle in the sync folder, so other
+// machines don't import them again. It returns how many entries were removed.
+func (s *IndexSyncService) Purge(match func(path string) bool) (int, error) {
+	syncDir := strings.TrimSpace(s.config.IndexSyncDir)
+	if syncDir == "" {
+		return 0, nil
+	}
+	syncRoot := filepath.Dir(filepath.Clean(syncDir))
+	bundles, err := filepath.Glob(filepath.Join(syncDir, "*"+indexSyncSuffix))
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, bundlePath := range bundles {
+		data, err := os.ReadFile(bundlePath)
+		if err != nil {
+			return removed, err
+		}
+		var bundle IndexSyncBundle
+		if err := json.Unmarshal(data, &bundle); err != nil {
+			s.logger.Error("Failed to parse index sync bundle %s: %v", bundlePath, err)
+			continue
+		}
+
+		kept := bundle.Entries[:0]
+		for _, entry := range bundle.Entries {
+			if match(filepath.Join(syncRoot, filepath.FromSlash(entry.Path))) {
+				continue
+			}
+			kept = append(kept, entry)
+		}
+		dropped := len(bundle.Entries) - len(kept)
+		if dropped == 0 {
+			continue
+		}
+		removed += dropped
+
+		if len(kept) == 0 {
+			if err := os.Remove(bundlePath); err != nil {
+				return removed, err
+			}
+			continue
+		}
+		bundle.Entries = kept
+		if data, err = json.Marshal(bundle); err != nil {
+			return removed, err
+		}
+		if err := writeFileAtomic(bundlePath, data); err != nil {
+			return removed, fmt.Errorf("failed to rewrite sync bundle: %w", err)
+		}
+	}
+	return removed, nil
+}
+
+// entryMatchesLocal reports whether the local file is the one the entry describes
+func (s *IndexSyncService) entryMatchesLocal(entry IndexSyncEntry, localPath string) bool {
+	info, err := os.Stat(localPath)
+	if err != nil || info.IsDir() || info.Size() != entry.FileSize {
+		return false
+	}
+	if info.ModTime().Unix() == entry.LastModified {
+		return true
+	}
+	// Timestamps often differ across machines and protocols; fall back to the content
+	hash, err := quickFileHash(localPath)
+	return err == nil && hash == entry.Hash
+}
+
+func (s *IndexSyncService) statePath(syncDir string) string {
+	return filepath.Join(syncDir, s.machine+indexSyncStateSuffix)
+}
+
+func (s *IndexSyncService) loadState(syncDir string) indexSyncState {
+	state := indexSyncState{Imported: make(map[string]bool)}
+	data, err := os.ReadFile(s.statePath(syncDir))
+	if err != nil {
+		return state
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		s.logger.Error("Failed to parse index sync state, starting over: %v", err)
+		return indexSyncState{Imported: make(map[string]bool)}
+	}
+	if state.Imported == nil {
+		state.Imported = make(map[string]bool)
+	}
+	return state
+}
+
+func (s *IndexSyncService) saveState(syncDir string, state indexSyncState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.statePath(syncDir), data, 0644); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	return nil
+}
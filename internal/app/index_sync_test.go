@@ -0,0 +1,165 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIndexSync_SharesDescriptionsBetweenMachines(t *testing.T) {
+	// Each machine mounts the share at a different path; the sync folder is shared between them
+	desktopRoot := filepath.Join(t.TempDir(), "nas")
+	laptopRoot := filepath.Join(t.TempDir(), "Volumes", "nas")
+	sharedSync := t.TempDir()
+	for _, root := range []string{desktopRoot, laptopRoot} {
+		if err := os.MkdirAll(filepath.Join(root, "docs"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(sharedSync, filepath.Join(root, ".sync")); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+
+	modTime := time.Unix(1700000000, 0)
+	writeFile := func(root, name, content string, mtime time.Time) string {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	desktopReport := writeFile(desktopRoot, "docs/report.pdf", "quarterly numbers", modTime)
+	desktopNotes := writeFile(desktopRoot, "docs/notes.txt", "desktop version", modTime)
+	// Same content but a different mtime on the laptop: matched by hash
+	laptopReport := writeFile(laptopRoot, "docs/report.pdf", "quarterly numbers", modTime.Add(time.Hour))
+	// Same size but edited on the laptop: the hash tells them apart
+	laptopNotes := writeFile(laptopRoot, "docs/notes.txt", "laptop version!", modTime.Add(2*time.Hour))
+
+	newMachine := func(name, root string) (*DefaultIndexService, *IndexSyncService) {
+		indexService := NewIndexService(NewLogger(false))
+		if err := indexService.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { indexService.Close() })
+		syncService := NewIndexSyncService(indexService, &Config{IndexSyncDir: filepath.Join(root, ".sync")}, NewLogger(false))
+		syncService.machine = name
+		return indexService, syncService
+	}
+	desktopIndex, desktopSync := newMachine("desktop", desktopRoot)
+	laptopIndex, laptopSync := newMachine("laptop", laptopRoot)
+
+	for path, description := range map[string]string{desktopReport: "Q3 financial report", desktopNotes: "Meeting notes"} {
+		info, _ := os.Stat(path)
+		if err := desktopIndex.IndexFile(path, description, "text", info.Size(), info.ModTime()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := desktopSync.Sync()
+	if err != nil {
+		t.Fatalf("desktop sync failed: %v", err)
+	}
+	if result.Exported != 2 {
+		t.Errorf("expected 2 exported entries, got %+v", result)
+	}
+
+	result, err = laptopSync.Sync()
+	if err != nil {
+		t.Fatalf("laptop sync failed: %v", err)
+	}
+	if result.Imported != 1 || result.Skipped != 1 {
+		t.Errorf("expected 1 imported and 1 skipped entry, got %+v", result)
+	}
+
+	report, err := laptopIndex.GetIndexedFile(laptopReport)
+	if err != nil || report == nil || report.Description != "Q3 financial report" {
+		t.Fatalf("expected the report description to be imported, got %+v (err: %v)", report, err)
+	}
+	if needs, _ := laptopIndex.NeedsReindexing(laptopReport); needs {
+		t.Error("imported entry should match the local file's mtime")
+	}
+	if notes, _ := laptopIndex.GetIndexedFile(laptopNotes); notes != nil {
+		t.Error("expected differing file not to be imported")
+	}
+
+	// A second round must not bounce the same descriptions back and forth
+	if result, err = desktopSync.Sync(); err != nil || result.Imported != 0 {
+		t.Errorf("expected nothing new for the desktop, got %+v (err: %v)", result, err)
+	}
+	if result, err = desktopSync.Sync(); err != nil || result.Exported != 0 || result.Imported != 0 {
+		t.Errorf("expected an idle sync, got %+v (err: %v)", result, err)
+	}
+	// Imported descriptions aren't local changes, so the laptop has nothing to export
+	if result, err = laptopSync.Sync(); err != nil || result.Exported != 0 {
+		t.Errorf("expected the laptop not to export imported entries, got %+v (err: %v)", result, err)
+	}
+	if updated, _ := laptopIndex.GetIndexedFile(laptopReport); updated == nil || updated.UpdatedAt.After(report.UpdatedAt) {
+		t.Errorf("expected the imported entry to keep its time, got %+v", updated)
+	}
+}
+
+func TestIndexSync_MachinesAndPruning(t *testing.T) {
+	root := t.TempDir()
+	syncDir := filepath.Join(root, ".sync")
+	if err := os.MkdirAll(syncDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(root, "a.txt")
+	if err := os.WriteFile(path, []byte("shared"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(path)
+	hash, _ := quickFileHash(path)
+
+	now := time.Unix(1800000000, 0)
+	writeBundle := func(machine string, exportedAt time.Time) string {
+		bundle := IndexSyncBundle{Machine: machine, ExportedAt: exportedAt, Entries: []IndexSyncEntry{{
+			Path: "a.txt", Description: "From " + machine, FileType: "text", FileSize: info.Size(),
+			LastModified: info.ModTime().Unix(), Hash: hash, IndexedAt: exportedAt,
+		}}}
+		data, err := json.Marshal(bundle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		name := fmt.Sprintf("%s-%d%s", machine, exportedAt.UnixNano(), indexSyncSuffix)
+		if err := os.WriteFile(filepath.Join(syncDir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return name
+	}
+	ownOld := writeBundle("laptop", now.Add(-indexSyncRetention-time.Hour))
+	ownRecent := writeBundle("laptop", now.Add(-time.Hour))
+	other := writeBundle("laptop-work", now.Add(-2*time.Hour))
+
+	indexService := NewIndexService(NewLogger(false))
+	if err := indexService.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { indexService.Close() })
+	syncService := NewIndexSyncService(indexService, &Config{IndexSyncDir: syncDir}, NewLogger(false))
+	syncService.machine = "laptop"
+	syncService.now = func() time.Time { return now }
+
+	result, err := syncService.Sync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 1 {
+		t.Errorf("expected the laptop-work bundle to be imported, got %+v", result)
+	}
+	if file, _ := indexService.GetIndexedFile(path); file == nil || file.Description != "From laptop-work" {
+		t.Errorf("expected the laptop-work description, got %+v", file)
+	}
+
+	for name, want := range map[string]bool{ownOld: false, ownRecent: true, other: true} {
+		if _, err := os.Stat(filepath.Join(syncDir, name)); (err == nil) != want {
+			t.Errorf("bundle %s present = %v, want %v", name, err == nil, want)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// watcherFlushInterval is how often folders touched by events are invalidated and unpaired renames expire
+	watcherFlushInterval = 500 * time.Millisecond
+
+	// renamePairWindow is how long a renamed file waits for the create event naming its new path.
+	// Files that don't reappear were moved out of the watched folders and leave the index.
+	renamePairWindow = 2 * time.Second
+)
+
+// watchableIndex is implemented by index services that can trust a watcher instead of rescanning
+type watchableIndex interface {
+	SetWatched(root string, watched bool)
+	InvalidateFolder(dir string)
+}
+
+// pendingRename is an indexed file or folder that was renamed away, waiting for its new name
+type pendingRename struct {
+	path string
+	at   time.Time
+	file *IndexedFile // Nil for a folder with indexed files under it
+}
+
+// IndexWatcher keeps the index up to date with changes in registered folders as they happen
+// (inotify on Linux, FSEvents on macOS, ReadDirectoryChangesW on Windows). Renames and moves keep
+// their descriptions, deletions drop their entries, and folders where anything changed lose their
+// signature, so ScanDirectoryChanges only has to look at those instead of diffing the whole tree.
+// Files that are created or edited are left for the next analysis to describe.
+type IndexWatcher struct {
+	indexService IndexService
+	logger       *Logger
+	fsw          *fsnotify.Watcher
+
+	mu    sync.Mutex
+	roots map[string]bool
+
+	// Only touched by the event loop
+	dirty   map[string]bool
+	renames []pendingRename
+
+	wg sync.WaitGroup
+}
+
+func NewIndexWatcher(indexService IndexService, logger *Logger) (*IndexWatcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start folder watcher: %w", err)
+	}
+	w := &IndexWatcher{
+		indexService: indexService,
+		logger:       logger,
+		fsw:          fsw,
+		roots:        make(map[string]bool),
+		dirty:        make(map[string]bool),
+	}
+	w.wg.Add(1)
+	go w.run()
+	return w, nil
+}
+
+// ParseWatchedFolders splits a multiline list of folders, one per line, ignoring blank lines
+func ParseWatchedFolders(text string) []string {
+	var folders []string
+	for _, line := range strings.Split(text, "\n") {
+		if folder := strings.TrimSpace(line); folder != "" {
+			folders = append(folders, filepath.Clean(folder))
+		}
+	}
+	return folders
+}
+
+// Watch starts following changes in root and every folder below it
+func (w *IndexWatcher) Watch(root string) error {
+	root = filepath.Clean(root)
+	if err := w.addTree(root); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.roots[root] = true
+	w.mu.Unlock()
+	if index, ok := w.indexService.(watchableIndex); ok {
+		index.SetWatched(root, true)
+	}
+	w.logger.Info("Watching %s for index changes", root)
+	return nil
+}
+
+// Close stops watching; changes from then on are found by scanning again
+func (w *IndexWatcher) Close() error {
+	err := w.fsw.Close()
+	w.wg.Wait()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if index, ok := w.indexService.(watchableIndex); ok {
+		for root := range w.roots {
+			index.SetWatched(root, false)
+		}
+	}
+	return err
+}
+
+// addTree watches dir and the folders below it. Folders have to be watched one by one: inotify
+// isn't recursive.
+func (w *IndexWatcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path != dir && errors.Is(err, fs.ErrNotExist) {
+				return nil // Gone again before it could be watched
+			}
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if err := w.fsw.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+func (w *IndexWatcher) run() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(watcherFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			w.handle(event)
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			w.handleError(err)
+		case now := <-ticker.C:
+			w.flush(now)
+		}
+	}
+}
+
+func (w *IndexWatcher) handle(event fsnotify.Event) {
+	path := filepath.Clean(event.Name)
+	switch {
+	case event.Has(fsnotify.Create):
+		w.created(path)
+	case event.Has(fsnotify.Rename):
+		w.renamed(path)
+	case event.Has(fsnotify.Remove):
+		w.removed(path)
+	case !event.Has(fsnotify.Write):
+		return // Permission changes don't change what's in a folder
+	}
+	w.dirty[filepath.Dir(path)] = true
+}
+
+func (w *IndexWatcher) handleError(err error) {
+	if !errors.Is(err, fsnotify.ErrEventOverflow) {
+		w.logger.Error("Folder watcher error: %v", err)
+		return
+	}
+	// Some changes were lost, so nothing recorded under the roots can be trusted anymore
+	w.logger.Error("Folder watcher missed changes; the next analysis rescans the watched folders")
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if index, ok := w.indexService.(watchableIndex); ok {
+		for root := range w.roots {
+			index.SetWatched(root, true)
+		}
+	}
+}
+
+func (w *IndexWatcher) created(path string) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return
+	}
+	if info.IsDir() {
+		if err := w.addTree(path); err != nil {
+			w.logger.Error("%v; changes there are found by the next analysis", err)
+		}
+		// Folders created inside before the watch was added have no signature, so they are scanned
+		if from, ok := w.takeRename(path, info); ok {
+			w.moveFolder(from.path, path)
+		}
+		return
+	}
+	if from, ok := w.takeRename(path, info); ok {
+		if err := w.indexService.UpdateFilePath(from.path, path); err != nil {
+			w.logger.Error("Failed to follow %s to %s in the index: %v", from.path, path, err)
+			return
+		}
+		w.logger.Debug("Followed rename %s -> %s", from.path, path)
+	}
+}
+
+// takeRename finds the pending rename that path is the new name of: the one with the same name,
+// or else for files the one with the same size and modification time, and for folders the one
+// whose indexed files are in the new folder
+func (w *IndexWatcher) takeRename(path string, info os.FileInfo) (pendingRename, bool) {
+	match := -1
+	for i, pending := range w.renames {
+		if (pending.file == nil) != info.IsDir() {
+			continue
+		}
+		if filepath.Base(pending.path) == filepath.Base(path) {
+			match = i
+			break
+		}
+		if match >= 0 {
+			continue
+		}
+		if pending.file != nil && pending.file.FileSize == info.Size() &&
+			pending.file.LastModified.Unix() == info.ModTime().Unix() {
+			match = i
+		}
+		if pending.file == nil && w.holdsFilesOf(path, pending.path) {
+			match = i
+		}
+	}
+	if match < 0 {
+		return pendingRename{}, false
+	}
+	pending := w.renames[match]
+	w.renames = append(w.renames[:match], w.renames[match+1:]...)
+	return pending, true
+}
+
+// holdsFilesOf reports whether dir has an indexed file of the folder that was at from
+func (w *IndexWatcher) holdsFilesOf(dir, from string) bool {
+	files, err := w.indexService.GetIndexedFilesInDirectory(from)
+	if err != nil {
+		return false
+	}
+	for _, file := range files {
+		if rel, err := filepath.Rel(from, file.FilePath); err == nil && exists(filepath.Join(dir, rel)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *IndexWatcher) moveFolder(from, to string) {
+	files, err := w.indexService.GetIndexedFilesInDirectory(from)
+	if err != nil {
+		w.logger.Error("Failed to follow %s to %s in the index: %v", from, to, err)
+		return
+	}
+	for _, file := range files {
+		rel, err := filepath.Rel(from, file.FilePath)
+		if err != nil {
+			continue
+		}
+		if err := w.indexService.UpdateFilePath(file.FilePath, filepath.Join(to, rel)); err != nil {
+			w.logger.Error("Failed to follow %s in the index: %v", file.FilePath, err)
+		}
+	}
+	w.logger.Debug("Followed folder rename %s -> %s (%d files)", from, to, len(files))
+}
+
+// renamed holds on to an indexed file or folder until its new name shows up
+func (w *IndexWatcher) renamed(path string) {
+	for _, pending := range w.renames {
+		if pending.path == path {
+			return // Reported by both the folder and its parent
+		}
+	}
+	file, err := w.indexService.GetIndexedFile(path)
+	if err != nil {
+		w.logger.Error("Failed to look up %s in the index: %v", path, err)
+		return
+	}
+	if file == nil {
+		files, err := w.indexService.GetIndexedFilesInDirectory(path)
+		if err != nil || len(files) == 0 {
+			return
+		}
+	}
+	w.renames = append(w.renames, pendingRename{path: path, at: time.Now(), file: file})
+}
+
+func (w *IndexWatcher) removed(path string) {
+	file, err := w.indexService.GetIndexedFile(path)
+	if err != nil {
+		w.logger.Error("Failed to look up %s in the index: %v", path, err)
+		return
+	}
+	if file != nil {
+		if err := w.indexService.RemoveFile(path); err != nil {
+			w.logger.Error("Failed to remove %s from the index: %v", path, err)
+		}
+		return
+	}
+	files, err := w.indexService.GetIndexedFilesInDirectory(path)
+	if err == nil && len(files) > 0 {
+		if _, err := w.indexService.DeleteDirectoryIndex(path); err != nil {
+			w.logger.Error("Failed to remove %s from the index: %v", path, err)
+		}
+	}
+}
+
+// flush gives up on renames that never reappeared and invalidates the folders events touched
+func (w *IndexWatcher) flush(now time.Time) {
+	kept := w.renames[:0]
+	for _, pending := range w.renames {
+		if now.Sub(pending.at) < renamePairWindow {
+			kept = append(kept, pending)
+			continue
+		}
+		w.logger.Debug("%s was moved out of the watched folders", pending.path)
+		w.removed(pending.path)
+	}
+	w.renames = kept
+
+	if index, ok := w.indexService.(watchableIndex); ok {
+		for dir := range w.dirty {
+			index.InvalidateFolder(dir)
+		}
+	}
+	clear(w.dirty)
+}
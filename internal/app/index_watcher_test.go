@@ -0,0 +1,126 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// eventually polls cond until it holds or a few seconds pass
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting until %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestIndexWatcherFollowsChanges(t *testing.T) {
+	root := writeWalkFixture(t)
+	is := NewIndexService(NewLogger(false))
+	if err := is.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer is.Close()
+	is.SetIgnorePatterns("node_modules/")
+	indexTree(t, is, root)
+
+	w, err := NewIndexWatcher(is, NewLogger(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.Watch(root); err != nil {
+		t.Fatal(err)
+	}
+	indexed := func(rel string) bool {
+		file, err := is.GetIndexedFile(filepath.Join(root, filepath.FromSlash(rel)))
+		return err == nil && file != nil && file.Description == "test"
+	}
+
+	// Renamed and moved files keep their descriptions
+	if err := os.Rename(filepath.Join(root, "a.txt"), filepath.Join(root, "b", "renamed.txt")); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the renamed file is indexed under its new name", func() bool {
+		return indexed("b/renamed.txt") && !indexed("a.txt")
+	})
+
+	if err := os.Rename(filepath.Join(root, "photos"), filepath.Join(root, "b", "pictures")); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the renamed folder's files are indexed under it", func() bool {
+		return indexed("b/pictures/2024/y.jpg") && indexed("b/pictures/2023/x.jpg") && !indexed("photos/2024/y.jpg")
+	})
+
+	// Deleted files leave the index, and so do files moved out of the watched folder
+	if err := os.Remove(filepath.Join(root, "zz", "last.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(root, "skip-rest", "1.txt"), filepath.Join(t.TempDir(), "1.txt")); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "deleted and moved away files are removed", func() bool {
+		return !indexed("zz/last.txt") && !indexed("skip-rest/1.txt")
+	})
+
+	// Folders created after the watch started are watched too
+	if err := os.MkdirAll(filepath.Join(root, "new", "deeper"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err := os.Rename(filepath.Join(root, "b", "c.txt"), filepath.Join(root, "new", "deeper", "c.txt")); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "a file moved into a new folder is followed", func() bool {
+		return indexed("new/deeper/c.txt")
+	})
+}
+
+func TestWatchedScanNoticesEditsInPlace(t *testing.T) {
+	root := writeWalkFixture(t)
+	is := NewIndexService(NewLogger(false))
+	if err := is.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer is.Close()
+	is.SetIgnorePatterns("node_modules/")
+	indexTree(t, is, root)
+
+	w, err := NewIndexWatcher(is, NewLogger(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.Watch(root); err != nil {
+		t.Fatal(err)
+	}
+	// Signatures are trusted under a watched folder even though skipping is off
+	if _, err := is.ScanDirectoryChanges(root, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	edited := filepath.Join(root, "b", "d", "e.txt")
+	if err := os.WriteFile(edited, []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(edited, later, later); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the edit is picked up by a scan", func() bool {
+		changes, err := is.ScanDirectoryChanges(root, 0)
+		return err == nil && relPaths(root, changes.ModifiedFiles) == "b/d/e.txt"
+	})
+}
+
+func TestParseWatchedFolders(t *testing.T) {
+	got := ParseWatchedFolders("/srv/archive\n\n  /home/me/Documents/ \n")
+	if len(got) != 2 || got[0] != filepath.Clean("/srv/archive") || got[1] != filepath.Clean("/home/me/Documents") {
+		t.Errorf("ParseWatchedFolders() = %q", got)
+	}
+}
@@ -1,22 +1,87 @@
 package app
 
+import (
+	"context"
+	"time"
+)
+
 // Callback function type for streaming operations
 type OperationCallback func(op FileOperation)
 
 // AIService defines the contract for AI suggestion services
 type AIService interface {
 	// GetSuggestions now takes a callback to stream results
-	GetSuggestions(structure, userPrompt, basePath string, onOperation OperationCallback) ([]FileOperation, error)
+	GetSuggestions(ctx context.Context, structure, userPrompt, basePath string, onOperation OperationCallback) ([]FileOperation, error)
+}
+
+// VariantAIService is implemented by AI services that can run with a different system prompt
+// or model, which prompt comparisons rely on
+type VariantAIService interface {
+	// WithVariant returns a service using systemPrompt and model; empty values keep the configured ones
+	WithVariant(systemPrompt, model string) AIService
+}
+
+// PlanCritic is implemented by AI services that can review a plan they produced
+type PlanCritic interface {
+	CritiquePlan(ctx context.Context, structure, userPrompt, basePath string, operations []FileOperation) ([]OperationCritique, error)
+}
+
+// FileSelector is implemented by AI services that can pick the files a query is about
+type FileSelector interface {
+	SelectFiles(ctx context.Context, structure, query, basePath string) ([]string, error)
+}
+
+// RuleWriter is implemented by AI services that can write classification rules from index statistics
+type RuleWriter interface {
+	WriteClassificationRules(ctx context.Context, stats, userPrompt, basePath string) ([]ClassificationRule, error)
 }
 
 // FileService defines the contract for file operations
 type FileService interface {
-	GetDirectoryStructure(rootPath string, maxDepth int) (string, error)
-	ExecuteOperations(operations []FileOperation, basePath string, cleanEmpty bool) (ExecutionResult, error)
+	GetDirectoryStructure(rootPath string, maxDepth int, onProgress ScanProgressCallback) (string, error)
+	ExecuteOperations(operations []FileOperation, basePath string, opts ExecutionOptions) (ExecutionResult, error)
 	CountFiles(rootPath string) (int, error)
 	CleanEmptyDirectories(rootPath string) (int, error)
 }
 
+// StructureCacheInvalidator is implemented by file services that cache directory structures
+// between calls, so callers that learn about changes by other means can force a fresh walk
+type StructureCacheInvalidator interface {
+	InvalidateStructureCache(path string)
+}
+
+// ExecutionOptions controls how a batch of operations is executed
+type ExecutionOptions struct {
+	CleanEmpty  bool
+	OnOffline   OfflineHandler // Consulted when the base path goes offline mid-run; nil aborts
+	Parallelism int            // Maximum concurrent moves; 0 or 1 executes sequentially
+	Staged      bool           // All or nothing: move through a staging folder and undo everything on failure
+
+	// Files in use by other programs are detected before anything moves (on Windows always,
+	// elsewhere with lsof when CheckOpenFiles is set); OnLocked decides what happens. nil skips them.
+	CheckOpenFiles bool
+	OnLocked       LockedFilesHandler
+
+	// Compare permissions of moved items with their originals and report differences
+	AuditPermissions bool
+
+	// Pause ThrottleDelay after every ThrottleBatch moves (every move if ThrottleBatch <= 1) so
+	// cloud sync clients aren't flooded. Throttled executions run sequentially.
+	ThrottleDelay time.Duration
+	ThrottleBatch int
+
+	// Pauses or stops the run between operations; nil runs to the end
+	Control *ExecutionControl
+
+	// Moved files to compare by content hash before and after; 0 for none, negative for all
+	HashSample int
+
+	// A move still running after HeartbeatInterval (0 for the default of 5s) is logged, and
+	// reported to OnHeartbeat, once per interval until it finishes
+	OnHeartbeat       HeartbeatHandler
+	HeartbeatInterval time.Duration
+}
+
 // ExecutionResult and OperationResult remain unchanged...
 type ExecutionResult struct {
 	SuccessCount      int
@@ -26,6 +91,14 @@ type ExecutionResult struct {
 	CleanedDirs       int
 	Operations        []OperationResult
 	VerificationError error
+	Aborted           bool // True if execution stopped early (e.g. target went offline)
+	Stopped           bool // True if the user stopped it; see Remaining
+	PermissionChanges []PermissionChange
+	HashesVerified    int // Moved files whose content hash was compared
+	HashMismatches    []HashMismatch
+	Layout            LayoutDiff        // Files found after execution that differ from the simulated plan
+	Shortcuts         []ShortcutFix     // Shortcuts under the base path that pointed at moved files
+	References        []ReferenceUpdate // Playlists, notes and sidecars whose paths were rewritten
 }
 
 type OperationResult struct {
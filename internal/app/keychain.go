@@ -0,0 +1,117 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSecretNotFound      = errors.New("secret not found in keychain")
+	ErrKeychainUnavailable = errors.New("no system keychain available")
+)
+
+const (
+	keychainService = "io.github.sandwichdoge.vibesandfolders"
+	keychainTimeout = 30 * time.Second
+)
+
+// SecretStore keeps small secrets such as encryption keys outside the app's own files
+type SecretStore interface {
+	Get(account string) (string, error)
+	Set(account, secret string) error
+}
+
+// Keychain stores secrets in the system keychain: the login keychain on macOS (security),
+// the Secret Service on Linux (secret-tool) and DPAPI-protected files in dir on Windows
+type Keychain struct {
+	dir string
+}
+
+// NewKeychain returns the system keychain. dir holds the protected files on Windows.
+func NewKeychain(dir string) *Keychain {
+	return &Keychain{dir: dir}
+}
+
+// Get returns the secret stored for account, or ErrSecretNotFound
+func (k *Keychain) Get(account string) (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("security"); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrKeychainUnavailable, err)
+		}
+		out, err := runArgs([]string{"security", "find-generic-password", "-s", keychainService, "-a", account, "-w"}, nil, keychainTimeout)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrSecretNotFound, err)
+		}
+		return strings.TrimSpace(string(out)), nil
+	case "windows":
+		blob, err := os.ReadFile(k.protectedFile(account))
+		if errors.Is(err, os.ErrNotExist) {
+			return "", ErrSecretNotFound
+		}
+		if err != nil {
+			return "", err
+		}
+		out, err := runArgs([]string{"powershell", "-NoProfile", "-NonInteractive", "-Command", dpapiUnprotectScript}, blob, keychainTimeout)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrKeychainUnavailable, err)
+		}
+		return strings.TrimSpace(string(out)), nil
+	default:
+		if _, err := exec.LookPath("secret-tool"); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrKeychainUnavailable, err)
+		}
+		// secret-tool exits non-zero without output when nothing matches
+		out, err := runArgs([]string{"secret-tool", "lookup", "service", keychainService, "account", account}, nil, keychainTimeout)
+		if err != nil || len(out) == 0 {
+			return "", ErrSecretNotFound
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+}
+
+// Set stores secret for account, replacing any previous one
+func (k *Keychain) Set(account, secret string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("security"); err != nil {
+			return fmt.Errorf("%w: %v", ErrKeychainUnavailable, err)
+		}
+		// Fed through interactive mode so the secret never shows up in the process list
+		command := fmt.Sprintf("add-generic-password -U -s %q -a %q -w %q\n", keychainService, account, secret)
+		_, err := runArgs([]string{"security", "-i"}, []byte(command), keychainTimeout)
+		return err
+	case "windows":
+		out, err := runArgs([]string{"powershell", "-NoProfile", "-NonInteractive", "-Command", dpapiProtectScript}, []byte(secret), keychainTimeout)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrKeychainUnavailable, err)
+		}
+		return writeFileAtomic(k.protectedFile(account), []byte(strings.TrimSpace(string(out))))
+	default:
+		if _, err := exec.LookPath("secret-tool"); err != nil {
+			return fmt.Errorf("%w: %v", ErrKeychainUnavailable, err)
+		}
+		_, err := runArgs([]string{"secret-tool", "store", "--label=VibesAndFolders " + account, "service", keychainService, "account", account}, []byte(secret), keychainTimeout)
+		return err
+	}
+}
+
+func (k *Keychain) protectedFile(account string) string {
+	return filepath.Join(k.dir, account+".dpapi")
+}
+
+// DPAPI ties the protected data to the Windows user account, like the keychains elsewhere
+const (
+	dpapiProtectScript = `Add-Type -AssemblyName System.Security; ` +
+		`$d = [Text.Encoding]::UTF8.GetBytes([Console]::In.ReadToEnd()); ` +
+		`[Convert]::ToBase64String([Security.Cryptography.ProtectedData]::Protect($d, $null, 'CurrentUser'))`
+	dpapiUnprotectScript = `Add-Type -AssemblyName System.Security; ` +
+		`$d = [Convert]::FromBase64String([Console]::In.ReadToEnd().Trim()); ` +
+		`[Text.Encoding]::UTF8.GetString([Security.Cryptography.ProtectedData]::Unprotect($d, $null, 'CurrentUser'))`
+)
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var ErrLayoutMismatch = errors.New("the folder does not look like the plan said it would")
+
+// LayoutDiff compares the files found after an execution with the layout expected from the
+// operations that succeeded. Counts alone miss a file that went missing while another appeared,
+// e.g. a sync client's conflicted copy.
+type LayoutDiff struct {
+	Unexpected []string // Found, but not there before and not put there by the plan
+	Missing    []string // Expected where the plan put them (or left them), but not found
+}
+
+func (d LayoutDiff) Empty() bool {
+	return len(d.Unexpected) == 0 && len(d.Missing) == 0
+}
+
+// listFiles returns the files under rootPath that CountFiles counts: ignored paths and sidecars
+// are left out, and bundles are listed as a single entry
+func (fs *DefaultFileService) listFiles(rootPath string) ([]string, error) {
+	var files []string
+	err := walkTree(rootPath, fs.walk, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Check if path should be ignored
+		if fs.ignoreMatcher != nil && path != rootPath {
+			relPath, err := filepath.Rel(rootPath, path)
+			if err == nil && fs.ignoreMatcher.ShouldIgnoreFile(relPath, info) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+
+		if isBundleDir(info) && path != rootPath {
+			files = append(files, path)
+			return filepath.SkipDir
+		}
+
+		if !info.IsDir() && !isSidecarFile(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// expectedLayout applies the successful operations, in order, to the files found before execution
+func expectedLayout(before []string, results []OperationResult) map[string]bool {
+	layout := make(map[string]bool, len(before))
+	for _, path := range before {
+		layout[path] = true
+	}
+
+	sep := string(filepath.Separator)
+	for _, opResult := range results {
+		if !opResult.Success {
+			continue
+		}
+		from, to := filepath.Clean(opResult.Operation.From), filepath.Clean(opResult.Operation.To)
+		if layout[from] {
+			delete(layout, from)
+			layout[to] = true
+			continue
+		}
+		// A folder: everything listed inside it moves along
+		prefix := strings.TrimSuffix(from, sep) + sep
+		var moved []string
+		for path := range layout {
+			if strings.HasPrefix(path, prefix) {
+				moved = append(moved, path)
+			}
+		}
+		for _, path := range moved {
+			delete(layout, path)
+			layout[to+sep+strings.TrimPrefix(path, prefix)] = true
+		}
+	}
+	return layout
+}
+
+// diffLayouts compares an expected layout with the files actually found
+func diffLayouts(expected map[string]bool, actual []string) LayoutDiff {
+	var diff LayoutDiff
+	found := make(map[string]bool, len(actual))
+	for _, path := range actual {
+		found[path] = true
+		if !expected[path] {
+			diff.Unexpected = append(diff.Unexpected, path)
+		}
+	}
+	for path := range expected {
+		if !found[path] {
+			diff.Missing = append(diff.Missing, path)
+		}
+	}
+	sort.Strings(diff.Unexpected)
+	sort.Strings(diff.Missing)
+	return diff
+}
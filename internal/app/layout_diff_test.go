@@ -0,0 +1,84 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpectedLayout(t *testing.T) {
+	base := filepath.Join(string(filepath.Separator), "data")
+	p := func(rel string) string { return filepath.Join(base, filepath.FromSlash(rel)) }
+	before := []string{p("a.txt"), p("b.txt"), p("album/1.jpg"), p("album/sub/2.jpg"), p("albums.txt")}
+	results := []OperationResult{
+		{Operation: FileOperation{From: p("a.txt"), To: p("docs/a.txt")}, Success: true},
+		{Operation: FileOperation{From: p("b.txt"), To: p("docs/b.txt")}, Error: errors.New("failed")},
+		{Operation: FileOperation{From: p("album"), To: p("Photos/album")}, Success: true},
+		{Operation: FileOperation{From: p("docs/a.txt"), To: p("docs/final.txt")}, Success: true}, // Chained
+	}
+
+	got := expectedLayout(before, results)
+	want := map[string]bool{
+		p("docs/final.txt"):         true,
+		p("b.txt"):                  true,
+		p("Photos/album/1.jpg"):     true,
+		p("Photos/album/sub/2.jpg"): true,
+		p("albums.txt"):             true, // Shares a prefix with the moved folder but isn't in it
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expectedLayout() = %v, want %v", got, want)
+	}
+}
+
+func TestDiffLayouts(t *testing.T) {
+	expected := map[string]bool{"/d/a.txt": true, "/d/b.txt": true}
+	diff := diffLayouts(expected, []string{"/d/a.txt", "/d/b (conflicted copy).txt"})
+	want := LayoutDiff{Unexpected: []string{"/d/b (conflicted copy).txt"}, Missing: []string{"/d/b.txt"}}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("diffLayouts() = %+v, want %+v", diff, want)
+	}
+	if diffLayouts(expected, []string{"/d/b.txt", "/d/a.txt"}).Empty() != true {
+		t.Error("expected no differences for the same files in another order")
+	}
+}
+
+func TestExecuteOperationsComparesLayout(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ops := []FileOperation{
+		{From: filepath.Join(dir, "a.txt"), To: filepath.Join(dir, "docs", "a.txt")},
+		{From: filepath.Join(dir, "b.txt"), To: filepath.Join(dir, "docs", "b.txt")},
+	}
+
+	fs := NewFileService(NewValidator(), NewLogger(false))
+	result, err := fs.ExecuteOperations(ops, dir, ExecutionOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Layout.Empty() || result.VerificationError != nil {
+		t.Errorf("expected the layout to match the plan, got %+v (%v)", result.Layout, result.VerificationError)
+	}
+
+	// Simulate what a sync client can do between the listings: a file swapped for a conflicted copy
+	filesBefore, err := fs.listFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, "docs", "b.txt"), filepath.Join(dir, "docs", "b (conflicted copy).txt")); err != nil {
+		t.Fatal(err)
+	}
+	filesAfter, err := fs.listFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff := diffLayouts(expectedLayout(filesBefore, nil), filesAfter)
+	if len(filesAfter) != len(filesBefore) || len(diff.Unexpected) != 1 || len(diff.Missing) != 1 {
+		t.Errorf("expected the same count but one unexpected and one missing file, got %+v", diff)
+	}
+}
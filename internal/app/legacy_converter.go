@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const converterTimeout = 2 * time.Minute
+
+// convertedDocument is the output of a legacy format converter: either a file written to a
+// temporary directory (e.g. LibreOffice's --convert-to) or text printed to stdout (e.g. antiword)
+type convertedDocument struct {
+	Path    string
+	Text    string
+	tempDir string
+}
+
+// Cleanup removes the converter's temporary output
+func (cd *convertedDocument) Cleanup() {
+	if cd.tempDir != "" {
+		os.RemoveAll(cd.tempDir)
+	}
+}
+
+// convertLegacyDocument runs the configured converter command on inputPath.
+// The command may use the placeholders {input}, {outdir} and {format} (the modern format to convert
+// to, e.g. "docx" for .doc); without {input} the input path is appended as the last argument.
+// If {outdir} is used, the first file the converter writes there is returned, otherwise its stdout.
+func convertLegacyDocument(command, inputPath, format string) (*convertedDocument, error) {
+	args, err := splitCommandLine(command)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &convertedDocument{}
+	usesInput := false
+	for i, arg := range args {
+		if strings.Contains(arg, "{outdir}") && result.tempDir == "" {
+			result.tempDir, err = os.MkdirTemp("", "vibesandfolders-convert-")
+			if err != nil {
+				return nil, fmt.Errorf("failed to create conversion directory: %w", err)
+			}
+		}
+		usesInput = usesInput || strings.Contains(arg, "{input}")
+		args[i] = strings.NewReplacer("{input}", inputPath, "{outdir}", result.tempDir, "{format}", format).Replace(arg)
+	}
+	if !usesInput {
+		args = append(args, inputPath)
+	}
+
+	output, err := runArgs(args, nil, converterTimeout)
+	if err != nil {
+		result.Cleanup()
+		return nil, fmt.Errorf("converter failed: %w", err)
+	}
+
+	if result.tempDir == "" {
+		result.Text = strings.TrimSpace(string(output))
+		if result.Text == "" {
+			return nil, fmt.Errorf("converter produced no text")
+		}
+		return result, nil
+	}
+
+	entries, err := os.ReadDir(result.tempDir)
+	if err != nil {
+		result.Cleanup()
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			result.Path = filepath.Join(result.tempDir, entry.Name())
+			return result, nil
+		}
+	}
+	result.Cleanup()
+	return nil, fmt.Errorf("converter wrote no output file to {outdir}")
+}
+
+// analyzeLegacyFile converts a legacy binary Office file with the configured converter and analyzes
+// the result with the matching modern-format analyzer
+func (das *DeepAnalysisService) analyzeLegacyFile(ctx context.Context, filePath, format, contentType string) (string, error) {
+	das.logger.Debug("Converting legacy file %s to %s", filePath, format)
+
+	converted, err := convertLegacyDocument(das.config.LegacyConverterCommand, filePath, format)
+	if err != nil {
+		return "", err
+	}
+	defer converted.Cleanup()
+
+	if converted.Path == "" {
+		return das.analyzeContentWithLLM(ctx, converted.Text, contentType, filepath.Base(filePath))
+	}
+
+	switch strings.ToLower(filepath.Ext(converted.Path)) {
+	case ".docx":
+		return das.analyzeDocFile(ctx, converted.Path)
+	case ".pptx":
+		return das.analyzePowerPointFile(ctx, converted.Path)
+	case ".pdf":
+		return das.analyzePDFFile(ctx, converted.Path)
+	default:
+		data, err := os.ReadFile(converted.Path)
+		if err != nil {
+			return "", err
+		}
+		return das.analyzeContentWithLLM(ctx, string(data), contentType, filepath.Base(filePath))
+	}
+}
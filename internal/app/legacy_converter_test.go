@@ -0,0 +1,43 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestConvertLegacyDocument(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+
+	input := filepath.Join(t.TempDir(), "report.doc")
+	if err := os.WriteFile(input, []byte("legacy text"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stdout converter, input appended as the last argument
+	converted, err := convertLegacyDocument("cat", input, "docx")
+	if err != nil {
+		t.Fatalf("stdout converter: %v", err)
+	}
+	if converted.Text != "legacy text" || converted.Path != "" {
+		t.Errorf("stdout converter: got %+v", converted)
+	}
+
+	// Output-directory converter, like soffice --convert-to
+	converted, err = convertLegacyDocument(`sh -c 'cp "$0" "$1/report.$2"' {input} {outdir} {format}`, input, "docx")
+	if err != nil {
+		t.Fatalf("outdir converter: %v", err)
+	}
+	defer converted.Cleanup()
+	if filepath.Base(converted.Path) != "report.docx" {
+		t.Errorf("outdir converter: expected report.docx, got %q", converted.Path)
+	}
+
+	converted.Cleanup()
+	if _, err := os.Stat(converted.Path); !os.IsNotExist(err) {
+		t.Errorf("expected Cleanup to remove converter output")
+	}
+}
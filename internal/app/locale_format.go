@@ -0,0 +1,169 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+var ErrUnknownLocale = errors.New("unknown locale")
+
+type dateLayouts struct {
+	date, dateTime string
+}
+
+// Date layouts by language, or language and region; locales not listed get ISO 8601 dates
+var localeDateLayouts = map[string]dateLayouts{
+	"en":    {"1/2/2006", "1/2/2006 3:04:05 PM"},
+	"en-GB": {"02/01/2006", "02/01/2006 15:04:05"},
+	"en-AU": {"02/01/2006", "02/01/2006 15:04:05"},
+	"en-NZ": {"02/01/2006", "02/01/2006 15:04:05"},
+	"en-IE": {"02/01/2006", "02/01/2006 15:04:05"},
+	"en-IN": {"02/01/2006", "02/01/2006 15:04:05"},
+	"en-CA": {"2006-01-02", "2006-01-02 3:04:05 PM"},
+	"de":    {"02.01.2006", "02.01.2006 15:04:05"},
+	"ru":    {"02.01.2006", "02.01.2006 15:04:05"},
+	"pl":    {"02.01.2006", "02.01.2006 15:04:05"},
+	"cs":    {"02.01.2006", "02.01.2006 15:04:05"},
+	"tr":    {"02.01.2006", "02.01.2006 15:04:05"},
+	"uk":    {"02.01.2006", "02.01.2006 15:04:05"},
+	"nb":    {"02.01.2006", "02.01.2006 15:04:05"},
+	"nn":    {"02.01.2006", "02.01.2006 15:04:05"},
+	"da":    {"02.01.2006", "02.01.2006 15.04.05"},
+	"fi":    {"2.1.2006", "2.1.2006 15.04.05"},
+	"fr":    {"02/01/2006", "02/01/2006 15:04:05"},
+	"fr-CA": {"2006-01-02", "2006-01-02 15:04:05"},
+	"es":    {"02/01/2006", "02/01/2006 15:04:05"},
+	"it":    {"02/01/2006", "02/01/2006 15:04:05"},
+	"pt":    {"02/01/2006", "02/01/2006 15:04:05"},
+	"vi":    {"02/01/2006", "15:04:05 02/01/2006"},
+	"nl":    {"02-01-2006", "02-01-2006 15:04:05"},
+	"ja":    {"2006/01/02", "2006/01/02 15:04:05"},
+	"zh":    {"2006/01/02", "2006/01/02 15:04:05"},
+	"ko":    {"2006. 01. 02.", "2006. 01. 02. 15:04:05"},
+}
+
+// LocaleFormat formats sizes and timestamps shown to the user the way a locale writes them.
+// Files meant for other programs (CSV exports, sidecars, file names) keep fixed formats.
+type LocaleFormat struct {
+	printer  *message.Printer
+	date     string
+	dateTime string
+}
+
+// ParseLocale accepts BCP 47 tags ("de-CH") and POSIX locale names ("de_CH.UTF-8")
+func ParseLocale(locale string) (language.Tag, error) {
+	name := strings.TrimSpace(locale)
+	if i := strings.IndexAny(name, ".@"); i >= 0 {
+		name = name[:i]
+	}
+	if name == "" || name == "C" || name == "POSIX" {
+		return language.Und, fmt.Errorf("%w: %q", ErrUnknownLocale, locale)
+	}
+	tag, err := language.Parse(strings.ReplaceAll(name, "_", "-"))
+	if err != nil {
+		return language.Und, fmt.Errorf("%w: %q", ErrUnknownLocale, locale)
+	}
+	return tag, nil
+}
+
+// NewLocaleFormat formats for tag; language.Und gives ISO 8601 dates and English numbers
+func NewLocaleFormat(tag language.Tag) LocaleFormat {
+	f := LocaleFormat{printer: message.NewPrinter(tag), date: "2006-01-02", dateTime: "2006-01-02 15:04:05"}
+	base, confidence := tag.Base()
+	if confidence == language.No || tag == language.Und {
+		return f
+	}
+	keys := []string{base.String()}
+	if region, confidence := tag.Region(); confidence == language.Exact {
+		keys = append([]string{base.String() + "-" + region.String()}, keys...)
+	}
+	for _, key := range keys {
+		if layouts, ok := localeDateLayouts[key]; ok {
+			f.date, f.dateTime = layouts.date, layouts.dateTime
+			break
+		}
+	}
+	return f
+}
+
+// Size formats bytes in binary units with the locale's decimal separator
+func (f LocaleFormat) Size(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return f.printer.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	units := []string{"KB", "MB", "GB", "TB", "PB", "EB"}
+	return f.printer.Sprintf("%.1f %s", float64(bytes)/float64(div), units[exp])
+}
+
+func (f LocaleFormat) Date(t time.Time) string {
+	return t.Format(f.date)
+}
+
+func (f LocaleFormat) Timestamp(t time.Time) string {
+	return t.Format(f.dateTime)
+}
+
+// localeTable picks the format from Config.Locale, falling back to the system locale
+type localeTable struct {
+	mu     sync.Mutex
+	config *Config
+	system string
+	locale string
+	format *LocaleFormat
+}
+
+var localeFormats = &localeTable{}
+
+// UseLocale makes FormatSize, FormatDate and FormatTimestamp follow config's Locale, or
+// systemLocale while it is empty. Until it is called they use ISO 8601 dates.
+func UseLocale(config *Config, systemLocale string) {
+	localeFormats.mu.Lock()
+	defer localeFormats.mu.Unlock()
+	localeFormats.config = config
+	localeFormats.system = systemLocale
+	localeFormats.format = nil
+}
+
+// CurrentLocaleFormat is the format UseLocale set up
+func CurrentLocaleFormat() LocaleFormat {
+	t := localeFormats
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	locale := t.system
+	if t.config != nil && strings.TrimSpace(t.config.Locale) != "" {
+		locale = t.config.Locale
+	}
+	if t.format == nil || locale != t.locale {
+		// Invalid overrides are rejected when the config is saved; an unusable system locale means ISO dates
+		tag, _ := ParseLocale(locale)
+		format := NewLocaleFormat(tag)
+		t.format = &format
+		t.locale = locale
+	}
+	return *t.format
+}
+
+func FormatSize(bytes int64) string {
+	return CurrentLocaleFormat().Size(bytes)
+}
+
+func FormatDate(t time.Time) string {
+	return CurrentLocaleFormat().Date(t)
+}
+
+func FormatTimestamp(t time.Time) string {
+	return CurrentLocaleFormat().Timestamp(t)
+}
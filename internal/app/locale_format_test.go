@@ -0,0 +1,97 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+func TestLocaleFormat(t *testing.T) {
+	at := time.Date(2024, 3, 7, 14, 5, 9, 0, time.UTC)
+	tests := []struct {
+		locale        string
+		wantSize      string
+		wantDate      string
+		wantTimestamp string
+	}{
+		{"en-US", "1.5 MB", "3/7/2024", "3/7/2024 2:05:09 PM"},
+		{"en_GB.UTF-8", "1.5 MB", "07/03/2024", "07/03/2024 14:05:09"},
+		{"en-AU", "1.5 MB", "07/03/2024", "07/03/2024 14:05:09"},
+		{"de_DE", "1,5 MB", "07.03.2024", "07.03.2024 14:05:09"},
+		{"de-AT", "1,5 MB", "07.03.2024", "07.03.2024 14:05:09"},
+		{"fr-CA", "1,5 MB", "2024-03-07", "2024-03-07 14:05:09"},
+		{"fr-BE", "1,5 MB", "07/03/2024", "07/03/2024 14:05:09"},
+		{"nb_NO", "1,5 MB", "07.03.2024", "07.03.2024 14:05:09"},
+		{"ja-JP", "1.5 MB", "2024/03/07", "2024/03/07 14:05:09"},
+		{"sw", "1.5 MB", "2024-03-07", "2024-03-07 14:05:09"}, // No date layout: ISO 8601
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			tag, err := ParseLocale(tt.locale)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f := NewLocaleFormat(tag)
+			if got := f.Size(1536 * 1024); got != tt.wantSize {
+				t.Errorf("Size() = %q, want %q", got, tt.wantSize)
+			}
+			if got := f.Date(at); got != tt.wantDate {
+				t.Errorf("Date() = %q, want %q", got, tt.wantDate)
+			}
+			if got := f.Timestamp(at); got != tt.wantTimestamp {
+				t.Errorf("Timestamp() = %q, want %q", got, tt.wantTimestamp)
+			}
+		})
+	}
+}
+
+func TestLocaleFormatSize(t *testing.T) {
+	f := NewLocaleFormat(language.Und)
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{0, "0 B"},
+		{1023, "1,023 B"},
+		{1024, "1.0 KB"},
+		{5 * 1024 * 1024 * 1024, "5.0 GB"},
+	}
+	for _, tt := range tests {
+		if got := f.Size(tt.bytes); got != tt.want {
+			t.Errorf("Size(%d) = %q, want %q", tt.bytes, got, tt.want)
+		}
+	}
+}
+
+func TestParseLocaleRejects(t *testing.T) {
+	for _, locale := range []string{"", "C", "POSIX.UTF-8", "not a locale"} {
+		if _, err := ParseLocale(locale); !errors.Is(err, ErrUnknownLocale) {
+			t.Errorf("ParseLocale(%q) = %v, want ErrUnknownLocale", locale, err)
+		}
+	}
+}
+
+func TestUseLocale(t *testing.T) {
+	defer UseLocale(nil, "")
+	at := time.Date(2024, 3, 7, 14, 5, 9, 0, time.UTC)
+
+	config := &Config{}
+	UseLocale(config, "de_DE.UTF-8")
+	if got := FormatDate(at); got != "07.03.2024" {
+		t.Errorf("system locale: FormatDate() = %q", got)
+	}
+
+	// The configured locale wins, and changes apply without calling UseLocale again
+	config.Locale = "en-US"
+	if got := FormatDate(at); got != "3/7/2024" {
+		t.Errorf("configured locale: FormatDate() = %q", got)
+	}
+
+	UseLocale(config, "C")
+	config.Locale = ""
+	if got := FormatTimestamp(at); got != "2024-03-07 14:05:09" {
+		t.Errorf("unusable system locale: FormatTimestamp() = %q", got)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package app
+
+import "errors"
+
+var errLocationCheckUnsupported = errors.New("location checks are not supported on this system")
+
+// locationWritable can't tell here; execution reports read-only locations instead
+func locationWritable(string) (bool, error) {
+	return true, errLocationCheckUnsupported
+}
+
+// locationFreeSpace can't tell here; execution reports full disks instead
+func locationFreeSpace(string) (uint64, error) {
+	return 0, errLocationCheckUnsupported
+}
@@ -0,0 +1,32 @@
+//go:build linux || darwin || freebsd
+
+package app
+
+import (
+	"errors"
+	"syscall"
+)
+
+// accessWrite is W_OK for access(2)
+const accessWrite = 0x2
+
+// locationWritable asks the kernel whether dir can be written to, without writing anything
+func locationWritable(dir string) (bool, error) {
+	err := syscall.Access(dir, accessWrite)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, syscall.EACCES) || errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EROFS):
+		return false, nil
+	}
+	return true, err
+}
+
+// locationFreeSpace returns the bytes available to this user on dir's filesystem
+func locationFreeSpace(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,36 @@
+//go:build windows
+
+package app
+
+import "golang.org/x/sys/windows"
+
+// locationWritable reports whether dir's volume is mounted read-only. Folder ACLs are left to
+// execution: checking them would take a write.
+func locationWritable(dir string) (bool, error) {
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return true, err
+	}
+	root := make([]uint16, windows.MAX_PATH+1)
+	if err := windows.GetVolumePathName(path, &root[0], uint32(len(root))); err != nil {
+		return true, err
+	}
+	var flags uint32
+	if err := windows.GetVolumeInformation(&root[0], nil, 0, nil, nil, &flags, nil, 0); err != nil {
+		return true, err
+	}
+	return flags&windows.FILE_READ_ONLY_VOLUME == 0, nil
+}
+
+// locationFreeSpace returns the bytes available to this user (after quotas) on dir's volume
+func locationFreeSpace(dir string) (uint64, error) {
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+	var free uint64
+	if err := windows.GetDiskFreeSpaceEx(path, &free, nil, nil); err != nil {
+		return 0, err
+	}
+	return free, nil
+}
@@ -1,36 +1,256 @@
 package app
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"os"
 	"strings"
+	"sync"
 )
 
+// LogLevel is the least severe kind of line a logger writes
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelError
+	LogLevelOff
+)
+
+var logLevelNames = [...]string{"debug", "info", "error", "off"}
+
+func (level LogLevel) String() string {
+	if level < LogLevelDebug || level > LogLevelOff {
+		return fmt.Sprintf("LogLevel(%d)", int(level))
+	}
+	return logLevelNames[level]
+}
+
+var (
+	ErrUnknownLogLevel = errors.New("unknown log level")
+	ErrNoLogFile       = errors.New("no log file path set")
+)
+
+// ParseLogLevel accepts the names used in LogSettings.Level
+func ParseLogLevel(name string) (LogLevel, error) {
+	for i, levelName := range logLevelNames {
+		if strings.EqualFold(strings.TrimSpace(name), levelName) {
+			return LogLevel(i), nil
+		}
+	}
+	return LogLevelDebug, fmt.Errorf("%w: %q", ErrUnknownLogLevel, name)
+}
+
+// Subsystems whose level and destinations are configured separately in Config.Logging.
+// LogSubsystemApp covers everything not in one of the others, such as planning.
+const (
+	LogSubsystemApp       = "app"
+	LogSubsystemHTTP      = "http"
+	LogSubsystemIndex     = "index"
+	LogSubsystemExecution = "execution"
+	LogSubsystemUI        = "ui"
+)
+
+var LogSubsystems = []string{LogSubsystemApp, LogSubsystemHTTP, LogSubsystemIndex, LogSubsystemExecution, LogSubsystemUI}
+
+// LogSettings says how much a subsystem logs and where to
+type LogSettings struct {
+	Level   string `json:"level"` // A LogLevel name: debug, info, error or off
+	Console bool   `json:"console"`
+	File    bool   `json:"file"` // Append to Config.LogFilePath
+}
+
+// Logger writes leveled lines to the console and optionally a log file. The logger made by
+// NewLogger stands for LogSubsystemApp; For returns the logger of another subsystem, and
+// Configure on any of them reconfigures all of them.
 type Logger struct {
-	debugEnabled bool
+	root      *Logger
+	subsystem string
+
+	mu      sync.RWMutex
+	level   LogLevel
+	console bool
+	file    *log.Logger // nil unless this subsystem logs to the file
+
+	// Only used on the root
+	children map[string]*Logger
+	logFile  io.Closer
 }
 
 func NewLogger(debugEnabled bool) *Logger {
-	return &Logger{debugEnabled: debugEnabled}
+	level := LogLevelInfo
+	if debugEnabled {
+		level = LogLevelDebug
+	}
+	l := &Logger{subsystem: LogSubsystemApp, level: level, console: true}
+	l.root = l
+	return l
 }
 
-func (l *Logger) Debug(format string, args ...interface{}) {
-	if l.debugEnabled {
-		log.Printf("[DEBUG] "+format, args...)
+// For returns the logger of subsystem, which starts out with the same settings as this one
+func (l *Logger) For(subsystem string) *Logger {
+	root := l.root
+	if subsystem == LogSubsystemApp {
+		return root
+	}
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	if child, ok := root.children[subsystem]; ok {
+		return child
 	}
+	child := &Logger{root: root, subsystem: subsystem, level: root.level, console: root.console, file: root.file}
+	if root.children == nil {
+		root.children = make(map[string]*Logger)
+	}
+	root.children[subsystem] = child
+	return child
+}
+
+// Configure applies settings to every subsystem's logger; subsystems without settings keep
+// logging everything to the console. Log files are appended to at filePath, which is only
+// opened if some subsystem logs to it.
+func (l *Logger) Configure(settings map[string]LogSettings, filePath string) error {
+	root := l.root
+	for _, subsystem := range LogSubsystems {
+		if s, ok := settings[subsystem]; ok {
+			if _, err := ParseLogLevel(s.Level); err != nil {
+				return fmt.Errorf("%s: %w", subsystem, err)
+			}
+		}
+	}
+
+	var fileLogger *log.Logger
+	var logFile io.Closer
+	if subsystems := fileSubsystems(settings); len(subsystems) > 0 {
+		if filePath == "" {
+			return fmt.Errorf("%w for %s", ErrNoLogFile, strings.Join(subsystems, ", "))
+		}
+		f, err := os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %w", err)
+		}
+		fileLogger = log.New(f, "", log.LstdFlags)
+		logFile = f
+	}
+
+	apply := func(logger *Logger) {
+		s, ok := settings[logger.subsystem]
+		level, err := ParseLogLevel(s.Level)
+		if !ok || err != nil {
+			s = LogSettings{Console: true}
+			level = LogLevelDebug
+		}
+		logger.mu.Lock()
+		defer logger.mu.Unlock()
+		logger.level = level
+		logger.console = s.Console
+		logger.file = nil
+		if s.File {
+			logger.file = fileLogger
+		}
+	}
+
+	for _, subsystem := range LogSubsystems {
+		root.For(subsystem)
+	}
+	root.mu.Lock()
+	children := make([]*Logger, 0, len(root.children))
+	for _, child := range root.children {
+		children = append(children, child)
+	}
+	previous := root.logFile
+	root.logFile = logFile
+	root.mu.Unlock()
+
+	apply(root)
+	for _, child := range children {
+		apply(child)
+	}
+	if previous != nil {
+		previous.Close()
+	}
+	return nil
+}
+
+// fileSubsystems lists the subsystems that log to the file, in LogSubsystems order
+func fileSubsystems(settings map[string]LogSettings) []string {
+	var subsystems []string
+	for _, subsystem := range LogSubsystems {
+		if settings[subsystem].File {
+			subsystems = append(subsystems, subsystem)
+		}
+	}
+	return subsystems
+}
+
+// Close closes the log file, if any
+func (l *Logger) Close() error {
+	root := l.root
+	root.mu.Lock()
+	logFile := root.logFile
+	root.logFile = nil
+	root.mu.Unlock()
+	if logFile == nil {
+		return nil
+	}
+	return logFile.Close()
+}
+
+// Enabled reports whether lines of level are written anywhere
+func (l *Logger) Enabled(level LogLevel) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return level >= l.level && level != LogLevelOff && (l.console || l.file != nil)
+}
+
+func (l *Logger) logf(level LogLevel, format string, args ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	prefix := "[" + strings.ToUpper(level.String()) + "] "
+	if l.subsystem != LogSubsystemApp {
+		prefix += "[" + l.subsystem + "] "
+	}
+	line := prefix + fmt.Sprintf(format, args...)
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.console {
+		log.Print(line)
+	}
+	if l.file != nil {
+		l.file.Print(line)
+	}
+}
+
+func (l *Logger) Debug(format string, args ...interface{}) {
+	l.logf(LogLevelDebug, format, args...)
 }
 
 func (l *Logger) Info(format string, args ...interface{}) {
-	log.Printf("[INFO] "+format, args...)
+	l.logf(LogLevelInfo, format, args...)
 }
 
 func (l *Logger) Error(format string, args ...interface{}) {
-	log.Printf("[ERROR] "+format, args...)
+	l.logf(LogLevelError, format, args...)
 }
 
 func (l *Logger) DebugSection(title string, content string) {
-	if l.debugEnabled {
-		fmt.Printf("=== %s ===\n%s\n%s\n", title, content, strings.Repeat("=", len(title)+8))
+	if !l.Enabled(LogLevelDebug) {
+		return
+	}
+	section := fmt.Sprintf("=== %s ===\n%s\n%s\n", title, content, strings.Repeat("=", len(title)+8))
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.console {
+		fmt.Print(section)
+	}
+	if l.file != nil {
+		l.file.Print(section)
 	}
 }
 
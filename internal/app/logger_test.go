@@ -0,0 +1,100 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoggerConfigure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	logger := NewLogger(true)
+	httpLogger := logger.For(LogSubsystemHTTP)
+
+	err := logger.Configure(map[string]LogSettings{
+		LogSubsystemApp:   {Level: "off"},
+		LogSubsystemHTTP:  {Level: "info", File: true},
+		LogSubsystemIndex: {Level: "error", File: true},
+	}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Loggers handed out before and after Configure both follow it
+	indexLogger := logger.For(LogSubsystemIndex)
+	if logger.For(LogSubsystemHTTP) != httpLogger {
+		t.Fatal("For returned a new logger for a known subsystem")
+	}
+
+	logger.Error("general error")
+	httpLogger.Debug("http debug")
+	httpLogger.Info("http info")
+	indexLogger.Info("index info")
+	indexLogger.Error("index error")
+	httpLogger.DebugSection("Prompt", "http section")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{"[INFO] [http] http info", "[ERROR] [index] index error"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("log file is missing %q:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"general error", "http debug", "index info", "http section"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("log file has %q below its subsystem's level:\n%s", unwanted, got)
+		}
+	}
+
+	if logger.Enabled(LogLevelError) {
+		t.Error("app logger is off but reports errors as enabled")
+	}
+	if execution := logger.For(LogSubsystemExecution); !execution.Enabled(LogLevelDebug) {
+		t.Error("subsystem without settings should keep logging everything")
+	}
+}
+
+func TestLoggerConfigureErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]LogSettings
+		path     string
+		wantErr  error
+	}{
+		{"unknown level", map[string]LogSettings{LogSubsystemUI: {Level: "verbose", Console: true}}, "", ErrUnknownLogLevel},
+		{"file without path", map[string]LogSettings{LogSubsystemHTTP: {Level: "info", File: true}}, "", ErrNoLogFile},
+		{"console only needs no path", map[string]LogSettings{LogSubsystemHTTP: {Level: "info", Console: true}}, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewLogger(false).Configure(tt.settings, tt.path)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil) != (err == nil) {
+				t.Errorf("Configure() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyLoggingDefaults(t *testing.T) {
+	config := &Config{Logging: map[string]LogSettings{
+		LogSubsystemHTTP: {Level: "error", File: true},
+		LogSubsystemUI:   {Level: "loud"},
+	}}
+	applyLoggingDefaults(config)
+
+	if got := config.Logging[LogSubsystemHTTP]; got != (LogSettings{Level: "error", File: true}) {
+		t.Errorf("valid settings were replaced: %+v", got)
+	}
+	for _, subsystem := range []string{LogSubsystemApp, LogSubsystemIndex, LogSubsystemExecution, LogSubsystemUI} {
+		if got := config.Logging[subsystem]; got != (LogSettings{Level: "debug", Console: true}) {
+			t.Errorf("%s: got %+v, want debug to the console", subsystem, got)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// manualOperationReason marks operations the user added to a plan by hand
+const manualOperationReason = "Added manually"
+
+var (
+	ErrNotScanned        = errors.New("not in the scanned structure")
+	ErrAlreadyPlanned    = errors.New("already moved by the plan")
+	ErrDestinationTaken  = errors.New("another operation in the plan moves a file there")
+	ErrEmptyManualTarget = errors.New("both the file to move and its destination are needed")
+)
+
+// NewManualOperation builds a move to append to a plan from paths typed relative to basePath.
+// The source must have been listed in the scanned structure and not be moved by the plan already,
+// either itself or along with a folder it is in. A destination ending in a slash, or naming a
+// scanned folder, moves the file into that folder under its current name. The destination is
+// checked like an edited one, see EditDestination.
+func NewManualOperation(basePath, from, to string, grounding *StructureGrounding, plan []FileOperation, constraints PlanConstraints) (FileOperation, error) {
+	from = cleanManualPath(from)
+	folderTarget := strings.HasSuffix(strings.TrimSpace(to), "/")
+	to = cleanManualPath(to)
+	if from == "" || (to == "" && !folderTarget) {
+		return FileOperation{}, ErrEmptyManualTarget
+	}
+	if !grounding.paths[from] {
+		return FileOperation{}, fmt.Errorf("%w: %s", ErrNotScanned, from)
+	}
+	if folderTarget || grounding.IsFolder(to) {
+		to = path.Join(to, path.Base(from))
+	}
+
+	fromPath, err := JoinStoragePath(basePath, from)
+	if err != nil {
+		return FileOperation{}, err
+	}
+	toPath, err := JoinStoragePath(basePath, to)
+	if err != nil {
+		return FileOperation{}, err
+	}
+	op := FileOperation{
+		From:   fromPath,
+		To:     toPath,
+		Reason: manualOperationReason,
+	}
+	for _, planned := range plan {
+		plannedFrom := relativeSlashPath(basePath, planned.From)
+		if plannedFrom == from || strings.HasPrefix(from, plannedFrom+"/") {
+			return FileOperation{}, fmt.Errorf("%w: %s", ErrAlreadyPlanned, plannedFrom)
+		}
+	}
+	if err := checkDestination(basePath, plan, -1, op, grounding, constraints); err != nil {
+		return FileOperation{}, err
+	}
+	return op, nil
+}
+
+// cleanManualPath turns a typed path into the relative, slash-separated form the structure uses
+func cleanManualPath(p string) string {
+	p = strings.TrimSpace(strings.ReplaceAll(p, "\\", "/"))
+	if p == "" {
+		return ""
+	}
+	p = strings.TrimPrefix(path.Clean(p), "./")
+	if p == "." || p == "/" {
+		return ""
+	}
+	return strings.Trim(p, "/")
+}
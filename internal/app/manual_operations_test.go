@@ -0,0 +1,87 @@
+package app
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNewManualOperation(t *testing.T) {
+	base := filepath.Join(string(filepath.Separator), "home", "me", "Downloads")
+	g := NewStructureGrounding(`docs/
+docs/report.pdf (4096 bytes)
+photos/
+photos/cat.jpg (10 bytes)
+notes.txt (12 bytes)
+`)
+	plan := []FileOperation{
+		{From: filepath.Join(base, "photos"), To: filepath.Join(base, "media", "photos")},
+		{From: filepath.Join(base, "docs", "report.pdf"), To: filepath.Join(base, "work", "report.pdf")},
+	}
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		wantTo  string
+		wantErr error
+	}{
+		{"explicit destination", "notes.txt", "archive/notes.txt", "archive/notes.txt", nil},
+		{"into scanned folder", "notes.txt", "docs", "docs/notes.txt", nil},
+		{"into new folder", "./notes.txt", "text/", "text/notes.txt", nil},
+		{"backslashes", "notes.txt", `archive\old\notes.txt`, "archive/old/notes.txt", nil},
+		{"not scanned", "invented.txt", "docs/", "", ErrNotScanned},
+		{"already moved", "docs/report.pdf", "old/", "", ErrAlreadyPlanned},
+		{"inside moved folder", "photos/cat.jpg", "cats/", "", ErrAlreadyPlanned},
+		{"destination taken", "notes.txt", "work/report.pdf", "", ErrDestinationTaken},
+		{"same place", "notes.txt", "notes.txt", "", ErrDestinationExists},
+		{"missing destination", "notes.txt", " ", "", ErrEmptyManualTarget},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op, err := NewManualOperation(base, tt.from, tt.to, g, plan, PlanConstraints{})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := filepath.Join(base, filepath.FromSlash(tt.wantTo)); op.To != want {
+				t.Errorf("To = %q, want %q", op.To, want)
+			}
+			if op.Reason != manualOperationReason {
+				t.Errorf("Reason = %q", op.Reason)
+			}
+		})
+	}
+}
+
+func TestStructureGroundingComplete(t *testing.T) {
+	g := NewStructureGrounding(`Docs/
+Docs/report.pdf (4096 bytes)
+Downloads/
+dinner.txt (12 bytes)
+photos/
+`)
+	tests := []struct {
+		prefix      string
+		foldersOnly bool
+		limit       int
+		want        []string
+	}{
+		{"d", false, 0, []string{"Docs/", "Downloads/", "Docs/report.pdf", "dinner.txt"}},
+		{"docs/", false, 0, []string{"Docs/report.pdf"}},
+		{"d", true, 0, []string{"Docs/", "Downloads/"}},
+		{"", true, 2, []string{"Docs/", "Downloads/"}},
+		{"x", false, 0, nil},
+	}
+	for _, tt := range tests {
+		if got := g.Complete(tt.prefix, tt.foldersOnly, tt.limit); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Complete(%q, %v, %d) = %v, want %v", tt.prefix, tt.foldersOnly, tt.limit, got, tt.want)
+		}
+	}
+}
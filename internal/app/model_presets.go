@@ -0,0 +1,93 @@
+package app
+
+// ModelPreset bundles a model with a system prompt and sampling parameters known to work well
+// for it, so new users get good plans without tuning prompts themselves.
+type ModelPreset struct {
+	Name         string
+	Description  string
+	Endpoint     string
+	Model        string
+	SystemPrompt string
+	Temperature  *float64 // nil leaves it to the provider
+	MaxTokens    int      // 0 uses defaultMaxTokens
+}
+
+// Smaller local models drift from the output format unless it is spelled out and repeated
+const localSystemPrompt = `You are a file organization assistant. You reply ONLY with JSON Lines.
+
+Each line of your reply is one JSON object: {"from": "<path>", "to": "<path>"}
+- "from" is a path relative to the base folder and must appear in the listing.
+- "to" is the destination path relative to the base folder.
+- List only files that should move or be renamed.
+- Keep folder names consistent with the existing ones.
+- Leave folders that are already well organized alone.
+
+Example reply:
+{"from": "IMG_1234.jpg", "to": "photos/IMG_1234.jpg"}
+{"from": "invoice.pdf", "to": "documents/invoices/invoice.pdf"}
+
+Do not write explanations, headings or markdown code fences. Output nothing except JSON lines.`
+
+func presetTemperature(t float64) *float64 {
+	return &t
+}
+
+// ModelPresets are the presets offered in the configuration window
+var ModelPresets = []ModelPreset{
+	{
+		Name:         "Kimi K2 (OpenRouter)",
+		Description:  "The default: fast and cheap with large folders",
+		Endpoint:     defaultEndpoint,
+		Model:        defaultModel,
+		SystemPrompt: defaultSystemPrompt,
+		Temperature:  presetTemperature(0.6),
+		MaxTokens:    defaultMaxTokens,
+	},
+	{
+		Name:         "GPT-4o (OpenAI)",
+		Description:  "Careful renames; needs an OpenAI API key",
+		Endpoint:     "https://api.openai.com/v1/chat/completions",
+		Model:        "gpt-4o",
+		SystemPrompt: defaultSystemPrompt,
+		Temperature:  presetTemperature(0.2),
+		MaxTokens:    defaultMaxTokens,
+	},
+	{
+		Name:         "Llama 3 (local Ollama)",
+		Description:  "Runs offline through Ollama; any API key is accepted",
+		Endpoint:     "http://localhost:11434/v1/chat/completions",
+		Model:        "llama3.1:8b",
+		SystemPrompt: localSystemPrompt,
+		Temperature:  presetTemperature(0.1),
+		MaxTokens:    4096,
+	},
+}
+
+// FindModelPreset returns the preset with the given name
+func FindModelPreset(name string) (ModelPreset, bool) {
+	for _, preset := range ModelPresets {
+		if preset.Name == name {
+			return preset, true
+		}
+	}
+	return ModelPreset{}, false
+}
+
+// ApplyModelPreset replaces the endpoint, model, organization prompt and sampling parameters
+// with the preset's. The API key and analysis prompts are kept.
+func (c *Config) ApplyModelPreset(preset ModelPreset) {
+	c.Endpoint = preset.Endpoint
+	c.Model = preset.Model
+	c.SystemPrompt = preset.SystemPrompt
+	c.MarkPromptBaseline("system_prompt")
+	c.Temperature = preset.Temperature
+	c.MaxTokens = preset.MaxTokens
+}
+
+// planMaxTokens is the max_tokens sent with plan requests
+func (c *Config) planMaxTokens() int {
+	if c.MaxTokens > 0 {
+		return c.MaxTokens
+	}
+	return defaultMaxTokens
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestApplyModelPreset(t *testing.T) {
+	config := &Config{}
+	loadDefaults(config)
+	config.APIKey = "sk-test"
+	config.SystemPrompt = "my own prompt"
+
+	preset, ok := FindModelPreset("Llama 3 (local Ollama)")
+	if !ok {
+		t.Fatal("expected the local preset to exist")
+	}
+	config.ApplyModelPreset(preset)
+
+	if config.Endpoint != preset.Endpoint || config.Model != preset.Model || config.SystemPrompt != localSystemPrompt {
+		t.Errorf("preset not applied: %s %s", config.Endpoint, config.Model)
+	}
+	if config.Temperature == nil || *config.Temperature != 0.1 || config.MaxTokens != 4096 {
+		t.Errorf("expected preset parameters, got %v %d", config.Temperature, config.MaxTokens)
+	}
+	if config.APIKey != "sk-test" {
+		t.Error("expected the API key to be kept")
+	}
+	if field, _ := config.PromptField("system_prompt"); config.DefaultChanged(field) {
+		t.Error("expected the preset prompt to be baselined against the current default")
+	}
+
+	if _, ok := FindModelPreset("No Such Model"); ok {
+		t.Error("expected unknown presets not to be found")
+	}
+}
+
+func TestGetSuggestions_SendsPresetParameters(t *testing.T) {
+	var request map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &request)
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name            string
+		preset          string
+		wantTemperature any
+		wantMaxTokens   float64
+	}{
+		{"no preset", "", nil, defaultMaxTokens},
+		{"gpt-4o", "GPT-4o (OpenAI)", 0.2, defaultMaxTokens},
+		{"local", "Llama 3 (local Ollama)", 0.1, 4096},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{}
+			loadDefaults(config)
+			if tt.preset != "" {
+				preset, _ := FindModelPreset(tt.preset)
+				config.ApplyModelPreset(preset)
+			}
+			config.Endpoint = server.URL
+
+			logger := NewLogger(false)
+			service := NewOpenAIService(config, NewHTTPClient(config, logger), logger)
+			request = nil
+			if _, err := service.GetSuggestions(context.Background(), "a.txt", "", t.TempDir(), nil); err != nil {
+				t.Fatal(err)
+			}
+
+			if request["model"] != config.Model {
+				t.Errorf("expected model %s, got %v", config.Model, request["model"])
+			}
+			if request["temperature"] != tt.wantTemperature {
+				t.Errorf("expected temperature %v, got %v", tt.wantTemperature, request["temperature"])
+			}
+			if request["max_tokens"] != tt.wantMaxTokens {
+				t.Errorf("expected max_tokens %v, got %v", tt.wantMaxTokens, request["max_tokens"])
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Folder naming styles
+const (
+	NamingStyleNone  = ""           // Folder names are used as the model wrote them
+	NamingStyleKebab = "kebab-case" // summer-trip
+	NamingStyleSnake = "snake_case" // summer_trip
+	NamingStyleTitle = "title-case" // Summer Trip
+	NamingStyleLower = "lower-case" // summer trip
+)
+
+var (
+	// Leading dates like 2024-05-01, 2024_05_01, 2024.05, 20240501
+	separatedDatePrefix = regexp.MustCompile(`^(\d{4})[-_. ](\d{1,2})(?:[-_. ](\d{1,2}))?(?:[-_. ]+|$)`)
+	compactDatePrefix   = regexp.MustCompile(`^(\d{4})(\d{2})(\d{2})(?:[-_. ]+|$)`)
+	wordSeparators      = regexp.MustCompile(`[\s_-]+`)
+)
+
+// NormalizeFolderName applies style to a single folder name. With normalizeDates, a leading
+// date is rewritten as YYYY-MM-DD (or YYYY-MM) so date-prefixed folders sort correctly.
+func NormalizeFolderName(name, style string, normalizeDates bool) string {
+	datePrefix, rest := "", name
+	if normalizeDates {
+		datePrefix, rest = splitDatePrefix(name)
+	}
+
+	rest = applyNamingStyle(rest, style)
+	if datePrefix == "" {
+		return rest
+	}
+	if rest == "" {
+		return datePrefix
+	}
+
+	separator := " "
+	switch style {
+	case NamingStyleKebab:
+		separator = "-"
+	case NamingStyleSnake:
+		separator = "_"
+	}
+	return datePrefix + separator + rest
+}
+
+// splitDatePrefix returns a leading date in ISO form and the rest of the name. Names that
+// don't start with a valid date are returned unchanged.
+func splitDatePrefix(name string) (string, string) {
+	for _, pattern := range []*regexp.Regexp{separatedDatePrefix, compactDatePrefix} {
+		match := pattern.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+		month, _ := strconv.Atoi(match[2])
+		if month < 1 || month > 12 {
+			continue
+		}
+		date := fmt.Sprintf("%s-%02d", match[1], month)
+		if match[3] != "" {
+			day, _ := strconv.Atoi(match[3])
+			if day < 1 || day > 31 {
+				continue
+			}
+			date += fmt.Sprintf("-%02d", day)
+		}
+		return date, name[len(match[0]):]
+	}
+	return "", name
+}
+
+func applyNamingStyle(name, style string) string {
+	switch style {
+	case NamingStyleKebab, NamingStyleSnake:
+		separator := "-"
+		if style == NamingStyleSnake {
+			separator = "_"
+		}
+		words := splitWords(name)
+		for i, word := range words {
+			words[i] = strings.ToLower(word)
+		}
+		return strings.Join(words, separator)
+	case NamingStyleTitle:
+		words := splitWords(name)
+		for i, word := range words {
+			runes := []rune(word)
+			runes[0] = unicode.ToUpper(runes[0])
+			words[i] = string(runes)
+		}
+		return strings.Join(words, " ")
+	case NamingStyleLower:
+		return strings.ToLower(name)
+	default:
+		return name
+	}
+}
+
+// splitWords splits on spaces, dashes, underscores and camelCase boundaries
+func splitWords(name string) []string {
+	var words []string
+	for _, part := range wordSeparators.Split(name, -1) {
+		if part == "" {
+			continue
+		}
+		runes := []rune(part)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			// "summerTrip" -> summer, Trip; "PDFFiles" -> PDF, Files
+			lowerToUpper := unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i])
+			acronymEnd := i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i+1])
+			if lowerToUpper || acronymEnd {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// applyNamingConvention normalizes the folders of op.To that don't exist yet with the configured style
+func (s *OpenAIService) applyNamingConvention(basePath string, op FileOperation) FileOperation {
+	normalized := normalizeNewFolders(basePath, op, s.config.FolderNamingStyle, s.config.NormalizeDatePrefixes)
+	if normalized.To != op.To {
+		s.logger.Debug("Naming convention: %s -> %s", relativeSlashPath(basePath, op.To), relativeSlashPath(basePath, normalized.To))
+	}
+	return normalized
+}
+
+// normalizeNewFolders applies style to the folders of op.To that don't exist yet. Existing folders
+// keep their names so plans don't create near-duplicates of them.
+func normalizeNewFolders(basePath string, op FileOperation, style string, normalizeDates bool) FileOperation {
+	if style == NamingStyleNone && !normalizeDates {
+		return op
+	}
+
+	rel := relativeSlashPath(basePath, op.To)
+	parts := strings.Split(rel, "/")
+	if len(parts) < 2 || parts[0] == ".." {
+		return op
+	}
+
+	checkDisk := !IsObjectStoragePath(basePath)
+	current := basePath
+	for i, folder := range parts[:len(parts)-1] {
+		if checkDisk {
+			candidate := filepath.Join(current, folder)
+			if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+				current = candidate
+				continue
+			}
+			// Nothing below a new folder can exist either
+			checkDisk = false
+		}
+		if normalized := NormalizeFolderName(folder, style, normalizeDates); normalized != "" {
+			parts[i] = normalized
+		}
+	}
+
+	if to, err := JoinStoragePath(basePath, strings.Join(parts, "/")); err == nil {
+		op.To = to
+	}
+	return op
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeFolderName(t *testing.T) {
+	tests := []struct {
+		name           string
+		style          string
+		normalizeDates bool
+		want           string
+	}{
+		{"Summer Trip", NamingStyleKebab, false, "summer-trip"},
+		{"summerTrip_photos", NamingStyleSnake, false, "summer_trip_photos"},
+		{"PDFFiles", NamingStyleKebab, false, "pdf-files"},
+		{"tax-returns 2024", NamingStyleTitle, false, "Tax Returns 2024"},
+		{"Old iPhone Backups", NamingStyleLower, false, "old iphone backups"},
+		{"Keep As Is", NamingStyleNone, false, "Keep As Is"},
+		{"2024_5_1 Beach Day", NamingStyleKebab, true, "2024-05-01-beach-day"},
+		{"20240501_beach", NamingStyleTitle, true, "2024-05-01 Beach"},
+		{"2024.03", NamingStyleNone, true, "2024-03"},
+		{"2024-13 not a month", NamingStyleNone, true, "2024-13 not a month"},
+		{"1999 Songs", NamingStyleNone, true, "1999 Songs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeFolderName(tt.name, tt.style, tt.normalizeDates); got != tt.want {
+				t.Errorf("NormalizeFolderName(%q, %q, %v) = %q, want %q", tt.name, tt.style, tt.normalizeDates, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyNamingConventionKeepsExistingFolders(t *testing.T) {
+	base := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(base, "My Photos"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewOpenAIService(&Config{FolderNamingStyle: NamingStyleKebab}, nil, NewLogger(false))
+	op := service.applyNamingConvention(base, FileOperation{
+		From: filepath.Join(base, "IMG_1.jpg"),
+		To:   filepath.Join(base, "My Photos", "Summer Trip", "IMG_1.jpg"),
+	})
+
+	want := filepath.Join(base, "My Photos", "summer-trip", "IMG_1.jpg")
+	if op.To != want {
+		t.Errorf("To = %q, want %q", op.To, want)
+	}
+}
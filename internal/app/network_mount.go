@@ -0,0 +1,170 @@
+package app
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ErrPathOffline is returned when a directory stops responding (e.g. a network share dropped)
+var ErrPathOffline = errors.New("location is offline or unreachable")
+
+const defaultReachabilityTimeout = 5 * time.Second
+
+// networkFSTypes lists filesystem types that are backed by a remote server
+var networkFSTypes = map[string]bool{
+	"nfs":         true,
+	"nfs4":        true,
+	"cifs":        true,
+	"smbfs":       true,
+	"smb3":        true,
+	"afpfs":       true,
+	"webdav":      true,
+	"davfs":       true,
+	"9p":          true,
+	"fuse.sshfs":  true,
+	"sshfs":       true,
+	"fuse.rclone": true,
+}
+
+// OfflineDecision is the user's answer when the target location goes offline mid-execution
+type OfflineDecision int
+
+const (
+	OfflineAbort OfflineDecision = iota
+	OfflineRetry
+)
+
+// OfflineHandler is consulted when the base path becomes unreachable during execution.
+// It may block (e.g. while a dialog is shown) and returns whether to retry or abort.
+type OfflineHandler func(path string, err error) OfflineDecision
+
+// mountEntry is a single line from the system mount table
+type mountEntry struct {
+	Device     string
+	MountPoint string
+	FSType     string
+}
+
+// IsNetworkMount reports whether path lives on a network filesystem, and the filesystem type if known
+func IsNetworkMount(path string) (bool, string) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+
+	// UNC paths (\\server\share) are always remote on Windows
+	if runtime.GOOS == "windows" {
+		if strings.HasPrefix(absPath, `\\`) || strings.HasPrefix(absPath, "//") {
+			return true, "unc"
+		}
+		return false, ""
+	}
+
+	entries := readMountTable()
+	entry, ok := mountEntryFor(absPath, entries)
+	if !ok {
+		return false, ""
+	}
+	return networkFSTypes[entry.FSType], entry.FSType
+}
+
+// CheckPathReachable stats path with a timeout, since stat on a dead network mount can hang indefinitely
+func CheckPathReachable(path string, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = defaultReachabilityTimeout
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := os.Stat(path)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return errors.Join(ErrPathOffline, err)
+		}
+		return nil
+	case <-time.After(timeout):
+		return ErrPathOffline
+	}
+}
+
+// readMountTable returns the current mount table for the running platform
+func readMountTable() []mountEntry {
+	switch runtime.GOOS {
+	case "linux":
+		data, err := os.ReadFile("/proc/mounts")
+		if err != nil {
+			return nil
+		}
+		return parseProcMounts(string(data))
+	case "darwin", "freebsd", "openbsd", "netbsd":
+		out, err := exec.Command("mount").Output()
+		if err != nil {
+			return nil
+		}
+		return parseBSDMountOutput(string(out))
+	default:
+		return nil
+	}
+}
+
+// parseProcMounts parses the Linux /proc/mounts format: "device mountpoint fstype options 0 0"
+func parseProcMounts(content string) []mountEntry {
+	var entries []mountEntry
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		// Spaces in mount points are escaped as \040
+		mountPoint := strings.ReplaceAll(fields[1], `\040`, " ")
+		entries = append(entries, mountEntry{Device: fields[0], MountPoint: mountPoint, FSType: fields[2]})
+	}
+	return entries
+}
+
+// parseBSDMountOutput parses `mount` output on macOS/BSD: "//user@host/share on /Volumes/share (smbfs, nodev)"
+func parseBSDMountOutput(content string) []mountEntry {
+	var entries []mountEntry
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		onIdx := strings.Index(line, " on ")
+		openIdx := strings.LastIndex(line, " (")
+		if onIdx < 0 || openIdx < onIdx {
+			continue
+		}
+		mountPoint := line[onIdx+4 : openIdx]
+		opts := strings.TrimSuffix(line[openIdx+2:], ")")
+		fsType := strings.TrimSpace(strings.SplitN(opts, ",", 2)[0])
+		entries = append(entries, mountEntry{Device: line[:onIdx], MountPoint: mountPoint, FSType: fsType})
+	}
+	return entries
+}
+
+// mountEntryFor finds the most specific mount point containing path
+func mountEntryFor(path string, entries []mountEntry) (mountEntry, bool) {
+	var best mountEntry
+	found := false
+	for _, entry := range entries {
+		mp := entry.MountPoint
+		if path != mp && !strings.HasPrefix(path, strings.TrimSuffix(mp, "/")+"/") {
+			continue
+		}
+		if !found || len(mp) > len(best.MountPoint) {
+			best = entry
+			found = true
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseProcMounts_NetworkDetection(t *testing.T) {
+	content := `/dev/sda1 / ext4 rw,relatime 0 0
+//nas/share /mnt/nas cifs rw,vers=3.0 0 0
+server:/export /mnt/nfs nfs4 rw 0 0
+/dev/sdb1 /mnt/nas/local\040disk ext4 rw 0 0
+`
+	entries := parseProcMounts(content)
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{name: "root filesystem", path: "/home/user/docs", expected: "ext4"},
+		{name: "cifs share", path: "/mnt/nas/photos", expected: "cifs"},
+		{name: "mount point itself", path: "/mnt/nfs", expected: "nfs4"},
+		{name: "similar prefix is not a match", path: "/mnt/nfsbackup", expected: "ext4"},
+		{name: "nested local mount with escaped space", path: "/mnt/nas/local disk/a.txt", expected: "ext4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, ok := mountEntryFor(tt.path, entries)
+			if !ok {
+				t.Fatalf("mountEntryFor(%q) found no mount", tt.path)
+			}
+			if entry.FSType != tt.expected {
+				t.Errorf("mountEntryFor(%q) = %q, want %q", tt.path, entry.FSType, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseBSDMountOutput(t *testing.T) {
+	content := `/dev/disk3s1s1 on / (apfs, sealed, local, read-only, journaled)
+//guest@nas._smb._tcp.local/Media on /Volumes/Media (smbfs, nodev, nosuid, mounted by user)
+`
+	entries := parseBSDMountOutput(content)
+	entry, ok := mountEntryFor("/Volumes/Media/Movies", entries)
+	if !ok || entry.FSType != "smbfs" {
+		t.Fatalf("expected smbfs mount, got %+v (found=%v)", entry, ok)
+	}
+	if !networkFSTypes[entry.FSType] {
+		t.Errorf("smbfs should be treated as a network filesystem")
+	}
+}
+
+func TestExecuteOperations_AbortsWhenBaseOffline(t *testing.T) {
+	tempDir := t.TempDir()
+	basePath := filepath.Join(tempDir, "share")
+	if err := os.MkdirAll(basePath, 0755); err != nil {
+		t.Fatalf("Failed to create base: %v", err)
+	}
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(basePath, name), []byte("x"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	fs := NewFileService(NewValidator(), NewLogger(false))
+
+	// The first operation moves the base away, simulating the share disappearing mid-run
+	operations := []FileOperation{
+		{From: basePath, To: filepath.Join(tempDir, "unmounted")},
+		{From: filepath.Join(basePath, "a.txt"), To: filepath.Join(basePath, "sorted", "a.txt")},
+		{From: filepath.Join(basePath, "b.txt"), To: filepath.Join(basePath, "sorted", "b.txt")},
+	}
+
+	prompts := 0
+	result, err := fs.ExecuteOperations(operations, basePath, ExecutionOptions{
+		OnOffline: func(path string, err error) OfflineDecision {
+			prompts++
+			return OfflineAbort
+		},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteOperations() returned error: %v", err)
+	}
+
+	if prompts != 1 {
+		t.Errorf("offline handler called %d times, want 1", prompts)
+	}
+	if !result.Aborted {
+		t.Errorf("expected result to be marked aborted")
+	}
+	if result.SuccessCount != 1 || result.FailCount != 2 {
+		t.Errorf("SuccessCount = %d, FailCount = %d, want 1 and 2", result.SuccessCount, result.FailCount)
+	}
+	if !errors.Is(result.VerificationError, ErrPathOffline) {
+		t.Errorf("VerificationError = %v, want ErrPathOffline", result.VerificationError)
+	}
+}
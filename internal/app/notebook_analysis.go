@@ -0,0 +1,209 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	notebookMaxCodeCells    = 3    // Only the first code cells are sent; later ones are usually repetitive
+	notebookMaxCodeCellSize = 1000 // Characters kept per code cell
+)
+
+// notebookSource is a cell's source, stored either as a single string or as a list of lines
+type notebookSource string
+
+func (s *notebookSource) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*s = notebookSource(text)
+		return nil
+	}
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err != nil {
+		return err
+	}
+	*s = notebookSource(strings.Join(lines, ""))
+	return nil
+}
+
+type jupyterNotebook struct {
+	Cells []struct {
+		CellType string         `json:"cell_type"`
+		Source   notebookSource `json:"source"`
+	} `json:"cells"`
+	Metadata struct {
+		KernelSpec struct {
+			DisplayName string `json:"display_name"`
+			Language    string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+}
+
+// extractNotebookContent renders a Jupyter notebook as its markdown cells and first code cells,
+// leaving out outputs and the JSON structure that would otherwise fill the truncated content
+func extractNotebookContent(data []byte) (string, error) {
+	var notebook jupyterNotebook
+	if err := json.Unmarshal(data, &notebook); err != nil {
+		return "", fmt.Errorf("invalid notebook: %w", err)
+	}
+
+	var builder strings.Builder
+	language := notebook.Metadata.LanguageInfo.Name
+	if language == "" {
+		language = notebook.Metadata.KernelSpec.Language
+	}
+	if language != "" || notebook.Metadata.KernelSpec.DisplayName != "" {
+		builder.WriteString(fmt.Sprintf("Kernel: %s (%s)\n", notebook.Metadata.KernelSpec.DisplayName, language))
+	}
+
+	codeCells := 0
+	for _, cell := range notebook.Cells {
+		source := strings.TrimSpace(string(cell.Source))
+		if source == "" {
+			continue
+		}
+		switch cell.CellType {
+		case "markdown":
+			builder.WriteString("\n" + source + "\n")
+		case "code":
+			codeCells++
+			if codeCells > notebookMaxCodeCells {
+				continue
+			}
+			if runes := []rune(source); len(runes) > notebookMaxCodeCellSize {
+				source = string(runes[:notebookMaxCodeCellSize]) + "\n..."
+			}
+			builder.WriteString("\n[code]\n" + source + "\n")
+		}
+	}
+	if codeCells > notebookMaxCodeCells {
+		builder.WriteString(fmt.Sprintf("\n(%d more code cells omitted)\n", codeCells-notebookMaxCodeCells))
+	}
+
+	return strings.TrimSpace(builder.String()), nil
+}
+
+// analyzeNotebookFile describes a Jupyter notebook from its prose and first code cells
+func (das *DeepAnalysisService) analyzeNotebookFile(ctx context.Context, filePath string) (string, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return "", err
+	}
+	// Notebooks embed their outputs (plots, tables), so they get the document size limit
+	if info.Size() > maxDocFileSize {
+		return "", fmt.Errorf("notebook too large (%d bytes)", info.Size())
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := extractNotebookContent(data)
+	if err != nil {
+		return "", err
+	}
+	if content == "" {
+		return "", fmt.Errorf("notebook has no content")
+	}
+
+	description, err := das.analyzeContentWithLLM(ctx, content, "notebook", filepath.Base(filePath))
+	if err != nil {
+		return "", fmt.Errorf("notebook analysis failed: %w", err)
+	}
+	return description, nil
+}
+
+// splitFrontmatter separates a leading YAML frontmatter block ("---" ... "---") from a markdown body
+func splitFrontmatter(content string) (frontmatter, body string, ok bool) {
+	content = strings.TrimPrefix(content, "\ufeff") // UTF-8 BOM
+	rest, found := strings.CutPrefix(content, "---\n")
+	if !found {
+		rest, found = strings.CutPrefix(content, "---\r\n")
+		if !found {
+			return "", content, false
+		}
+	}
+
+	for offset := 0; offset < len(rest); {
+		end := strings.IndexByte(rest[offset:], '\n')
+		line := rest[offset:]
+		next := len(rest)
+		if end >= 0 {
+			line = rest[offset : offset+end]
+			next = offset + end + 1
+		}
+		if trimmed := strings.TrimRight(line, "\r \t"); trimmed == "---" || trimmed == "..." {
+			return rest[:offset], rest[next:], true
+		}
+		offset = next
+	}
+	return "", content, false
+}
+
+// formatFrontmatterValue flattens a YAML value into a single line
+func formatFrontmatterValue(value any) string {
+	switch v := value.(type) {
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, formatFrontmatterValue(item))
+		}
+		return strings.Join(items, ", ")
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		items := make([]string, 0, len(keys))
+		for _, key := range keys {
+			items = append(items, key+"="+formatFrontmatterValue(v[key]))
+		}
+		return strings.Join(items, ", ")
+	default:
+		return strings.TrimSpace(fmt.Sprint(v))
+	}
+}
+
+// formatMarkdownForAnalysis moves a markdown file's frontmatter (title, tags, date...) into a compact
+// metadata section ahead of the body. Content without valid frontmatter is returned unchanged.
+func formatMarkdownForAnalysis(content string) string {
+	frontmatter, body, ok := splitFrontmatter(content)
+	if !ok {
+		return content
+	}
+
+	var fields map[string]any
+	if err := yaml.Unmarshal([]byte(frontmatter), &fields); err != nil || len(fields) == 0 {
+		return content
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	builder.WriteString("Frontmatter:\n")
+	for _, key := range keys {
+		if value := formatFrontmatterValue(fields[key]); value != "" {
+			builder.WriteString(fmt.Sprintf("%s: %s\n", key, value))
+		}
+	}
+	builder.WriteString("\nBody:\n")
+	builder.WriteString(strings.TrimSpace(body))
+	return builder.String()
+}
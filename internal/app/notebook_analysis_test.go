@@ -0,0 +1,73 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractNotebookContent(t *testing.T) {
+	notebook := `{
+		"cells": [
+			{"cell_type": "markdown", "source": ["# Sales forecast\n", "Quarterly model"]},
+			{"cell_type": "code", "source": "import pandas as pd", "outputs": [{"data": {"image/png": "AAAA"}}]},
+			{"cell_type": "code", "source": ["df = pd.read_csv('sales.csv')"]},
+			{"cell_type": "code", "source": "df.plot()"},
+			{"cell_type": "code", "source": "df.describe()"},
+			{"cell_type": "raw", "source": "ignored"}
+		],
+		"metadata": {"kernelspec": {"display_name": "Python 3", "language": "python"}}
+	}`
+
+	content, err := extractNotebookContent([]byte(notebook))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{"Kernel: Python 3 (python)", "# Sales forecast\nQuarterly model", "import pandas as pd", "df.plot()", "(1 more code cells omitted)"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected content to contain %q, got:\n%s", want, content)
+		}
+	}
+	for _, unwanted := range []string{"df.describe()", "AAAA", "ignored"} {
+		if strings.Contains(content, unwanted) {
+			t.Errorf("expected content not to contain %q, got:\n%s", unwanted, content)
+		}
+	}
+}
+
+func TestFormatMarkdownForAnalysis(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "frontmatter",
+			content:  "---\ntitle: Trip notes\ntags: [travel, japan]\ndraft: false\n---\n\n# Day 1\n",
+			expected: "Frontmatter:\ndraft: false\ntags: travel, japan\ntitle: Trip notes\n\nBody:\n# Day 1",
+		},
+		{
+			name:     "no frontmatter",
+			content:  "# Heading\n---\ntext",
+			expected: "# Heading\n---\ntext",
+		},
+		{
+			name:     "unterminated frontmatter",
+			content:  "---\ntitle: x\n# Heading",
+			expected: "---\ntitle: x\n# Heading",
+		},
+		{
+			name:     "invalid yaml",
+			content:  "---\n: : :\n---\nbody",
+			expected: "---\n: : :\n---\nbody",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatMarkdownForAnalysis(tt.content); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const notificationTimeout = 30 * time.Second
+
+// Outcomes of an automated run, as reported in RunSummary.Status
+const (
+	RunStatusApplied = "applied"   // The plan was executed
+	RunStatusQueued  = "queued"    // The plan waits for review
+	RunStatusHeld    = "held"      // The plan wasn't applied and there is no review queue
+	RunStatusNothing = "unchanged" // There was nothing to organize
+	RunStatusFailed  = "failed"
+)
+
+// RunSummary is what an automated run reports once it's done
+type RunSummary struct {
+	Job        string            `json:"job"`
+	BasePath   string            `json:"base_path"`
+	Status     string            `json:"status"`
+	Reason     string            `json:"reason,omitempty"` // Why the plan wasn't applied
+	Error      string            `json:"error,omitempty"`
+	Planned    int               `json:"planned_operations"`
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+	Result     *HookResultReport `json:"result,omitempty"` // Set when the plan was executed
+}
+
+// NewRunSummary describes the outcome of an automated job; err is what AutoApplier.Run returned
+func NewRunSummary(job AutomatedJob, outcome *AutoApplyOutcome, err error, startedAt time.Time) RunSummary {
+	summary := RunSummary{
+		Job:        job.Name,
+		BasePath:   job.Request.DirectoryPath,
+		StartedAt:  startedAt,
+		FinishedAt: time.Now(),
+	}
+	if outcome != nil {
+		summary.Planned = len(outcome.Operations)
+		summary.Reason = outcome.Decision.Reason
+	}
+	switch {
+	case err != nil:
+		summary.Status = RunStatusFailed
+		summary.Error = err.Error()
+	case outcome.Execution != nil:
+		summary.Status = RunStatusApplied
+		summary.Reason = ""
+		summary.Result = NewHookResultReport(*outcome.Execution)
+		if outcome.Execution.FailCount > 0 || outcome.Execution.VerificationError != nil {
+			summary.Status = RunStatusFailed
+		}
+	case outcome.Queued:
+		summary.Status = RunStatusQueued
+	case len(outcome.Operations) == 0:
+		summary.Status = RunStatusNothing
+	default:
+		summary.Status = RunStatusHeld
+	}
+	return summary
+}
+
+// Subject is a one-line description of the run, used as the email subject
+func (s RunSummary) Subject() string {
+	switch s.Status {
+	case RunStatusApplied:
+		return fmt.Sprintf("%s: moved %d files", s.Job, s.Result.SuccessCount)
+	case RunStatusQueued:
+		return fmt.Sprintf("%s: %d operations wait for review", s.Job, s.Planned)
+	case RunStatusHeld:
+		return fmt.Sprintf("%s: %d operations not applied", s.Job, s.Planned)
+	case RunStatusNothing:
+		return fmt.Sprintf("%s: nothing to organize", s.Job)
+	default:
+		if s.Result != nil {
+			return fmt.Sprintf("%s: %d of %d moves failed", s.Job, s.Result.FailCount, s.Result.SuccessCount+s.Result.FailCount)
+		}
+		return fmt.Sprintf("%s failed", s.Job)
+	}
+}
+
+// Notifier posts the summary of every automated run to a webhook and/or emails it, so
+// unattended organizing can be followed. Settings are read from the config on every call.
+type Notifier struct {
+	config *Config
+	logger *Logger
+	client *http.Client
+}
+
+func NewNotifier(config *Config, logger *Logger) *Notifier {
+	return &Notifier{
+		config: config,
+		logger: logger,
+		client: &http.Client{Transport: sharedTransport, Timeout: notificationTimeout},
+	}
+}
+
+// Notify sends summary to every configured destination. Each failure is logged; the first is returned.
+func (n *Notifier) Notify(summary RunSummary) error {
+	if n == nil {
+		return nil
+	}
+	body, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal run summary: %w", err)
+	}
+
+	var firstErr error
+	if webhook := strings.TrimSpace(n.config.NotifyWebhookURL); webhook != "" {
+		if err := n.postWebhook(webhook, body); err != nil {
+			n.logger.Error("Failed to post run summary to webhook: %v", err)
+			firstErr = err
+		}
+	}
+	if server := strings.TrimSpace(n.config.NotifySMTPServer); server != "" && strings.TrimSpace(n.config.NotifyEmailTo) != "" {
+		if err := n.sendEmail(server, summary.Subject(), body); err != nil {
+			n.logger.Error("Failed to email run summary: %v", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func (n *Notifier) postWebhook(webhook string, body []byte) error {
+	req, err := http.NewRequest("POST", webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// sendEmail sends the summary JSON as a plain-text mail. Port 465 uses TLS from the start; other
+// ports upgrade with STARTTLS when the server offers it.
+func (n *Notifier) sendEmail(server, subject string, body []byte) error {
+	host, port, err := net.SplitHostPort(server)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP server %q: %w", server, err)
+	}
+	recipients, err := mail.ParseAddressList(n.config.NotifyEmailTo)
+	if err != nil {
+		return fmt.Errorf("invalid recipients: %w", err)
+	}
+	from := strings.TrimSpace(n.config.NotifyEmailFrom)
+	if from == "" {
+		from = n.config.NotifySMTPUser
+	}
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return fmt.Errorf("invalid sender: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notificationTimeout)
+	defer cancel()
+	var conn net.Conn
+	if port == "465" {
+		conn, err = (&tls.Dialer{Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", server)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", server)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(notificationTimeout))
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && port != "465" {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if n.config.NotifySMTPUser != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.config.NotifySMTPUser, n.config.NotifySMTPPassword, host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(sender.Address); err != nil {
+		return err
+	}
+	to := make([]string, len(recipients))
+	for i, recipient := range recipients {
+		to[i] = recipient.Address
+		if err := client.Rcpt(recipient.Address); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(emailMessage(sender.String(), to, subject, body)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// emailMessage formats a UTF-8 plain-text mail with CRLF line endings
+func emailMessage(from string, to []string, subject string, body []byte) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "VibesAndFolders: "+subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(string(body), "\r\n", "\n"), "\n", "\r\n"))
+	msg.WriteString("\r\n")
+	return msg.Bytes()
+}
+
+// ValidateNotificationSettings checks the webhook URL, SMTP server and addresses before they're saved
+func ValidateNotificationSettings(webhook, server, from, to string) error {
+	if webhook = strings.TrimSpace(webhook); webhook != "" {
+		u, err := url.Parse(webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook must be an http:// or https:// URL")
+		}
+	}
+	if server = strings.TrimSpace(server); server == "" {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		return fmt.Errorf("SMTP server must be host:port, e.g. smtp.example.com:587")
+	}
+	if _, err := mail.ParseAddressList(to); err != nil {
+		return fmt.Errorf("recipients: %w", err)
+	}
+	if from = strings.TrimSpace(from); from != "" {
+		if _, err := mail.ParseAddress(from); err != nil {
+			return fmt.Errorf("sender: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewRunSummary(t *testing.T) {
+	job := AutomatedJob{Name: "Downloads", Request: AnalysisRequest{DirectoryPath: "/nas/Downloads"}}
+	planned := []FileOperation{{From: "a.pdf", To: "docs/a.pdf"}, {From: "b.jpg", To: "photos/b.jpg"}}
+
+	tests := []struct {
+		name        string
+		outcome     *AutoApplyOutcome
+		err         error
+		wantStatus  string
+		wantSubject string
+	}{
+		{"analysis failed", nil, errors.New("model unreachable"), RunStatusFailed, "Downloads failed"},
+		{"nothing to do", &AutoApplyOutcome{}, nil, RunStatusNothing, "Downloads: nothing to organize"},
+		{"queued", &AutoApplyOutcome{Operations: planned, Queued: true, Decision: AutoApplyDecision{Reason: "auto-apply is off"}}, nil, RunStatusQueued, "Downloads: 2 operations wait for review"},
+		{"held", &AutoApplyOutcome{Operations: planned, Decision: AutoApplyDecision{Reason: "auto-apply is off"}}, nil, RunStatusHeld, "Downloads: 2 operations not applied"},
+		{"applied", &AutoApplyOutcome{Operations: planned, Decision: AutoApplyDecision{Apply: true}, Execution: &ExecutionResult{SuccessCount: 2}}, nil, RunStatusApplied, "Downloads: moved 2 files"},
+		{"moves failed", &AutoApplyOutcome{Operations: planned, Decision: AutoApplyDecision{Apply: true}, Execution: &ExecutionResult{SuccessCount: 1, FailCount: 1}}, nil, RunStatusFailed, "Downloads: 1 of 2 moves failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary := NewRunSummary(job, tt.outcome, tt.err, time.Now())
+			if summary.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", summary.Status, tt.wantStatus)
+			}
+			if got := summary.Subject(); got != tt.wantSubject {
+				t.Errorf("subject = %q, want %q", got, tt.wantSubject)
+			}
+			if summary.BasePath != "/nas/Downloads" || (tt.err != nil) != (summary.Error != "") {
+				t.Errorf("summary = %+v", summary)
+			}
+		})
+	}
+}
+
+func TestValidateNotificationSettings(t *testing.T) {
+	tests := []struct {
+		name                      string
+		webhook, server, from, to string
+		wantErr                   bool
+	}{
+		{name: "nothing configured"},
+		{name: "webhook", webhook: "https://example.com/hook"},
+		{name: "email", server: "smtp.example.com:587", from: "NAS <nas@example.com>", to: "me@example.com, you@example.com"},
+		{name: "webhook without scheme", webhook: "example.com/hook", wantErr: true},
+		{name: "server without port", server: "smtp.example.com", to: "me@example.com", wantErr: true},
+		{name: "no recipients", server: "smtp.example.com:587", wantErr: true},
+		{name: "bad sender", server: "smtp.example.com:587", from: "nas@", to: "me@example.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateNotificationSettings(tt.webhook, tt.server, tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fakeSMTPServer accepts one message without TLS or authentication and returns its recipients and data
+func fakeSMTPServer(t *testing.T) (addr string, received <-chan []string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	ch := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		tp.PrintfLine("220 localhost ready")
+		var got []string
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.Fields(line + " ")[0]); cmd {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "RCPT":
+				got = append(got, line)
+				tp.PrintfLine("250 OK")
+			case "DATA":
+				tp.PrintfLine("354 Go ahead")
+				data, _ := tp.ReadDotLines()
+				got = append(got, strings.Join(data, "\n"))
+				tp.PrintfLine("250 OK")
+			case "QUIT":
+				tp.PrintfLine("221 Bye")
+				ch <- got
+				return
+			default:
+				tp.PrintfLine("250 OK")
+			}
+		}
+	}()
+	return listener.Addr().String(), ch
+}
+
+func TestNotifierEmail(t *testing.T) {
+	addr, received := fakeSMTPServer(t)
+	config := &Config{NotifySMTPServer: addr, NotifyEmailFrom: "nas@example.com", NotifyEmailTo: "me@example.com, Admin <admin@example.com>"}
+	summary := RunSummary{Job: "Fotos für Oma", BasePath: "/nas/Photos", Status: RunStatusNothing}
+	if err := NewNotifier(config, NewLogger(false)).Notify(summary); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	select {
+	case got := <-received:
+		if len(got) != 3 || !strings.Contains(got[0], "me@example.com") || !strings.Contains(got[1], "admin@example.com") {
+			t.Fatalf("received %q", got)
+		}
+		message := got[2]
+		for _, want := range []string{"Subject: =?utf-8?q?", "Content-Type: text/plain; charset=utf-8", `"base_path": "/nas/Photos"`, `"status": "unchanged"`} {
+			if !strings.Contains(message, want) {
+				t.Errorf("message lacks %q:\n%s", want, message)
+			}
+		}
+		header, _ := textproto.NewReader(bufio.NewReader(strings.NewReader(message + "\n"))).ReadMIMEHeader()
+		if header.Get("To") != "me@example.com, admin@example.com" {
+			t.Errorf("To = %q", header.Get("To"))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+
+	// Nothing is sent without a notifier
+	var notifier *Notifier
+	if err := notifier.Notify(summary); err != nil {
+		t.Errorf("nil notifier: %v", err)
+	}
+}
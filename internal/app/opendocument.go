@@ -0,0 +1,244 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const maxOpenDocumentContentSize = 20 * 1024 * 1024 // Uncompressed content.xml limit
+
+// odfBlockElements end a run of text in content.xml (paragraphs, headings, table cells, list items)
+var odfBlockElements = map[string]bool{
+	"p":          true,
+	"h":          true,
+	"table-cell": true,
+	"list-item":  true,
+	"frame":      true,
+}
+
+// extractTextFromODFXML extracts plain text from an OpenDocument content.xml
+func extractTextFromODFXML(content []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	var builder strings.Builder
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.CharData:
+			builder.Write(t)
+		case xml.StartElement:
+			// <text:s/>, <text:tab/> and <text:line-break/> stand for whitespace
+			if t.Name.Local == "s" || t.Name.Local == "tab" || t.Name.Local == "line-break" {
+				builder.WriteByte(' ')
+			}
+		case xml.EndElement:
+			if odfBlockElements[t.Name.Local] {
+				builder.WriteByte(' ')
+			}
+		}
+	}
+
+	return strings.Join(strings.Fields(builder.String()), " "), nil
+}
+
+// analyzeOpenDocumentFile extracts text from an OpenDocument file (.odt, .ods, .odp) and analyzes it.
+// contentType matches the equivalent MS Office analyzer so both get the same prompt handling.
+func (das *DeepAnalysisService) analyzeOpenDocumentFile(ctx context.Context, filePath, contentType string) (string, error) {
+	zipReader, err := zip.OpenReader(filePath)
+	if err != nil {
+		das.logger.Debug("Failed to open OpenDocument file as ZIP %s: %v", filePath, err)
+		return "", fmt.Errorf("failed to open OpenDocument file: %w", err)
+	}
+	defer zipReader.Close()
+
+	var contentFile *zip.File
+	for _, file := range zipReader.File {
+		if file.Name == "content.xml" {
+			contentFile = file
+			break
+		}
+	}
+	if contentFile == nil {
+		return "", fmt.Errorf("OpenDocument file has no content.xml")
+	}
+
+	rc, err := contentFile.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open content.xml: %w", err)
+	}
+	content, err := io.ReadAll(io.LimitReader(rc, maxOpenDocumentContentSize))
+	rc.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to read content.xml: %w", err)
+	}
+
+	text, err := extractTextFromODFXML(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse content.xml: %w", err)
+	}
+	if text == "" {
+		return "", fmt.Errorf("OpenDocument file has no extractable text")
+	}
+	das.logger.Debug("Extracted %d characters from OpenDocument file %s", len(text), filePath)
+
+	description, err := das.analyzeContentWithLLM(ctx, text, contentType, filepath.Base(filePath))
+	if err != nil {
+		return "", fmt.Errorf("OpenDocument analysis failed: %w", err)
+	}
+	return description, nil
+}
+
+var (
+	// rtfSkipGroups are destinations whose content is metadata rather than document text
+	rtfSkipGroups = regexp.MustCompile(`^\\\*|^\\(fonttbl|colortbl|stylesheet|info|pict|header|footer|listtable|listoverridetable|themedata|datastore|latentstyles|xmlnstbl|rsidtbl|generator)\b`)
+)
+
+// extractTextFromRTF returns the visible text of an RTF document. It handles the control words
+// that affect text (paragraphs, tabs, escaped characters, \'hh and \uN) and skips metadata groups.
+func extractTextFromRTF(rtf string) string {
+	var builder strings.Builder
+	skipDepth := 0 // Group depth at which skipping started; 0 = not skipping
+	depth := 0
+	ucSkip := 1 // Fallback characters to skip after \uN
+
+	for i := 0; i < len(rtf); i++ {
+		c := rtf[i]
+		switch c {
+		case '{':
+			depth++
+			if skipDepth == 0 && rtfSkipGroups.MatchString(rtf[i+1:min(len(rtf), i+32)]) {
+				skipDepth = depth
+			}
+		case '}':
+			if skipDepth == depth {
+				skipDepth = 0
+			}
+			depth--
+		case '\r', '\n':
+			// Raw line breaks are not significant in RTF
+		case '\\':
+			if i+1 >= len(rtf) {
+				continue
+			}
+			next := rtf[i+1]
+			switch {
+			case next == '\\' || next == '{' || next == '}':
+				if skipDepth == 0 {
+					builder.WriteByte(next)
+				}
+				i++
+			case next == '\'':
+				// \'hh: a character in the document's code page; treat as Latin-1
+				if i+3 < len(rtf) {
+					var b byte
+					if _, err := fmt.Sscanf(rtf[i+2:i+4], "%02x", &b); err == nil && skipDepth == 0 {
+						builder.WriteRune(rune(b))
+					}
+				}
+				i += 3
+			case (next >= 'a' && next <= 'z') || (next >= 'A' && next <= 'Z'):
+				j := i + 1
+				for j < len(rtf) && ((rtf[j] >= 'a' && rtf[j] <= 'z') || (rtf[j] >= 'A' && rtf[j] <= 'Z')) {
+					j++
+				}
+				word := rtf[i+1 : j]
+				k := j
+				if k < len(rtf) && rtf[k] == '-' {
+					k++
+				}
+				for k < len(rtf) && rtf[k] >= '0' && rtf[k] <= '9' {
+					k++
+				}
+				param := rtf[j:k]
+				if k < len(rtf) && rtf[k] == ' ' {
+					k++ // The delimiting space belongs to the control word
+				}
+				i = k - 1
+
+				if skipDepth != 0 {
+					continue
+				}
+				switch word {
+				case "par", "line", "sect", "page", "cell", "row":
+					builder.WriteByte('\n')
+				case "tab":
+					builder.WriteByte('\t')
+				case "uc":
+					fmt.Sscanf(param, "%d", &ucSkip)
+				case "u":
+					var code int
+					if _, err := fmt.Sscanf(param, "%d", &code); err == nil {
+						if code < 0 {
+							code += 65536
+						}
+						builder.WriteRune(rune(code))
+						// Skip the ANSI fallback characters, which may themselves be \'hh escapes
+						for n := 0; n < ucSkip && i+1 < len(rtf); n++ {
+							if strings.HasPrefix(rtf[i+1:], "\\'") {
+								i += 4
+							} else {
+								i++
+							}
+						}
+					}
+				}
+			default:
+				// Control symbols such as \~ (non-breaking space) or \- (optional hyphen)
+				if next == '~' && skipDepth == 0 {
+					builder.WriteByte(' ')
+				}
+				i++
+			}
+		default:
+			if skipDepth == 0 {
+				builder.WriteByte(c)
+			}
+		}
+	}
+
+	var lines []string
+	for _, line := range strings.Split(builder.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// analyzeRTFFile extracts text from an RTF document and analyzes it
+func (das *DeepAnalysisService) analyzeRTFFile(ctx context.Context, filePath string) (string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte(`{\rtf`)) {
+		return "", fmt.Errorf("not a valid RTF document")
+	}
+
+	text := extractTextFromRTF(string(data))
+	if text == "" {
+		return "", fmt.Errorf("RTF document has no extractable text")
+	}
+	das.logger.Debug("Extracted %d characters from RTF document %s", len(text), filePath)
+
+	description, err := das.analyzeContentWithLLM(ctx, text, "word", filepath.Base(filePath))
+	if err != nil {
+		return "", fmt.Errorf("RTF analysis failed: %w", err)
+	}
+	return description, nil
+}
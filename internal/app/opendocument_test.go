@@ -0,0 +1,46 @@
+package app
+
+import "testing"
+
+func TestExtractTextFromODFXML(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0">
+<office:body><office:text>
+<text:h text:outline-level="1">Budget</text:h><text:p>Total<text:s/>for <text:span>2024</text:span></text:p>
+<table:table><table:table-row><table:table-cell><text:p>A</text:p></table:table-cell><table:table-cell><text:p>B</text:p></table:table-cell></table:table-row></table:table>
+</office:text></office:body></office:document-content>`
+
+	got, err := extractTextFromODFXML([]byte(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "Budget Total for 2024 A B"; got != want {
+		t.Errorf("extractTextFromODFXML() = %q, want %q", got, want)
+	}
+}
+
+func TestExtractTextFromRTF(t *testing.T) {
+	tests := []struct {
+		name string
+		rtf  string
+		want string
+	}{
+		{
+			name: "skips font table and keeps paragraphs",
+			rtf:  `{\rtf1\ansi{\fonttbl{\f0 Times New Roman;}}{\*\generator Writer;}\f0\fs24 Hello \b world\b0 !\par Second line}`,
+			want: "Hello world!\nSecond line",
+		},
+		{
+			name: "escapes and unicode",
+			rtf:  `{\rtf1 Caf\'e9 \{x\} \u8364\'80 price}`,
+			want: "Café {x} € price",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractTextFromRTF(tt.rtf); got != tt.want {
+				t.Errorf("extractTextFromRTF() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
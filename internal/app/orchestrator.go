@@ -1,9 +1,14 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 )
 
 type Orchestrator struct {
@@ -13,9 +18,41 @@ type Orchestrator struct {
 	logger               *Logger
 	indexOrchestrator    *IndexDirectoryOrchestrator
 	indexService         IndexService
+	hooks                *HookRunner
+	indexSync            *IndexSyncService
+	pendingPlans         *PendingPlanStore
+	planMerger           *PlanMerger
+	audit                *AuditLog
+	corrections          *CorrectionStore
+	metrics              *UsageMetrics
+	shutdown             *ShutdownCoordinator
+	budget               *BudgetGuard
+
+	// Enriched structures from the previous analyze run, reused while the index is unchanged
+	enrichMu    sync.Mutex
+	enrichCache map[string]cachedEnrichment
+
+	// Recently executed operations, so plans made before them can be reconciled before they run.
+	// Sequence numbers start at 1; a plan's PlannedAt of 0 means it predates the log.
+	execMu       sync.Mutex
+	executionSeq uint64
+	executed     []executedOperation
 }
 
-func NewOrchestrator(aiService AIService, fileService FileService, validator *Validator, logger *Logger, indexOrchestrator *IndexDirectoryOrchestrator, indexService IndexService) *Orchestrator {
+type executedOperation struct {
+	seq uint64
+	op  FileOperation
+}
+
+// maxExecutionLog bounds the executed operations kept for reconciling stale plans
+const maxExecutionLog = 10000
+
+type cachedEnrichment struct {
+	structure string
+	enriched  string
+}
+
+func NewOrchestrator(aiService AIService, fileService FileService, validator *Validator, logger *Logger, indexOrchestrator *IndexDirectoryOrchestrator, indexService IndexService, hooks *HookRunner) *Orchestrator {
 	return &Orchestrator{
 		aiService:         aiService,
 		fileService:       fileService,
@@ -23,6 +60,10 @@ func NewOrchestrator(aiService AIService, fileService FileService, validator *Va
 		logger:            logger,
 		indexOrchestrator: indexOrchestrator,
 		indexService:      indexService,
+		hooks:             hooks,
+		enrichCache:       make(map[string]cachedEnrichment),
+		planMerger:        NewPlanMerger(logger),
+		executionSeq:      1,
 	}
 }
 
@@ -31,23 +72,97 @@ type AnalysisRequest struct {
 	UserPrompt         string
 	MaxDepth           int
 	EnableDeepAnalysis bool
+	OnScanProgress     ScanProgressCallback
+	ExplainMoves       bool     // Ask the model for a reason per operation (costs extra output tokens)
+	SelfCritique       bool     // Have the model review its plan and drop or flag suspect operations
+	Constraints        string   // Rules for this directory, one per line; see ParseConstraints
+	Pipeline           string   // Steps around the model for this directory, one per line; see ParsePipeline
+	OnlyFiles          []string // Plans just these files (absolute paths), e.g. a confirmed FileSelection; nil plans all
+	IndexRules         bool     // Plan from index statistics and model-written rules instead of the structure; needs deep analysis
+	NewFilesOnly       bool     // Plans just the files modified since the last successful execution in the directory
+	SplitCompanions    bool     // Lets companion files (movie.srt next to movie.mkv) go separate ways; see ResolveCompanions
+
+	// Called with the files deep analysis is about to send to the model; returning false plans with
+	// the descriptions already in the index. Nil never asks.
+	ConfirmDeepAnalysis func(files []string) bool
+
+	// Budget run the request's spend counts toward, so that e.g. a file selection and the plan that
+	// follows share one run ceiling. Nil starts a new run.
+	BudgetRun *BudgetRun
 }
 
 type AnalysisResult struct {
-	Structure  string
-	Operations []FileOperation
-	Error      error
+	Structure    string
+	Operations   []FileOperation
+	Error        error
+	PlannedAt    uint64                // Pass to ExecutionRequest.PlannedAt so later executions are taken into account
+	Removed      []FileOperation       // Operations the self-critique pass dropped, with the reason in Flag
+	Rejected     []FileOperation       // Operations that broke the directory's constraints, with the rule in Flag
+	Hallucinated []FileOperation       // Operations on paths that weren't in the structure sent to the model
+	FromRules    int                   // Operations planned by the pipeline's rules; the model planned the rest
+	Merged       []FolderMerge         // Near-duplicate destination folders that were consolidated
+	Rules        []ClassificationRule  // The rules the model wrote, when planned with IndexRules
+	NewSince     time.Time             // With NewFilesOnly, the last execution the plan is limited to files since; zero plans all
+	Companions   []FileOperation       // Operations added or redirected to keep companion files together
+	Fingerprint  *DirectoryFingerprint // The directory as it was when planned; nil if it can't be taken
 }
 
 type ExecutionRequest struct {
-	Operations []FileOperation
-	BasePath   string
-	CleanEmpty bool
+	Operations  []FileOperation
+	BasePath    string
+	CleanEmpty  bool
+	OnOffline   OfflineHandler
+	Parallelism int
+	PlannedAt   uint64 // AnalysisResult.PlannedAt of the plan; 0 executes the operations as given
+	Staged      bool   // Apply all operations or none of them
+
+	CheckOpenFiles bool               // Ask lsof for files in use (Windows always checks)
+	OnLocked       LockedFilesHandler // nil skips files that are in use
+
+	AuditPermissions bool // Report moved items whose permissions changed
+
+	ThrottleDelay time.Duration // See ExecutionOptions; Config.ThrottleFor picks values for a folder
+	ThrottleBatch int
+
+	Control     *ExecutionControl // Lets the user pause or stop the run between operations
+	HashSample  int               // See ExecutionOptions; Config.HashSample picks the value
+	OnHeartbeat HeartbeatHandler  // Told about moves that take longer than a few seconds
+
+	Rollback bool // Undoes an earlier execution; counted separately in the usage statistics
+
+	// Points shortcuts, aliases and symlinks at the moved files; they are only reported otherwise
+	RewriteShortcuts bool
+	// Rewrites paths to the moved files in playlists, Markdown notes and XMP sidecars
+	UpdateReferences bool
 }
 
 func (o *Orchestrator) ExecuteOrganization(req ExecutionRequest) ExecutionResult {
 	o.logger.Info("Starting execution of %d operations", len(req.Operations))
 
+	// Quitting stops the run between operations, like the Stop button
+	if req.Control == nil {
+		req.Control = NewExecutionControl()
+	}
+	job, err := o.shutdown.Begin(fmt.Sprintf("execution of %d operations", len(req.Operations)), JobExecution, req.Control.Stop)
+	if err != nil {
+		return o.blockedResult(req.Operations, err)
+	}
+	defer job.Done()
+
+	// Another plan (e.g. from a folder watcher) may have moved some of these files since this plan was made
+	var conflicts []PlanConflict
+	if req.PlannedAt > 0 {
+		req.Operations, conflicts = o.planMerger.Rebase(req.Operations, o.executedSince(req.PlannedAt))
+		for _, conflict := range conflicts {
+			o.logger.Info("Skipping operation: %s", conflict.String())
+		}
+	}
+
+	if err := o.hooks.Run(HookPayload{Event: HookPreExecute, BasePath: req.BasePath, Operations: req.Operations}); err != nil {
+		o.logger.Error("Execution blocked by hook: %v", err)
+		return o.blockedResult(req.Operations, err)
+	}
+
 	// Create index snapshot before execution if deep analysis is enabled
 	var indexSnapshot *IndexSnapshot
 	if o.indexOrchestrator != nil && o.indexService != nil {
@@ -61,7 +176,25 @@ func (o *Orchestrator) ExecuteOrganization(req ExecutionRequest) ExecutionResult
 		}
 	}
 
-	result, err := o.fileService.ExecuteOperations(req.Operations, req.BasePath, req.CleanEmpty)
+	startedAt := time.Now()
+	result, err := o.fileService.ExecuteOperations(req.Operations, req.BasePath, ExecutionOptions{
+		CleanEmpty:  req.CleanEmpty,
+		OnOffline:   req.OnOffline,
+		Parallelism: req.Parallelism,
+		Staged:      req.Staged,
+
+		CheckOpenFiles: req.CheckOpenFiles,
+		OnLocked:       req.OnLocked,
+
+		AuditPermissions: req.AuditPermissions,
+
+		ThrottleDelay: req.ThrottleDelay,
+		ThrottleBatch: req.ThrottleBatch,
+
+		Control:     req.Control,
+		HashSample:  req.HashSample,
+		OnHeartbeat: req.OnHeartbeat,
+	})
 	if err != nil {
 		o.logger.Error("Execution failed: %v", err)
 	} else {
@@ -84,7 +217,9 @@ func (o *Orchestrator) ExecuteOrganization(req ExecutionRequest) ExecutionResult
 				}
 			}
 
-			if err := o.indexOrchestrator.UpdateIndexAfterOperations(successfulOps); err != nil {
+			// Indexing the moved files is a run of its own
+			ctx := WithBudgetRun(context.Background(), o.budget.BeginRun())
+			if err := o.indexOrchestrator.UpdateIndexAfterOperations(ctx, successfulOps); err != nil {
 				o.logger.Error("Failed to update index after execution: %v", err)
 				// Rollback the transaction
 				if rbErr := o.indexService.RollbackTransaction(); rbErr != nil {
@@ -108,22 +243,326 @@ func (o *Orchestrator) ExecuteOrganization(req ExecutionRequest) ExecutionResult
 		}
 	}
 
+	var executedOps []FileOperation
+	for _, opResult := range result.Operations {
+		if opResult.Success {
+			executedOps = append(executedOps, opResult.Operation)
+		}
+	}
+	o.recordExecuted(executedOps)
+	o.pendingPlans.Rebase(o.planMerger, executedOps)
+	result.Shortcuts = o.fixShortcuts(req.BasePath, executedOps, req.RewriteShortcuts)
+	if req.UpdateReferences {
+		result.References = o.updateReferences(req.BasePath, executedOps)
+	}
+
+	for _, conflict := range conflicts {
+		result.Operations = append(result.Operations, OperationResult{Operation: conflict.Operation, Error: conflict.Err()})
+		result.FailCount++
+	}
+
+	o.audit.RecordExecution(req.BasePath, result)
+	if !req.Rollback {
+		o.recordExecution(req.BasePath, startedAt, result)
+	}
+	o.metrics.RecordExecution(result, req.Rollback)
+	o.invalidateStructureCaches(req.BasePath)
+
+	if err := o.hooks.Run(HookPayload{Event: HookPostExecute, BasePath: req.BasePath, Operations: req.Operations, Result: NewHookResultReport(result)}); err != nil {
+		o.logger.Error("Post-execute hook failed: %v", err)
+	}
+
+	return result
+}
+
+// fixShortcuts finds the shortcuts that pointed at moved files and rewrites them if asked to
+func (o *Orchestrator) fixShortcuts(basePath string, moves []FileOperation, rewrite bool) []ShortcutFix {
+	fixes := FindShortcutFixes(basePath, moves)
+	if !rewrite {
+		for i := range fixes {
+			fixes[i].Error = ErrShortcutNotRewritten
+		}
+		return fixes
+	}
+	RewriteShortcuts(fixes)
+	for _, fix := range fixes {
+		if fix.Error != nil {
+			o.logger.Error("Failed to update shortcut %s: %v", fix.Shortcut, fix.Error)
+		} else {
+			o.logger.Info("Updated shortcut %s", fix.String())
+		}
+	}
+	return fixes
+}
+
+// updateReferences rewrites paths to the moved files in the reference-holding files under basePath
+func (o *Orchestrator) updateReferences(basePath string, moves []FileOperation) []ReferenceUpdate {
+	updates := UpdateReferences(basePath, moves)
+	for _, update := range updates {
+		if update.Error != nil {
+			o.logger.Error("Failed to update references in %s: %v", update.File, update.Error)
+		} else {
+			o.logger.Info("Updated %d references in %s", len(update.Changes), update.File)
+		}
+	}
+	return updates
+}
+
+// blockedResult reports every operation as not attempted because execution was vetoed before it started
+func (o *Orchestrator) blockedResult(operations []FileOperation, reason error) ExecutionResult {
+	result := ExecutionResult{
+		Operations:        make([]OperationResult, 0, len(operations)),
+		Aborted:           true,
+		VerificationError: reason,
+	}
+	for _, op := range operations {
+		result.Operations = append(result.Operations, OperationResult{Operation: op, Error: reason})
+		result.FailCount++
+	}
 	return result
 }
 
 func (o *Orchestrator) AnalyzeDirectory(req AnalysisRequest, onOperation OperationCallback) AnalysisResult {
-	result := AnalysisResult{}
+	ctx := o.budgetContext(req)
+	if req.IndexRules {
+		return o.analyzeWithIndexRules(ctx, req, onOperation)
+	}
 
-	if err := o.validator.ValidateDirectory(req.DirectoryPath); err != nil {
+	result := AnalysisResult{PlannedAt: o.executionMark()}
+	defer func() { o.metrics.RecordAnalysis(result) }()
+
+	pipeline, err := ParsePipeline(req.Pipeline)
+	if err != nil {
 		result.Error = err
 		return result
 	}
 
-	if err := o.validator.ValidatePrompt(req.UserPrompt); err != nil {
+	enrichedStructure, err := o.prepareStructure(ctx, &req)
+	if err != nil {
 		result.Error = err
 		return result
 	}
 
+	if len(req.OnlyFiles) > 0 {
+		enrichedStructure = restrictStructure(req.DirectoryPath, enrichedStructure, req.OnlyFiles)
+		o.logger.Info("Planning only the %d selected files", len(req.OnlyFiles))
+	}
+	if req.NewFilesOnly {
+		if result.NewSince = o.lastExecution(req.DirectoryPath); !result.NewSince.IsZero() {
+			var count int
+			enrichedStructure, count = restrictToNewFiles(req.DirectoryPath, enrichedStructure, result.NewSince)
+			if count == 0 {
+				result.Error = ErrNoNewFiles
+				return result
+			}
+			o.logger.Info("Planning only the %d files changed since %s", count, result.NewSince.Format(time.DateTime))
+		}
+	}
+	result.Structure = enrichedStructure
+	result.Fingerprint = o.fingerprint(req.DirectoryPath, req.MaxDepth)
+
+	constraints := ParseConstraints(req.Constraints)
+	planValidator := NewPlanValidator(constraints)
+
+	// Destinations like "photo/" and "Photos/" are consolidated as operations come in, so the
+	// inconsistency is never shown
+	merger := newFolderMerger(NewStructureGrounding(enrichedStructure))
+
+	// Files the pipeline's rules handle are planned here and never shown to the model
+	prePass := pipeline.prePass(req.DirectoryPath, enrichedStructure)
+	for i := range prePass.operations {
+		prePass.operations[i] = merger.apply(req.DirectoryPath, prePass.operations[i])
+	}
+	ruleOperations, rejectedRules := planValidator.Filter(req.DirectoryPath, prePass.operations)
+	result.Rejected = append(result.Rejected, rejectedRules...)
+	result.FromRules = len(ruleOperations)
+	if !pipeline.Empty() {
+		o.logger.Info("Pipeline rules planned %d operations, %d files left for the model", len(ruleOperations), prePass.leftovers)
+	}
+	if onOperation != nil && !req.SelfCritique {
+		for _, op := range ruleOperations {
+			onOperation(op)
+		}
+	}
+
+	var operations []FileOperation
+	if pipeline.SkipAI || prePass.leftovers == 0 {
+		o.logger.Info("No files left for the model, skipping the AI request")
+	} else {
+		o.logger.Info("Requesting AI suggestions (Streaming)")
+		grounding := NewStructureGrounding(prePass.structure)
+
+		userPrompt := req.UserPrompt + o.corrections.PromptContext(req.DirectoryPath) + constraints.PromptText() + rulesPromptText(req.DirectoryPath, ruleOperations)
+		if req.ExplainMoves {
+			userPrompt += explainMovesInstruction
+		}
+
+		// Operations are only streamed once the review has decided which ones stay, and never when
+		// they break a constraint or move something the model wasn't shown
+		var streamed OperationCallback
+		if onOperation != nil && !req.SelfCritique {
+			streamed = func(op FileOperation) {
+				if !grounding.Contains(req.DirectoryPath, op.From) {
+					return
+				}
+				op = merger.apply(req.DirectoryPath, pipeline.normalize(req.DirectoryPath, op))
+				if planValidator.Check(req.DirectoryPath, op) == nil {
+					onOperation(op)
+				}
+			}
+		}
+
+		// Pass the callback here
+		operations, err = o.aiService.GetSuggestions(ctx, prePass.structure, userPrompt, req.DirectoryPath, streamed)
+
+		if err != nil {
+			result.Error = fmt.Errorf("failed to get AI suggestions: %w", err)
+			return result
+		}
+
+		operations, result.Hallucinated = grounding.Filter(req.DirectoryPath, operations)
+		if len(result.Hallucinated) > 0 {
+			o.logger.Info("Dropped %d operations on paths that weren't in the scanned structure", len(result.Hallucinated))
+		}
+
+		for i := range operations {
+			operations[i] = merger.apply(req.DirectoryPath, pipeline.normalize(req.DirectoryPath, operations[i]))
+		}
+		var rejected []FileOperation
+		operations, rejected = planValidator.Filter(req.DirectoryPath, operations)
+		result.Rejected = append(result.Rejected, rejected...)
+
+		if req.SelfCritique {
+			operations, result.Removed = o.critiquePlan(ctx, prePass.structure, req, operations)
+		}
+	}
+	if len(result.Rejected) > 0 {
+		o.logger.Info("Discarded %d operations that break the directory's constraints", len(result.Rejected))
+	}
+
+	result.Merged = merger.result()
+	if len(result.Merged) > 0 {
+		o.logger.Info("Merged %d near-duplicate destination folders", len(result.Merged))
+	}
+
+	operations = append(ruleOperations, operations...)
+	if !req.SplitCompanions {
+		// Companions follow only as far as the model could have moved them itself: files that were
+		// scanned, to destinations the constraints allow
+		scanned := NewStructureGrounding(enrichedStructure)
+		var rejected []FileOperation
+		operations, result.Companions, rejected = ResolveCompanions(operations,
+			func(path string) bool { return scanned.Contains(req.DirectoryPath, path) },
+			func(op FileOperation) error { return planValidator.Check(req.DirectoryPath, op) })
+		if len(result.Companions) > 0 {
+			o.logger.Info("Added or redirected %d operations to keep companion files together", len(result.Companions))
+		}
+		if len(rejected) > 0 {
+			o.logger.Info("Discarded %d operations whose companion files can't move with them", len(rejected))
+			result.Rejected = append(result.Rejected, rejected...)
+		}
+	}
+	if req.SelfCritique && onOperation != nil {
+		for _, op := range operations {
+			onOperation(op)
+		}
+	}
+	result.Operations = operations
+
+	o.logger.Info("Analysis complete: %d operations suggested", len(operations))
+	return result
+}
+
+// critiquePlan runs the self-critique pass. If the review fails the plan is kept as it is.
+func (o *Orchestrator) critiquePlan(ctx context.Context, structure string, req AnalysisRequest, operations []FileOperation) (kept, removed []FileOperation) {
+	critic, ok := o.aiService.(PlanCritic)
+	if !ok || len(operations) == 0 {
+		return operations, nil
+	}
+
+	critiques, err := critic.CritiquePlan(ctx, structure, req.UserPrompt, req.DirectoryPath, operations)
+	if err != nil {
+		o.logger.Error("Plan review failed, keeping the plan unreviewed: %v", err)
+		return operations, nil
+	}
+
+	kept, removed = applyCritique(operations, critiques)
+	o.logger.Info("Plan review: %d operations removed, %d flagged", len(removed), len(critiques)-len(removed))
+	return kept, removed
+}
+
+// budgetContext returns the context for the LLM requests made for req, in req.BudgetRun or in a
+// run of their own
+func (o *Orchestrator) budgetContext(req AnalysisRequest) context.Context {
+	run := req.BudgetRun
+	if run == nil {
+		run = o.budget.BeginRun()
+	}
+	return WithBudgetRun(context.Background(), run)
+}
+
+// prepareStructure validates the request, indexes the directory if deep analysis needs it and
+// returns the structure to send to the model. It may turn off deep analysis in req.
+func (o *Orchestrator) prepareStructure(ctx context.Context, req *AnalysisRequest) (string, error) {
+	if err := o.prepareIndex(ctx, req); err != nil {
+		return "", err
+	}
+
+	o.logger.Info("Scanning directory: %s (depth: %d)", req.DirectoryPath, req.MaxDepth)
+	structure, err := o.fileService.GetDirectoryStructure(req.DirectoryPath, req.MaxDepth, req.OnScanProgress)
+	if err != nil {
+		return "", fmt.Errorf("failed to scan directory: %w", err)
+	}
+
+	// Enrich structure with descriptions from index if deep analysis is enabled
+	enrichedStructure := structure
+	if req.EnableDeepAnalysis && o.indexOrchestrator != nil && o.indexService != nil {
+		o.enrichMu.Lock()
+		cached, ok := o.enrichCache[req.DirectoryPath]
+		o.enrichMu.Unlock()
+
+		if ok && cached.structure == structure {
+			enrichedStructure = cached.enriched
+			o.logger.Info("Directory and index unchanged, reusing enriched structure")
+		} else {
+			enrichedStructure, err = o.enrichStructureWithDescriptions(req.DirectoryPath, structure)
+			if err != nil {
+				o.logger.Error("Failed to enrich structure with descriptions: %v", err)
+				// Fall back to basic structure
+				enrichedStructure = structure
+			} else {
+				o.logger.Info("Structure enriched with AI descriptions")
+				o.enrichMu.Lock()
+				o.enrichCache[req.DirectoryPath] = cachedEnrichment{structure: structure, enriched: enrichedStructure}
+				o.enrichMu.Unlock()
+			}
+		}
+	}
+
+	return enrichedStructure, nil
+}
+
+// prepareIndex validates the request and brings the index up to date if deep analysis needs it.
+// It may turn off deep analysis in req.
+func (o *Orchestrator) prepareIndex(ctx context.Context, req *AnalysisRequest) error {
+	if err := o.validator.ValidateDirectory(req.DirectoryPath); err != nil {
+		return err
+	}
+
+	if err := o.validator.ValidatePrompt(req.UserPrompt); err != nil {
+		return err
+	}
+
+	if err := o.hooks.Run(HookPayload{Event: HookPreAnalysis, BasePath: req.DirectoryPath, UserPrompt: req.UserPrompt, MaxDepth: req.MaxDepth}); err != nil {
+		return fmt.Errorf("analysis blocked by hook: %w", err)
+	}
+
+	// Deep analysis reads file contents from disk, which object storage prefixes don't support yet
+	if req.EnableDeepAnalysis && IsObjectStoragePath(req.DirectoryPath) {
+		o.logger.Info("Deep analysis is not available for object storage; planning from object keys only")
+		req.EnableDeepAnalysis = false
+	}
+
 	// Index the directory before analysis if deep analysis is enabled and there are files to index
 	if req.EnableDeepAnalysis && o.indexOrchestrator != nil && o.indexService != nil {
 		o.logger.Info("Checking if directory needs indexing: %s (depth: %d)", req.DirectoryPath, req.MaxDepth)
@@ -139,13 +578,22 @@ func (o *Orchestrator) AnalyzeDirectory(req AnalysisRequest, onOperation Operati
 		changes, err := o.indexService.ScanDirectoryChanges(req.DirectoryPath, req.MaxDepth)
 		if err != nil {
 			o.logger.Error("Failed to scan directory changes: %v", err)
+			o.invalidateStructureCaches(req.DirectoryPath)
 		} else {
 			totalToIndex := len(changes.NewFiles) + len(changes.ModifiedFiles)
-			if totalToIndex > 0 {
+			// The scan compares file mtimes, so it also catches in-place edits the structure cache can't see
+			if removed > 0 || totalToIndex > 0 || len(changes.DeletedFiles) > 0 {
+				o.invalidateStructureCaches(req.DirectoryPath)
+			}
+			if totalToIndex > 0 && req.ConfirmDeepAnalysis != nil && !req.ConfirmDeepAnalysis(append(changes.NewFiles, changes.ModifiedFiles...)) {
+				o.logger.Info("Deep analysis of %d files declined, planning with existing descriptions", totalToIndex)
+			} else if totalToIndex > 0 {
 				o.logger.Info("Found %d files to index, starting indexing...", totalToIndex)
-				if err := o.indexOrchestrator.IndexDirectory(req.DirectoryPath, req.MaxDepth, func(current, total int, fileName string) {
+				if err := o.indexOrchestrator.IndexDirectory(ctx, req.DirectoryPath, req.MaxDepth, func(current, total int, fileName string) {
 					o.logger.Debug("Indexing file %d/%d: %s", current, total, fileName)
-				}); err != nil {
+				}); errors.Is(err, ErrBudgetExceeded) {
+					return err
+				} else if err != nil {
 					o.logger.Error("Failed to index directory: %v", err)
 				} else {
 					o.logger.Info("Indexing complete")
@@ -156,45 +604,100 @@ func (o *Orchestrator) AnalyzeDirectory(req AnalysisRequest, onOperation Operati
 		}
 	}
 
-	o.logger.Info("Scanning directory: %s (depth: %d)", req.DirectoryPath, req.MaxDepth)
-	structure, err := o.fileService.GetDirectoryStructure(req.DirectoryPath, req.MaxDepth)
-	if err != nil {
-		result.Error = fmt.Errorf("failed to scan directory: %w", err)
-		return result
+	return nil
+}
+
+// executionMark returns the sequence number the next executed operation will get
+func (o *Orchestrator) executionMark() uint64 {
+	o.execMu.Lock()
+	defer o.execMu.Unlock()
+	return o.executionSeq
+}
+
+// recordExecuted appends successful operations to the execution log
+func (o *Orchestrator) recordExecuted(operations []FileOperation) {
+	o.execMu.Lock()
+	defer o.execMu.Unlock()
+	for _, op := range operations {
+		o.executed = append(o.executed, executedOperation{seq: o.executionSeq, op: op})
+		o.executionSeq++
+	}
+	if excess := len(o.executed) - maxExecutionLog; excess > 0 {
+		o.executed = append([]executedOperation(nil), o.executed[excess:]...)
+	}
+}
+
+// executedSince returns the operations executed at or after mark, in execution order
+func (o *Orchestrator) executedSince(mark uint64) []FileOperation {
+	o.execMu.Lock()
+	defer o.execMu.Unlock()
+	var operations []FileOperation
+	for _, executed := range o.executed {
+		if executed.seq >= mark {
+			operations = append(operations, executed.op)
+		}
 	}
+	return operations
+}
 
-	// Enrich structure with descriptions from index if deep analysis is enabled
-	enrichedStructure := structure
-	if req.EnableDeepAnalysis && o.indexOrchestrator != nil && o.indexService != nil {
-		enrichedStructure, err = o.enrichStructureWithDescriptions(req.DirectoryPath, structure)
-		if err != nil {
-			o.logger.Error("Failed to enrich structure with descriptions: %v", err)
-			// Fall back to basic structure
-			enrichedStructure = structure
-		} else {
-			o.logger.Info("Structure enriched with AI descriptions")
+// fingerprint summarizes dirPath so a plan made now can be checked for staleness before it runs
+func (o *Orchestrator) fingerprint(dirPath string, maxDepth int) *DirectoryFingerprint {
+	fingerprinter, ok := o.fileService.(Fingerprinter)
+	if !ok {
+		return nil
+	}
+	fp, err := fingerprinter.Fingerprint(dirPath, maxDepth)
+	if err != nil {
+		if !errors.Is(err, ErrFingerprintUnsupported) {
+			o.logger.Error("Failed to fingerprint %s: %v", dirPath, err)
 		}
+		return nil
 	}
+	return &fp
+}
 
-	result.Structure = enrichedStructure
+// CheckPlanFresh takes the directory's fingerprint again and reports whether it changed since
+// planned was taken. A nil planned fingerprint is never stale.
+func (o *Orchestrator) CheckPlanFresh(planned *DirectoryFingerprint) (now DirectoryFingerprint, changed bool) {
+	if planned == nil {
+		return DirectoryFingerprint{}, false
+	}
+	current := o.fingerprint(planned.Path, planned.MaxDepth)
+	if current == nil {
+		return DirectoryFingerprint{}, false
+	}
+	return *current, planned.Changed(*current)
+}
 
-	o.logger.Info("Requesting AI suggestions (Streaming)")
+// CheckWritable reports read-only or full locations the operations would write to, so a plan
+// that can't run is refused before execution rather than failing midway through
+func (o *Orchestrator) CheckWritable(operations []FileOperation) error {
+	return o.validator.ValidateWritable(operations)
+}
 
-	// Pass the callback here
-	operations, err := o.aiService.GetSuggestions(enrichedStructure, req.UserPrompt, req.DirectoryPath, onOperation)
+// RevalidatePlan checks the operations of a plan against the directory as it is now
+func (o *Orchestrator) RevalidatePlan(operations []FileOperation) (kept, dropped []FileOperation) {
+	return o.validator.RevalidatePlan(operations)
+}
 
-	if err != nil {
-		result.Error = fmt.Errorf("failed to get AI suggestions: %w", err)
-		return result
+// invalidateStructureCaches forgets cached and enriched structures for dirPath
+// (or for every directory when dirPath is empty)
+func (o *Orchestrator) invalidateStructureCaches(dirPath string) {
+	if inv, ok := o.fileService.(StructureCacheInvalidator); ok {
+		inv.InvalidateStructureCache(dirPath)
 	}
-	result.Operations = operations
 
-	o.logger.Info("Analysis complete: %d operations suggested", len(operations))
-	return result
+	o.enrichMu.Lock()
+	defer o.enrichMu.Unlock()
+	if dirPath == "" {
+		o.enrichCache = make(map[string]cachedEnrichment)
+		return
+	}
+	delete(o.enrichCache, dirPath)
 }
 
-func (o *Orchestrator) GetDirectoryStructure(path string, maxDepth int) (string, error) {
-	return o.fileService.GetDirectoryStructure(path, maxDepth)
+func (o *Orchestrator) GetDirectoryStructure(path string, maxDepth int, onProgress ScanProgressCallback) (string, error) {
+	return o.fileService.GetDirectoryStructure(path, maxDepth, onProgress)
 }
 
 // GetDirectoryIndexStats returns statistics about indexed files in a directory
@@ -218,7 +721,19 @@ func (o *Orchestrator) IndexDirectory(dirPath string, maxDepth int, onProgress f
 	if o.indexOrchestrator == nil {
 		return fmt.Errorf("index orchestrator not available")
 	}
-	return o.indexOrchestrator.IndexDirectory(dirPath, maxDepth, onProgress)
+	ctx := WithBudgetRun(context.Background(), o.budget.BeginRun())
+	return o.indexOrchestrator.IndexDirectory(ctx, dirPath, maxDepth, onProgress)
+}
+
+// ReanalyzeFiles replaces the descriptions of the given files with fresh ones, regardless of
+// whether they changed; see IndexDirectoryOrchestrator.ReanalyzeFiles
+func (o *Orchestrator) ReanalyzeFiles(filePaths []string, onProgress func(current, total int, fileName string)) (map[string]error, error) {
+	if o.indexOrchestrator == nil {
+		return nil, fmt.Errorf("index orchestrator not available")
+	}
+	defer o.invalidateStructureCaches("")
+	ctx := WithBudgetRun(context.Background(), o.budget.BeginRun())
+	return o.indexOrchestrator.ReanalyzeFiles(ctx, filePaths, onProgress)
 }
 
 // DeleteDirectoryIndex deletes all indexed files for a directory
@@ -226,6 +741,7 @@ func (o *Orchestrator) DeleteDirectoryIndex(dirPath string) (int, error) {
 	if o.indexService == nil {
 		return 0, fmt.Errorf("index service not available")
 	}
+	o.invalidateStructureCaches(dirPath)
 	return o.indexService.DeleteDirectoryIndex(dirPath)
 }
 
@@ -237,11 +753,194 @@ func (o *Orchestrator) GetIndexedFiles(dirPath string) ([]IndexedFile, error) {
 	return o.indexService.GetIndexedFilesInDirectory(dirPath)
 }
 
+// QueryIndexedFiles returns the indexed files matching query, with the filtering done by the
+// index database
+func (o *Orchestrator) QueryIndexedFiles(query IndexQuery) ([]IndexedFile, error) {
+	querier, ok := o.indexService.(IndexQuerier)
+	if !ok {
+		return nil, fmt.Errorf("index service can't filter indexed files")
+	}
+	return querier.QueryIndexedFiles(query)
+}
+
+// CountIndexedFileTypes returns how many files under dirPath are indexed or failed analysis, by type
+func (o *Orchestrator) CountIndexedFileTypes(dirPath string) (map[string]int, error) {
+	querier, ok := o.indexService.(IndexQuerier)
+	if !ok {
+		return nil, fmt.Errorf("index service can't filter indexed files")
+	}
+	return querier.CountIndexedFileTypes(dirPath)
+}
+
+// SetIndexSync enables sharing index descriptions with other machines
+func (o *Orchestrator) SetIndexSync(indexSync *IndexSyncService) {
+	o.indexSync = indexSync
+}
+
+// SyncIndex exchanges index descriptions with other machines through the configured sync folder
+func (o *Orchestrator) SyncIndex() (IndexSyncResult, error) {
+	if o.indexSync == nil {
+		return IndexSyncResult{}, fmt.Errorf("index service not available")
+	}
+	result, err := o.indexSync.Sync()
+	if result.Imported > 0 {
+		o.invalidateStructureCaches("")
+	}
+	return result, err
+}
+
+// SetAuditLog records every execution in audit
+func (o *Orchestrator) SetAuditLog(audit *AuditLog) {
+	o.audit = audit
+}
+
+// ExportAuditLog writes the audit log as CSV
+func (o *Orchestrator) ExportAuditLog(w io.Writer) error {
+	if o.audit == nil {
+		return fmt.Errorf("audit log not available")
+	}
+	return o.audit.ExportCSV(w)
+}
+
+// SetMetrics sets where analyses and executions are counted for the usage statistics
+func (o *Orchestrator) SetMetrics(metrics *UsageMetrics) {
+	o.metrics = metrics
+}
+
+// SetBudget sets the spending guard whose per-run ceiling restarts with every indexing or
+// re-analysis started here and every automated job; analyses started from the UI begin their own run
+func (o *Orchestrator) SetBudget(budget *BudgetGuard) {
+	o.budget = budget
+}
+
+// SetShutdown registers executions and indexing with shutdown, so quitting stops them between files
+func (o *Orchestrator) SetShutdown(shutdown *ShutdownCoordinator) {
+	o.shutdown = shutdown
+	if o.indexOrchestrator != nil {
+		o.indexOrchestrator.SetShutdown(shutdown)
+	}
+}
+
+// UsageStats summarizes the analyses and executions recorded since the given time
+func (o *Orchestrator) UsageStats(since time.Time) (UsageSummary, error) {
+	if o.metrics == nil {
+		return UsageSummary{}, fmt.Errorf("usage statistics not available")
+	}
+	return o.metrics.Summary(since)
+}
+
+// ResetUsageStats deletes the recorded usage statistics
+func (o *Orchestrator) ResetUsageStats() error {
+	if o.metrics == nil {
+		return nil
+	}
+	return o.metrics.Reset()
+}
+
+// SetPendingPlans sets the store where automated plans wait for review
+func (o *Orchestrator) SetPendingPlans(store *PendingPlanStore) {
+	o.pendingPlans = store
+}
+
+// PendingPlans returns the automated plans waiting for review, oldest first
+func (o *Orchestrator) PendingPlans() []PendingPlan {
+	if o.pendingPlans == nil {
+		return nil
+	}
+	return o.pendingPlans.List()
+}
+
+// ApprovePendingPlan executes a plan from the review queue with the options of req. The plan
+// leaves the queue only when every operation succeeded; otherwise the operations that didn't
+// run, or failed, go back into it.
+func (o *Orchestrator) ApprovePendingPlan(id string, req ExecutionRequest) (ExecutionResult, error) {
+	if o.pendingPlans == nil {
+		return ExecutionResult{}, fmt.Errorf("review queue not available")
+	}
+	// Taken for the execution, so it can't be approved twice or rebased onto its own moves
+	plan, err := o.pendingPlans.Take(id)
+	if err != nil {
+		return ExecutionResult{}, err
+	}
+	o.logger.Info("Executing approved plan from %s (%d operations)", plan.JobName, len(plan.Operations))
+	req.Operations = plan.Operations
+	req.BasePath = plan.BasePath
+	result := o.ExecuteOrganization(req)
+
+	succeeded := make(map[string]bool, len(result.Operations))
+	for _, opResult := range result.Operations {
+		if opResult.Success {
+			succeeded[filepath.Clean(opResult.Operation.From)] = true
+		}
+	}
+	var rest []FileOperation
+	for _, op := range plan.Operations {
+		if !succeeded[filepath.Clean(op.From)] {
+			rest = append(rest, op)
+		}
+	}
+	if len(rest) == 0 {
+		return result, nil
+	}
+	o.logger.Info("Returning %d of %d operations of the plan from %s to the review queue", len(rest), len(plan.Operations), plan.JobName)
+	plan.Operations = rest
+	plan.Reason = fmt.Sprintf("%d of %d operations didn't run when the plan was approved", len(rest), len(req.Operations))
+	if err := o.pendingPlans.Restore(plan); err != nil {
+		return result, fmt.Errorf("failed to return unexecuted operations to the review queue: %w", err)
+	}
+	return result, nil
+}
+
+// RejectPendingPlan discards a plan from the review queue without executing it
+func (o *Orchestrator) RejectPendingPlan(id string) error {
+	if o.pendingPlans == nil {
+		return fmt.Errorf("review queue not available")
+	}
+	plan, err := o.pendingPlans.Take(id)
+	if err == nil {
+		o.logger.Info("Rejected plan from %s (%d operations)", plan.JobName, len(plan.Operations))
+		o.corrections.Record(plan.BasePath, plan.Operations, CorrectionRejected)
+	}
+	return err
+}
+
+// SetCorrections sets where rejected and reverted moves are remembered
+func (o *Orchestrator) SetCorrections(store *CorrectionStore) {
+	o.corrections = store
+}
+
+// RecordCorrections remembers operations the user turned down, so later analyses of basePath
+// avoid them
+func (o *Orchestrator) RecordCorrections(basePath string, operations []FileOperation, kind string) {
+	o.corrections.Record(basePath, operations, kind)
+}
+
+// PastCorrections returns the remembered corrections for basePath
+func (o *Orchestrator) PastCorrections(basePath string) []Correction {
+	return o.corrections.List(basePath)
+}
+
+// ForgetCorrections clears the remembered corrections for basePath
+func (o *Orchestrator) ForgetCorrections(basePath string) error {
+	return o.corrections.Clear(basePath)
+}
+
+// RestorePermissions puts back the permissions an execution reported as changed
+func (o *Orchestrator) RestorePermissions(changes []PermissionChange) (int, error) {
+	restored, err := RestorePermissions(changes)
+	o.logger.Info("Restored permissions of %d of %d items", restored, len(changes))
+	if err != nil {
+		o.logger.Error("Failed to restore some permissions: %v", err)
+	}
+	return restored, err
+}
+
 // DeleteIndexEntry deletes a specific indexed file entry
 func (o *Orchestrator) DeleteIndexEntry(filePath string) error {
 	if o.indexService == nil {
 		return fmt.Errorf("index service not available")
 	}
+	o.invalidateStructureCaches("")
 	return o.indexService.RemoveFile(filePath)
 }
 
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// pathsOverlap reports whether a and b are the same path or one contains the other
+func pathsOverlap(a, b string) bool {
+	if a == b {
+		return true
+	}
+	sep := string(filepath.Separator)
+	return strings.HasPrefix(a, strings.TrimSuffix(b, sep)+sep) || strings.HasPrefix(b, strings.TrimSuffix(a, sep)+sep)
+}
+
+// operationsConflict reports whether two operations touch the same part of the tree and must keep
+// their plan order: a move into a folder and a move of that folder, chained renames (a→b, b→c),
+// two operations on the same path, or two moves into the same destination folder (or one inside
+// the other), which would race to create and label it. Moves into unrelated folders are independent.
+func operationsConflict(a, b FileOperation) bool {
+	if pathsOverlap(filepath.Dir(filepath.Clean(a.To)), filepath.Dir(filepath.Clean(b.To))) {
+		return true
+	}
+	aPaths := [...]string{filepath.Clean(a.From), filepath.Clean(a.To)}
+	bPaths := [...]string{filepath.Clean(b.From), filepath.Clean(b.To)}
+	for _, ap := range aPaths {
+		for _, bp := range bPaths {
+			if pathsOverlap(ap, bp) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// executeParallel runs operations on up to opts.Parallelism workers. Operations are dispatched in plan
+// order and an operation only starts once no conflicting earlier operation is still running, so any
+// two operations touching the same directories execute in the same order as they would sequentially.
+// Results are recorded in plan order so rollback can replay them in reverse.
+func (fs *DefaultFileService) executeParallel(result *ExecutionResult, operations []FileOperation, basePath string, opts ExecutionOptions) {
+	fs.logger.Info("Executing %d operations with up to %d parallel moves", len(operations), opts.Parallelism)
+
+	results := make([]OperationResult, len(operations))
+	done := make([]bool, len(operations))
+
+	var mu sync.Mutex
+	cond := sync.NewCond(&mu)
+	running := make(map[int]bool)
+	aborted := false
+
+	// Only one offline prompt at a time; once aborted, other workers stop without asking again
+	var promptMu sync.Mutex
+	onOffline := func(path string, err error) OfflineDecision {
+		promptMu.Lock()
+		defer promptMu.Unlock()
+		mu.Lock()
+		stop := aborted
+		mu.Unlock()
+		if stop || opts.OnOffline == nil {
+			return OfflineAbort
+		}
+		return opts.OnOffline(path, err)
+	}
+
+	// blocked reports whether op i must wait for a running operation. Caller must hold mu.
+	blocked := func(i int) bool {
+		if len(running) >= opts.Parallelism {
+			return true
+		}
+		for j := range running {
+			if operationsConflict(operations[i], operations[j]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	beat := heartbeatFor(opts)
+	var wg sync.WaitGroup
+	stopped := false
+	for i := range operations {
+		// Operations already running finish; nothing new starts while paused or after a stop
+		if !opts.Control.proceed() {
+			stopped = true
+			break
+		}
+		mu.Lock()
+		for !aborted && blocked(i) {
+			cond.Wait()
+		}
+		if aborted {
+			mu.Unlock()
+			break
+		}
+		running[i] = true
+		mu.Unlock()
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			opResult, ok := fs.executeWithOfflinePause(operations[i], basePath, onOffline, beat)
+
+			mu.Lock()
+			if ok {
+				results[i] = opResult
+				done[i] = true
+			} else {
+				aborted = true
+			}
+			delete(running, i)
+			cond.Broadcast()
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+
+	var remaining []FileOperation
+	for i, op := range operations {
+		if done[i] {
+			fs.recordResult(result, results[i])
+		} else {
+			remaining = append(remaining, op)
+		}
+	}
+	if aborted {
+		fs.abortRemaining(result, remaining, ErrPathOffline)
+	} else if stopped {
+		fs.stopRemaining(result, remaining)
+	}
+}
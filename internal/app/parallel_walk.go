@@ -0,0 +1,210 @@
+package app
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// walkOptions bounds how a tree is read: up to workers directories at once, and at most batch
+// directory listings read ahead of the walk. workers <= 1 walks sequentially with filepath.Walk.
+type walkOptions struct {
+	workers int
+	batch   int
+}
+
+// walkTree walks root like filepath.Walk: fn is called for every entry in lexical order from a
+// single goroutine, and may return filepath.SkipDir or filepath.SkipAll. With several workers,
+// the listings of the subdirectories of each visited directory are read in the background, which
+// hides the latency of network filesystems where each directory read is a round trip.
+func walkTree(root string, opts walkOptions, fn filepath.WalkFunc) error {
+	if opts.workers <= 1 {
+		return filepath.Walk(root, fn)
+	}
+	info, err := os.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		w := newParallelWalker(opts)
+		defer w.close()
+		err = w.walk(root, info, fn)
+	}
+	if errors.Is(err, filepath.SkipDir) || errors.Is(err, filepath.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+// dirListing is one directory's entries, sorted by name, as filepath.Walk would see them
+type dirListing struct {
+	started bool
+	done    chan struct{}
+	infos   []os.FileInfo
+	errs    []error // Lstat errors, by entry; the entry's info is nil
+	paths   []string
+	err     error // The directory itself couldn't be read
+}
+
+type parallelWalker struct {
+	opts     walkOptions
+	mu       sync.Mutex
+	cond     *sync.Cond
+	pending  []string // Directories to read ahead, the next one last
+	listings map[string]*dirListing
+	unread   int // Listings read ahead that the walk hasn't taken yet
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+func newParallelWalker(opts walkOptions) *parallelWalker {
+	w := &parallelWalker{opts: opts, listings: make(map[string]*dirListing)}
+	w.cond = sync.NewCond(&w.mu)
+	for i := 0; i < opts.workers; i++ {
+		w.wg.Add(1)
+		go w.work()
+	}
+	return w
+}
+
+func (w *parallelWalker) close() {
+	w.mu.Lock()
+	w.closed = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+	w.wg.Wait()
+}
+
+// work reads pending directories until the walker is closed, staying at most batch listings ahead
+func (w *parallelWalker) work() {
+	defer w.wg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for {
+		for !w.closed && (len(w.pending) == 0 || (w.opts.batch > 0 && w.unread >= w.opts.batch)) {
+			w.cond.Wait()
+		}
+		if w.closed {
+			return
+		}
+		dir := w.pending[len(w.pending)-1]
+		w.pending = w.pending[:len(w.pending)-1]
+		listing := w.listings[dir]
+		if listing == nil || listing.started {
+			continue
+		}
+		listing.started = true
+		w.unread++
+		w.mu.Unlock()
+		readListing(dir, listing)
+		w.mu.Lock()
+	}
+}
+
+// prefetch queues dirs to be read in the background, the first of them first
+func (w *parallelWalker) prefetch(dirs []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if w.listings[dirs[i]] == nil {
+			w.listings[dirs[i]] = &dirListing{done: make(chan struct{})}
+			w.pending = append(w.pending, dirs[i])
+		}
+	}
+	w.cond.Broadcast()
+}
+
+// discard forgets listings of dirs that weren't taken
+func (w *parallelWalker) discard(dirs []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, dir := range dirs {
+		if listing, ok := w.listings[dir]; ok {
+			delete(w.listings, dir)
+			if listing.started {
+				w.unread--
+			}
+		}
+	}
+	w.cond.Broadcast()
+}
+
+// take returns the listing of dir, reading it here unless a worker already started on it
+func (w *parallelWalker) take(dir string) *dirListing {
+	w.mu.Lock()
+	listing := w.listings[dir]
+	delete(w.listings, dir)
+	if listing == nil || !listing.started {
+		if listing == nil {
+			listing = &dirListing{done: make(chan struct{})}
+		}
+		listing.started = true
+		w.mu.Unlock()
+		readListing(dir, listing)
+		return listing
+	}
+	w.unread--
+	w.cond.Broadcast()
+	w.mu.Unlock()
+	<-listing.done
+	return listing
+}
+
+func readListing(dir string, listing *dirListing) {
+	defer close(listing.done)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		listing.err = err
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	listing.infos = make([]os.FileInfo, len(entries))
+	listing.errs = make([]error, len(entries))
+	listing.paths = make([]string, len(entries))
+	for i, entry := range entries {
+		listing.paths[i] = filepath.Join(dir, entry.Name())
+		// Like filepath.Walk, report what the entry is, not what a symlink points to
+		listing.infos[i], listing.errs[i] = os.Lstat(listing.paths[i])
+	}
+}
+
+func (w *parallelWalker) walk(path string, info os.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+
+	listing := w.take(path)
+	err := fn(path, info, listing.err)
+	// A directory that can't be read is reported once, as filepath.Walk does
+	if err != nil || listing.err != nil {
+		return err
+	}
+
+	var subdirs []string
+	for i, entryInfo := range listing.infos {
+		if entryInfo != nil && entryInfo.IsDir() {
+			subdirs = append(subdirs, listing.paths[i])
+		}
+	}
+	w.prefetch(subdirs)
+	// Listings read ahead for directories the walk never reached must not hold up the workers
+	defer w.discard(subdirs)
+
+	for i, entryPath := range listing.paths {
+		if listing.errs[i] != nil {
+			if err := fn(entryPath, nil, listing.errs[i]); err != nil && !errors.Is(err, fs.SkipDir) {
+				return err
+			}
+			continue
+		}
+		if err := w.walk(entryPath, listing.infos[i], fn); err != nil {
+			// SkipDir from a file skips the rest of its directory
+			if !listing.infos[i].IsDir() || !errors.Is(err, fs.SkipDir) {
+				return err
+			}
+		}
+	}
+	return nil
+}
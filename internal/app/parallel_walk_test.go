@@ -0,0 +1,123 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeWalkFixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, rel := range []string{
+		"a.txt", "b/c.txt", "b/d/e.txt", "b/d/f.txt", "b/z.txt",
+		"node_modules/pkg/index.js", "photos/2023/x.jpg", "photos/2024/y.jpg", "photos/2024/z.jpg",
+		"skip-rest/1.txt", "skip-rest/2.txt", "skip-rest/3.txt", "zz/last.txt",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(rel), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(root, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestWalkTreeMatchesFilepathWalk(t *testing.T) {
+	root := writeWalkFixture(t)
+	tests := []struct {
+		name string
+		skip func(rel string, info os.FileInfo) error
+	}{
+		{"everything", func(string, os.FileInfo) error { return nil }},
+		{"skip a directory", func(rel string, info os.FileInfo) error {
+			if rel == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}},
+		{"skip the rest of a directory from a file", func(rel string, info os.FileInfo) error {
+			if rel == "skip-rest/2.txt" || rel == "b/d/e.txt" {
+				return filepath.SkipDir
+			}
+			return nil
+		}},
+		{"skip all", func(rel string, info os.FileInfo) error {
+			if rel == "photos/2024" {
+				return filepath.SkipAll
+			}
+			return nil
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			visit := func(visited *[]string) filepath.WalkFunc {
+				return func(path string, info os.FileInfo, err error) error {
+					if err != nil {
+						return err
+					}
+					rel, _ := filepath.Rel(root, path)
+					rel = filepath.ToSlash(rel)
+					*visited = append(*visited, rel)
+					return tt.skip(rel, info)
+				}
+			}
+
+			var want []string
+			if err := filepath.Walk(root, visit(&want)); err != nil {
+				t.Fatal(err)
+			}
+			for _, opts := range []walkOptions{{workers: 1}, {workers: 4, batch: 64}, {workers: 3, batch: 1}} {
+				var got []string
+				if err := walkTree(root, opts, visit(&got)); err != nil {
+					t.Fatal(err)
+				}
+				if strings.Join(got, "\n") != strings.Join(want, "\n") {
+					t.Errorf("%+v visited\n%s\nwant\n%s", opts, strings.Join(got, "\n"), strings.Join(want, "\n"))
+				}
+			}
+		})
+	}
+}
+
+func TestWalkTreeMissingRoot(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "gone")
+	err := walkTree(missing, walkOptions{workers: 4}, func(path string, info os.FileInfo, err error) error {
+		if path != missing || info != nil || err == nil {
+			t.Errorf("unexpected call for %s (%v, %v)", path, info, err)
+		}
+		return err
+	})
+	if !os.IsNotExist(err) {
+		t.Errorf("walkTree() = %v, want a not-exist error", err)
+	}
+}
+
+func TestDirectoryStructureWithWalkWorkers(t *testing.T) {
+	root := writeWalkFixture(t)
+	sequential := NewFileService(NewValidator(), NewLogger(false))
+	sequential.SetIgnorePatterns("node_modules/")
+	parallel := NewFileService(NewValidator(), NewLogger(false))
+	parallel.SetIgnorePatterns("node_modules/")
+	parallel.SetWalkConcurrency(4, 2)
+
+	for _, depth := range []int{0, 2} {
+		want, err := sequential.GetDirectoryStructure(root, depth, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := parallel.GetDirectoryStructure(root, depth, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("depth %d: parallel walk listed\n%s\nwant\n%s", depth, got, want)
+		}
+	}
+}
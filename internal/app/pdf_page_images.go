@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+
+	"github.com/gen2brain/go-fitz"
+)
+
+const (
+	pdfPageImageDPI       = 100 // Enough to read body text once downscaled
+	visualPDFCharsPerPage = 100 // PDFs with less text than this per page are analyzed as images
+)
+
+// wantsPageImages reports whether a PDF with this much extracted text should be analyzed from
+// images of its pages
+func (das *DeepAnalysisService) wantsPageImages(text string, pages int) bool {
+	switch das.config.PDFPageImages {
+	case PDFPageImagesAlways:
+		return pages > 0
+	case PDFPageImagesNever:
+		return false
+	}
+	return pages > 0 && len([]rune(text)) < visualPDFCharsPerPage*pages
+}
+
+// pdfPageImageCount is how many pages are rendered for page image analysis
+func (das *DeepAnalysisService) pdfPageImageCount() int {
+	if das.config.PDFPageImageCount > 0 {
+		return das.config.PDFPageImageCount
+	}
+	return defaultPDFPageImageCount
+}
+
+// analyzePDFPages renders the first pages of a PDF and describes them with the multimodal model,
+// passing along whatever text the PDF has
+func (das *DeepAnalysisService) analyzePDFPages(ctx context.Context, doc *fitz.Document, filePath, text string) (string, error) {
+	totalPages := doc.NumPage()
+	count := min(das.pdfPageImageCount(), totalPages)
+
+	var imageURLs []string
+	for pageNum := range count {
+		page, err := doc.ImageDPI(pageNum, pdfPageImageDPI)
+		if err != nil {
+			return "", fmt.Errorf("failed to render page %d: %w", pageNum+1, err)
+		}
+		data, err := encodeJPEG(scaleImage(page, das.imageMaxDimension()))
+		if err != nil {
+			return "", err
+		}
+		imageURLs = append(imageURLs, "data:image/jpeg;base64,"+base64.StdEncoding.EncodeToString(data))
+	}
+	das.logger.Debug("Rendered %d of %d pages of %s for image analysis", count, totalPages, filePath)
+
+	userText := fmt.Sprintf("PDF file: %s\nPages: %d (images of the first %d follow)\n", filepath.Base(filePath), totalPages, count)
+	if text != "" {
+		userText += "\nExtracted text:\n" + das.truncateContent(text, 2000) + "\n"
+	}
+	userText += "\nDescribe only what is clearly visible:"
+
+	return das.analyzeImagesWithLLM(ctx, das.config.PDFAnalysisPrompt, userText, imageURLs)
+}
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePDF writes a PDF with one page per content stream
+func writePDF(t *testing.T, path string, pages ...string) {
+	t.Helper()
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+	for i, content := range pages {
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	var pdf strings.Builder
+	pdf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = pdf.Len()
+		fmt.Fprintf(&pdf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := pdf.Len()
+	fmt.Fprintf(&pdf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&pdf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&pdf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	if err := os.WriteFile(path, []byte(pdf.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAnalyzePDF_PageImages(t *testing.T) {
+	dir := t.TempDir()
+	slides := filepath.Join(dir, "slides.pdf")
+	writePDF(t, slides,
+		"0 0 1 rg 100 100 400 300 re f BT /F1 36 Tf 120 500 Td (Q3 Roadmap) Tj ET",
+		"1 0 0 rg 200 200 200 200 re f")
+	report := filepath.Join(dir, "report.pdf")
+	writePDF(t, report, "BT /F1 12 Tf 72 720 Td ("+strings.Repeat("Quarterly revenue grew in every region. ", 6)+") Tj ET")
+	blank := filepath.Join(dir, "blank.pdf")
+	writePDF(t, blank, "")
+
+	var request string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		request = string(body)
+		w.Write([]byte(`{"choices": [{"message": {"content": "A document."}}]}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		mode       string
+		file       string
+		wantImages int // -1 expects no request
+		wantText   bool
+	}{
+		{"slides as images", PDFPageImagesAuto, slides, 2, true},
+		{"text-heavy report as text", PDFPageImagesAuto, report, 0, true},
+		{"report forced to images", PDFPageImagesAlways, report, 1, true},
+		{"slides as text", PDFPageImagesNever, slides, 0, true},
+		{"blank page as an image", PDFPageImagesAuto, blank, 1, false},
+		{"blank page without images", PDFPageImagesNever, blank, -1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request = ""
+			config := &Config{Endpoint: server.URL, PDFPageImages: tt.mode, PDFPageImageCount: 3}
+			logger := NewLogger(false)
+			das := NewDeepAnalysisService(config, NewHTTPClient(config, logger), nil, logger)
+
+			_, err := das.AnalyzeFile(context.Background(), tt.file)
+			if tt.wantImages < 0 {
+				if err == nil || request != "" {
+					t.Fatalf("expected an error without a request, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Count(request, "data:image/jpeg;base64,"); got != tt.wantImages {
+				t.Errorf("expected %d page images, got %d", tt.wantImages, got)
+			}
+			hasText := strings.Contains(request, "Roadmap") || strings.Contains(request, "Quarterly revenue")
+			if hasText != tt.wantText {
+				t.Errorf("expected extracted text sent: %v, got %v", tt.wantText, hasText)
+			}
+		})
+	}
+}
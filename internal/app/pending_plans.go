@@ -0,0 +1,286 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrPendingPlanNotFound = errors.New("pending plan not found")
+
+// PendingPlanStore keeps plans from automated jobs until the user approves or rejects them.
+// Plans are persisted so they survive restarts while the user is away.
+type PendingPlanStore struct {
+	path   string
+	logger *Logger
+
+	mu       sync.Mutex
+	plans    []PendingPlan
+	nextID   int64
+	onChange func(count int)
+}
+
+// NewPendingPlanStore creates a store persisted to path (empty keeps plans in memory only)
+func NewPendingPlanStore(path string, logger *Logger) *PendingPlanStore {
+	s := &PendingPlanStore{
+		path:   path,
+		logger: logger,
+	}
+	s.load()
+	return s
+}
+
+// SetChangeHandler sets the callback invoked with the number of pending plans after every change
+func (s *PendingPlanStore) SetChangeHandler(handler func(count int)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = handler
+}
+
+// Enqueue adds a plan for review; it implements ReviewQueue
+func (s *PendingPlanStore) Enqueue(plan PendingPlan) error {
+	s.mu.Lock()
+	// IDs only need to be unique within the store; the time keeps them unique across restarts
+	s.nextID++
+	plan.ID = fmt.Sprintf("%d-%d", time.Now().UnixNano(), s.nextID)
+	if plan.CreatedAt.IsZero() {
+		plan.CreatedAt = time.Now()
+	}
+	s.plans = append(s.plans, plan)
+	err := s.saveLocked()
+	count, onChange := len(s.plans), s.onChange
+	s.mu.Unlock()
+
+	if onChange != nil {
+		onChange(count)
+	}
+	return err
+}
+
+// List returns the pending plans, oldest first
+func (s *PendingPlanStore) List() []PendingPlan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	plans := make([]PendingPlan, len(s.plans))
+	copy(plans, s.plans)
+	return plans
+}
+
+// Count returns the number of pending plans
+func (s *PendingPlanStore) Count() int {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.plans)
+}
+
+// Take removes the plan with id from the store and returns it
+func (s *PendingPlanStore) Take(id string) (PendingPlan, error) {
+	s.mu.Lock()
+	var taken PendingPlan
+	found := false
+	for i, plan := range s.plans {
+		if plan.ID == id {
+			taken, found = plan, true
+			s.plans = append(s.plans[:i], s.plans[i+1:]...)
+			break
+		}
+	}
+	if !found {
+		s.mu.Unlock()
+		return PendingPlan{}, fmt.Errorf("%w: %s", ErrPendingPlanNotFound, id)
+	}
+	if err := s.saveLocked(); err != nil {
+		// The plan is gone for this session either way; it may reappear after a restart
+		s.logger.Error("%v", err)
+	}
+	count, onChange := len(s.plans), s.onChange
+	s.mu.Unlock()
+
+	if onChange != nil {
+		onChange(count)
+	}
+	return taken, nil
+}
+
+// Restore puts a taken plan back under its ID, e.g. with the operations an approved execution
+// didn't get to
+func (s *PendingPlanStore) Restore(plan PendingPlan) error {
+	s.mu.Lock()
+	i := sort.Search(len(s.plans), func(i int) bool { return s.plans[i].CreatedAt.After(plan.CreatedAt) })
+	s.plans = slices.Insert(s.plans, i, plan)
+	err := s.saveLocked()
+	count, onChange := len(s.plans), s.onChange
+	s.mu.Unlock()
+
+	if onChange != nil {
+		onChange(count)
+	}
+	return err
+}
+
+// Rebase reconciles every pending plan with operations that were just executed. Operations that
+// conflict are removed and recorded on their plan; plans left with nothing to do are dropped.
+func (s *PendingPlanStore) Rebase(merger *PlanMerger, executed []FileOperation) {
+	if s == nil || len(executed) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	changed := false
+	kept := s.plans[:0]
+	for _, plan := range s.plans {
+		operations, conflicts := merger.Rebase(plan.Operations, executed)
+		if len(conflicts) == 0 && equalOperations(operations, plan.Operations) {
+			kept = append(kept, plan)
+			continue
+		}
+		changed = true
+		plan.Operations = operations
+		plan.Conflicts = append(plan.Conflicts, conflicts...)
+		if len(plan.Operations) == 0 && len(plan.Conflicts) == 0 {
+			s.logger.Info("Dropping pending plan from %s: everything in it was already done", plan.JobName)
+			continue
+		}
+		kept = append(kept, plan)
+	}
+	s.plans = kept
+
+	if !changed {
+		s.mu.Unlock()
+		return
+	}
+	if err := s.saveLocked(); err != nil {
+		s.logger.Error("%v", err)
+	}
+	count, onChange := len(s.plans), s.onChange
+	s.mu.Unlock()
+
+	if onChange != nil {
+		onChange(count)
+	}
+}
+
+// Purge removes operations touching paths that match, and plans for a matching folder or left
+// empty. It returns how many plans and operations were removed.
+func (s *PendingPlanStore) Purge(match func(path string) bool) (plans, operations int) {
+	if s == nil {
+		return 0, 0
+	}
+
+	s.mu.Lock()
+	kept := s.plans[:0]
+	for _, plan := range s.plans {
+		if match(plan.BasePath) {
+			plans++
+			operations += len(plan.Operations)
+			continue
+		}
+		remaining := plan.Operations[:0]
+		for _, op := range plan.Operations {
+			if match(op.From) || match(op.To) {
+				operations++
+				continue
+			}
+			remaining = append(remaining, op)
+		}
+		plan.Operations = remaining
+		if len(plan.Operations) == 0 {
+			plans++
+			continue
+		}
+		kept = append(kept, plan)
+	}
+	s.plans = kept
+
+	if operations == 0 && plans == 0 {
+		s.mu.Unlock()
+		return 0, 0
+	}
+	if err := s.saveLocked(); err != nil {
+		s.logger.Error("%v", err)
+	}
+	count, onChange := len(s.plans), s.onChange
+	s.mu.Unlock()
+
+	if onChange != nil {
+		onChange(count)
+	}
+	return plans, operations
+}
+
+func equalOperations(a, b []FileOperation) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *PendingPlanStore) load() {
+	if s.path == "" {
+		return
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.logger.Error("Failed to read pending plans: %v", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &s.plans); err != nil {
+		s.logger.Error("Failed to parse pending plans, starting with an empty queue: %v", err)
+		s.plans = nil
+	}
+}
+
+// saveLocked persists the plans. Caller must hold s.mu.
+func (s *PendingPlanStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.plans, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save pending plans: %w", err)
+	}
+	return nil
+}
+
+// FormatPlanDiff renders a plan as a diff-style preview with paths relative to the plan's base path
+func FormatPlanDiff(plan PendingPlan) string {
+	relative := func(path string) string {
+		if rel, err := filepath.Rel(plan.BasePath, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+		return path
+	}
+
+	var builder strings.Builder
+	for _, op := range plan.Operations {
+		builder.WriteString(fmt.Sprintf("- %s\n+ %s", relative(op.From), relative(op.To)))
+		if op.Confidence > 0 {
+			builder.WriteString(fmt.Sprintf("  (%.0f%% confident)", op.Confidence*100))
+		}
+		if EscapesBase(plan.BasePath, op.To) {
+			builder.WriteString("  " + OutOfScopeMarker)
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPendingPlanStorePersists(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "pending_plans.json")
+	logger := NewLogger(false)
+
+	store := NewPendingPlanStore(storePath, logger)
+	changes := 0
+	store.SetChangeHandler(func(count int) { changes = count })
+
+	for _, job := range []string{"Downloads", "Desktop"} {
+		if err := store.Enqueue(PendingPlan{JobName: job, BasePath: "/home/me/" + job}); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+	if changes != 2 {
+		t.Errorf("change handler saw %d plans, want 2", changes)
+	}
+
+	reloaded := NewPendingPlanStore(storePath, logger)
+	plans := reloaded.List()
+	if len(plans) != 2 || plans[0].JobName != "Downloads" || plans[0].ID == "" || plans[0].ID == plans[1].ID {
+		t.Fatalf("reloaded plans = %+v, want both jobs with distinct IDs", plans)
+	}
+
+	taken, err := reloaded.Take(plans[0].ID)
+	if err != nil || taken.JobName != "Downloads" {
+		t.Fatalf("Take() = %+v, %v", taken, err)
+	}
+	if _, err := reloaded.Take(plans[0].ID); !errors.Is(err, ErrPendingPlanNotFound) {
+		t.Errorf("second Take() error = %v, want ErrPendingPlanNotFound", err)
+	}
+	if count := NewPendingPlanStore(storePath, logger).Count(); count != 1 {
+		t.Errorf("persisted count after Take = %d, want 1", count)
+	}
+}
+
+func TestFormatPlanDiff(t *testing.T) {
+	plan := PendingPlan{
+		BasePath: "/data",
+		Operations: []FileOperation{
+			{From: "/data/invoice.pdf", To: "/data/finance/invoice.pdf", Confidence: 0.93},
+			{From: "/data/notes.txt", To: "/elsewhere/notes.txt"},
+		},
+	}
+
+	want := "- invoice.pdf\n+ finance/invoice.pdf  (93% confident)\n- notes.txt\n+ /elsewhere/notes.txt  " + OutOfScopeMarker + "\n"
+	if got := FormatPlanDiff(plan); got != want {
+		t.Errorf("FormatPlanDiff() = %q, want %q", got, want)
+	}
+}
+
+func TestApprovePendingPlan(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "invoice.pdf"), []byte("pdf"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := NewLogger(false)
+	validator := NewValidator()
+	orchestrator := NewOrchestrator(&stubAIService{}, NewFileService(validator, logger), validator, logger, nil, nil, NewHookRunner(&Config{}, logger))
+	store := NewPendingPlanStore("", logger)
+	orchestrator.SetPendingPlans(store)
+
+	store.Enqueue(PendingPlan{
+		JobName:    "Downloads",
+		BasePath:   dir,
+		Operations: []FileOperation{{From: filepath.Join(dir, "invoice.pdf"), To: filepath.Join(dir, "finance", "invoice.pdf")}},
+	})
+	store.Enqueue(PendingPlan{JobName: "Desktop", BasePath: dir})
+
+	plans := orchestrator.PendingPlans()
+	result, err := orchestrator.ApprovePendingPlan(plans[0].ID, ExecutionRequest{Parallelism: 1})
+	if err != nil || result.SuccessCount != 1 {
+		t.Fatalf("ApprovePendingPlan() = %+v, %v", result, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "finance", "invoice.pdf")); err != nil {
+		t.Errorf("approved plan was not executed: %v", err)
+	}
+
+	if err := orchestrator.RejectPendingPlan(plans[1].ID); err != nil {
+		t.Fatalf("RejectPendingPlan() error = %v", err)
+	}
+	if remaining := orchestrator.PendingPlans(); len(remaining) != 0 {
+		t.Errorf("expected an empty queue, got %+v", remaining)
+	}
+}
+
+func TestApprovePendingPlanKeepsFailedOperations(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "invoice.pdf"), []byte("pdf"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := NewLogger(false)
+	validator := NewValidator()
+	orchestrator := NewOrchestrator(&stubAIService{}, NewFileService(validator, logger), validator, logger, nil, nil, NewHookRunner(&Config{}, logger))
+	store := NewPendingPlanStore("", logger)
+	orchestrator.SetPendingPlans(store)
+
+	missing := FileOperation{From: filepath.Join(dir, "gone.pdf"), To: filepath.Join(dir, "finance", "gone.pdf")}
+	store.Enqueue(PendingPlan{JobName: "Earlier", BasePath: dir, CreatedAt: time.Now().Add(-time.Hour)})
+	store.Enqueue(PendingPlan{
+		JobName:  "Downloads",
+		BasePath: dir,
+		Operations: []FileOperation{
+			{From: filepath.Join(dir, "invoice.pdf"), To: filepath.Join(dir, "finance", "invoice.pdf")},
+			missing,
+		},
+	})
+
+	id := orchestrator.PendingPlans()[1].ID
+	result, err := orchestrator.ApprovePendingPlan(id, ExecutionRequest{Parallelism: 1})
+	if err != nil || result.SuccessCount != 1 || result.FailCount != 1 {
+		t.Fatalf("ApprovePendingPlan() = %+v, %v", result, err)
+	}
+	plans := orchestrator.PendingPlans()
+	if len(plans) != 2 || plans[1].ID != id {
+		t.Fatalf("plan did not go back into the queue in its place: %+v", plans)
+	}
+	if len(plans[1].Operations) != 1 || plans[1].Operations[0] != missing {
+		t.Errorf("operations back in the queue = %+v, want only the failed one", plans[1].Operations)
+	}
+}
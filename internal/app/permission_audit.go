@@ -0,0 +1,143 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// auditedModeBits are the mode bits compared before and after a move
+const auditedModeBits = os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+
+// maxAuditedEntries bounds how many items inside a moved folder are recorded
+const maxAuditedEntries = 10000
+
+// FileOwner is the numeric user and group owning a file on Unix systems
+type FileOwner struct {
+	UID int
+	GID int
+}
+
+func (o FileOwner) String() string {
+	return fmt.Sprintf("%d:%d", o.UID, o.GID)
+}
+
+// PermissionChange is a moved file or folder (or an item inside a moved folder) whose
+// permissions or owner differ from before the move. Renames normally keep them, but network
+// shares (e.g. Samba create masks and forced users) and filesystems without Unix modes can
+// apply their own.
+type PermissionChange struct {
+	Path        string      // Location after the move
+	Before      os.FileMode // Permissions at the original location
+	After       os.FileMode
+	OwnerBefore *FileOwner // Only set when the owner or group changed
+	OwnerAfter  *FileOwner
+}
+
+func (c PermissionChange) String() string {
+	var parts []string
+	if c.Before != c.After {
+		parts = append(parts, fmt.Sprintf("%v → %v", c.Before, c.After))
+	}
+	if c.OwnerBefore != nil {
+		parts = append(parts, fmt.Sprintf("owner %v → %v", *c.OwnerBefore, *c.OwnerAfter))
+	}
+	return c.Path + ": " + strings.Join(parts, ", ")
+}
+
+// permissionState is the audited state of an operation's source or of an item inside it
+type permissionState struct {
+	rel   string // Suffix appended to the operation's path; empty for the source itself
+	mode  os.FileMode
+	owner *FileOwner // Nil where files have no Unix owner
+}
+
+func newPermissionState(info os.FileInfo) permissionState {
+	return permissionState{mode: info.Mode() & auditedModeBits, owner: fileOwner(info)}
+}
+
+// snapshotPermissions records the permissions and owner of every operation's source, and of
+// everything inside sources that are folders. Symlinks are skipped: their own mode is
+// meaningless on most systems.
+func snapshotPermissions(operations []FileOperation) map[string][]permissionState {
+	snapshot := make(map[string][]permissionState, len(operations))
+	for _, op := range operations {
+		info, err := os.Lstat(op.From)
+		if err != nil || info.Mode()&os.ModeSymlink != 0 {
+			continue
+		}
+		states := []permissionState{newPermissionState(info)}
+		if info.IsDir() {
+			filepath.WalkDir(op.From, func(p string, d fs.DirEntry, err error) error {
+				if err != nil || p == op.From || d.Type()&fs.ModeSymlink != 0 {
+					return nil
+				}
+				if len(states) > maxAuditedEntries {
+					return filepath.SkipAll
+				}
+				info, err := d.Info()
+				if err != nil {
+					return nil
+				}
+				state := newPermissionState(info)
+				state.rel = p[len(op.From):]
+				states = append(states, state)
+				return nil
+			})
+		}
+		snapshot[op.From] = states
+	}
+	return snapshot
+}
+
+// auditPermissions compares the destinations of successful operations with the snapshot taken before execution
+func auditPermissions(results []OperationResult, before map[string][]permissionState) []PermissionChange {
+	var changes []PermissionChange
+	for _, opResult := range results {
+		if !opResult.Success {
+			continue
+		}
+		for _, state := range before[opResult.Operation.From] {
+			path := opResult.Operation.To + state.rel
+			info, err := os.Lstat(path)
+			if err != nil {
+				continue
+			}
+			after := newPermissionState(info)
+			change := PermissionChange{Path: path, Before: state.mode, After: after.mode}
+			if state.owner != nil && after.owner != nil && *state.owner != *after.owner {
+				change.OwnerBefore, change.OwnerAfter = state.owner, after.owner
+			}
+			if change.Before != change.After || change.OwnerBefore != nil {
+				changes = append(changes, change)
+			}
+		}
+	}
+	return changes
+}
+
+// RestorePermissions sets each changed path back to its original permissions and owner and
+// returns how many were restored. Changing the owner usually needs administrator rights.
+func RestorePermissions(changes []PermissionChange) (int, error) {
+	restored := 0
+	var errs []error
+	for _, change := range changes {
+		if change.Before != change.After {
+			if err := os.Chmod(change.Path, change.Before); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", change.Path, err))
+				continue
+			}
+		}
+		if change.OwnerBefore != nil {
+			if err := os.Lchown(change.Path, change.OwnerBefore.UID, change.OwnerBefore.GID); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", change.Path, err))
+				continue
+			}
+		}
+		restored++
+	}
+	return restored, errors.Join(errs...)
+}
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestPermissionAudit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permission bits are not supported on Windows")
+	}
+
+	tests := []struct {
+		name        string
+		changeAfter bool // Simulate a share applying its own mode to the moved file
+		wantChanges int
+	}{
+		{name: "rename keeps permissions", wantChanges: 0},
+		{name: "changed permissions are reported and restored", changeAfter: true, wantChanges: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			from := filepath.Join(dir, "report.txt")
+			to := filepath.Join(dir, "docs", "report.txt")
+			if err := os.WriteFile(from, []byte("report"), 0640); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chmod(from, 0640); err != nil {
+				t.Fatal(err)
+			}
+
+			ops := []FileOperation{{From: from, To: to}}
+			before := snapshotPermissions(ops)
+
+			fs := NewFileService(NewValidator(), NewLogger(false))
+			opResult := fs.ExecuteOperation(ops[0])
+			if !opResult.Success {
+				t.Fatalf("move failed: %v", opResult.Error)
+			}
+			if tt.changeAfter {
+				if err := os.Chmod(to, 0666); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			changes := auditPermissions([]OperationResult{opResult}, before)
+			if len(changes) != tt.wantChanges {
+				t.Fatalf("got %d changes, want %d: %v", len(changes), tt.wantChanges, changes)
+			}
+			if len(changes) == 0 {
+				return
+			}
+
+			if restored, err := RestorePermissions(changes); err != nil || restored != 1 {
+				t.Fatalf("RestorePermissions() = %d, %v", restored, err)
+			}
+			info, err := os.Stat(to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != 0640 {
+				t.Errorf("mode after restore = %v, want %v", info.Mode().Perm(), os.FileMode(0640))
+			}
+		})
+	}
+}
+
+func TestPermissionAuditInsideMovedFolder(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permission bits are not supported on Windows")
+	}
+
+	dir := t.TempDir()
+	from := filepath.Join(dir, "photos")
+	to := filepath.Join(dir, "Archive", "photos")
+	if err := os.MkdirAll(filepath.Join(from, "2024"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(from, "2024", "a.jpg"), []byte("jpg"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	ops := []FileOperation{{From: from, To: to}}
+	before := snapshotPermissions(ops)
+	opResult := NewFileService(NewValidator(), NewLogger(false)).ExecuteOperation(ops[0])
+	if !opResult.Success {
+		t.Fatalf("move failed: %v", opResult.Error)
+	}
+	moved := filepath.Join(to, "2024", "a.jpg")
+	if err := os.Chmod(moved, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	changes := auditPermissions([]OperationResult{opResult}, before)
+	if len(changes) != 1 || changes[0].Path != moved || changes[0].Before != 0640 {
+		t.Fatalf("expected the file inside the folder to be reported, got %v", changes)
+	}
+}
+
+func TestPermissionAuditOwner(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() != 0 {
+		t.Skip("changing a file's owner needs root on a Unix system")
+	}
+
+	dir := t.TempDir()
+	from := filepath.Join(dir, "report.txt")
+	to := filepath.Join(dir, "docs", "report.txt")
+	if err := os.WriteFile(from, []byte("report"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ops := []FileOperation{{From: from, To: to}}
+	before := snapshotPermissions(ops)
+	opResult := NewFileService(NewValidator(), NewLogger(false)).ExecuteOperation(ops[0])
+	if !opResult.Success {
+		t.Fatalf("move failed: %v", opResult.Error)
+	}
+	// Simulate a share forcing its own user onto new files
+	if err := os.Lchown(to, 12345, 12345); err != nil {
+		t.Fatal(err)
+	}
+
+	changes := auditPermissions([]OperationResult{opResult}, before)
+	if len(changes) != 1 || changes[0].OwnerAfter == nil || *changes[0].OwnerAfter != (FileOwner{UID: 12345, GID: 12345}) {
+		t.Fatalf("expected the owner change to be reported, got %v", changes)
+	}
+	if restored, err := RestorePermissions(changes); err != nil || restored != 1 {
+		t.Fatalf("RestorePermissions() = %d, %v", restored, err)
+	}
+	if after := fileOwner(mustLstat(t, to)); after == nil || *after != *changes[0].OwnerBefore {
+		t.Errorf("owner after restore = %v, want %v", after, changes[0].OwnerBefore)
+	}
+}
+
+func mustLstat(t *testing.T, path string) os.FileInfo {
+	t.Helper()
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package app
+
+import "os"
+
+// fileOwner returns nil: only Unix systems have numeric owners to compare
+func fileOwner(os.FileInfo) *FileOwner {
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package app
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileOwner returns the user and group owning the file info describes
+func fileOwner(info os.FileInfo) *FileOwner {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	return &FileOwner{UID: int(stat.Uid), GID: int(stat.Gid)}
+}
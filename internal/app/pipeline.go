@@ -0,0 +1,218 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+var ErrInvalidPipeline = errors.New("unrecognized pipeline step")
+
+// ruleReasonPrefix marks operations planned by a pipeline rule rather than the model
+const ruleReasonPrefix = "rule: "
+
+// PipelineRule moves files matching Glob into Folder before the model plans, or keeps them where
+// they are when Folder is empty. Matched files are never sent to the model.
+type PipelineRule struct {
+	Glob   string
+	Folder string
+	Line   string // The rule as the user wrote it
+}
+
+// Pipeline is how a directory is planned: a deterministic rule pre-pass, the model for the files
+// no rule matched, then a normalization post-pass over the new folders of every destination
+type Pipeline struct {
+	Rules          []PipelineRule
+	SkipAI         bool   // Files no rule matched stay where they are
+	NamingStyle    string // NamingStyleNone keeps folder names as planned
+	NormalizeDates bool
+}
+
+// pipelineNamingStyles are the styles a normalize step accepts
+var pipelineNamingStyles = []string{NamingStyleKebab, NamingStyleSnake, NamingStyleTitle, NamingStyleLower}
+
+// ParsePipeline reads one step per line, in any order. Blank lines and lines starting with # are
+// skipped. Rules are tried top to bottom and the first match wins:
+//
+//	move *.pdf to Documents        (glob on the file name, or on the path if it contains /)
+//	keep *.psd                     (left where it is and not sent to the model)
+//	no ai                          (files no rule matched are left alone)
+//	normalize kebab-case           (or snake_case, title-case, lower-case)
+//	normalize dates                (leading dates of new folders become YYYY-MM-DD)
+func ParsePipeline(text string) (Pipeline, error) {
+	var p Pipeline
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := p.parseStep(line); err != nil {
+			return Pipeline{}, fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+	return p, nil
+}
+
+func (p *Pipeline) parseStep(line string) error {
+	lower := strings.ToLower(line)
+	glob := func(g string) (string, error) {
+		g = strings.Trim(strings.TrimSpace(g), `"'`)
+		if g == "" || !doublestar.ValidatePattern(g) {
+			return "", fmt.Errorf("%w: bad pattern in %q", ErrInvalidPipeline, line)
+		}
+		return g, nil
+	}
+
+	switch {
+	case lower == "no ai" || lower == "skip ai":
+		p.SkipAI = true
+	case lower == "normalize dates":
+		p.NormalizeDates = true
+	case strings.HasPrefix(lower, "normalize "):
+		style := strings.TrimSpace(lower[len("normalize "):])
+		for _, known := range pipelineNamingStyles {
+			if style == known {
+				p.NamingStyle = style
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: unknown naming style in %q (use %s)", ErrInvalidPipeline, line, strings.Join(pipelineNamingStyles, ", "))
+	case strings.HasPrefix(lower, "keep "):
+		g, err := glob(line[len("keep "):])
+		if err != nil {
+			return err
+		}
+		p.Rules = append(p.Rules, PipelineRule{Glob: g, Line: line})
+	case strings.HasPrefix(lower, "move "):
+		rest := line[len("move "):]
+		sep := strings.LastIndex(strings.ToLower(rest), " to ")
+		if sep < 0 {
+			return fmt.Errorf("%w: expected \"move <pattern> to <folder>\", got %q", ErrInvalidPipeline, line)
+		}
+		g, err := glob(rest[:sep])
+		if err != nil {
+			return err
+		}
+		folder := path.Clean(strings.Trim(strings.TrimSpace(rest[sep+len(" to "):]), `"'/`))
+		if folder == "." || folder == ".." || strings.HasPrefix(folder, "../") {
+			return fmt.Errorf("%w: bad folder in %q", ErrInvalidPipeline, line)
+		}
+		p.Rules = append(p.Rules, PipelineRule{Glob: g, Folder: folder, Line: line})
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidPipeline, line)
+	}
+	return nil
+}
+
+// Empty reports whether the pipeline changes nothing about planning
+func (p Pipeline) Empty() bool {
+	return len(p.Rules) == 0 && !p.SkipAI && p.NamingStyle == NamingStyleNone && !p.NormalizeDates
+}
+
+// match returns the first rule matching rel (relative, with forward slashes)
+func (p Pipeline) match(rel string) (PipelineRule, bool) {
+	for _, rule := range p.Rules {
+		target := rel
+		if !strings.Contains(rule.Glob, "/") {
+			target = path.Base(rel)
+		}
+		if ok, _ := doublestar.Match(rule.Glob, target); ok {
+			return rule, true
+		}
+	}
+	return PipelineRule{}, false
+}
+
+// pipelinePrePass is what the rules decided before the model is asked
+type pipelinePrePass struct {
+	operations []FileOperation
+	structure  string // Without the files the rules handled
+	leftovers  int    // Files still listed in structure
+}
+
+// prePass runs the rules over the files of a text structure produced by GetDirectoryStructure
+func (p Pipeline) prePass(basePath, structure string) pipelinePrePass {
+	var result pipelinePrePass
+	var kept []string
+	for _, line := range strings.Split(structure, "\n") {
+		m := structureFileLine.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			kept = append(kept, line)
+			continue
+		}
+		rule, ok := p.match(m[1])
+		if !ok {
+			kept = append(kept, line)
+			result.leftovers++
+			continue
+		}
+		if rule.Folder == "" || strings.EqualFold(path.Dir(m[1]), rule.Folder) {
+			continue
+		}
+		from, err := JoinStoragePath(basePath, m[1])
+		if err != nil {
+			continue
+		}
+		to, err := JoinStoragePath(basePath, rule.Folder+"/"+path.Base(m[1]))
+		if err != nil {
+			kept = append(kept, line)
+			result.leftovers++
+			continue
+		}
+		op := FileOperation{
+			From:       from,
+			To:         to,
+			Confidence: 1,
+			Reason:     ruleReasonPrefix + rule.Line,
+		}
+		result.operations = append(result.operations, p.normalize(basePath, op))
+	}
+	result.structure = strings.Join(kept, "\n")
+	return result
+}
+
+// normalize is the post-pass applied to every planned operation
+func (p Pipeline) normalize(basePath string, op FileOperation) FileOperation {
+	return normalizeNewFolders(basePath, op, p.NamingStyle, p.NormalizeDates)
+}
+
+// rulesPromptText tells the model which folders the rules are already filling, so its plan can
+// use them too
+func rulesPromptText(basePath string, operations []FileOperation) string {
+	seen := make(map[string]bool)
+	var folders []string
+	for _, op := range operations {
+		folder := path.Dir(relativeSlashPath(basePath, op.To))
+		if !seen[folder] {
+			seen[folder] = true
+			folders = append(folders, folder)
+		}
+	}
+	if len(folders) == 0 {
+		return ""
+	}
+	sort.Strings(folders)
+	return "\n\nOther files were already sorted by rules into these folders, which you may also use: " + strings.Join(folders, ", ")
+}
+
+// PipelineFor returns the pipeline text saved for dir
+func (c *Config) PipelineFor(dir string) string {
+	return c.DirectoryPipelines[directoryKey(dir)]
+}
+
+// SetPipeline saves the pipeline text for dir; empty text removes it
+func (c *Config) SetPipeline(dir, text string) {
+	key := directoryKey(dir)
+	if strings.TrimSpace(text) == "" {
+		delete(c.DirectoryPipelines, key)
+		return
+	}
+	if c.DirectoryPipelines == nil {
+		c.DirectoryPipelines = make(map[string]string)
+	}
+	c.DirectoryPipelines[key] = text
+}
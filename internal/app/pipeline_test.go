@@ -0,0 +1,120 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParsePipeline(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    Pipeline
+		wantErr bool
+	}{
+		{
+			name: "all steps",
+			text: "# sort the obvious ones\nmove *.pdf to Documents/\nkeep \"*.psd\"\nNo AI\nnormalize kebab-case\nnormalize dates",
+			want: Pipeline{
+				Rules: []PipelineRule{
+					{Glob: "*.pdf", Folder: "Documents", Line: "move *.pdf to Documents/"},
+					{Glob: "*.psd", Line: "keep \"*.psd\""},
+				},
+				SkipAI:         true,
+				NamingStyle:    NamingStyleKebab,
+				NormalizeDates: true,
+			},
+		},
+		{name: "empty", text: "\n  \n# nothing yet", want: Pipeline{}},
+		{name: "unknown step", text: "sort *.pdf", wantErr: true},
+		{name: "unknown style", text: "normalize camelCase", wantErr: true},
+		{name: "move without folder", text: "move *.pdf", wantErr: true},
+		{name: "folder outside the directory", text: "move *.pdf to ../elsewhere", wantErr: true},
+		{name: "bad pattern", text: "keep [", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePipeline(tt.text)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPipeline) {
+					t.Fatalf("ParsePipeline() error = %v, want ErrInvalidPipeline", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Rules) != len(tt.want.Rules) || got.SkipAI != tt.want.SkipAI || got.NamingStyle != tt.want.NamingStyle || got.NormalizeDates != tt.want.NormalizeDates {
+				t.Fatalf("ParsePipeline() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got.Rules {
+				if got.Rules[i] != tt.want.Rules[i] {
+					t.Errorf("rule %d = %+v, want %+v", i, got.Rules[i], tt.want.Rules[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzeDirectoryPipeline(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.pdf", "cover.psd", "notes.txt", "Documents/old.pdf"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logger := NewLogger(false)
+	validator := NewValidator()
+	config := &Config{}
+	config.SetPipeline(dir, "move *.pdf to Documents\nkeep *.psd\nnormalize kebab-case")
+	ai := &stubAIService{operations: []FileOperation{
+		{From: "notes.txt", To: "My Notes/notes.txt"},
+		{From: "a.pdf", To: "Other/a.pdf"}, // Handled by a rule, so the model never saw it
+	}}
+	orchestrator := NewOrchestrator(ai, NewFileService(validator, logger), validator, logger, nil, nil, NewHookRunner(config, logger))
+
+	var streamed []string
+	result := orchestrator.AnalyzeDirectory(AnalysisRequest{DirectoryPath: dir, UserPrompt: "Sort", MaxDepth: 2, Pipeline: config.PipelineFor(dir)}, func(op FileOperation) {
+		streamed = append(streamed, relativeSlashPath(dir, op.To))
+	})
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+
+	var got []string
+	for _, op := range result.Operations {
+		got = append(got, relativeSlashPath(dir, op.From)+" -> "+relativeSlashPath(dir, op.To))
+	}
+	want := "a.pdf -> Documents/a.pdf, notes.txt -> my-notes/notes.txt"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("plan = %v, want %s", got, want)
+	}
+	if strings.Join(streamed, ", ") != "Documents/a.pdf, my-notes/notes.txt" {
+		t.Errorf("streamed %v", streamed)
+	}
+	if result.FromRules != 1 || len(result.Hallucinated) != 1 {
+		t.Errorf("FromRules = %d, Hallucinated = %d; want 1 each", result.FromRules, len(result.Hallucinated))
+	}
+	if !strings.Contains(ai.lastPrompt, "Documents") {
+		t.Errorf("expected the rule folders in the prompt, got %q", ai.lastPrompt)
+	}
+
+	// Once the rules cover every file the model isn't asked at all
+	ai.lastPrompt = ""
+	config.SetPipeline(dir, "move *.pdf to Documents\nkeep *")
+	result = orchestrator.AnalyzeDirectory(AnalysisRequest{DirectoryPath: dir, UserPrompt: "Sort", MaxDepth: 2, Pipeline: config.PipelineFor(dir)}, nil)
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	if ai.lastPrompt != "" || len(result.Operations) != 1 {
+		t.Errorf("model asked: %v, %d operations; want no request and the one rule move", ai.lastPrompt != "", len(result.Operations))
+	}
+}
@@ -0,0 +1,205 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+var ErrConstraintViolated = errors.New("breaks a constraint for this directory")
+
+// Constraint rule kinds
+const (
+	constraintNoRename   = "no-rename"    // Files keep their names
+	constraintMaxDepth   = "max-depth"    // Destinations are at most N folders deep
+	constraintNeverTouch = "never-touch"  // Matching files are not moved at all
+	constraintNeverOutOf = "never-out-of" // Nothing leaves the folder
+	constraintNeverInto  = "never-into"   // Nothing is moved into the folder
+)
+
+var maxDepthPattern = regexp.MustCompile(`(?i)^(?:max(?:imum)? depth|only create up to|at most|max(?:imum)?)\s+(\d+)(?:\s+folder)?(?:\s+levels?)?(?:\s+deep)?$`)
+
+// PlanConstraint is one enforceable rule from a directory's constraints
+type PlanConstraint struct {
+	Kind  string
+	Arg   string // Glob for never-touch, folder for never-out-of/never-into
+	Depth int    // For max-depth
+	Line  string // The rule as the user wrote it
+}
+
+// PlanConstraints are the rules a plan for one directory must obey. Every line is sent to the
+// model; only the lines recognized as rules are also enforced.
+type PlanConstraints struct {
+	Rules      []PlanConstraint
+	Unenforced []string // Free-form lines the model is asked to follow but that can't be checked
+	lines      []string
+}
+
+// ParseConstraints reads one constraint per line. Blank lines and lines starting with # are
+// skipped. Recognized rules:
+//
+//	never rename
+//	max depth 2                    (also "only create up to 2 folder levels")
+//	never touch *.psd              (glob on the file name, or on the path if it contains /)
+//	never move out of Scans
+//	never move into Archive
+func ParseConstraints(text string) PlanConstraints {
+	var constraints PlanConstraints
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		constraints.lines = append(constraints.lines, line)
+
+		if rule, ok := parseConstraint(line); ok {
+			constraints.Rules = append(constraints.Rules, rule)
+		} else {
+			constraints.Unenforced = append(constraints.Unenforced, line)
+		}
+	}
+	return constraints
+}
+
+func parseConstraint(line string) (PlanConstraint, bool) {
+	lower := strings.ToLower(strings.TrimSuffix(line, "."))
+	arg := func(prefix string) string {
+		return strings.Trim(strings.TrimSpace(strings.TrimSuffix(line, ".")[len(prefix):]), `"'/`)
+	}
+
+	switch {
+	case lower == "never rename" || lower == "never rename files" || lower == "no renames" || lower == "no renaming":
+		return PlanConstraint{Kind: constraintNoRename, Line: line}, true
+	case strings.HasPrefix(lower, "never touch "):
+		if glob := arg("never touch "); glob != "" && doublestar.ValidatePattern(glob) {
+			return PlanConstraint{Kind: constraintNeverTouch, Arg: glob, Line: line}, true
+		}
+	case strings.HasPrefix(lower, "never move anything out of "):
+		if dir := arg("never move anything out of "); dir != "" {
+			return PlanConstraint{Kind: constraintNeverOutOf, Arg: dir, Line: line}, true
+		}
+	case strings.HasPrefix(lower, "never move out of "):
+		if dir := arg("never move out of "); dir != "" {
+			return PlanConstraint{Kind: constraintNeverOutOf, Arg: dir, Line: line}, true
+		}
+	case strings.HasPrefix(lower, "never move into "):
+		if dir := arg("never move into "); dir != "" {
+			return PlanConstraint{Kind: constraintNeverInto, Arg: dir, Line: line}, true
+		}
+	}
+
+	if match := maxDepthPattern.FindStringSubmatch(lower); match != nil {
+		if depth, err := strconv.Atoi(match[1]); err == nil && depth > 0 {
+			return PlanConstraint{Kind: constraintMaxDepth, Depth: depth, Line: line}, true
+		}
+	}
+	return PlanConstraint{}, false
+}
+
+// Empty reports whether there are no constraints at all
+func (c PlanConstraints) Empty() bool {
+	return len(c.lines) == 0
+}
+
+// PromptText is the instruction appended to the user prompt, or "" without constraints
+func (c PlanConstraints) PromptText() string {
+	if c.Empty() {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\nHard constraints for this directory. Operations that break them are discarded:")
+	for _, line := range c.lines {
+		sb.WriteString("\n- ")
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
+
+// PlanValidator checks operations against a directory's constraints after the model has
+// planned them, since the model doesn't always follow instructions
+type PlanValidator struct {
+	constraints PlanConstraints
+}
+
+func NewPlanValidator(constraints PlanConstraints) *PlanValidator {
+	return &PlanValidator{constraints: constraints}
+}
+
+// Check returns an error wrapping ErrConstraintViolated if op breaks a rule
+func (v *PlanValidator) Check(basePath string, op FileOperation) error {
+	from := relativeSlashPath(basePath, op.From)
+	to := relativeSlashPath(basePath, op.To)
+
+	for _, rule := range v.constraints.Rules {
+		violated := false
+		switch rule.Kind {
+		case constraintNoRename:
+			violated = path.Base(from) != path.Base(to)
+		case constraintMaxDepth:
+			violated = folderDepth(path.Dir(to)) > rule.Depth
+		case constraintNeverTouch:
+			target := from
+			if !strings.Contains(rule.Arg, "/") {
+				target = path.Base(from)
+			}
+			violated, _ = doublestar.Match(rule.Arg, target)
+		case constraintNeverOutOf:
+			violated = isWithin(from, rule.Arg) && !isWithin(to, rule.Arg)
+		case constraintNeverInto:
+			violated = !isWithin(from, rule.Arg) && isWithin(to, rule.Arg)
+		}
+		if violated {
+			return fmt.Errorf("%w: %s", ErrConstraintViolated, rule.Line)
+		}
+	}
+	return nil
+}
+
+// Filter splits a plan into operations that obey the constraints and those that don't. Rejected
+// operations carry the broken rule in Flag.
+func (v *PlanValidator) Filter(basePath string, operations []FileOperation) (kept, rejected []FileOperation) {
+	for _, op := range operations {
+		if err := v.Check(basePath, op); err != nil {
+			op.Flag = err.Error()
+			rejected = append(rejected, op)
+			continue
+		}
+		kept = append(kept, op)
+	}
+	return kept, rejected
+}
+
+func folderDepth(dir string) int {
+	if dir == "." || dir == "" {
+		return 0
+	}
+	return strings.Count(dir, "/") + 1
+}
+
+func isWithin(relPath, dir string) bool {
+	dir = strings.Trim(dir, "/")
+	return strings.EqualFold(relPath, dir) || strings.HasPrefix(strings.ToLower(relPath), strings.ToLower(dir)+"/")
+}
+
+// ConstraintsFor returns the constraint text saved for dir
+func (c *Config) ConstraintsFor(dir string) string {
+	return c.DirectoryConstraints[directoryKey(dir)]
+}
+
+// SetConstraints saves the constraint text for dir; empty text removes it
+func (c *Config) SetConstraints(dir, text string) {
+	key := directoryKey(dir)
+	if strings.TrimSpace(text) == "" {
+		delete(c.DirectoryConstraints, key)
+		return
+	}
+	if c.DirectoryConstraints == nil {
+		c.DirectoryConstraints = make(map[string]string)
+	}
+	c.DirectoryConstraints[key] = text
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseConstraints(t *testing.T) {
+	constraints := ParseConstraints(`
+# Rules for the photo library
+never rename
+only create up to 2 folder levels
+never touch *.psd
+never move anything out of /Scans/
+Never move into Archive.
+keep things tidy
+`)
+
+	var kinds []string
+	for _, rule := range constraints.Rules {
+		kinds = append(kinds, rule.Kind)
+	}
+	want := []string{constraintNoRename, constraintMaxDepth, constraintNeverTouch, constraintNeverOutOf, constraintNeverInto}
+	if strings.Join(kinds, ",") != strings.Join(want, ",") {
+		t.Errorf("kinds = %v, want %v", kinds, want)
+	}
+	if constraints.Rules[1].Depth != 2 || constraints.Rules[3].Arg != "Scans" || constraints.Rules[4].Arg != "Archive" {
+		t.Errorf("unexpected rule arguments: %+v", constraints.Rules)
+	}
+	if len(constraints.Unenforced) != 1 || constraints.Unenforced[0] != "keep things tidy" {
+		t.Errorf("Unenforced = %v", constraints.Unenforced)
+	}
+
+	prompt := constraints.PromptText()
+	if !strings.Contains(prompt, "- keep things tidy") || strings.Contains(prompt, "# Rules") {
+		t.Errorf("unexpected prompt text: %q", prompt)
+	}
+	if ParseConstraints("  \n# only a comment").PromptText() != "" {
+		t.Error("Expected no prompt text without constraints")
+	}
+}
+
+func TestPlanValidatorCheck(t *testing.T) {
+	base := filepath.Join(string(filepath.Separator), "data")
+	join := func(rel string) string { return filepath.Join(base, filepath.FromSlash(rel)) }
+
+	tests := []struct {
+		name        string
+		constraints string
+		from, to    string
+		wantErr     bool
+	}{
+		{"rename allowed", "max depth 2", "a.txt", "docs/b.txt", false},
+		{"rename forbidden", "never rename", "a.txt", "docs/b.txt", true},
+		{"move keeps name", "never rename", "a.txt", "docs/a.txt", false},
+		{"too deep", "max depth 2", "a.txt", "docs/2024/jan/a.txt", true},
+		{"deep enough", "max depth 2", "a.txt", "docs/2024/a.txt", false},
+		{"protected glob", "never touch *.psd", "art/cover.psd", "images/cover.psd", true},
+		{"protected path glob", "never touch art/**", "art/sub/x.png", "images/x.png", true},
+		{"other file", "never touch *.psd", "art/cover.png", "images/cover.png", false},
+		{"leaves folder", "never move out of Scans", "Scans/a.pdf", "Docs/a.pdf", true},
+		{"stays in folder", "never move out of scans", "Scans/a.pdf", "Scans/2024/a.pdf", false},
+		{"enters folder", "never move into Archive", "a.pdf", "Archive/a.pdf", true},
+		{"similar prefix", "never move into Archive", "a.pdf", "Archives/a.pdf", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := NewPlanValidator(ParseConstraints(tt.constraints))
+			err := validator.Check(base, FileOperation{From: join(tt.from), To: join(tt.to)})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrConstraintViolated) {
+				t.Errorf("Expected ErrConstraintViolated, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAnalyzeDirectoryEnforcesConstraints(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.pdf", "cover.psd"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := &Config{}
+	config.SetConstraints(dir, "never touch *.psd")
+	logger := NewLogger(false)
+	validator := NewValidator()
+	ai := &stubAIService{operations: []FileOperation{
+		{From: "a.pdf", To: "docs/a.pdf"},
+		{From: "cover.psd", To: "art/cover.psd"},
+	}}
+	orchestrator := NewOrchestrator(ai, NewFileService(validator, logger), validator, logger, nil, nil, NewHookRunner(config, logger))
+
+	var streamed int
+	result := orchestrator.AnalyzeDirectory(AnalysisRequest{DirectoryPath: dir, UserPrompt: "Sort", Constraints: config.ConstraintsFor(dir)}, func(FileOperation) { streamed++ })
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	if len(result.Operations) != 1 || len(result.Rejected) != 1 || streamed != 1 {
+		t.Errorf("got %d kept, %d rejected, %d streamed; want 1 each", len(result.Operations), len(result.Rejected), streamed)
+	}
+	if !strings.Contains(ai.lastPrompt, "never touch *.psd") {
+		t.Errorf("Expected the constraints in the prompt, got %q", ai.lastPrompt)
+	}
+}
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Critique verdicts
+const (
+	CritiqueRemove = "remove" // The move is wrong; it is dropped from the plan
+	CritiqueFlag   = "flag"   // The move is risky; it stays but is marked for the user
+)
+
+const planCritiqueSystemPrompt = `You review file organization plans written by another assistant before a user sees them.
+For each numbered move, check it against the user's instructions and the existing directory structure. Look for moves that:
+- contradict or go beyond the instructions
+- break up things that belong together (projects, source trees, photo sets, app data)
+- put files in folders that don't match their name or type
+- would make files hard to find or overwrite other files
+
+Output one JSON object per line for each problematic move, and nothing for moves that are fine:
+{"index": 3, "verdict": "remove", "reason": "Splits a source tree from its build files"}
+Use "remove" when the move is clearly wrong and "flag" when it is questionable but may be intended.
+Output nothing else: no markdown, no commentary. If every move is fine, output nothing.`
+
+// OperationCritique is the reviewer's verdict on one operation of a plan
+type OperationCritique struct {
+	Index   int    `json:"index"` // 1-based position in the reviewed plan
+	Verdict string `json:"verdict"`
+	Reason  string `json:"reason"`
+}
+
+// CritiquePlan asks the model to review a plan it produced against the instructions and the
+// existing structure. Only operations with problems are returned.
+func (s *OpenAIService) CritiquePlan(ctx context.Context, structure, userPrompt, basePath string, operations []FileOperation) ([]OperationCritique, error) {
+	var plan strings.Builder
+	plan.WriteString(s.buildUserPrompt(basePath, structure, userPrompt))
+	plan.WriteString("\n\nProposed moves:\n")
+	for i, op := range operations {
+		fmt.Fprintf(&plan, "%d. %s -> %s\n", i+1, relativeToBase(basePath, op.From), relativeToBase(basePath, op.To))
+	}
+
+	reqBody := OpenAIRequest{
+		Model: s.config.Model,
+		Messages: []Message{
+			{Role: "system", Content: planCritiqueSystemPrompt},
+			{Role: "user", Content: plan.String()},
+		},
+		MaxTokens:   s.config.planMaxTokens(),
+		Temperature: s.config.Temperature,
+		Stream:      false,
+	}
+
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", s.config.APIKey),
+		"HTTP-Referer":  "https://github.com/sandwichdoge/vibesandfolders",
+		"X-Title":       "VibesAndFolders",
+	}
+
+	s.logger.Info("Asking %s to review %d operations", s.config.Model, len(operations))
+	body, err := s.httpClient.Post(ctx, s.config.Endpoint, headers, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("no response from LLM")
+	}
+
+	return parseCritique(response.Choices[0].Message.Content, len(operations)), nil
+}
+
+// parseCritique reads one verdict per line, skipping anything that isn't a valid verdict for
+// one of count operations
+func parseCritique(content string, count int) []OperationCritique {
+	var critiques []OperationCritique
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), ","))
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var critique OperationCritique
+		if err := json.Unmarshal([]byte(line), &critique); err != nil {
+			continue
+		}
+		if critique.Index < 1 || critique.Index > count {
+			continue
+		}
+		if critique.Verdict != CritiqueRemove && critique.Verdict != CritiqueFlag {
+			continue
+		}
+		critiques = append(critiques, critique)
+	}
+	return critiques
+}
+
+// applyCritique drops removed operations and marks flagged ones. Removed operations are
+// returned separately with the reviewer's reason, so the user can see what was taken out.
+func applyCritique(operations []FileOperation, critiques []OperationCritique) (kept, removed []FileOperation) {
+	verdicts := make(map[int]OperationCritique, len(critiques))
+	for _, critique := range critiques {
+		// Keep the harsher verdict if the model repeats an index
+		if previous, ok := verdicts[critique.Index]; ok && previous.Verdict == CritiqueRemove {
+			continue
+		}
+		verdicts[critique.Index] = critique
+	}
+
+	for i, op := range operations {
+		critique, ok := verdicts[i+1]
+		if !ok {
+			kept = append(kept, op)
+			continue
+		}
+		op.Flag = critique.Reason
+		if critique.Verdict == CritiqueRemove {
+			removed = append(removed, op)
+		} else {
+			kept = append(kept, op)
+		}
+	}
+	return kept, removed
+}
+
+func relativeToBase(basePath, path string) string {
+	if IsObjectStoragePath(basePath) {
+		return strings.TrimPrefix(strings.TrimPrefix(path, strings.TrimSuffix(basePath, "/")), "/")
+	}
+	if rel, err := filepath.Rel(basePath, path); err == nil {
+		return rel
+	}
+	return path
+}
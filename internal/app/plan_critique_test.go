@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseCritique(t *testing.T) {
+	content := "```json\n" +
+		`{"index": 1, "verdict": "remove", "reason": "breaks the project"}` + "\n" +
+		`{"index": 2, "verdict": "flag", "reason": "odd folder"},` + "\n" +
+		`{"index": 7, "verdict": "remove", "reason": "no such move"}` + "\n" +
+		`{"index": 3, "verdict": "delete", "reason": "unknown verdict"}` + "\n" +
+		"Everything else looks fine.\n```"
+
+	critiques := parseCritique(content, 3)
+	if len(critiques) != 2 {
+		t.Fatalf("got %d critiques, want 2: %+v", len(critiques), critiques)
+	}
+	if critiques[0].Verdict != CritiqueRemove || critiques[1].Verdict != CritiqueFlag {
+		t.Errorf("unexpected verdicts: %+v", critiques)
+	}
+}
+
+func TestApplyCritique(t *testing.T) {
+	operations := []FileOperation{
+		{From: "a.go", To: "code/a.go"},
+		{From: "b.jpg", To: "docs/b.jpg"},
+		{From: "c.pdf", To: "docs/c.pdf"},
+	}
+	critiques := []OperationCritique{
+		{Index: 1, Verdict: CritiqueRemove, Reason: "splits the project"},
+		{Index: 2, Verdict: CritiqueFlag, Reason: "images in docs"},
+		{Index: 1, Verdict: CritiqueFlag, Reason: "repeated"},
+	}
+
+	kept, removed := applyCritique(operations, critiques)
+	if len(removed) != 1 || removed[0].From != "a.go" || removed[0].Flag != "splits the project" {
+		t.Errorf("unexpected removed operations: %+v", removed)
+	}
+	if len(kept) != 2 || kept[0].Flag != "images in docs" || kept[1].Flag != "" {
+		t.Errorf("unexpected kept operations: %+v", kept)
+	}
+}
+
+// critiquingAIService reviews every plan by removing its first operation
+type critiquingAIService struct {
+	stubAIService
+}
+
+func (s *critiquingAIService) CritiquePlan(_ context.Context, structure, userPrompt, basePath string, operations []FileOperation) ([]OperationCritique, error) {
+	return []OperationCritique{{Index: 1, Verdict: CritiqueRemove, Reason: "wrong"}}, nil
+}
+
+func TestAnalyzeDirectorySelfCritique(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.pdf", "b.pdf"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("pdf"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := &Config{}
+	logger := NewLogger(false)
+	validator := NewValidator()
+	ai := &critiquingAIService{stubAIService{operations: []FileOperation{
+		{From: "a.pdf", To: "docs/a.pdf"},
+		{From: "b.pdf", To: "docs/b.pdf"},
+	}}}
+	orchestrator := NewOrchestrator(ai, NewFileService(validator, logger), validator, logger, nil, nil, NewHookRunner(config, logger))
+
+	var streamed []FileOperation
+	result := orchestrator.AnalyzeDirectory(AnalysisRequest{DirectoryPath: dir, UserPrompt: "Sort", SelfCritique: true}, func(op FileOperation) {
+		streamed = append(streamed, op)
+	})
+	if result.Error != nil {
+		t.Fatalf("AnalyzeDirectory failed: %v", result.Error)
+	}
+	if len(result.Operations) != 1 || len(result.Removed) != 1 {
+		t.Fatalf("got %d kept and %d removed operations, want 1 and 1", len(result.Operations), len(result.Removed))
+	}
+	if len(streamed) != 1 || streamed[0].From != filepath.Join(dir, "b.pdf") {
+		t.Errorf("Expected only the kept operation to be streamed, got %+v", streamed)
+	}
+}
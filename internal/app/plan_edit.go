@@ -0,0 +1,86 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+var (
+	ErrOutsideBase     = errors.New("destination is outside the scanned folder")
+	ErrInvalidName     = errors.New("name is not valid on every system")
+	ErrMoveIntoItself  = errors.New("a folder cannot be moved into itself")
+	ErrInvalidPlanItem = errors.New("operation is not in the plan")
+)
+
+// EditDestination returns plan[index] with its destination changed to newTo, typed relative to
+// basePath. As with NewManualOperation, a trailing slash or a scanned folder keeps the current
+// name. The edit is rejected if it collides with the plan or the disk, or touches a path the
+// folder's constraints protect.
+func EditDestination(basePath string, plan []FileOperation, index int, newTo string, grounding *StructureGrounding, constraints PlanConstraints) (FileOperation, error) {
+	if index < 0 || index >= len(plan) {
+		return FileOperation{}, ErrInvalidPlanItem
+	}
+	folderTarget := strings.HasSuffix(strings.TrimSpace(newTo), "/")
+	to := cleanManualPath(newTo)
+	if to == "" && !folderTarget {
+		return FileOperation{}, ErrEmptyManualTarget
+	}
+
+	op := plan[index]
+	if folderTarget || (grounding != nil && grounding.IsFolder(to)) {
+		to = path.Join(to, path.Base(relativeSlashPath(basePath, op.From)))
+	}
+	var err error
+	if op.To, err = JoinStoragePath(basePath, to); err != nil {
+		return FileOperation{}, err
+	}
+	op.Adjusted = ""
+	if err := checkDestination(basePath, plan, index, op, grounding, constraints); err != nil {
+		return FileOperation{}, err
+	}
+	return op, nil
+}
+
+// checkDestination validates op.To against the rest of the plan (skip is op's own index, or -1),
+// the files already on disk, the scanned folders and the directory's constraints
+func checkDestination(basePath string, plan []FileOperation, skip int, op FileOperation, grounding *StructureGrounding, constraints PlanConstraints) error {
+	from := relativeSlashPath(basePath, op.From)
+	to := relativeSlashPath(basePath, op.To)
+	if EscapesBase(basePath, op.To) {
+		return fmt.Errorf("%w: %s", ErrOutsideBase, op.To)
+	}
+	if to == from {
+		return fmt.Errorf("%w: %s", ErrDestinationExists, to)
+	}
+	if strings.HasPrefix(to, from+"/") {
+		return fmt.Errorf("%w: %s", ErrMoveIntoItself, from)
+	}
+	for _, name := range strings.Split(to, "/") {
+		if _, changes := SanitizeName(name); len(changes) > 0 {
+			return fmt.Errorf("%w: %q (%s)", ErrInvalidName, name, strings.Join(changes, ", "))
+		}
+	}
+	if bundle, ok := containingBundle(op.To); ok {
+		return fmt.Errorf("%w: %s", ErrInsideBundle, bundle)
+	}
+
+	for i, planned := range plan {
+		if i != skip && planned.To == op.To {
+			return fmt.Errorf("%w: %s", ErrDestinationTaken, to)
+		}
+	}
+	// Moves may run in parallel, so a file another operation moves away still blocks its place
+	if !IsObjectStoragePath(basePath) {
+		if _, err := os.Lstat(op.To); err == nil {
+			return fmt.Errorf("%w: %s", ErrDestinationExists, to)
+		}
+	}
+	if err := checkNearDuplicate(basePath, grounding, plan, skip, op); err != nil {
+		return err
+	}
+
+	return NewPlanValidator(constraints).Check(basePath, op)
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEditDestination(t *testing.T) {
+	useOpaqueBundles(t, true)
+	base := t.TempDir()
+	for _, dir := range []string{"docs", "photos", "Archive"} {
+		if err := os.Mkdir(filepath.Join(base, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"a.pdf", "b.pdf", "docs/c.pdf"} {
+		if err := os.WriteFile(filepath.Join(base, filepath.FromSlash(file)), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g := NewStructureGrounding("docs/\ndocs/c.pdf (1 bytes)\nphotos/\nArchive/\na.pdf (1 bytes)\nb.pdf (1 bytes)\n")
+	plan := []FileOperation{
+		{From: filepath.Join(base, "a.pdf"), To: filepath.Join(base, "pdf", "a.pdf"), Adjusted: "was a?.pdf"},
+		{From: filepath.Join(base, "b.pdf"), To: filepath.Join(base, "pdf", "b.pdf")},
+		{From: filepath.Join(base, "docs", "c.pdf"), To: filepath.Join(base, "pdf", "c.pdf")},
+		{From: filepath.Join(base, "photos"), To: filepath.Join(base, "media", "photos")},
+	}
+	constraints := ParseConstraints("never move into Archive")
+
+	tests := []struct {
+		name    string
+		index   int
+		to      string
+		wantTo  string
+		wantErr error
+	}{
+		{"rename", 0, "pdf/invoice.pdf", "pdf/invoice.pdf", nil},
+		{"into scanned folder", 0, "docs", "docs/a.pdf", nil},
+		{"into new folder", 0, "papers/", "papers/a.pdf", nil},
+		{"collides with plan", 0, "pdf/b.pdf", "", ErrDestinationTaken},
+		{"collides with disk", 0, "b.pdf", "", ErrDestinationExists},
+		{"moved away by another operation", 0, "docs/c.pdf", "", ErrDestinationExists},
+		{"outside base", 0, "../a.pdf", "", ErrOutsideBase},
+		{"invalid name", 0, "pdf/a?.pdf", "", ErrInvalidName},
+		{"inside bundle", 0, "Notes.app/a.pdf", "", ErrInsideBundle},
+		{"constraint", 0, "Archive/", "", ErrConstraintViolated},
+		{"near-duplicate of scanned folder", 0, "Docs/", "", ErrNearDuplicateFolder},
+		{"near-duplicate of planned folder", 3, "PDF/", "", ErrNearDuplicateFolder},
+		{"folder into itself", 3, "photos/old/", "", ErrMoveIntoItself},
+		{"empty", 0, "", "", ErrEmptyManualTarget},
+		{"bad index", 9, "x.pdf", "", ErrInvalidPlanItem},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op, err := EditDestination(base, plan, tt.index, tt.to, g, constraints)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := filepath.Join(base, filepath.FromSlash(tt.wantTo)); op.To != want {
+				t.Errorf("To = %q, want %q", op.To, want)
+			}
+			if op.From != plan[tt.index].From || op.Adjusted != "" {
+				t.Errorf("unexpected operation %+v", op)
+			}
+		})
+	}
+}
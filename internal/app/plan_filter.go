@@ -0,0 +1,96 @@
+package app
+
+import (
+	"path"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// planFilterTerm is one condition of a PlanFilter
+type planFilterTerm struct {
+	field   string // "from", "to" or "" for either side
+	pattern string // Lowercased; a glob if it has wildcards, otherwise a substring
+	glob    bool
+}
+
+// PlanFilter narrows a long plan down to the operations worth auditing. Terms are separated by
+// spaces and must all match. A term is a substring, or a glob like *.pdf when it has wildcards,
+// matched against the source or destination. Prefix a term with from: or to: to only look at one
+// side, e.g. "*.pdf to:Taxes". Matching ignores case.
+type PlanFilter struct {
+	terms []planFilterTerm
+}
+
+func ParsePlanFilter(query string) PlanFilter {
+	var f PlanFilter
+	for _, word := range strings.Fields(query) {
+		term := planFilterTerm{}
+		lower := strings.ToLower(word)
+		for _, field := range []string{"from:", "to:"} {
+			if strings.HasPrefix(lower, field) {
+				term.field = strings.TrimSuffix(field, ":")
+				lower = strings.TrimPrefix(lower, field)
+				break
+			}
+		}
+		if lower == "" {
+			continue
+		}
+		term.pattern = lower
+		term.glob = strings.ContainsAny(lower, "*?[")
+		f.terms = append(f.terms, term)
+	}
+	return f
+}
+
+// Empty reports whether the filter lets every operation through
+func (f PlanFilter) Empty() bool {
+	return len(f.terms) == 0
+}
+
+// Match reports whether op satisfies every term
+func (f PlanFilter) Match(basePath string, op FileOperation) bool {
+	from := strings.ToLower(relativeSlashPath(basePath, op.From))
+	to := strings.ToLower(relativeSlashPath(basePath, op.To))
+	for _, term := range f.terms {
+		matched := false
+		switch term.field {
+		case "from":
+			matched = term.matches(from)
+		case "to":
+			matched = term.matches(to)
+		default:
+			matched = term.matches(from) || term.matches(to)
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// Indices returns the positions of the operations that match, so edits can find their way back
+func (f PlanFilter) Indices(basePath string, operations []FileOperation) []int {
+	indices := make([]int, 0, len(operations))
+	for i, op := range operations {
+		if f.Match(basePath, op) {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// matches checks one relative, lowercased path. A glob without a slash is matched against the
+// name only, like the constraints' "never touch" rules.
+func (t planFilterTerm) matches(rel string) bool {
+	if !t.glob {
+		return strings.Contains(rel, t.pattern)
+	}
+	target := rel
+	if !strings.Contains(t.pattern, "/") {
+		target = path.Base(rel)
+	}
+	ok, _ := doublestar.Match(t.pattern, target)
+	return ok
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPlanFilter(t *testing.T) {
+	base := filepath.Join(string(filepath.Separator), "home", "me", "Downloads")
+	op := func(from, to string) FileOperation {
+		return FileOperation{From: filepath.Join(base, filepath.FromSlash(from)), To: filepath.Join(base, filepath.FromSlash(to))}
+	}
+	plan := []FileOperation{
+		op("invoice-2023.pdf", "Taxes/2023/invoice-2023.pdf"),
+		op("scan.PDF", "Scans/scan.PDF"),
+		op("taxes.xlsx", "Spreadsheets/taxes.xlsx"),
+		op("photos/cat.jpg", "Pictures/cat.jpg"),
+	}
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"", []int{0, 1, 2, 3}},
+		{"*.pdf", []int{0, 1}},
+		{"to:taxes/", []int{0}},
+		{"taxes", []int{0, 2}},
+		{"*.pdf to:Taxes", []int{0}},
+		{"from:photos/*", []int{3}},
+		{"to:**/2023/*", []int{0}},
+		{"from:taxes", []int{2}},
+		{"to:", []int{0, 1, 2, 3}},
+		{"*.docx", []int{}},
+	}
+	for _, tt := range tests {
+		f := ParsePlanFilter(tt.query)
+		if got := f.Indices(base, plan); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.query, got, tt.want)
+		}
+		if f.Empty() != (tt.query == "" || tt.query == "to:") {
+			t.Errorf("%q: Empty() = %v", tt.query, f.Empty())
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DirectoryFingerprint is a cheap summary of a directory, taken when a plan is made and compared
+// again before it is executed. Adding, removing or renaming anything changes a count or the
+// modification time of the folder it is in.
+type DirectoryFingerprint struct {
+	Path         string
+	MaxDepth     int // The scan depth of the plan; 0 is unlimited
+	Files        int
+	Folders      int
+	LatestChange time.Time // Newest modification time of any file or folder
+}
+
+var ErrFingerprintUnsupported = errors.New("this location can't be checked for changes")
+
+// Fingerprinter is implemented by file services that can fingerprint their directories
+type Fingerprinter interface {
+	Fingerprint(rootPath string, maxDepth int) (DirectoryFingerprint, error)
+}
+
+// Changed reports whether now differs from f
+func (f DirectoryFingerprint) Changed(now DirectoryFingerprint) bool {
+	return f.Files != now.Files || f.Folders != now.Folders || !f.LatestChange.Equal(now.LatestChange)
+}
+
+// Describe explains how now differs from f, for a warning
+func (f DirectoryFingerprint) Describe(now DirectoryFingerprint) string {
+	var changes []string
+	if diff := now.Files - f.Files; diff != 0 {
+		changes = append(changes, fmt.Sprintf("%+d files", diff))
+	}
+	if diff := now.Folders - f.Folders; diff != 0 {
+		changes = append(changes, fmt.Sprintf("%+d folders", diff))
+	}
+	if now.LatestChange.After(f.LatestChange) {
+		changes = append(changes, "modified at "+FormatTimestamp(now.LatestChange))
+	}
+	if len(changes) == 0 {
+		return "contents changed"
+	}
+	return strings.Join(changes, ", ")
+}
+
+// Fingerprint counts the files and folders under rootPath the way GetDirectoryStructure lists them
+// down to maxDepth (0 for all), and finds the newest modification time among them
+func (fs *DefaultFileService) Fingerprint(rootPath string, maxDepth int) (DirectoryFingerprint, error) {
+	fp := DirectoryFingerprint{Path: rootPath, MaxDepth: maxDepth}
+	err := walkTree(rootPath, fs.walk, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == rootPath {
+			fp.LatestChange = info.ModTime()
+			return nil
+		}
+
+		relPath, err := filepath.Rel(rootPath, path)
+		if err != nil {
+			return nil
+		}
+		relPath = filepath.ToSlash(relPath)
+		if fs.ignoreMatcher != nil && fs.ignoreMatcher.ShouldIgnoreFile(relPath, info) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), stagingDirPrefix) {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && isSidecarFile(path) {
+			return nil
+		}
+
+		// Entries below the depth limit count toward the newest change too; adding or removing
+		// them changes their folder's time anyway
+		if info.ModTime().After(fp.LatestChange) {
+			fp.LatestChange = info.ModTime()
+		}
+
+		if maxDepth > 0 && len(strings.Split(relPath, "/")) > maxDepth {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch {
+		case isBundleDir(info):
+			fp.Files++
+			return filepath.SkipDir
+		case info.IsDir():
+			fp.Folders++
+		default:
+			fp.Files++
+		}
+		return nil
+	})
+	return fp, err
+}
+
+// RevalidatePlan drops the operations of a plan that can no longer run as planned: their source is
+// gone or their destination is taken. Sources an earlier operation creates and destinations an
+// earlier operation frees count as fine. Dropped operations carry the reason in Flag.
+func (v *Validator) RevalidatePlan(operations []FileOperation) (kept, dropped []FileOperation) {
+	created := make(map[string]bool)
+	freed := make(map[string]bool)
+	for _, op := range operations {
+		err := v.ValidateFileOperation(op)
+		switch {
+		case errors.Is(err, ErrSourceNotExist) && within(created, op.From):
+			err = nil
+		case errors.Is(err, ErrDestinationExists) && within(freed, op.To):
+			err = nil
+		}
+		if err != nil {
+			op.Flag = err.Error()
+			dropped = append(dropped, op)
+			continue
+		}
+		created[op.To] = true
+		freed[op.From] = true
+		kept = append(kept, op)
+	}
+	return kept, dropped
+}
+
+// within reports whether path is, or is inside, one of the paths in set
+func within(set map[string]bool, path string) bool {
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if set[dir] {
+			return true
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return false
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "docs/b.txt", "docs/deep/c.txt"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fs := NewFileService(NewValidator(), NewLogger(false))
+
+	tests := []struct {
+		maxDepth    int
+		files, dirs int
+	}{
+		{0, 3, 2},
+		{1, 1, 1},
+		{2, 2, 2},
+	}
+	for _, tt := range tests {
+		fp, err := fs.Fingerprint(dir, tt.maxDepth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fp.Files != tt.files || fp.Folders != tt.dirs || fp.LatestChange.IsZero() {
+			t.Errorf("depth %d: got %d files, %d folders, latest %v; want %d and %d", tt.maxDepth, fp.Files, fp.Folders, fp.LatestChange, tt.files, tt.dirs)
+		}
+	}
+
+	before, _ := fs.Fingerprint(dir, 0)
+	if now, _ := fs.Fingerprint(dir, 0); before.Changed(now) {
+		t.Error("fingerprint changed without any change to the directory")
+	}
+
+	later := time.Now().Add(time.Minute)
+	if err := os.WriteFile(filepath.Join(dir, "docs", "new.txt"), []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(dir, "docs", "new.txt"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	now, _ := fs.Fingerprint(dir, 0)
+	if !before.Changed(now) {
+		t.Fatal("expected a new file to change the fingerprint")
+	}
+	if desc := before.Describe(now); !strings.Contains(desc, "+1 files") || !strings.Contains(desc, "modified at") {
+		t.Errorf("Describe() = %q", desc)
+	}
+}
+
+func TestRevalidatePlan(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "taken.txt", "old/c.txt"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := func(rel string) string { return filepath.Join(dir, filepath.FromSlash(rel)) }
+	ops := []FileOperation{
+		{From: p("a.txt"), To: p("docs/a.txt")},
+		{From: p("docs/a.txt"), To: p("final/a.txt")}, // Created by the first operation
+		{From: p("gone.txt"), To: p("docs/gone.txt")}, // Deleted since the plan was made
+		{From: p("b.txt"), To: p("taken.txt")},        // Something appeared at the destination
+		{From: p("old"), To: p("archive/old")},
+		{From: p("taken.txt"), To: p("old/c.txt")}, // Moved away along with its folder
+	}
+
+	kept, dropped := NewValidator().RevalidatePlan(ops)
+	if len(kept) != 4 || len(dropped) != 2 {
+		t.Fatalf("expected 4 kept and 2 dropped, got %v and %v", kept, dropped)
+	}
+	for _, op := range dropped {
+		if op.Flag == "" {
+			t.Errorf("dropped operation %v has no reason", op)
+		}
+	}
+}
+
+func TestCheckPlanFresh(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.pdf"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := NewLogger(false)
+	validator := NewValidator()
+	ai := &stubAIService{operations: []FileOperation{{From: "a.pdf", To: "docs/a.pdf"}}}
+	orchestrator := NewOrchestrator(ai, NewFileService(validator, logger), validator, logger, nil, nil, NewHookRunner(&Config{}, logger))
+
+	result := orchestrator.AnalyzeDirectory(AnalysisRequest{DirectoryPath: dir, UserPrompt: "Sort"}, nil)
+	if result.Error != nil || result.Fingerprint == nil {
+		t.Fatalf("expected a fingerprint with the plan, got %v (%v)", result.Fingerprint, result.Error)
+	}
+	if _, changed := orchestrator.CheckPlanFresh(result.Fingerprint); changed {
+		t.Error("plan reported stale without any change")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "b.pdf"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	now, changed := orchestrator.CheckPlanFresh(result.Fingerprint)
+	if !changed || now.Files != 2 {
+		t.Errorf("expected the new file to make the plan stale, got changed=%v files=%d", changed, now.Files)
+	}
+	if _, changed := orchestrator.CheckPlanFresh(nil); changed {
+		t.Error("a plan without a fingerprint can't be stale")
+	}
+}
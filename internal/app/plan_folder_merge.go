@@ -0,0 +1,110 @@
+package app
+
+import (
+	"sort"
+	"strings"
+)
+
+// FolderMerge is a destination folder of a plan that was folded into a near-identical one
+type FolderMerge struct {
+	Folder string // As the model wrote it, relative to the scanned folder
+	Into   string // The folder used instead
+	Count  int    // Operations changed
+}
+
+// folderMerger consolidates destination folders that differ only in case, separators or a plural
+// ending ("photo", "Photos"). Scanned folders keep their names; among new folders the first
+// spelling planned wins, so operations can be merged one at a time as they stream in.
+type folderMerger struct {
+	canonical    map[string]string // folderMergeKey path -> spelling used
+	destinations map[string]string // Destination -> source, to avoid merging two files into one
+	merges       map[string]*FolderMerge
+	sources      map[string]map[string]bool // Merged folder -> sources of the operations changed
+	order        []string
+}
+
+func newFolderMerger(grounding *StructureGrounding) *folderMerger {
+	m := &folderMerger{
+		canonical:    make(map[string]string),
+		destinations: make(map[string]string),
+		merges:       make(map[string]*FolderMerge),
+		sources:      make(map[string]map[string]bool),
+	}
+	var scanned []string
+	for folder := range grounding.folders {
+		scanned = append(scanned, folder)
+	}
+	sort.Strings(scanned)
+	for _, folder := range scanned {
+		key := folderPathKey(folder, folderMergeKey)
+		if _, ok := m.canonical[key]; !ok {
+			m.canonical[key] = folder
+		}
+	}
+	return m
+}
+
+// apply returns op with the folders of its destination replaced by their first spelling. Applying
+// it again to the same operation changes nothing.
+func (m *folderMerger) apply(basePath string, op FileOperation) FileOperation {
+	if EscapesBase(basePath, op.To) {
+		return op
+	}
+	parts := strings.Split(relativeSlashPath(basePath, op.To), "/")
+	merged := make([]string, len(parts))
+	copy(merged, parts)
+
+	var pending []string // Folders first planned by this operation
+	var changed []string // Folders of this operation merged into another spelling
+	for i := 0; i < len(merged)-1; i++ {
+		folder := strings.Join(merged[:i+1], "/")
+		key := folderPathKey(folder, folderMergeKey)
+		canonical, ok := m.canonical[key]
+		if !ok {
+			pending = append(pending, key, folder)
+			continue
+		}
+		if canonical != folder {
+			changed = append(changed, folder)
+			copy(merged, strings.Split(canonical, "/"))
+		}
+	}
+
+	to, err := JoinStoragePath(basePath, strings.Join(merged, "/"))
+	if err != nil {
+		return op
+	}
+	if from, taken := m.destinations[to]; taken && from != op.From {
+		// Merging would move two files onto each other, so this one keeps its folder
+		to = op.To
+		changed = nil
+	}
+	m.destinations[to] = op.From
+	for i := 0; i < len(pending); i += 2 {
+		if _, ok := m.canonical[pending[i]]; !ok {
+			m.canonical[pending[i]] = pending[i+1]
+		}
+	}
+	for _, folder := range changed {
+		into := m.canonical[folderPathKey(folder, folderMergeKey)]
+		if _, ok := m.merges[folder]; !ok {
+			m.merges[folder] = &FolderMerge{Folder: folder, Into: into}
+			m.sources[folder] = make(map[string]bool)
+			m.order = append(m.order, folder)
+		}
+		m.sources[folder][op.From] = true
+	}
+	op.To = to
+	return op
+}
+
+// result lists the merges in the order they were first made
+func (m *folderMerger) result() []FolderMerge {
+	merges := make([]FolderMerge, 0, len(m.order))
+	for _, folder := range m.order {
+		merge := *m.merges[folder]
+		merge.Count = len(m.sources[folder])
+		merges = append(merges, merge)
+	}
+	return merges
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFolderMerger(t *testing.T) {
+	base := t.TempDir()
+	merger := newFolderMerger(NewStructureGrounding("Photos/\nPhotos/old.jpg (1 bytes)\n"))
+	op := func(from, to string) FileOperation {
+		return FileOperation{From: filepath.Join(base, filepath.FromSlash(from)), To: filepath.Join(base, filepath.FromSlash(to))}
+	}
+	plan := []FileOperation{
+		op("x/a.jpg", "Photos/a.jpg"),
+		op("a.jpg", "photo/a.jpg"), // Would land on the file above, so it keeps its folder
+		op("b.jpg", "photo/b.jpg"),
+		op("c.pdf", "Invoices/c.pdf"),
+		op("d.pdf", "invoice/d.pdf"),
+		op("e.pdf", "Tax-Returns/2024/e.pdf"),
+		op("f.pdf", "tax returns/2024/f.pdf"),
+		op("g.txt", "Notes/g.txt"),
+		op("h.txt", "../outside/h.txt"),
+	}
+	want := []string{
+		"Photos/a.jpg",
+		"photo/a.jpg",
+		"Photos/b.jpg",
+		"Invoices/c.pdf",
+		"Invoices/d.pdf",
+		"Tax-Returns/2024/e.pdf",
+		"Tax-Returns/2024/f.pdf",
+		"Notes/g.txt",
+		"../outside/h.txt",
+	}
+
+	// Streamed operations go through the merger again with the final plan
+	for pass := 0; pass < 2; pass++ {
+		for i, planned := range plan {
+			if got := filepath.ToSlash(relativeSlashPath(base, merger.apply(base, planned).To)); got != want[i] {
+				t.Errorf("pass %d: %s went to %s, want %s", pass, planned.From, got, want[i])
+			}
+		}
+	}
+
+	var merges []string
+	for _, merge := range merger.result() {
+		merges = append(merges, fmt.Sprintf("%s -> %s (%d)", merge.Folder, merge.Into, merge.Count))
+	}
+	if got := strings.Join(merges, ", "); got != "photo -> Photos (1), invoice -> Invoices (1), tax returns -> Tax-Returns (1)" {
+		t.Errorf("merges = %s", got)
+	}
+}
+
+func TestAnalyzeDirectoryMergesNearDuplicateFolders(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.jpg", "b.jpg", "c.jpg"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logger := NewLogger(false)
+	validator := NewValidator()
+	ai := &stubAIService{operations: []FileOperation{
+		{From: "a.jpg", To: "Holiday Photos/a.jpg"},
+		{From: "b.jpg", To: "holiday-photo/b.jpg"},
+		{From: "c.jpg", To: "Holiday_Photos/c.jpg"},
+	}}
+	orchestrator := NewOrchestrator(ai, NewFileService(validator, logger), validator, logger, nil, nil, NewHookRunner(&Config{}, logger))
+
+	var streamed []string
+	result := orchestrator.AnalyzeDirectory(AnalysisRequest{DirectoryPath: dir, UserPrompt: "Sort", MaxDepth: 1}, func(op FileOperation) {
+		streamed = append(streamed, relativeSlashPath(dir, op.To))
+	})
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	for i, op := range result.Operations {
+		if got := relativeSlashPath(dir, op.To); !strings.HasPrefix(got, "Holiday Photos/") || streamed[i] != got {
+			t.Errorf("operation %d went to %s (streamed %s)", i, got, streamed[i])
+		}
+	}
+	if len(result.Merged) != 2 {
+		t.Errorf("Merged = %+v, want both variants", result.Merged)
+	}
+}
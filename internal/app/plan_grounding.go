@@ -0,0 +1,83 @@
+package app
+
+import (
+	"sort"
+	"strings"
+)
+
+// hallucinatedFlag is set on operations whose source the model was never shown
+const hallucinatedFlag = "not in the scanned structure"
+
+// StructureGrounding knows which paths were listed in the structure sent to the model, so
+// operations on files the model made up, or that lie deeper than the scan went, can be caught
+type StructureGrounding struct {
+	paths   map[string]bool // Relative, with forward slashes
+	folders map[string]bool
+}
+
+// NewStructureGrounding collects the files and folders listed in a text structure produced by
+// GetDirectoryStructure, enriched or not
+func NewStructureGrounding(structure string) *StructureGrounding {
+	g := &StructureGrounding{paths: make(map[string]bool), folders: make(map[string]bool)}
+	for _, line := range strings.Split(structure, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasSuffix(line, "/") {
+			g.paths[strings.TrimSuffix(line, "/")] = true
+			g.folders[strings.TrimSuffix(line, "/")] = true
+		} else if m := structureFileLine.FindStringSubmatch(line); m != nil {
+			g.paths[m[1]] = true
+		}
+	}
+	return g
+}
+
+// Contains reports whether p (absolute, under basePath) was listed in the structure
+func (g *StructureGrounding) Contains(basePath, p string) bool {
+	return g.paths[relativeSlashPath(basePath, p)]
+}
+
+// Filter splits operations into those whose source was listed and the hallucinated rest, which
+// are returned with Flag set
+func (g *StructureGrounding) Filter(basePath string, operations []FileOperation) (kept, hallucinated []FileOperation) {
+	for _, op := range operations {
+		if g.Contains(basePath, op.From) {
+			kept = append(kept, op)
+			continue
+		}
+		op.Flag = hallucinatedFlag
+		hallucinated = append(hallucinated, op)
+	}
+	return kept, hallucinated
+}
+
+// IsFolder reports whether rel (relative, with forward slashes) was listed as a folder
+func (g *StructureGrounding) IsFolder(rel string) bool {
+	return g.folders[strings.Trim(rel, "/")]
+}
+
+// Complete lists up to limit listed paths starting with prefix, ignoring case, for autocompletion.
+// Folders come first and end with a slash; foldersOnly leaves files out, for destinations.
+func (g *StructureGrounding) Complete(prefix string, foldersOnly bool, limit int) []string {
+	prefix = strings.ToLower(strings.TrimPrefix(prefix, "./"))
+	var folders, files []string
+	for p := range g.paths {
+		if !strings.HasPrefix(strings.ToLower(p), prefix) {
+			continue
+		}
+		if g.folders[p] {
+			folders = append(folders, p+"/")
+		} else if !foldersOnly {
+			files = append(files, p)
+		}
+	}
+	sort.Strings(folders)
+	sort.Strings(files)
+	matches := append(folders, files...)
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
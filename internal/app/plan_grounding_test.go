@@ -0,0 +1,67 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStructureGrounding(t *testing.T) {
+	base := filepath.Join(string(filepath.Separator), "home", "me", "Downloads")
+	structure := `docs/
+docs/report.pdf [Quarterly report] (4096 bytes)
+notes.txt (12 bytes)
+node_modules/
+`
+	g := NewStructureGrounding(structure)
+
+	tests := []struct {
+		from string
+		want bool
+	}{
+		{"docs/report.pdf", true},
+		{"notes.txt", true},
+		{"docs", true},
+		{"node_modules", true},
+		{"docs/invented.pdf", false},
+		{"node_modules/left-pad/index.js", false}, // Inside a folder whose contents weren't listed
+		{"report.pdf", false},
+	}
+	for _, tt := range tests {
+		if got := g.Contains(base, filepath.Join(base, filepath.FromSlash(tt.from))); got != tt.want {
+			t.Errorf("Contains(%q) = %v, want %v", tt.from, got, tt.want)
+		}
+	}
+
+	kept, hallucinated := g.Filter(base, []FileOperation{
+		{From: filepath.Join(base, "notes.txt"), To: filepath.Join(base, "docs", "notes.txt")},
+		{From: filepath.Join(base, "taxes.pdf"), To: filepath.Join(base, "docs", "taxes.pdf")},
+	})
+	if len(kept) != 1 || len(hallucinated) != 1 || hallucinated[0].Flag != hallucinatedFlag {
+		t.Errorf("expected one kept and one flagged operation, got %v and %v", kept, hallucinated)
+	}
+}
+
+func TestAnalyzeDirectoryDropsHallucinatedPaths(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.pdf"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := NewLogger(false)
+	validator := NewValidator()
+	ai := &stubAIService{operations: []FileOperation{
+		{From: "a.pdf", To: "docs/a.pdf"},
+		{From: "b.pdf", To: "docs/b.pdf"},
+	}}
+	orchestrator := NewOrchestrator(ai, NewFileService(validator, logger), validator, logger, nil, nil, NewHookRunner(&Config{}, logger))
+
+	var streamed int
+	result := orchestrator.AnalyzeDirectory(AnalysisRequest{DirectoryPath: dir, UserPrompt: "Sort"}, func(FileOperation) { streamed++ })
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	if len(result.Operations) != 1 || len(result.Hallucinated) != 1 || streamed != 1 {
+		t.Errorf("got %d kept, %d hallucinated, %d streamed; want 1 each", len(result.Operations), len(result.Hallucinated), streamed)
+	}
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+var ErrPlanConflict = errors.New("conflicts with an operation that was already executed")
+
+// PlanConflictKind describes how an operation clashes with one that already ran
+type PlanConflictKind string
+
+const (
+	PlanConflictSource      PlanConflictKind = "source"      // The file was already moved somewhere else
+	PlanConflictDestination PlanConflictKind = "destination" // Another file was already moved to the destination
+)
+
+// PlanConflict is an operation dropped from a plan because it clashes with an executed one
+type PlanConflict struct {
+	Kind      PlanConflictKind `json:"kind"`
+	Operation FileOperation    `json:"operation"`
+	Executed  FileOperation    `json:"executed"`
+}
+
+func (c PlanConflict) String() string {
+	switch c.Kind {
+	case PlanConflictSource:
+		return fmt.Sprintf("%s was already moved to %s", c.Operation.From, c.Executed.To)
+	default:
+		return fmt.Sprintf("%s is already taken by %s", c.Operation.To, c.Executed.From)
+	}
+}
+
+// Err returns the conflict as an error wrapping ErrPlanConflict
+func (c PlanConflict) Err() error {
+	return fmt.Errorf("%w: %s", ErrPlanConflict, c.String())
+}
+
+// PlanMerger reconciles plans that were made against the same tree, so a plan that is executed
+// after another one (a watcher's plan and a manual run, say) doesn't fail on sources that have
+// already moved.
+type PlanMerger struct {
+	logger *Logger
+}
+
+func NewPlanMerger(logger *Logger) *PlanMerger {
+	return &PlanMerger{logger: logger}
+}
+
+// Rebase adapts operations to executed, which ran after the operations were planned:
+//   - operations that were already carried out are dropped
+//   - paths inside a folder that has since moved follow the folder to its new location
+//   - operations whose source was moved elsewhere, or whose destination is now taken, are
+//     dropped and reported as conflicts
+func (m *PlanMerger) Rebase(operations, executed []FileOperation) ([]FileOperation, []PlanConflict) {
+	var rebased []FileOperation
+	var conflicts []PlanConflict
+
+next:
+	for _, op := range operations {
+		for _, done := range executed {
+			from, to := filepath.Clean(done.From), filepath.Clean(done.To)
+
+			switch {
+			case filepath.Clean(op.From) == from:
+				if filepath.Clean(op.To) == to {
+					m.logger.Debug("Dropping operation that was already executed: %s -> %s", op.From, op.To)
+				} else {
+					conflicts = append(conflicts, PlanConflict{Kind: PlanConflictSource, Operation: op, Executed: done})
+				}
+				continue next
+			case filepath.Clean(op.To) == to:
+				conflicts = append(conflicts, PlanConflict{Kind: PlanConflictDestination, Operation: op, Executed: done})
+				continue next
+			}
+
+			// A folder containing the source (or destination) was moved as a whole
+			if moved, ok := rebasePath(op.From, from, to); ok {
+				op.From = moved
+			}
+			if moved, ok := rebasePath(op.To, from, to); ok {
+				op.To = moved
+			}
+		}
+		rebased = append(rebased, op)
+	}
+	return rebased, conflicts
+}
+
+// rebasePath maps path inside the folder oldDir to the same place under newDir
+func rebasePath(path, oldDir, newDir string) (string, bool) {
+	rel, err := filepath.Rel(oldDir, filepath.Clean(path))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path, false
+	}
+	return filepath.Join(newDir, rel), true
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPlanMergerRebase(t *testing.T) {
+	op := func(from, to string) FileOperation { return FileOperation{From: from, To: to} }
+
+	tests := []struct {
+		name          string
+		operations    []FileOperation
+		executed      []FileOperation
+		want          []FileOperation
+		wantConflicts []PlanConflictKind
+	}{
+		{
+			name:       "unrelated operations are kept",
+			operations: []FileOperation{op("/d/a.pdf", "/d/docs/a.pdf")},
+			executed:   []FileOperation{op("/d/b.jpg", "/d/photos/b.jpg")},
+			want:       []FileOperation{op("/d/a.pdf", "/d/docs/a.pdf")},
+		},
+		{
+			name:       "operations already carried out are dropped",
+			operations: []FileOperation{op("/d/a.pdf", "/d/docs/a.pdf"), op("/d/c.txt", "/d/notes/c.txt")},
+			executed:   []FileOperation{op("/d/a.pdf", "/d/docs/a.pdf")},
+			want:       []FileOperation{op("/d/c.txt", "/d/notes/c.txt")},
+		},
+		{
+			name:          "source moved elsewhere",
+			operations:    []FileOperation{op("/d/a.pdf", "/d/docs/a.pdf")},
+			executed:      []FileOperation{op("/d/a.pdf", "/d/archive/a.pdf")},
+			wantConflicts: []PlanConflictKind{PlanConflictSource},
+		},
+		{
+			name:          "destination taken",
+			operations:    []FileOperation{op("/d/a.pdf", "/d/docs/report.pdf")},
+			executed:      []FileOperation{op("/d/b.pdf", "/d/docs/report.pdf")},
+			wantConflicts: []PlanConflictKind{PlanConflictDestination},
+		},
+		{
+			name:       "source follows its moved folder",
+			operations: []FileOperation{op("/d/inbox/a.pdf", "/d/docs/a.pdf")},
+			executed:   []FileOperation{op("/d/inbox", "/d/archive/inbox")},
+			want:       []FileOperation{op("/d/archive/inbox/a.pdf", "/d/docs/a.pdf")},
+		},
+		{
+			name:       "folder with a similar name is not rebased",
+			operations: []FileOperation{op("/d/inbox2/a.pdf", "/d/docs/a.pdf")},
+			executed:   []FileOperation{op("/d/inbox", "/d/archive/inbox")},
+			want:       []FileOperation{op("/d/inbox2/a.pdf", "/d/docs/a.pdf")},
+		},
+	}
+
+	merger := NewPlanMerger(NewLogger(false))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := merger.Rebase(tt.operations, tt.executed)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Rebase() operations = %v, want %v", got, tt.want)
+			}
+			var kinds []PlanConflictKind
+			for _, conflict := range conflicts {
+				kinds = append(kinds, conflict.Kind)
+			}
+			if !reflect.DeepEqual(kinds, tt.wantConflicts) {
+				t.Errorf("Rebase() conflicts = %v, want %v", kinds, tt.wantConflicts)
+			}
+		})
+	}
+}
+
+func TestExecuteStalePlan(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.pdf", "b.pdf"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logger := NewLogger(false)
+	validator := NewValidator()
+	ai := &stubAIService{operations: []FileOperation{{From: "a.pdf", To: "docs/a.pdf"}, {From: "b.pdf", To: "docs/b.pdf"}}}
+	orchestrator := NewOrchestrator(ai, NewFileService(validator, logger), validator, logger, nil, nil, NewHookRunner(&Config{}, logger))
+
+	store := NewPendingPlanStore("", logger)
+	orchestrator.SetPendingPlans(store)
+	store.Enqueue(PendingPlan{JobName: "Downloads", BasePath: dir, Operations: []FileOperation{
+		{From: filepath.Join(dir, "a.pdf"), To: filepath.Join(dir, "docs", "a.pdf")},
+	}})
+
+	// The manual plan is made first, then a watcher moves one of its files before it runs
+	manual := orchestrator.AnalyzeDirectory(AnalysisRequest{DirectoryPath: dir, UserPrompt: "Sort documents"}, nil)
+	orchestrator.ExecuteOrganization(ExecutionRequest{
+		Operations: []FileOperation{{From: filepath.Join(dir, "b.pdf"), To: filepath.Join(dir, "inbox", "b.pdf")}},
+		BasePath:   dir,
+	})
+
+	result := orchestrator.ExecuteOrganization(ExecutionRequest{Operations: manual.Operations, BasePath: dir, PlannedAt: manual.PlannedAt})
+	if result.SuccessCount != 1 || result.FailCount != 1 {
+		t.Fatalf("expected 1 success and 1 conflict, got %d/%d", result.SuccessCount, result.FailCount)
+	}
+	for _, opResult := range result.Operations {
+		if !opResult.Success && !errors.Is(opResult.Error, ErrPlanConflict) {
+			t.Errorf("expected a plan conflict for %s, got %v", opResult.Operation.From, opResult.Error)
+		}
+	}
+
+	// The queued plan only contained a.pdf, which the manual run just moved to the same place
+	if remaining := store.List(); len(remaining) != 0 {
+		t.Errorf("expected the satisfied pending plan to be dropped, got %+v", remaining)
+	}
+}
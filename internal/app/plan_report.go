@@ -0,0 +1,258 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// explainMovesInstruction asks the model for a short reason per operation, shown in review reports
+const explainMovesInstruction = `
+
+Add a "reason" field to every line: a few words saying why the file goes there, e.g. {"from": "a.pdf", "to": "taxes/2023/a.pdf", "reason": "2023 tax return"}.`
+
+const (
+	planIDLabel     = "Plan ID:"
+	approvedByLabel = "Approved by:"
+)
+
+var (
+	ErrNoApproval       = errors.New("the report has not been approved")
+	ErrApprovalMismatch = errors.New("the approval is for a different plan")
+)
+
+// PlanFingerprint identifies a plan by its operations, so an approval can't be applied to a plan
+// that changed after it was exported
+func PlanFingerprint(operations []FileOperation) string {
+	hash := sha256.New()
+	for _, op := range operations {
+		fmt.Fprintf(hash, "%s\x00%s\x00", op.From, op.To)
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// PlanApproval is the approval marker read back from a reviewed report
+type PlanApproval struct {
+	PlanID     string
+	ApprovedBy string
+}
+
+// ReadPlanApproval finds the plan ID and the reviewer's name in a Markdown or HTML report.
+// Reviewers approve a report by writing their name after "Approved by:".
+func ReadPlanApproval(report []byte) (PlanApproval, error) {
+	var approval PlanApproval
+	scanner := bufio.NewScanner(bytes.NewReader(report))
+	for scanner.Scan() {
+		line := strings.TrimSpace(stripTags(scanner.Text()))
+		if value, ok := strings.CutPrefix(line, planIDLabel); ok && approval.PlanID == "" {
+			approval.PlanID = strings.Trim(strings.TrimSpace(value), "`")
+		}
+		if value, ok := strings.CutPrefix(line, approvedByLabel); ok {
+			approval.ApprovedBy = strings.TrimSpace(strings.Trim(strings.TrimSpace(value), "_*"))
+		}
+	}
+	if approval.PlanID == "" {
+		return approval, fmt.Errorf("no %q line found; is this an exported plan report?", planIDLabel)
+	}
+	if approval.ApprovedBy == "" {
+		return approval, ErrNoApproval
+	}
+	return approval, nil
+}
+
+// CheckPlanApproval reads an approval and makes sure it's for plan
+func CheckPlanApproval(report []byte, plan PendingPlan) (PlanApproval, error) {
+	approval, err := ReadPlanApproval(report)
+	if err != nil {
+		return approval, err
+	}
+	if approval.PlanID != PlanFingerprint(plan.Operations) {
+		return approval, fmt.Errorf("%w (report is for plan %s)", ErrApprovalMismatch, approval.PlanID)
+	}
+	return approval, nil
+}
+
+// stripTags removes HTML tags from a line, leaving Markdown untouched
+func stripTags(line string) string {
+	var builder strings.Builder
+	inTag := false
+	for _, r := range line {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>' && inTag:
+			inTag = false
+		case !inTag:
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// planReportRow is one move in a review report, with paths relative to the plan's base path
+type planReportRow struct {
+	Index      int
+	From       string
+	To         string
+	Confidence string
+	Reason     string
+}
+
+// planFolderChange counts the files leaving and arriving in a folder
+type planFolderChange struct {
+	Folder string
+	Out    int
+	In     int
+}
+
+type planReport struct {
+	Plan    PendingPlan
+	PlanID  string
+	Created string
+	Rows    []planReportRow
+	Folders []planFolderChange
+}
+
+func newPlanReport(plan PendingPlan) planReport {
+	relative := func(path string) string {
+		if rel, err := filepath.Rel(plan.BasePath, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+		return path
+	}
+
+	report := planReport{
+		Plan:    plan,
+		PlanID:  PlanFingerprint(plan.Operations),
+		Created: FormatTimestamp(plan.CreatedAt),
+	}
+	folders := make(map[string]*planFolderChange)
+	folder := func(path string) *planFolderChange {
+		dir := filepath.ToSlash(filepath.Dir(relative(path))) + "/"
+		if dir == "./" {
+			dir = "/"
+		}
+		if folders[dir] == nil {
+			folders[dir] = &planFolderChange{Folder: dir}
+		}
+		return folders[dir]
+	}
+
+	for i, op := range plan.Operations {
+		row := planReportRow{Index: i + 1, From: relative(op.From), To: relative(op.To), Reason: op.Reason}
+		if op.Confidence > 0 {
+			row.Confidence = fmt.Sprintf("%.0f%%", op.Confidence*100)
+		}
+		report.Rows = append(report.Rows, row)
+		folder(op.From).Out++
+		folder(op.To).In++
+	}
+	for _, change := range folders {
+		report.Folders = append(report.Folders, *change)
+	}
+	sort.Slice(report.Folders, func(i, j int) bool { return report.Folders[i].Folder < report.Folders[j].Folder })
+	return report
+}
+
+// RenderPlanMarkdown renders a plan as a Markdown report for someone to review and approve
+func RenderPlanMarkdown(plan PendingPlan) string {
+	report := newPlanReport(plan)
+	escape := strings.NewReplacer("|", `\|`, "\n", " ").Replace
+
+	var builder strings.Builder
+	builder.WriteString("# Organization plan for review\n\n")
+	builder.WriteString(fmt.Sprintf("- Folder: `%s`\n", plan.BasePath))
+	builder.WriteString(fmt.Sprintf("- Created: %s\n", report.Created))
+	builder.WriteString(fmt.Sprintf("- Source: %s\n", plan.JobName))
+	builder.WriteString(fmt.Sprintf("- Moves: %d\n", len(plan.Operations)))
+	if plan.Reason != "" {
+		builder.WriteString(fmt.Sprintf("- Note: %s\n", plan.Reason))
+	}
+
+	builder.WriteString("\n## Folder changes\n\n| Folder | Files out | Files in |\n|---|---:|---:|\n")
+	for _, change := range report.Folders {
+		builder.WriteString(fmt.Sprintf("| `%s` | %d | %d |\n", escape(change.Folder), change.Out, change.In))
+	}
+
+	builder.WriteString("\n## Moves\n\n| # | From | To | Confidence | Reason |\n|---:|---|---|---:|---|\n")
+	for _, row := range report.Rows {
+		builder.WriteString(fmt.Sprintf("| %d | `%s` | `%s` | %s | %s |\n", row.Index, escape(row.From), escape(row.To), row.Confidence, escape(row.Reason)))
+	}
+
+	builder.WriteString("\n## Approval\n\n")
+	builder.WriteString("To approve, write your name after \"" + approvedByLabel + "\" below and send this file back.\n\n")
+	builder.WriteString(fmt.Sprintf("%s `%s`\n\n", planIDLabel, report.PlanID))
+	builder.WriteString(approvedByLabel + " \n")
+	return builder.String()
+}
+
+var planHTMLTemplate = template.Must(template.New("plan").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Organization plan for review</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+td.num { text-align: right; }
+code { font-size: 0.95em; }
+</style>
+</head>
+<body>
+<h1>Organization plan for review</h1>
+<ul>
+<li>Folder: <code>{{.Plan.BasePath}}</code></li>
+<li>Created: {{.Created}}</li>
+<li>Source: {{.Plan.JobName}}</li>
+<li>Moves: {{len .Rows}}</li>
+{{- if .Plan.Reason}}
+<li>Note: {{.Plan.Reason}}</li>
+{{- end}}
+</ul>
+<h2>Folder changes</h2>
+<table>
+<tr><th>Folder</th><th>Files out</th><th>Files in</th></tr>
+{{- range .Folders}}
+<tr><td><code>{{.Folder}}</code></td><td class="num">{{.Out}}</td><td class="num">{{.In}}</td></tr>
+{{- end}}
+</table>
+<h2>Moves</h2>
+<table>
+<tr><th>#</th><th>From</th><th>To</th><th>Confidence</th><th>Reason</th></tr>
+{{- range .Rows}}
+<tr><td class="num">{{.Index}}</td><td><code>{{.From}}</code></td><td><code>{{.To}}</code></td><td class="num">{{.Confidence}}</td><td>{{.Reason}}</td></tr>
+{{- end}}
+</table>
+<h2>Approval</h2>
+<p>To approve, open this file in a text editor, write your name after "Approved by:" below and send the file back.</p>
+<p>Plan ID: <code>{{.PlanID}}</code></p>
+<p>Approved by: </p>
+</body>
+</html>
+`))
+
+// RenderPlanHTML renders a plan as a standalone HTML report for someone to review and approve
+func RenderPlanHTML(plan PendingPlan) (string, error) {
+	var buf bytes.Buffer
+	if err := planHTMLTemplate.Execute(&buf, newPlanReport(plan)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// RenderPlanReport picks HTML or Markdown from the file name's extension
+func RenderPlanReport(plan PendingPlan, fileName string) (string, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".html", ".htm":
+		return RenderPlanHTML(plan)
+	}
+	return RenderPlanMarkdown(plan), nil
+}
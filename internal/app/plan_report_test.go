@@ -0,0 +1,61 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlanReportApproval(t *testing.T) {
+	plan := PendingPlan{
+		JobName:  "Downloads",
+		BasePath: "/share/team",
+		Operations: []FileOperation{
+			{From: "/share/team/q3.pdf", To: "/share/team/reports/2024/q3.pdf", Confidence: 0.92, Reason: "quarterly report"},
+			{From: "/share/team/logo.png", To: "/share/team/brand/logo.png"},
+		},
+		CreatedAt: time.Date(2024, 10, 1, 9, 30, 0, 0, time.UTC),
+	}
+	other := PendingPlan{Operations: []FileOperation{{From: "/share/team/a.txt", To: "/share/team/b.txt"}}}
+
+	html, err := RenderPlanHTML(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	markdown := RenderPlanMarkdown(plan)
+	for _, want := range []string{"`q3.pdf`", "`reports/2024/q3.pdf`", "92%", "quarterly report", "| `reports/2024/` | 0 | 1 |"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown report missing %q:\n%s", want, markdown)
+		}
+	}
+
+	approve := func(report, name string) []byte {
+		return []byte(strings.Replace(report, approvedByLabel+" ", approvedByLabel+" "+name, 1))
+	}
+
+	tests := []struct {
+		name         string
+		report       []byte
+		plan         PendingPlan
+		wantErr      error
+		wantReviewer string
+	}{
+		{name: "unapproved markdown", report: []byte(markdown), plan: plan, wantErr: ErrNoApproval},
+		{name: "approved markdown", report: approve(markdown, "Dana"), plan: plan, wantReviewer: "Dana"},
+		{name: "approved html", report: approve(html, "Sam Lee"), plan: plan, wantReviewer: "Sam Lee"},
+		{name: "approval for another plan", report: approve(markdown, "Dana"), plan: other, wantErr: ErrApprovalMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			approval, err := CheckPlanApproval(tt.report, tt.plan)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CheckPlanApproval() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && approval.ApprovedBy != tt.wantReviewer {
+				t.Errorf("ApprovedBy = %q, want %q", approval.ApprovedBy, tt.wantReviewer)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// OutOfScopeMarker tags operations whose destination lies outside the scanned folder wherever plans are shown
+const OutOfScopeMarker = "⛔ LEAVES THE SCANNED FOLDER"
+
+// OutOfScope returns the operations that move something to a destination outside basePath
+func OutOfScope(basePath string, operations []FileOperation) []FileOperation {
+	var escaping []FileOperation
+	for _, op := range operations {
+		if EscapesBase(basePath, op.To) {
+			escaping = append(escaping, op)
+		}
+	}
+	return escaping
+}
+
+// EscapesBase reports whether p lies outside basePath
+func EscapesBase(basePath, p string) bool {
+	if IsObjectStoragePath(basePath) || IsObjectStoragePath(p) {
+		base := strings.TrimSuffix(basePath, "/")
+		return p != base && !strings.HasPrefix(p, base+"/")
+	}
+	return !isSubPath(filepath.Clean(basePath), filepath.Clean(p))
+}
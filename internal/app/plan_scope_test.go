@@ -0,0 +1,35 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestEscapesBase(t *testing.T) {
+	base := filepath.Join(string(filepath.Separator), "home", "me", "Downloads")
+	tests := []struct {
+		base string
+		path string
+		want bool
+	}{
+		{base, filepath.Join(base, "docs", "a.pdf"), false},
+		{base, base, false},
+		{base, filepath.Join(base, "..", "Documents", "a.pdf"), true},
+		{base, filepath.Join(string(filepath.Separator), "home", "me", "DownloadsOld", "a.pdf"), true},
+		{"s3://bucket/inbox", "s3://bucket/inbox/a.pdf", false},
+		{"s3://bucket/inbox", "s3://bucket/archive/a.pdf", true},
+	}
+	for _, tt := range tests {
+		if got := EscapesBase(tt.base, tt.path); got != tt.want {
+			t.Errorf("EscapesBase(%q, %q) = %v, want %v", tt.base, tt.path, got, tt.want)
+		}
+	}
+
+	operations := []FileOperation{
+		{From: filepath.Join(base, "a.pdf"), To: filepath.Join(base, "docs", "a.pdf")},
+		{From: filepath.Join(base, "b.pdf"), To: filepath.Join(base, "..", "b.pdf")},
+	}
+	if escaping := OutOfScope(base, operations); len(escaping) != 1 || escaping[0] != operations[1] {
+		t.Errorf("OutOfScope = %v, want only the second operation", escaping)
+	}
+}
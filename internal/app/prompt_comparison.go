@@ -0,0 +1,130 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var ErrVariantsUnsupported = errors.New("the AI service cannot run prompt variants")
+
+// PromptVariant is one side of a prompt comparison. Empty fields use the configured values.
+type PromptVariant struct {
+	SystemPrompt string
+	Model        string
+}
+
+// PromptComparison holds the plans two prompt variants produced for the same structure
+type PromptComparison struct {
+	Structure   string
+	OperationsA []FileOperation
+	OperationsB []FileOperation
+	ErrorA      error
+	ErrorB      error
+	Rows        []PlanComparisonRow
+	Stability   float64 // Share of touched files both plans send to the same place, 0-1
+	Error       error   // Set when the structure couldn't be prepared; neither variant ran
+}
+
+// PlanComparisonRow shows where each plan sends one file. An empty destination means the plan
+// leaves the file where it is.
+type PlanComparisonRow struct {
+	From string
+	ToA  string
+	ToB  string
+}
+
+// Agrees reports whether both plans treat the file the same way
+func (r PlanComparisonRow) Agrees() bool {
+	return r.ToA == r.ToB
+}
+
+// ComparePrompts scans the directory once and asks both variants for a plan, so differences
+// come from the prompts or models rather than from the input. Comparing a variant with itself
+// measures how stable a prompt is between runs.
+func (o *Orchestrator) ComparePrompts(req AnalysisRequest, a, b PromptVariant) PromptComparison {
+	var comparison PromptComparison
+
+	variants, ok := o.aiService.(VariantAIService)
+	if !ok {
+		comparison.Error = ErrVariantsUnsupported
+		return comparison
+	}
+
+	ctx := o.budgetContext(req)
+	structure, err := o.prepareStructure(ctx, &req)
+	if err != nil {
+		comparison.Error = err
+		return comparison
+	}
+	comparison.Structure = structure
+
+	constraints := ParseConstraints(req.Constraints)
+	planValidator := NewPlanValidator(constraints)
+	userPrompt := req.UserPrompt + o.corrections.PromptContext(req.DirectoryPath) + constraints.PromptText()
+	if req.ExplainMoves {
+		userPrompt += explainMovesInstruction
+	}
+
+	run := func(variant PromptVariant, operations *[]FileOperation, errp *error) {
+		ops, err := variants.WithVariant(variant.SystemPrompt, variant.Model).GetSuggestions(ctx, structure, userPrompt, req.DirectoryPath, nil)
+		if err != nil {
+			*errp = fmt.Errorf("failed to get AI suggestions: %w", err)
+			return
+		}
+		*operations, _ = planValidator.Filter(req.DirectoryPath, ops)
+	}
+
+	o.logger.Info("Comparing prompt variants for %s", req.DirectoryPath)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		run(a, &comparison.OperationsA, &comparison.ErrorA)
+	}()
+	go func() {
+		defer wg.Done()
+		run(b, &comparison.OperationsB, &comparison.ErrorB)
+	}()
+	wg.Wait()
+
+	comparison.Rows, comparison.Stability = ComparePlans(comparison.OperationsA, comparison.OperationsB)
+	o.logger.Info("Prompt comparison: %d vs %d operations, stability %.0f%%", len(comparison.OperationsA), len(comparison.OperationsB), comparison.Stability*100)
+	return comparison
+}
+
+// ComparePlans lines up two plans by source path and scores how many of the files touched by
+// either plan end up in the same place. Two empty plans agree completely.
+func ComparePlans(a, b []FileOperation) ([]PlanComparisonRow, float64) {
+	rows := make(map[string]*PlanComparisonRow)
+	row := func(from string) *PlanComparisonRow {
+		if r, ok := rows[from]; ok {
+			return r
+		}
+		r := &PlanComparisonRow{From: from}
+		rows[from] = r
+		return r
+	}
+	for _, op := range a {
+		row(op.From).ToA = op.To
+	}
+	for _, op := range b {
+		row(op.From).ToB = op.To
+	}
+
+	result := make([]PlanComparisonRow, 0, len(rows))
+	agreed := 0
+	for _, r := range rows {
+		result = append(result, *r)
+		if r.Agrees() {
+			agreed++
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].From < result[j].From })
+
+	if len(result) == 0 {
+		return result, 1
+	}
+	return result, float64(agreed) / float64(len(result))
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// variantAIService returns a different plan per model
+type variantAIService struct {
+	plans map[string][]FileOperation
+	model string
+}
+
+func (s *variantAIService) GetSuggestions(ctx context.Context, structure, userPrompt, basePath string, onOperation OperationCallback) ([]FileOperation, error) {
+	return s.plans[s.model], nil
+}
+
+func (s *variantAIService) WithVariant(systemPrompt, model string) AIService {
+	return &variantAIService{plans: s.plans, model: model}
+}
+
+func TestComparePlans(t *testing.T) {
+	tests := []struct {
+		name          string
+		a, b          []FileOperation
+		wantRows      int
+		wantStability float64
+	}{
+		{name: "both empty", wantStability: 1},
+		{
+			name:          "identical",
+			a:             []FileOperation{{From: "a.pdf", To: "docs/a.pdf"}},
+			b:             []FileOperation{{From: "a.pdf", To: "docs/a.pdf"}},
+			wantRows:      1,
+			wantStability: 1,
+		},
+		{
+			name:          "different destination and one-sided move",
+			a:             []FileOperation{{From: "a.pdf", To: "docs/a.pdf"}, {From: "b.jpg", To: "pics/b.jpg"}, {From: "c.txt", To: "notes/c.txt"}},
+			b:             []FileOperation{{From: "a.pdf", To: "pdf/a.pdf"}, {From: "b.jpg", To: "pics/b.jpg"}},
+			wantRows:      3,
+			wantStability: 1.0 / 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, stability := ComparePlans(tt.a, tt.b)
+			if len(rows) != tt.wantRows {
+				t.Errorf("got %d rows, want %d", len(rows), tt.wantRows)
+			}
+			if stability != tt.wantStability {
+				t.Errorf("stability = %v, want %v", stability, tt.wantStability)
+			}
+		})
+	}
+}
+
+func TestComparePromptsRunsBothVariants(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.pdf"), []byte("pdf"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := &Config{}
+	logger := NewLogger(false)
+	validator := NewValidator()
+	ai := &variantAIService{plans: map[string][]FileOperation{
+		"model-a": {{From: "a.pdf", To: "docs/a.pdf"}},
+		"model-b": {{From: "a.pdf", To: "pdf/a.pdf"}},
+	}}
+	orchestrator := NewOrchestrator(ai, NewFileService(validator, logger), validator, logger, nil, nil, NewHookRunner(config, logger))
+
+	comparison := orchestrator.ComparePrompts(AnalysisRequest{DirectoryPath: dir, UserPrompt: "Sort"}, PromptVariant{Model: "model-a"}, PromptVariant{Model: "model-b"})
+	if comparison.Error != nil || comparison.ErrorA != nil || comparison.ErrorB != nil {
+		t.Fatalf("ComparePrompts failed: %v, %v, %v", comparison.Error, comparison.ErrorA, comparison.ErrorB)
+	}
+	if comparison.Structure == "" {
+		t.Error("Expected the scanned structure")
+	}
+	if len(comparison.Rows) != 1 || comparison.Rows[0].ToA != "docs/a.pdf" || comparison.Rows[0].ToB != "pdf/a.pdf" {
+		t.Errorf("Unexpected rows: %+v", comparison.Rows)
+	}
+	if comparison.Stability != 0 {
+		t.Errorf("stability = %v, want 0", comparison.Stability)
+	}
+
+	plain := NewOrchestrator(&stubAIService{}, NewFileService(validator, logger), validator, logger, nil, nil, NewHookRunner(config, logger))
+	if got := plain.ComparePrompts(AnalysisRequest{DirectoryPath: dir, UserPrompt: "Sort"}, PromptVariant{}, PromptVariant{}); got.Error != ErrVariantsUnsupported {
+		t.Errorf("Expected ErrVariantsUnsupported, got %v", got.Error)
+	}
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// PromptField is one of the editable system prompts in Config, paired with the default the
+// app ships for it
+type PromptField struct {
+	Key     string // Config JSON key, also the key in Config.PromptBaselines
+	Default string
+	Value   *string
+}
+
+// PromptFields lists the prompts that can be modified and reset
+func (c *Config) PromptFields() []PromptField {
+	return []PromptField{
+		{Key: "system_prompt", Default: defaultSystemPrompt, Value: &c.SystemPrompt},
+		{Key: "pdf_analysis_prompt", Default: defaultPDFAnalysisPrompt, Value: &c.PDFAnalysisPrompt},
+		{Key: "text_analysis_prompt", Default: defaultTextAnalysisPrompt, Value: &c.TextAnalysisPrompt},
+		{Key: "image_analysis_prompt", Default: defaultImageAnalysisPrompt, Value: &c.ImageAnalysisPrompt},
+	}
+}
+
+// PromptField returns the prompt stored under key
+func (c *Config) PromptField(key string) (PromptField, bool) {
+	for _, field := range c.PromptFields() {
+		if field.Key == key {
+			return field, true
+		}
+	}
+	return PromptField{}, false
+}
+
+// Modified reports whether text differs from the shipped default
+func (p PromptField) Modified(text string) bool {
+	return strings.TrimSpace(text) != strings.TrimSpace(p.Default)
+}
+
+// DefaultChanged reports whether the shipped default has changed since the prompt was last
+// edited, meaning a modified prompt may be missing improvements from the new default
+func (c *Config) DefaultChanged(p PromptField) bool {
+	baseline, ok := c.PromptBaselines[p.Key]
+	return ok && baseline != promptHash(p.Default)
+}
+
+// MarkPromptBaseline records that the prompt stored under key was written against the current
+// default. Call it when the user edits or resets the prompt.
+func (c *Config) MarkPromptBaseline(key string) {
+	field, ok := c.PromptField(key)
+	if !ok {
+		return
+	}
+	if c.PromptBaselines == nil {
+		c.PromptBaselines = make(map[string]string)
+	}
+	c.PromptBaselines[key] = promptHash(field.Default)
+}
+
+// upgradePrompts moves prompts the user never changed onto new defaults, and records a baseline
+// for prompts that don't have one yet
+func upgradePrompts(config *Config) {
+	if config.PromptBaselines == nil {
+		config.PromptBaselines = make(map[string]string)
+	}
+	for _, field := range config.PromptFields() {
+		current := promptHash(field.Default)
+		baseline, ok := config.PromptBaselines[field.Key]
+		if !ok {
+			config.PromptBaselines[field.Key] = current
+			continue
+		}
+		if baseline != current && promptHash(*field.Value) == baseline {
+			*field.Value = field.Default
+			config.PromptBaselines[field.Key] = current
+		}
+	}
+}
+
+func promptHash(prompt string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(prompt)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// DiffLine is one line of a line-based diff
+type DiffLine struct {
+	Op   byte // ' ' unchanged, '-' only in the old text, '+' only in the new text
+	Text string
+}
+
+// DiffLines compares two texts line by line using their longest common subsequence
+func DiffLines(oldText, newText string) []DiffLine {
+	a := strings.Split(oldText, "\n")
+	b := strings.Split(newText, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var diff []DiffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			diff = append(diff, DiffLine{Op: ' ', Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{Op: '-', Text: a[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: '+', Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, DiffLine{Op: '-', Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, DiffLine{Op: '+', Text: b[j]})
+	}
+	return diff
+}
+
+// FormatDiff renders a diff in unified style, one "+", "-" or " " prefixed line per entry
+func FormatDiff(diff []DiffLine) string {
+	var sb strings.Builder
+	for _, line := range diff {
+		sb.WriteByte(line.Op)
+		sb.WriteByte(' ')
+		sb.WriteString(line.Text)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
@@ -0,0 +1,59 @@
+package app
+
+import "testing"
+
+func TestUpgradePrompts(t *testing.T) {
+	oldDefault := "Organize files by type."
+	config := &Config{}
+	loadDefaults(config)
+
+	// Prompts saved by a release that shipped oldDefault
+	config.SystemPrompt = oldDefault
+	config.PDFAnalysisPrompt = "My own PDF prompt"
+	config.PromptBaselines["system_prompt"] = promptHash(oldDefault)
+	config.PromptBaselines["pdf_analysis_prompt"] = promptHash(oldDefault)
+
+	applyDefaults(config)
+
+	if config.SystemPrompt != defaultSystemPrompt {
+		t.Errorf("Expected an unmodified prompt to move to the new default, got %q", config.SystemPrompt)
+	}
+	system, _ := config.PromptField("system_prompt")
+	if config.DefaultChanged(system) {
+		t.Error("Expected the upgraded prompt to track the new default")
+	}
+
+	if config.PDFAnalysisPrompt != "My own PDF prompt" {
+		t.Errorf("Expected a modified prompt to be kept, got %q", config.PDFAnalysisPrompt)
+	}
+	pdf, _ := config.PromptField("pdf_analysis_prompt")
+	if !pdf.Modified(config.PDFAnalysisPrompt) || !config.DefaultChanged(pdf) {
+		t.Error("Expected the modified prompt to report a changed default")
+	}
+
+	config.MarkPromptBaseline("pdf_analysis_prompt")
+	if config.DefaultChanged(pdf) {
+		t.Error("Expected MarkPromptBaseline to acknowledge the new default")
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{"identical", "a\nb", "a\nb", "  a\n  b\n"},
+		{"added line", "a\nc", "a\nb\nc", "  a\n+ b\n  c\n"},
+		{"removed line", "a\nb\nc", "a\nc", "  a\n- b\n  c\n"},
+		{"changed line", "a\nb", "a\nx", "  a\n- b\n+ x\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatDiff(DiffLines(tt.old, tt.new)); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
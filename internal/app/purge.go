@@ -0,0 +1,203 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+var ErrInvalidPurgeTarget = errors.New("invalid purge target")
+
+// IndexPurger is implemented by index services that can delete entries by path
+type IndexPurger interface {
+	PurgeMatching(match func(path string) bool) ([]string, error)
+}
+
+// SnapshotPurger is implemented by index services that keep directory snapshots
+type SnapshotPurger interface {
+	PurgeSnapshotFiles(match func(path string) bool) (int, error)
+}
+
+// PurgeMatcher decides which paths a purge covers. A plain path covers itself and everything
+// under it; a glob such as "**/Medical/**" or "/home/me/tax-*" is matched against full paths
+// with forward slashes, and also covers everything under a matching folder.
+type PurgeMatcher struct {
+	target string
+	glob   bool
+}
+
+// NewPurgeMatcher parses target as a path or glob pattern
+func NewPurgeMatcher(target string) (*PurgeMatcher, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return nil, fmt.Errorf("%w: no path or pattern given", ErrInvalidPurgeTarget)
+	}
+	if strings.ContainsAny(target, "*?[{") {
+		target = filepath.ToSlash(target)
+		if !doublestar.ValidatePattern(target) {
+			return nil, fmt.Errorf("%w: malformed pattern %s", ErrInvalidPurgeTarget, target)
+		}
+		return &PurgeMatcher{target: target, glob: true}, nil
+	}
+	if IsObjectStoragePath(target) {
+		return &PurgeMatcher{target: strings.TrimSuffix(target, "/")}, nil
+	}
+	if !filepath.IsAbs(target) {
+		return nil, fmt.Errorf("%w: %s is not an absolute path", ErrInvalidPurgeTarget, target)
+	}
+	return &PurgeMatcher{target: filepath.Clean(target)}, nil
+}
+
+// Matches reports whether p is covered by the purge
+func (m *PurgeMatcher) Matches(p string) bool {
+	if p == "" {
+		return false
+	}
+	if !m.glob {
+		if IsObjectStoragePath(m.target) {
+			return p == m.target || strings.HasPrefix(p, m.target+"/")
+		}
+		return !IsObjectStoragePath(p) && isSubPath(m.target, filepath.Clean(p))
+	}
+
+	p = filepath.ToSlash(p)
+	for {
+		if ok, _ := doublestar.Match(m.target, p); ok {
+			return true
+		}
+		parent := path.Dir(p)
+		if parent == p || parent == "." || strings.HasSuffix(parent, ":") {
+			return false
+		}
+		p = parent
+	}
+}
+
+// PurgeReport lists what a purge deleted
+type PurgeReport struct {
+	Target            string
+	IndexEntries      []string // Paths whose descriptions were deleted from the index
+	Sidecars          []string // Description sidecars deleted next to files
+	SyncEntries       int      // Entries removed from index sync bundles
+	SnapshotEntries   int      // Files removed from directory snapshots
+	PendingPlans      int      // Pending plans dropped entirely
+	PendingOperations int      // Operations removed from pending plans
+	Corrections       int      // Remembered rejected or reverted moves
+	ExecutionHistory  int      // Recently executed moves forgotten
+	CachedAnalyses    int      // Cached enriched structures dropped
+	AuditReferences   int      // Audit log entries that mention the paths; the log is append-only
+	Errors            []string
+}
+
+// String summarizes the report, one line per kind of data
+func (r PurgeReport) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Purged data about %s:\n", r.Target)
+	fmt.Fprintf(&sb, "- %d index entries\n", len(r.IndexEntries))
+	fmt.Fprintf(&sb, "- %d description sidecars\n", len(r.Sidecars))
+	fmt.Fprintf(&sb, "- %d shared index sync entries\n", r.SyncEntries)
+	fmt.Fprintf(&sb, "- %d files in directory snapshots\n", r.SnapshotEntries)
+	fmt.Fprintf(&sb, "- %d pending plans and %d pending operations\n", r.PendingPlans, r.PendingOperations)
+	fmt.Fprintf(&sb, "- %d remembered corrections\n", r.Corrections)
+	fmt.Fprintf(&sb, "- %d moves from the execution history\n", r.ExecutionHistory)
+	fmt.Fprintf(&sb, "- %d cached analyses\n", r.CachedAnalyses)
+	if r.AuditReferences > 0 {
+		fmt.Fprintf(&sb, "Kept %d audit log entries that mention these paths; the audit log cannot be changed.\n", r.AuditReferences)
+	}
+	for _, e := range r.Errors {
+		fmt.Fprintf(&sb, "Error: %s\n", e)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// PurgeData deletes everything the app stored about paths matching target (see PurgeMatcher):
+// index entries and the sidecars the app wrote for them, shared sync entries, directory snapshots, pending plans, remembered corrections,
+// the in-memory execution history and cached analyses. Failures in one store don't stop the
+// others; they are listed in the report.
+func (o *Orchestrator) PurgeData(target string) (PurgeReport, error) {
+	matcher, err := NewPurgeMatcher(target)
+	if err != nil {
+		return PurgeReport{}, err
+	}
+	report := PurgeReport{Target: strings.TrimSpace(target)}
+	match := matcher.Matches
+
+	if purger, ok := o.indexService.(IndexPurger); ok {
+		paths, err := purger.PurgeMatching(match)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("index: %v", err))
+		}
+		report.IndexEntries = paths
+	}
+
+	// Sidecars are only ever written for indexed files, but may outlive their index entry.
+	// XMP sidecars written by other tools are left alone.
+	for _, p := range report.IndexEntries {
+		for _, sidecar := range []string{p + sidecarJSONSuffix, p + sidecarXMPSuffix} {
+			if strings.HasSuffix(sidecar, sidecarXMPSuffix) {
+				if data, err := os.ReadFile(sidecar); err != nil || !isOwnXMP(data) {
+					continue
+				}
+			} else if _, err := os.Lstat(sidecar); err != nil {
+				continue
+			}
+			if err := os.Remove(sidecar); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("sidecar %s: %v", sidecar, err))
+				continue
+			}
+			report.Sidecars = append(report.Sidecars, sidecar)
+		}
+	}
+
+	if o.indexSync != nil {
+		if report.SyncEntries, err = o.indexSync.Purge(match); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("index sync: %v", err))
+		}
+	}
+
+	if purger, ok := o.indexService.(SnapshotPurger); ok {
+		if report.SnapshotEntries, err = purger.PurgeSnapshotFiles(match); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("snapshots: %v", err))
+		}
+	}
+
+	report.PendingPlans, report.PendingOperations = o.pendingPlans.Purge(match)
+
+	if report.Corrections, err = o.corrections.Purge(match); err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("corrections: %v", err))
+	}
+
+	o.execMu.Lock()
+	kept := o.executed[:0]
+	for _, e := range o.executed {
+		if match(e.op.From) || match(e.op.To) {
+			report.ExecutionHistory++
+			continue
+		}
+		kept = append(kept, e)
+	}
+	o.executed = kept
+	o.execMu.Unlock()
+
+	// Enriched structures embed descriptions; dropping all of them is simpler than finding the lines
+	o.enrichMu.Lock()
+	report.CachedAnalyses = len(o.enrichCache)
+	o.enrichMu.Unlock()
+	o.invalidateStructureCaches("")
+
+	if report.AuditReferences, err = o.audit.CountReferences(match); err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("audit log: %v", err))
+	}
+	// The purged paths themselves are left out of the log on purpose
+	o.audit.Record(AuditPurge, "", fmt.Sprintf("%d index entries, %d sidecars, %d pending operations, %d corrections",
+		len(report.IndexEntries), len(report.Sidecars), report.PendingOperations, report.Corrections))
+
+	o.logger.Info("Purged %d index entries and %d other records", len(report.IndexEntries),
+		len(report.Sidecars)+report.SyncEntries+report.SnapshotEntries+report.PendingOperations+report.Corrections+report.ExecutionHistory)
+	return report, nil
+}
@@ -0,0 +1,136 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPurgeMatcher(t *testing.T) {
+	tests := []struct {
+		target string
+		path   string
+		want   bool
+	}{
+		{"/home/me/Medical", "/home/me/Medical", true},
+		{"/home/me/Medical", "/home/me/Medical/scan.pdf", true},
+		{"/home/me/Medical", "/home/me/MedicalBills/bill.pdf", false},
+		{"**/Medical", "/home/me/Medical/2023/scan.pdf", true},
+		{"**/Medical/**", "/data/Medical/scan.pdf", true},
+		{"/home/me/tax-*", "/home/me/tax-2023.pdf", true},
+		{"/home/me/tax-*", "/home/me/taxes.pdf", false},
+		{"s3://bucket/private", "s3://bucket/private/a.txt", true},
+		{"s3://bucket/private", "s3://bucket/private-not/a.txt", false},
+	}
+	for _, tt := range tests {
+		matcher, err := NewPurgeMatcher(tt.target)
+		if err != nil {
+			t.Fatalf("NewPurgeMatcher(%q): %v", tt.target, err)
+		}
+		if got := matcher.Matches(tt.path); got != tt.want {
+			t.Errorf("%q matches %q = %v, want %v", tt.target, tt.path, got, tt.want)
+		}
+	}
+
+	for _, target := range []string{"", "  ", "relative/path", "/bad/[pattern"} {
+		if _, err := NewPurgeMatcher(target); !errors.Is(err, ErrInvalidPurgeTarget) {
+			t.Errorf("NewPurgeMatcher(%q): expected ErrInvalidPurgeTarget, got %v", target, err)
+		}
+	}
+}
+
+func TestPurgeData(t *testing.T) {
+	root := t.TempDir()
+	private := filepath.Join(root, "Medical")
+	if err := os.MkdirAll(private, 0755); err != nil {
+		t.Fatal(err)
+	}
+	scan := filepath.Join(private, "scan.pdf")
+	xray := filepath.Join(private, "xray.jpg")
+	notes := filepath.Join(root, "notes.txt")
+	for _, p := range []string{scan, xray, notes, scan + sidecarJSONSuffix} {
+		if err := os.WriteFile(p, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := NewSidecarWriter(&Config{SidecarFormat: SidecarFormatXMP}, NewLogger(false)).Write(scan, "description of scan.pdf", "text"); err != nil {
+		t.Fatal(err)
+	}
+	foreignXMP := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:Description xmp:CreatorTool="darktable"/></x:xmpmeta>`
+	if err := os.WriteFile(xray+sidecarXMPSuffix, []byte(foreignXMP), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := NewLogger(false)
+	indexService := NewIndexService(logger)
+	if err := indexService.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer indexService.Close()
+	for _, p := range []string{scan, xray, notes} {
+		if err := indexService.IndexFile(p, "description of "+filepath.Base(p), "text", 1, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	audit, err := OpenAuditLog(filepath.Join(t.TempDir(), "audit.db"), "test", logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer audit.Close()
+	audit.Record(AuditMove, filepath.Join(root, "Archive", "scan.pdf"), "from "+scan)
+
+	pending := NewPendingPlanStore("", logger)
+	pending.Enqueue(PendingPlan{BasePath: root, Operations: []FileOperation{
+		{From: scan, To: filepath.Join(root, "Archive", "scan.pdf")},
+		{From: notes, To: filepath.Join(root, "Docs", "notes.txt")},
+	}})
+	corrections := NewCorrectionStore("", logger)
+	corrections.Record(root, []FileOperation{{From: scan, To: filepath.Join(root, "Other", "scan.pdf")}}, CorrectionRejected)
+
+	validator := NewValidator()
+	orchestrator := NewOrchestrator(&stubAIService{}, NewFileService(validator, logger), validator, logger, nil, indexService, NewHookRunner(&Config{}, logger))
+	orchestrator.SetAuditLog(audit)
+	orchestrator.SetPendingPlans(pending)
+	orchestrator.SetCorrections(corrections)
+
+	report, err := orchestrator.PurgeData(private)
+	if err != nil {
+		t.Fatalf("PurgeData: %v", err)
+	}
+
+	if len(report.IndexEntries) != 2 {
+		t.Errorf("expected only the files in %s to be purged from the index, got %v", private, report.IndexEntries)
+	}
+	if file, _ := indexService.GetIndexedFile(scan); file != nil {
+		t.Error("purged file is still indexed")
+	}
+	if file, _ := indexService.GetIndexedFile(notes); file == nil {
+		t.Error("unrelated file was purged from the index")
+	}
+	for _, sidecar := range []string{scan + sidecarJSONSuffix, scan + sidecarXMPSuffix} {
+		if _, err := os.Stat(sidecar); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be deleted (%v)", sidecar, err)
+		}
+	}
+	if len(report.Sidecars) != 2 {
+		t.Errorf("expected two sidecars in the report, got %v", report.Sidecars)
+	}
+	if _, err := os.Stat(xray + sidecarXMPSuffix); err != nil {
+		t.Errorf("another tool's XMP sidecar must be kept: %v", err)
+	}
+	if _, err := os.Stat(scan); err != nil {
+		t.Errorf("the file itself must be kept: %v", err)
+	}
+	if report.PendingOperations != 1 || report.PendingPlans != 0 || len(pending.List()[0].Operations) != 1 {
+		t.Errorf("expected one pending operation removed, got %+v", report)
+	}
+	if report.Corrections != 1 || len(corrections.List(root)) != 0 {
+		t.Errorf("expected the correction to be forgotten, got %d", report.Corrections)
+	}
+	if report.AuditReferences != 1 {
+		t.Errorf("expected one audit reference to be reported, got %d", report.AuditReferences)
+	}
+}
@@ -0,0 +1,149 @@
+package app
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+)
+
+// Why operations are quarantined
+const (
+	RiskLeavesBase      = "moves the file out of the scanned folder"
+	RiskOverwritesNewer = "a newer file already exists at the destination"
+	RiskShortcutTarget  = "a shortcut points to it"
+)
+
+// maxShortcutSize bounds how much of a .lnk file is searched for the paths it points to
+const maxShortcutSize = 64 << 10
+
+// QuarantinedOperation is an operation held out of bulk execution until it is confirmed on its own
+type QuarantinedOperation struct {
+	Operation FileOperation
+	Reasons   []string // Risk heuristics it matched, with details
+}
+
+// Quarantine splits operations into those that can run in bulk and those matching a risk
+// heuristic: the destination is outside basePath, it would replace a newer file, or a symlink or
+// Windows shortcut under basePath points to the file (or into the folder) being moved.
+func Quarantine(basePath string, operations []FileOperation) (safe []FileOperation, quarantined []QuarantinedOperation) {
+	shortcuts := shortcutReferences(basePath, operations)
+	for _, op := range operations {
+		var reasons []string
+		if EscapesBase(basePath, op.To) {
+			reasons = append(reasons, RiskLeavesBase)
+		}
+		if overwritesNewer(op) {
+			reasons = append(reasons, RiskOverwritesNewer)
+		}
+		for _, shortcut := range shortcuts[filepath.Clean(op.From)] {
+			reasons = append(reasons, RiskShortcutTarget+": "+relativeSlashPath(basePath, shortcut))
+		}
+		if len(reasons) == 0 {
+			safe = append(safe, op)
+			continue
+		}
+		quarantined = append(quarantined, QuarantinedOperation{Operation: op, Reasons: reasons})
+	}
+	return safe, quarantined
+}
+
+// overwritesNewer reports whether a different file newer than the source sits at the destination
+func overwritesNewer(op FileOperation) bool {
+	if IsObjectStoragePath(op.From) || IsObjectStoragePath(op.To) {
+		return false
+	}
+	to, err := os.Lstat(op.To)
+	if err != nil {
+		return false
+	}
+	from, err := os.Lstat(op.From)
+	if err != nil || os.SameFile(from, to) {
+		// A case-only rename finds the source itself on case-insensitive filesystems
+		return false
+	}
+	return to.ModTime().After(from.ModTime())
+}
+
+// shortcutReferences finds the symlinks and .lnk files under basePath that point to the source of
+// an operation or to something inside it, keyed by the source
+func shortcutReferences(basePath string, operations []FileOperation) map[string][]string {
+	references := make(map[string][]string)
+	if IsObjectStoragePath(basePath) || len(operations) == 0 {
+		return references
+	}
+	sources := make([]string, 0, len(operations))
+	for _, op := range operations {
+		sources = append(sources, filepath.Clean(op.From))
+	}
+
+	filepath.WalkDir(basePath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		var matches func(source string) bool
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return nil
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(p), target)
+			}
+			target = filepath.Clean(target)
+			matches = func(source string) bool { return isSubPath(source, target) }
+		case d.Type().IsRegular() && strings.EqualFold(filepath.Ext(p), ".lnk"):
+			content := readShortcut(p)
+			matches = func(source string) bool { return shortcutMentions(content, source) }
+		default:
+			return nil
+		}
+		for _, source := range sources {
+			if source != filepath.Clean(p) && matches(source) {
+				references[source] = append(references[source], p)
+			}
+		}
+		return nil
+	})
+	return references
+}
+
+// readShortcut returns the start of a shortcut file with ASCII lowercased, or nil if it can't be read
+func readShortcut(p string) []byte {
+	return foldASCII(readShortcutFile(p))
+}
+
+// foldASCII lowercases ASCII letters in place. Nothing else is touched, so the binary parts of a
+// shortcut stay as they are and UTF-16 text folds the same way as ANSI text.
+func foldASCII(b []byte) []byte {
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return b
+}
+
+// shortcutMentions reports whether a lowercased .lnk file holds source as a complete path, in the
+// ANSI or UTF-16 form shortcuts store it in. Shortcuts to something inside source count too.
+func shortcutMentions(content []byte, source string) bool {
+	for _, ending := range []string{"\x00", `\`, "/"} {
+		path := source + ending
+		if bytes.Contains(content, foldASCII([]byte(path))) {
+			return true
+		}
+		units := utf16.Encode([]rune(path))
+		wide := make([]byte, 0, 2*len(units))
+		for _, u := range units {
+			wide = binary.LittleEndian.AppendUint16(wide, u)
+		}
+		if bytes.Contains(content, foldASCII(wide)) {
+			return true
+		}
+	}
+	return false
+}
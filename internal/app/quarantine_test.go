@@ -0,0 +1,99 @@
+package app
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+func TestQuarantine(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, modTime time.Time) string {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	old, recent := time.Now().Add(-time.Hour), time.Now()
+
+	write("plain.txt", old)
+	write("stale.txt", recent)
+	write("Archive/stale.txt", old)
+	write("fresh.txt", old)
+	write("Archive/fresh.txt", recent)
+	write("linked.txt", old)
+	write("Projects/a/notes.txt", old)
+	report := write("Report.pdf", old)
+	write("Report.pdf.bak", old)
+	wide := write("Wide.doc", old)
+
+	if err := os.MkdirAll(filepath.Join(dir, "Links"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../linked.txt", filepath.Join(dir, "Links", "linked")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "Projects", "a", "notes.txt"), filepath.Join(dir, "Links", "notes")); err != nil {
+		t.Fatal(err)
+	}
+	// Shortcuts keep their target as ANSI and UTF-16 strings among binary data
+	lnk := append([]byte("L\x00\x00\x00\x01\x14\x02"), []byte(strings.ToUpper(report)+"\x00")...)
+	if err := os.WriteFile(filepath.Join(dir, "Links", "report.lnk"), lnk, 0644); err != nil {
+		t.Fatal(err)
+	}
+	lnk = []byte("L\x00\x00\x00")
+	for _, u := range utf16.Encode([]rune(wide + "\x00")) {
+		lnk = binary.LittleEndian.AppendUint16(lnk, u)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Links", "wide.lnk"), lnk, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	move := func(from, to string) FileOperation {
+		return FileOperation{From: filepath.Join(dir, filepath.FromSlash(from)), To: filepath.Join(dir, filepath.FromSlash(to))}
+	}
+	operations := []FileOperation{
+		move("plain.txt", "Text/plain.txt"),
+		move("plain.txt", "../plain.txt"),
+		move("stale.txt", "Archive/stale.txt"),    // Destination is older
+		move("fresh.txt", "Archive/fresh.txt"),    // Destination is newer
+		move("linked.txt", "Text/linked.txt"),     // Relative symlink
+		move("Projects", "Old/Projects"),          // A symlink points inside
+		move("Report.pdf", "Docs/Report.pdf"),     // ANSI shortcut, other case
+		move("Report.pdf.bak", "Docs/Report.bak"), // Only a prefix is in the shortcut
+		move("Wide.doc", "Docs/Wide.doc"),         // UTF-16 shortcut
+	}
+	safe, quarantined := Quarantine(dir, operations)
+
+	got := make(map[string]string)
+	for _, q := range quarantined {
+		got[relativeSlashPath(dir, q.Operation.From)+" -> "+q.Operation.To] = strings.Join(q.Reasons, "; ")
+	}
+	want := map[string]string{
+		"plain.txt -> " + filepath.Join(dir, "..", "plain.txt"):      RiskLeavesBase,
+		"fresh.txt -> " + filepath.Join(dir, "Archive", "fresh.txt"): RiskOverwritesNewer,
+		"linked.txt -> " + filepath.Join(dir, "Text", "linked.txt"):  RiskShortcutTarget + ": Links/linked",
+		"Projects -> " + filepath.Join(dir, "Old", "Projects"):       RiskShortcutTarget + ": Links/notes",
+		"Report.pdf -> " + filepath.Join(dir, "Docs", "Report.pdf"):  RiskShortcutTarget + ": Links/report.lnk",
+		"Wide.doc -> " + filepath.Join(dir, "Docs", "Wide.doc"):      RiskShortcutTarget + ": Links/wide.lnk",
+	}
+	for key, reasons := range want {
+		if got[key] != reasons {
+			t.Errorf("%s quarantined for %q, want %q", key, got[key], reasons)
+		}
+	}
+	if len(quarantined) != len(want) || len(safe) != len(operations)-len(want) {
+		t.Errorf("%d safe and %d quarantined: %v", len(safe), len(quarantined), got)
+	}
+}
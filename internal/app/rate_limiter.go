@@ -0,0 +1,275 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backgroundReserve is the share of each bucket that background requests (deep analysis, indexing)
+// leave untouched, so an interactive planning request never has to queue behind a long indexing run
+const backgroundReserve = 0.2
+
+// RateLimit is the budget for a single provider. Zero means unlimited.
+type RateLimit struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+}
+
+// ParseRateLimits parses the rate limit configuration: one provider per line in the form
+// "host: requests/min, tokens/min" (e.g. "openrouter.ai: 60, 200000").
+// Blank lines and lines starting with # are ignored.
+func ParseRateLimits(spec string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+
+	for i, line := range strings.Split(spec, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		host, values, found := strings.Cut(line, ":")
+		host = strings.ToLower(strings.TrimSpace(host))
+		if !found || host == "" {
+			return nil, fmt.Errorf("line %d: expected \"host: requests/min, tokens/min\"", i+1)
+		}
+
+		var limit RateLimit
+		parts := strings.Split(values, ",")
+		if len(parts) > 2 {
+			return nil, fmt.Errorf("line %d: expected at most two values", i+1)
+		}
+		for j, part := range parts {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("line %d: invalid limit %q", i+1, part)
+			}
+			if j == 0 {
+				limit.RequestsPerMinute = n
+			} else {
+				limit.TokensPerMinute = n
+			}
+		}
+		limits[host] = limit
+	}
+
+	return limits, nil
+}
+
+// providerKey identifies the provider behind an endpoint by its host
+func providerKey(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return strings.ToLower(endpoint)
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// estimateTokens roughly estimates the token cost of text (about 4 bytes per token)
+func estimateTokens(bodySize int) int {
+	return bodySize/4 + 1
+}
+
+// estimateRequestTokens estimates the input tokens of a chat completion request body. Image
+// parts count at a fixed cost rather than by the size of their base64 data, which is far larger
+// than what vision models bill for a downscaled image.
+func estimateRequestTokens(jsonData []byte) int {
+	var req struct {
+		Messages []struct {
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(jsonData, &req); err != nil || len(req.Messages) == 0 {
+		return estimateTokens(len(jsonData))
+	}
+
+	tokens := 1
+	for _, message := range req.Messages {
+		var text string
+		if err := json.Unmarshal(message.Content, &text); err == nil {
+			tokens += estimateTokens(len(text))
+			continue
+		}
+		var parts []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(message.Content, &parts); err != nil {
+			tokens += estimateTokens(len(message.Content))
+			continue
+		}
+		for _, part := range parts {
+			if part.Type == "image_url" {
+				tokens += estimatedImageTokens
+			} else {
+				tokens += estimateTokens(len(part.Text))
+			}
+		}
+	}
+	return tokens
+}
+
+// tokenBucket refills continuously up to capacity at capacity-per-minute
+type tokenBucket struct {
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+func newTokenBucket(perMinute int, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		capacity: float64(perMinute),
+		tokens:   float64(perMinute),
+		last:     now,
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Minutes()
+	if elapsed > 0 {
+		b.tokens = math.Min(b.capacity, b.tokens+elapsed*b.capacity)
+		b.last = now
+	}
+}
+
+// wait returns how long until n tokens can be taken while keeping reserve tokens in the bucket
+func (b *tokenBucket) wait(n, reserve float64) time.Duration {
+	// Requests larger than the whole bucket are allowed once it is full
+	n = math.Min(n, b.capacity)
+	reserve = math.Min(reserve, b.capacity-n)
+
+	missing := n + reserve - b.tokens
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(missing / b.capacity * float64(time.Minute))
+}
+
+func (b *tokenBucket) take(n float64) {
+	b.tokens -= math.Min(n, b.capacity)
+}
+
+type providerBuckets struct {
+	limit    RateLimit
+	requests *tokenBucket
+	tokens   *tokenBucket
+}
+
+// RateLimiter enforces per-provider request and token budgets shared by every LLM caller.
+// Limits are re-read from the config whenever they change.
+type RateLimiter struct {
+	config *Config
+	logger *Logger
+
+	mu      sync.Mutex
+	spec    string
+	limits  map[string]RateLimit
+	buckets map[string]*providerBuckets
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+func NewRateLimiter(config *Config, logger *Logger) *RateLimiter {
+	return &RateLimiter{
+		config:  config,
+		logger:  logger,
+		buckets: make(map[string]*providerBuckets),
+		now:     time.Now,
+		sleep:   time.Sleep,
+	}
+}
+
+// bucketsFor returns the buckets for provider, or nil if it has no limit. Caller must hold rl.mu.
+func (rl *RateLimiter) bucketsFor(provider string) *providerBuckets {
+	if rl.config.RateLimits != rl.spec {
+		limits, err := ParseRateLimits(rl.config.RateLimits)
+		if err != nil {
+			rl.logger.Error("Invalid rate limit configuration: %v", err)
+		}
+		rl.spec = rl.config.RateLimits
+		rl.limits = limits
+		rl.buckets = make(map[string]*providerBuckets)
+	}
+
+	limit, ok := rl.limits[provider]
+	if !ok || (limit.RequestsPerMinute == 0 && limit.TokensPerMinute == 0) {
+		return nil
+	}
+
+	pb, ok := rl.buckets[provider]
+	if !ok {
+		now := rl.now()
+		pb = &providerBuckets{limit: limit}
+		if limit.RequestsPerMinute > 0 {
+			pb.requests = newTokenBucket(limit.RequestsPerMinute, now)
+		}
+		if limit.TokensPerMinute > 0 {
+			pb.tokens = newTokenBucket(limit.TokensPerMinute, now)
+		}
+		rl.buckets[provider] = pb
+	}
+	return pb
+}
+
+// Wait blocks until a request with the estimated token count may be sent to endpoint.
+// Background requests leave a reserve in each bucket for interactive ones.
+func (rl *RateLimiter) Wait(endpoint string, estimatedTokens int, interactive bool) {
+	provider := providerKey(endpoint)
+	logged := false
+
+	for {
+		rl.mu.Lock()
+		pb := rl.bucketsFor(provider)
+		if pb == nil {
+			rl.mu.Unlock()
+			return
+		}
+
+		now := rl.now()
+		var delay time.Duration
+		if pb.requests != nil {
+			pb.requests.refill(now)
+			reserve := 0.0
+			if !interactive {
+				reserve = pb.requests.capacity * backgroundReserve
+			}
+			delay = max(delay, pb.requests.wait(1, reserve))
+		}
+		if pb.tokens != nil {
+			pb.tokens.refill(now)
+			reserve := 0.0
+			if !interactive {
+				reserve = pb.tokens.capacity * backgroundReserve
+			}
+			delay = max(delay, pb.tokens.wait(float64(estimatedTokens), reserve))
+		}
+
+		if delay == 0 {
+			if pb.requests != nil {
+				pb.requests.take(1)
+			}
+			if pb.tokens != nil {
+				pb.tokens.take(float64(estimatedTokens))
+			}
+			rl.mu.Unlock()
+			return
+		}
+		rl.mu.Unlock()
+
+		if !logged {
+			rl.logger.Debug("Rate limit reached for %s, waiting %v", provider, delay.Round(time.Millisecond))
+			logged = true
+		}
+		rl.sleep(delay)
+	}
+}
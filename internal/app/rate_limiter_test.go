@@ -0,0 +1,98 @@
+package app
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	limits, err := ParseRateLimits("# comment\nOpenRouter.ai: 60, 200000\n\nlocalhost: , 5000\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := limits["openrouter.ai"]; got != (RateLimit{RequestsPerMinute: 60, TokensPerMinute: 200000}) {
+		t.Errorf("openrouter.ai: got %+v", got)
+	}
+	if got := limits["localhost"]; got != (RateLimit{TokensPerMinute: 5000}) {
+		t.Errorf("localhost: got %+v", got)
+	}
+
+	for _, bad := range []string{"no-colon", "host: abc", "host: 1, 2, 3", "host: -5"} {
+		if _, err := ParseRateLimits(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestRateLimiter_WaitsAndReservesForInteractive(t *testing.T) {
+	config := &Config{RateLimits: "api.example.com: 10"}
+	rl := NewRateLimiter(config, NewLogger(false))
+
+	now := time.Unix(0, 0)
+	var slept time.Duration
+	rl.now = func() time.Time { return now }
+	rl.sleep = func(d time.Duration) {
+		slept += d
+		now = now.Add(d)
+	}
+
+	endpoint := "https://api.example.com/v1/chat/completions"
+
+	// Background requests stop once only the reserve (2 of 10) is left
+	for i := 0; i < 8; i++ {
+		rl.Wait(endpoint, 1, false)
+	}
+	if slept != 0 {
+		t.Fatalf("expected no waiting within budget, slept %v", slept)
+	}
+
+	// Interactive requests may use the reserve
+	rl.Wait(endpoint, 1, true)
+	rl.Wait(endpoint, 1, true)
+	if slept != 0 {
+		t.Fatalf("expected interactive requests to use the reserve, slept %v", slept)
+	}
+
+	// Bucket is empty: the next request waits for one token to refill (6s at 10/min)
+	rl.Wait(endpoint, 1, true)
+	if slept < 5*time.Second || slept > 7*time.Second {
+		t.Errorf("expected ~6s wait, slept %v", slept)
+	}
+
+	// Unlisted providers are never limited
+	slept = 0
+	rl.Wait("https://other.example.com/v1", 1000000, false)
+	if slept != 0 {
+		t.Errorf("expected no limit for unlisted provider, slept %v", slept)
+	}
+}
+
+func TestEstimateRequestTokens(t *testing.T) {
+	image := "data:image/jpeg;base64," + strings.Repeat("A", 600000)
+	body, err := json.Marshal(map[string]interface{}{
+		"model": "vision",
+		"messages": []map[string]interface{}{
+			{"role": "system", "content": strings.Repeat("s", 400)},
+			{"role": "user", "content": []map[string]interface{}{
+				{"type": "text", "text": strings.Repeat("t", 800)},
+				{"type": "image_url", "image_url": map[string]string{"url": image}},
+				{"type": "image_url", "image_url": map[string]string{"url": image}},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := estimateRequestTokens(body)
+	want := 1 + estimateTokens(400) + estimateTokens(800) + 2*estimatedImageTokens
+	if got != want {
+		t.Errorf("estimateRequestTokens() = %d, want %d", got, want)
+	}
+
+	if got := estimateRequestTokens([]byte("not json")); got != estimateTokens(len("not json")) {
+		t.Errorf("estimateRequestTokens(invalid) = %d, want the body-size estimate", got)
+	}
+}
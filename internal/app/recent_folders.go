@@ -0,0 +1,111 @@
+package app
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+const maxRecentFolders = 8
+
+// FolderShortcut is a well-known folder offered next to the folder picker
+type FolderShortcut struct {
+	Name string
+	Path string
+}
+
+// AddRecentFolder moves path to the front of the recently used folders and reports whether
+// that changed anything
+func (c *Config) AddRecentFolder(path string) bool {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return false
+	}
+	if !strings.Contains(path, "://") {
+		path = filepath.Clean(path)
+	}
+	if len(c.RecentFolders) > 0 && c.RecentFolders[0] == path {
+		return false
+	}
+	recent := slices.DeleteFunc(slices.Clone(c.RecentFolders), func(p string) bool { return p == path })
+	c.RecentFolders = append([]string{path}, recent...)
+	if len(c.RecentFolders) > maxRecentFolders {
+		c.RecentFolders = c.RecentFolders[:maxRecentFolders]
+	}
+	return true
+}
+
+// BrowseStartFolder returns where the folder picker should open: current if it is a local folder,
+// else the most recent folder that still exists, else the home folder ("" if there is none)
+func (c *Config) BrowseStartFolder(current string) string {
+	for _, path := range append([]string{current}, c.RecentFolders...) {
+		if path == "" || strings.Contains(path, "://") {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return path
+		}
+	}
+	home, _ := os.UserHomeDir()
+	return home
+}
+
+// FolderShortcuts returns the user's Home, Downloads, Documents and Desktop folders that exist.
+// On Linux the localized names from xdg-user-dirs are used when set.
+func FolderShortcuts() []FolderShortcut {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	shortcuts := []FolderShortcut{{"Home", home}}
+	xdg := xdgUserDirs(home)
+	for _, dir := range []struct{ name, xdgKey string }{
+		{"Downloads", "XDG_DOWNLOAD_DIR"},
+		{"Documents", "XDG_DOCUMENTS_DIR"},
+		{"Desktop", "XDG_DESKTOP_DIR"},
+	} {
+		path := filepath.Join(home, dir.name)
+		if xdgPath := xdg[dir.xdgKey]; xdgPath != "" {
+			path = xdgPath
+		}
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			shortcuts = append(shortcuts, FolderShortcut{dir.name, path})
+		}
+	}
+	return shortcuts
+}
+
+// xdgUserDirs reads ~/.config/user-dirs.dirs, where lines look like XDG_DOWNLOAD_DIR="$HOME/Downloads"
+func xdgUserDirs(home string) map[string]string {
+	dirs := make(map[string]string)
+	if runtime.GOOS != "linux" {
+		return dirs
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+	f, err := os.Open(filepath.Join(configHome, "user-dirs.dirs"))
+	if err != nil {
+		return dirs
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok || strings.HasPrefix(key, "#") {
+			continue
+		}
+		value = strings.Trim(value, `"`)
+		value = strings.Replace(value, "$HOME", home, 1)
+		if filepath.IsAbs(value) && filepath.Clean(value) != filepath.Clean(home) {
+			dirs[key] = value // A folder set to $HOME itself means the user disabled it
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestAddRecentFolder(t *testing.T) {
+	config := &Config{}
+	for _, path := range []string{"/a", "/b/", "s3://bucket/docs", "/a"} {
+		config.AddRecentFolder(path)
+	}
+	if want := []string{"/a", "s3://bucket/docs", "/b"}; !slices.Equal(config.RecentFolders, want) {
+		t.Errorf("expected %v, got %v", want, config.RecentFolders)
+	}
+	if config.AddRecentFolder("/a") || config.AddRecentFolder("  ") {
+		t.Error("expected no change for the latest folder or an empty path")
+	}
+
+	for i := range maxRecentFolders + 3 {
+		config.AddRecentFolder(fmt.Sprintf("/dir%d", i))
+	}
+	if len(config.RecentFolders) != maxRecentFolders || config.RecentFolders[0] != fmt.Sprintf("/dir%d", maxRecentFolders+2) {
+		t.Errorf("expected the %d latest folders, got %v", maxRecentFolders, config.RecentFolders)
+	}
+}
+
+func TestBrowseStartFolder(t *testing.T) {
+	existing := t.TempDir()
+	missing := filepath.Join(existing, "gone")
+	current := t.TempDir()
+	config := &Config{RecentFolders: []string{"s3://bucket", missing, existing}}
+
+	tests := []struct {
+		current string
+		want    string
+	}{
+		{"", existing},
+		{missing, existing},
+		{"s3://bucket/prefix", existing},
+		{current, current},
+	}
+	for _, tt := range tests {
+		if got := config.BrowseStartFolder(tt.current); got != tt.want {
+			t.Errorf("%q: expected %q, got %q", tt.current, tt.want, got)
+		}
+	}
+
+	home, _ := os.UserHomeDir()
+	if got := (&Config{}).BrowseStartFolder(""); got != home {
+		t.Errorf("expected the home folder without recents, got %q", got)
+	}
+}
+
+func TestXDGUserDirs(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("xdg-user-dirs is only read on Linux")
+	}
+	home := t.TempDir()
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	dirs := `# Written by xdg-user-dirs-update
+XDG_DESKTOP_DIR="$HOME/Schreibtisch"
+XDG_DOWNLOAD_DIR="$HOME/Downloads"
+XDG_DOCUMENTS_DIR="$HOME/"
+`
+	if err := os.WriteFile(filepath.Join(configHome, "user-dirs.dirs"), []byte(dirs), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := xdgUserDirs(home)
+	if got["XDG_DESKTOP_DIR"] != filepath.Join(home, "Schreibtisch") || got["XDG_DOWNLOAD_DIR"] != filepath.Join(home, "Downloads") {
+		t.Errorf("unexpected folders %v", got)
+	}
+	if _, ok := got["XDG_DOCUMENTS_DIR"]; ok {
+		t.Error("expected a folder set to the home folder to be ignored")
+	}
+}
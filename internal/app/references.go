@@ -0,0 +1,235 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxReferenceFileSize bounds the playlists, notes and sidecars read for references
+const maxReferenceFileSize = 4 << 20
+
+var (
+	// [text](target "title") and ![alt](target); the target may be wrapped in <>
+	markdownInlineLink = regexp.MustCompile(`(!?\[[^\]]*\]\()(<[^>\n]*>|[^)\s]+)`)
+	// [label]: target
+	markdownLinkDefinition = regexp.MustCompile(`(?m)^( {0,3}\[[^\]\n]+\]:[ \t]*)(<[^>\n]*>|\S+)`)
+	// Paths photo tools keep in sidecars: linked and derived-from files, and the raw file name
+	xmpPathAttribute = regexp.MustCompile(`((?:stRef:filePath|xmpMM:DerivedFrom|crs:RawFileName)=")([^"]*)`)
+	xmpPathElement   = regexp.MustCompile(`(<(?:stRef:filePath|xmpMM:DerivedFrom|crs:RawFileName)>)([^<]*)`)
+	urlScheme        = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]+:`)
+)
+
+// ReferenceChange is one path rewritten in a file
+type ReferenceChange struct {
+	Old string
+	New string
+}
+
+// ReferenceUpdate is a playlist, Markdown note or XMP sidecar whose paths to moved files were
+// rewritten
+type ReferenceUpdate struct {
+	File    string
+	Changes []ReferenceChange
+	Error   error // Why the file couldn't be rewritten; nil if it was
+}
+
+// referenceRewriter adjusts the paths in one file. Relative paths are resolved from where the
+// file was before the moves, so notes moved away from what they link to are fixed too.
+type referenceRewriter struct {
+	dir     string // Where the file is now
+	origDir string // Where it was before the moves
+	moves   []FileOperation
+	changes []ReferenceChange
+}
+
+// rewrite returns the reference to where its target is now, in the same form (relative or
+// absolute, slashes or backslashes), or ref itself if it doesn't need to change
+func (r *referenceRewriter) rewrite(ref string) string {
+	if ref == "" || urlScheme.MatchString(ref) && !filepath.IsAbs(ref) {
+		return ref
+	}
+	backslashes := strings.Contains(ref, `\`) && !strings.Contains(ref, "/")
+	p := filepath.FromSlash(strings.ReplaceAll(ref, `\`, "/"))
+	relative := !filepath.IsAbs(p)
+	old := filepath.Clean(p)
+	if relative {
+		old = filepath.Join(r.origDir, p)
+	}
+
+	target, moved := movedPath(old, r.moves)
+	if !moved {
+		if !relative || r.dir == r.origDir || !exists(old) {
+			return ref
+		}
+		target = old // The file holding the reference moved away from its target
+	}
+	updated := target
+	if relative {
+		rel, err := filepath.Rel(r.dir, target)
+		if err != nil {
+			return ref
+		}
+		updated = filepath.ToSlash(rel)
+		if strings.HasPrefix(ref, "./") || strings.HasPrefix(ref, `.\`) {
+			updated = "./" + updated
+		}
+	}
+	if backslashes {
+		updated = strings.ReplaceAll(filepath.ToSlash(updated), "/", `\`)
+	}
+	if updated == ref {
+		return ref
+	}
+	r.changes = append(r.changes, ReferenceChange{Old: ref, New: updated})
+	return updated
+}
+
+// rewritePlaylist adjusts the entries of an M3U playlist, one path per line
+func (r *referenceRewriter) rewritePlaylist(content []byte) []byte {
+	var out bytes.Buffer
+	// Lines keep their endings, so CRLF playlists stay CRLF
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		if entry := strings.TrimSpace(line); entry != "" && !strings.HasPrefix(entry, "#") {
+			line = strings.Replace(line, entry, r.rewrite(entry), 1)
+		}
+		out.WriteString(line)
+	}
+	return out.Bytes()
+}
+
+// rewriteMarkdownTarget adjusts a link target, keeping its <> wrapping, #fragment and escaping
+func (r *referenceRewriter) rewriteMarkdownTarget(target string) string {
+	wrapped := strings.HasPrefix(target, "<") && strings.HasSuffix(target, ">")
+	if wrapped {
+		target = target[1 : len(target)-1]
+	}
+	path, fragment, _ := strings.Cut(target, "#")
+	if fragment != "" || strings.HasSuffix(target, "#") {
+		fragment = "#" + fragment
+	}
+	escaped := strings.Contains(path, "%")
+	if escaped {
+		unescaped, err := url.PathUnescape(path)
+		if err != nil {
+			escaped = false
+		} else {
+			path = unescaped
+		}
+	}
+
+	before := len(r.changes)
+	updated := r.rewrite(path)
+	if len(r.changes) > before && escaped {
+		updated = (&url.URL{Path: updated}).EscapedPath()
+		r.changes[len(r.changes)-1] = ReferenceChange{Old: target, New: updated + fragment}
+	}
+	updated += fragment
+	if wrapped {
+		updated = "<" + updated + ">"
+	}
+	return updated
+}
+
+func (r *referenceRewriter) rewriteMarkdown(content []byte) []byte {
+	for _, re := range []*regexp.Regexp{markdownInlineLink, markdownLinkDefinition} {
+		content = re.ReplaceAllFunc(content, func(match []byte) []byte {
+			groups := re.FindSubmatch(match)
+			return append(append([]byte{}, groups[1]...), r.rewriteMarkdownTarget(string(groups[2]))...)
+		})
+	}
+	return content
+}
+
+func (r *referenceRewriter) rewriteXMP(content []byte) []byte {
+	for _, re := range []*regexp.Regexp{xmpPathAttribute, xmpPathElement} {
+		content = re.ReplaceAllFunc(content, func(match []byte) []byte {
+			groups := re.FindSubmatch(match)
+			ref := html.UnescapeString(string(groups[2]))
+			updated := r.rewrite(ref)
+			if updated == ref {
+				return match
+			}
+			return append(append([]byte{}, groups[1]...), html.EscapeString(updated)...)
+		})
+	}
+	return content
+}
+
+// UpdateReferences rewrites the paths to moved files in the M3U playlists, Markdown notes and XMP
+// sidecars under basePath, after the moves ran. Only files that changed are returned.
+func UpdateReferences(basePath string, moves []FileOperation) []ReferenceUpdate {
+	if IsObjectStoragePath(basePath) || len(moves) == 0 {
+		return nil
+	}
+	undo := make([]FileOperation, len(moves))
+	for i, op := range moves {
+		undo[i] = FileOperation{From: op.To, To: op.From}
+	}
+
+	var updates []ReferenceUpdate
+	filepath.WalkDir(basePath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		var rewrite func(r *referenceRewriter, content []byte) []byte
+		ext := strings.ToLower(filepath.Ext(p))
+		switch ext {
+		case ".m3u", ".m3u8":
+			rewrite = (*referenceRewriter).rewritePlaylist
+		case ".md", ".markdown":
+			rewrite = (*referenceRewriter).rewriteMarkdown
+		case sidecarXMPSuffix:
+			rewrite = (*referenceRewriter).rewriteXMP
+		default:
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > maxReferenceFileSize {
+			return nil
+		}
+
+		r := &referenceRewriter{dir: filepath.Dir(p), origDir: filepath.Dir(p), moves: moves}
+		orig, moved := movedPath(filepath.Clean(p), undo)
+		if !moved && ext == sidecarXMPSuffix {
+			// Sidecars are carried along with their file rather than moved by an operation
+			orig, moved = movedPath(filepath.Clean(p[:len(p)-len(ext)]), undo)
+		}
+		if moved {
+			r.origDir = filepath.Dir(orig)
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			updates = append(updates, ReferenceUpdate{File: p, Error: err})
+			return nil
+		}
+		updated := rewrite(r, content)
+		if len(r.changes) == 0 {
+			return nil
+		}
+		update := ReferenceUpdate{File: p, Changes: r.changes}
+		update.Error = replaceFile(p, updated, info.Mode().Perm())
+		updates = append(updates, update)
+		return nil
+	})
+	return updates
+}
+
+// replaceFile swaps in new content with a rename, so a failed write leaves the old file intact
+func replaceFile(p string, content []byte, perm fs.FileMode) error {
+	tmp := filepath.Join(filepath.Dir(p), replaceTempPrefix+filepath.Base(p))
+	if err := os.WriteFile(tmp, content, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdateReferences(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	abs := func(name string) string { return filepath.Join(dir, filepath.FromSlash(name)) }
+
+	write("Inbox/song one.mp3", "")
+	write("Inbox/song2.mp3", "")
+	write("Inbox/cover.png", "")
+	write("Inbox/IMG_001.CR2", "")
+	write("Inbox/IMG_001.CR2.xmp", `<rdf:Description crs:RawFileName="IMG_001.CR2"><xmpMM:DerivedFrom>IMG_001.CR2</xmpMM:DerivedFrom></rdf:Description>`)
+	write("stays.txt", "")
+	write("Playlists/mix.m3u", "#EXTM3U\r\n#EXTINF:1,One\r\n../Inbox/song one.mp3\r\n"+abs("Inbox/song2.mp3")+"\r\nhttp://radio.example/stream\r\n../stays.txt")
+	write("Playlists/win.m3u8", `..\Inbox\song2.mp3`+"\n")
+	write("Notes/readme.md", strings.Join([]string{
+		"![Cover](../Inbox/cover.png \"cover\")",
+		"[Song](<../Inbox/song one.mp3>) and [escaped](../Inbox/song%20one.mp3#t=10)",
+		"[Section](#top) [Site](https://example.com/Inbox/cover.png) [Mail](mailto:a@example.com)",
+		"[stays]: ../stays.txt",
+		"[song]: ../Inbox/song2.mp3",
+	}, "\n"))
+	write("Inbox/links.md", "[Stays](../stays.txt) [Cover](cover.png)")
+
+	moves := []FileOperation{
+		{From: abs("Inbox/song one.mp3"), To: abs("Music/song one.mp3")},
+		{From: abs("Inbox/song2.mp3"), To: abs("Music/Albums/song2.mp3")},
+		{From: abs("Inbox/cover.png"), To: abs("Images/cover.png")},
+		{From: abs("Inbox/IMG_001.CR2"), To: abs("Photos/2024-01-05 IMG_001.CR2")},
+		{From: abs("Inbox/links.md"), To: abs("Notes/Inbox/links.md")},
+	}
+	for _, op := range moves {
+		if err := os.MkdirAll(filepath.Dir(op.To), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(op.From, op.To); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The sidecar goes along with its photo, the way moveSidecars carries it
+	if err := os.Rename(abs("Inbox/IMG_001.CR2.xmp"), abs("Photos/2024-01-05 IMG_001.CR2.xmp")); err != nil {
+		t.Fatal(err)
+	}
+
+	updates := UpdateReferences(dir, moves)
+	changed := make(map[string]int)
+	for _, update := range updates {
+		if update.Error != nil {
+			t.Errorf("%s: %v", update.File, update.Error)
+		}
+		changed[update.File] = len(update.Changes)
+	}
+
+	tests := []struct {
+		name    string
+		changes int
+		want    string
+	}{
+		{"Playlists/mix.m3u", 2, "#EXTM3U\r\n#EXTINF:1,One\r\n../Music/song one.mp3\r\n" + abs("Music/Albums/song2.mp3") + "\r\nhttp://radio.example/stream\r\n../stays.txt"},
+		{"Playlists/win.m3u8", 1, `..\Music\Albums\song2.mp3` + "\n"},
+		{"Notes/readme.md", 4, strings.Join([]string{
+			"![Cover](../Images/cover.png \"cover\")",
+			"[Song](<../Music/song one.mp3>) and [escaped](../Music/song%20one.mp3#t=10)",
+			"[Section](#top) [Site](https://example.com/Inbox/cover.png) [Mail](mailto:a@example.com)",
+			"[stays]: ../stays.txt",
+			"[song]: ../Music/Albums/song2.mp3",
+		}, "\n")},
+		{"Notes/Inbox/links.md", 2, "[Stays](../../stays.txt) [Cover](../../Images/cover.png)"},
+		{"Photos/2024-01-05 IMG_001.CR2.xmp", 2, `<rdf:Description crs:RawFileName="2024-01-05 IMG_001.CR2"><xmpMM:DerivedFrom>2024-01-05 IMG_001.CR2</xmpMM:DerivedFrom></rdf:Description>`},
+	}
+	if len(updates) != len(tests) {
+		t.Errorf("UpdateReferences changed %d files, want %d: %v", len(updates), len(tests), changed)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := changed[abs(tt.name)]; got != tt.changes {
+				t.Errorf("changes = %d, want %d", got, tt.changes)
+			}
+			content, err := os.ReadFile(abs(tt.name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("content =\n%s\nwant\n%s", content, tt.want)
+			}
+		})
+	}
+
+	// Undoing the moves restores every reference
+	var undo []FileOperation
+	for i := len(moves) - 1; i >= 0; i-- {
+		undo = append(undo, FileOperation{From: moves[i].To, To: moves[i].From})
+		if err := os.Rename(moves[i].To, moves[i].From); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Rename(abs("Photos/2024-01-05 IMG_001.CR2.xmp"), abs("Inbox/IMG_001.CR2.xmp")); err != nil {
+		t.Fatal(err)
+	}
+	UpdateReferences(dir, undo)
+	if content, _ := os.ReadFile(abs("Inbox/links.md")); string(content) != "[Stays](../stays.txt) [Cover](cover.png)" {
+		t.Errorf("links.md after undo = %s", content)
+	}
+	if content, _ := os.ReadFile(abs("Playlists/win.m3u8")); string(content) != `..\Inbox\song2.mp3`+"\n" {
+		t.Errorf("win.m3u8 after undo = %s", content)
+	}
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"io"
+	"sync"
+)
+
+const defaultMaxConcurrentRequests = 4
+
+// RequestQueue limits how many requests are in flight to each provider. Waiting interactive
+// requests (organization planning) are let through before any waiting background request (deep
+// analysis, indexing), and they may use one slot beyond the limit, so the Analyze button never
+// waits behind a long indexing run. The limit is re-read from the config on every request.
+type RequestQueue struct {
+	config *Config
+	logger *Logger
+
+	mu        sync.Mutex
+	providers map[string]*providerQueue
+}
+
+type providerQueue struct {
+	inFlight    int
+	interactive []chan struct{} // Waiting requests in arrival order
+	background  []chan struct{}
+}
+
+func NewRequestQueue(config *Config, logger *Logger) *RequestQueue {
+	return &RequestQueue{
+		config:    config,
+		logger:    logger,
+		providers: make(map[string]*providerQueue),
+	}
+}
+
+func (q *RequestQueue) limit() int {
+	if q.config.MaxConcurrentRequests > 0 {
+		return q.config.MaxConcurrentRequests
+	}
+	return defaultMaxConcurrentRequests
+}
+
+// Acquire blocks until a request may be sent to endpoint. The returned function frees the slot;
+// calling it more than once has no further effect.
+func (q *RequestQueue) Acquire(endpoint string, interactive bool) func() {
+	provider := providerKey(endpoint)
+
+	q.mu.Lock()
+	pq, ok := q.providers[provider]
+	if !ok {
+		pq = &providerQueue{}
+		q.providers[provider] = pq
+	}
+
+	var ready chan struct{}
+	if interactive {
+		if len(pq.interactive) == 0 && pq.inFlight < q.limit()+1 {
+			pq.inFlight++
+		} else {
+			ready = make(chan struct{})
+			pq.interactive = append(pq.interactive, ready)
+		}
+	} else {
+		if len(pq.interactive) == 0 && len(pq.background) == 0 && pq.inFlight < q.limit() {
+			pq.inFlight++
+		} else {
+			ready = make(chan struct{})
+			pq.background = append(pq.background, ready)
+		}
+	}
+	queued := len(pq.interactive) + len(pq.background)
+	q.mu.Unlock()
+
+	if ready != nil {
+		q.logger.Debug("%d requests to %s are waiting for a free slot", queued, provider)
+		<-ready
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { q.release(pq) })
+	}
+}
+
+// release hands the slot to the next waiting request, interactive ones first
+func (q *RequestQueue) release(pq *providerQueue) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pq.inFlight--
+	limit := q.limit()
+	for len(pq.interactive) > 0 && pq.inFlight < limit+1 {
+		pq.inFlight++
+		close(pq.interactive[0])
+		pq.interactive = pq.interactive[1:]
+	}
+	for len(pq.interactive) == 0 && len(pq.background) > 0 && pq.inFlight < limit {
+		pq.inFlight++
+		close(pq.background[0])
+		pq.background = pq.background[1:]
+	}
+}
+
+// releasingBody frees a request's queue slot when its streamed body is closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
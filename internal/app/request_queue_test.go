@@ -0,0 +1,91 @@
+package app
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitForQueue waits until the given numbers of requests to provider are waiting
+func waitForQueue(t *testing.T, q *RequestQueue, provider string, interactive, background int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		q.mu.Lock()
+		pq := q.providers[provider]
+		done := pq != nil && len(pq.interactive) == interactive && len(pq.background) == background
+		q.mu.Unlock()
+		if done {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected %d interactive and %d background requests waiting", interactive, background)
+}
+
+func TestRequestQueue_InteractiveFirst(t *testing.T) {
+	const endpoint = "https://api.example.com/v1/chat/completions"
+	config := &Config{MaxConcurrentRequests: 1}
+	q := NewRequestQueue(config, NewLogger(false))
+
+	releaseIndexing := q.Acquire(endpoint, false)
+	releasePlanning := q.Acquire(endpoint, true) // The extra slot kept for planning
+
+	order := make(chan string, 3)
+	var wg sync.WaitGroup
+	wg.Add(3)
+	acquire := func(name string, interactive bool) {
+		defer wg.Done()
+		release := q.Acquire(endpoint, interactive)
+		order <- name
+		<-time.After(10 * time.Millisecond)
+		release()
+	}
+	go acquire("background 1", false)
+	waitForQueue(t, q, "api.example.com", 0, 1)
+	go acquire("background 2", false)
+	waitForQueue(t, q, "api.example.com", 0, 2)
+	go acquire("interactive", true)
+	waitForQueue(t, q, "api.example.com", 1, 2)
+
+	releaseIndexing()
+	releaseIndexing() // Releasing twice frees one slot
+	releasePlanning()
+
+	for i, want := range []string{"interactive", "background 1", "background 2"} {
+		select {
+		case got := <-order:
+			if got != want {
+				t.Errorf("request %d: expected %s, got %s", i+1, want, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("request %d never got a slot", i+1)
+		}
+	}
+
+	wg.Wait()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if inFlight := q.providers["api.example.com"].inFlight; inFlight != 0 {
+		t.Errorf("expected all slots to be free, %d still in flight", inFlight)
+	}
+}
+
+func TestRequestQueue_ProvidersAreIndependent(t *testing.T) {
+	config := &Config{MaxConcurrentRequests: 1}
+	q := NewRequestQueue(config, NewLogger(false))
+
+	release := q.Acquire("https://openrouter.ai/api/v1/chat/completions", false)
+	defer release()
+
+	done := make(chan struct{})
+	go func() {
+		q.Acquire("http://localhost:11434/v1/chat/completions", false)()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a request to another provider not to wait")
+	}
+}
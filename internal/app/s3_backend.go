@@ -0,0 +1,338 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	s3Scheme           = "s3"
+	defaultS3Region    = "us-east-1"
+	emptyPayloadSHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// S3Backend implements StorageBackend for AWS S3 and S3-compatible stores (MinIO, R2, Wasabi, ...).
+// It speaks the REST API directly with SigV4 signing, reading credentials from Config on every call
+// so changes made in the config window apply immediately.
+type S3Backend struct {
+	config *Config
+	client *http.Client
+	logger *Logger
+	now    func() time.Time
+}
+
+func NewS3Backend(config *Config, logger *Logger) *S3Backend {
+	return &S3Backend{
+		config: config,
+		client: &http.Client{Transport: sharedTransport, Timeout: 60 * time.Second},
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+func (b *S3Backend) Scheme() string {
+	return s3Scheme
+}
+
+// parseS3Path splits "s3://bucket/some/key" into bucket and key
+func parseS3Path(p string) (string, string, error) {
+	scheme, rest, ok := splitScheme(p)
+	if !ok || scheme != s3Scheme {
+		return "", "", fmt.Errorf("not an s3:// path: %s", p)
+	}
+	bucket, key, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("missing bucket in %s", p)
+	}
+	return bucket, key, nil
+}
+
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+}
+
+func (b *S3Backend) List(prefix string) ([]StorageEntry, error) {
+	bucket, keyPrefix, err := parseS3Path(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []StorageEntry
+	continuation := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", keyPrefix)
+		if continuation != "" {
+			query.Set("continuation-token", continuation)
+		}
+
+		resp, err := b.do(http.MethodGet, bucket, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read list response: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("S3 list error: %s - Body: %s", resp.Status, truncate(string(body), 500))
+		}
+
+		var page listBucketResult
+		if err := xml.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse list response: %w", err)
+		}
+
+		for _, obj := range page.Contents {
+			// Zero-byte "folder marker" objects are an S3 console convention, not real files
+			if strings.HasSuffix(obj.Key, "/") {
+				continue
+			}
+			entries = append(entries, StorageEntry{
+				Path:    fmt.Sprintf("s3://%s/%s", bucket, obj.Key),
+				Size:    obj.Size,
+				ModTime: obj.LastModified,
+			})
+		}
+
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			break
+		}
+		continuation = page.NextContinuationToken
+	}
+
+	b.logger.Debug("Listed %d objects under %s", len(entries), prefix)
+	return entries, nil
+}
+
+func (b *S3Backend) Exists(objectPath string) (bool, error) {
+	bucket, key, err := parseS3Path(objectPath)
+	if err != nil {
+		return false, err
+	}
+	if key == "" {
+		return false, nil
+	}
+
+	resp, err := b.do(http.MethodHead, bucket, key, nil, nil)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("S3 head error for %s: %s", objectPath, resp.Status)
+	}
+}
+
+// Move copies the object to its new key and deletes the original
+func (b *S3Backend) Move(from, to string) error {
+	srcBucket, srcKey, err := parseS3Path(from)
+	if err != nil {
+		return err
+	}
+	dstBucket, dstKey, err := parseS3Path(to)
+	if err != nil {
+		return err
+	}
+
+	copySource := "/" + srcBucket + "/" + s3EscapePath(srcKey)
+	resp, err := b.do(http.MethodPut, dstBucket, dstKey, nil, map[string]string{"x-amz-copy-source": copySource})
+	if err != nil {
+		return err
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	// CopyObject can return 200 with an <Error> body, so check both
+	if resp.StatusCode != http.StatusOK || strings.Contains(string(body), "<Error>") {
+		return fmt.Errorf("S3 copy error: %s - Body: %s", resp.Status, truncate(string(body), 500))
+	}
+
+	resp, err = b.do(http.MethodDelete, srcBucket, srcKey, nil, nil)
+	if err != nil {
+		return fmt.Errorf("copied but failed to delete original: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("copied but failed to delete original: %s", resp.Status)
+	}
+	return nil
+}
+
+// do builds, signs and sends a request against bucket/key
+func (b *S3Backend) do(method, bucket, key string, query url.Values, headers map[string]string) (*http.Response, error) {
+	reqURL, err := b.objectURL(bucket, key, query)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(method, reqURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	region := b.config.S3Region
+	if region == "" {
+		region = defaultS3Region
+	}
+	signS3Request(req, b.config.S3AccessKey, b.config.S3SecretKey, region, b.now().UTC())
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// objectURL resolves the request URL using path-style (endpoint/bucket/key) or
+// virtual-hosted style (bucket.endpoint/key) addressing
+func (b *S3Backend) objectURL(bucket, key string, query url.Values) (*url.URL, error) {
+	endpoint := strings.TrimSuffix(b.config.S3Endpoint, "/")
+	if endpoint == "" {
+		region := b.config.S3Region
+		if region == "" {
+			region = defaultS3Region
+		}
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint %q: %w", endpoint, err)
+	}
+
+	if b.config.S3UsePathStyle {
+		u.Path = "/" + bucket
+		if key != "" {
+			u.Path += "/" + key
+		}
+	} else {
+		u.Host = bucket + "." + u.Host
+		u.Path = "/" + key
+	}
+	u.RawPath = s3EscapePath(u.Path)
+	u.RawQuery = canonicalQueryString(query)
+	return u, nil
+}
+
+// signS3Request adds AWS Signature Version 4 headers for an empty-body request.
+// Every header already set on req is included in the signature.
+func signS3Request(req *http.Request, accessKey, secretKey, region string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", emptyPayloadSHA256)
+
+	// Canonical headers: lowercase names, sorted, host included
+	headerValues := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headerValues[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headerValues))
+	for name := range headerValues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headerValues[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQueryString(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptyPayloadSHA256,
+	}, "\n")
+
+	scope := dateStamp + "/" + region + "/s3/aws4_request"
+	hashedRequest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hashedRequest[:])
+
+	signingKey := hmacSHA256([]byte("AWS4"+secretKey), dateStamp)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQueryString encodes query parameters sorted by key, as SigV4 requires
+func canonicalQueryString(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, s3Escape(k)+"="+s3Escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3EscapePath URI-encodes each path segment while keeping the slashes
+func s3EscapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// s3Escape percent-encodes everything except RFC 3986 unreserved characters
+func s3Escape(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
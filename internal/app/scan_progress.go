@@ -0,0 +1,68 @@
+package app
+
+import "time"
+
+// scanProgressInterval throttles progress callbacks so slow UIs aren't flooded on fast disks
+const scanProgressInterval = 200 * time.Millisecond
+
+// ScanProgress reports how far a directory walk has got
+type ScanProgress struct {
+	FilesScanned   int
+	CurrentPath    string
+	FilesPerSecond float64
+	Done           bool
+}
+
+// ScanProgressCallback receives periodic updates while a directory structure is being walked
+type ScanProgressCallback func(progress ScanProgress)
+
+// scanProgressReporter counts scanned entries and forwards throttled updates to a callback
+type scanProgressReporter struct {
+	onProgress ScanProgressCallback
+	start      time.Time
+	lastReport time.Time
+	files      int
+}
+
+func newScanProgressReporter(onProgress ScanProgressCallback) *scanProgressReporter {
+	now := time.Now()
+	return &scanProgressReporter{
+		onProgress: onProgress,
+		start:      now,
+		lastReport: now,
+	}
+}
+
+// fileScanned records one more file; path is reported as the current position of the walk
+func (r *scanProgressReporter) fileScanned(path string) {
+	r.files++
+	if r.onProgress == nil {
+		return
+	}
+	now := time.Now()
+	if now.Sub(r.lastReport) < scanProgressInterval {
+		return
+	}
+	r.lastReport = now
+	r.onProgress(r.progress(path, now, false))
+}
+
+// finish sends the final update for the walk
+func (r *scanProgressReporter) finish() {
+	if r.onProgress != nil {
+		r.onProgress(r.progress("", time.Now(), true))
+	}
+}
+
+func (r *scanProgressReporter) progress(path string, now time.Time, done bool) ScanProgress {
+	rate := 0.0
+	if elapsed := now.Sub(r.start).Seconds(); elapsed > 0 {
+		rate = float64(r.files) / elapsed
+	}
+	return ScanProgress{
+		FilesScanned:   r.files,
+		CurrentPath:    path,
+		FilesPerSecond: rate,
+		Done:           done,
+	}
+}
@@ -0,0 +1,199 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ParseScheduledJobs parses the scheduled job configuration: one job per line in the form
+// "/nas/Downloads | Sort into folders by file type | 1", where the last field is the optional
+// scan depth (0 or missing scans everything). Blank lines and lines starting with # are ignored.
+func ParseScheduledJobs(spec string) ([]AutomatedJob, error) {
+	var jobs []AutomatedJob
+	for i, line := range strings.Split(spec, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "|")
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("line %d: expected \"folder | instructions [| depth]\"", i+1)
+		}
+		folder, prompt := strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1])
+		if folder == "" || prompt == "" {
+			return nil, fmt.Errorf("line %d: folder and instructions are required", i+1)
+		}
+		depth := 0
+		if len(fields) == 3 {
+			var err error
+			if depth, err = strconv.Atoi(strings.TrimSpace(fields[2])); err != nil || depth < 0 {
+				return nil, fmt.Errorf("line %d: depth must be a whole number, 0 for unlimited", i+1)
+			}
+		}
+		if !IsObjectStoragePath(folder) {
+			folder = filepath.Clean(folder)
+		}
+		jobs = append(jobs, AutomatedJob{
+			Name: filepath.Base(folder),
+			Request: AnalysisRequest{
+				DirectoryPath: folder,
+				UserPrompt:    prompt,
+				MaxDepth:      depth,
+			},
+		})
+	}
+	return jobs, nil
+}
+
+// JobScheduler runs the configured scheduled jobs through an AutoApplier when their last run is
+// older than the configured interval. Jobs and interval are read from the config on every
+// check; the time of each job's last run is persisted so restarts don't reset the schedule.
+type JobScheduler struct {
+	applier *AutoApplier
+	config  *Config
+	logger  *Logger
+	path    string
+
+	mu        sync.Mutex
+	lastRun   map[string]time.Time // Keyed by the job's directory
+	stop      chan struct{}
+	onOutcome func(job AutomatedJob, outcome *AutoApplyOutcome, err error)
+}
+
+// NewJobScheduler creates a scheduler that keeps its run times in path (empty keeps them in memory only)
+func NewJobScheduler(applier *AutoApplier, config *Config, path string, logger *Logger) *JobScheduler {
+	s := &JobScheduler{
+		applier: applier,
+		config:  config,
+		logger:  logger,
+		path:    path,
+		lastRun: make(map[string]time.Time),
+	}
+	s.load()
+	return s
+}
+
+// SetOutcomeHandler sets the callback invoked after every job run
+func (s *JobScheduler) SetOutcomeHandler(handler func(job AutomatedJob, outcome *AutoApplyOutcome, err error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onOutcome = handler
+}
+
+// Start checks for due jobs in the background until Stop is called
+func (s *JobScheduler) Start() {
+	s.mu.Lock()
+	if s.stop != nil {
+		s.mu.Unlock()
+		return
+	}
+	s.stop = make(chan struct{})
+	stop := s.stop
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(janitorCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				s.RunOnce(now)
+			}
+		}
+	}()
+}
+
+// Stop ends the background loop; a job already running finishes first
+func (s *JobScheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// RunOnce runs the jobs that are due at now, one after another, and returns how many ran.
+// Unreachable folders are skipped and tried again at the next check.
+func (s *JobScheduler) RunOnce(now time.Time) int {
+	interval := time.Duration(s.config.ScheduledJobIntervalHours) * time.Hour
+	if interval <= 0 {
+		return 0
+	}
+	jobs, err := ParseScheduledJobs(s.config.ScheduledJobs)
+	if err != nil {
+		s.logger.Error("Scheduled jobs: %v", err)
+		return 0
+	}
+
+	ran := 0
+	for _, job := range jobs {
+		folder := job.Request.DirectoryPath
+		s.mu.Lock()
+		last := s.lastRun[folder]
+		s.mu.Unlock()
+		if now.Sub(last) < interval {
+			continue
+		}
+		if err := CheckPathReachable(folder, 0); err != nil && !IsObjectStoragePath(folder) {
+			s.logger.Info("Skipping scheduled job %q: %v", job.Name, err)
+			continue
+		}
+
+		s.logger.Info("Running scheduled job %q on %s", job.Name, folder)
+		outcome, err := s.applier.Run(job)
+		if err != nil {
+			s.logger.Error("%v", err)
+		}
+		ran++
+
+		s.mu.Lock()
+		s.lastRun[folder] = now
+		s.saveLocked()
+		onOutcome := s.onOutcome
+		s.mu.Unlock()
+		if onOutcome != nil {
+			onOutcome(job, outcome, err)
+		}
+	}
+	return ran
+}
+
+func (s *JobScheduler) load() {
+	if s.path == "" {
+		return
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.logger.Error("Failed to read scheduled job times: %v", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &s.lastRun); err != nil {
+		s.logger.Error("Failed to parse scheduled job times, running every job at the next check: %v", err)
+		s.lastRun = make(map[string]time.Time)
+	}
+}
+
+// saveLocked persists the run times. Caller must hold s.mu.
+func (s *JobScheduler) saveLocked() {
+	if s.path == "" {
+		return
+	}
+	data, err := json.MarshalIndent(s.lastRun, "", "  ")
+	if err == nil {
+		err = os.WriteFile(s.path, data, 0644)
+	}
+	if err != nil {
+		s.logger.Error("Failed to save scheduled job times: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseScheduledJobs(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []AnalysisRequest
+		wantErr bool
+	}{
+		{
+			name: "jobs with and without depth",
+			spec: "# Downloads first\n/nas/Downloads/ | Sort by file type | 1\n\n/nas/Scans | Group by sender",
+			want: []AnalysisRequest{
+				{DirectoryPath: filepath.Clean("/nas/Downloads"), UserPrompt: "Sort by file type", MaxDepth: 1},
+				{DirectoryPath: filepath.Clean("/nas/Scans"), UserPrompt: "Group by sender"},
+			},
+		},
+		{name: "missing instructions", spec: "/nas/Downloads", wantErr: true},
+		{name: "empty instructions", spec: "/nas/Downloads |  ", wantErr: true},
+		{name: "bad depth", spec: "/nas/Downloads | Sort | deep", wantErr: true},
+		{name: "too many fields", spec: "/nas/Downloads | Sort | 1 | 2", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs, err := ParseScheduledJobs(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(jobs) != len(tt.want) {
+				t.Fatalf("got %d jobs, want %d", len(jobs), len(tt.want))
+			}
+			for i, job := range jobs {
+				got := job.Request
+				if got.DirectoryPath != tt.want[i].DirectoryPath || got.UserPrompt != tt.want[i].UserPrompt || got.MaxDepth != tt.want[i].MaxDepth {
+					t.Errorf("job %d = %+v, want %+v", i, got, tt.want[i])
+				}
+				if job.Name != filepath.Base(got.DirectoryPath) {
+					t.Errorf("job name = %q", job.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestJobSchedulerRunOnce(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "invoice.pdf"), []byte("pdf"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := &Config{
+		AutoApply: true, AutoApplyMinConfidence: 0.9, AutoApplyMaxOperations: 20, ParallelMoves: 1,
+		ScheduledJobs:             dir + " | Sort by type\n" + filepath.Join(dir, "missing") + " | Sort by type",
+		ScheduledJobIntervalHours: 24,
+	}
+	logger := NewLogger(false)
+	validator := NewValidator()
+	ai := &stubAIService{operations: []FileOperation{{From: "invoice.pdf", To: "finance/invoice.pdf", Confidence: 0.97}}}
+	orchestrator := NewOrchestrator(ai, NewFileService(validator, logger), validator, logger, nil, nil, NewHookRunner(config, logger))
+	timesPath := filepath.Join(t.TempDir(), "scheduled_jobs.json")
+	scheduler := NewJobScheduler(NewAutoApplier(orchestrator, config, logger), config, timesPath, logger)
+
+	var outcomes []*AutoApplyOutcome
+	scheduler.SetOutcomeHandler(func(job AutomatedJob, outcome *AutoApplyOutcome, err error) {
+		if err != nil {
+			t.Errorf("job %s: %v", job.Name, err)
+		}
+		outcomes = append(outcomes, outcome)
+	})
+
+	now := time.Now()
+	// The unreachable folder is skipped
+	if ran := scheduler.RunOnce(now); ran != 1 {
+		t.Fatalf("first check ran %d jobs, want 1", ran)
+	}
+	if len(outcomes) != 1 || outcomes[0].Execution == nil {
+		t.Fatalf("outcomes = %+v, want one executed plan", outcomes)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "finance", "invoice.pdf")); err != nil {
+		t.Errorf("file was not moved: %v", err)
+	}
+
+	// Not due again until the interval has passed, also after a restart
+	if ran := scheduler.RunOnce(now.Add(time.Hour)); ran != 0 {
+		t.Errorf("check within the interval ran %d jobs", ran)
+	}
+	restarted := NewJobScheduler(NewAutoApplier(orchestrator, config, logger), config, timesPath, logger)
+	if ran := restarted.RunOnce(now.Add(time.Hour)); ran != 0 {
+		t.Errorf("check after restart ran %d jobs", ran)
+	}
+	if ran := restarted.RunOnce(now.Add(25 * time.Hour)); ran != 1 {
+		t.Errorf("check after the interval ran %d jobs, want 1", ran)
+	}
+
+	config.ScheduledJobIntervalHours = 0
+	if ran := restarted.RunOnce(now.Add(100 * time.Hour)); ran != 0 {
+		t.Errorf("disabled scheduler ran %d jobs", ran)
+	}
+}
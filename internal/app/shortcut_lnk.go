@@ -0,0 +1,298 @@
+package app
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+)
+
+var ErrInvalidShortcut = errors.New("not a valid Windows shortcut")
+
+// Windows shortcuts (.lnk, see [MS-SHLLINK]) locate their target through a shell item ID list and
+// a LinkInfo structure holding the local path. Rewriting drops the ID list and the extra data
+// that refers to it, so Windows resolves the new path from LinkInfo.
+const (
+	lnkHeaderSize = 0x4C
+
+	lnkHasTargetIDList        = 0x00000001
+	lnkHasLinkInfo            = 0x00000002
+	lnkHasName                = 0x00000004
+	lnkHasRelativePath        = 0x00000008
+	lnkHasWorkingDir          = 0x00000010
+	lnkHasArguments           = 0x00000020
+	lnkHasIconLocation        = 0x00000040
+	lnkIsUnicode              = 0x00000080
+	lnkForceNoLinkInfo        = 0x00000100
+	lnkHasExpString           = 0x00000200
+	lnkPreferEnvironmentPath  = 0x02000000
+	lnkVolumeIDAndLocalBase   = 0x00000001
+	lnkLinkInfoHeaderUnicode  = 0x24
+	lnkLinkInfoHeaderAnsiOnly = 0x1C
+
+	lnkEnvironmentBlock  = 0xA0000001
+	lnkSpecialFolder     = 0xA0000005
+	lnkKnownFolder       = 0xA000000B
+	lnkVistaIDListBlock  = 0xA000000C
+	lnkDefaultVolumeSize = 0x11
+)
+
+var lnkCLSID = []byte{0x01, 0x14, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46}
+
+// lnkStringFlags are the optional strings of a shortcut, in the order they are stored
+var lnkStringFlags = []uint32{lnkHasName, lnkHasRelativePath, lnkHasWorkingDir, lnkHasArguments, lnkHasIconLocation}
+
+// lnkFile is a parsed shortcut
+type lnkFile struct {
+	header    []byte
+	flags     uint32
+	volumeID  []byte // Copied into rewritten LinkInfo; nil for network shortcuts
+	target    string // Local path from LinkInfo; empty if the shortcut has none
+	strings   map[uint32]string
+	extraData [][]byte // Blocks, each with its size and signature
+}
+
+func lnkUint32(data []byte, off int) (uint32, bool) {
+	if off < 0 || off+4 > len(data) {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint32(data[off:]), true
+}
+
+// lnkCString reads a NUL-terminated ANSI string
+func lnkCString(data []byte, off int) string {
+	if off <= 0 || off >= len(data) {
+		return ""
+	}
+	end := bytes.IndexByte(data[off:], 0)
+	if end < 0 {
+		return ""
+	}
+	return string(data[off : off+end])
+}
+
+// lnkWideString reads a NUL-terminated UTF-16LE string
+func lnkWideString(data []byte, off int) string {
+	if off <= 0 || off >= len(data) {
+		return ""
+	}
+	var units []uint16
+	for i := off; i+1 < len(data); i += 2 {
+		u := binary.LittleEndian.Uint16(data[i:])
+		if u == 0 {
+			break
+		}
+		units = append(units, u)
+	}
+	return string(utf16.Decode(units))
+}
+
+func parseLnk(data []byte) (*lnkFile, error) {
+	if len(data) < lnkHeaderSize || binary.LittleEndian.Uint32(data) != lnkHeaderSize || !bytes.Equal(data[4:20], lnkCLSID) {
+		return nil, ErrInvalidShortcut
+	}
+	lnk := &lnkFile{header: data[:lnkHeaderSize], flags: binary.LittleEndian.Uint32(data[20:]), strings: make(map[uint32]string)}
+	off := lnkHeaderSize
+
+	if lnk.flags&lnkHasTargetIDList != 0 {
+		if off+2 > len(data) {
+			return nil, ErrInvalidShortcut
+		}
+		off += 2 + int(binary.LittleEndian.Uint16(data[off:]))
+	}
+
+	if lnk.flags&lnkHasLinkInfo != 0 && lnk.flags&lnkForceNoLinkInfo == 0 {
+		size, ok := lnkUint32(data, off)
+		if !ok || size < lnkLinkInfoHeaderAnsiOnly || off+int(size) > len(data) {
+			return nil, ErrInvalidShortcut
+		}
+		info := data[off : off+int(size)]
+		headerSize, _ := lnkUint32(info, 4)
+		infoFlags, _ := lnkUint32(info, 8)
+		if infoFlags&lnkVolumeIDAndLocalBase != 0 {
+			volumeOff, _ := lnkUint32(info, 12)
+			if volumeSize, ok := lnkUint32(info, int(volumeOff)); ok && int(volumeOff+volumeSize) <= len(info) {
+				lnk.volumeID = info[volumeOff : volumeOff+volumeSize]
+			}
+			baseOff, _ := lnkUint32(info, 16)
+			suffixOff, _ := lnkUint32(info, 24)
+			base, suffix := lnkCString(info, int(baseOff)), lnkCString(info, int(suffixOff))
+			if headerSize >= lnkLinkInfoHeaderUnicode {
+				wideBaseOff, _ := lnkUint32(info, 28)
+				wideSuffixOff, _ := lnkUint32(info, 32)
+				if wide := lnkWideString(info, int(wideBaseOff)); wide != "" {
+					base, suffix = wide, lnkWideString(info, int(wideSuffixOff))
+				}
+			}
+			lnk.target = base + suffix
+		}
+		off += int(size)
+	}
+
+	for _, flag := range lnkStringFlags {
+		if lnk.flags&flag == 0 {
+			continue
+		}
+		if off+2 > len(data) {
+			return nil, ErrInvalidShortcut
+		}
+		count := int(binary.LittleEndian.Uint16(data[off:]))
+		off += 2
+		if lnk.flags&lnkIsUnicode != 0 {
+			if off+2*count > len(data) {
+				return nil, ErrInvalidShortcut
+			}
+			units := make([]uint16, count)
+			for i := range units {
+				units[i] = binary.LittleEndian.Uint16(data[off+2*i:])
+			}
+			lnk.strings[flag] = string(utf16.Decode(units))
+			off += 2 * count
+		} else {
+			if off+count > len(data) {
+				return nil, ErrInvalidShortcut
+			}
+			lnk.strings[flag] = string(data[off : off+count])
+			off += count
+		}
+	}
+
+	for {
+		size, ok := lnkUint32(data, off)
+		if !ok || size < 8 || off+int(size) > len(data) {
+			break // Terminal block, or a truncated file
+		}
+		lnk.extraData = append(lnk.extraData, data[off:off+int(size)])
+		off += int(size)
+	}
+	return lnk, nil
+}
+
+// windowsRelativePath is the relative path Explorer stores in shortcuts, e.g. "..\Docs\a.pdf"
+func windowsRelativePath(shortcut, target string) (string, bool) {
+	rel, err := filepath.Rel(filepath.Dir(shortcut), target)
+	if err != nil {
+		return "", false
+	}
+	rel = strings.ReplaceAll(rel, "/", `\`)
+	if !strings.HasPrefix(rel, "..") {
+		rel = `.\` + rel
+	}
+	return rel, true
+}
+
+// ansiPath stands in "?" for characters ANSI code pages may lack; Windows reads the Unicode copy
+func ansiPath(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0x7F {
+			r = '?'
+		}
+		out = append(out, byte(r))
+	}
+	return out
+}
+
+func appendWide(buf []byte, s string) []byte {
+	for _, u := range utf16.Encode([]rune(s)) {
+		buf = binary.LittleEndian.AppendUint16(buf, u)
+	}
+	return buf
+}
+
+// retarget returns the shortcut at shortcutPath pointing to target instead. The working directory
+// follows the target when it was the old target's folder.
+func (lnk *lnkFile) retarget(shortcutPath, target string) []byte {
+	oldTarget := lnk.target
+	flags := lnk.flags
+	flags &^= lnkHasTargetIDList | lnkForceNoLinkInfo | lnkHasExpString | lnkPreferEnvironmentPath
+	flags |= lnkHasLinkInfo
+
+	strs := make(map[uint32]string, len(lnk.strings))
+	for flag, s := range lnk.strings {
+		strs[flag] = s
+	}
+	if flags&lnkHasRelativePath != 0 {
+		if rel, ok := windowsRelativePath(shortcutPath, target); ok {
+			strs[lnkHasRelativePath] = rel
+		} else {
+			flags &^= lnkHasRelativePath
+		}
+	}
+	if dir, ok := strs[lnkHasWorkingDir]; ok && oldTarget != "" && strings.EqualFold(filepath.Clean(dir), filepath.Dir(oldTarget)) {
+		strs[lnkHasWorkingDir] = filepath.Dir(target)
+	}
+
+	out := make([]byte, 0, 512)
+	out = append(out, lnk.header...)
+	binary.LittleEndian.PutUint32(out[20:], flags)
+
+	// LinkInfo: header with Unicode offsets, VolumeID, then the ANSI and Unicode paths
+	volumeID := lnk.volumeID
+	if volumeID == nil {
+		volumeID = make([]byte, lnkDefaultVolumeSize)
+		binary.LittleEndian.PutUint32(volumeID, lnkDefaultVolumeSize)
+		binary.LittleEndian.PutUint32(volumeID[4:], 3) // DRIVE_FIXED
+		binary.LittleEndian.PutUint32(volumeID[12:], 0x10)
+	}
+	ansiBase := append(ansiPath(target), 0)
+	wideBase := append(appendWide(nil, target), 0, 0)
+	volumeOff := lnkLinkInfoHeaderUnicode
+	baseOff := volumeOff + len(volumeID)
+	suffixOff := baseOff + len(ansiBase)
+	wideBaseOff := suffixOff + 1
+	wideSuffixOff := wideBaseOff + len(wideBase)
+	infoSize := wideSuffixOff + 2
+
+	info := make([]byte, lnkLinkInfoHeaderUnicode, infoSize)
+	for i, v := range []int{infoSize, lnkLinkInfoHeaderUnicode, lnkVolumeIDAndLocalBase, volumeOff, baseOff, 0, suffixOff, wideBaseOff, wideSuffixOff} {
+		binary.LittleEndian.PutUint32(info[4*i:], uint32(v))
+	}
+	info = append(info, volumeID...)
+	info = append(info, ansiBase...)
+	info = append(info, 0)
+	info = append(info, wideBase...)
+	info = append(info, 0, 0)
+	out = append(out, info...)
+
+	for _, flag := range lnkStringFlags {
+		if flags&flag == 0 {
+			continue
+		}
+		s := strs[flag]
+		if flags&lnkIsUnicode != 0 {
+			units := utf16.Encode([]rune(s))
+			out = binary.LittleEndian.AppendUint16(out, uint16(len(units)))
+			out = appendWide(out, s)
+		} else {
+			b := ansiPath(s)
+			out = binary.LittleEndian.AppendUint16(out, uint16(len(b)))
+			out = append(out, b...)
+		}
+	}
+
+	for _, block := range lnk.extraData {
+		switch binary.LittleEndian.Uint32(block[4:]) {
+		case lnkEnvironmentBlock, lnkSpecialFolder, lnkKnownFolder, lnkVistaIDListBlock:
+			// They point to the old target or into the dropped ID list
+			continue
+		}
+		out = append(out, block...)
+	}
+	return binary.LittleEndian.AppendUint32(out, 0)
+}
+
+// lnkTarget returns the local path a shortcut points to
+func lnkTarget(data []byte) (string, error) {
+	lnk, err := parseLnk(data)
+	if err != nil {
+		return "", err
+	}
+	if lnk.target == "" {
+		return "", fmt.Errorf("%w: the shortcut has no local target path", ErrShortcutUnsupported)
+	}
+	return lnk.target, nil
+}
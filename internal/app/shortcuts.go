@@ -0,0 +1,324 @@
+package app
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+var (
+	ErrShortcutUnsupported  = errors.New("shortcut can't be rewritten")
+	ErrShortcutNotRewritten = errors.New("shortcut still points to the old path")
+	ErrInvalidAlias         = errors.New("not a valid macOS alias")
+)
+
+// replaceTempPrefix names the replacement written next to a file before it's renamed over it
+const replaceTempPrefix = ".vaf-replace-"
+
+// Kinds of shortcuts that can point at moved files
+const (
+	ShortcutSymlink = "symlink"
+	ShortcutWindows = "lnk"
+	ShortcutAlias   = "alias"
+)
+
+// ShortcutFix is a shortcut under the organized folder whose target was moved
+type ShortcutFix struct {
+	Shortcut  string
+	Kind      string // ShortcutSymlink, ShortcutWindows or ShortcutAlias
+	OldTarget string
+	NewTarget string
+	Error     error // Why it wasn't rewritten; nil if it was
+}
+
+func (f ShortcutFix) String() string {
+	return fmt.Sprintf("%s (%s): %s → %s", f.Shortcut, f.Kind, f.OldTarget, f.NewTarget)
+}
+
+// FindShortcutFixes walks basePath after moves ran and returns the symlinks, Windows shortcuts
+// and (on macOS) Finder aliases whose targets were moved, with where they point now. Shortcuts
+// that were moved themselves are found at their new location; relative symlinks among them are
+// resolved from where they were.
+func FindShortcutFixes(basePath string, moves []FileOperation) []ShortcutFix {
+	if IsObjectStoragePath(basePath) || len(moves) == 0 {
+		return nil
+	}
+	undo := make([]FileOperation, len(moves))
+	for i, op := range moves {
+		undo[i] = FileOperation{From: op.To, To: op.From}
+	}
+
+	var fixes []ShortcutFix
+	filepath.WalkDir(basePath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		fix := ShortcutFix{Shortcut: p}
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return nil
+			}
+			fix.Kind = ShortcutSymlink
+			if filepath.IsAbs(target) {
+				fix.OldTarget = filepath.Clean(target)
+				break
+			}
+			current := filepath.Join(filepath.Dir(p), target)
+			fix.OldTarget = current
+			if from, moved := movedPath(filepath.Clean(p), undo); moved {
+				fix.OldTarget = filepath.Join(filepath.Dir(from), target)
+				if newTarget, ok := movedPath(fix.OldTarget, moves); ok && newTarget == current {
+					return nil // Moved along with its target
+				}
+				if !exists(current) && exists(fix.OldTarget) {
+					fix.NewTarget = fix.OldTarget // Moved away from a target that stayed
+				}
+			}
+		case d.Type().IsRegular() && strings.EqualFold(filepath.Ext(p), ".lnk"):
+			target, err := lnkTarget(readShortcutFile(p))
+			if err != nil {
+				return nil
+			}
+			fix.Kind, fix.OldTarget = ShortcutWindows, target
+		case d.Type().IsRegular() && runtime.GOOS == "darwin" && isAliasFile(p, d):
+			target, err := aliasTarget(readShortcutFile(p))
+			if err != nil {
+				return nil
+			}
+			fix.Kind, fix.OldTarget = ShortcutAlias, target
+		default:
+			return nil
+		}
+
+		if target, ok := movedPath(fix.OldTarget, moves); ok {
+			fix.NewTarget = target
+		}
+		if fix.NewTarget == "" {
+			return nil
+		}
+		fixes = append(fixes, fix)
+		return nil
+	})
+	return fixes
+}
+
+// movedPath maps a path to where the moves put it, including paths inside moved folders
+func movedPath(p string, moves []FileOperation) (string, bool) {
+	for _, op := range moves {
+		from := filepath.Clean(op.From)
+		if samePath(p, from) {
+			return filepath.Clean(op.To), true
+		}
+		if len(p) > len(from) && samePath(p[:len(from)], from) && (p[len(from)] == '/' || p[len(from)] == '\\') {
+			return filepath.Clean(op.To) + p[len(from):], true
+		}
+	}
+	return "", false
+}
+
+// samePath compares paths the way the platform's default filesystem does
+func samePath(a, b string) bool {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// RewriteShortcuts points each shortcut at its new target and records failures in the fixes
+func RewriteShortcuts(fixes []ShortcutFix) {
+	for i := range fixes {
+		fix := &fixes[i]
+		switch fix.Kind {
+		case ShortcutSymlink:
+			fix.Error = rewriteSymlink(fix.Shortcut, fix.NewTarget)
+		case ShortcutWindows:
+			fix.Error = rewriteLnk(fix.Shortcut, fix.NewTarget)
+		case ShortcutAlias:
+			fix.Error = rewriteAlias(fix.Shortcut, fix.NewTarget)
+		default:
+			fix.Error = ErrShortcutUnsupported
+		}
+	}
+}
+
+// rewriteSymlink replaces a symlink in one rename, keeping relative links relative
+func rewriteSymlink(link, target string) error {
+	old, err := os.Readlink(link)
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(old) {
+		if rel, err := filepath.Rel(filepath.Dir(link), target); err == nil {
+			target = rel
+		}
+	}
+	tmp := filepath.Join(filepath.Dir(link), replaceTempPrefix+filepath.Base(link))
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, link); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func rewriteLnk(shortcut, target string) error {
+	info, err := os.Stat(shortcut)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(shortcut)
+	if err != nil {
+		return err
+	}
+	lnk, err := parseLnk(data)
+	if err != nil {
+		return err
+	}
+	if lnk.target == "" {
+		return fmt.Errorf("%w: the shortcut has no local target path", ErrShortcutUnsupported)
+	}
+	return replaceFile(shortcut, lnk.retarget(shortcut, target), info.Mode().Perm())
+}
+
+// Finder makes the new alias, since alias data carries volume and file IDs only it can fill in
+const makeAliasScript = `on run argv
+	tell application "Finder"
+		set newAlias to make new alias file at (POSIX file (item 1 of argv) as alias) to (POSIX file (item 2 of argv) as alias)
+		set name of newAlias to (item 3 of argv)
+	end tell
+end run`
+
+// rewriteAlias replaces a Finder alias with a new one of the same name, restoring the old one
+// if Finder fails
+func rewriteAlias(alias, target string) error {
+	if runtime.GOOS != "darwin" {
+		return fmt.Errorf("%w: aliases are rewritten through Finder on macOS", ErrShortcutUnsupported)
+	}
+	aside := filepath.Join(filepath.Dir(alias), replaceTempPrefix+filepath.Base(alias))
+	if err := os.Rename(alias, aside); err != nil {
+		return err
+	}
+	out, err := exec.Command("osascript", "-e", makeAliasScript, filepath.Dir(alias), target, filepath.Base(alias)).CombinedOutput()
+	if err != nil {
+		os.Remove(alias)
+		if restoreErr := os.Rename(aside, alias); restoreErr != nil {
+			return fmt.Errorf("Finder couldn't make the alias (%v: %s) and the old one is at %s: %w", err, strings.TrimSpace(string(out)), aside, restoreErr)
+		}
+		return fmt.Errorf("Finder couldn't make the alias: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return os.Remove(aside)
+}
+
+// readShortcutFile returns the start of a possible shortcut file, or nil if it can't be read
+func readShortcutFile(p string) []byte {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	content, _ := io.ReadAll(io.LimitReader(f, maxShortcutSize))
+	return content
+}
+
+// isAliasFile checks small files for the bookmark header without reading the rest
+func isAliasFile(p string, d fs.DirEntry) bool {
+	if info, err := d.Info(); err != nil || info.Size() > maxShortcutSize {
+		return false
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	magic := make([]byte, len(bookmarkMagic))
+	_, err = io.ReadFull(f, magic)
+	return err == nil && string(magic) == bookmarkMagic
+}
+
+// macOS alias files hold bookmark data: a "book" header, then a data area with a table of
+// contents whose kBookmarkPath entry is an array of the target's path components
+const (
+	bookmarkMagic      = "book"
+	bookmarkTOCMagic   = 0xfffffffe
+	bookmarkPathKey    = 0x1004
+	bookmarkTypeString = 0x0101
+	bookmarkTypeArray  = 0x0601
+)
+
+// aliasTarget returns the path a macOS alias or bookmark points to
+func aliasTarget(data []byte) (string, error) {
+	if len(data) < 16 || !bytes.HasPrefix(data, []byte(bookmarkMagic)) {
+		return "", ErrInvalidAlias
+	}
+	le := binary.LittleEndian
+	u32 := func(off uint32) (uint32, bool) {
+		if uint64(off)+4 > uint64(len(data)) {
+			return 0, false
+		}
+		return le.Uint32(data[off:]), true
+	}
+	base, _ := u32(12)
+	tocOff, ok := u32(base)
+	if !ok {
+		return "", ErrInvalidAlias
+	}
+
+	// item returns the type and data of the item at off in the data area
+	item := func(off uint32) (uint32, []byte, bool) {
+		length, ok1 := u32(base + off)
+		typ, ok2 := u32(base + off + 4)
+		start := uint64(base) + uint64(off) + 8
+		if !ok1 || !ok2 || start+uint64(length) > uint64(len(data)) {
+			return 0, nil, false
+		}
+		return typ, data[start : start+uint64(length)], true
+	}
+
+	for seen := 0; tocOff != 0 && seen < 16; seen++ {
+		toc := base + tocOff
+		magic, ok1 := u32(toc + 4)
+		next, ok2 := u32(toc + 12)
+		count, ok3 := u32(toc + 16)
+		if !ok1 || !ok2 || !ok3 || magic != bookmarkTOCMagic {
+			return "", ErrInvalidAlias
+		}
+		for i := uint32(0); i < count && i < 1024; i++ {
+			entry := toc + 20 + 12*i
+			key, ok1 := u32(entry)
+			off, ok2 := u32(entry + 4)
+			if !ok1 || !ok2 {
+				return "", ErrInvalidAlias
+			}
+			if key != bookmarkPathKey {
+				continue
+			}
+			typ, array, ok := item(off)
+			if !ok || typ != bookmarkTypeArray {
+				return "", ErrInvalidAlias
+			}
+			var components []string
+			for j := 0; j+4 <= len(array); j += 4 {
+				typ, name, ok := item(le.Uint32(array[j:]))
+				if !ok || typ != bookmarkTypeString {
+					return "", ErrInvalidAlias
+				}
+				components = append(components, string(name))
+			}
+			return "/" + strings.Join(components, "/"), nil
+		}
+		tocOff = next
+	}
+	return "", fmt.Errorf("%w: no target path", ErrInvalidAlias)
+}
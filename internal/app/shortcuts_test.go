@@ -0,0 +1,230 @@
+package app
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+)
+
+// buildLnk makes a Unicode shortcut the way Explorer does: an ID list, an ANSI-only LinkInfo,
+// relative path and working directory strings, and a known folder and a tracker block
+func buildLnk(target, relativePath, workingDir string) []byte {
+	data := make([]byte, lnkHeaderSize)
+	binary.LittleEndian.PutUint32(data, lnkHeaderSize)
+	copy(data[4:], lnkCLSID)
+	binary.LittleEndian.PutUint32(data[20:], lnkHasTargetIDList|lnkHasLinkInfo|lnkHasRelativePath|lnkHasWorkingDir|lnkIsUnicode)
+
+	data = binary.LittleEndian.AppendUint16(data, 4)
+	data = append(data, 0xAA, 0xBB, 0, 0)
+
+	volumeID := make([]byte, lnkDefaultVolumeSize)
+	binary.LittleEndian.PutUint32(volumeID, lnkDefaultVolumeSize)
+	binary.LittleEndian.PutUint32(volumeID[4:], 3)
+	binary.LittleEndian.PutUint32(volumeID[8:], 0x1234)
+	base := append([]byte(target), 0)
+	info := make([]byte, lnkLinkInfoHeaderAnsiOnly)
+	size := lnkLinkInfoHeaderAnsiOnly + len(volumeID) + len(base) + 1
+	for i, v := range []int{size, lnkLinkInfoHeaderAnsiOnly, lnkVolumeIDAndLocalBase, lnkLinkInfoHeaderAnsiOnly, lnkLinkInfoHeaderAnsiOnly + len(volumeID), 0, size - 1} {
+		binary.LittleEndian.PutUint32(info[4*i:], uint32(v))
+	}
+	info = append(info, volumeID...)
+	info = append(info, base...)
+	data = append(append(data, info...), 0)
+
+	for _, s := range []string{relativePath, workingDir} {
+		units := utf16.Encode([]rune(s))
+		data = binary.LittleEndian.AppendUint16(data, uint16(len(units)))
+		data = appendWide(data, s)
+	}
+
+	knownFolder := make([]byte, 0x1C)
+	binary.LittleEndian.PutUint32(knownFolder, 0x1C)
+	binary.LittleEndian.PutUint32(knownFolder[4:], lnkKnownFolder)
+	tracker := make([]byte, 0x60)
+	binary.LittleEndian.PutUint32(tracker, 0x60)
+	binary.LittleEndian.PutUint32(tracker[4:], 0xA0000003)
+	tracker[0x60-1] = 0x7F
+	data = append(append(data, knownFolder...), tracker...)
+	return binary.LittleEndian.AppendUint32(data, 0)
+}
+
+func TestLnkRetarget(t *testing.T) {
+	dir := t.TempDir()
+	shortcut := filepath.Join(dir, "Desktop", "report.lnk")
+	oldTarget := filepath.Join(dir, "Inbox", "report.pdf")
+	newTarget := filepath.Join(dir, "Documents", "Reports", "report.pdf")
+
+	lnk, err := parseLnk(buildLnk(oldTarget, `..\Inbox\report.pdf`, filepath.Join(dir, "Inbox")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lnk.target != oldTarget {
+		t.Fatalf("target = %q, want %q", lnk.target, oldTarget)
+	}
+
+	rewritten, err := parseLnk(lnk.retarget(shortcut, newTarget))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rewritten.target != newTarget {
+		t.Errorf("target = %q, want %q", rewritten.target, newTarget)
+	}
+	if rewritten.flags&lnkHasTargetIDList != 0 || rewritten.flags&lnkIsUnicode == 0 {
+		t.Errorf("flags = %#x, want the ID list dropped and Unicode kept", rewritten.flags)
+	}
+	wantRel, _ := windowsRelativePath(shortcut, newTarget)
+	if got := rewritten.strings[lnkHasRelativePath]; got != wantRel {
+		t.Errorf("relative path = %q, want %q", got, wantRel)
+	}
+	if got := rewritten.strings[lnkHasWorkingDir]; got != filepath.Dir(newTarget) {
+		t.Errorf("working dir = %q, want %q", got, filepath.Dir(newTarget))
+	}
+	if len(rewritten.volumeID) != lnkDefaultVolumeSize || binary.LittleEndian.Uint32(rewritten.volumeID[8:]) != 0x1234 {
+		t.Errorf("volume ID not kept: %x", rewritten.volumeID)
+	}
+	if len(rewritten.extraData) != 1 || binary.LittleEndian.Uint32(rewritten.extraData[0][4:]) != 0xA0000003 {
+		t.Errorf("extra data = %d blocks, want only the tracker block", len(rewritten.extraData))
+	}
+
+	if _, err := parseLnk([]byte("L\x00\x00\x00not a shortcut")); !errors.Is(err, ErrInvalidShortcut) {
+		t.Errorf("parseLnk(garbage) error = %v, want ErrInvalidShortcut", err)
+	}
+}
+
+// buildBookmark makes bookmark data holding only a path
+func buildBookmark(components ...string) []byte {
+	const headerSize = 48
+	area := make([]byte, 4) // TOC offset, filled in below
+	var offsets []uint32
+	for _, c := range components {
+		offsets = append(offsets, uint32(len(area)))
+		area = binary.LittleEndian.AppendUint32(area, uint32(len(c)))
+		area = binary.LittleEndian.AppendUint32(area, bookmarkTypeString)
+		area = append(area, c...)
+		for len(area)%4 != 0 {
+			area = append(area, 0)
+		}
+	}
+	arrayOff := uint32(len(area))
+	area = binary.LittleEndian.AppendUint32(area, uint32(4*len(offsets)))
+	area = binary.LittleEndian.AppendUint32(area, bookmarkTypeArray)
+	for _, off := range offsets {
+		area = binary.LittleEndian.AppendUint32(area, off)
+	}
+	tocOff := uint32(len(area))
+	binary.LittleEndian.PutUint32(area, tocOff)
+	for _, v := range []uint32{32, bookmarkTOCMagic, 1, 0, 1, 0x1003, 0, 0, bookmarkPathKey, arrayOff, 0} {
+		area = binary.LittleEndian.AppendUint32(area, v)
+	}
+	// The first entry has an unrelated key, so the count must cover both
+	binary.LittleEndian.PutUint32(area[tocOff+16:], 2)
+
+	header := make([]byte, headerSize)
+	copy(header, bookmarkMagic)
+	binary.LittleEndian.PutUint32(header[4:], uint32(headerSize+len(area)))
+	binary.LittleEndian.PutUint32(header[12:], headerSize)
+	return append(header, area...)
+}
+
+func TestAliasTarget(t *testing.T) {
+	got, err := aliasTarget(buildBookmark("Users", "me", "Documents", "Notes.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "/Users/me/Documents/Notes.txt" {
+		t.Errorf("aliasTarget = %q", got)
+	}
+	for _, data := range [][]byte{nil, []byte("book"), []byte("not a bookmark at all"), buildBookmark("a")[:60]} {
+		if _, err := aliasTarget(data); !errors.Is(err, ErrInvalidAlias) {
+			t.Errorf("aliasTarget(%q) error = %v, want ErrInvalidAlias", data, err)
+		}
+	}
+}
+
+func TestFindAndRewriteShortcuts(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	link := func(target, name string) {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+			t.Skipf("symlinks unavailable: %v", err)
+		}
+	}
+
+	write("Inbox/report.pdf", []byte("report"))
+	write("Inbox/Project/plan.txt", []byte("plan"))
+	write("stays.txt", []byte("stays"))
+	write("Desktop/report.lnk", buildLnk(filepath.Join(dir, "Inbox", "report.pdf"), `..\Inbox\report.pdf`, ""))
+	write("Desktop/other.lnk", buildLnk(filepath.Join(dir, "stays.txt"), `..\stays.txt`, ""))
+	link(filepath.Join(dir, "Inbox", "Project", "plan.txt"), "Links/plan")
+	link("../stays.txt", "Links/stays")
+	link("../stays.txt", "Inbox/up")
+	link("plan.txt", "Inbox/Project/sibling")
+
+	// Execute the moves the plan describes, then look for the shortcuts they broke
+	moves := []FileOperation{
+		{From: filepath.Join(dir, "Inbox", "report.pdf"), To: filepath.Join(dir, "Documents", "report.pdf")},
+		{From: filepath.Join(dir, "Inbox", "Project"), To: filepath.Join(dir, "Projects", "Project")},
+		{From: filepath.Join(dir, "Inbox", "up"), To: filepath.Join(dir, "Links", "Moved", "up")},
+	}
+	for _, op := range moves {
+		if err := os.MkdirAll(filepath.Dir(op.To), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(op.From, op.To); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fixes := FindShortcutFixes(dir, moves)
+	want := map[string]string{
+		filepath.Join(dir, "Desktop", "report.lnk"): filepath.Join(dir, "Documents", "report.pdf"),
+		filepath.Join(dir, "Links", "plan"):         filepath.Join(dir, "Projects", "Project", "plan.txt"),
+		filepath.Join(dir, "Links", "Moved", "up"):  filepath.Join(dir, "stays.txt"),
+	}
+	if len(fixes) != len(want) {
+		t.Fatalf("FindShortcutFixes found %v, want %d shortcuts", fixes, len(want))
+	}
+	for _, fix := range fixes {
+		if want[fix.Shortcut] != fix.NewTarget {
+			t.Errorf("%s → %s, want %s", fix.Shortcut, fix.NewTarget, want[fix.Shortcut])
+		}
+	}
+
+	RewriteShortcuts(fixes)
+	for _, fix := range fixes {
+		if fix.Error != nil {
+			t.Errorf("rewriting %s: %v", fix.Shortcut, fix.Error)
+		}
+	}
+	if content, err := os.ReadFile(filepath.Join(dir, "Links", "Moved", "up")); err != nil || string(content) != "stays" {
+		t.Errorf("relative link after rewrite reads %q, %v", content, err)
+	}
+	if target, _ := os.Readlink(filepath.Join(dir, "Links", "Moved", "up")); filepath.IsAbs(target) {
+		t.Errorf("relative link became absolute: %s", target)
+	}
+	if content, err := os.ReadFile(filepath.Join(dir, "Links", "plan")); err != nil || string(content) != "plan" {
+		t.Errorf("absolute link after rewrite reads %q, %v", content, err)
+	}
+	target, err := lnkTarget(readShortcutFile(filepath.Join(dir, "Desktop", "report.lnk")))
+	if err != nil || target != want[filepath.Join(dir, "Desktop", "report.lnk")] {
+		t.Errorf("shortcut after rewrite points to %q, %v", target, err)
+	}
+	if fixes := FindShortcutFixes(dir, moves); len(fixes) != 0 {
+		t.Errorf("FindShortcutFixes after rewrite = %v, want none", fixes)
+	}
+}
@@ -0,0 +1,196 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrShuttingDown is returned by jobs that stopped, or were refused, because the app is exiting
+var ErrShuttingDown = errors.New("the app is shutting down")
+
+// ErrShutdownTimeout means jobs were still running when shutdown stopped waiting for them
+var ErrShutdownTimeout = errors.New("background jobs did not finish in time")
+
+// DefaultShutdownTimeout bounds how long shutdown waits for jobs to reach a safe point
+const DefaultShutdownTimeout = 30 * time.Second
+
+// Job kinds; executions are the ones worth asking the user about before quitting
+const (
+	JobIndexing  = "indexing"
+	JobExecution = "execution"
+)
+
+// ShutdownCoordinator lets the app exit cleanly: background jobs register while they run, and
+// Shutdown asks each to stop at its next safe point (indexing keeps its checkpoint, executions
+// finish the moves in flight), waits for them, then closes databases and logs in reverse order
+// of registration. A nil coordinator tracks nothing.
+type ShutdownCoordinator struct {
+	logger *Logger
+
+	mu      sync.Mutex
+	jobs    map[int]*BackgroundJob
+	nextID  int
+	closing bool
+	closers []namedCloser
+	idle    *sync.Cond
+
+	once sync.Once
+	err  error
+}
+
+// BackgroundJob is a running job registered with a ShutdownCoordinator
+type BackgroundJob struct {
+	Name string
+	Kind string
+
+	id   int
+	stop func()
+	c    *ShutdownCoordinator
+}
+
+type namedCloser struct {
+	name  string
+	close func() error
+}
+
+func NewShutdownCoordinator(logger *Logger) *ShutdownCoordinator {
+	c := &ShutdownCoordinator{logger: logger, jobs: make(map[int]*BackgroundJob)}
+	c.idle = sync.NewCond(&c.mu)
+	return c
+}
+
+// Begin registers a job; stop, which may be nil, asks it to wind down. Jobs can't start once
+// shutdown has begun.
+func (c *ShutdownCoordinator) Begin(name, kind string, stop func()) (*BackgroundJob, error) {
+	if c == nil {
+		return nil, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closing {
+		return nil, fmt.Errorf("%w: %s not started", ErrShuttingDown, name)
+	}
+	c.nextID++
+	job := &BackgroundJob{Name: name, Kind: kind, id: c.nextID, stop: stop, c: c}
+	c.jobs[job.id] = job
+	return job, nil
+}
+
+// Done unregisters the job
+func (j *BackgroundJob) Done() {
+	if j == nil {
+		return
+	}
+	j.c.mu.Lock()
+	defer j.c.mu.Unlock()
+	delete(j.c.jobs, j.id)
+	if len(j.c.jobs) == 0 {
+		j.c.idle.Broadcast()
+	}
+}
+
+// ShuttingDown reports whether jobs should stop at their next safe point
+func (c *ShutdownCoordinator) ShuttingDown() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closing
+}
+
+// Running returns the names of running jobs of kind, or of every kind for ""
+func (c *ShutdownCoordinator) Running(kind string) []string {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var names []string
+	for _, job := range c.jobs {
+		if kind == "" || job.Kind == kind {
+			names = append(names, job.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// OnShutdown registers fn to run after the jobs have stopped; later registrations run first
+func (c *ShutdownCoordinator) OnShutdown(name string, fn func() error) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closers = append(c.closers, namedCloser{name: name, close: fn})
+}
+
+// Shutdown stops the jobs, waits up to timeout for them, and runs the OnShutdown functions.
+// Only the first call does anything; later calls wait for it and return its result.
+func (c *ShutdownCoordinator) Shutdown(timeout time.Duration) error {
+	if c == nil {
+		return nil
+	}
+	c.once.Do(func() { c.err = c.shutdown(timeout) })
+	return c.err
+}
+
+func (c *ShutdownCoordinator) shutdown(timeout time.Duration) error {
+	c.mu.Lock()
+	c.closing = true
+	jobs := make([]*BackgroundJob, 0, len(c.jobs))
+	for _, job := range c.jobs {
+		jobs = append(jobs, job)
+	}
+	c.mu.Unlock()
+
+	for _, job := range jobs {
+		c.logger.Info("Stopping %s", job.Name)
+		if job.stop != nil {
+			job.stop()
+		}
+	}
+
+	var errs []error
+	if still := c.wait(timeout); len(still) > 0 {
+		// Closing the databases under them fails their remaining writes, which is better than hanging
+		c.logger.Error("Still running after %v: %s", timeout, strings.Join(still, ", "))
+		errs = append(errs, fmt.Errorf("%w: %s", ErrShutdownTimeout, strings.Join(still, ", ")))
+	}
+
+	c.mu.Lock()
+	closers := c.closers
+	c.mu.Unlock()
+	for i := len(closers) - 1; i >= 0; i-- {
+		if err := closers[i].close(); err != nil {
+			c.logger.Error("Failed to close %s: %v", closers[i].name, err)
+			errs = append(errs, fmt.Errorf("failed to close %s: %w", closers[i].name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// wait blocks until no job is running or timeout passes, and returns the jobs still running
+func (c *ShutdownCoordinator) wait(timeout time.Duration) []string {
+	drained := make(chan struct{})
+	go func() {
+		c.mu.Lock()
+		for len(c.jobs) > 0 {
+			c.idle.Wait()
+		}
+		c.mu.Unlock()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-time.After(timeout):
+		return c.Running("")
+	}
+}
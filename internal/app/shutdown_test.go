@@ -0,0 +1,118 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShutdownStopsJobsThenCloses(t *testing.T) {
+	c := NewShutdownCoordinator(NewLogger(false))
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+	c.OnShutdown("database", func() error { record("close database"); return nil })
+	c.OnShutdown("watcher", func() error { record("close watcher"); return nil })
+
+	control := NewExecutionControl()
+	job, err := c.Begin("execution", JobExecution, control.Stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Running(JobExecution); len(got) != 1 || len(c.Running(JobIndexing)) != 0 {
+		t.Errorf("Running() = %v", got)
+	}
+	go func() {
+		// The job finishes its current step once asked to stop
+		for control.proceed() {
+			time.Sleep(time.Millisecond)
+		}
+		record("job stopped")
+		job.Done()
+	}()
+
+	if err := c.Shutdown(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(events, ", "); got != "job stopped, close watcher, close database" {
+		t.Errorf("shutdown went %s", got)
+	}
+	if !c.ShuttingDown() {
+		t.Error("ShuttingDown() = false after Shutdown")
+	}
+	if _, err := c.Begin("late", JobIndexing, nil); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("Begin() after shutdown = %v, want ErrShuttingDown", err)
+	}
+	// Later calls don't close anything twice
+	if err := c.Shutdown(time.Second); err != nil || len(events) != 3 {
+		t.Errorf("second Shutdown() = %v, events %v", err, events)
+	}
+}
+
+func TestShutdownGivesUpOnStuckJobs(t *testing.T) {
+	c := NewShutdownCoordinator(NewLogger(false))
+	closed := false
+	c.OnShutdown("database", func() error { closed = true; return nil })
+	if _, err := c.Begin("stuck", JobIndexing, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Shutdown(10 * time.Millisecond); !errors.Is(err, ErrShutdownTimeout) {
+		t.Errorf("Shutdown() = %v, want ErrShutdownTimeout", err)
+	}
+	if !closed {
+		t.Error("closers didn't run after the timeout")
+	}
+}
+
+func TestIndexDirectoryStopsOnShutdown(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	logger := NewLogger(false)
+	indexService := NewIndexService(logger)
+	if err := indexService.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer indexService.Close()
+
+	shutdown := NewShutdownCoordinator(logger)
+	ido := NewIndexDirectoryOrchestrator(indexService, &scriptedAnalyzer{}, logger)
+	ido.SetShutdown(shutdown)
+	stopped := make(chan error, 1)
+	err := ido.IndexDirectory(context.Background(), dir, 1, func(current, total int, fileName string) {
+		if current == 2 {
+			// Shutdown waits for this run, so it has to be started elsewhere
+			go func() { stopped <- shutdown.Shutdown(time.Second) }()
+			for !shutdown.ShuttingDown() {
+				time.Sleep(time.Millisecond)
+			}
+		}
+	})
+	if !errors.Is(err, ErrShuttingDown) {
+		t.Fatalf("IndexDirectory() = %v, want ErrShuttingDown", err)
+	}
+	if err := <-stopped; err != nil {
+		t.Errorf("Shutdown() = %v", err)
+	}
+
+	// The file in progress is finished and the rest is left for next time
+	checkpoint, err := indexService.LoadIndexCheckpoint(dir)
+	if err != nil || checkpoint == nil {
+		t.Fatalf("expected a checkpoint, got %v (%v)", checkpoint, err)
+	}
+	if filepath.Base(checkpoint.LastPath) != "b.txt" {
+		t.Errorf("checkpoint after %s, want b.txt", checkpoint.LastPath)
+	}
+}
@@ -0,0 +1,158 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// Formats for metadata sidecars written next to indexed files
+	SidecarFormatNone = ""
+	SidecarFormatJSON = "json"
+	SidecarFormatXMP  = "xmp"
+
+	sidecarJSONSuffix = ".vaf.json"
+	sidecarXMPSuffix  = ".xmp" // photo.jpg.xmp, as darktable and digiKam name them
+	sidecarGenerator  = "VibesAndFolders"
+)
+
+// SidecarMetadata is the content of a .vaf.json sidecar
+type SidecarMetadata struct {
+	Description string    `json:"description"`
+	FileType    string    `json:"file_type"`
+	Tags        []string  `json:"tags,omitempty"`
+	IndexedAt   time.Time `json:"indexed_at"`
+	Generator   string    `json:"generator"`
+}
+
+// isSidecarFile reports whether path is metadata belonging to another file (or, for
+// desktop.ini, to its folder) rather than a file of its own; such files are left out of scans
+// and listings.
+func isSidecarFile(path string) bool {
+	if strings.HasSuffix(strings.ToLower(path), sidecarJSONSuffix) || strings.EqualFold(filepath.Base(path), folderIconFile) {
+		return true
+	}
+	if len(path) > len(sidecarXMPSuffix) && strings.EqualFold(path[len(path)-len(sidecarXMPSuffix):], sidecarXMPSuffix) {
+		_, err := os.Lstat(path[:len(path)-len(sidecarXMPSuffix)])
+		return err == nil
+	}
+	return false
+}
+
+// isOwnXMP reports whether XMP sidecar content was written by this app rather than another tool
+func isOwnXMP(data []byte) bool {
+	return bytes.Contains(data, []byte(`xmp:CreatorTool="`+sidecarGenerator+`"`))
+}
+
+// moveSidecars carries any sidecars of from over to to after the file itself was moved
+func moveSidecars(from, to string, logger *Logger) {
+	for _, suffix := range []string{sidecarJSONSuffix, sidecarXMPSuffix} {
+		if _, err := os.Lstat(from + suffix); err != nil {
+			continue
+		}
+		if _, err := os.Lstat(to + suffix); err == nil {
+			logger.Info("Not moving sidecar %s: %s already exists", from+suffix, to+suffix)
+			continue
+		}
+		if err := os.Rename(from+suffix, to+suffix); err != nil {
+			logger.Error("Failed to move sidecar %s: %v", from+suffix, err)
+		}
+	}
+}
+
+// SidecarWriter stores index descriptions next to the files they describe,
+// so they survive outside the index database and other tools can read them.
+type SidecarWriter struct {
+	config *Config
+	logger *Logger
+	now    func() time.Time
+}
+
+func NewSidecarWriter(config *Config, logger *Logger) *SidecarWriter {
+	return &SidecarWriter{
+		config: config,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Write stores description in the configured sidecar format; it does nothing when sidecars are off
+func (sw *SidecarWriter) Write(filePath, description, fileType string) error {
+	if sw == nil || IsObjectStoragePath(filePath) || isSidecarFile(filePath) {
+		return nil
+	}
+
+	switch sw.config.SidecarFormat {
+	case SidecarFormatJSON:
+		return sw.writeJSON(filePath, description, fileType)
+	case SidecarFormatXMP:
+		return sw.writeXMP(filePath, description, fileType)
+	default:
+		return nil
+	}
+}
+
+func (sw *SidecarWriter) writeJSON(filePath, description, fileType string) error {
+	metadata := SidecarMetadata{
+		Description: description,
+		FileType:    fileType,
+		Tags:        []string{fileType},
+		IndexedAt:   sw.now().UTC(),
+		Generator:   sidecarGenerator,
+	}
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath+sidecarJSONSuffix, append(data, '\n'), 0644)
+}
+
+func (sw *SidecarWriter) writeXMP(filePath, description, fileType string) error {
+	sidecarPath := filePath + sidecarXMPSuffix
+
+	// Never overwrite metadata another tool keeps for this file
+	if existing, err := os.ReadFile(sidecarPath); err == nil && !isOwnXMP(existing) {
+		sw.logger.Debug("Keeping existing XMP sidecar %s", sidecarPath)
+		return nil
+	}
+
+	var escaped, escapedType bytes.Buffer
+	if err := xml.EscapeText(&escaped, []byte(description)); err != nil {
+		return err
+	}
+	if err := xml.EscapeText(&escapedType, []byte(fileType)); err != nil {
+		return err
+	}
+
+	content := fmt.Sprintf(`<?xpacket begin="%s" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about=""
+    xmlns:dc="http://purl.org/dc/elements/1.1/"
+    xmlns:xmp="http://ns.adobe.com/xap/1.0/"
+    xmp:CreatorTool="%s"
+    xmp:MetadataDate="%s">
+   <dc:description>
+    <rdf:Alt>
+     <rdf:li xml:lang="x-default">%s</rdf:li>
+    </rdf:Alt>
+   </dc:description>
+   <dc:subject>
+    <rdf:Bag>
+     <rdf:li>%s</rdf:li>
+    </rdf:Bag>
+   </dc:subject>
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>
+`, "\ufeff", sidecarGenerator, sw.now().UTC().Format(time.RFC3339), escaped.String(), escapedType.String())
+
+	return os.WriteFile(sidecarPath, []byte(content), 0644)
+}
@@ -0,0 +1,152 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSidecarWriter(t *testing.T) {
+	indexedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		format      string
+		existingXMP string
+		wantFile    string
+		wantContain []string
+		wantMissing bool
+	}{
+		{
+			name:        "off",
+			format:      SidecarFormatNone,
+			wantFile:    "photo.jpg.vaf.json",
+			wantMissing: true,
+		},
+		{
+			name:        "json",
+			format:      SidecarFormatJSON,
+			wantFile:    "photo.jpg.vaf.json",
+			wantContain: []string{`"description": "Beach at sunset \u0026 friends"`, `"file_type": "image"`, `"indexed_at": "2025-03-01T12:00:00Z"`},
+		},
+		{
+			name:        "xmp",
+			format:      SidecarFormatXMP,
+			wantFile:    "photo.jpg.xmp",
+			wantContain: []string{`<rdf:li xml:lang="x-default">Beach at sunset &amp; friends</rdf:li>`, `<rdf:li>image</rdf:li>`, `xmp:CreatorTool="VibesAndFolders"`},
+		},
+		{
+			name:        "xmp replaces its own sidecar",
+			format:      SidecarFormatXMP,
+			existingXMP: `<x:xmpmeta xmp:CreatorTool="VibesAndFolders">old</x:xmpmeta>`,
+			wantFile:    "photo.jpg.xmp",
+			wantContain: []string{"Beach at sunset"},
+		},
+		{
+			name:        "xmp keeps another tool's sidecar",
+			format:      SidecarFormatXMP,
+			existingXMP: `<x:xmpmeta xmp:CreatorTool="darktable">ratings</x:xmpmeta>`,
+			wantFile:    "photo.jpg.xmp",
+			wantContain: []string{"darktable"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filePath := filepath.Join(dir, "photo.jpg")
+			if err := os.WriteFile(filePath, []byte("jpeg"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.existingXMP != "" {
+				if err := os.WriteFile(filePath+".xmp", []byte(tt.existingXMP), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			writer := NewSidecarWriter(&Config{SidecarFormat: tt.format}, NewLogger(false))
+			writer.now = func() time.Time { return indexedAt }
+			if err := writer.Write(filePath, "Beach at sunset & friends", "image"); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, tt.wantFile))
+			if tt.wantMissing {
+				if err == nil {
+					t.Fatalf("expected no sidecar, found %s", tt.wantFile)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sidecar not written: %v", err)
+			}
+			for _, want := range tt.wantContain {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("sidecar missing %q:\n%s", want, data)
+				}
+			}
+			if tt.format == SidecarFormatJSON {
+				var metadata SidecarMetadata
+				if err := json.Unmarshal(data, &metadata); err != nil {
+					t.Errorf("sidecar is not valid JSON: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestIsSidecarFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "photo.jpg"), []byte("jpeg"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"photo.jpg", false},
+		{"photo.jpg.vaf.json", true},
+		{"notes.txt.VAF.JSON", true},
+		{"photo.jpg.xmp", true},
+		{"photo.xmp", false}, // No file named "photo" next to it
+		{"settings.json", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSidecarFile(filepath.Join(dir, tt.name)); got != tt.want {
+				t.Errorf("isSidecarFile(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoveFileCarriesSidecars(t *testing.T) {
+	dir := t.TempDir()
+	from := filepath.Join(dir, "photo.jpg")
+	to := filepath.Join(dir, "trips", "beach.jpg")
+	for _, path := range []string{from, from + ".vaf.json", from + ".xmp"} {
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fs := NewFileService(NewValidator(), NewLogger(false))
+	result := fs.ExecuteOperation(FileOperation{From: from, To: to})
+	if !result.Success {
+		t.Fatalf("ExecuteOperation() failed: %v", result.Error)
+	}
+
+	for _, suffix := range []string{".vaf.json", ".xmp"} {
+		if _, err := os.Stat(to + suffix); err != nil {
+			t.Errorf("sidecar %s was not moved: %v", suffix, err)
+		}
+		if _, err := os.Stat(from + suffix); err == nil {
+			t.Errorf("sidecar %s left behind", suffix)
+		}
+	}
+}
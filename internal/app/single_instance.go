@@ -0,0 +1,175 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrInstanceRunning means another instance holds the index; the launch request was handed to it
+	ErrInstanceRunning = errors.New("another instance is already running")
+	// ErrInstanceNotResponding means another instance holds the index but the request couldn't be handed over
+	ErrInstanceNotResponding = errors.New("another instance is running but not responding")
+)
+
+const (
+	// instanceDialTimeout bounds how long a launch waits for the running instance to answer
+	instanceDialTimeout = 2 * time.Second
+
+	windowsConnRefused syscall.Errno = 10061 // WSAECONNREFUSED
+)
+
+// InstanceRequest is what a later launch asks the running instance to do
+type InstanceRequest struct {
+	Directory string `json:"directory,omitempty"` // Folder to open; empty just brings the window to front
+}
+
+// InstanceServer is held by the one running instance. It listens on a local socket next to the
+// index, so a second launch can't open the database alongside it and forwards its request instead.
+// Unix domain sockets also work on Windows 10 and later.
+type InstanceServer struct {
+	listener net.Listener
+	path     string
+	logger   *Logger
+
+	mu      sync.Mutex
+	handler func(InstanceRequest)
+	pending []InstanceRequest // Arrived before a handler was set
+	wg      sync.WaitGroup
+}
+
+// ClaimInstance makes this process the running instance for socketPath. If another process
+// already is, request is forwarded to it and ErrInstanceRunning is returned, or
+// ErrInstanceNotResponding when it is busy or answers unexpectedly. A socket left behind by an
+// instance that crashed is taken over.
+func ClaimInstance(socketPath string, request InstanceRequest, logger *Logger) (*InstanceServer, error) {
+	if err := os.MkdirAll(filepath.Dir(socketPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create instance socket directory: %w", err)
+	}
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		forwardErr := forwardInstanceRequest(socketPath, request)
+		if forwardErr == nil {
+			return nil, ErrInstanceRunning
+		}
+		if !isStaleSocket(forwardErr) {
+			return nil, fmt.Errorf("%w: %v", ErrInstanceNotResponding, forwardErr)
+		}
+		// Nobody listens: the socket is stale
+		if removeErr := os.Remove(socketPath); removeErr != nil && !os.IsNotExist(removeErr) {
+			return nil, fmt.Errorf("failed to remove stale instance socket: %w", removeErr)
+		}
+		listener, err = net.Listen("unix", socketPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen for other instances: %w", err)
+		}
+	}
+
+	s := &InstanceServer{listener: listener, path: socketPath, logger: logger}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// isStaleSocket reports whether dialing the socket failed because no process listens on it anymore
+func isStaleSocket(err error) bool {
+	var errno syscall.Errno
+	if runtime.GOOS == "windows" && errors.As(err, &errno) && errno == windowsConnRefused {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ENOENT)
+}
+
+func forwardInstanceRequest(socketPath string, request InstanceRequest) error {
+	conn, err := net.DialTimeout("unix", socketPath, instanceDialTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(instanceDialTimeout))
+
+	if err := json.NewEncoder(conn).Encode(request); err != nil {
+		return err
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if reply != "ok\n" {
+		return fmt.Errorf("unexpected reply from running instance: %q", reply)
+	}
+	return nil
+}
+
+// SetHandler receives requests from later launches, including any that arrived before it was set.
+// It is called from a background goroutine, one per request.
+func (s *InstanceServer) SetHandler(handler func(InstanceRequest)) {
+	s.mu.Lock()
+	s.handler = handler
+	pending := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+	for _, request := range pending {
+		handler(request)
+	}
+}
+
+func (s *InstanceServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.logger.Error("Instance socket failed: %v", err)
+			}
+			return
+		}
+		s.handle(conn)
+	}
+}
+
+func (s *InstanceServer) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(instanceDialTimeout))
+
+	var request InstanceRequest
+	if err := json.NewDecoder(conn).Decode(&request); err != nil {
+		s.logger.Error("Ignoring malformed request from another instance: %v", err)
+		return
+	}
+	if _, err := conn.Write([]byte("ok\n")); err != nil {
+		s.logger.Debug("Failed to answer another instance: %v", err)
+	}
+	s.logger.Info("Another launch handed over %q", request.Directory)
+
+	s.mu.Lock()
+	handler := s.handler
+	if handler == nil {
+		s.pending = append(s.pending, request)
+	}
+	s.mu.Unlock()
+	// A slow handler must not keep the next launch waiting for its answer
+	if handler != nil {
+		go handler(request)
+	}
+}
+
+// Close stops listening and removes the socket, so the next launch becomes the running instance
+func (s *InstanceServer) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	// Closing a unix listener normally unlinks the socket already
+	if removeErr := os.Remove(s.path); removeErr != nil && !os.IsNotExist(removeErr) && err == nil {
+		err = removeErr
+	}
+	return err
+}
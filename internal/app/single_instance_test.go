@@ -0,0 +1,119 @@
+package app
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClaimInstanceHandsOverToRunningInstance(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "instance.sock")
+	logger := NewLogger(false)
+
+	first, err := ClaimInstance(socket, InstanceRequest{}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+
+	// A launch before the window is ready is delivered once the handler is set
+	if _, err := ClaimInstance(socket, InstanceRequest{Directory: "/srv/early"}, logger); !errors.Is(err, ErrInstanceRunning) {
+		t.Fatalf("second ClaimInstance() = %v, want ErrInstanceRunning", err)
+	}
+	received := make(chan InstanceRequest, 2)
+	first.SetHandler(func(request InstanceRequest) { received <- request })
+	if _, err := ClaimInstance(socket, InstanceRequest{Directory: "/srv/late"}, logger); !errors.Is(err, ErrInstanceRunning) {
+		t.Fatalf("third ClaimInstance() = %v, want ErrInstanceRunning", err)
+	}
+
+	for _, want := range []string{"/srv/early", "/srv/late"} {
+		select {
+		case request := <-received:
+			if request.Directory != want {
+				t.Errorf("received %q, want %q", request.Directory, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("request for %s never arrived", want)
+		}
+	}
+}
+
+func TestClaimInstanceTakesOverStaleSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "instance.sock")
+	logger := NewLogger(false)
+
+	// An instance that crashed leaves its socket behind
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: socket, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener.SetUnlinkOnClose(false)
+	listener.Close()
+
+	instance, err := ClaimInstance(socket, InstanceRequest{}, logger)
+	if err != nil {
+		t.Fatalf("ClaimInstance() over a stale socket = %v", err)
+	}
+	if err := instance.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once closed, the next launch becomes the running instance
+	next, err := ClaimInstance(socket, InstanceRequest{}, logger)
+	if err != nil {
+		t.Fatalf("ClaimInstance() after Close = %v", err)
+	}
+	next.Close()
+}
+
+func TestClaimInstanceKeepsBusyInstance(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "instance.sock")
+	logger := NewLogger(false)
+
+	// A running instance that accepts but doesn't answer in time
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(io.Discard, conn) // Until the launch gives up
+	}()
+
+	if _, err := ClaimInstance(socket, InstanceRequest{}, logger); !errors.Is(err, ErrInstanceNotResponding) {
+		t.Fatalf("ClaimInstance() = %v, want ErrInstanceNotResponding", err)
+	}
+	if _, err := os.Stat(socket); err != nil {
+		t.Errorf("the running instance's socket was removed: %v", err)
+	}
+}
+
+func TestInstanceServerSlowHandler(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "instance.sock")
+	logger := NewLogger(false)
+
+	first, err := ClaimInstance(socket, InstanceRequest{}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	release := make(chan struct{})
+	defer close(release)
+	first.SetHandler(func(InstanceRequest) { <-release })
+
+	// Both launches are answered while the handler is still busy with the first
+	for _, dir := range []string{"/srv/a", "/srv/b"} {
+		if _, err := ClaimInstance(socket, InstanceRequest{Directory: dir}, logger); !errors.Is(err, ErrInstanceRunning) {
+			t.Fatalf("ClaimInstance(%s) = %v, want ErrInstanceRunning", dir, err)
+		}
+	}
+}
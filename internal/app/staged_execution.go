@@ -0,0 +1,189 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// stagingDirPrefix names the hidden folder staged executions park files in
+const stagingDirPrefix = ".vaf-staging-"
+
+var ErrStagedRollback = errors.New("not applied: another operation in the staged batch failed")
+
+// executeStaged gives a batch all-or-nothing semantics: every source is first moved into a hidden
+// staging folder under basePath (a rename on the same filesystem), and files only go to their
+// destinations once all of them were staged. If any step fails, everything moved so far is put
+// back where it was. Staged batches run sequentially and don't pause for offline targets. Stopping
+// a staged batch puts everything back as well, leaving all of it to be resumed.
+func (fs *DefaultFileService) executeStaged(result *ExecutionResult, operations []FileOperation, basePath string, control *ExecutionControl, beat heartbeat) {
+	fs.logger.Info("Executing %d operations through a staging folder", len(operations))
+
+	if failed, err := fs.validateStagedBatch(operations); err != nil {
+		fs.failStaged(result, operations, failed, err)
+		return
+	}
+
+	stagingDir := filepath.Join(basePath, stagingDirPrefix+strconv.FormatInt(time.Now().UnixNano(), 10))
+	if err := os.Mkdir(stagingDir, 0755); err != nil {
+		fs.failStaged(result, operations, -1, fmt.Errorf("failed to create staging folder: %w", err))
+		return
+	}
+
+	// A manifest lets the user put files back by hand if the app dies mid-batch
+	if manifest, err := json.MarshalIndent(operations, "", "  "); err == nil {
+		if err := os.WriteFile(filepath.Join(stagingDir, "manifest.json"), manifest, 0644); err != nil {
+			fs.logger.Error("Failed to write staging manifest: %v", err)
+		}
+	}
+
+	staged := make([]OperationResult, 0, len(operations))
+	stagedPath := func(i int) string {
+		return filepath.Join(stagingDir, strconv.Itoa(i), filepath.Base(operations[i].From))
+	}
+
+	// Phase 1: move every source into the staging folder
+	for i, op := range operations {
+		if !control.proceed() {
+			fs.unstage(operations, staged, nil, stagedPath)
+			fs.finishStaging(result, stagingDir)
+			fs.failStaged(result, operations, -1, ErrExecutionStopped)
+			result.Stopped = true
+			return
+		}
+		stageOp := FileOperation{From: op.From, To: stagedPath(i)}
+		opResult := beat.run(fs.logger, stageOp, func() OperationResult { return fs.ExecuteOperation(stageOp) })
+		if !opResult.Success {
+			fs.logger.Error("Staging %s failed: %v", op.From, opResult.Error)
+			fs.unstage(operations, staged, nil, stagedPath)
+			fs.finishStaging(result, stagingDir)
+			fs.failStaged(result, operations, i, opResult.Error)
+			return
+		}
+		staged = append(staged, opResult)
+	}
+
+	// Phase 2: move staged files to their destinations
+	committed := make([]OperationResult, 0, len(operations))
+	for i, op := range operations {
+		if !control.proceed() {
+			fs.unstage(operations, staged, committed, stagedPath)
+			fs.finishStaging(result, stagingDir)
+			fs.failStaged(result, operations, -1, ErrExecutionStopped)
+			result.Stopped = true
+			return
+		}
+		commitOp := FileOperation{From: stagedPath(i), To: op.To}
+		opResult := beat.run(fs.logger, commitOp, func() OperationResult { return fs.ExecuteOperation(commitOp) })
+		if !opResult.Success {
+			fs.logger.Error("Committing %s failed: %v", op.To, opResult.Error)
+			fs.unstage(operations, staged, committed, stagedPath)
+			fs.finishStaging(result, stagingDir)
+			fs.failStaged(result, operations, i, opResult.Error)
+			return
+		}
+		committed = append(committed, opResult)
+	}
+
+	for i, op := range operations {
+		fs.recordResult(result, OperationResult{
+			Operation:     op,
+			Success:       true,
+			SymlinkTarget: staged[i].SymlinkTarget,
+			CreatedDirs:   committed[i].CreatedDirs,
+		})
+	}
+	fs.finishStaging(result, stagingDir)
+}
+
+// validateStagedBatch checks the whole batch up front so an obviously failing batch moves nothing.
+// A destination may exist if another operation in the batch moves it away (e.g. swapping names).
+func (fs *DefaultFileService) validateStagedBatch(operations []FileOperation) (int, error) {
+	sources := make(map[string]bool, len(operations))
+	destinations := make(map[string]bool, len(operations))
+	for _, op := range operations {
+		sources[filepath.Clean(op.From)] = true
+	}
+
+	for i, op := range operations {
+		if _, err := os.Lstat(op.From); err != nil {
+			return i, ErrSourceNotExist
+		}
+		to := filepath.Clean(op.To)
+		if destinations[to] {
+			return i, fmt.Errorf("%w: two operations move files to %s", ErrDestinationExists, op.To)
+		}
+		destinations[to] = true
+		if _, err := os.Lstat(op.To); err == nil && !sources[to] {
+			return i, ErrDestinationExists
+		}
+	}
+	return -1, nil
+}
+
+// unstage puts files back after a failed batch: committed files return from their destinations
+// and staged ones from the staging folder, in reverse order
+func (fs *DefaultFileService) unstage(operations []FileOperation, staged, committed []OperationResult, stagedPath func(int) string) {
+	for i := len(committed) - 1; i >= 0; i-- {
+		if opResult := fs.ExecuteOperation(FileOperation{From: operations[i].To, To: stagedPath(i)}); !opResult.Success {
+			fs.logger.Error("Failed to take back %s: %v", operations[i].To, opResult.Error)
+			continue
+		}
+		fs.removeCreatedDirs(committed[i].CreatedDirs)
+	}
+	for i := len(staged) - 1; i >= 0; i-- {
+		if opResult := fs.ExecuteOperation(FileOperation{From: stagedPath(i), To: operations[i].From}); !opResult.Success {
+			fs.logger.Error("Failed to restore %s: %v", operations[i].From, opResult.Error)
+		}
+	}
+}
+
+// removeCreatedDirs removes directories created for a destination if they are empty again
+func (fs *DefaultFileService) removeCreatedDirs(dirs []string) {
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := RemoveCreatedFolder(dirs[i]); err != nil {
+			fs.logger.Debug("Keeping directory %s: %v", dirs[i], err)
+		}
+	}
+}
+
+// finishStaging removes the staging folder once it holds nothing but the manifest. Anything still
+// inside means files could not be restored, which is reported instead of deleted.
+func (fs *DefaultFileService) finishStaging(result *ExecutionResult, stagingDir string) {
+	entries, err := os.ReadDir(stagingDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		slot := filepath.Join(stagingDir, entry.Name())
+		if err := os.Remove(slot); err != nil {
+			result.VerificationError = fmt.Errorf("some files could not be restored and are still in %s (see manifest.json)", stagingDir)
+			fs.logger.Error("%v", result.VerificationError)
+			return
+		}
+	}
+	os.Remove(filepath.Join(stagingDir, "manifest.json"))
+	if err := os.Remove(stagingDir); err != nil {
+		fs.logger.Error("Failed to remove staging folder %s: %v", stagingDir, err)
+	}
+}
+
+// failStaged reports every operation of a batch that was rolled back; the one at failedIndex
+// (if any) carries the error that caused it
+func (fs *DefaultFileService) failStaged(result *ExecutionResult, operations []FileOperation, failedIndex int, cause error) {
+	for i, op := range operations {
+		opErr := ErrStagedRollback
+		if i == failedIndex || failedIndex < 0 {
+			opErr = cause
+		}
+		fs.recordResult(result, OperationResult{Operation: op, Error: opErr})
+	}
+	fs.logger.Info("Staged execution rolled back: %v", cause)
+}
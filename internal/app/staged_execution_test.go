@@ -0,0 +1,103 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecuteStaged(t *testing.T) {
+	tests := []struct {
+		name        string
+		operations  func(dir string) []FileOperation
+		wantSuccess int
+		wantFiles   map[string]string // Relative path -> content after execution
+	}{
+		{
+			name: "all operations succeed, including a swap",
+			operations: func(dir string) []FileOperation {
+				return []FileOperation{
+					{From: filepath.Join(dir, "a.txt"), To: filepath.Join(dir, "b.txt")},
+					{From: filepath.Join(dir, "b.txt"), To: filepath.Join(dir, "a.txt")},
+					{From: filepath.Join(dir, "notes.txt"), To: filepath.Join(dir, "docs", "notes.txt")},
+				}
+			},
+			wantSuccess: 3,
+			wantFiles:   map[string]string{"a.txt": "b", "b.txt": "a", "docs/notes.txt": "notes"},
+		},
+		{
+			name: "a failing destination rolls everything back",
+			operations: func(dir string) []FileOperation {
+				return []FileOperation{
+					{From: filepath.Join(dir, "a.txt"), To: filepath.Join(dir, "docs", "a.txt")},
+					// notes.txt is a file, so nothing can be created inside it
+					{From: filepath.Join(dir, "b.txt"), To: filepath.Join(dir, "notes.txt", "b.txt")},
+				}
+			},
+			wantFiles: map[string]string{"a.txt": "a", "b.txt": "b", "notes.txt": "notes"},
+		},
+		{
+			name: "a missing source moves nothing",
+			operations: func(dir string) []FileOperation {
+				return []FileOperation{
+					{From: filepath.Join(dir, "a.txt"), To: filepath.Join(dir, "docs", "a.txt")},
+					{From: filepath.Join(dir, "missing.txt"), To: filepath.Join(dir, "docs", "missing.txt")},
+				}
+			},
+			wantFiles: map[string]string{"a.txt": "a", "b.txt": "b", "notes.txt": "notes"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range map[string]string{"a.txt": "a", "b.txt": "b", "notes.txt": "notes"} {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			fs := NewFileService(NewValidator(), NewLogger(false))
+			result, err := fs.ExecuteOperations(tt.operations(dir), dir, ExecutionOptions{Staged: true})
+			if err != nil {
+				t.Fatalf("ExecuteOperations() error = %v", err)
+			}
+			if result.SuccessCount != tt.wantSuccess {
+				t.Errorf("SuccessCount = %d, want %d", result.SuccessCount, tt.wantSuccess)
+			}
+			if tt.wantSuccess == 0 {
+				rolledBack := 0
+				for _, opResult := range result.Operations {
+					if errors.Is(opResult.Error, ErrStagedRollback) {
+						rolledBack++
+					}
+				}
+				if rolledBack != len(result.Operations)-1 {
+					t.Errorf("expected all but the failing operation to report ErrStagedRollback, got %d of %d", rolledBack, len(result.Operations))
+				}
+			}
+			if result.InitialFileCount != result.FinalFileCount {
+				t.Errorf("file count changed from %d to %d", result.InitialFileCount, result.FinalFileCount)
+			}
+
+			for name, want := range tt.wantFiles {
+				data, err := os.ReadFile(filepath.Join(dir, name))
+				if err != nil || string(data) != want {
+					t.Errorf("%s = %q (%v), want %q", name, data, err, want)
+				}
+			}
+
+			entries, _ := os.ReadDir(dir)
+			for _, entry := range entries {
+				if strings.HasPrefix(entry.Name(), stagingDirPrefix) {
+					t.Errorf("staging folder %s was left behind", entry.Name())
+				}
+				if entry.Name() == "docs" && tt.wantSuccess == 0 {
+					t.Errorf("directory created for a rolled back batch was left behind")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,379 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// StorageEntry describes a single object (or implicit folder) in a storage backend
+type StorageEntry struct {
+	Path    string // Full path including scheme, e.g. s3://bucket/photos/a.jpg
+	IsDir   bool
+	Size    int64
+	ModTime time.Time
+}
+
+// StorageBackend abstracts a flat object store so FileService logic can run against it
+type StorageBackend interface {
+	// Scheme returns the URI scheme handled by this backend (e.g. "s3")
+	Scheme() string
+	// List returns every object under prefix, recursively
+	List(prefix string) ([]StorageEntry, error)
+	// Exists reports whether an object exists at exactly this path
+	Exists(objectPath string) (bool, error)
+	// Move relocates a single object (copy + delete for object stores)
+	Move(from, to string) error
+}
+
+// splitScheme splits "s3://bucket/key" into ("s3", "bucket/key")
+func splitScheme(p string) (string, string, bool) {
+	idx := strings.Index(p, "://")
+	if idx <= 0 {
+		return "", "", false
+	}
+	return p[:idx], p[idx+3:], true
+}
+
+// IsObjectStoragePath reports whether p refers to an object store rather than the local filesystem
+func IsObjectStoragePath(p string) bool {
+	_, _, ok := splitScheme(p)
+	return ok
+}
+
+// ErrOutsideStorageBase is returned when a path would leave the bucket or prefix it was joined onto
+var ErrOutsideStorageBase = errors.New("path leaves the storage location")
+
+// JoinStoragePath joins a relative path onto a base that may be a local directory or an object-storage URI.
+// On object storage the cleaned result must stay under the base's bucket and prefix; local paths outside
+// base are left to the validator, which holds moves out of the scanned folder for confirmation.
+func JoinStoragePath(base, rel string) (string, error) {
+	if scheme, rest, ok := splitScheme(base); ok {
+		root := path.Clean("/" + rest)
+		joined := path.Join(root, filepath.ToSlash(rel))
+		if joined != root && !strings.HasPrefix(joined, strings.TrimSuffix(root, "/")+"/") {
+			return "", fmt.Errorf("%w: %s is outside %s", ErrOutsideStorageBase, rel, base)
+		}
+		return scheme + "://" + strings.TrimPrefix(joined, "/"), nil
+	}
+	return filepath.Clean(filepath.Join(base, rel)), nil
+}
+
+// ObjectStorageFileService implements FileService on top of a StorageBackend.
+// Folders are implicit key prefixes, so creating and cleaning directories is a no-op.
+type ObjectStorageFileService struct {
+	backend       StorageBackend
+	logger        *Logger
+	ignoreMatcher *IgnorePatternMatcher
+}
+
+func NewObjectStorageFileService(backend StorageBackend, logger *Logger) *ObjectStorageFileService {
+	return &ObjectStorageFileService{
+		backend: backend,
+		logger:  logger,
+	}
+}
+
+// SetIgnorePatterns configures the ignore pattern matcher
+func (ofs *ObjectStorageFileService) SetIgnorePatterns(patterns string) {
+	ignoreHidden := ofs.ignoreMatcher.IgnoresHidden()
+	if patterns == "" && !ignoreHidden {
+		ofs.ignoreMatcher = nil
+		return
+	}
+	ofs.ignoreMatcher = NewIgnorePatternMatcher(patterns, ofs.logger)
+	ofs.ignoreMatcher.SetIgnoreHidden(ignoreHidden)
+}
+
+// SetIgnoreHidden skips objects whose key has an element starting with a dot
+func (ofs *ObjectStorageFileService) SetIgnoreHidden(ignore bool) {
+	if ofs.ignoreMatcher == nil {
+		ofs.ignoreMatcher = NewIgnorePatternMatcher("", ofs.logger)
+	}
+	ofs.ignoreMatcher.SetIgnoreHidden(ignore)
+}
+
+// listRelative lists objects under rootPath and returns them keyed by slash-separated relative path,
+// with ignored entries filtered out
+func (ofs *ObjectStorageFileService) listRelative(rootPath string) ([]StorageEntry, []string, error) {
+	root := strings.TrimSuffix(rootPath, "/")
+	entries, err := ofs.backend.List(root + "/")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var kept []StorageEntry
+	var rels []string
+	for _, entry := range entries {
+		rel := strings.TrimPrefix(entry.Path, root+"/")
+		if rel == "" {
+			continue
+		}
+		if ofs.ignoreMatcher != nil && ofs.isIgnored(rel) {
+			continue
+		}
+		kept = append(kept, entry)
+		rels = append(rels, rel)
+	}
+	return kept, rels, nil
+}
+
+// isIgnored checks the object itself and each of its implicit parent folders against the ignore patterns
+func (ofs *ObjectStorageFileService) isIgnored(rel string) bool {
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if ofs.ignoreMatcher.ShouldIgnore(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return ofs.ignoreMatcher.ShouldIgnore(rel, false)
+}
+
+func (ofs *ObjectStorageFileService) CountFiles(rootPath string) (int, error) {
+	entries, _, err := ofs.listRelative(rootPath)
+	if err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+func (ofs *ObjectStorageFileService) GetDirectoryStructure(rootPath string, maxDepth int, onProgress ScanProgressCallback) (string, error) {
+	entries, rels, err := ofs.listRelative(rootPath)
+	if err != nil {
+		return "", err
+	}
+
+	// Listing is a single paginated call, so only the final count is reported
+	progress := newScanProgressReporter(onProgress)
+	progress.files = len(rels)
+	progress.finish()
+
+	// Object stores have no directories, so synthesize them from key prefixes
+	lines := make(map[string]string)
+	for i, rel := range rels {
+		parts := strings.Split(rel, "/")
+		for depth := 1; depth < len(parts); depth++ {
+			if maxDepth > 0 && depth > maxDepth {
+				break
+			}
+			dir := strings.Join(parts[:depth], "/")
+			lines[dir+"/"] = fmt.Sprintf("%s/\n", dir)
+		}
+		if maxDepth > 0 && len(parts) > maxDepth {
+			continue
+		}
+		lines[rel] = fmt.Sprintf("%s (%d bytes)\n", rel, entries[i].Size)
+	}
+
+	keys := make([]string, 0, len(lines))
+	for key := range lines {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	for _, key := range keys {
+		builder.WriteString(lines[key])
+	}
+	return builder.String(), nil
+}
+
+// CleanEmptyDirectories is a no-op: prefixes disappear as soon as their last object is moved
+func (ofs *ObjectStorageFileService) CleanEmptyDirectories(rootPath string) (int, error) {
+	return 0, nil
+}
+
+func (ofs *ObjectStorageFileService) ExecuteOperations(operations []FileOperation, basePath string, opts ExecutionOptions) (ExecutionResult, error) {
+	result := ExecutionResult{
+		Operations: make([]OperationResult, 0, len(operations)),
+	}
+
+	initialCount, err := ofs.CountFiles(basePath)
+	if err != nil {
+		result.VerificationError = fmt.Errorf("integrity check failed for %s: %w", basePath, err)
+		return result, result.VerificationError
+	}
+	result.InitialFileCount = initialCount
+
+	if opts.Staged {
+		ofs.logger.Info("Staged execution is not available for object storage; copying objects one by one")
+	}
+
+	beat := heartbeatFor(opts)
+	for i, op := range operations {
+		if !opts.Control.proceed() {
+			for _, remaining := range operations[i:] {
+				result.Operations = append(result.Operations, OperationResult{Operation: remaining, Error: ErrExecutionStopped})
+				result.FailCount++
+			}
+			result.Stopped = true
+			break
+		}
+		opResult := beat.run(ofs.logger, op, func() OperationResult { return ofs.executeOperation(op) })
+		result.Operations = append(result.Operations, opResult)
+		if opResult.Success {
+			result.SuccessCount++
+		} else {
+			result.FailCount++
+		}
+	}
+
+	finalCount, err := ofs.CountFiles(basePath)
+	if err != nil {
+		result.VerificationError = fmt.Errorf("post-execution count failed for %s: %w", basePath, err)
+	}
+	result.FinalFileCount = finalCount
+
+	return result, nil
+}
+
+// executeOperation moves a single object, or every object under a prefix when the source is a "folder"
+func (ofs *ObjectStorageFileService) executeOperation(op FileOperation) OperationResult {
+	result := OperationResult{Operation: op}
+
+	if exists, err := ofs.backend.Exists(op.To); err != nil {
+		result.Error = err
+		return result
+	} else if exists {
+		result.Error = ErrDestinationExists
+		return result
+	}
+
+	exists, err := ofs.backend.Exists(op.From)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	if exists {
+		if err := ofs.backend.Move(op.From, op.To); err != nil {
+			result.Error = err
+			return result
+		}
+		result.Success = true
+		ofs.logger.Debug("Successfully moved object: %s -> %s", op.From, op.To)
+		return result
+	}
+
+	// No object at that exact key; treat it as a folder prefix
+	children, err := ofs.backend.List(strings.TrimSuffix(op.From, "/") + "/")
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	if len(children) == 0 {
+		result.Error = ErrSourceNotExist
+		return result
+	}
+
+	// Copying onto an existing key overwrites it, so the destination "folder" must not hold any of the names
+	destPrefix := strings.TrimSuffix(op.To, "/") + "/"
+	existing, err := ofs.backend.List(destPrefix)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, entry := range existing {
+		taken[entry.Path] = true
+	}
+	moves := make([]FileOperation, 0, len(children))
+	for _, child := range children {
+		dest := destPrefix + strings.TrimPrefix(child.Path, strings.TrimSuffix(op.From, "/")+"/")
+		if taken[dest] {
+			result.Error = fmt.Errorf("%w: %s", ErrDestinationExists, dest)
+			return result
+		}
+		moves = append(moves, FileOperation{From: child.Path, To: dest})
+	}
+
+	for i, move := range moves {
+		if err := ofs.backend.Move(move.From, move.To); err != nil {
+			result.Error = fmt.Errorf("failed to move %s: %w", move.From, err)
+			if undoErr := ofs.undoMoves(moves[:i]); undoErr != nil {
+				result.Error = fmt.Errorf("%w; %d objects already moved could not be moved back: %v", result.Error, i, undoErr)
+			}
+			return result
+		}
+	}
+
+	result.Success = true
+	ofs.logger.Debug("Successfully moved prefix (%d objects): %s -> %s", len(children), op.From, op.To)
+	return result
+}
+
+// undoMoves moves objects back to where they were, newest first, so a failed prefix move doesn't
+// leave the prefix half-moved
+func (ofs *ObjectStorageFileService) undoMoves(moves []FileOperation) error {
+	var errs []error
+	for i := len(moves) - 1; i >= 0; i-- {
+		if err := ofs.backend.Move(moves[i].To, moves[i].From); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", moves[i].To, err))
+		}
+	}
+	if len(errs) > 0 {
+		ofs.logger.Error("Failed to move back %d objects after a failed prefix move", len(errs))
+	}
+	return errors.Join(errs...)
+}
+
+// RoutingFileService dispatches to a backend-specific FileService based on the path's URI scheme,
+// falling back to the local filesystem service for plain paths
+type RoutingFileService struct {
+	local    FileService
+	backends map[string]FileService
+}
+
+func NewRoutingFileService(local FileService) *RoutingFileService {
+	return &RoutingFileService{
+		local:    local,
+		backends: make(map[string]FileService),
+	}
+}
+
+// Register routes paths with the given scheme (e.g. "s3") to svc
+func (r *RoutingFileService) Register(scheme string, svc FileService) {
+	r.backends[scheme] = svc
+}
+
+func (r *RoutingFileService) serviceFor(p string) FileService {
+	if scheme, _, ok := splitScheme(p); ok {
+		if svc, found := r.backends[scheme]; found {
+			return svc
+		}
+	}
+	return r.local
+}
+
+func (r *RoutingFileService) GetDirectoryStructure(rootPath string, maxDepth int, onProgress ScanProgressCallback) (string, error) {
+	return r.serviceFor(rootPath).GetDirectoryStructure(rootPath, maxDepth, onProgress)
+}
+
+func (r *RoutingFileService) ExecuteOperations(operations []FileOperation, basePath string, opts ExecutionOptions) (ExecutionResult, error) {
+	return r.serviceFor(basePath).ExecuteOperations(operations, basePath, opts)
+}
+
+func (r *RoutingFileService) CountFiles(rootPath string) (int, error) {
+	return r.serviceFor(rootPath).CountFiles(rootPath)
+}
+
+func (r *RoutingFileService) CleanEmptyDirectories(rootPath string) (int, error) {
+	return r.serviceFor(rootPath).CleanEmptyDirectories(rootPath)
+}
+
+func (r *RoutingFileService) Fingerprint(rootPath string, maxDepth int) (DirectoryFingerprint, error) {
+	if fp, ok := r.serviceFor(rootPath).(Fingerprinter); ok {
+		return fp.Fingerprint(rootPath, maxDepth)
+	}
+	return DirectoryFingerprint{}, ErrFingerprintUnsupported
+}
+
+func (r *RoutingFileService) InvalidateStructureCache(path string) {
+	if inv, ok := r.serviceFor(path).(StructureCacheInvalidator); ok {
+		inv.InvalidateStructureCache(path)
+	}
+}
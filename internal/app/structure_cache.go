@@ -0,0 +1,98 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type structureCacheKey struct {
+	rootPath string
+	maxDepth int
+}
+
+type structureCacheEntry struct {
+	structure string
+	dirMtimes map[string]time.Time // Every directory and bundle visited by the walk
+	files     []string             // Listed files in walk order, replayed as progress on a hit
+}
+
+// StructureCache remembers walked directory structures between analyze runs.
+// An entry stays valid while none of the walked directories has a new modification time:
+// adding, removing or renaming an entry updates its parent directory's mtime, so checking them
+// is a stat per directory, without re-reading any folder. As with the index's folder signatures,
+// files edited in place (and the insides of bundles) don't change their folder, so their listed
+// sizes stay stale until something else in the folder changes or the cache is invalidated.
+type StructureCache struct {
+	mu      sync.Mutex
+	entries map[structureCacheKey]*structureCacheEntry
+}
+
+func NewStructureCache() *StructureCache {
+	return &StructureCache{
+		entries: make(map[structureCacheKey]*structureCacheEntry),
+	}
+}
+
+// Get returns the cached structure and the files it lists if every recorded directory is unchanged
+func (c *StructureCache) Get(rootPath string, maxDepth int) (string, []string, bool) {
+	key := structureCacheKey{rootPath: filepath.Clean(rootPath), maxDepth: maxDepth}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok {
+		return "", nil, false
+	}
+
+	if !entry.unchanged() {
+		c.mu.Lock()
+		delete(c.entries, key)
+		c.mu.Unlock()
+		return "", nil, false
+	}
+	return entry.structure, entry.files, true
+}
+
+func (e *structureCacheEntry) unchanged() bool {
+	for dir, mtime := range e.dirMtimes {
+		info, err := os.Stat(dir)
+		if err != nil || !info.ModTime().Equal(mtime) {
+			return false
+		}
+	}
+	return true
+}
+
+// Put stores a freshly walked structure along with the mtimes of the directories it visited
+// and the relative paths of the files it listed
+func (c *StructureCache) Put(rootPath string, maxDepth int, structure string, dirMtimes map[string]time.Time, files []string) {
+	key := structureCacheKey{rootPath: filepath.Clean(rootPath), maxDepth: maxDepth}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = &structureCacheEntry{
+		structure: structure,
+		dirMtimes: dirMtimes,
+		files:     files,
+	}
+}
+
+// Invalidate drops every cached structure whose root contains or is contained by path.
+// An empty path clears the whole cache.
+func (c *StructureCache) Invalidate(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if path == "" {
+		c.entries = make(map[structureCacheKey]*structureCacheEntry)
+		return
+	}
+	path = filepath.Clean(path)
+	for key := range c.entries {
+		if pathsOverlap(key.rootPath, path) {
+			delete(c.entries, key)
+		}
+	}
+}
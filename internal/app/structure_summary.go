@@ -0,0 +1,77 @@
+package app
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// structureSummaryLargest is how many of the largest files a summary lists
+const structureSummaryLargest = 5
+
+// StructureSummary describes a scanned directory so its scope can be checked before analysis
+type StructureSummary struct {
+	Files     int
+	Folders   int
+	TotalSize int64
+	MaxDepth  int            // Deepest nesting level; entries directly in the directory are at depth 1
+	ByType    map[string]int // File counts keyed by AnalysisFileTypes
+	Largest   []SummaryFile
+}
+
+// SummaryFile is a file listed in a structure summary
+type SummaryFile struct {
+	Path string // Relative, with forward slashes
+	Size int64
+}
+
+// TypesByCount returns the file types ordered from most to least common
+func (s StructureSummary) TypesByCount() []string {
+	types := make([]string, 0, len(s.ByType))
+	for fileType := range s.ByType {
+		types = append(types, fileType)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if s.ByType[types[i]] != s.ByType[types[j]] {
+			return s.ByType[types[i]] > s.ByType[types[j]]
+		}
+		return types[i] < types[j]
+	})
+	return types
+}
+
+// SummarizeStructure counts the files, folders and sizes in a text structure produced by
+// GetDirectoryStructure, enriched or not
+func SummarizeStructure(structure string) StructureSummary {
+	summary := StructureSummary{ByType: make(map[string]int)}
+
+	for _, line := range strings.Split(structure, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if strings.HasSuffix(line, "/") {
+			summary.Folders++
+			summary.MaxDepth = max(summary.MaxDepth, strings.Count(strings.TrimSuffix(line, "/"), "/")+1)
+			continue
+		}
+
+		m := structureFileLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		size, _ := strconv.ParseInt(m[3], 10, 64)
+		summary.Files++
+		summary.TotalSize += size
+		summary.ByType[DetermineFileType(m[1])]++
+		summary.MaxDepth = max(summary.MaxDepth, strings.Count(m[1], "/")+1)
+		summary.Largest = append(summary.Largest, SummaryFile{Path: m[1], Size: size})
+	}
+
+	sort.SliceStable(summary.Largest, func(i, j int) bool { return summary.Largest[i].Size > summary.Largest[j].Size })
+	if len(summary.Largest) > structureSummaryLargest {
+		summary.Largest = summary.Largest[:structureSummaryLargest]
+	}
+	return summary
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSummarizeStructure(t *testing.T) {
+	structure := `docs/
+docs/2023/
+docs/2023/report.pdf [Quarterly report] (4096 bytes)
+docs/notes.txt (100 bytes)
+photos/
+photos/a.jpg (2000000 bytes)
+photos/b.jpg (3000000 bytes)
+main.go (50 bytes)
+`
+	summary := SummarizeStructure(structure)
+
+	if summary.Files != 5 || summary.Folders != 3 {
+		t.Errorf("expected 5 files in 3 folders, got %d in %d", summary.Files, summary.Folders)
+	}
+	if summary.TotalSize != 5004246 {
+		t.Errorf("TotalSize = %d, want 5004246", summary.TotalSize)
+	}
+	if summary.MaxDepth != 3 {
+		t.Errorf("MaxDepth = %d, want 3", summary.MaxDepth)
+	}
+	if got := summary.TypesByCount(); !reflect.DeepEqual(got, []string{"image", "code", "pdf", "text"}) {
+		t.Errorf("TypesByCount = %v", got)
+	}
+	if len(summary.Largest) != 5 || summary.Largest[0].Path != "photos/b.jpg" || summary.Largest[2].Path != "docs/2023/report.pdf" {
+		t.Errorf("unexpected largest files: %+v", summary.Largest)
+	}
+
+	if empty := SummarizeStructure(""); empty.Files != 0 || empty.MaxDepth != 0 || len(empty.Largest) != 0 {
+		t.Errorf("expected an empty summary, got %+v", empty)
+	}
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const maxSubtitleFileSize = 2 * 1024 * 1024 // Subtitle text read per video
+
+var subtitleTagPattern = regexp.MustCompile(`<[^>]*>|\{\\[^}]*\}`) // <i>, <c.color>, {\an8}
+
+// findSubtitleSidecar returns the .srt/.vtt file next to a video ("talk.srt", "talk.en.vtt"), or "" if none
+func findSubtitleSidecar(videoPath string) string {
+	base := strings.TrimSuffix(videoPath, filepath.Ext(videoPath))
+
+	for _, ext := range []string{".srt", ".vtt", ".SRT", ".VTT"} {
+		if info, err := os.Stat(base + ext); err == nil && info.Mode().IsRegular() {
+			return base + ext
+		}
+	}
+
+	// Language-tagged sidecars; prefer a stable choice when there are several
+	var candidates []string
+	for _, pattern := range []string{".*.srt", ".*.vtt"} {
+		matches, err := filepath.Glob(escapeGlob(base) + pattern)
+		if err == nil {
+			candidates = append(candidates, matches...)
+		}
+	}
+	sort.Strings(candidates)
+	if len(candidates) > 0 {
+		return candidates[0]
+	}
+	return ""
+}
+
+// escapeGlob escapes glob metacharacters so a literal path can be used as a filepath.Glob prefix
+func escapeGlob(path string) string {
+	replacer := strings.NewReplacer(`*`, `\*`, `?`, `\?`, `[`, `\[`)
+	if filepath.Separator == '\\' {
+		// Backslash is the path separator on Windows and can't be used for escaping
+		replacer = strings.NewReplacer(`*`, `[*]`, `?`, `[?]`, `[`, `[[]`)
+	}
+	return replacer.Replace(path)
+}
+
+// extractSubtitleText strips cue identifiers, timestamps and formatting from SRT/WebVTT content,
+// returning the spoken text with repeated lines (common in rolling captions) removed
+func extractSubtitleText(r io.Reader) string {
+	var raw []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		raw = append(raw, strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff")))
+	}
+
+	var lines []string
+	previous := ""
+	inNote := false
+	for i, line := range raw {
+		switch {
+		case line == "":
+			inNote = false
+			continue
+		case inNote:
+			continue
+		case line == "WEBVTT" || strings.HasPrefix(line, "WEBVTT "):
+			continue
+		case strings.HasPrefix(line, "NOTE") || line == "STYLE" || line == "REGION":
+			inNote = true
+			continue
+		case strings.Contains(line, "-->"):
+			continue
+		case i+1 < len(raw) && strings.Contains(raw[i+1], "-->"):
+			// Cue identifier ("1" in SRT, any label in WebVTT)
+			continue
+		}
+
+		line = strings.TrimSpace(subtitleTagPattern.ReplaceAllString(line, ""))
+		if line == "" || line == previous {
+			continue
+		}
+		lines = append(lines, line)
+		previous = line
+	}
+
+	return strings.Join(lines, " ")
+}
+
+// analyzeVideoFile describes a video from its subtitle sidecar when one exists, so videos
+// can be organized by what is said in them without analyzing frames
+func (das *DeepAnalysisService) analyzeVideoFile(ctx context.Context, filePath string) (string, error) {
+	sidecar := findSubtitleSidecar(filePath)
+	if sidecar == "" {
+		return das.analyzeGenericFile(ctx, filePath)
+	}
+
+	file, err := os.Open(sidecar)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	transcript := extractSubtitleText(io.LimitReader(file, maxSubtitleFileSize))
+	if transcript == "" {
+		das.logger.Debug("Subtitle sidecar %s has no text, describing %s by metadata only", sidecar, filePath)
+		return das.analyzeGenericFile(ctx, filePath)
+	}
+	das.logger.Debug("Using subtitle sidecar %s for %s", sidecar, filePath)
+
+	content := fmt.Sprintf("Video transcript (from %s):\n%s", filepath.Base(sidecar), transcript)
+	description, err := das.analyzeContentWithLLM(ctx, content, "transcript", filepath.Base(filePath))
+	if err != nil {
+		return "", fmt.Errorf("transcript analysis failed: %w", err)
+	}
+	return description, nil
+}
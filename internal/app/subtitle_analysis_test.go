@@ -0,0 +1,59 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractSubtitleText(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "srt",
+			content:  "1\n00:00:01,000 --> 00:00:03,000\n<i>Welcome to</i> lecture three.\n\n2\n00:00:03,500 --> 00:00:05,000\n{\\an8}Today: linear algebra\n",
+			expected: "Welcome to lecture three. Today: linear algebra",
+		},
+		{
+			name:     "vtt with notes and rolling captions",
+			content:  "WEBVTT - lecture\n\nNOTE recorded live\nsecond note line\n\n00:01.000 --> 00:02.000\n<c.yellow>eigenvalues</c>\n\n00:02.000 --> 00:03.000\neigenvalues\n\nintro\n00:03.000 --> 00:04.000\nand eigenvectors\n",
+			expected: "eigenvalues and eigenvectors",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractSubtitleText(strings.NewReader(tt.content)); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFindSubtitleSidecar(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"talk [2024].mp4", "talk [2024].fr.vtt", "talk [2024].en.srt", "other.mp4", "other.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := findSubtitleSidecar(filepath.Join(dir, "talk [2024].mp4")); got != filepath.Join(dir, "talk [2024].en.srt") {
+		t.Errorf("expected language-tagged sidecar, got %q", got)
+	}
+	if got := findSubtitleSidecar(filepath.Join(dir, "other.mp4")); got != "" {
+		t.Errorf("expected no sidecar, got %q", got)
+	}
+
+	exact := filepath.Join(dir, "talk [2024].vtt")
+	if err := os.WriteFile(exact, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := findSubtitleSidecar(filepath.Join(dir, "talk [2024].mp4")); got != exact {
+		t.Errorf("expected exact-name sidecar to win, got %q", got)
+	}
+}
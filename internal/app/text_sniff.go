@@ -0,0 +1,77 @@
+package app
+
+import (
+	"bytes"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	sniffLength = 8192 // Bytes inspected when deciding whether content is text
+
+	// Share of suspicious characters (invalid UTF-8, control characters) above which content is
+	// treated as binary. Legacy single-byte encodings such as Latin-1 stay well below it.
+	maxSuspiciousRatio = 0.1
+)
+
+// decodeText returns content as UTF-8 text, or false when it looks binary. Files with a UTF-16 byte
+// order mark are converted; anything else must be mostly valid UTF-8 without null bytes, so data
+// files with text extensions (.cfg, .conf, .json dumps) aren't sent to the model as garbage.
+func decodeText(content []byte) (string, bool) {
+	if text, ok := decodeUTF16(content); ok {
+		return text, true
+	}
+	content = bytes.TrimPrefix(content, []byte{0xEF, 0xBB, 0xBF})
+
+	sample := content[:min(len(content), sniffLength)]
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return "", false
+	}
+
+	suspicious, total := 0, 0
+	for len(sample) > 0 {
+		r, size := utf8.DecodeRune(sample)
+		if r == utf8.RuneError && size <= 1 {
+			// A rune cut off by the sample limit isn't evidence of binary data
+			if len(content) > sniffLength && len(sample) < utf8.UTFMax {
+				break
+			}
+			suspicious++
+		} else if r < 0x20 && r != '\t' && r != '\n' && r != '\r' && r != '\f' && r != 0x1b {
+			suspicious++
+		}
+		total++
+		sample = sample[size:]
+	}
+	if total > 0 && float64(suspicious)/float64(total) > maxSuspiciousRatio {
+		return "", false
+	}
+	return string(content), true
+}
+
+// decodeUTF16 converts content that starts with a UTF-16 byte order mark
+func decodeUTF16(content []byte) (string, bool) {
+	if len(content) < 2 {
+		return "", false
+	}
+	var bigEndian bool
+	switch {
+	case content[0] == 0xFE && content[1] == 0xFF:
+		bigEndian = true
+	case content[0] == 0xFF && content[1] == 0xFE:
+		bigEndian = false
+	default:
+		return "", false
+	}
+
+	content = content[2:]
+	units := make([]uint16, 0, len(content)/2)
+	for i := 0; i+1 < len(content); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(content[i])<<8|uint16(content[i+1]))
+		} else {
+			units = append(units, uint16(content[i+1])<<8|uint16(content[i]))
+		}
+	}
+	return string(utf16.Decode(units)), true
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		want    string
+		ok      bool
+	}{
+		{"plain text", []byte("key = value\n\tindented\r\n"), "key = value\n\tindented\r\n", true},
+		{"utf-8 with bom", []byte("\xEF\xBB\xBFnaïve café"), "naïve café", true},
+		{"latin-1 accents", []byte("caf\xe9 cr\xe8me br\xfbl\xe9e and a long enough sentence around it"), "", true},
+		{"null bytes", []byte("MZ\x90\x00\x03\x00\x00\x00"), "", false},
+		{"random bytes", []byte{0x8f, 0x13, 0xc7, 0x02, 0xfe, 0x91, 0x07, 0xaa, 0x3c, 0xd4, 0x11, 0x9b}, "", false},
+		{"utf-16 little endian", []byte{0xFF, 0xFE, 'h', 0, 'i', 0}, "hi", true},
+		{"utf-16 big endian", []byte{0xFE, 0xFF, 0, 'h', 0, 'i'}, "hi", true},
+		{"empty", nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := decodeText(tt.content)
+			if ok != tt.ok {
+				t.Fatalf("expected ok=%v, got %v", tt.ok, ok)
+			}
+			if tt.want != "" && got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDecodeText_RuneCutBySampleLimit(t *testing.T) {
+	// The sample ends in the middle of a multi-byte character
+	content := append(bytes.Repeat([]byte("a"), sniffLength-1), []byte("é and more")...)
+	if _, ok := decodeText(content); !ok {
+		t.Error("expected text whose sample ends mid-character to be accepted")
+	}
+}
+
+func TestAnalyzeFile_BinaryContentNotSent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.cfg")
+	if err := os.WriteFile(path, []byte("\x00\x01\x02binary\x00blob"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// No endpoint is configured, so any request to the model would fail
+	config := &Config{}
+	logger := NewLogger(false)
+	das := NewDeepAnalysisService(config, NewHTTPClient(config, logger), nil, logger)
+	description, err := das.AnalyzeFile(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(description, "binary file: settings.cfg") {
+		t.Errorf("expected a metadata-only description, got %q", description)
+	}
+}
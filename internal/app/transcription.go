@@ -0,0 +1,164 @@
+package app
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const ffprobeTimeout = 15 * time.Second
+
+// wavDuration reads the length of a PCM WAV recording from its RIFF header
+func wavDuration(r io.Reader) (time.Duration, bool) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil || string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return 0, false
+	}
+
+	var byteRate uint32
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return 0, false
+		}
+		id := string(chunk[0:4])
+		size := binary.LittleEndian.Uint32(chunk[4:8])
+
+		switch id {
+		case "fmt ":
+			// AudioFormat(2) NumChannels(2) SampleRate(4) ByteRate(4) ...
+			if size < 12 {
+				return 0, false
+			}
+			format := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, format); err != nil {
+				return 0, false
+			}
+			byteRate = binary.LittleEndian.Uint32(format[8:12])
+		case "data":
+			if byteRate == 0 {
+				return 0, false
+			}
+			return time.Duration(float64(size) / float64(byteRate) * float64(time.Second)), true
+		default:
+			// Chunks are padded to an even size
+			if _, err := io.CopyN(io.Discard, r, int64(size+size%2)); err != nil {
+				return 0, false
+			}
+		}
+	}
+}
+
+// audioDuration returns the length of a recording from the WAV header, or from ffprobe for other
+// formats when it is installed. ok is false when the length can't be determined.
+func audioDuration(filePath string) (time.Duration, bool) {
+	if strings.ToLower(filepath.Ext(filePath)) == ".wav" {
+		file, err := os.Open(filePath)
+		if err != nil {
+			return 0, false
+		}
+		defer file.Close()
+		return wavDuration(file)
+	}
+
+	ffprobe, err := exec.LookPath("ffprobe")
+	if err != nil {
+		return 0, false
+	}
+	output, err := runArgs([]string{ffprobe, "-v", "error", "-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1", filePath}, nil, ffprobeTimeout)
+	if err != nil {
+		return 0, false
+	}
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil || seconds <= 0 {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+// transcriptionAllowed checks the opt-in and the size/duration limits before a recording is uploaded
+func (das *DeepAnalysisService) transcriptionAllowed(filePath string, size int64) (bool, string) {
+	if !das.config.TranscribeAudio || strings.TrimSpace(das.config.TranscriptionEndpoint) == "" {
+		return false, "transcription is disabled"
+	}
+	if maxSize := int64(das.config.TranscriptionMaxSizeMB) * 1024 * 1024; maxSize > 0 && size > maxSize {
+		return false, fmt.Sprintf("file is larger than %d MB", das.config.TranscriptionMaxSizeMB)
+	}
+	if das.config.TranscriptionMaxMinutes > 0 {
+		duration, ok := audioDuration(filePath)
+		if !ok {
+			das.logger.Debug("Could not determine the length of %s, relying on the size limit", filePath)
+		} else if duration > time.Duration(das.config.TranscriptionMaxMinutes)*time.Minute {
+			return false, fmt.Sprintf("recording is longer than %d minutes", das.config.TranscriptionMaxMinutes)
+		}
+	}
+	return true, ""
+}
+
+// transcribeAudio uploads a recording to the Whisper-compatible transcription endpoint
+func (das *DeepAnalysisService) transcribeAudio(filePath string) (string, error) {
+	apiKey := das.config.TranscriptionAPIKey
+	if apiKey == "" {
+		apiKey = das.config.APIKey
+	}
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", apiKey),
+	}
+	fields := map[string]string{
+		"model":           das.config.TranscriptionModel,
+		"response_format": "json",
+	}
+
+	body, err := das.httpClient.PostMultipart(das.config.TranscriptionEndpoint, headers, fields, "file", filePath)
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("failed to parse transcription response: %w", err)
+	}
+	return strings.TrimSpace(response.Text), nil
+}
+
+// analyzeAudioFile describes a recording from its transcript when transcription is enabled
+// and the file is within the limits; otherwise only its metadata is described
+func (das *DeepAnalysisService) analyzeAudioFile(ctx context.Context, filePath string) (string, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	if allowed, reason := das.transcriptionAllowed(filePath, info.Size()); !allowed {
+		das.logger.Debug("Not transcribing %s: %s", filePath, reason)
+		return das.analyzeGenericFile(ctx, filePath)
+	}
+
+	das.logger.Debug("Transcribing %s", filePath)
+	transcript, err := das.transcribeAudio(filePath)
+	if err != nil {
+		// Returning the error leaves the file unindexed so it is retried on the next scan
+		return "", fmt.Errorf("transcription failed: %w", err)
+	}
+	if transcript == "" {
+		return das.analyzeGenericFile(ctx, filePath)
+	}
+
+	content := fmt.Sprintf("Audio transcript:\n%s", transcript)
+	description, err := das.analyzeContentWithLLM(ctx, content, "transcript", filepath.Base(filePath))
+	if err != nil {
+		return "", fmt.Errorf("transcript analysis failed: %w", err)
+	}
+	return description, nil
+}
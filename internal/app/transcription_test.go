@@ -0,0 +1,116 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// buildWAV returns a minimal PCM WAV file with an extra chunk before the data
+func buildWAV(byteRate uint32, dataSize int) []byte {
+	var buf bytes.Buffer
+	le := func(v any) { binary.Write(&buf, binary.LittleEndian, v) }
+
+	buf.WriteString("RIFF")
+	le(uint32(0)) // Patched below
+	buf.WriteString("WAVE")
+	buf.WriteString("fmt ")
+	le(uint32(16))
+	le(uint16(1))    // PCM
+	le(uint16(1))    // Mono
+	le(byteRate / 2) // Sample rate
+	le(byteRate)     // Byte rate
+	le(uint16(2))    // Block align
+	le(uint16(16))   // Bits per sample
+	buf.WriteString("LIST")
+	le(uint32(3))
+	buf.WriteString("abc\x00") // Odd-sized chunk plus padding byte
+	buf.WriteString("data")
+	le(uint32(dataSize))
+	buf.Write(make([]byte, dataSize))
+
+	data := buf.Bytes()
+	binary.LittleEndian.PutUint32(data[4:8], uint32(len(data)-8))
+	return data
+}
+
+func TestWavDuration(t *testing.T) {
+	duration, ok := wavDuration(bytes.NewReader(buildWAV(1000, 2500)))
+	if !ok || duration != 2500*time.Millisecond {
+		t.Errorf("expected 2.5s, got %v (ok: %v)", duration, ok)
+	}
+
+	if _, ok := wavDuration(bytes.NewReader([]byte("ID3 not a wav file"))); ok {
+		t.Error("expected non-WAV data to be rejected")
+	}
+}
+
+func TestAnalyzeAudioFile_TranscriptionGate(t *testing.T) {
+	dir := t.TempDir()
+	longPath := filepath.Join(dir, "long.wav")
+	shortPath := filepath.Join(dir, "memo.wav")
+	if err := os.WriteFile(longPath, buildWAV(100, 100*61), 0644); err != nil { // 61 seconds
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(shortPath, buildWAV(100, 100*5), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	uploads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/audio/transcriptions":
+			uploads++
+			file, _, err := r.FormFile("file")
+			if err != nil || r.FormValue("model") != "whisper-1" {
+				http.Error(w, "bad upload", http.StatusBadRequest)
+				return
+			}
+			data, _ := io.ReadAll(file)
+			if len(data) != 556 {
+				http.Error(w, "truncated upload", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"text": "Remember to call the dentist on Monday."}`))
+		case "/chat/completions":
+			w.Write([]byte(`{"choices": [{"message": {"content": "Voice memo about a dentist appointment."}}]}`))
+		}
+	}))
+	defer server.Close()
+
+	config := &Config{
+		Endpoint:                server.URL + "/chat/completions",
+		TranscriptionEndpoint:   server.URL + "/audio/transcriptions",
+		TranscriptionModel:      "whisper-1",
+		TranscriptionMaxSizeMB:  1,
+		TranscriptionMaxMinutes: 1,
+	}
+	logger := NewLogger(false)
+	das := NewDeepAnalysisService(config, NewHTTPClient(config, logger), nil, logger)
+
+	// Disabled: described by metadata only, nothing uploaded
+	description, err := das.AnalyzeFile(context.Background(), shortPath)
+	if err != nil || uploads != 0 || description != "audio file: memo.wav (556 bytes)" {
+		t.Fatalf("expected metadata-only description, got %q (err: %v, uploads: %d)", description, err, uploads)
+	}
+
+	config.TranscribeAudio = true
+	if _, err := das.AnalyzeFile(context.Background(), longPath); err != nil || uploads != 0 {
+		t.Fatalf("expected recording over the duration limit not to be uploaded (err: %v, uploads: %d)", err, uploads)
+	}
+
+	description, err = das.AnalyzeFile(context.Background(), shortPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if uploads != 1 || description != "Voice memo about a dentist appointment." {
+		t.Errorf("expected transcript-based description, got %q (uploads: %d)", description, uploads)
+	}
+}
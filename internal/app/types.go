@@ -1,6 +1,10 @@
 package app
 
 type FileOperation struct {
-	From string `json:"from"`
-	To   string `json:"to"`
+	From       string  `json:"from"`
+	To         string  `json:"to"`
+	Confidence float64 `json:"confidence,omitempty"` // 0-1 as reported by the model; 0 when not reported
+	Reason     string  `json:"reason,omitempty"`     // Model's explanation, when asked for one
+	Flag       string  `json:"flag,omitempty"`       // Concern raised by the self-critique pass
+	Adjusted   string  `json:"adjusted,omitempty"`   // What sanitization changed in To
 }
@@ -0,0 +1,319 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Update modes
+const (
+	UpdateModeOff      = "off"
+	UpdateModeNotify   = "notify"   // Tell the user when a newer release exists
+	UpdateModeDownload = "download" // Also download this platform's release so it's ready to install
+)
+
+const (
+	defaultReleasesURL = "https://api.github.com/repos/sandwichdoge/VibesAndFolders/releases/latest"
+	updateCheckTimeout = 15 * time.Second
+	updateDownloadTime = 10 * time.Minute
+)
+
+var ErrNoReleaseAsset = errors.New("the release has no download for this platform")
+
+// ReleaseAsset is a downloadable file of a release
+type ReleaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+	Size int64  `json:"size"`
+}
+
+// githubRelease is the subset of GitHub's release API response the checker uses
+type githubRelease struct {
+	TagName string         `json:"tag_name"`
+	HTMLURL string         `json:"html_url"`
+	Body    string         `json:"body"`
+	Assets  []ReleaseAsset `json:"assets"`
+}
+
+// UpdateInfo is the result of an update check
+type UpdateInfo struct {
+	CurrentVersion string
+	LatestVersion  string
+	Available      bool   // LatestVersion is newer than CurrentVersion
+	ReleaseURL     string // Release page, for release notes and manual downloads
+	Notes          string
+	StagedPath     string // Downloaded installer or archive, when UpdateModeDownload staged one
+}
+
+// UpdateChecker looks for newer releases on GitHub and, if configured, downloads the one for
+// this platform into stagingDir. It never installs anything itself.
+type UpdateChecker struct {
+	config         *Config
+	currentVersion string
+	stagingDir     string
+	releasesURL    string
+	client         *http.Client
+	logger         *Logger
+}
+
+func NewUpdateChecker(config *Config, currentVersion, stagingDir string, logger *Logger) *UpdateChecker {
+	return &UpdateChecker{
+		config:         config,
+		currentVersion: currentVersion,
+		stagingDir:     stagingDir,
+		releasesURL:    defaultReleasesURL,
+		client:         &http.Client{Transport: sharedTransport},
+		logger:         logger,
+	}
+}
+
+// Enabled reports whether automatic checks are turned on
+func (u *UpdateChecker) Enabled() bool {
+	return u.config.UpdateMode == UpdateModeNotify || u.config.UpdateMode == UpdateModeDownload
+}
+
+// Check fetches the latest release and compares it with the running version. Development
+// builds without a version never report updates.
+func (u *UpdateChecker) Check(ctx context.Context) (*UpdateInfo, error) {
+	checkCtx, cancel := context.WithTimeout(ctx, updateCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(checkCtx, "GET", u.releasesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for updates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to check for updates: %s", resp.Status)
+	}
+
+	var release githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to parse release: %w", err)
+	}
+
+	info := &UpdateInfo{
+		CurrentVersion: u.currentVersion,
+		LatestVersion:  strings.TrimPrefix(release.TagName, "v"),
+		ReleaseURL:     release.HTMLURL,
+		Notes:          release.Body,
+	}
+	info.Available = u.currentVersion != "" && compareVersions(info.LatestVersion, u.currentVersion) > 0
+	if !info.Available {
+		u.logger.Debug("No update available (running %s, latest %s)", u.currentVersion, info.LatestVersion)
+		return info, nil
+	}
+	u.logger.Info("Update available: %s (running %s)", info.LatestVersion, u.currentVersion)
+
+	if u.config.UpdateMode == UpdateModeDownload {
+		staged, err := u.stage(ctx, info.LatestVersion, release.Assets)
+		if err != nil {
+			// The notification still points at the release page
+			u.logger.Error("Failed to download update: %v", err)
+		} else {
+			info.StagedPath = staged
+		}
+	}
+	return info, nil
+}
+
+// stage downloads the release asset for this platform, verifying it against a checksum file
+// when the release has one. Already staged downloads are reused.
+func (u *UpdateChecker) stage(ctx context.Context, version string, assets []ReleaseAsset) (string, error) {
+	asset, ok := selectReleaseAsset(assets, runtime.GOOS, runtime.GOARCH)
+	if !ok {
+		return "", fmt.Errorf("%w (%s/%s)", ErrNoReleaseAsset, runtime.GOOS, runtime.GOARCH)
+	}
+
+	dir := filepath.Join(u.stagingDir, version)
+	target := filepath.Join(dir, filepath.Base(asset.Name))
+	if info, err := os.Stat(target); err == nil && info.Size() == asset.Size {
+		return target, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	downloadCtx, cancel := context.WithTimeout(ctx, updateDownloadTime)
+	defer cancel()
+
+	partial := target + ".part"
+	sum, err := u.download(downloadCtx, asset.URL, partial)
+	if err != nil {
+		os.Remove(partial)
+		return "", err
+	}
+	if checksums, ok := selectChecksumAsset(assets); ok {
+		want, err := u.fetchChecksum(downloadCtx, checksums.URL, asset.Name)
+		if err != nil {
+			os.Remove(partial)
+			return "", err
+		}
+		if !strings.EqualFold(want, sum) {
+			os.Remove(partial)
+			return "", fmt.Errorf("checksum mismatch for %s", asset.Name)
+		}
+	}
+	if err := os.Rename(partial, target); err != nil {
+		return "", err
+	}
+
+	u.logger.Info("Downloaded update %s to %s", version, target)
+	return target, nil
+}
+
+// download saves url to path and returns the file's SHA-256 in hex
+func (u *UpdateChecker) download(ctx context.Context, url, path string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download update: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download update: %s", resp.Status)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(file, hash), resp.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to download update: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// fetchChecksum finds name in a "sha256  filename" checksum file
+func (u *UpdateChecker) fetchChecksum(ctx context.Context, url, name string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download checksums: %w", err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return fields[0], nil
+		}
+	}
+	return "", fmt.Errorf("no checksum listed for %s", name)
+}
+
+// platformNames are the spellings release file names use for each GOOS and GOARCH
+var platformNames = map[string][]string{
+	"windows": {"windows"},
+	"darwin":  {"darwin", "macos", "mac", "osx"},
+	"linux":   {"linux"},
+	"amd64":   {"amd64", "x86_64", "x64"},
+	"arm64":   {"arm64", "aarch64"},
+}
+
+// selectReleaseAsset picks the download for goos/goarch. Assets naming no architecture (e.g.
+// universal macOS builds) are used when none names this one.
+func selectReleaseAsset(assets []ReleaseAsset, goos, goarch string) (ReleaseAsset, bool) {
+	mentions := func(name string, key string) bool {
+		for _, alias := range platformNames[key] {
+			if strings.Contains(name, alias) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var fallback *ReleaseAsset
+	for i, asset := range assets {
+		name := strings.ToLower(asset.Name)
+		if !mentions(name, goos) || isChecksumFile(name) {
+			continue
+		}
+		if mentions(name, goarch) {
+			return asset, true
+		}
+		if fallback == nil && !mentions(name, "amd64") && !mentions(name, "arm64") {
+			fallback = &assets[i]
+		}
+	}
+	if fallback != nil {
+		return *fallback, true
+	}
+	return ReleaseAsset{}, false
+}
+
+func isChecksumFile(name string) bool {
+	return strings.Contains(name, "checksum") || strings.Contains(name, "sha256")
+}
+
+func selectChecksumAsset(assets []ReleaseAsset) (ReleaseAsset, bool) {
+	for _, asset := range assets {
+		if isChecksumFile(strings.ToLower(asset.Name)) {
+			return asset, true
+		}
+	}
+	return ReleaseAsset{}, false
+}
+
+// compareVersions compares dotted numeric versions like "1.0.5", ignoring a leading "v" and
+// any pre-release suffix. It returns -1, 0 or 1.
+func compareVersions(a, b string) int {
+	parse := func(version string) []int {
+		version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+		version, _, _ = strings.Cut(version, "-")
+		var parts []int
+		for _, part := range strings.Split(version, ".") {
+			n, _ := strconv.Atoi(part)
+			parts = append(parts, n)
+		}
+		return parts
+	}
+
+	pa, pb := parse(a), parse(b)
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.5", "1.0.5", 0},
+		{"v1.0.6", "1.0.5", 1},
+		{"1.0.5", "1.1", -1},
+		{"1.10.0", "1.9.9", 1},
+		{"2.0", "2.0.0", 0},
+		{"1.1.0-beta", "1.0.9", 1},
+	}
+
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSelectReleaseAsset(t *testing.T) {
+	assets := []ReleaseAsset{
+		{Name: "checksums.txt"},
+		{Name: "VibesAndFolders-windows-amd64.zip"},
+		{Name: "VibesAndFolders-linux-amd64.tar.xz"},
+		{Name: "VibesAndFolders-linux-arm64.tar.xz"},
+		{Name: "VibesAndFolders-macOS-universal.dmg"},
+	}
+
+	tests := []struct {
+		goos, goarch string
+		want         string
+		wantOK       bool
+	}{
+		{"linux", "arm64", "VibesAndFolders-linux-arm64.tar.xz", true},
+		{"windows", "amd64", "VibesAndFolders-windows-amd64.zip", true},
+		{"darwin", "arm64", "VibesAndFolders-macOS-universal.dmg", true},
+		{"windows", "arm64", "", false},
+		{"freebsd", "amd64", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := selectReleaseAsset(assets, tt.goos, tt.goarch)
+		if ok != tt.wantOK || got.Name != tt.want {
+			t.Errorf("selectReleaseAsset(%s/%s) = %q, %v; want %q, %v", tt.goos, tt.goarch, got.Name, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestUpdateCheckerDownloadsVerifiedRelease(t *testing.T) {
+	payload := []byte("new release")
+	sum := sha256.Sum256(payload)
+	assetName := fmt.Sprintf("VibesAndFolders-%s-%s.zip", runtime.GOOS, runtime.GOARCH)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latest":
+			json.NewEncoder(w).Encode(githubRelease{
+				TagName: "v1.2.0",
+				HTMLURL: "https://example.com/release",
+				Assets: []ReleaseAsset{
+					{Name: assetName, URL: server.URL + "/asset", Size: int64(len(payload))},
+					{Name: "checksums.txt", URL: server.URL + "/checksums"},
+				},
+			})
+		case "/asset":
+			w.Write(payload)
+		case "/checksums":
+			fmt.Fprintf(w, "%s  %s\n", hex.EncodeToString(sum[:]), assetName)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	config := &Config{UpdateMode: UpdateModeDownload}
+	checker := NewUpdateChecker(config, "1.0.5", t.TempDir(), NewLogger(false))
+	checker.releasesURL = server.URL + "/latest"
+
+	info, err := checker.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if !info.Available || info.LatestVersion != "1.2.0" {
+		t.Fatalf("Expected 1.2.0 to be available, got %+v", info)
+	}
+	if info.StagedPath == "" {
+		t.Fatal("Expected the release to be staged")
+	}
+	data, err := os.ReadFile(info.StagedPath)
+	if err != nil || string(data) != string(payload) {
+		t.Errorf("Staged file = %q, %v; want %q", data, err, payload)
+	}
+
+	// Development builds never report updates
+	checker.currentVersion = ""
+	info, err = checker.Check(context.Background())
+	if err != nil || info.Available {
+		t.Errorf("Expected no update for a development build, got %+v, %v", info, err)
+	}
+}
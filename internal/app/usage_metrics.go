@@ -0,0 +1,167 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Kinds of events counted in the local usage metrics
+const (
+	MetricAnalysis  = "analysis"
+	MetricExecution = "execution"
+	MetricRollback  = "rollback"
+)
+
+// UsageMetrics counts analyses and executions in a local SQLite database for the statistics
+// page. Only counts are stored: no paths, prompts or file names, and nothing is sent anywhere.
+type UsageMetrics struct {
+	db     *sql.DB
+	logger *Logger
+	now    func() time.Time
+}
+
+// UsageSummary aggregates the events recorded since some point in time
+type UsageSummary struct {
+	Analyses             int
+	FailedAnalyses       int
+	PlannedOperations    int // Operations suggested by successful analyses
+	Executions           int
+	OperationsExecuted   int
+	OperationsFailed     int
+	Rollbacks            int
+	OperationsRolledBack int
+}
+
+// AveragePlanSize is the mean number of operations per successful analysis
+func (s UsageSummary) AveragePlanSize() float64 {
+	if succeeded := s.Analyses - s.FailedAnalyses; succeeded > 0 {
+		return float64(s.PlannedOperations) / float64(succeeded)
+	}
+	return 0
+}
+
+// AnalysisFailureRate is the share of analyses that ended in an error, between 0 and 1
+func (s UsageSummary) AnalysisFailureRate() float64 {
+	if s.Analyses == 0 {
+		return 0
+	}
+	return float64(s.FailedAnalyses) / float64(s.Analyses)
+}
+
+// OperationFailureRate is the share of attempted operations that failed, between 0 and 1
+func (s UsageSummary) OperationFailureRate() float64 {
+	if attempted := s.OperationsExecuted + s.OperationsFailed; attempted > 0 {
+		return float64(s.OperationsFailed) / float64(attempted)
+	}
+	return 0
+}
+
+// OpenUsageMetrics opens (or creates) the metrics database at path
+func OpenUsageMetrics(path string, logger *Logger) (*UsageMetrics, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create metrics directory: %w", err)
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metrics database: %w", err)
+	}
+
+	schema := `
+	CREATE TABLE IF NOT EXISTS usage_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		recorded_at INTEGER NOT NULL,
+		kind TEXT NOT NULL,
+		succeeded INTEGER NOT NULL DEFAULT 0,
+		failed INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_usage_recorded_at ON usage_events(recorded_at);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create metrics schema: %w", err)
+	}
+
+	return &UsageMetrics{db: db, logger: logger, now: time.Now}, nil
+}
+
+// RecordAnalysis counts an analysis and the size of its plan. Safe to call on nil metrics.
+func (m *UsageMetrics) RecordAnalysis(result AnalysisResult) {
+	if result.Error != nil {
+		m.record(MetricAnalysis, 0, 1)
+		return
+	}
+	m.record(MetricAnalysis, len(result.Operations), 0)
+}
+
+// RecordExecution counts the operations of an execution, or of a rollback when rollback is set.
+// Safe to call on nil metrics.
+func (m *UsageMetrics) RecordExecution(result ExecutionResult, rollback bool) {
+	kind := MetricExecution
+	if rollback {
+		kind = MetricRollback
+	}
+	m.record(kind, result.SuccessCount, result.FailCount)
+}
+
+func (m *UsageMetrics) record(kind string, succeeded, failed int) {
+	if m == nil {
+		return
+	}
+	_, err := m.db.Exec("INSERT INTO usage_events (recorded_at, kind, succeeded, failed) VALUES (?, ?, ?, ?)",
+		m.now().UnixMilli(), kind, succeeded, failed)
+	if err != nil {
+		m.logger.Error("Failed to record usage metric %s: %v", kind, err)
+	}
+}
+
+// Summary aggregates the events recorded at or after since
+func (m *UsageMetrics) Summary(since time.Time) (UsageSummary, error) {
+	var summary UsageSummary
+	rows, err := m.db.Query(`
+		SELECT kind, COUNT(*), SUM(succeeded), SUM(failed), SUM(CASE WHEN failed > 0 THEN 1 ELSE 0 END)
+		FROM usage_events WHERE recorded_at >= ? GROUP BY kind
+	`, since.UnixMilli())
+	if err != nil {
+		return summary, fmt.Errorf("failed to query metrics: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kind string
+		var events, succeeded, failed, eventsWithFailures int
+		if err := rows.Scan(&kind, &events, &succeeded, &failed, &eventsWithFailures); err != nil {
+			return summary, fmt.Errorf("failed to read metrics: %w", err)
+		}
+		switch kind {
+		case MetricAnalysis:
+			summary.Analyses = events
+			summary.FailedAnalyses = eventsWithFailures
+			summary.PlannedOperations = succeeded
+		case MetricExecution:
+			summary.Executions = events
+			summary.OperationsExecuted = succeeded
+			summary.OperationsFailed = failed
+		case MetricRollback:
+			summary.Rollbacks = events
+			summary.OperationsRolledBack = succeeded
+		}
+	}
+	return summary, rows.Err()
+}
+
+// Reset deletes every recorded event
+func (m *UsageMetrics) Reset() error {
+	_, err := m.db.Exec("DELETE FROM usage_events")
+	return err
+}
+
+func (m *UsageMetrics) Close() error {
+	if m == nil {
+		return nil
+	}
+	return m.db.Close()
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUsageMetricsSummary(t *testing.T) {
+	metrics, err := OpenUsageMetrics(filepath.Join(t.TempDir(), "metrics.db"), NewLogger(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer metrics.Close()
+
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	metrics.now = func() time.Time { return now.AddDate(0, -2, 0) }
+	metrics.RecordAnalysis(AnalysisResult{Operations: make([]FileOperation, 10)})
+
+	metrics.now = func() time.Time { return now }
+	metrics.RecordAnalysis(AnalysisResult{Operations: make([]FileOperation, 4)})
+	metrics.RecordAnalysis(AnalysisResult{Error: errors.New("timeout")})
+	metrics.RecordExecution(ExecutionResult{SuccessCount: 9, FailCount: 1}, false)
+	metrics.RecordExecution(ExecutionResult{SuccessCount: 3}, true)
+
+	all, err := metrics.Summary(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := UsageSummary{
+		Analyses: 3, FailedAnalyses: 1, PlannedOperations: 14,
+		Executions: 1, OperationsExecuted: 9, OperationsFailed: 1,
+		Rollbacks: 1, OperationsRolledBack: 3,
+	}
+	if all != want {
+		t.Errorf("Summary = %+v, want %+v", all, want)
+	}
+	if got := all.AveragePlanSize(); got != 7 {
+		t.Errorf("AveragePlanSize = %v, want 7", got)
+	}
+	if got := all.OperationFailureRate(); got != 0.1 {
+		t.Errorf("OperationFailureRate = %v, want 0.1", got)
+	}
+
+	recent, err := metrics.Summary(now.AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recent.Analyses != 2 || recent.AveragePlanSize() != 4 || recent.AnalysisFailureRate() != 0.5 {
+		t.Errorf("unexpected summary for the last 30 days: %+v", recent)
+	}
+
+	if err := metrics.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if empty, _ := metrics.Summary(time.Time{}); empty != (UsageSummary{}) {
+		t.Errorf("expected no events after Reset, got %+v", empty)
+	}
+
+	// Recording on nil metrics is a no-op, so the orchestrator works without a database
+	var none *UsageMetrics
+	none.RecordAnalysis(AnalysisResult{})
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+)
+
+// apiUsage is the token usage block reported by OpenAI-compatible APIs
+type apiUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// requestMaxTokens extracts max_tokens from a marshaled request body, used as the worst-case output size
+func requestMaxTokens(jsonData []byte) int {
+	var req struct {
+		MaxTokens int `json:"max_tokens"`
+	}
+	if err := json.Unmarshal(jsonData, &req); err != nil {
+		return 0
+	}
+	return req.MaxTokens
+}
+
+// responseUsage returns the token usage of a non-streaming response, falling back to
+// estimates when the provider doesn't report usage
+func responseUsage(body []byte, estimatedInput int) (int, int) {
+	var resp struct {
+		Usage   *apiUsage `json:"usage"`
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return estimatedInput, estimateTokens(len(body))
+	}
+	if resp.Usage != nil && resp.Usage.PromptTokens+resp.Usage.CompletionTokens > 0 {
+		return resp.Usage.PromptTokens, resp.Usage.CompletionTokens
+	}
+
+	output := 0
+	for _, choice := range resp.Choices {
+		output += estimateTokens(len(choice.Message.Content))
+	}
+	return estimatedInput, output
+}
+
+// usageTrackingReader passes a server-sent event stream through unchanged while accumulating
+// the generated content, and records the request's usage with the budget guard on Close
+type usageTrackingReader struct {
+	body        io.ReadCloser
+	budget      *BudgetGuard
+	run         *BudgetRun
+	inputTokens int
+
+	pending      []byte
+	contentBytes int
+	usage        *apiUsage
+	once         sync.Once
+}
+
+func (r *usageTrackingReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	if n > 0 {
+		r.scan(p[:n])
+	}
+	return n, err
+}
+
+// scan inspects complete "data: " lines for content deltas and a final usage block
+func (r *usageTrackingReader) scan(chunk []byte) {
+	r.pending = append(r.pending, chunk...)
+	for {
+		idx := bytes.IndexByte(r.pending, '\n')
+		if idx < 0 {
+			return
+		}
+		line := strings.TrimSpace(string(r.pending[:idx]))
+		r.pending = r.pending[idx+1:]
+
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok || data == "[DONE]" {
+			continue
+		}
+		var event struct {
+			Usage   *apiUsage `json:"usage"`
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			continue
+		}
+		if event.Usage != nil {
+			r.usage = event.Usage
+		}
+		for _, choice := range event.Choices {
+			r.contentBytes += len(choice.Delta.Content)
+		}
+	}
+}
+
+func (r *usageTrackingReader) Close() error {
+	r.once.Do(func() {
+		if r.usage != nil && r.usage.PromptTokens+r.usage.CompletionTokens > 0 {
+			r.budget.Record(r.run, r.usage.PromptTokens, r.usage.CompletionTokens)
+		} else {
+			r.budget.Record(r.run, r.inputTokens, estimateTokens(r.contentBytes))
+		}
+	})
+	return r.body.Close()
+}
@@ -2,7 +2,9 @@ package app
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -15,6 +17,9 @@ var (
 	ErrSourceNotExist      = errors.New("source file does not exist")
 	ErrDestinationExists   = errors.New("destination already exists")
 	ErrCannotCreateDir     = errors.New("could not create directory")
+	ErrLocationReadOnly    = errors.New("location is not writable")
+	ErrLocationFull        = errors.New("not enough free space")
+	ErrInsideBundle        = errors.New("path is inside an application bundle or document package")
 )
 
 type Validator struct{}
@@ -27,6 +32,10 @@ func (v *Validator) ValidateDirectory(path string) error {
 	if strings.TrimSpace(path) == "" {
 		return ErrEmptyDirectory
 	}
+	// Object storage prefixes can't be stat'ed locally; the backend reports missing buckets on listing
+	if IsObjectStoragePath(path) {
+		return nil
+	}
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return err
 	}
@@ -56,8 +65,59 @@ func (v *Validator) ValidateFileOperation(op FileOperation) error {
 	if _, err := os.Lstat(op.From); os.IsNotExist(err) {
 		return ErrSourceNotExist
 	}
+	for _, path := range []string{op.From, op.To} {
+		if bundle, ok := containingBundle(path); ok {
+			return fmt.Errorf("%w: %s", ErrInsideBundle, bundle)
+		}
+	}
 	if _, err := os.Lstat(op.To); err == nil {
 		return ErrDestinationExists
 	}
 	return nil
 }
+
+// minFreeSpace is the room a location needs for the folders and directory entries a plan creates
+const minFreeSpace = 1 << 20
+
+// ValidateWritable checks that every folder the operations take files from or put files into can
+// be written to and has room left. It asks the system for permissions and free space rather than
+// writing anything, so checking changes no folder's mtime and wakes no watcher or sync client.
+// Moves are renames, so they need no room for file contents, but a read-only mount or a full disk
+// (or exceeded quota) would otherwise only show up as failures halfway through the plan.
+// Destination folders that don't exist yet are checked at their nearest existing parent. Folders
+// that can't be checked are left to execution, which knows how to wait for offline shares.
+func (v *Validator) ValidateWritable(operations []FileOperation) error {
+	checked := make(map[string]bool)
+	for _, op := range operations {
+		if IsObjectStoragePath(op.From) || IsObjectStoragePath(op.To) {
+			continue
+		}
+		for _, dir := range []string{filepath.Dir(op.From), nearestExistingDir(filepath.Dir(op.To))} {
+			if dir == "" || checked[dir] {
+				continue
+			}
+			checked[dir] = true
+			if writable, err := locationWritable(dir); err == nil && !writable {
+				return fmt.Errorf("%w: %s", ErrLocationReadOnly, dir)
+			}
+			if free, err := locationFreeSpace(dir); err == nil && free < minFreeSpace {
+				return fmt.Errorf("%w: %s has %s left", ErrLocationFull, dir, FormatSize(int64(free)))
+			}
+		}
+	}
+	return nil
+}
+
+// nearestExistingDir returns dir or its closest ancestor that exists, or "" if none does
+func nearestExistingDir(dir string) string {
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
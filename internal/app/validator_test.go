@@ -0,0 +1,62 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestValidateWritable(t *testing.T) {
+	tests := []struct {
+		name     string
+		readOnly bool
+		wantErr  error
+	}{
+		{name: "writable folders pass"},
+		{name: "read-only destination fails", readOnly: true, wantErr: ErrLocationReadOnly},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.readOnly && (runtime.GOOS == "windows" || os.Geteuid() == 0) {
+				t.Skip("folder permissions aren't enforced for this user")
+			}
+
+			dir := t.TempDir()
+			src := filepath.Join(dir, "a.txt")
+			dest := filepath.Join(dir, "archive")
+			if err := os.WriteFile(src, []byte("a"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Mkdir(dest, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.readOnly {
+				if err := os.Chmod(dest, 0555); err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() { os.Chmod(dest, 0755) })
+			}
+
+			past := time.Now().Add(-time.Hour)
+			if err := os.Chtimes(dest, past, past); err != nil {
+				t.Fatal(err)
+			}
+
+			// The destination's own folder doesn't exist yet, so its parent is checked
+			ops := []FileOperation{{From: src, To: filepath.Join(dest, "2024", "a.txt")}}
+			err := NewValidator().ValidateWritable(ops)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateWritable() = %v, want %v", err, tt.wantErr)
+			}
+
+			// Checking must not write anything, which would wake watchers and sync clients
+			if info, err := os.Stat(dest); err != nil || !info.ModTime().Equal(past) {
+				t.Errorf("checking changed the destination folder: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package app
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// volumeCacheTTL bounds how long a looked-up volume is trusted; drives can be swapped at any time
+const volumeCacheTTL = 30 * time.Second
+
+// volumeInfo identifies the volume a path lives on
+type volumeInfo struct {
+	ID         string // Stable across mounts, e.g. "uuid:1234-ABCD"
+	MountPoint string // Where the volume is mounted right now
+}
+
+// volumeRelativePath returns path relative to the volume's mount point, slash-separated
+func (v volumeInfo) volumeRelativePath(path string) (string, bool) {
+	rel, err := filepath.Rel(v.MountPoint, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// absolutePath maps a volume-relative path back to the volume's current mount point
+func (v volumeInfo) absolutePath(volumePath string) string {
+	return filepath.Join(v.MountPoint, filepath.FromSlash(volumePath))
+}
+
+type cachedVolume struct {
+	info     volumeInfo
+	ok       bool
+	cachedAt time.Time
+}
+
+var (
+	volumeCacheMu sync.Mutex
+	volumeCache   = make(map[string]cachedVolume) // Keyed by mount point
+)
+
+// volumeFor identifies the removable or secondary volume holding path. The system volume is
+// never reported, since its mount point can't change and identifying it would only cost time.
+func volumeFor(path string) (volumeInfo, bool) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return volumeInfo{}, false
+	}
+
+	var mountPoint, device string
+	if runtime.GOOS == "windows" {
+		mountPoint = filepath.VolumeName(absPath) + `\`
+		if len(mountPoint) != 3 || strings.EqualFold(mountPoint, os.Getenv("SystemDrive")+`\`) {
+			return volumeInfo{}, false // UNC shares and the system drive
+		}
+	} else {
+		entry, ok := mountEntryFor(absPath, cachedMountTable())
+		if !ok || entry.MountPoint == "/" || networkFSTypes[entry.FSType] {
+			// Network shares keep their server path; only local devices get remounted elsewhere
+			return volumeInfo{}, false
+		}
+		mountPoint, device = entry.MountPoint, entry.Device
+	}
+
+	volumeCacheMu.Lock()
+	cached, found := volumeCache[mountPoint]
+	volumeCacheMu.Unlock()
+	if found && time.Since(cached.cachedAt) < volumeCacheTTL {
+		return cached.info, cached.ok
+	}
+
+	id := lookupVolumeID(mountPoint, device)
+	cached = cachedVolume{
+		info:     volumeInfo{ID: id, MountPoint: mountPoint},
+		ok:       id != "",
+		cachedAt: time.Now(),
+	}
+
+	volumeCacheMu.Lock()
+	volumeCache[mountPoint] = cached
+	volumeCacheMu.Unlock()
+	return cached.info, cached.ok
+}
+
+var (
+	mountTableMu     sync.Mutex
+	mountTable       []mountEntry
+	mountTableReadAt time.Time
+)
+
+// cachedMountTable returns the mount table, read again once it is older than volumeCacheTTL.
+// Indexing looks up the volume of every file it stores, and on macOS reading the table runs mount.
+func cachedMountTable() []mountEntry {
+	mountTableMu.Lock()
+	defer mountTableMu.Unlock()
+	if mountTableReadAt.IsZero() || time.Since(mountTableReadAt) >= volumeCacheTTL {
+		mountTable = readMountTable()
+		mountTableReadAt = time.Now()
+	}
+	return mountTable
+}
+
+var (
+	diskutilUUIDPattern = regexp.MustCompile(`(?m)^\s*Volume UUID:\s*(\S+)`)
+	volSerialPattern    = regexp.MustCompile(`([0-9A-Fa-f]{4}-[0-9A-Fa-f]{4})`)
+)
+
+// lookupVolumeID asks the platform for the volume's UUID or serial number, or returns ""
+func lookupVolumeID(mountPoint, device string) string {
+	switch runtime.GOOS {
+	case "linux":
+		for _, dir := range []string{"/dev/disk/by-uuid", "/dev/disk/by-label"} {
+			if id := linuxDiskLink(dir, device); id != "" {
+				return strings.TrimPrefix(filepath.Base(dir), "by-") + ":" + id
+			}
+		}
+	case "darwin":
+		out, err := exec.Command("diskutil", "info", mountPoint).Output()
+		if err == nil {
+			if m := diskutilUUIDPattern.FindSubmatch(out); m != nil {
+				return "uuid:" + string(m[1])
+			}
+		}
+	case "windows":
+		out, err := exec.Command("cmd", "/c", "vol", strings.TrimSuffix(mountPoint, `\`)).Output()
+		if err == nil {
+			if m := volSerialPattern.FindSubmatch(out); m != nil {
+				return "serial:" + strings.ToUpper(string(m[1]))
+			}
+		}
+	}
+	return ""
+}
+
+// linuxDiskLink finds the entry in a /dev/disk/by-* directory that links to device
+func linuxDiskLink(dir, device string) string {
+	if !strings.HasPrefix(device, "/dev/") {
+		return ""
+	}
+	resolvedDevice, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		return ""
+	}
+	links, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	for _, link := range links {
+		target, err := filepath.EvalSymlinks(filepath.Join(dir, link.Name()))
+		if err == nil && target == resolvedDevice {
+			// udev escapes special characters in labels, e.g. spaces as \x20
+			return strings.ReplaceAll(link.Name(), `\x20`, " ")
+		}
+	}
+	return ""
+}
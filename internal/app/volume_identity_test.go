@@ -0,0 +1,119 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRebaseVolume_FollowsRemountedDrive(t *testing.T) {
+	oldMount := t.TempDir()
+	newMount := t.TempDir()
+
+	indexService := NewIndexService(NewLogger(false))
+	if err := indexService.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer indexService.Close()
+
+	// The drive is first mounted at oldMount, then comes back at newMount
+	mountPoint := oldMount
+	indexService.volumeResolver = func(path string) (volumeInfo, bool) {
+		for _, mount := range []string{oldMount, newMount} {
+			if path == mount || strings.HasPrefix(path, mount+string(filepath.Separator)) {
+				return volumeInfo{ID: "uuid:test", MountPoint: mountPoint}, mount == mountPoint
+			}
+		}
+		return volumeInfo{}, false
+	}
+
+	tracked := filepath.Join(oldMount, "docs", "report.txt")
+	if err := indexService.IndexFile(tracked, "quarterly report", "text", 10, time.Unix(1000, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	// An entry indexed before volumes were tracked gets its volume recorded on the next scan
+	legacy := filepath.Join(oldMount, "notes.txt")
+	resolver := indexService.volumeResolver
+	indexService.volumeResolver = func(string) (volumeInfo, bool) { return volumeInfo{}, false }
+	if err := indexService.IndexFile(legacy, "meeting notes", "text", 5, time.Unix(1000, 0)); err != nil {
+		t.Fatal(err)
+	}
+	indexService.volumeResolver = resolver
+	indexService.rebaseVolume(oldMount)
+
+	mountPoint = newMount
+	for _, name := range []string{filepath.Join("docs", "report.txt"), "notes.txt"} {
+		path := filepath.Join(newMount, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := indexService.RemoveOrphanedEntries(newMount)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if removed != 0 {
+		t.Errorf("expected remounted entries not to be treated as orphans, %d removed", removed)
+	}
+
+	for _, name := range []string{filepath.Join("docs", "report.txt"), "notes.txt"} {
+		file, err := indexService.GetIndexedFile(filepath.Join(newMount, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if file == nil {
+			t.Errorf("expected %s to follow the drive to its new mount point", name)
+		}
+	}
+	if indexed, _ := indexService.IsFileIndexed(tracked); indexed {
+		t.Error("expected the old mount point entry to be moved, not copied")
+	}
+}
+
+func TestRebaseVolume_WildcardsInMountPoint(t *testing.T) {
+	// "_" matches any single character in LIKE, so a/aXb would pass for entries already under a_b
+	base := t.TempDir()
+	oldMount := filepath.Join(base, "aXb")
+	newMount := filepath.Join(base, "a_b")
+
+	indexService := NewIndexService(NewLogger(false))
+	if err := indexService.Initialize(filepath.Join(t.TempDir(), "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer indexService.Close()
+
+	mountPoint := oldMount
+	indexService.volumeResolver = func(string) (volumeInfo, bool) {
+		return volumeInfo{ID: "uuid:test", MountPoint: mountPoint}, true
+	}
+	if err := indexService.IndexFile(filepath.Join(oldMount, "report.txt"), "quarterly report", "text", 10, time.Unix(1000, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	mountPoint = newMount
+	indexService.rebaseVolume(newMount)
+	if file, err := indexService.GetIndexedFile(filepath.Join(newMount, "report.txt")); err != nil || file == nil {
+		t.Errorf("expected the entry to follow the drive to %s, got %v (err: %v)", newMount, file, err)
+	}
+}
+
+func TestLikePrefix(t *testing.T) {
+	tests := map[string]string{
+		"/media/disk/": "/media/disk/%",
+		"/media/a_b/":  `/media/a\_b/%`,
+		"/media/100%/": `/media/100\%/%`,
+		`E:\Backups\`:  `E:\\Backups\\%`,
+	}
+	for prefix, want := range tests {
+		if got := likePrefix(prefix); got != want {
+			t.Errorf("likePrefix(%q) = %q, want %q", prefix, got, want)
+		}
+	}
+}
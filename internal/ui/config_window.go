@@ -1,11 +1,16 @@
 package ui
 
 import (
+	"fmt"
+	"math"
+	"strconv"
 	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
 
 	"io.github.sandwichdoge.vibesandfolders/internal/app"
@@ -44,10 +49,231 @@ func (cw *ConfigWindow) Show(onFirstRunSubmit func(), onFirstRunCancel func()) {
 	modelEntry.SetText(cw.config.Model)
 	modelEntry.SetPlaceHolder("gpt-4o")
 
+	temperatureEntry := widget.NewEntry()
+	if cw.config.Temperature != nil {
+		temperatureEntry.SetText(strconv.FormatFloat(*cw.config.Temperature, 'f', -1, 64))
+	}
+	temperatureEntry.SetPlaceHolder("Provider default")
+
+	maxTokensEntry := widget.NewEntry()
+	if cw.config.MaxTokens > 0 {
+		maxTokensEntry.SetText(strconv.Itoa(cw.config.MaxTokens))
+	}
+	maxTokensEntry.SetPlaceHolder("8192")
+
 	dbPathEntry := widget.NewEntry()
 	dbPathEntry.SetText(cw.config.IndexDBPath)
 	dbPathEntry.SetPlaceHolder("Path to index database (optional)")
 
+	auditLogPathEntry := widget.NewEntry()
+	auditLogPathEntry.SetText(cw.config.AuditLogPath)
+	auditLogPathEntry.SetPlaceHolder("Path to audit log database (optional; applies after restart)")
+
+	encryptIndexCheck := widget.NewCheck("Encrypt file descriptions, keeping the key in the system keychain (applies after restart)", nil)
+	encryptIndexCheck.SetChecked(cw.config.EncryptIndex)
+
+	indexSyncDirEntry := widget.NewEntry()
+	indexSyncDirEntry.SetText(cw.config.IndexSyncDir)
+	indexSyncDirEntry.SetPlaceHolder("Folder on a shared drive, e.g. /mnt/nas/.vibesandfolders-sync (optional)")
+
+	watchedFoldersEntry := widget.NewMultiLineEntry()
+	watchedFoldersEntry.SetText(cw.config.WatchedFolders)
+	watchedFoldersEntry.SetPlaceHolder("One folder per line; renames, moves and deletions update the index right away (applies after restart)")
+	watchedFoldersEntry.SetMinRowsVisible(3)
+
+	sidecarFormatOptions := map[string]string{
+		"Off":                app.SidecarFormatNone,
+		"JSON (.vaf.json)":   app.SidecarFormatJSON,
+		"XMP (file.ext.xmp)": app.SidecarFormatXMP,
+	}
+	sidecarFormatSelect := widget.NewSelect([]string{"Off", "JSON (.vaf.json)", "XMP (file.ext.xmp)"}, nil)
+	sidecarFormatSelect.SetSelected("Off")
+	for label, format := range sidecarFormatOptions {
+		if format == cw.config.SidecarFormat {
+			sidecarFormatSelect.SetSelected(label)
+		}
+	}
+
+	namingStyleOptions := map[string]string{
+		"As suggested by the model": app.NamingStyleNone,
+		"kebab-case":                app.NamingStyleKebab,
+		"snake_case":                app.NamingStyleSnake,
+		"Title Case":                app.NamingStyleTitle,
+		"lower case":                app.NamingStyleLower,
+	}
+	namingStyleSelect := widget.NewSelect([]string{"As suggested by the model", "kebab-case", "snake_case", "Title Case", "lower case"}, nil)
+	namingStyleSelect.SetSelected("As suggested by the model")
+	for label, style := range namingStyleOptions {
+		if style == cw.config.FolderNamingStyle {
+			namingStyleSelect.SetSelected(label)
+		}
+	}
+	normalizeDatesCheck := widget.NewCheck("Rewrite dates at the start of new folder names as YYYY-MM-DD", nil)
+	normalizeDatesCheck.SetChecked(cw.config.NormalizeDatePrefixes)
+
+	folderEmojiCheck := widget.NewCheck("Put the category emoji before new folder names (📷 Photos)", nil)
+	folderEmojiCheck.SetChecked(cw.config.FolderEmojiPrefixes)
+	folderColorsCheck := widget.NewCheck("Color new folders in Finder, or give them an icon on Windows", nil)
+	folderColorsCheck.SetChecked(cw.config.FolderColorLabels)
+	folderLabelRulesEntry := widget.NewMultiLineEntry()
+	folderLabelRulesEntry.SetText(cw.config.FolderLabelRules)
+	folderLabelRulesEntry.Wrapping = fyne.TextWrapOff
+	folderLabelRulesEntry.SetMinRowsVisible(4)
+
+	updateModeOptions := map[string]string{
+		"Off":                         app.UpdateModeOff,
+		"Notify about new versions":   app.UpdateModeNotify,
+		"Notify and download updates": app.UpdateModeDownload,
+	}
+	updateModeSelect := widget.NewSelect([]string{"Off", "Notify about new versions", "Notify and download updates"}, nil)
+	updateModeSelect.SetSelected("Off")
+	for label, mode := range updateModeOptions {
+		if mode == cw.config.UpdateMode {
+			updateModeSelect.SetSelected(label)
+		}
+	}
+
+	janitorIntervalEntry := widget.NewEntry()
+	janitorIntervalEntry.SetText(strconv.Itoa(cw.config.IndexJanitorIntervalHours))
+	janitorIntervalEntry.SetPlaceHolder("0 = only when a directory is analyzed")
+
+	snapshotFoldersEntry := widget.NewMultiLineEntry()
+	snapshotFoldersEntry.SetText(cw.config.SnapshotFolders)
+	snapshotFoldersEntry.SetPlaceHolder("One folder per line; file lists with hashes are kept to compare dates (Plan > Folder Snapshots)")
+	snapshotFoldersEntry.SetMinRowsVisible(2)
+	snapshotIntervalEntry := widget.NewEntry()
+	snapshotIntervalEntry.SetText(strconv.Itoa(cw.config.SnapshotIntervalHours))
+	snapshotIntervalEntry.SetPlaceHolder("0 = only when taken by hand")
+
+	throttleSyncedCheck := widget.NewCheck("Slow down moves in Dropbox, OneDrive, Google Drive and iCloud folders", nil)
+	throttleSyncedCheck.SetChecked(cw.config.ThrottleSyncedFolders)
+	throttleDelayEntry := widget.NewEntry()
+	throttleDelayEntry.SetText(strconv.Itoa(cw.config.SyncThrottleDelayMs))
+	throttleBatchEntry := widget.NewEntry()
+	throttleBatchEntry.SetText(strconv.Itoa(cw.config.SyncThrottleBatchSize))
+	downloadPlaceholdersCheck := widget.NewCheck("Download online-only files when deep analysis needs their content", nil)
+	downloadPlaceholdersCheck.SetChecked(cw.config.DownloadPlaceholders)
+	throttleRow := container.NewHBox(
+		widget.NewLabel("Pause"), container.NewGridWrap(fyne.NewSize(80, throttleDelayEntry.MinSize().Height), throttleDelayEntry),
+		widget.NewLabel("ms after every"), container.NewGridWrap(fyne.NewSize(80, throttleBatchEntry.MinSize().Height), throttleBatchEntry),
+		widget.NewLabel("moves"),
+	)
+
+	scanSummaryCheck := widget.NewCheck("Show a summary of the scanned folder and ask before calling the model", nil)
+	scanSummaryCheck.SetChecked(!cw.config.SkipScanSummary)
+	changedOnlyCheck := widget.NewCheck("Only plan files that are new or modified since the last run (needs deep analysis)", nil)
+	changedOnlyCheck.SetChecked(cw.config.PlanChangedFilesOnly)
+
+	parallelMovesEntry := widget.NewEntry()
+	parallelMovesEntry.SetText(strconv.Itoa(cw.config.ParallelMoves))
+	parallelMovesEntry.SetPlaceHolder("1 = one move at a time")
+
+	walkWorkersEntry := widget.NewEntry()
+	walkWorkersEntry.SetText(strconv.Itoa(cw.config.WalkWorkers))
+	walkBatchSizeEntry := widget.NewEntry()
+	walkBatchSizeEntry.SetText(strconv.Itoa(cw.config.WalkBatchSize))
+	walkRow := container.NewHBox(
+		container.NewGridWrap(fyne.NewSize(80, walkWorkersEntry.MinSize().Height), walkWorkersEntry),
+		widget.NewLabel("folders at once, up to"),
+		container.NewGridWrap(fyne.NewSize(80, walkBatchSizeEntry.MinSize().Height), walkBatchSizeEntry),
+		widget.NewLabel("read ahead (applies after restart)"),
+	)
+	skipUnchangedCheck := widget.NewCheck("Skip folders that haven't changed since the last scan (misses files edited in place; applies after restart)", nil)
+	skipUnchangedCheck.SetChecked(cw.config.SkipUnchangedFolders)
+
+	stagedExecutionCheck := widget.NewCheck("All or nothing: stage files first and undo everything if one move fails", nil)
+	stagedExecutionCheck.SetChecked(cw.config.StagedExecution)
+
+	checkOpenFilesCheck := widget.NewCheck("Check for files open in other programs before moving (uses lsof on macOS/Linux)", nil)
+	checkOpenFilesCheck.SetChecked(cw.config.CheckOpenFiles)
+
+	auditPermissionsCheck := widget.NewCheck("Report permission and owner changes after moving (shared folders)", nil)
+	auditPermissionsCheck.SetChecked(cw.config.AuditPermissions)
+
+	rewriteShortcutsCheck := widget.NewCheck("Update shortcuts, aliases and symlinks that point at moved files", nil)
+	rewriteShortcutsCheck.SetChecked(cw.config.RewriteShortcuts)
+
+	updateReferencesCheck := widget.NewCheck("Update paths to moved files in playlists, Markdown notes and XMP sidecars", nil)
+	updateReferencesCheck.SetChecked(cw.config.UpdateReferences)
+
+	keepCompanionsCheck := widget.NewCheck("Keep companion files together (subtitles, XMP sidecars, RAW+JPEG pairs)", nil)
+	keepCompanionsCheck.SetChecked(!cw.config.SplitCompanions)
+
+	hashVerificationOptions := map[string]string{
+		"Off (count files only)": app.HashVerificationOff,
+		"A random sample of":     app.HashVerificationSample,
+		"Every moved file":       app.HashVerificationAll,
+	}
+	hashVerificationSelect := widget.NewSelect([]string{"Off (count files only)", "A random sample of", "Every moved file"}, nil)
+	hashVerificationSelect.SetSelected("Off (count files only)")
+	for label, mode := range hashVerificationOptions {
+		if cw.config.HashVerification == mode {
+			hashVerificationSelect.SetSelected(label)
+		}
+	}
+	hashSampleSizeEntry := widget.NewEntry()
+	hashSampleSizeEntry.SetText(strconv.Itoa(cw.config.HashSampleSize))
+	hashVerificationRow := container.NewHBox(
+		hashVerificationSelect,
+		container.NewGridWrap(fyne.NewSize(80, hashSampleSizeEntry.MinSize().Height), hashSampleSizeEntry),
+		widget.NewLabel("files"),
+	)
+
+	logLevelSelects := make(map[string]*widget.Select)
+	logConsoleChecks := make(map[string]*widget.Check)
+	logFileChecks := make(map[string]*widget.Check)
+	loggingForm := &widget.Form{}
+	for _, subsystem := range app.LogSubsystems {
+		settings := cw.config.Logging[subsystem]
+		levelSelect := widget.NewSelect([]string{"debug", "info", "error", "off"}, nil)
+		levelSelect.SetSelected(settings.Level)
+		consoleCheck := widget.NewCheck("Console", nil)
+		consoleCheck.SetChecked(settings.Console)
+		fileCheck := widget.NewCheck("Log file", nil)
+		fileCheck.SetChecked(settings.File)
+		logLevelSelects[subsystem] = levelSelect
+		logConsoleChecks[subsystem] = consoleCheck
+		logFileChecks[subsystem] = fileCheck
+		loggingForm.Append(logSubsystemLabel(subsystem), container.NewHBox(levelSelect, consoleCheck, fileCheck))
+	}
+	logFilePathEntry := widget.NewEntry()
+	logFilePathEntry.SetText(cw.config.LogFilePath)
+	logFilePathEntry.SetPlaceHolder(app.LogFilePath(&app.Config{}, cw.app.Storage().RootURI().Path()))
+	loggingForm.Append("Log File", logFilePathEntry)
+
+	structureFormatOptions := map[string]string{
+		"Indented text": app.StructureFormatText,
+		"JSON tree":     app.StructureFormatJSON,
+	}
+	structureFormatSelect := widget.NewSelect([]string{"Indented text", "JSON tree"}, nil)
+	structureFormatSelect.SetSelected("Indented text")
+	if cw.config.StructureFormat == app.StructureFormatJSON {
+		structureFormatSelect.SetSelected("JSON tree")
+	}
+
+	descriptionWordsEntry := widget.NewEntry()
+	if cw.config.DescriptionMaxWords > 0 {
+		descriptionWordsEntry.SetText(strconv.Itoa(cw.config.DescriptionMaxWords))
+	}
+	descriptionWordsEntry.SetPlaceHolder("Default (as the analysis prompts say)")
+
+	deepAnalysisConfirmEntry := widget.NewEntry()
+	if cw.config.DeepAnalysisConfirmCalls > 0 {
+		deepAnalysisConfirmEntry.SetText(strconv.Itoa(cw.config.DeepAnalysisConfirmCalls))
+	}
+	deepAnalysisConfirmEntry.SetPlaceHolder("Never ask")
+
+	imageMaxDimensionEntry := widget.NewEntry()
+	imageMaxDimensionEntry.SetText(strconv.Itoa(cw.config.ImageMaxDimension))
+
+	descriptionLanguageEntry := widget.NewEntry()
+	descriptionLanguageEntry.SetText(cw.config.DescriptionLanguage)
+	descriptionLanguageEntry.SetPlaceHolder("e.g. German, 日本語 (empty = model's choice)")
+
+	localeEntry := widget.NewEntry()
+	localeEntry.SetText(cw.config.Locale)
+	localeEntry.SetPlaceHolder(fmt.Sprintf("e.g. de-DE (empty = system, %s)", lang.SystemLocale()))
+
 	// Organization Prompt Tab
 	systemPromptEntry := widget.NewMultiLineEntry()
 	systemPromptEntry.SetText(cw.config.SystemPrompt)
@@ -55,10 +281,58 @@ func (cw *ConfigWindow) Show(onFirstRunSubmit func(), onFirstRunCancel func()) {
 	systemPromptEntry.Wrapping = fyne.TextWrapWord
 	systemPromptEntry.SetMinRowsVisible(20)
 
+	explainMovesCheck := widget.NewCheck("Ask the model to explain each move (shown in exported plan reports; uses more tokens)", nil)
+	explainMovesCheck.SetChecked(cw.config.ExplainMoves)
+	selfCritiqueCheck := widget.NewCheck("Have the model review its plan and remove or flag suspect moves (one extra request per analysis)", nil)
+	selfCritiqueCheck.SetChecked(cw.config.SelfCritique)
+	indexRulesCheck := widget.NewCheck("Plan from index statistics: the model writes sorting rules that are applied to every indexed file (for folders too large to send; needs deep analysis)", nil)
+	indexRulesCheck.SetChecked(cw.config.IndexRules)
+
+	// Presets fill in the fields above; nothing is stored until Save
+	presetNames := make([]string, len(app.ModelPresets))
+	for i, preset := range app.ModelPresets {
+		presetNames[i] = preset.Name
+	}
+	presetDescription := widget.NewLabel("Fills in the endpoint, model, organization prompt and parameters")
+	presetSelect := widget.NewSelect(presetNames, func(name string) {
+		preset, ok := app.FindModelPreset(name)
+		if !ok {
+			return
+		}
+		endpointEntry.SetText(preset.Endpoint)
+		modelEntry.SetText(preset.Model)
+		systemPromptEntry.SetText(preset.SystemPrompt)
+		temperatureEntry.SetText("")
+		if preset.Temperature != nil {
+			temperatureEntry.SetText(strconv.FormatFloat(*preset.Temperature, 'f', -1, 64))
+		}
+		maxTokensEntry.SetText("")
+		if preset.MaxTokens > 0 {
+			maxTokensEntry.SetText(strconv.Itoa(preset.MaxTokens))
+		}
+		presetDescription.SetText(preset.Description)
+	})
+	presetSelect.PlaceHolder = "Choose a preset..."
+
 	// PDF Analysis Prompt Tab
 	pdfPromptEntry := widget.NewMultiLineEntry()
 	pdfPromptEntry.SetText(cw.config.PDFAnalysisPrompt)
 	pdfPromptEntry.SetPlaceHolder("Enter system prompt for PDF analysis...")
+
+	pdfPageImagesOptions := map[string]string{
+		"When a PDF has little text": app.PDFPageImagesAuto,
+		"Always":                     app.PDFPageImagesAlways,
+		"Never (text only)":          app.PDFPageImagesNever,
+	}
+	pdfPageImagesSelect := widget.NewSelect([]string{"When a PDF has little text", "Always", "Never (text only)"}, nil)
+	pdfPageImagesSelect.SetSelected("When a PDF has little text")
+	for label, mode := range pdfPageImagesOptions {
+		if cw.config.PDFPageImages == mode {
+			pdfPageImagesSelect.SetSelected(label)
+		}
+	}
+	pdfPageImageCountEntry := widget.NewEntry()
+	pdfPageImageCountEntry.SetText(strconv.Itoa(cw.config.PDFPageImageCount))
 	pdfPromptEntry.Wrapping = fyne.TextWrapWord
 	pdfPromptEntry.SetMinRowsVisible(20)
 
@@ -76,6 +350,46 @@ func (cw *ConfigWindow) Show(onFirstRunSubmit func(), onFirstRunCancel func()) {
 	imagePromptEntry.Wrapping = fyne.TextWrapWord
 	imagePromptEntry.SetMinRowsVisible(20)
 
+	// Analysis Types Tab
+	analysisTypeLabels := make([]string, 0, len(app.AnalysisFileTypes))
+	analysisTypeByLabel := make(map[string]string)
+	var enabledTypeLabels []string
+	for _, fileType := range app.AnalysisFileTypes {
+		label := analysisTypeLabel(fileType)
+		analysisTypeLabels = append(analysisTypeLabels, label)
+		analysisTypeByLabel[label] = fileType
+		if cw.config.AnalysisTypeEnabled(fileType) {
+			enabledTypeLabels = append(enabledTypeLabels, label)
+		}
+	}
+	analysisTypesCheck := widget.NewCheckGroup(analysisTypeLabels, nil)
+	analysisTypesCheck.SetSelected(enabledTypeLabels)
+
+	ignoreEmbeddedCheck := widget.NewCheck("Analyze files even when they carry a description (XMP, EXIF, PDF Subject)", nil)
+	ignoreEmbeddedCheck.SetChecked(cw.config.IgnoreEmbeddedDescriptions)
+
+	// Audio Transcription Tab
+	transcribeCheck := widget.NewCheck("Transcribe audio recordings (uploads the audio to the endpoint below)", nil)
+	transcribeCheck.SetChecked(cw.config.TranscribeAudio)
+
+	transcriptionEndpointEntry := widget.NewEntry()
+	transcriptionEndpointEntry.SetText(cw.config.TranscriptionEndpoint)
+	transcriptionEndpointEntry.SetPlaceHolder("https://api.openai.com/v1/audio/transcriptions")
+
+	transcriptionAPIKeyEntry := widget.NewPasswordEntry()
+	transcriptionAPIKeyEntry.SetText(cw.config.TranscriptionAPIKey)
+	transcriptionAPIKeyEntry.SetPlaceHolder("Same as the general API key")
+
+	transcriptionModelEntry := widget.NewEntry()
+	transcriptionModelEntry.SetText(cw.config.TranscriptionModel)
+	transcriptionModelEntry.SetPlaceHolder("whisper-1")
+
+	transcriptionMaxSizeEntry := widget.NewEntry()
+	transcriptionMaxSizeEntry.SetText(strconv.Itoa(cw.config.TranscriptionMaxSizeMB))
+
+	transcriptionMaxMinutesEntry := widget.NewEntry()
+	transcriptionMaxMinutesEntry.SetText(strconv.Itoa(cw.config.TranscriptionMaxMinutes))
+
 	// Ignore Patterns Tab
 	ignorePatternsEntry := widget.NewMultiLineEntry()
 	ignorePatternsEntry.SetText(cw.config.IgnorePatterns)
@@ -83,6 +397,127 @@ func (cw *ConfigWindow) Show(onFirstRunSubmit func(), onFirstRunCancel func()) {
 	ignorePatternsEntry.Wrapping = fyne.TextWrapWord
 	ignorePatternsEntry.SetMinRowsVisible(20)
 
+	ignoreHiddenCheck := widget.NewCheck("Ignore hidden files (dotfiles, and files marked hidden or system on Windows)", nil)
+	ignoreHiddenCheck.SetChecked(cw.config.IgnoreHiddenFiles)
+
+	// File Types Tab
+	fileTypesEntry := widget.NewMultiLineEntry()
+	fileTypesEntry.SetText(cw.config.FileTypeMappings)
+	fileTypesEntry.SetPlaceHolder("# One mapping per line: .ext1,.ext2: type\n.heic, .dng: image\n.gpx: text")
+	fileTypesEntry.Wrapping = fyne.TextWrapOff
+	fileTypesEntry.SetMinRowsVisible(12)
+
+	// Analyzer Plugins Tab
+	pluginsEntry := widget.NewMultiLineEntry()
+	pluginsEntry.SetText(cw.config.AnalyzerPlugins)
+	pluginsEntry.SetPlaceHolder("# One plugin per line: .ext1,.ext2: command --args\n.dcm,.dicom: dicom-describe\n.dwg: python3 /opt/plugins/cad.py")
+	pluginsEntry.Wrapping = fyne.TextWrapOff
+	pluginsEntry.SetMinRowsVisible(12)
+
+	legacyConverterEntry := widget.NewEntry()
+	legacyConverterEntry.SetText(cw.config.LegacyConverterCommand)
+	legacyConverterEntry.SetPlaceHolder("soffice --headless --convert-to {format} --outdir {outdir} {input}")
+
+	// Usage Limits Tab
+	rateLimitsEntry := widget.NewMultiLineEntry()
+	rateLimitsEntry.SetText(cw.config.RateLimits)
+	rateLimitsEntry.SetPlaceHolder("# One provider per line: host: requests/min, tokens/min\nopenrouter.ai: 60, 200000")
+	rateLimitsEntry.Wrapping = fyne.TextWrapOff
+	rateLimitsEntry.SetMinRowsVisible(6)
+
+	concurrentRequestsEntry := widget.NewEntry()
+	concurrentRequestsEntry.SetText(strconv.Itoa(cw.config.MaxConcurrentRequests))
+
+	priceInputEntry := widget.NewEntry()
+	priceInputEntry.SetText(formatAmount(cw.config.PriceInputPerMillion))
+	priceInputEntry.SetPlaceHolder("e.g. 0.60")
+
+	priceOutputEntry := widget.NewEntry()
+	priceOutputEntry.SetText(formatAmount(cw.config.PriceOutputPerMillion))
+	priceOutputEntry.SetPlaceHolder("e.g. 2.50")
+
+	runBudgetEntry := widget.NewEntry()
+	runBudgetEntry.SetText(formatAmount(cw.config.RunBudget))
+	runBudgetEntry.SetPlaceHolder("No limit")
+
+	monthlyBudgetEntry := widget.NewEntry()
+	monthlyBudgetEntry.SetText(formatAmount(cw.config.MonthlyBudget))
+	monthlyBudgetEntry.SetPlaceHolder("No limit")
+
+	// Automation Tab
+	autoApplyCheck := widget.NewCheck("Apply plans from automated jobs without review when the model is confident", nil)
+	autoApplyCheck.SetChecked(cw.config.AutoApply)
+
+	autoApplyConfidenceEntry := widget.NewEntry()
+	autoApplyConfidenceEntry.SetText(strconv.Itoa(int(math.Round(cw.config.AutoApplyMinConfidence * 100))))
+	autoApplyConfidenceEntry.SetPlaceHolder("90")
+
+	autoApplyMaxOpsEntry := widget.NewEntry()
+	autoApplyMaxOpsEntry.SetText(strconv.Itoa(cw.config.AutoApplyMaxOperations))
+	autoApplyMaxOpsEntry.SetPlaceHolder("20")
+
+	scheduledJobsEntry := widget.NewMultiLineEntry()
+	scheduledJobsEntry.SetText(cw.config.ScheduledJobs)
+	scheduledJobsEntry.SetPlaceHolder("One job per line: folder | instructions | depth (optional)\n/nas/Downloads | Sort into folders by file type | 1")
+	scheduledJobsEntry.Wrapping = fyne.TextWrapOff
+	scheduledJobsEntry.SetMinRowsVisible(3)
+	scheduledJobIntervalEntry := widget.NewEntry()
+	scheduledJobIntervalEntry.SetText(strconv.Itoa(cw.config.ScheduledJobIntervalHours))
+	scheduledJobIntervalEntry.SetPlaceHolder("0 = never")
+
+	notifyWebhookEntry := widget.NewEntry()
+	notifyWebhookEntry.SetText(cw.config.NotifyWebhookURL)
+	notifyWebhookEntry.SetPlaceHolder("https://example.com/hooks/organizer (optional)")
+
+	notifySMTPServerEntry := widget.NewEntry()
+	notifySMTPServerEntry.SetText(cw.config.NotifySMTPServer)
+	notifySMTPServerEntry.SetPlaceHolder("smtp.example.com:587 (optional)")
+
+	notifySMTPUserEntry := widget.NewEntry()
+	notifySMTPUserEntry.SetText(cw.config.NotifySMTPUser)
+
+	notifySMTPPasswordEntry := widget.NewPasswordEntry()
+	notifySMTPPasswordEntry.SetText(cw.config.NotifySMTPPassword)
+
+	notifyEmailFromEntry := widget.NewEntry()
+	notifyEmailFromEntry.SetText(cw.config.NotifyEmailFrom)
+	notifyEmailFromEntry.SetPlaceHolder("Same as the user")
+
+	notifyEmailToEntry := widget.NewEntry()
+	notifyEmailToEntry.SetText(cw.config.NotifyEmailTo)
+	notifyEmailToEntry.SetPlaceHolder("me@example.com, nas-admin@example.com")
+
+	// Hooks Tab
+	preAnalysisHookEntry := widget.NewEntry()
+	preAnalysisHookEntry.SetText(cw.config.HookPreAnalysis)
+	preAnalysisHookEntry.SetPlaceHolder("Command run before analysis (optional)")
+
+	preExecuteHookEntry := widget.NewEntry()
+	preExecuteHookEntry.SetText(cw.config.HookPreExecute)
+	preExecuteHookEntry.SetPlaceHolder("Command run before execution (optional)")
+
+	postExecuteHookEntry := widget.NewEntry()
+	postExecuteHookEntry.SetText(cw.config.HookPostExecute)
+	postExecuteHookEntry.SetPlaceHolder("Command run after execution (optional)")
+
+	// Object Storage Tab
+	s3EndpointEntry := widget.NewEntry()
+	s3EndpointEntry.SetText(cw.config.S3Endpoint)
+	s3EndpointEntry.SetPlaceHolder("Leave empty for AWS, or e.g. http://localhost:9000 for MinIO")
+
+	s3RegionEntry := widget.NewEntry()
+	s3RegionEntry.SetText(cw.config.S3Region)
+	s3RegionEntry.SetPlaceHolder("us-east-1")
+
+	s3AccessKeyEntry := widget.NewEntry()
+	s3AccessKeyEntry.SetText(cw.config.S3AccessKey)
+
+	s3SecretKeyEntry := widget.NewPasswordEntry()
+	s3SecretKeyEntry.SetText(cw.config.S3SecretKey)
+
+	s3PathStyleCheck := widget.NewCheck("Use path-style addressing (required by most S3-compatible servers)", nil)
+	s3PathStyleCheck.SetChecked(cw.config.S3UsePathStyle)
+
 	// Determine the Model label based on Deep Analysis setting
 	modelLabel := "Model"
 	if cw.config.EnableDeepAnalysis {
@@ -142,16 +577,315 @@ func (cw *ConfigWindow) Show(onFirstRunSubmit func(), onFirstRunCancel func()) {
 			return
 		}
 
+		parallelMoves, err := strconv.Atoi(strings.TrimSpace(parallelMovesEntry.Text))
+		if err != nil || parallelMoves < 1 {
+			dialog.ShowError(fmt.Errorf("parallel moves must be a whole number of at least 1"), configWin)
+			return
+		}
+		walkWorkers, err := strconv.Atoi(strings.TrimSpace(walkWorkersEntry.Text))
+		if err != nil || walkWorkers < 1 {
+			dialog.ShowError(fmt.Errorf("folders scanned at once must be a whole number of at least 1"), configWin)
+			return
+		}
+		walkBatchSize, err := strconv.Atoi(strings.TrimSpace(walkBatchSizeEntry.Text))
+		if err != nil || walkBatchSize < 1 {
+			dialog.ShowError(fmt.Errorf("folders read ahead must be a whole number of at least 1"), configWin)
+			return
+		}
+
+		throttleDelay, err := strconv.Atoi(strings.TrimSpace(throttleDelayEntry.Text))
+		if err != nil || throttleDelay < 1 {
+			dialog.ShowError(fmt.Errorf("the sync throttle pause must be a positive number of milliseconds"), configWin)
+			return
+		}
+		throttleBatch, err := strconv.Atoi(strings.TrimSpace(throttleBatchEntry.Text))
+		if err != nil || throttleBatch < 1 {
+			dialog.ShowError(fmt.Errorf("the sync throttle batch must be a whole number of at least 1"), configWin)
+			return
+		}
+
+		janitorInterval, err := strconv.Atoi(strings.TrimSpace(janitorIntervalEntry.Text))
+		if err != nil || janitorInterval < 0 {
+			dialog.ShowError(fmt.Errorf("index cleanup interval must be a whole number of hours (0 to disable)"), configWin)
+			return
+		}
+
+		snapshotInterval, err := strconv.Atoi(strings.TrimSpace(snapshotIntervalEntry.Text))
+		if err != nil || snapshotInterval < 0 {
+			dialog.ShowError(fmt.Errorf("snapshot interval must be a whole number of hours (0 to disable)"), configWin)
+			return
+		}
+
+		descriptionWords := 0
+		if text := strings.TrimSpace(descriptionWordsEntry.Text); text != "" {
+			descriptionWords, err = strconv.Atoi(text)
+			if err != nil || descriptionWords < 1 {
+				dialog.ShowError(fmt.Errorf("description length must be a positive number of words"), configWin)
+				return
+			}
+		}
+
+		var temperature *float64
+		if text := strings.TrimSpace(temperatureEntry.Text); text != "" {
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil || value < 0 || value > 2 {
+				dialog.ShowError(fmt.Errorf("temperature must be a number between 0 and 2"), configWin)
+				return
+			}
+			temperature = &value
+		}
+
+		maxTokens := 0
+		if text := strings.TrimSpace(maxTokensEntry.Text); text != "" {
+			maxTokens, err = strconv.Atoi(text)
+			if err != nil || maxTokens < 1 {
+				dialog.ShowError(fmt.Errorf("max output tokens must be a positive whole number"), configWin)
+				return
+			}
+		}
+
+		deepAnalysisConfirm := 0
+		if text := strings.TrimSpace(deepAnalysisConfirmEntry.Text); text != "" {
+			deepAnalysisConfirm, err = strconv.Atoi(text)
+			if err != nil || deepAnalysisConfirm < 0 {
+				dialog.ShowError(fmt.Errorf("the deep analysis confirmation threshold must be a whole number of files"), configWin)
+				return
+			}
+		}
+
+		imageMaxDimension, err := strconv.Atoi(strings.TrimSpace(imageMaxDimensionEntry.Text))
+		if err != nil || imageMaxDimension < 256 {
+			dialog.ShowError(fmt.Errorf("the image size limit must be a whole number of pixels, at least 256"), configWin)
+			return
+		}
+
+		hashSampleSize, err := strconv.Atoi(strings.TrimSpace(hashSampleSizeEntry.Text))
+		if err != nil || hashSampleSize < 1 {
+			dialog.ShowError(fmt.Errorf("the number of files to verify by content must be a positive whole number"), configWin)
+			return
+		}
+
+		pdfPageImageCount, err := strconv.Atoi(strings.TrimSpace(pdfPageImageCountEntry.Text))
+		if err != nil || pdfPageImageCount < 1 {
+			dialog.ShowError(fmt.Errorf("the number of PDF pages to send must be a positive whole number"), configWin)
+			return
+		}
+
+		locale := strings.TrimSpace(localeEntry.Text)
+		if locale != "" {
+			if _, err := app.ParseLocale(locale); err != nil {
+				dialog.ShowError(fmt.Errorf("locale must look like en-US or de_DE: %w", err), configWin)
+				return
+			}
+		}
+		transcriptionMaxSize, err := strconv.Atoi(strings.TrimSpace(transcriptionMaxSizeEntry.Text))
+		if err != nil || transcriptionMaxSize < 1 {
+			dialog.ShowError(fmt.Errorf("transcription size limit must be a whole number of megabytes"), configWin)
+			return
+		}
+		transcriptionMaxMinutes, err := strconv.Atoi(strings.TrimSpace(transcriptionMaxMinutesEntry.Text))
+		if err != nil || transcriptionMaxMinutes < 1 {
+			dialog.ShowError(fmt.Errorf("transcription length limit must be a whole number of minutes"), configWin)
+			return
+		}
+		if transcribeCheck.Checked && strings.TrimSpace(transcriptionEndpointEntry.Text) == "" {
+			dialog.ShowError(fmt.Errorf("enter a transcription endpoint to transcribe audio"), configWin)
+			return
+		}
+		if _, err := app.ParseAnalyzerPlugins(pluginsEntry.Text); err != nil {
+			dialog.ShowError(fmt.Errorf("analyzer plugins: %w", err), configWin)
+			return
+		}
+		if _, err := app.ParseFileTypeMappings(fileTypesEntry.Text); err != nil {
+			dialog.ShowError(fmt.Errorf("file types: %w", err), configWin)
+			return
+		}
+		if _, err := app.ParseFolderLabelRules(folderLabelRulesEntry.Text); err != nil {
+			dialog.ShowError(fmt.Errorf("folder labels: %w", err), configWin)
+			return
+		}
+		if _, err := app.ParseRateLimits(rateLimitsEntry.Text); err != nil {
+			dialog.ShowError(fmt.Errorf("rate limits: %w", err), configWin)
+			return
+		}
+		concurrentRequests, err := strconv.Atoi(strings.TrimSpace(concurrentRequestsEntry.Text))
+		if err != nil || concurrentRequests < 1 {
+			dialog.ShowError(fmt.Errorf("concurrent requests must be a positive whole number"), configWin)
+			return
+		}
+		var priceInput, priceOutput, runBudget, monthlyBudget float64
+		amounts := []struct {
+			name  string
+			entry *widget.Entry
+			dest  *float64
+		}{
+			{"Input price", priceInputEntry, &priceInput},
+			{"Output price", priceOutputEntry, &priceOutput},
+			{"Budget per run", runBudgetEntry, &runBudget},
+			{"Monthly budget", monthlyBudgetEntry, &monthlyBudget},
+		}
+		for _, amount := range amounts {
+			value, err := parseAmount(amount.entry.Text)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("%s: %w", amount.name, err), configWin)
+				return
+			}
+			*amount.dest = value
+		}
+		autoApplyConfidence, err := strconv.Atoi(strings.TrimSpace(autoApplyConfidenceEntry.Text))
+		if err != nil || autoApplyConfidence < 1 || autoApplyConfidence > 100 {
+			dialog.ShowError(fmt.Errorf("auto-apply confidence must be a percentage from 1 to 100"), configWin)
+			return
+		}
+		autoApplyMaxOps, err := strconv.Atoi(strings.TrimSpace(autoApplyMaxOpsEntry.Text))
+		if err != nil || autoApplyMaxOps < 1 {
+			dialog.ShowError(fmt.Errorf("auto-apply operation limit must be a positive whole number"), configWin)
+			return
+		}
+		if _, err := app.ParseScheduledJobs(scheduledJobsEntry.Text); err != nil {
+			dialog.ShowError(fmt.Errorf("scheduled jobs: %w", err), configWin)
+			return
+		}
+		scheduledJobInterval, err := strconv.Atoi(strings.TrimSpace(scheduledJobIntervalEntry.Text))
+		if err != nil || scheduledJobInterval < 0 {
+			dialog.ShowError(fmt.Errorf("scheduled job interval must be a whole number of hours (0 to disable)"), configWin)
+			return
+		}
+		if err := app.ValidateNotificationSettings(notifyWebhookEntry.Text, notifySMTPServerEntry.Text, notifyEmailFromEntry.Text, notifyEmailToEntry.Text); err != nil {
+			dialog.ShowError(fmt.Errorf("notifications: %w", err), configWin)
+			return
+		}
+		logging := make(map[string]app.LogSettings)
+		for _, subsystem := range app.LogSubsystems {
+			logging[subsystem] = app.LogSettings{
+				Level:   logLevelSelects[subsystem].Selected,
+				Console: logConsoleChecks[subsystem].Checked,
+				File:    logFileChecks[subsystem].Checked,
+			}
+		}
+		logFilePath := strings.TrimSpace(logFilePathEntry.Text)
+		// Last check before anything is applied: services read the config live, so a rejected save
+		// must leave it untouched
+		if err := cw.logger.Configure(logging, app.LogFilePath(&app.Config{LogFilePath: logFilePath}, cw.app.Storage().RootURI().Path())); err != nil {
+			dialog.ShowError(err, configWin)
+			return
+		}
+
+		// Prompts edited in this session now track the current defaults
+		for key, entry := range map[string]*widget.Entry{
+			"system_prompt":         systemPromptEntry,
+			"pdf_analysis_prompt":   pdfPromptEntry,
+			"text_analysis_prompt":  textPromptEntry,
+			"image_analysis_prompt": imagePromptEntry,
+		} {
+			if field, ok := cw.config.PromptField(key); ok && entry.Text != *field.Value {
+				cw.config.MarkPromptBaseline(key)
+			}
+		}
+
 		cw.config.Endpoint = endpointEntry.Text
 		cw.config.APIKey = apiKeyEntry.Text
 		cw.config.Model = modelEntry.Text
 		cw.config.SystemPrompt = systemPromptEntry.Text
+		cw.config.Temperature = temperature
+		cw.config.MaxTokens = maxTokens
+		cw.config.ExplainMoves = explainMovesCheck.Checked
+		cw.config.SelfCritique = selfCritiqueCheck.Checked
+		cw.config.IndexRules = indexRulesCheck.Checked
 		cw.config.PDFAnalysisPrompt = pdfPromptEntry.Text
 		cw.config.TextAnalysisPrompt = textPromptEntry.Text
 		cw.config.ImageAnalysisPrompt = imagePromptEntry.Text
 		cw.config.IndexDBPath = dbPathEntry.Text
+		cw.config.EncryptIndex = encryptIndexCheck.Checked
+		cw.config.AuditLogPath = strings.TrimSpace(auditLogPathEntry.Text)
+		cw.config.IndexJanitorIntervalHours = janitorInterval
+		cw.config.SnapshotFolders = strings.TrimSpace(snapshotFoldersEntry.Text)
+		cw.config.SnapshotIntervalHours = snapshotInterval
+		cw.config.IndexSyncDir = strings.TrimSpace(indexSyncDirEntry.Text)
+		cw.config.WatchedFolders = strings.TrimSpace(watchedFoldersEntry.Text)
+		cw.config.SidecarFormat = sidecarFormatOptions[sidecarFormatSelect.Selected]
+		cw.config.UpdateMode = updateModeOptions[updateModeSelect.Selected]
+		cw.config.FolderNamingStyle = namingStyleOptions[namingStyleSelect.Selected]
+		cw.config.NormalizeDatePrefixes = normalizeDatesCheck.Checked
+		cw.config.FolderEmojiPrefixes = folderEmojiCheck.Checked
+		cw.config.FolderColorLabels = folderColorsCheck.Checked
+		cw.config.ParallelMoves = parallelMoves
+		cw.config.WalkWorkers = walkWorkers
+		cw.config.WalkBatchSize = walkBatchSize
+		cw.config.SkipUnchangedFolders = skipUnchangedCheck.Checked
+		cw.config.ThrottleSyncedFolders = throttleSyncedCheck.Checked
+		cw.config.SyncThrottleDelayMs = throttleDelay
+		cw.config.SyncThrottleBatchSize = throttleBatch
+		cw.config.DownloadPlaceholders = downloadPlaceholdersCheck.Checked
+		cw.config.StagedExecution = stagedExecutionCheck.Checked
+		cw.config.CheckOpenFiles = checkOpenFilesCheck.Checked
+		cw.config.AuditPermissions = auditPermissionsCheck.Checked
+		cw.config.RewriteShortcuts = rewriteShortcutsCheck.Checked
+		cw.config.UpdateReferences = updateReferencesCheck.Checked
+		cw.config.SplitCompanions = !keepCompanionsCheck.Checked
+		cw.config.HashVerification = hashVerificationOptions[hashVerificationSelect.Selected]
+		cw.config.HashSampleSize = hashSampleSize
+		cw.config.StructureFormat = structureFormatOptions[structureFormatSelect.Selected]
+		cw.config.SkipScanSummary = !scanSummaryCheck.Checked
+		cw.config.PlanChangedFilesOnly = changedOnlyCheck.Checked
+		cw.config.DescriptionMaxWords = descriptionWords
+		cw.config.DeepAnalysisConfirmCalls = deepAnalysisConfirm
+		cw.config.ImageMaxDimension = imageMaxDimension
+		cw.config.PDFPageImages = pdfPageImagesOptions[pdfPageImagesSelect.Selected]
+		cw.config.PDFPageImageCount = pdfPageImageCount
+		cw.config.DescriptionLanguage = strings.TrimSpace(descriptionLanguageEntry.Text)
+		cw.config.Locale = locale
+		enabledTypes := make(map[string]bool)
+		for _, label := range analysisTypesCheck.Selected {
+			enabledTypes[analysisTypeByLabel[label]] = true
+		}
+		cw.config.DisabledAnalysisTypes = nil
+		for _, fileType := range app.AnalysisFileTypes {
+			if !enabledTypes[fileType] {
+				cw.config.DisabledAnalysisTypes = append(cw.config.DisabledAnalysisTypes, fileType)
+			}
+		}
+		cw.config.IgnoreEmbeddedDescriptions = ignoreEmbeddedCheck.Checked
+		cw.config.TranscribeAudio = transcribeCheck.Checked
+		cw.config.TranscriptionEndpoint = strings.TrimSpace(transcriptionEndpointEntry.Text)
+		cw.config.TranscriptionAPIKey = transcriptionAPIKeyEntry.Text
+		cw.config.TranscriptionModel = strings.TrimSpace(transcriptionModelEntry.Text)
+		cw.config.TranscriptionMaxSizeMB = transcriptionMaxSize
+		cw.config.TranscriptionMaxMinutes = transcriptionMaxMinutes
 		cw.config.IgnorePatterns = ignorePatternsEntry.Text
-		app.SaveConfig(cw.app, cw.config, cw.logger)
+		cw.config.IgnoreHiddenFiles = ignoreHiddenCheck.Checked
+		cw.config.AnalyzerPlugins = pluginsEntry.Text
+		cw.config.FileTypeMappings = fileTypesEntry.Text
+		cw.config.FolderLabelRules = folderLabelRulesEntry.Text
+		cw.config.LegacyConverterCommand = strings.TrimSpace(legacyConverterEntry.Text)
+		cw.config.RateLimits = rateLimitsEntry.Text
+		cw.config.MaxConcurrentRequests = concurrentRequests
+		cw.config.PriceInputPerMillion = priceInput
+		cw.config.PriceOutputPerMillion = priceOutput
+		cw.config.RunBudget = runBudget
+		cw.config.MonthlyBudget = monthlyBudget
+		cw.config.AutoApply = autoApplyCheck.Checked
+		cw.config.AutoApplyMinConfidence = float64(autoApplyConfidence) / 100
+		cw.config.AutoApplyMaxOperations = autoApplyMaxOps
+		cw.config.ScheduledJobs = strings.TrimSpace(scheduledJobsEntry.Text)
+		cw.config.ScheduledJobIntervalHours = scheduledJobInterval
+		cw.config.NotifyWebhookURL = strings.TrimSpace(notifyWebhookEntry.Text)
+		cw.config.NotifySMTPServer = strings.TrimSpace(notifySMTPServerEntry.Text)
+		cw.config.NotifySMTPUser = strings.TrimSpace(notifySMTPUserEntry.Text)
+		cw.config.NotifySMTPPassword = notifySMTPPasswordEntry.Text
+		cw.config.NotifyEmailFrom = strings.TrimSpace(notifyEmailFromEntry.Text)
+		cw.config.NotifyEmailTo = strings.TrimSpace(notifyEmailToEntry.Text)
+		cw.config.HookPreAnalysis = strings.TrimSpace(preAnalysisHookEntry.Text)
+		cw.config.HookPreExecute = strings.TrimSpace(preExecuteHookEntry.Text)
+		cw.config.HookPostExecute = strings.TrimSpace(postExecuteHookEntry.Text)
+		cw.config.S3Endpoint = strings.TrimSpace(s3EndpointEntry.Text)
+		cw.config.S3Region = strings.TrimSpace(s3RegionEntry.Text)
+		cw.config.S3AccessKey = strings.TrimSpace(s3AccessKeyEntry.Text)
+		cw.config.S3SecretKey = s3SecretKeyEntry.Text
+		cw.config.S3UsePathStyle = s3PathStyleCheck.Checked
+		cw.config.Logging = logging
+		cw.config.LogFilePath = logFilePath
+		saveConfig(cw.app, cw.config, cw.logger)
 
 		dialog.ShowInformation("Saved", "Configuration has been saved.", configWin)
 		configWin.Close()
@@ -172,39 +906,244 @@ func (cw *ConfigWindow) Show(onFirstRunSubmit func(), onFirstRunCancel func()) {
 	// Create General Settings tab
 	generalForm := &widget.Form{
 		Items: []*widget.FormItem{
+			{Text: "Preset", Widget: presetSelect},
+			{Text: "", Widget: presetDescription},
 			{Text: "Endpoint", Widget: endpointEntry},
 			{Text: "API Key", Widget: apiKeyEntry},
 			{Text: modelLabel, Widget: modelContainer},
 			{Text: "", Widget: verifyStatusLabel},
+			{Text: "Temperature", Widget: temperatureEntry},
+			{Text: "Max Output Tokens", Widget: maxTokensEntry},
 			{Text: "Index DB Path", Widget: dbPathEntry},
+			{Text: "", Widget: encryptIndexCheck},
+			{Text: "Audit Log Path", Widget: auditLogPathEntry},
+			{Text: "Index Cleanup (hours)", Widget: janitorIntervalEntry},
+			{Text: "Index Sync Folder", Widget: indexSyncDirEntry},
+			{Text: "Watched Folders", Widget: watchedFoldersEntry},
+			{Text: "Snapshot Folders", Widget: snapshotFoldersEntry},
+			{Text: "Snapshot Every (hours)", Widget: snapshotIntervalEntry},
+			{Text: "Sidecar Metadata", Widget: sidecarFormatSelect},
+			{Text: "Updates", Widget: updateModeSelect},
+			{Text: "Parallel Moves", Widget: parallelMovesEntry},
+			{Text: "Cloud Sync", Widget: throttleSyncedCheck},
+			{Text: "", Widget: throttleRow},
+			{Text: "", Widget: downloadPlaceholdersCheck},
+			{Text: "", Widget: stagedExecutionCheck},
+			{Text: "", Widget: checkOpenFilesCheck},
+			{Text: "", Widget: auditPermissionsCheck},
+			{Text: "", Widget: rewriteShortcutsCheck},
+			{Text: "", Widget: updateReferencesCheck},
+			{Text: "", Widget: keepCompanionsCheck},
+			{Text: "Verify Content", Widget: hashVerificationRow},
+			{Text: "Structure Format", Widget: structureFormatSelect},
+			{Text: "Scanning", Widget: walkRow},
+			{Text: "", Widget: skipUnchangedCheck},
+			{Text: "", Widget: scanSummaryCheck},
+			{Text: "", Widget: changedOnlyCheck},
+			{Text: "New Folder Names", Widget: namingStyleSelect},
+			{Text: "", Widget: normalizeDatesCheck},
+			{Text: "", Widget: folderEmojiCheck},
+			{Text: "", Widget: folderColorsCheck},
+			{Text: "Folder Labels", Widget: folderLabelRulesEntry},
+			{Text: "Description Max Words", Widget: descriptionWordsEntry},
+			{Text: "Ask Before Analyzing Over (files)", Widget: deepAnalysisConfirmEntry},
+			{Text: "Image Max Dimension (px)", Widget: imageMaxDimensionEntry},
+			{Text: "Description Language", Widget: descriptionLanguageEntry},
+			{Text: "Dates and Sizes", Widget: localeEntry},
 		},
 	}
 	generalTab := container.NewBorder(generalForm, nil, nil, nil)
 
 	// Create Organization Prompt tab
-	orgPromptLabel := widget.NewLabelWithStyle("System Prompt for File Organization:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	orgPromptLabel := cw.promptHeader("System Prompt for File Organization:", "system_prompt", systemPromptEntry, configWin)
 	orgPromptScroll := container.NewScroll(systemPromptEntry)
-	orgPromptTab := container.NewBorder(orgPromptLabel, nil, nil, nil, orgPromptScroll)
+	orgPromptTab := container.NewBorder(orgPromptLabel, container.NewVBox(explainMovesCheck, selfCritiqueCheck, indexRulesCheck), nil, nil, orgPromptScroll)
 
 	// Create PDF Analysis Prompt tab
-	pdfPromptLabel := widget.NewLabelWithStyle("System Prompt for PDF Analysis:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	pdfPromptLabel := cw.promptHeader("System Prompt for PDF Analysis:", "pdf_analysis_prompt", pdfPromptEntry, configWin)
 	pdfPromptScroll := container.NewScroll(pdfPromptEntry)
-	pdfPromptTab := container.NewBorder(pdfPromptLabel, nil, nil, nil, pdfPromptScroll)
+	pdfPageImagesForm := widget.NewForm(
+		widget.NewFormItem("Send Pages as Images", pdfPageImagesSelect),
+		widget.NewFormItem("Pages to Send", pdfPageImageCountEntry),
+	)
+	pdfPromptTab := container.NewBorder(pdfPromptLabel, pdfPageImagesForm, nil, nil, pdfPromptScroll)
 
 	// Create Text Analysis Prompt tab
-	textPromptLabel := widget.NewLabelWithStyle("System Prompt for Text/Document Analysis:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	textPromptLabel := cw.promptHeader("System Prompt for Text/Document Analysis:", "text_analysis_prompt", textPromptEntry, configWin)
 	textPromptScroll := container.NewScroll(textPromptEntry)
 	textPromptTab := container.NewBorder(textPromptLabel, nil, nil, nil, textPromptScroll)
 
 	// Create Image Analysis Prompt tab
-	imagePromptLabel := widget.NewLabelWithStyle("System Prompt for Image Analysis:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	imagePromptLabel := cw.promptHeader("System Prompt for Image Analysis:", "image_analysis_prompt", imagePromptEntry, configWin)
 	imagePromptScroll := container.NewScroll(imagePromptEntry)
 	imagePromptTab := container.NewBorder(imagePromptLabel, nil, nil, nil, imagePromptScroll)
 
+	// Create Analysis Types tab
+	analysisTypesLabel := widget.NewLabelWithStyle("Deep Analysis by File Type:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	analysisTypesHelp := widget.NewLabel("Only checked types are read and sent to the model during deep analysis. Unchecked types are skipped by indexing; descriptions already in the index are kept.")
+	analysisTypesHelp.Wrapping = fyne.TextWrapWord
+	analysisTypesTab := container.NewBorder(
+		container.NewVBox(analysisTypesLabel, analysisTypesHelp),
+		ignoreEmbeddedCheck, nil, nil,
+		container.NewScroll(analysisTypesCheck),
+	)
+
+	// Create File Types tab
+	fileTypesLabel := widget.NewLabelWithStyle("Extra File Extensions:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	var builtinTypes strings.Builder
+	builtinTypes.WriteString("Entries here take precedence over the built-in table. Files whose content clearly shows another type (e.g. a PNG named .txt) are still typed by content.\n\nBuilt in:")
+	for _, mapping := range app.BuiltinFileTypes() {
+		fmt.Fprintf(&builtinTypes, "\n%s: %s", mapping.FileType, strings.Join(mapping.Extensions, " "))
+	}
+	fmt.Fprintf(&builtinTypes, "\n\nTypes: %s", strings.Join(app.AnalysisFileTypes, ", "))
+	fileTypesHelp := widget.NewLabel(builtinTypes.String())
+	fileTypesHelp.Wrapping = fyne.TextWrapWord
+	fileTypesTab := container.NewBorder(
+		fileTypesLabel, nil, nil, nil,
+		container.NewVSplit(container.NewScroll(fileTypesEntry), container.NewScroll(fileTypesHelp)),
+	)
+
+	// Create Audio Transcription tab
+	transcriptionForm := &widget.Form{
+		Items: []*widget.FormItem{
+			{Text: "", Widget: transcribeCheck},
+			{Text: "Endpoint", Widget: transcriptionEndpointEntry},
+			{Text: "API Key", Widget: transcriptionAPIKeyEntry},
+			{Text: "Model", Widget: transcriptionModelEntry},
+			{Text: "Max Size (MB)", Widget: transcriptionMaxSizeEntry},
+			{Text: "Max Length (minutes)", Widget: transcriptionMaxMinutesEntry},
+		},
+	}
+	transcriptionHelp := widget.NewLabel("Voice memos and recordings are described from their transcript during deep analysis. Recordings over either limit, or all recordings while transcription is off, are described by name and size only. The length of non-WAV files is read with ffprobe when it is installed.")
+	transcriptionHelp.Wrapping = fyne.TextWrapWord
+	transcriptionTab := container.NewBorder(container.NewVBox(transcriptionForm, transcriptionHelp), nil, nil, nil)
+
 	// Create Ignore Patterns tab
 	ignorePatternsLabel := widget.NewLabelWithStyle("Ignore Patterns (one per line, similar to .gitignore):", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
 	ignorePatternsScroll := container.NewScroll(ignorePatternsEntry)
-	ignorePatternsTab := container.NewBorder(ignorePatternsLabel, nil, nil, nil, ignorePatternsScroll)
+	ignoreTesterEntry := widget.NewEntry()
+	ignoreTesterEntry.SetPlaceHolder("Sample path, e.g. src/build/app.log (end folders with /)")
+	ignoreTesterResult := widget.NewLabel("")
+	ignoreTesterResult.Wrapping = fyne.TextWrapWord
+	updateIgnoreTester := func() {
+		sample := strings.TrimSpace(ignoreTesterEntry.Text)
+		if sample == "" {
+			ignoreTesterResult.SetText("")
+			return
+		}
+		matcher := app.NewIgnorePatternMatcher(ignorePatternsEntry.Text, nil)
+		matcher.SetIgnoreHidden(ignoreHiddenCheck.Checked)
+		sample = strings.ReplaceAll(sample, `\`, "/")
+		isDir := strings.HasSuffix(sample, "/")
+		sample = strings.TrimRight(sample, "/")
+		ignoreTesterResult.SetText(matcher.Explain(sample, isDir).String())
+	}
+	ignoreTesterEntry.OnChanged = func(string) { updateIgnoreTester() }
+	ignorePatternsEntry.OnChanged = func(string) { updateIgnoreTester() }
+	ignoreHiddenCheck.OnChanged = func(bool) { updateIgnoreTester() }
+	ignoreTesterLabel := widget.NewLabelWithStyle("Test a path:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	ignoreTester := container.NewVBox(ignoreHiddenCheck, ignoreTesterLabel, ignoreTesterEntry, ignoreTesterResult)
+	ignorePatternsTab := container.NewBorder(ignorePatternsLabel, ignoreTester, nil, nil, ignorePatternsScroll)
+
+	// Create Analyzer Plugins tab
+	pluginsLabel := widget.NewLabelWithStyle("Analyzer Plugins (external commands for extra file formats):", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	pluginsHelp := widget.NewLabel(`Each command receives {"file_path", "file_name", "file_size"} as JSON on stdin and must print {"description": "..."} or {"content": "..."} to stdout. Returned content is summarized with the text analysis prompt.`)
+	pluginsHelp.Wrapping = fyne.TextWrapWord
+	legacyConverterLabel := widget.NewLabelWithStyle("Legacy .doc/.ppt Converter:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	legacyConverterHelp := widget.NewLabel("Optional. Placeholders: {input}, {outdir}, {format} (docx or pptx). With {outdir}, the converted file is analyzed; otherwise the command's output is used as text (e.g. antiword {input}).")
+	legacyConverterHelp.Wrapping = fyne.TextWrapWord
+	pluginsTab := container.NewBorder(
+		container.NewVBox(legacyConverterLabel, legacyConverterEntry, legacyConverterHelp, widget.NewSeparator(), pluginsLabel, pluginsHelp),
+		nil, nil, nil,
+		container.NewScroll(pluginsEntry),
+	)
+
+	// Create Usage Limits tab
+	rateLimitsLabel := widget.NewLabelWithStyle("Rate Limits (per provider, shared by planning and deep analysis):", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	rateLimitsHelp := widget.NewLabel("The host is taken from the endpoint URL. Use 0 or leave a value empty for no limit. Deep analysis leaves part of each budget free so organization requests are not held up by indexing.")
+	rateLimitsHelp.Wrapping = fyne.TextWrapWord
+	concurrencyForm := &widget.Form{
+		Items: []*widget.FormItem{
+			{Text: "Concurrent requests per provider", Widget: concurrentRequestsEntry},
+		},
+	}
+	concurrencyHelp := widget.NewLabel("Further requests wait in a queue where organization requests go ahead of deep analysis, and may use one extra slot.")
+	concurrencyHelp.Wrapping = fyne.TextWrapWord
+	budgetLabel := widget.NewLabelWithStyle("Spending Budget:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	budgetForm := &widget.Form{
+		Items: []*widget.FormItem{
+			{Text: "Input price ($ / 1M tokens)", Widget: priceInputEntry},
+			{Text: "Output price ($ / 1M tokens)", Widget: priceOutputEntry},
+			{Text: "Budget per run ($)", Widget: runBudgetEntry},
+			{Text: "Monthly budget ($)", Widget: monthlyBudgetEntry},
+		},
+	}
+	monthSpend := cw.httpClient.Budget().Spend()
+	budgetHelp := widget.NewLabel(fmt.Sprintf("Estimated spend this month: $%.4f. When the next request would exceed a budget, analysis pauses and asks before continuing. Set prices to enable tracking.", monthSpend))
+	budgetHelp.Wrapping = fyne.TextWrapWord
+	limitsTab := container.NewBorder(
+		container.NewVBox(budgetLabel, budgetForm, budgetHelp, widget.NewSeparator(), concurrencyForm, concurrencyHelp, rateLimitsLabel, rateLimitsHelp),
+		nil, nil, nil,
+		container.NewScroll(rateLimitsEntry),
+	)
+
+	// Create Automation tab
+	automationForm := &widget.Form{
+		Items: []*widget.FormItem{
+			{Text: "", Widget: autoApplyCheck},
+			{Text: "Minimum confidence (%)", Widget: autoApplyConfidenceEntry},
+			{Text: "Maximum operations", Widget: autoApplyMaxOpsEntry},
+			{Text: "Scheduled Jobs", Widget: scheduledJobsEntry},
+			{Text: "Run Every (hours)", Widget: scheduledJobIntervalEntry},
+		},
+	}
+	automationHelp := widget.NewLabel("Scheduled jobs organize their folder with their instructions while the app is running, and ask the model to rate each move. A plan is applied on its own only if every move meets the minimum confidence and it has no more moves than the maximum; otherwise it waits for your review.")
+	automationHelp.Wrapping = fyne.TextWrapWord
+	notificationsLabel := widget.NewLabelWithStyle("Run Summaries:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	notificationsForm := &widget.Form{
+		Items: []*widget.FormItem{
+			{Text: "Webhook URL", Widget: notifyWebhookEntry},
+			{Text: "SMTP server", Widget: notifySMTPServerEntry},
+			{Text: "SMTP user", Widget: notifySMTPUserEntry},
+			{Text: "SMTP password", Widget: notifySMTPPasswordEntry},
+			{Text: "Email from", Widget: notifyEmailFromEntry},
+			{Text: "Email to", Widget: notifyEmailToEntry},
+		},
+	}
+	notificationsHelp := widget.NewLabel("After every scheduled job run, its summary (what was moved, queued for review or failed) is posted as JSON to the webhook and emailed to the recipients.")
+	notificationsHelp.Wrapping = fyne.TextWrapWord
+	automationTab := container.NewBorder(container.NewVBox(automationForm, automationHelp, widget.NewSeparator(), notificationsLabel, notificationsForm, notificationsHelp), nil, nil, nil)
+
+	// Create Hooks tab
+	hooksForm := &widget.Form{
+		Items: []*widget.FormItem{
+			{Text: "Pre-analysis", Widget: preAnalysisHookEntry},
+			{Text: "Pre-execute", Widget: preExecuteHookEntry},
+			{Text: "Post-execute", Widget: postExecuteHookEntry},
+		},
+	}
+	hooksHelp := widget.NewLabel("Hooks receive the request, plan, or results as JSON on stdin. A pre-analysis or pre-execute hook that exits with a non-zero status blocks that step.")
+	hooksHelp.Wrapping = fyne.TextWrapWord
+	hooksTab := container.NewBorder(container.NewVBox(hooksForm, hooksHelp), nil, nil, nil)
+
+	// Create Object Storage tab
+	s3Form := &widget.Form{
+		Items: []*widget.FormItem{
+			{Text: "Endpoint", Widget: s3EndpointEntry},
+			{Text: "Region", Widget: s3RegionEntry},
+			{Text: "Access Key", Widget: s3AccessKeyEntry},
+			{Text: "Secret Key", Widget: s3SecretKeyEntry},
+			{Text: "", Widget: s3PathStyleCheck},
+		},
+	}
+	s3HelpLabel := widget.NewLabel("Enter a directory path like s3://bucket/prefix in the main window to organize objects in a bucket.")
+	s3HelpLabel.Wrapping = fyne.TextWrapWord
+	objectStorageTab := container.NewBorder(container.NewVBox(s3Form, s3HelpLabel), nil, nil, nil)
+
+	// Create Logging tab
+	loggingHelp := widget.NewLabel("Each part of the app logs at its own level: debug includes prompts and responses, info the steps taken, error only failures. Lines go to the console, the log file, or both.")
+	loggingHelp.Wrapping = fyne.TextWrapWord
+	loggingTab := container.NewBorder(container.NewVBox(loggingForm, loggingHelp), nil, nil, nil)
 
 	// Create tabs
 	tabs := container.NewAppTabs(
@@ -213,7 +1152,16 @@ func (cw *ConfigWindow) Show(onFirstRunSubmit func(), onFirstRunCancel func()) {
 		container.NewTabItem("PDF Analysis", pdfPromptTab),
 		container.NewTabItem("Text Analysis", textPromptTab),
 		container.NewTabItem("Image Analysis", imagePromptTab),
+		container.NewTabItem("Analysis Types", analysisTypesTab),
+		container.NewTabItem("File Types", fileTypesTab),
+		container.NewTabItem("Audio Transcription", transcriptionTab),
 		container.NewTabItem("Ignore Patterns", ignorePatternsTab),
+		container.NewTabItem("Analyzer Plugins", pluginsTab),
+		container.NewTabItem("Usage Limits", limitsTab),
+		container.NewTabItem("Automation", automationTab),
+		container.NewTabItem("Hooks", hooksTab),
+		container.NewTabItem("Object Storage", objectStorageTab),
+		container.NewTabItem("Logging", loggingTab),
 	)
 
 	buttonBar := container.NewHBox(saveBtn, cancelBtn)
@@ -234,3 +1182,126 @@ func (cw *ConfigWindow) Show(onFirstRunSubmit func(), onFirstRunCancel func()) {
 		configWin.Show()
 	}
 }
+
+// logSubsystemLabel names a logging subsystem for the configuration window
+func logSubsystemLabel(subsystem string) string {
+	switch subsystem {
+	case app.LogSubsystemApp:
+		return "Planning and General"
+	case app.LogSubsystemHTTP:
+		return "Model and Network Requests"
+	case app.LogSubsystemIndex:
+		return "Indexing"
+	case app.LogSubsystemExecution:
+		return "Moving Files"
+	case app.LogSubsystemUI:
+		return "User Interface"
+	default:
+		return subsystem
+	}
+}
+
+// analysisTypeLabel describes a deep analysis file type for the configuration window
+func analysisTypeLabel(fileType string) string {
+	switch fileType {
+	case "text":
+		return "Text and markup (.txt, .md, .json, .yaml...)"
+	case "code":
+		return "Source code (.go, .py, .js...)"
+	case "pdf":
+		return "PDF documents"
+	case "document":
+		return "Word processor documents (.docx, .odt, .rtf...)"
+	case "excel":
+		return "Spreadsheets (.xlsx, .ods...)"
+	case "csv":
+		return "Delimited data (.csv, .tsv)"
+	case "powerpoint":
+		return "Presentations (.pptx, .odp...)"
+	case "notebook":
+		return "Jupyter notebooks"
+	case "image":
+		return "Images"
+	case "video":
+		return "Videos (subtitle sidecars)"
+	case "audio":
+		return "Audio (transcription)"
+	case "other":
+		return "Other files (name and size only)"
+	default:
+		return fileType
+	}
+}
+
+// promptHeader shows a prompt's title with a "modified" badge that follows the entry, plus
+// buttons to compare it with or reset it to the shipped default
+func (cw *ConfigWindow) promptHeader(title, key string, entry *widget.Entry, win fyne.Window) fyne.CanvasObject {
+	field, _ := cw.config.PromptField(key)
+	defaultChanged := cw.config.DefaultChanged(field)
+
+	titleLabel := widget.NewLabelWithStyle(title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	badge := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Italic: true})
+	diffButton := widget.NewButton("Compare with Default", func() {
+		diff := widget.NewLabelWithStyle(app.FormatDiff(app.DiffLines(field.Default, entry.Text)), fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+		diff.Wrapping = fyne.TextWrapWord
+		legend := widget.NewLabel("Lines starting with - are only in the default, + only in your prompt.")
+		d := dialog.NewCustom("Changes from Default", "Close", container.NewBorder(legend, nil, nil, nil, container.NewScroll(diff)), win)
+		d.Resize(fyne.NewSize(750, 500))
+		d.Show()
+	})
+	resetButton := widget.NewButton("Reset to Default", func() {
+		dialog.ShowConfirm("Reset Prompt", "Replace this prompt with the default? Your version is discarded when you save.", func(ok bool) {
+			if ok {
+				entry.SetText(field.Default)
+			}
+		}, win)
+	})
+
+	update := func() {
+		modified := field.Modified(entry.Text)
+		switch {
+		case modified && defaultChanged:
+			badge.SetText("(modified - the default has changed since you edited it)")
+		case modified:
+			badge.SetText("(modified)")
+		default:
+			badge.SetText("")
+		}
+		if modified {
+			diffButton.Enable()
+			resetButton.Enable()
+		} else {
+			diffButton.Disable()
+			resetButton.Disable()
+		}
+	}
+	entry.OnChanged = func(string) { update() }
+	update()
+
+	return container.NewHBox(titleLabel, badge, layout.NewSpacer(), diffButton, resetButton)
+}
+
+// formatAmount shows zero as empty so the "No limit" placeholder is visible
+func formatAmount(value float64) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func parseAmount(text string) (float64, error) {
+	text = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), "$"))
+	if text == "" {
+		return 0, nil
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid amount %q", text)
+	}
+	return value, nil
+}
+
+// saveConfig writes config to the app's storage folder
+func saveConfig(a fyne.App, config *app.Config, logger *app.Logger) {
+	app.SaveConfig(a.Storage().RootURI().Path(), config, logger)
+}
@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -11,12 +12,33 @@ import (
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
 	"io.github.sandwichdoge.vibesandfolders/internal/app"
 )
 
+// indexedRange is a filter chip for when files were indexed; zero durations don't bound
+type indexedRange struct {
+	label     string
+	newerThan time.Duration
+	olderThan time.Duration
+}
+
+var indexedRanges = []indexedRange{
+	{"Last 24 hours", 24 * time.Hour, 0},
+	{"Last 7 days", 7 * 24 * time.Hour, 0},
+	{"Last 30 days", 30 * 24 * time.Hour, 0},
+	{"Older than 30 days", 0, 30 * 24 * time.Hour},
+}
+
+// Status filter chips
+var indexStatusChips = map[string]string{
+	"Described": app.IndexStatusDescribed,
+	"Failed":    app.IndexStatusFailed,
+}
+
 type IndexDetailsWindow struct {
 	app          fyne.App
 	window       fyne.Window
@@ -29,9 +51,24 @@ type IndexDetailsWindow struct {
 	statusLabel   *widget.Label
 	statsLabel    *widget.Label
 	searchEntry   *widget.Entry
+	progressBar   *widget.ProgressBar
+	typeChips     *fyne.Container
+
+	filterTypes   map[string]bool // File types to list; none lists all
+	filterIndexed string          // Label of the chosen indexedRange
+	filterStatus  string          // One of the app.IndexStatus values
+	loadSeq       int             // Ignores results of loads overtaken by a newer one
+	totalFiles    int
+
+	selectShownBtn    *widget.Button
+	clearSelectionBtn *widget.Button
+	reanalyzeBtn      *widget.Button
+	exportBtn         *widget.Button
 
 	allFiles      []app.IndexedFile
 	filteredFiles []app.IndexedFile
+	selected      map[string]bool // File paths chosen for re-analysis
+	reanalyzing   bool
 }
 
 func NewIndexDetailsWindow(fyneApp fyne.App, orchestrator *app.Orchestrator, logger *app.Logger, dirPath string) *IndexDetailsWindow {
@@ -41,6 +78,8 @@ func NewIndexDetailsWindow(fyneApp fyne.App, orchestrator *app.Orchestrator, log
 		orchestrator: orchestrator,
 		logger:       logger,
 		dirPath:      dirPath,
+		selected:     make(map[string]bool),
+		filterTypes:  make(map[string]bool),
 	}
 
 	idw.initializeComponents()
@@ -62,18 +101,61 @@ func (idw *IndexDetailsWindow) initializeComponents() {
 
 	idw.listContainer = container.NewVBox()
 	idw.scrollContent = container.NewScroll(idw.listContainer)
+
+	idw.typeChips = container.NewHBox()
+
+	idw.progressBar = widget.NewProgressBar()
+	idw.progressBar.Hide()
+
+	idw.selectShownBtn = widget.NewButton("Select Shown", func() {
+		for _, file := range idw.filteredFiles {
+			idw.selected[file.FilePath] = true
+		}
+		idw.renderFiles()
+		idw.updateSelection()
+	})
+	idw.clearSelectionBtn = widget.NewButton("Clear Selection", func() {
+		idw.selected = make(map[string]bool)
+		idw.renderFiles()
+		idw.updateSelection()
+	})
+	idw.reanalyzeBtn = widget.NewButtonWithIcon("Re-analyze Selected", theme.ViewRefreshIcon(), func() {
+		idw.reanalyzeSelected()
+	})
+	idw.reanalyzeBtn.Importance = widget.HighImportance
+	idw.exportBtn = widget.NewButtonWithIcon("Export Shown...", theme.DocumentSaveIcon(), func() {
+		idw.exportShown()
+	})
+	idw.updateSelection()
 }
 
 func (idw *IndexDetailsWindow) setupLayout() {
+	rangeLabels := make([]string, len(indexedRanges))
+	for i, r := range indexedRanges {
+		rangeLabels[i] = r.label
+	}
+	rangeChips := newChoiceChips(rangeLabels, func(selected string) {
+		idw.filterIndexed = selected
+		idw.loadData()
+	})
+	statusChips := newChoiceChips([]string{"Described", "Failed"}, func(selected string) {
+		idw.filterStatus = indexStatusChips[selected]
+		idw.loadData()
+	})
+
 	content := container.NewBorder(
 		container.NewVBox(
 			widget.NewLabel("Indexed Files for: " + idw.dirPath),
 			idw.statsLabel,
 			idw.searchEntry,
+			container.NewBorder(nil, nil, widget.NewLabel("Type:"), nil, container.NewHScroll(idw.typeChips)),
+			container.NewHBox(widget.NewLabel("Indexed:"), rangeChips, widget.NewLabel("Status:"), statusChips),
+			container.NewHBox(idw.selectShownBtn, idw.clearSelectionBtn, layout.NewSpacer(), idw.exportBtn, idw.reanalyzeBtn),
 			widget.NewSeparator(),
 		),
 		container.NewVBox(
 			widget.NewSeparator(),
+			idw.progressBar,
 			idw.statusLabel,
 		),
 		nil, nil,
@@ -84,14 +166,48 @@ func (idw *IndexDetailsWindow) setupLayout() {
 	idw.window.Resize(fyne.NewSize(1000, 600))
 }
 
+// currentQuery returns the index query for the chosen filter chips
+func (idw *IndexDetailsWindow) currentQuery() app.IndexQuery {
+	query := app.IndexQuery{DirPath: idw.dirPath, Status: idw.filterStatus}
+	for _, fileType := range app.AnalysisFileTypes {
+		if idw.filterTypes[fileType] {
+			query.FileTypes = append(query.FileTypes, fileType)
+		}
+	}
+	for _, r := range indexedRanges {
+		if r.label != idw.filterIndexed {
+			continue
+		}
+		if r.newerThan > 0 {
+			query.IndexedAfter = time.Now().Add(-r.newerThan)
+		}
+		if r.olderThan > 0 {
+			query.IndexedBefore = time.Now().Add(-r.olderThan)
+		}
+	}
+	return query
+}
+
+// loadData lists the files matching the filter chips. The database does the filtering; the search
+// box then narrows the result in memory, since descriptions may be stored encrypted.
 func (idw *IndexDetailsWindow) loadData() {
 	idw.statusLabel.SetText("Loading indexed files...")
 	idw.statsLabel.SetText("Loading statistics...")
 
+	idw.loadSeq++
+	seq := idw.loadSeq
+	query := idw.currentQuery()
 	go func() {
-		files, err := idw.orchestrator.GetIndexedFiles(idw.dirPath)
+		files, err := idw.orchestrator.QueryIndexedFiles(query)
+		var counts map[string]int
+		if err == nil {
+			counts, err = idw.orchestrator.CountIndexedFileTypes(idw.dirPath)
+		}
 
 		fyne.Do(func() {
+			if seq != idw.loadSeq {
+				return
+			}
 			if err != nil {
 				idw.logger.Error("Failed to load indexed files: %v", err)
 				dialog.ShowError(fmt.Errorf("failed to load indexed files: %w", err), idw.window)
@@ -101,19 +217,44 @@ func (idw *IndexDetailsWindow) loadData() {
 			}
 
 			idw.allFiles = files
-			idw.filteredFiles = files
-			idw.updateStats()
-			idw.renderFiles()
 
-			if len(files) == 0 {
-				idw.statusLabel.SetText("No indexed files found")
-			} else {
-				idw.statusLabel.SetText(fmt.Sprintf("Showing %d indexed files", len(files)))
+			// Forget selected entries that are no longer listed
+			for path := range idw.selected {
+				if !slices.ContainsFunc(files, func(f app.IndexedFile) bool { return f.FilePath == path }) {
+					delete(idw.selected, path)
+				}
 			}
+			idw.updateSelection()
+			idw.updateStats(counts)
+			idw.renderTypeChips(counts)
+			idw.filterData(idw.searchEntry.Text)
 		})
 	}()
 }
 
+// renderTypeChips shows a chip for each file type in the directory
+func (idw *IndexDetailsWindow) renderTypeChips(counts map[string]int) {
+	idw.typeChips.Objects = nil
+	for _, fileType := range app.AnalysisFileTypes {
+		if counts[fileType] == 0 && !idw.filterTypes[fileType] {
+			continue
+		}
+		chip := widget.NewButton(fmt.Sprintf("%s (%d)", fileType, counts[fileType]), nil)
+		chip.OnTapped = func() {
+			if idw.filterTypes[fileType] {
+				delete(idw.filterTypes, fileType)
+			} else {
+				idw.filterTypes[fileType] = true
+			}
+			setChipState(chip, idw.filterTypes[fileType])
+			idw.loadData()
+		}
+		setChipState(chip, idw.filterTypes[fileType])
+		idw.typeChips.Add(chip)
+	}
+	idw.typeChips.Refresh()
+}
+
 func (idw *IndexDetailsWindow) filterData(query string) {
 	if query == "" {
 		idw.filteredFiles = idw.allFiles
@@ -144,7 +285,11 @@ func (idw *IndexDetailsWindow) filterData(query string) {
 	}
 
 	idw.renderFiles()
-	idw.statusLabel.SetText(fmt.Sprintf("Showing %d of %d indexed files", len(idw.filteredFiles), len(idw.allFiles)))
+	if idw.totalFiles == 0 {
+		idw.statusLabel.SetText("No indexed files found")
+		return
+	}
+	idw.statusLabel.SetText(fmt.Sprintf("Showing %d of %d indexed files", len(idw.filteredFiles), idw.totalFiles))
 }
 
 func (idw *IndexDetailsWindow) renderFiles() {
@@ -181,6 +326,16 @@ func (idw *IndexDetailsWindow) createFileCard(file app.IndexedFile) fyne.CanvasO
 	// Description label (with wrapping)
 	descLabel := widget.NewLabel(file.Description)
 	descLabel.Wrapping = fyne.TextWrapWord
+	if file.Description == "" {
+		descLabel.SetText("(no description)")
+		descLabel.Importance = widget.LowImportance
+	}
+
+	// Why the last analysis failed, if it did
+	errorLabel := widget.NewLabel("Last analysis failed: " + file.AnalysisError)
+	errorLabel.Wrapping = fyne.TextWrapWord
+	errorLabel.Importance = widget.DangerImportance
+	errorLabel.Hidden = file.AnalysisError == ""
 
 	// Create metadata line
 	metaText := fmt.Sprintf("Type: %s  |  Size: %s  |  Modified: %s  |  Indexed: %s",
@@ -192,6 +347,18 @@ func (idw *IndexDetailsWindow) createFileCard(file app.IndexedFile) fyne.CanvasO
 	metaLabel := widget.NewLabel(metaText)
 	metaLabel.TextStyle = fyne.TextStyle{Italic: true}
 
+	// Selects the file for re-analysis
+	selectCheck := widget.NewCheck("", nil)
+	selectCheck.SetChecked(idw.selected[file.FilePath])
+	selectCheck.OnChanged = func(checked bool) {
+		if checked {
+			idw.selected[file.FilePath] = true
+		} else {
+			delete(idw.selected, file.FilePath)
+		}
+		idw.updateSelection()
+	}
+
 	// Create delete button
 	deleteBtn := widget.NewButton("Delete", func() {
 		idw.deleteEntry(file)
@@ -202,12 +369,13 @@ func (idw *IndexDetailsWindow) createFileCard(file app.IndexedFile) fyne.CanvasO
 	separator := canvas.NewLine(theme.ShadowColor())
 	separator.StrokeWidth = 1
 
-	// Assemble the card with delete button at the right
-	topRow := container.NewBorder(nil, nil, nil, deleteBtn, pathLabel)
+	// Assemble the card with the selection box at the left and delete button at the right
+	topRow := container.NewBorder(nil, nil, selectCheck, deleteBtn, pathLabel)
 
 	cardContent := container.NewVBox(
 		topRow,
 		descLabel,
+		errorLabel,
 		layout.NewSpacer(),
 		metaLabel,
 		separator,
@@ -254,58 +422,191 @@ func (idw *IndexDetailsWindow) deleteEntry(file app.IndexedFile) {
 	)
 }
 
-func (idw *IndexDetailsWindow) updateStats() {
-	if len(idw.allFiles) == 0 {
-		idw.statsLabel.SetText("No indexed files")
-		return
+// exportShown saves the files currently listed as CSV, or as JSON if the file name ends in .json
+func (idw *IndexDetailsWindow) exportShown() {
+	files := idw.filteredFiles
+	d := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, idw.window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if err := app.ExportIndexedFiles(writer, files, writer.URI().Name()); err != nil {
+			dialog.ShowError(fmt.Errorf("failed to export index: %w", err), idw.window)
+			return
+		}
+		idw.logger.Info("Exported %d indexed files to %s", len(files), writer.URI().Path())
+		idw.statusLabel.SetText(fmt.Sprintf("Exported %d files to %s", len(files), writer.URI().Path()))
+	}, idw.window)
+	d.SetFileName(fmt.Sprintf("index-%s-%s.csv", filepath.Base(idw.dirPath), time.Now().Format("2006-01-02")))
+	d.SetFilter(storage.NewExtensionFileFilter([]string{".csv", ".json"}))
+	d.Show()
+}
+
+// updateSelection refreshes the selection buttons for the number of selected files
+func (idw *IndexDetailsWindow) updateSelection() {
+	idw.reanalyzeBtn.SetText(fmt.Sprintf("Re-analyze Selected (%d)", len(idw.selected)))
+	if idw.reanalyzing || len(idw.selected) == 0 {
+		idw.reanalyzeBtn.Disable()
+	} else {
+		idw.reanalyzeBtn.Enable()
+	}
+	if idw.reanalyzing {
+		idw.selectShownBtn.Disable()
+		idw.clearSelectionBtn.Disable()
+	} else {
+		idw.selectShownBtn.Enable()
+		idw.clearSelectionBtn.Enable()
 	}
+}
 
-	// Count by file type
-	typeCount := make(map[string]int)
+// reanalyzeSelected asks the model for fresh descriptions of the selected files, even if they
+// haven't changed since they were indexed
+func (idw *IndexDetailsWindow) reanalyzeSelected() {
+	var files []string
 	for _, file := range idw.allFiles {
-		typeCount[file.FileType]++
+		if idw.selected[file.FilePath] {
+			files = append(files, file.FilePath)
+		}
+	}
+	if len(files) == 0 {
+		return
 	}
 
-	// Format the statistics text
-	statsText := fmt.Sprintf("Total: %d files", len(idw.allFiles))
-
-	// Add breakdown by type if there are multiple types
-	if len(typeCount) > 1 {
-		statsText += " ("
-		first := true
-		for fileType, count := range typeCount {
-			if !first {
-				statsText += ", "
+	dialog.ShowConfirm(
+		"Re-analyze Files",
+		fmt.Sprintf("Send %d selected files to the model for fresh descriptions?\n\nTheir current descriptions are replaced even if the files haven't changed.", len(files)),
+		func(confirmed bool) {
+			if !confirmed {
+				return
 			}
-			statsText += fmt.Sprintf("%s: %d", fileType, count)
-			first = false
+
+			idw.reanalyzing = true
+			idw.updateSelection()
+			idw.progressBar.SetValue(0)
+			idw.progressBar.Show()
+
+			go func() {
+				failed, err := idw.orchestrator.ReanalyzeFiles(files, func(current, total int, fileName string) {
+					fyne.Do(func() {
+						idw.progressBar.SetValue(float64(current-1) / float64(total))
+						idw.statusLabel.SetText(fmt.Sprintf("Re-analyzing %d of %d: %s", current, total, filepath.Base(fileName)))
+					})
+				})
+
+				fyne.Do(func() {
+					idw.reanalyzing = false
+					idw.progressBar.Hide()
+
+					// Failed files stay selected so they can be retried
+					idw.selected = make(map[string]bool)
+					for path := range failed {
+						idw.selected[path] = true
+					}
+					idw.loadData()
+
+					if err != nil {
+						idw.logger.Error("Re-analysis stopped: %v", err)
+						dialog.ShowError(err, idw.window)
+						return
+					}
+					idw.showReanalysisResult(len(files), failed)
+				})
+			}()
+		},
+		idw.window,
+	)
+}
+
+// showReanalysisResult summarizes a finished re-analysis, listing the files that failed
+func (idw *IndexDetailsWindow) showReanalysisResult(total int, failed map[string]error) {
+	if len(failed) == 0 {
+		dialog.ShowInformation("Re-analysis Complete", fmt.Sprintf("Updated the descriptions of %d files.", total), idw.window)
+		return
+	}
+
+	paths := make([]string, 0, len(failed))
+	for path := range failed {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "Updated %d of %d files. These kept their old descriptions and are still selected:\n\n", total-len(failed), total)
+	for i, path := range paths {
+		if i == 10 {
+			fmt.Fprintf(&msg, "...and %d more\n", len(paths)-i)
+			break
 		}
-		statsText += ")"
+		relPath, err := filepath.Rel(idw.dirPath, path)
+		if err != nil {
+			relPath = path
+		}
+		fmt.Fprintf(&msg, "%s: %v\n", relPath, failed[path])
 	}
+	dialog.ShowInformation("Re-analysis Finished with Errors", msg.String(), idw.window)
+}
 
-	idw.statsLabel.SetText(statsText)
+// updateStats shows the number of files in the directory; the type chips break it down
+func (idw *IndexDetailsWindow) updateStats(counts map[string]int) {
+	idw.totalFiles = 0
+	for _, count := range counts {
+		idw.totalFiles += count
+	}
+	if idw.totalFiles == 0 {
+		idw.statsLabel.SetText("No indexed files")
+		return
+	}
+	idw.statsLabel.SetText(fmt.Sprintf("Total: %d files", idw.totalFiles))
+}
+
+// newChoiceChips returns a row of filter chips of which at most one is on; tapping the chip that
+// is on turns it off. onChange gets the label of the chip that is on, or "" for none.
+func newChoiceChips(labels []string, onChange func(selected string)) *fyne.Container {
+	row := container.NewHBox()
+	chips := make([]*widget.Button, len(labels))
+	selected := ""
+	for i, label := range labels {
+		chips[i] = widget.NewButton(label, func() {
+			if selected == label {
+				selected = ""
+			} else {
+				selected = label
+			}
+			for j, chip := range chips {
+				setChipState(chip, labels[j] == selected)
+			}
+			onChange(selected)
+		})
+		setChipState(chips[i], false)
+		row.Add(chips[i])
+	}
+	return row
+}
+
+// setChipState highlights a filter chip that is on
+func setChipState(chip *widget.Button, on bool) {
+	chip.Importance = widget.LowImportance
+	if on {
+		chip.Importance = widget.HighImportance
+	}
+	chip.Refresh()
 }
 
 func (idw *IndexDetailsWindow) Show() {
 	idw.window.Show()
 }
 
-// formatFileSize formats bytes into human-readable format
+// formatFileSize formats bytes into human-readable format for the configured locale
 func formatFileSize(bytes int64) string {
-	const unit = 1024
-	if bytes < unit {
-		return fmt.Sprintf("%d B", bytes)
-	}
-	div, exp := int64(unit), 0
-	for n := bytes / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
-	}
-	units := []string{"KB", "MB", "GB", "TB"}
-	return fmt.Sprintf("%.1f %s", float64(bytes)/float64(div), units[exp])
+	return app.FormatSize(bytes)
 }
 
-// formatTimestamp formats time into a readable format
+// formatTimestamp formats time into a readable format for the configured locale
 func formatTimestamp(t time.Time) string {
-	return t.Format("2006-01-02 15:04:05")
+	return app.FormatTimestamp(t)
 }
@@ -1,15 +1,20 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os"
+	"net/url"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
 	"io.github.sandwichdoge.vibesandfolders/internal/app"
@@ -20,6 +25,12 @@ const (
 	defaultWindowHeight = 700
 	outputTextRows      = 15
 	promptTextRows      = 3
+	maxPathCompletions  = 30
+	maxLayoutDiffLines  = 20
+
+	manualChangesHeader = "\n=== Changed by Hand ===\n"
+
+	executeBtnText = "✓ Execute These Operations"
 )
 
 type MainWindow struct {
@@ -29,11 +40,15 @@ type MainWindow struct {
 	config       *app.Config
 	logger       *app.Logger
 	httpClient   *app.HTTPClient
+	updates      *app.UpdateChecker
+
+	syncThrottleOffered bool // Throttling was suggested for a cloud-synced folder this session
 
 	dirEntry          *widget.Entry
 	promptEntry       *widget.Entry
 	depthSelect       *widget.Select
 	cleanCheck        *widget.Check
+	newFilesOnlyCheck *widget.Check
 	deepAnalysisCheck *widget.Check
 	viewIndexBtn      *widget.Button
 	deleteIndexBtn    *widget.Button
@@ -42,13 +57,32 @@ type MainWindow struct {
 	statusLabel       *widget.Label
 	progressBar       *widget.ProgressBarInfinite
 	executeBtn        *widget.Button
+	pauseBtn          *widget.Button
+	stopBtn           *widget.Button
+	executionControls *fyne.Container
 	analyzeBtn        *widget.Button
 	rollbackBtn       *widget.Button
 	bottomStatus      *fyne.Container
 
+	planList         *widget.List
+	planPanel        *fyne.Container
+	planFilterEntry  *widget.Entry
+	planCountLabel   *widget.Label
+	addOperationForm *fyne.Container
+	addFromEntry     *widget.SelectEntry
+	addToEntry       *widget.SelectEntry
+
 	lastOutputContent     string
 	currentOperations     []app.FileOperation
+	currentPlannedAt      uint64
+	currentPlanCreatedAt  time.Time
+	currentGrounding      *app.StructureGrounding   // Paths of the last scan, for adding operations by hand
+	currentFingerprint    *app.DirectoryFingerprint // The folder when the plan was made, to detect stale plans
+	planView              []int                     // Indices into currentOperations that the plan list shows
 	lastSuccessfulResults []app.OperationResult
+	stoppedResults        []app.OperationResult // Successes of a stopped run; the next execution resumes it
+	executionControl      *app.ExecutionControl
+	shutdown              *app.ShutdownCoordinator
 }
 
 func NewMainWindow(fyneApp fyne.App, orchestrator *app.Orchestrator, config *app.Config, logger *app.Logger, httpClient *app.HTTPClient) *MainWindow {
@@ -65,12 +99,14 @@ func NewMainWindow(fyneApp fyne.App, orchestrator *app.Orchestrator, config *app
 	mw.setupLayout()
 	mw.setupMenu()
 
+	httpClient.Budget().SetConfirmHandler(mw.promptBudget)
+
 	return mw
 }
 
 func (mw *MainWindow) initializeComponents() {
 	mw.dirEntry = widget.NewEntry()
-	mw.dirEntry.SetPlaceHolder("Enter directory path (e.g., /home/user/Documents)")
+	mw.dirEntry.SetPlaceHolder("Enter directory path (e.g., /home/user/Documents or s3://bucket/prefix)")
 
 	mw.promptEntry = widget.NewMultiLineEntry()
 	mw.promptEntry.SetPlaceHolder("Enter your organization instructions (e.g., 'Organize by file type into folders')")
@@ -82,15 +118,22 @@ func (mw *MainWindow) initializeComponents() {
 	mw.cleanCheck = widget.NewCheck("Clean-up empty directories after execution", nil)
 	mw.cleanCheck.SetChecked(true)
 
+	mw.newFilesOnlyCheck = widget.NewCheck("Organize only files added or changed since the last execution", func(checked bool) {
+		mw.config.NewFilesOnly = checked
+		saveConfig(mw.app, mw.config, mw.logger)
+	})
+	mw.newFilesOnlyCheck.SetChecked(mw.config.NewFilesOnly)
+
 	mw.viewIndexBtn = widget.NewButton("View Index", mw.onViewIndexDetails)
 	mw.deleteIndexBtn = widget.NewButton("Clear Index", mw.onDeleteIndex)
+	syncIndexBtn := widget.NewButton("Sync Index", mw.onSyncIndex)
 
-	mw.indexDetailsBox = container.NewHBox(mw.viewIndexBtn, mw.deleteIndexBtn)
+	mw.indexDetailsBox = container.NewHBox(mw.viewIndexBtn, mw.deleteIndexBtn, syncIndexBtn)
 	mw.indexDetailsBox.Hidden = !mw.config.EnableDeepAnalysis
 
 	mw.deepAnalysisCheck = widget.NewCheck("Enable Deep Analysis (PDFs, images, docs, sheets, slides content indexing)", func(checked bool) {
 		mw.config.EnableDeepAnalysis = checked
-		app.SaveConfig(mw.app, mw.config, mw.logger)
+		saveConfig(mw.app, mw.config, mw.logger)
 		mw.updateIndexDetailsVisibility()
 	})
 	mw.deepAnalysisCheck.SetChecked(mw.config.EnableDeepAnalysis)
@@ -109,34 +152,73 @@ func (mw *MainWindow) initializeComponents() {
 	mw.progressBar = widget.NewProgressBarInfinite()
 	mw.progressBar.Hide()
 
-	mw.executeBtn = widget.NewButton("✓ Execute These Operations", mw.onExecute)
+	mw.executeBtn = widget.NewButton(executeBtnText, mw.onExecute)
 	mw.executeBtn.Hide()
 
+	mw.pauseBtn = widget.NewButton("⏸ Pause", mw.onPauseExecution)
+	mw.stopBtn = widget.NewButton("⏹ Stop", mw.onStopExecution)
+	mw.stopBtn.Importance = widget.DangerImportance
+	mw.executionControls = container.NewGridWithColumns(2, mw.pauseBtn, mw.stopBtn)
+	mw.executionControls.Hide()
+
 	mw.rollbackBtn = widget.NewButton("↶ Undo Changes (Rollback)", mw.onRollback)
 	mw.rollbackBtn.Importance = widget.DangerImportance
 	mw.rollbackBtn.Hide()
 
 	mw.analyzeBtn = widget.NewButton("Analyze & Get AI Suggestions", mw.onAnalyze)
+
+	mw.addFromEntry = widget.NewSelectEntry(nil)
+	mw.addFromEntry.SetPlaceHolder("File or folder to move")
+	mw.addFromEntry.OnChanged = func(text string) {
+		mw.completePath(mw.addFromEntry, text, false)
+	}
+	mw.addToEntry = widget.NewSelectEntry(nil)
+	mw.addToEntry.SetPlaceHolder("Destination (end with / to keep the name)")
+	mw.addToEntry.OnChanged = func(text string) {
+		mw.completeDestination(mw.addToEntry, text, -1)
+	}
+	addBtn := widget.NewButton("Add", mw.onAddOperation)
+	mw.addOperationForm = container.NewBorder(nil, nil, widget.NewLabel("Add operation:"), addBtn,
+		container.NewGridWithColumns(2, mw.addFromEntry, mw.addToEntry))
+
+	mw.planList = mw.newPlanList()
+	mw.planFilterEntry = widget.NewEntry()
+	mw.planFilterEntry.SetPlaceHolder("Filter, e.g. *.pdf or to:Taxes (double-click an operation to change its destination)")
+	mw.planFilterEntry.OnChanged = func(string) {
+		mw.refreshPlanView()
+	}
+	mw.planCountLabel = widget.NewLabel("")
+	mw.planPanel = container.NewBorder(container.NewBorder(nil, nil, nil, mw.planCountLabel, mw.planFilterEntry), nil, nil, nil, mw.planList)
+	mw.setPlanEditable(false)
 }
 
 func (mw *MainWindow) setupLayout() {
-	browseBtn := widget.NewButton("Browse", func() {
-		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
-			if err != nil || uri == nil {
-				return
-			}
-			mw.dirEntry.SetText(uri.Path())
-		}, mw.window)
-	})
+	browseBtn := widget.NewButton("Browse", mw.onBrowse)
+	recentBtn := widget.NewButtonWithIcon("", theme.HistoryIcon(), nil)
+	recentBtn.OnTapped = func() {
+		mw.showRecentFolders(recentBtn)
+	}
+
+	// Shortcuts to the usual places, since the folder dialog only remembers where it was last
+	shortcuts := container.NewHBox()
+	for _, shortcut := range app.FolderShortcuts() {
+		btn := widget.NewButton(shortcut.Name, func() {
+			mw.selectFolder(shortcut.Path)
+		})
+		btn.Importance = widget.LowImportance
+		shortcuts.Add(btn)
+	}
 
 	topInputs := container.NewVBox(
 		widget.NewLabel("Directory Path:"),
-		container.NewBorder(nil, nil, nil, browseBtn, mw.dirEntry),
+		container.NewBorder(nil, nil, nil, container.NewHBox(recentBtn, browseBtn), mw.dirEntry),
+		shortcuts,
 		widget.NewLabel("What to do with this directory:"),
 		mw.promptEntry,
 		container.NewVBox(
 			container.NewHBox(widget.NewLabel("Scan Depth:"), mw.depthSelect),
 			mw.cleanCheck,
+			mw.newFilesOnlyCheck,
 			mw.deepAnalysisCheck,
 			mw.indexDetailsBox,
 		),
@@ -148,12 +230,14 @@ func (mw *MainWindow) setupLayout() {
 	mw.bottomStatus = container.NewVBox(
 		mw.progressBar,
 		mw.statusLabel,
+		mw.addOperationForm,
 		mw.executeBtn,
+		mw.executionControls,
 		mw.rollbackBtn,
 	)
 
 	mw.window.SetContent(container.NewPadded(
-		container.NewBorder(topInputs, mw.bottomStatus, nil, nil, mw.outputText),
+		container.NewBorder(topInputs, mw.bottomStatus, nil, nil, container.NewVSplit(mw.outputText, mw.planPanel)),
 	))
 	mw.window.Resize(fyne.NewSize(defaultWindowWidth, defaultWindowHeight))
 }
@@ -164,12 +248,384 @@ func (mw *MainWindow) setupMenu() {
 			configWindow := NewConfigWindow(mw.app, mw.config, mw.logger, mw.httpClient)
 			configWindow.Show(nil, nil)
 		}),
+		fyne.NewMenuItem("Export Audit Log...", mw.onExportAuditLog),
+		fyne.NewMenuItem("Purge Data About...", mw.onPurgeData),
+		fyne.NewMenuItem("Usage Statistics", mw.onShowUsageStats),
+		fyne.NewMenuItem("Check for Updates", func() { go mw.checkForUpdates(true) }),
 		fyne.NewMenuItem("About", mw.showAboutDialog),
 	)
-	mainMenu := fyne.NewMainMenu(settingsMenu)
+	automationMenu := fyne.NewMenu("Automation",
+		fyne.NewMenuItem("Pending Plans", func() {
+			NewPendingPlansWindow(mw.app, mw.orchestrator, mw.config, mw.logger).Show()
+		}),
+	)
+	planMenu := fyne.NewMenu("Plan",
+		fyne.NewMenuItem("Export for Review...", mw.onExportPlan),
+		fyne.NewMenuItem("Import Approval...", mw.onImportApproval),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Compare Prompts...", mw.onComparePrompts),
+		fyne.NewMenuItem("Past Corrections...", mw.onShowCorrections),
+		fyne.NewMenuItem("Folder Constraints...", mw.onEditConstraints),
+		fyne.NewMenuItem("Folder Pipeline...", mw.onEditPipeline),
+		fyne.NewMenuItem("Folder Snapshots...", mw.onShowSnapshots),
+	)
+	mainMenu := fyne.NewMainMenu(settingsMenu, planMenu, automationMenu)
 	mw.window.SetMainMenu(mainMenu)
 }
 
+// currentPlan wraps the plan waiting to be executed for review reports
+func (mw *MainWindow) currentPlan() (app.PendingPlan, bool) {
+	if len(mw.currentOperations) == 0 || !mw.executeBtn.Visible() {
+		return app.PendingPlan{}, false
+	}
+	return app.PendingPlan{
+		JobName:    "Manual analysis",
+		BasePath:   mw.dirEntry.Text,
+		Operations: mw.currentOperations,
+		CreatedAt:  mw.currentPlanCreatedAt,
+	}, true
+}
+
+func (mw *MainWindow) onExportPlan() {
+	plan, ok := mw.currentPlan()
+	if !ok {
+		dialog.ShowInformation("Export for Review", "Analyze a folder first; there is no plan waiting to be executed.", mw.window)
+		return
+	}
+	showExportPlanReport(mw.window, plan, mw.logger)
+}
+
+// onComparePrompts opens the prompt comparison harness for the current folder and instructions
+func (mw *MainWindow) onComparePrompts() {
+	if mw.dirEntry.Text == "" {
+		dialog.ShowError(app.ErrEmptyDirectory, mw.window)
+		return
+	}
+	if mw.promptEntry.Text == "" {
+		dialog.ShowError(app.ErrEmptyPrompt, mw.window)
+		return
+	}
+	maxDepth, err := mw.parseDepth()
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("%w: %v", app.ErrInvalidDepth, err), mw.window)
+		return
+	}
+
+	NewPromptComparisonWindow(mw.app, mw.orchestrator, mw.config, mw.logger, app.AnalysisRequest{
+		DirectoryPath:      mw.dirEntry.Text,
+		UserPrompt:         mw.promptEntry.Text,
+		MaxDepth:           maxDepth,
+		EnableDeepAnalysis: mw.config.EnableDeepAnalysis,
+		ExplainMoves:       mw.config.ExplainMoves,
+		Constraints:        mw.config.ConstraintsFor(mw.dirEntry.Text),
+	}).Show()
+}
+
+// onEditConstraints edits the rules plans for the current folder must obey
+func (mw *MainWindow) onEditConstraints() {
+	dirPath := mw.dirEntry.Text
+	if dirPath == "" {
+		dialog.ShowError(app.ErrEmptyDirectory, mw.window)
+		return
+	}
+
+	help := widget.NewLabel("One rule per line. Every line is sent to the model, and moves breaking these rules are also discarded:\n" +
+		"never rename · max depth 2 · never touch *.psd · never move out of Scans · never move into Archive")
+	help.Wrapping = fyne.TextWrapWord
+
+	status := widget.NewLabel("")
+	status.Wrapping = fyne.TextWrapWord
+	entry := widget.NewMultiLineEntry()
+	entry.SetPlaceHolder("never rename\nonly create up to 2 folder levels\nnever touch *.psd")
+	entry.SetMinRowsVisible(8)
+	entry.OnChanged = func(text string) {
+		constraints := app.ParseConstraints(text)
+		switch {
+		case constraints.Empty():
+			status.SetText("No constraints for this folder.")
+		case len(constraints.Unenforced) > 0:
+			status.SetText(fmt.Sprintf("%d enforced. Only passed to the model: %s", len(constraints.Rules), strings.Join(constraints.Unenforced, "; ")))
+		default:
+			status.SetText(fmt.Sprintf("%d enforced.", len(constraints.Rules)))
+		}
+	}
+	entry.SetText(mw.config.ConstraintsFor(dirPath))
+	entry.OnChanged(entry.Text)
+
+	d := dialog.NewCustomConfirm("Constraints for "+filepath.Base(dirPath), "Save", "Cancel", container.NewBorder(help, status, nil, nil, entry), func(save bool) {
+		if !save {
+			return
+		}
+		mw.config.SetConstraints(dirPath, entry.Text)
+		saveConfig(mw.app, mw.config, mw.logger)
+	}, mw.window)
+	d.Resize(fyne.NewSize(650, 420))
+	d.Show()
+}
+
+// onEditPipeline edits the rules run before the model and the normalization run after it for the current folder
+func (mw *MainWindow) onShowSnapshots() {
+	dirPath := mw.dirEntry.Text
+	if dirPath == "" {
+		dialog.ShowError(app.ErrEmptyDirectory, mw.window)
+		return
+	}
+	NewSnapshotsWindow(mw.app, mw.orchestrator, mw.logger, dirPath).Show()
+}
+
+func (mw *MainWindow) onEditPipeline() {
+	dirPath := mw.dirEntry.Text
+	if dirPath == "" {
+		dialog.ShowError(app.ErrEmptyDirectory, mw.window)
+		return
+	}
+
+	help := widget.NewLabel("One step per line. Files matching a rule are planned by it and never sent to the model; " +
+		"the model plans the rest, then new folder names are normalized:\n" +
+		"move *.pdf to Documents · keep *.psd · no ai · normalize kebab-case · normalize dates")
+	help.Wrapping = fyne.TextWrapWord
+
+	status := widget.NewLabel("")
+	status.Wrapping = fyne.TextWrapWord
+	entry := widget.NewMultiLineEntry()
+	entry.SetPlaceHolder("move *.pdf to Documents\nmove Screenshot*.png to Pictures/Screenshots\nkeep *.psd\nnormalize kebab-case")
+	entry.SetMinRowsVisible(8)
+	entry.OnChanged = func(text string) {
+		pipeline, err := app.ParsePipeline(text)
+		switch {
+		case err != nil:
+			status.SetText(err.Error())
+		case pipeline.Empty():
+			status.SetText("No pipeline for this folder; the model plans every file.")
+		case pipeline.SkipAI:
+			status.SetText(fmt.Sprintf("%d rules, no model.", len(pipeline.Rules)))
+		default:
+			status.SetText(fmt.Sprintf("%d rules, then the model for the remaining files.", len(pipeline.Rules)))
+		}
+	}
+	entry.SetText(mw.config.PipelineFor(dirPath))
+	entry.OnChanged(entry.Text)
+
+	d := dialog.NewCustomConfirm("Pipeline for "+filepath.Base(dirPath), "Save", "Cancel", container.NewBorder(help, status, nil, nil, entry), func(save bool) {
+		if !save {
+			return
+		}
+		if _, err := app.ParsePipeline(entry.Text); err != nil {
+			dialog.ShowError(err, mw.window)
+			return
+		}
+		mw.config.SetPipeline(dirPath, entry.Text)
+		saveConfig(mw.app, mw.config, mw.logger)
+	}, mw.window)
+	d.Resize(fyne.NewSize(650, 420))
+	d.Show()
+}
+
+// onShowCorrections lists the moves remembered as mistakes for the current folder
+func (mw *MainWindow) onShowCorrections() {
+	dirPath := mw.dirEntry.Text
+	if dirPath == "" {
+		dialog.ShowError(app.ErrEmptyDirectory, mw.window)
+		return
+	}
+
+	corrections := mw.orchestrator.PastCorrections(dirPath)
+	if len(corrections) == 0 {
+		dialog.ShowInformation("Past Corrections", "No rejected or rolled back moves are remembered for this folder.", mw.window)
+		return
+	}
+
+	var sb strings.Builder
+	for _, c := range corrections {
+		sb.WriteString(fmt.Sprintf("%s  %s → %s (%s)\n", app.FormatDate(c.At), c.From, c.To, c.Kind))
+	}
+	list := widget.NewLabel(sb.String())
+	list.Wrapping = fyne.TextWrapWord
+	help := widget.NewLabel("These moves are sent to the model as examples of what not to do in this folder.")
+	help.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewCustomConfirm("Past Corrections", "Forget All", "Close", container.NewBorder(help, nil, nil, nil, container.NewScroll(list)), func(forget bool) {
+		if !forget {
+			return
+		}
+		if err := mw.orchestrator.ForgetCorrections(dirPath); err != nil {
+			dialog.ShowError(err, mw.window)
+		}
+	}, mw.window)
+	d.Resize(fyne.NewSize(700, 450))
+	d.Show()
+}
+
+func (mw *MainWindow) onImportApproval() {
+	plan, ok := mw.currentPlan()
+	if !ok {
+		dialog.ShowInformation("Import Approval", "There is no plan waiting to be executed.", mw.window)
+		return
+	}
+	showImportPlanApproval(mw.window, func(report []byte) {
+		approval, err := app.CheckPlanApproval(report, plan)
+		if err != nil {
+			dialog.ShowError(err, mw.window)
+			return
+		}
+		mw.logger.Info("Plan %s approved by %s", approval.PlanID, approval.ApprovedBy)
+		mw.statusLabel.SetText(fmt.Sprintf("Approved by %s. Ready to execute %d operations", approval.ApprovedBy, len(plan.Operations)))
+	})
+}
+
+// onBrowse opens the folder dialog at the current folder, or the last one used
+func (mw *MainWindow) onBrowse() {
+	d := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil || uri == nil {
+			return
+		}
+		mw.selectFolder(uri.Path())
+	}, mw.window)
+	if start := mw.config.BrowseStartFolder(mw.dirEntry.Text); start != "" {
+		if location, err := storage.ListerForURI(storage.NewFileURI(start)); err == nil {
+			d.SetLocation(location)
+		}
+	}
+	d.Show()
+}
+
+// showRecentFolders lists the recently used folders under anchor
+func (mw *MainWindow) showRecentFolders(anchor fyne.CanvasObject) {
+	var items []*fyne.MenuItem
+	for _, path := range mw.config.RecentFolders {
+		items = append(items, fyne.NewMenuItem(path, func() {
+			mw.selectFolder(path)
+		}))
+	}
+	if len(items) == 0 {
+		disabled := fyne.NewMenuItem("No recent folders", nil)
+		disabled.Disabled = true
+		items = append(items, disabled)
+	}
+	widget.ShowPopUpMenuAtRelativePosition(fyne.NewMenu("", items...), mw.window.Canvas(), fyne.NewPos(0, anchor.Size().Height), anchor)
+}
+
+// selectFolder fills in the directory path and remembers it as recently used
+func (mw *MainWindow) selectFolder(path string) {
+	mw.dirEntry.SetText(path)
+	mw.rememberFolder(path)
+}
+
+// rememberFolder puts path at the top of the recent folders
+func (mw *MainWindow) rememberFolder(path string) {
+	if mw.config.AddRecentFolder(path) {
+		saveConfig(mw.app, mw.config, mw.logger)
+	}
+}
+
+// onExportAuditLog saves the audit log as CSV
+func (mw *MainWindow) onExportAuditLog() {
+	d := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, mw.window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if err := mw.orchestrator.ExportAuditLog(writer); err != nil {
+			dialog.ShowError(fmt.Errorf("failed to export audit log: %w", err), mw.window)
+			return
+		}
+		mw.statusLabel.SetText("Audit log exported to " + writer.URI().Path())
+	}, mw.window)
+	d.SetFileName(fmt.Sprintf("audit-%s.csv", time.Now().Format("2006-01-02")))
+	d.Show()
+}
+
+// onPurgeData deletes everything stored about a path or pattern and shows what was removed
+func (mw *MainWindow) onPurgeData() {
+	targetEntry := widget.NewEntry()
+	targetEntry.SetPlaceHolder("/home/me/Medical or **/Medical/**")
+	help := widget.NewLabel("Deletes index descriptions, sidecars, shared sync entries, pending plans, " +
+		"remembered corrections and cached analyses for a folder, a file or every path matching a pattern. " +
+		"Files themselves are not touched.")
+	help.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewCustomConfirm("Purge Data", "Purge", "Cancel", container.NewVBox(help, targetEntry), func(purge bool) {
+		if !purge {
+			return
+		}
+		target := targetEntry.Text
+		go func() {
+			report, err := mw.orchestrator.PurgeData(target)
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, mw.window)
+					return
+				}
+				summary := widget.NewLabel(report.String())
+				summary.Wrapping = fyne.TextWrapWord
+				result := dialog.NewCustom("Purge Report", "Close", container.NewScroll(summary), mw.window)
+				result.Resize(fyne.NewSize(600, 350))
+				result.Show()
+			})
+		}()
+	}, mw.window)
+	d.Resize(fyne.NewSize(600, 250))
+	d.Show()
+}
+
+// onShowUsageStats shows the locally recorded usage statistics
+func (mw *MainWindow) onShowUsageStats() {
+	allTime, err := mw.orchestrator.UsageStats(time.Time{})
+	if err != nil {
+		dialog.ShowError(err, mw.window)
+		return
+	}
+	recent, err := mw.orchestrator.UsageStats(time.Now().AddDate(0, 0, -30))
+	if err != nil {
+		dialog.ShowError(err, mw.window)
+		return
+	}
+
+	grid := container.NewGridWithColumns(3, widget.NewLabel(""), widget.NewLabel("Last 30 days"), widget.NewLabel("All time"))
+	addRow := func(label string, format func(s app.UsageSummary) string) {
+		grid.Add(widget.NewLabel(label))
+		grid.Add(widget.NewLabel(format(recent)))
+		grid.Add(widget.NewLabel(format(allTime)))
+	}
+	addRow("Analyses run", func(s app.UsageSummary) string { return strconv.Itoa(s.Analyses) })
+	addRow("Failed analyses", func(s app.UsageSummary) string {
+		return fmt.Sprintf("%d (%.0f%%)", s.FailedAnalyses, s.AnalysisFailureRate()*100)
+	})
+	addRow("Average plan size", func(s app.UsageSummary) string { return fmt.Sprintf("%.1f operations", s.AveragePlanSize()) })
+	addRow("Plans executed", func(s app.UsageSummary) string { return strconv.Itoa(s.Executions) })
+	addRow("Operations executed", func(s app.UsageSummary) string { return strconv.Itoa(s.OperationsExecuted) })
+	addRow("Failed operations", func(s app.UsageSummary) string {
+		return fmt.Sprintf("%d (%.0f%%)", s.OperationsFailed, s.OperationFailureRate()*100)
+	})
+	addRow("Rollbacks", func(s app.UsageSummary) string {
+		return fmt.Sprintf("%d (%d operations)", s.Rollbacks, s.OperationsRolledBack)
+	})
+
+	note := widget.NewLabel("These counts are kept only on this computer and are never sent anywhere.")
+	note.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewCustomConfirm("Usage Statistics", "Reset", "Close", container.NewBorder(nil, note, nil, nil, grid), func(reset bool) {
+		if !reset {
+			return
+		}
+		dialog.ShowConfirm("Reset Statistics", "Delete all recorded usage statistics?", func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := mw.orchestrator.ResetUsageStats(); err != nil {
+				dialog.ShowError(err, mw.window)
+			}
+		}, mw.window)
+	}, mw.window)
+	d.Resize(fyne.NewSize(550, 350))
+	d.Show()
+}
+
 func (mw *MainWindow) setOutputText(text string) {
 	mw.lastOutputContent = text
 	mw.outputText.SetText(text)
@@ -228,91 +684,694 @@ func (mw *MainWindow) onAnalyze() {
 		dialog.ShowError(fmt.Errorf("%w: %v", app.ErrInvalidDepth, err), mw.window)
 		return
 	}
+	mw.rememberFolder(dirPath)
+
+	mw.progressBar.Show()
+	mw.analyzeBtn.Disable()
+	mw.executeBtn.Hide()
+	mw.setPlanEditable(false)
+	mw.planFilterEntry.SetText("")
+	mw.rollbackBtn.Hide()
+	mw.refreshBottomStatus()
+	mw.statusLabel.SetText("Scanning directory...")
+
+	mw.setOutputText("")
+	var outputBuffer strings.Builder
+
+	// The file selection and the plan share one run ceiling
+	budgetRun := mw.httpClient.Budget().BeginRun()
+
+	go func() {
+		req := app.AnalysisRequest{
+			DirectoryPath:       dirPath,
+			UserPrompt:          userPrompt,
+			MaxDepth:            maxDepth,
+			EnableDeepAnalysis:  mw.config.EnableDeepAnalysis,
+			OnScanProgress:      mw.showScanProgress,
+			ExplainMoves:        mw.config.ExplainMoves,
+			SelfCritique:        mw.config.SelfCritique,
+			Constraints:         mw.config.ConstraintsFor(dirPath),
+			Pipeline:            mw.config.PipelineFor(dirPath),
+			IndexRules:          mw.config.IndexRules,
+			NewFilesOnly:        mw.config.NewFilesOnly,
+			SplitCompanions:     mw.config.SplitCompanions,
+			ConfirmDeepAnalysis: mw.confirmDeepAnalysis,
+			BudgetRun:           budgetRun,
+		}
+
+		structure, _ := mw.orchestrator.GetDirectoryStructure(dirPath, maxDepth, mw.showScanProgress)
+		summary := app.SummarizeStructure(structure)
+
+		// What changed since the folder was last indexed; only meaningful while deep analysis keeps the index
+		var changes *app.DirectoryChanges
+		if mw.config.EnableDeepAnalysis {
+			if changes, err = mw.orchestrator.ScanDirectoryChanges(dirPath, maxDepth); err != nil {
+				mw.logger.Error("Failed to compare the folder with the index: %v", err)
+				changes = nil
+			}
+		}
+
+		fyne.Do(func() {
+			mw.currentGrounding = app.NewStructureGrounding(structure)
+			outputBuffer.WriteString(fmt.Sprintf("Directory Structure:\n%s\n\n=== Summary ===\n%s\n\n", structure, formatStructureSummary(summary)))
+			if changes != nil {
+				outputBuffer.WriteString(fmt.Sprintf("=== Changes Since Last Run ===\n%s\n\n", mw.formatDirectoryChanges(dirPath, changes)))
+			}
+			mw.setOutputText(outputBuffer.String())
+
+			analyze := func(changedOnly bool) {
+				if changedOnly && changes != nil && !changes.FirstRun() {
+					if len(changes.Changed()) == 0 {
+						mw.progressBar.Hide()
+						mw.analyzeBtn.Enable()
+						mw.refreshBottomStatus()
+						mw.statusLabel.SetText("No new or modified files since the last run")
+						return
+					}
+					req.OnlyFiles = changes.Changed()
+				}
+				mw.startAnalysis(req, &outputBuffer)
+			}
+			if mw.config.SkipScanSummary {
+				analyze(mw.config.PlanChangedFilesOnly)
+				return
+			}
+			mw.confirmScanSummary(summary, changes, func(proceed, changedOnly bool) {
+				if !proceed {
+					mw.progressBar.Hide()
+					mw.analyzeBtn.Enable()
+					mw.refreshBottomStatus()
+					mw.statusLabel.SetText("Analysis cancelled")
+					return
+				}
+				analyze(changedOnly)
+			})
+		})
+	}()
+}
+
+// startAnalysis plans right away, or for prompts like "only organize the screenshots" first has the
+// model select the files meant and asks the user to confirm them, then plans just those
+func (mw *MainWindow) startAnalysis(req app.AnalysisRequest, outputBuffer *strings.Builder) {
+	// Selecting files sends the whole structure, which index rules are meant to avoid
+	if req.IndexRules || !app.IsSelectionPrompt(req.UserPrompt) {
+		mw.runAnalysis(req, outputBuffer)
+		return
+	}
+
+	stop := func(status string) {
+		mw.progressBar.Hide()
+		mw.analyzeBtn.Enable()
+		mw.refreshBottomStatus()
+		mw.statusLabel.SetText(status)
+	}
+	mw.statusLabel.SetText(fmt.Sprintf("Selecting matching files with %s...", mw.config.Model))
+	go func() {
+		selection := mw.orchestrator.SelectFiles(req)
+		fyne.Do(func() {
+			switch {
+			case errors.Is(selection.Error, app.ErrSelectionUnsupported):
+				mw.runAnalysis(req, outputBuffer)
+				return
+			case selection.Error != nil:
+				dialog.ShowError(selection.Error, mw.window)
+				stop("Error while selecting files")
+				return
+			case len(selection.Files) == 0:
+				dialog.ShowInformation("No Matching Files", "No files in this folder match the prompt.", mw.window)
+				stop("No matching files")
+				return
+			}
+
+			var list strings.Builder
+			for _, file := range selection.Files {
+				list.WriteString(mw.getRelativePath(req.DirectoryPath, file) + "\n")
+			}
+			details := widget.NewLabel(strings.TrimSuffix(list.String(), "\n"))
+			header := widget.NewLabel(fmt.Sprintf("The plan will only cover these %d files:", len(selection.Files)))
+			d := dialog.NewCustomConfirm("Confirm Selection", "Plan These", "Cancel", container.NewBorder(header, nil, nil, nil, container.NewScroll(details)), func(proceed bool) {
+				if !proceed {
+					stop("Analysis cancelled")
+					return
+				}
+				req.OnlyFiles = selection.Files
+				outputBuffer.WriteString(fmt.Sprintf("=== Selected Files (%d) ===\n%s\n", len(selection.Files), list.String()))
+				mw.runAnalysis(req, outputBuffer)
+			}, mw.window)
+			d.Resize(fyne.NewSize(600, 420))
+			d.Show()
+		})
+	}()
+}
+
+// runAnalysis asks the model for a plan; the scanned structure is already in outputBuffer
+func (mw *MainWindow) runAnalysis(req app.AnalysisRequest, outputBuffer *strings.Builder) {
+	outputBuffer.WriteString("=== AI Suggested Operations ===\n")
+	mw.setOutputText(outputBuffer.String())
+	if req.SelfCritique {
+		mw.statusLabel.SetText(fmt.Sprintf("Analyzing and reviewing the plan with %s...", mw.config.Model))
+	} else {
+		mw.statusLabel.SetText(fmt.Sprintf("Analyzing with %s...", mw.config.Model))
+	}
+
+	go func() {
+		opCount := 0
+		onOperation := func(op app.FileOperation) {
+			fyne.Do(func() {
+				opCount++
+				outputBuffer.WriteString(mw.formatOperation(req.DirectoryPath, op))
+				mw.setOutputText(outputBuffer.String())
+				mw.statusLabel.SetText(fmt.Sprintf("Found %d operations...", opCount))
+			})
+		}
+
+		result := mw.orchestrator.AnalyzeDirectory(req, onOperation)
+
+		fyne.Do(func() {
+			mw.progressBar.Hide()
+			mw.analyzeBtn.Enable()
+			mw.refreshBottomStatus()
+
+			if errors.Is(result.Error, app.ErrNoNewFiles) {
+				mw.statusLabel.SetText("Nothing to organize: no files were added or changed since the last execution")
+				return
+			}
+			if result.Error != nil {
+				dialog.ShowError(result.Error, mw.window)
+				mw.statusLabel.SetText("Error during analysis")
+				return
+			}
+
+			if len(result.Hallucinated) > 0 {
+				outputBuffer.WriteString(fmt.Sprintf("\n=== Discarded: Not in Scanned Structure (%d) ===\n", len(result.Hallucinated)))
+				for _, op := range result.Hallucinated {
+					outputBuffer.WriteString(fmt.Sprintf("%s → %s\n", mw.getRelativePath(req.DirectoryPath, op.From), mw.getRelativePath(req.DirectoryPath, op.To)))
+				}
+				mw.setOutputText(outputBuffer.String())
+			}
+
+			// Made-up paths say something about the model's reliability, so they stay visible in the status line
+			dropped := ""
+			if len(result.Hallucinated) > 0 {
+				dropped = fmt.Sprintf(" (%d hallucinated paths dropped)", len(result.Hallucinated))
+			}
+
+			if len(result.Rejected) > 0 {
+				outputBuffer.WriteString(fmt.Sprintf("\n=== Discarded: Break Folder Constraints (%d) ===\n", len(result.Rejected)))
+				for _, op := range result.Rejected {
+					outputBuffer.WriteString(fmt.Sprintf("%s → %s\n  (%s)\n", mw.getRelativePath(req.DirectoryPath, op.From), mw.getRelativePath(req.DirectoryPath, op.To), op.Flag))
+				}
+				mw.setOutputText(outputBuffer.String())
+			}
+
+			if len(result.Removed) > 0 {
+				outputBuffer.WriteString(fmt.Sprintf("\n=== Removed by Plan Review (%d) ===\n", len(result.Removed)))
+				for _, op := range result.Removed {
+					outputBuffer.WriteString(fmt.Sprintf("%s → %s\n  (%s)\n", mw.getRelativePath(req.DirectoryPath, op.From), mw.getRelativePath(req.DirectoryPath, op.To), op.Flag))
+				}
+				mw.setOutputText(outputBuffer.String())
+			}
+
+			if len(result.Rules) > 0 {
+				outputBuffer.WriteString(fmt.Sprintf("\n=== Classification Rules (%d) ===\n", len(result.Rules)))
+				for _, rule := range result.Rules {
+					outputBuffer.WriteString(rule.String() + "\n")
+				}
+				mw.setOutputText(outputBuffer.String())
+			}
+
+			if len(result.Merged) > 0 {
+				outputBuffer.WriteString(fmt.Sprintf("\n=== Merged Near-Duplicate Folders (%d) ===\n", len(result.Merged)))
+				for _, merge := range result.Merged {
+					outputBuffer.WriteString(fmt.Sprintf("%s → %s (%d operations)\n", merge.Folder, merge.Into, merge.Count))
+				}
+				mw.setOutputText(outputBuffer.String())
+			}
+
+			if len(result.Companions) > 0 {
+				outputBuffer.WriteString(fmt.Sprintf("\n=== Companion Files Kept Together (%d) ===\n", len(result.Companions)))
+				for _, op := range result.Companions {
+					outputBuffer.WriteString(mw.formatOperation(req.DirectoryPath, op))
+				}
+				mw.setOutputText(outputBuffer.String())
+			}
+
+			if len(result.Operations) == 0 {
+				mw.statusLabel.SetText("No changes suggested" + dropped)
+				return
+			}
+
+			fromRules := ""
+			if result.FromRules > 0 {
+				fromRules = fmt.Sprintf(", %d from folder rules", result.FromRules)
+			}
+			if !result.NewSince.IsZero() {
+				fromRules += ", new files since " + result.NewSince.Format("2006-01-02 15:04")
+			}
+			mw.statusLabel.SetText(fmt.Sprintf("Ready to execute %d operations%s%s", len(result.Operations), fromRules, dropped))
+			mw.currentOperations = result.Operations
+			mw.stoppedResults = nil
+			mw.executeBtn.SetText(executeBtnText)
+			mw.currentPlannedAt = result.PlannedAt
+			mw.currentFingerprint = result.Fingerprint
+			mw.currentPlanCreatedAt = time.Now()
+			mw.executeBtn.Show()
+			mw.setPlanEditable(true)
+			mw.refreshBottomStatus()
+		})
+	}()
+}
+
+// formatOperation renders one planned operation the way the plan list shows it
+func (mw *MainWindow) formatOperation(basePath string, op app.FileOperation) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s → %s\n", mw.getRelativePath(basePath, op.From), mw.getRelativePath(basePath, op.To)))
+	if op.Reason != "" {
+		sb.WriteString(fmt.Sprintf("  (%s)\n", op.Reason))
+	}
+	if op.Flag != "" {
+		sb.WriteString(fmt.Sprintf("  ⚠ Review: %s\n", op.Flag))
+	}
+	if op.Adjusted != "" {
+		sb.WriteString(fmt.Sprintf("  ✎ Name adjusted: %s\n", op.Adjusted))
+	}
+	if app.EscapesBase(basePath, op.To) {
+		sb.WriteString(fmt.Sprintf("  %s: %s\n", app.OutOfScopeMarker, op.To))
+	}
+	return sb.String()
+}
+
+// completePath offers the scanned paths starting with what was typed so far
+func (mw *MainWindow) completePath(entry *widget.SelectEntry, text string, foldersOnly bool) {
+	if mw.currentGrounding == nil {
+		return
+	}
+	entry.SetOptions(mw.currentGrounding.Complete(text, foldersOnly, maxPathCompletions))
+}
+
+// completeDestination offers scanned folders and the folders the plan already uses, except those
+// of operation skip (or -1), so edited destinations keep the plan's naming
+func (mw *MainWindow) completeDestination(entry *widget.SelectEntry, text string, skip int) {
+	if mw.currentGrounding == nil {
+		return
+	}
+	entry.SetOptions(app.SuggestDestinations(mw.dirEntry.Text, mw.currentGrounding, mw.currentOperations, skip, text, maxPathCompletions))
+}
+
+// onAddOperation appends a move typed by the user to the plan waiting to be executed
+func (mw *MainWindow) onAddOperation() {
+	if mw.currentGrounding == nil {
+		return
+	}
+	basePath := mw.dirEntry.Text
+	op, err := app.NewManualOperation(basePath, mw.addFromEntry.Text, mw.addToEntry.Text, mw.currentGrounding, mw.currentOperations,
+		app.ParseConstraints(mw.config.ConstraintsFor(basePath)))
+	if err != nil {
+		dialog.ShowError(err, mw.window)
+		return
+	}
+
+	mw.currentOperations = append(mw.currentOperations, op)
+	mw.refreshPlanView()
+	mw.appendManualChange(mw.formatOperation(basePath, op))
+	mw.addFromEntry.SetText("")
+	mw.addToEntry.SetText("")
+	mw.statusLabel.SetText(fmt.Sprintf("Added %s. Ready to execute %d operations", mw.getRelativePath(basePath, op.From), len(mw.currentOperations)))
+}
+
+// confirmScanSummary shows what the scan found and lets the user stop before any tokens are spent
+func (mw *MainWindow) confirmScanSummary(summary app.StructureSummary, changes *app.DirectoryChanges, onDone func(proceed, changedOnly bool)) {
+	text := formatStructureSummary(summary)
+	if changes != nil {
+		text += "\n\nSince the last run:\n" + mw.formatDirectoryChanges(mw.dirEntry.Text, changes)
+	}
+	details := widget.NewLabel(text)
+	details.Wrapping = fyne.TextWrapWord
+	skipCheck := widget.NewCheck("Don't ask again (can be changed in Settings)", nil)
+	options := container.NewVBox(skipCheck)
+
+	// Routine runs on a big archive only need to plan what is new
+	changedOnlyCheck := widget.NewCheck("", nil)
+	if changes != nil && !changes.FirstRun() {
+		changedOnlyCheck.SetText(fmt.Sprintf("Only plan the %d new and modified files", len(changes.Changed())))
+		changedOnlyCheck.SetChecked(mw.config.PlanChangedFilesOnly)
+		options.Add(changedOnlyCheck)
+	}
+
+	d := dialog.NewCustomConfirm("Scan Summary", "Analyze", "Cancel", container.NewBorder(nil, options, nil, nil, container.NewScroll(details)), func(proceed bool) {
+		save := false
+		if proceed && skipCheck.Checked {
+			mw.config.SkipScanSummary = true
+			save = true
+		}
+		if proceed && changes != nil && !changes.FirstRun() && changedOnlyCheck.Checked != mw.config.PlanChangedFilesOnly {
+			mw.config.PlanChangedFilesOnly = changedOnlyCheck.Checked
+			save = true
+		}
+		if save {
+			saveConfig(mw.app, mw.config, mw.logger)
+		}
+		onDone(proceed, changedOnlyCheck.Checked)
+	}, mw.window)
+	d.Resize(fyne.NewSize(600, 420))
+	d.Show()
+}
+
+// maxListedChanges bounds the paths listed per kind of change
+const maxListedChanges = 20
+
+// formatDirectoryChanges lists what is new, modified and deleted since the folder was last indexed
+func (mw *MainWindow) formatDirectoryChanges(basePath string, changes *app.DirectoryChanges) string {
+	if changes.FirstRun() {
+		return fmt.Sprintf("Not indexed before; all %d files are new", len(changes.NewFiles))
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d new, %d modified, %d deleted, %d unchanged", len(changes.NewFiles), len(changes.ModifiedFiles), len(changes.DeletedFiles), len(changes.UnchangedFiles)))
+	for _, group := range []struct {
+		label string
+		paths []string
+	}{{"New", changes.NewFiles}, {"Modified", changes.ModifiedFiles}, {"Deleted", changes.DeletedFiles}} {
+		for i, p := range group.paths {
+			if i == maxListedChanges {
+				sb.WriteString(fmt.Sprintf("\n  ... and %d more", len(group.paths)-i))
+				break
+			}
+			sb.WriteString(fmt.Sprintf("\n  %s: %s", group.label, mw.getRelativePath(basePath, p)))
+		}
+	}
+	return sb.String()
+}
+
+// formatStructureSummary lists the counts, depth and largest files of a scan
+func formatStructureSummary(summary app.StructureSummary) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d files in %d folders, %s in total, %d levels deep\n", summary.Files, summary.Folders, formatFileSize(summary.TotalSize), summary.MaxDepth))
+	for _, fileType := range summary.TypesByCount() {
+		sb.WriteString(fmt.Sprintf("  %s: %d\n", fileType, summary.ByType[fileType]))
+	}
+	if len(summary.Largest) > 0 {
+		sb.WriteString("Largest files:\n")
+		for _, file := range summary.Largest {
+			sb.WriteString(fmt.Sprintf("  %s (%s)\n", file.Path, formatFileSize(file.Size)))
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
 
-	mw.progressBar.Show()
-	mw.analyzeBtn.Disable()
-	mw.executeBtn.Hide()
-	mw.rollbackBtn.Hide()
-	mw.refreshBottomStatus()
-	mw.statusLabel.SetText("Analyzing directory...")
+// showScanProgress shows live feedback while a (possibly slow) directory walk is running
+func (mw *MainWindow) showScanProgress(progress app.ScanProgress) {
+	fyne.Do(func() {
+		if progress.Done {
+			mw.statusLabel.SetText(fmt.Sprintf("Scanned %d files (%.0f files/s)", progress.FilesScanned, progress.FilesPerSecond))
+			return
+		}
+		mw.statusLabel.SetText(fmt.Sprintf("Scanning directory... %d files (%.0f files/s) - %s",
+			progress.FilesScanned, progress.FilesPerSecond, truncateMiddle(progress.CurrentPath, 60)))
+	})
+}
 
-	mw.setOutputText("")
-	var outputBuffer strings.Builder
+// onExecute checks that the plan's locations can be written to and that the folder still looks
+// like it did when the plan was made, then runs it
+func (mw *MainWindow) onExecute() {
+	planned := mw.currentFingerprint
+	operations := mw.currentOperations
 
+	mw.executeBtn.Disable()
+	mw.statusLabel.SetText("Checking the folders the plan moves files between...")
 	go func() {
-		req := app.AnalysisRequest{
-			DirectoryPath:      dirPath,
-			UserPrompt:         userPrompt,
-			MaxDepth:           maxDepth,
-			EnableDeepAnalysis: mw.config.EnableDeepAnalysis,
+		writableErr := mw.orchestrator.CheckWritable(operations)
+		var now app.DirectoryFingerprint
+		changed := false
+		if writableErr == nil && planned != nil {
+			now, changed = mw.orchestrator.CheckPlanFresh(planned)
 		}
-
-		structure, _ := mw.orchestrator.GetDirectoryStructure(dirPath, maxDepth)
 		fyne.Do(func() {
-			outputBuffer.WriteString(fmt.Sprintf("Directory Structure:\n%s\n\n=== AI Suggested Operations ===\n", structure))
-			mw.setOutputText(outputBuffer.String())
-			mw.statusLabel.SetText(fmt.Sprintf("Analyzing with %s...", mw.config.Model))
+			mw.executeBtn.Enable()
+			switch {
+			case writableErr != nil:
+				mw.statusLabel.SetText("Execution cancelled: nothing was moved")
+				dialog.ShowError(fmt.Errorf("the plan can't be executed: %w", writableErr), mw.window)
+			case changed:
+				mw.warnStalePlan(*planned, now)
+			default:
+				mw.confirmQuarantine()
+			}
 		})
+	}()
+}
 
-		opCount := 0
-		onOperation := func(op app.FileOperation) {
-			fyne.Do(func() {
-				opCount++
-				fromRel := mw.getRelativePath(mw.dirEntry.Text, op.From)
-				toRel := mw.getRelativePath(mw.dirEntry.Text, op.To)
-				outputBuffer.WriteString(fmt.Sprintf("%s → %s\n", fromRel, toRel))
-				mw.setOutputText(outputBuffer.String())
-				mw.statusLabel.SetText(fmt.Sprintf("Found %d operations...", opCount))
-			})
-		}
+// warnStalePlan offers to re-check a plan against a folder that changed after it was made
+func (mw *MainWindow) warnStalePlan(planned, now app.DirectoryFingerprint) {
+	msg := widget.NewLabel(fmt.Sprintf("The folder changed since this plan was made (%s).\n\n"+
+		"Some operations may no longer apply. Re-validate drops the ones whose file is gone or whose destination is taken, "+
+		"so you can review the plan before executing it.", planned.Describe(now)))
+	msg.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewCustomWithoutButtons("Folder Changed", msg, mw.window)
+	revalidateBtn := widget.NewButton("Re-validate", func() {
+		d.Hide()
+		mw.revalidatePlan(now)
+	})
+	revalidateBtn.Importance = widget.HighImportance
+	d.SetButtons([]fyne.CanvasObject{
+		widget.NewButton("Cancel", func() {
+			d.Hide()
+			mw.statusLabel.SetText("Execution cancelled: the folder changed since the plan was made")
+		}),
+		widget.NewButton("Execute Anyway", func() {
+			d.Hide()
+			mw.confirmQuarantine()
+		}),
+		revalidateBtn,
+	})
+	d.Resize(fyne.NewSize(500, 220))
+	d.Show()
+}
 
-		result := mw.orchestrator.AnalyzeDirectory(req, onOperation)
+// revalidatePlan drops the operations that no longer fit the folder and leaves the rest for review
+func (mw *MainWindow) revalidatePlan(now app.DirectoryFingerprint) {
+	basePath := mw.dirEntry.Text
+	kept, dropped := mw.orchestrator.RevalidatePlan(mw.currentOperations)
+	mw.currentOperations = kept
+	mw.currentFingerprint = &now
+
+	output := mw.lastOutputContent
+	output += fmt.Sprintf("\n=== Dropped After Re-validation (%d) ===\n", len(dropped))
+	for _, op := range dropped {
+		output += fmt.Sprintf("%s → %s\n  (%s)\n", mw.getRelativePath(basePath, op.From), mw.getRelativePath(basePath, op.To), op.Flag)
+	}
+	mw.setOutputText(output)
+	mw.refreshPlanView()
 
-		fyne.Do(func() {
-			mw.progressBar.Hide()
-			mw.analyzeBtn.Enable()
-			mw.refreshBottomStatus()
+	if len(kept) == 0 {
+		mw.executeBtn.Hide()
+		mw.setPlanEditable(false)
+		mw.refreshBottomStatus()
+		mw.statusLabel.SetText("Nothing left to execute after re-validation")
+		return
+	}
+	mw.statusLabel.SetText(fmt.Sprintf("Re-validated: %d operations dropped, %d left. Review them and execute again.", len(dropped), len(kept)))
+}
 
-			if result.Error != nil {
-				dialog.ShowError(result.Error, mw.window)
-				mw.statusLabel.SetText("Error during analysis")
+// confirmQuarantine holds back operations that match a risk heuristic (see app.Quarantine) and
+// asks about each of them separately, then runs the plan
+func (mw *MainWindow) confirmQuarantine() {
+	basePath := mw.dirEntry.Text
+	operations := mw.currentOperations
+	mw.executeBtn.Disable()
+	mw.statusLabel.SetText("Checking the plan for risky operations...")
+	go func() {
+		_, quarantined := app.Quarantine(basePath, operations)
+		fyne.Do(func() {
+			mw.executeBtn.Enable()
+			if len(quarantined) == 0 {
+				mw.offerSyncThrottle()
 				return
 			}
+			mw.showQuarantine(basePath, quarantined)
+		})
+	}()
+}
 
-			if len(result.Operations) == 0 {
-				mw.statusLabel.SetText("No changes suggested")
+func (mw *MainWindow) showQuarantine(basePath string, quarantined []app.QuarantinedOperation) {
+	message := widget.NewLabel(fmt.Sprintf("%d operations were held back from the bulk execution. Check the ones that should run anyway; "+
+		"the others stay where they are.", len(quarantined)))
+	message.Wrapping = fyne.TextWrapWord
+
+	checks := make([]*widget.Check, len(quarantined))
+	list := container.NewVBox()
+	for i, q := range quarantined {
+		to := q.Operation.To
+		if !app.EscapesBase(basePath, to) {
+			to = mw.getRelativePath(basePath, to)
+		}
+		checks[i] = widget.NewCheck(fmt.Sprintf("%s → %s", mw.getRelativePath(basePath, q.Operation.From), to), nil)
+		reasons := widget.NewLabel("  " + strings.Join(q.Reasons, "\n  "))
+		reasons.Importance = widget.WarningImportance
+		reasons.Wrapping = fyne.TextWrapWord
+		list.Add(checks[i])
+		list.Add(reasons)
+	}
+
+	d := dialog.NewCustomConfirm("Quarantined Operations", "Execute", "Cancel", container.NewBorder(message, nil, nil, nil, container.NewScroll(list)), func(execute bool) {
+		if !execute {
+			return
+		}
+		skip := make(map[app.FileOperation]bool)
+		for i, q := range quarantined {
+			if !checks[i].Checked {
+				skip[q.Operation] = true
+			}
+		}
+		if len(skip) > 0 {
+			var kept []app.FileOperation
+			for _, op := range mw.currentOperations {
+				if !skip[op] {
+					kept = append(kept, op)
+				}
+			}
+			mw.currentOperations = kept
+
+			output := mw.lastOutputContent + fmt.Sprintf("\n=== Left in Quarantine (%d) ===\n", len(skip))
+			for _, q := range quarantined {
+				if skip[q.Operation] {
+					output += fmt.Sprintf("%s → %s\n  (%s)\n", mw.getRelativePath(basePath, q.Operation.From), q.Operation.To, strings.Join(q.Reasons, "; "))
+				}
+			}
+			mw.setOutputText(output)
+			if len(kept) == 0 {
+				mw.executeBtn.Hide()
+				mw.setPlanEditable(false)
+				mw.refreshBottomStatus()
+				mw.statusLabel.SetText("Nothing left to execute")
 				return
 			}
+		}
+		mw.offerSyncThrottle()
+	}, mw.window)
+	d.Resize(fyne.NewSize(650, 450))
+	d.Show()
+}
 
-			mw.statusLabel.SetText(fmt.Sprintf("Ready to execute %d operations", len(result.Operations)))
-			mw.currentOperations = result.Operations
-			mw.executeBtn.Show()
-			mw.refreshBottomStatus()
-		})
-	}()
+// offerSyncThrottle suggests throttling the first time a plan runs in a cloud-synced folder, then executes it
+func (mw *MainWindow) offerSyncThrottle() {
+	provider, _, synced := app.DetectSyncRoot(mw.dirEntry.Text)
+	if !synced || mw.config.ThrottleSyncedFolders || mw.syncThrottleOffered {
+		mw.executePlan()
+		return
+	}
+
+	mw.syncThrottleOffered = true
+	message := widget.NewLabel(fmt.Sprintf("This folder is synced by %s. Moving many files at once can make the sync client "+
+		"create conflicted copies. Slow down moves in synced folders? You can change this in Settings.", provider))
+	message.Wrapping = fyne.TextWrapWord
+	d := dialog.NewCustomConfirm("Cloud-Synced Folder", "Throttle Moves", "Full Speed", message, func(throttle bool) {
+		if throttle {
+			mw.config.ThrottleSyncedFolders = true
+			saveConfig(mw.app, mw.config, mw.logger)
+		}
+		mw.executePlan()
+	}, mw.window)
+	d.Resize(fyne.NewSize(500, 200))
+	d.Show()
 }
 
-func (mw *MainWindow) onExecute() {
+func (mw *MainWindow) executePlan() {
 	mw.executeBtn.Hide()
+	mw.setPlanEditable(false)
 	mw.rollbackBtn.Hide()
+	control := app.NewExecutionControl()
+	mw.showExecutionControls(control)
 	mw.refreshBottomStatus()
 
+	throttleDelay, throttleBatch := mw.config.ThrottleFor(mw.dirEntry.Text)
 	go func() {
 		result := mw.orchestrator.ExecuteOrganization(app.ExecutionRequest{
 			Operations: mw.currentOperations,
 			BasePath:   mw.dirEntry.Text,
-			CleanEmpty: mw.cleanCheck.Checked,
+			CleanEmpty:  mw.cleanCheck.Checked,
+			OnOffline:   mw.promptOffline,
+			Parallelism: mw.config.ParallelMoves,
+			PlannedAt:   mw.currentPlannedAt,
+			Staged:      mw.config.StagedExecution,
+
+			CheckOpenFiles: mw.config.CheckOpenFiles,
+			OnLocked:       mw.promptLocked,
+
+			AuditPermissions: mw.config.AuditPermissions,
+			RewriteShortcuts: mw.config.RewriteShortcuts,
+			UpdateReferences: mw.config.UpdateReferences,
+
+			ThrottleDelay: throttleDelay,
+			ThrottleBatch: throttleBatch,
+
+			Control:     control,
+			HashSample:  mw.config.HashSample(),
+			OnHeartbeat: mw.reportHeartbeat,
+		})
+		fyne.Do(func() {
+			mw.showExecutionControls(nil)
+			mw.displayExecutionResult(result, false)
 		})
-		fyne.Do(func() { mw.displayExecutionResult(result, false) })
 	}()
 }
 
+// showExecutionControls shows Pause and Stop for a running execution, or hides them for nil
+func (mw *MainWindow) showExecutionControls(control *app.ExecutionControl) {
+	mw.executionControl = control
+	if control == nil {
+		mw.executionControls.Hide()
+		return
+	}
+	mw.pauseBtn.SetText("⏸ Pause")
+	mw.pauseBtn.Enable()
+	mw.stopBtn.Enable()
+	mw.executionControls.Show()
+	mw.statusLabel.SetText(fmt.Sprintf("Executing %d operations...", len(mw.currentOperations)))
+}
+
+// reportHeartbeat shows which move is taking long, so a slow copy doesn't look like a hang
+func (mw *MainWindow) reportHeartbeat(op app.FileOperation, elapsed time.Duration) {
+	fyne.Do(func() {
+		mw.statusLabel.SetText(fmt.Sprintf("Still moving %s (%v so far)...",
+			mw.getRelativePath(mw.dirEntry.Text, op.From), elapsed.Truncate(time.Second)))
+	})
+}
+
+func (mw *MainWindow) onPauseExecution() {
+	control := mw.executionControl
+	if control == nil {
+		return
+	}
+	if control.Paused() {
+		control.Resume()
+		mw.pauseBtn.SetText("⏸ Pause")
+		mw.statusLabel.SetText("Executing...")
+		return
+	}
+	control.Pause()
+	mw.pauseBtn.SetText("▶ Continue")
+	mw.statusLabel.SetText("Paused. Moves already started will finish first.")
+}
+
+// onStopExecution ends the run after the moves in flight; the rest can be resumed or the moves undone
+func (mw *MainWindow) onStopExecution() {
+	if mw.executionControl == nil {
+		return
+	}
+	mw.executionControl.Stop()
+	mw.pauseBtn.Disable()
+	mw.stopBtn.Disable()
+	mw.statusLabel.SetText("Stopping after the current move...")
+}
+
 func (mw *MainWindow) onRollback() {
 	mw.rollbackBtn.Hide()
 	mw.progressBar.Show()
 	mw.refreshBottomStatus()
 	mw.statusLabel.SetText("Rolling back changes...")
 
+	throttleDelay, throttleBatch := mw.config.ThrottleFor(mw.dirEntry.Text)
 	go func() {
 		var inverseOps []app.FileOperation
 		for i := len(mw.lastSuccessfulResults) - 1; i >= 0; i-- {
@@ -326,9 +1385,33 @@ func (mw *MainWindow) onRollback() {
 		result := mw.orchestrator.ExecuteOrganization(app.ExecutionRequest{
 			Operations: inverseOps,
 			BasePath:   mw.dirEntry.Text,
-			CleanEmpty: false,
+			CleanEmpty:  false,
+			OnOffline:   mw.promptOffline,
+			Parallelism: mw.config.ParallelMoves,
+
+			CheckOpenFiles: mw.config.CheckOpenFiles,
+			OnLocked:       mw.promptLocked,
+
+			ThrottleDelay: throttleDelay,
+			ThrottleBatch: throttleBatch,
+
+			HashSample:  mw.config.HashSample(),
+			OnHeartbeat: mw.reportHeartbeat,
+
+			Rollback:         true,
+			RewriteShortcuts: mw.config.RewriteShortcuts,
+			UpdateReferences: mw.config.UpdateReferences,
 		})
 
+		// Undone moves are remembered so the next analysis doesn't suggest them again
+		var reverted []app.FileOperation
+		for _, opResult := range result.Operations {
+			if opResult.Success {
+				reverted = append(reverted, app.FileOperation{From: opResult.Operation.To, To: opResult.Operation.From})
+			}
+		}
+		mw.orchestrator.RecordCorrections(mw.dirEntry.Text, reverted, app.CorrectionReverted)
+
 		dirsToRemove := make(map[string]bool)
 		for i := len(mw.lastSuccessfulResults) - 1; i >= 0; i-- {
 			for _, dir := range mw.lastSuccessfulResults[i].CreatedDirs {
@@ -350,7 +1433,7 @@ func (mw *MainWindow) onRollback() {
 
 		removedCount := 0
 		for _, dir := range dirList {
-			if err := os.Remove(dir); err == nil {
+			if err := app.RemoveCreatedFolder(dir); err == nil {
 				removedCount++
 				mw.logger.Debug("Removed directory during rollback: %s", dir)
 			}
@@ -368,12 +1451,139 @@ func (mw *MainWindow) onRollback() {
 	}()
 }
 
+// promptOffline blocks the execution goroutine while asking the user whether to retry or abort
+// after the target location stopped responding
+func (mw *MainWindow) promptOffline(path string, err error) app.OfflineDecision {
+	decision := make(chan app.OfflineDecision, 1)
+
+	fyne.Do(func() {
+		mw.statusLabel.SetText("Paused: target location is offline")
+		msg := fmt.Sprintf("The target location stopped responding:\n\n%s\n\n%v\n\nReconnect the drive or network share, then choose Retry. Abort leaves the remaining operations unexecuted.", path, err)
+		dialog.ShowCustomConfirm("Location Offline", "Retry", "Abort", widget.NewLabel(msg), func(retry bool) {
+			if retry {
+				mw.statusLabel.SetText("Retrying...")
+				decision <- app.OfflineRetry
+			} else {
+				decision <- app.OfflineAbort
+			}
+		}, mw.window)
+	})
+
+	return <-decision
+}
+
+// promptLocked blocks the execution goroutine while asking what to do about files other programs have open
+func (mw *MainWindow) promptLocked(files []app.LockedFile) app.LockedDecision {
+	fyne.Do(func() {
+		mw.statusLabel.SetText("Paused: some files are in use")
+	})
+	decision := askLockedFiles(mw.window, files)
+	if decision == app.LockedRetry {
+		fyne.Do(func() {
+			mw.statusLabel.SetText("Checking again...")
+		})
+	}
+	return decision
+}
+
+// askLockedFiles shows which files are in use and blocks until the user picks retry, skip or cancel.
+// It must not be called from the UI goroutine.
+func askLockedFiles(window fyne.Window, files []app.LockedFile) app.LockedDecision {
+	decision := make(chan app.LockedDecision, 1)
+
+	fyne.Do(func() {
+		lines := make([]string, 0, len(files))
+		for i, file := range files {
+			if i == 10 {
+				lines = append(lines, fmt.Sprintf("...and %d more", len(files)-i))
+				break
+			}
+			lines = append(lines, file.String())
+		}
+		msg := widget.NewLabel(fmt.Sprintf("These files are open in other programs and can't be moved right now:\n\n%s\n\nClose them and choose Retry, or Skip to move everything else.", strings.Join(lines, "\n")))
+
+		d := dialog.NewCustomWithoutButtons("Files in Use", msg, window)
+		answer := func(choice app.LockedDecision) func() {
+			return func() {
+				d.Hide()
+				decision <- choice
+			}
+		}
+		retryBtn := widget.NewButton("Retry", answer(app.LockedRetry))
+		retryBtn.Importance = widget.HighImportance
+		d.SetButtons([]fyne.CanvasObject{
+			widget.NewButton("Cancel", answer(app.LockedAbort)),
+			widget.NewButton("Skip", answer(app.LockedSkip)),
+			retryBtn,
+		})
+		d.Show()
+	})
+
+	return <-decision
+}
+
+// promptBudget blocks the requesting goroutine while asking whether to keep spending
+// past the configured per-run or per-month ceiling
+func (mw *MainWindow) promptBudget(scope app.BudgetScope, spent, limit float64) bool {
+	decision := make(chan bool, 1)
+
+	fyne.Do(func() {
+		mw.statusLabel.SetText("Paused: spending budget reached")
+		msg := fmt.Sprintf("Estimated spend this %s is $%.4f, and the next request would exceed the $%.2f budget.\n\nContinue anyway for the rest of this %s?", scope, spent, limit, scope)
+		dialog.ShowCustomConfirm("Budget Reached", "Continue", "Stop", widget.NewLabel(msg), func(cont bool) {
+			if cont {
+				mw.statusLabel.SetText("Continuing past budget...")
+			}
+			decision <- cont
+		}, mw.window)
+	})
+
+	return <-decision
+}
+
+// confirmDeepAnalysis asks before deep analysis sends more files to the model than the configured
+// threshold. It is called on the analysis goroutine.
+func (mw *MainWindow) confirmDeepAnalysis(files []string) bool {
+	estimate := app.EstimateDeepAnalysis(files, mw.config)
+	if !estimate.NeedsConfirmation(mw.config) {
+		return true
+	}
+
+	decision := make(chan bool, 1)
+	fyne.Do(func() {
+		mw.statusLabel.SetText("Paused: confirm deep analysis")
+		var msg strings.Builder
+		fmt.Fprintf(&msg, "Deep analysis is about to describe %d new or changed files (%s).\n\n", estimate.Files, formatFileSize(estimate.Bytes))
+		fmt.Fprintf(&msg, "Model calls: %d\n", estimate.Calls)
+		fmt.Fprintf(&msg, "Tokens: up to about %d input and %d output\n", estimate.InputTokens, estimate.OutputTokens)
+		if estimate.Cost > 0 {
+			fmt.Fprintf(&msg, "Cost: up to about $%.2f\n", estimate.Cost)
+		} else {
+			msg.WriteString("Cost: set token prices in Configuration to see an estimate\n")
+		}
+		msg.WriteString("\nSkipping plans with the descriptions already in the index.")
+		dialog.ShowCustomConfirm("Start Deep Analysis?", "Analyze Files", "Skip", widget.NewLabel(msg.String()), func(analyze bool) {
+			if analyze {
+				mw.statusLabel.SetText("Indexing files...")
+			} else {
+				mw.statusLabel.SetText("Planning without deep analysis of new files...")
+			}
+			decision <- analyze
+		}, mw.window)
+	})
+
+	return <-decision
+}
+
 func (mw *MainWindow) displayExecutionResult(result app.ExecutionResult, isRollback bool) {
 	var resultsText strings.Builder
 	basePath := mw.dirEntry.Text
 
 	if !isRollback {
-		mw.lastSuccessfulResults = []app.OperationResult{}
+		// From here on the folder differs from the plan's fingerprint because of our own moves
+		mw.currentFingerprint = nil
+		// A resumed run adds to the moves of the part before the stop, so Undo covers both
+		mw.lastSuccessfulResults = append([]app.OperationResult{}, mw.stoppedResults...)
 	}
 
 	title := map[bool]string{false: "Execution Results", true: "Rollback Results"}[isRollback]
@@ -386,6 +1596,8 @@ func (mw *MainWindow) displayExecutionResult(result app.ExecutionResult, isRollb
 			if !isRollback {
 				mw.lastSuccessfulResults = append(mw.lastSuccessfulResults, opResult)
 			}
+		} else if errors.Is(opResult.Error, app.ErrExecutionStopped) {
+			resultsText.WriteString(fmt.Sprintf("… [NOT RUN] %s → %s\n", fromRel, toRel))
 		} else {
 			resultsText.WriteString(fmt.Sprintf("✗ [FAILED] %s → %s\n  Error: %v\n", fromRel, toRel, opResult.Error))
 		}
@@ -395,10 +1607,79 @@ func (mw *MainWindow) displayExecutionResult(result app.ExecutionResult, isRollb
 		resultsText.WriteString(fmt.Sprintf("\n✨ Cleaned up %d empty directories.\n", result.CleanedDirs))
 	}
 
+	if len(result.PermissionChanges) > 0 {
+		resultsText.WriteString(fmt.Sprintf("\n🔒 Permissions or owners changed on %d items:\n", len(result.PermissionChanges)))
+		for _, change := range result.PermissionChanges {
+			change.Path = mw.getRelativePath(basePath, change.Path)
+			resultsText.WriteString(fmt.Sprintf("  %s\n", change))
+		}
+	}
+
+	if len(result.Shortcuts) > 0 {
+		resultsText.WriteString(fmt.Sprintf("\n🔗 Shortcuts pointing at moved files: %d\n", len(result.Shortcuts)))
+		for _, fix := range result.Shortcuts {
+			status := "updated"
+			if fix.Error != nil {
+				status = fix.Error.Error()
+			}
+			resultsText.WriteString(fmt.Sprintf("  %s → %s (%s)\n", mw.getRelativePath(basePath, fix.Shortcut), mw.getRelativePath(basePath, fix.NewTarget), status))
+		}
+	}
+
+	if len(result.References) > 0 {
+		resultsText.WriteString(fmt.Sprintf("\n📝 References updated in %d files:\n", len(result.References)))
+		for _, update := range result.References {
+			resultsText.WriteString(fmt.Sprintf("  %s\n", mw.getRelativePath(basePath, update.File)))
+			if update.Error != nil {
+				resultsText.WriteString(fmt.Sprintf("    Error: %v\n", update.Error))
+				continue
+			}
+			for _, change := range update.Changes {
+				resultsText.WriteString(fmt.Sprintf("    %s → %s\n", change.Old, change.New))
+			}
+		}
+	}
+
+	for _, section := range []struct {
+		title string
+		paths []string
+	}{
+		{"Unexpected files (not put there by the plan)", result.Layout.Unexpected},
+		{"Missing files (expected after the plan)", result.Layout.Missing},
+	} {
+		if len(section.paths) == 0 {
+			continue
+		}
+		resultsText.WriteString(fmt.Sprintf("\n🔍 %s: %d\n", section.title, len(section.paths)))
+		for i, path := range section.paths {
+			if i == maxLayoutDiffLines {
+				resultsText.WriteString(fmt.Sprintf("  ...and %d more\n", len(section.paths)-i))
+				break
+			}
+			resultsText.WriteString(fmt.Sprintf("  %s\n", mw.getRelativePath(basePath, path)))
+		}
+	}
+
+	if len(result.HashMismatches) > 0 {
+		resultsText.WriteString(fmt.Sprintf("\n🛑 Content changed in %d of %d checked files:\n", len(result.HashMismatches), result.HashesVerified))
+		for _, mismatch := range result.HashMismatches {
+			resultsText.WriteString(fmt.Sprintf("  %s\n", mismatch.String()))
+		}
+	} else if result.HashesVerified > 0 {
+		resultsText.WriteString(fmt.Sprintf("\n🔎 Content verified: %d moved files match their originals.\n", result.HashesVerified))
+	}
+
 	verificationMsg := ""
 	verificationSuccess := false
 
-	if result.VerificationError != nil {
+	if result.Aborted {
+		verificationMsg = fmt.Sprintf("\n⏸ EXECUTION ABORTED: %v", result.VerificationError)
+	} else if result.Stopped {
+		verificationMsg = fmt.Sprintf("\n⏹ EXECUTION STOPPED: %d operations were not run. Resume them or undo the moves made so far.", len(result.Remaining()))
+		if result.VerificationError != nil {
+			verificationMsg += fmt.Sprintf(" (%v)", result.VerificationError)
+		}
+	} else if result.VerificationError != nil {
 		verificationMsg = fmt.Sprintf("\n⚠ VERIFICATION ERROR: %v", result.VerificationError)
 	} else {
 		if result.InitialFileCount == result.FinalFileCount {
@@ -417,17 +1698,43 @@ func (mw *MainWindow) displayExecutionResult(result app.ExecutionResult, isRollb
 	newContent := fmt.Sprintf("=== %s ===\n%s", title, resultsText.String())
 	mw.setOutputText(newContent)
 
+	if !isRollback && result.Stopped {
+		// The journal of what ran stays in lastSuccessfulResults; what didn't becomes the plan
+		mw.stoppedResults = mw.lastSuccessfulResults
+		mw.currentOperations = result.Remaining()
+		mw.executeBtn.SetText(fmt.Sprintf("▶ Resume Remaining Operations (%d)", len(mw.currentOperations)))
+		mw.executeBtn.Show()
+		mw.setPlanEditable(true)
+	} else if !isRollback {
+		mw.stoppedResults = nil
+		mw.executeBtn.SetText(executeBtnText)
+	}
+
 	if !isRollback && len(mw.lastSuccessfulResults) > 0 {
 		mw.rollbackBtn.Show()
 		mw.refreshBottomStatus()
 	} else if isRollback && result.FailCount == 0 {
 		// If rollback finished successfully, we return to the "Ready to Execute" state
+		if mw.stoppedResults != nil {
+			// The moves made before a stop are back in the plan, ahead of the ones that never ran
+			var undone []app.FileOperation
+			for _, opResult := range mw.stoppedResults {
+				undone = append(undone, opResult.Operation)
+			}
+			mw.currentOperations = append(undone, mw.currentOperations...)
+			mw.stoppedResults = nil
+			mw.executeBtn.SetText(executeBtnText)
+		}
 		mw.executeBtn.Show()
+		mw.setPlanEditable(true)
 		mw.refreshBottomStatus()
 		mw.statusLabel.SetText("Rollback Complete. Ready to Execute original plan.")
 	}
 
-	if result.FailCount > 0 || !verificationSuccess {
+	if result.Stopped {
+		// Not run operations are counted as failures, but nothing went wrong
+		mw.statusLabel.SetText(fmt.Sprintf("Stopped: %d done, %d remaining", result.SuccessCount, len(result.Remaining())))
+	} else if result.FailCount > 0 || !verificationSuccess {
 		msg := finalStatus + "\n\n" + verificationMsg
 		if result.FailCount > 0 {
 			msg += "\n\nSome operations failed."
@@ -438,6 +1745,26 @@ func (mw *MainWindow) displayExecutionResult(result app.ExecutionResult, isRollb
 		msgTitle := map[bool]string{false: "Success", true: "Rollback Successful"}[isRollback]
 		dialog.ShowInformation(msgTitle, "All operations processed successfully!\n"+verificationMsg, mw.window)
 	}
+
+	if len(result.PermissionChanges) > 0 {
+		mw.offerPermissionRestore(result.PermissionChanges)
+	}
+}
+
+// offerPermissionRestore asks whether to put back permissions that changed during execution
+func (mw *MainWindow) offerPermissionRestore(changes []app.PermissionChange) {
+	msg := fmt.Sprintf("%d moved items have different permissions or owners than before.\nRestore the original ones?", len(changes))
+	dialog.ShowConfirm("Permissions Changed", msg, func(restore bool) {
+		if !restore {
+			return
+		}
+		restored, err := mw.orchestrator.RestorePermissions(changes)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("restored %d of %d items: %w", restored, len(changes), err), mw.window)
+			return
+		}
+		mw.statusLabel.SetText(fmt.Sprintf("Restored permissions of %d items", restored))
+	}, mw.window)
 }
 
 func (mw *MainWindow) updateIndexDetailsVisibility() {
@@ -542,6 +1869,138 @@ func (mw *MainWindow) onDeleteIndex() {
 	}, mw.window)
 }
 
+func (mw *MainWindow) onSyncIndex() {
+	if mw.orchestrator == nil {
+		dialog.ShowError(fmt.Errorf("orchestrator not initialized"), mw.window)
+		return
+	}
+	if strings.TrimSpace(mw.config.IndexSyncDir) == "" {
+		dialog.ShowInformation("Index Sync", "Set an index sync folder on a shared drive in the configuration first.\n\nEvery machine that points at the same folder shares its file descriptions, so deep analysis only has to run once.", mw.window)
+		return
+	}
+
+	go func() {
+		fyne.Do(func() {
+			mw.progressBar.Show()
+			mw.refreshBottomStatus()
+			mw.statusLabel.SetText("Syncing index...")
+		})
+
+		result, err := mw.orchestrator.SyncIndex()
+
+		fyne.Do(func() {
+			mw.progressBar.Hide()
+			mw.refreshBottomStatus()
+			mw.statusLabel.SetText("Ready")
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("index sync failed: %w", err), mw.window)
+				return
+			}
+			dialog.ShowInformation("Index Synced", fmt.Sprintf("Shared %d descriptions and imported %d from other machines.\n%d entries were skipped because the files differ or the local description is newer.", result.Exported, result.Imported, result.Skipped), mw.window)
+		})
+	}()
+}
+
+// SetDirectory fills in the folder to organize
+func (mw *MainWindow) SetDirectory(dir string) {
+	mw.dirEntry.SetText(dir)
+}
+
+// Raise brings the window to front, e.g. when a later launch hands over to this instance
+func (mw *MainWindow) Raise() {
+	mw.window.Show()
+	mw.window.RequestFocus()
+}
+
+// SetShutdown makes closing the window stop background jobs cleanly, asking first if files are being moved
+func (mw *MainWindow) SetShutdown(shutdown *app.ShutdownCoordinator) {
+	mw.shutdown = shutdown
+	mw.window.SetCloseIntercept(mw.onClose)
+}
+
+// onClose quits right away unless an execution is running, which is only stopped if the user agrees.
+// Indexing stops after the current file and resumes where it left off next time.
+func (mw *MainWindow) onClose() {
+	if len(mw.shutdown.Running(app.JobExecution)) == 0 {
+		mw.quit()
+		return
+	}
+	dialog.ShowConfirm("Files Are Being Moved",
+		"Quitting stops the execution once the moves in progress have finished. The remaining moves are not made.\n\nStop and quit?",
+		func(quit bool) {
+			if quit {
+				mw.quit()
+			}
+		}, mw.window)
+}
+
+func (mw *MainWindow) quit() {
+	if len(mw.shutdown.Running("")) == 0 {
+		mw.window.Close()
+		return
+	}
+	mw.executeBtn.Disable()
+	mw.analyzeBtn.Disable()
+	mw.progressBar.Show()
+	mw.statusLabel.SetText("Finishing the current step before quitting...")
+	go func() {
+		mw.shutdown.Shutdown(app.DefaultShutdownTimeout)
+		fyne.Do(mw.window.Close)
+	}()
+}
+
+// SetUpdateChecker enables update checks and runs one in the background if they're turned on
+func (mw *MainWindow) SetUpdateChecker(updates *app.UpdateChecker) {
+	mw.updates = updates
+	if updates.Enabled() {
+		go mw.checkForUpdates(false)
+	}
+}
+
+// checkForUpdates looks for a newer release. Automatic checks stay quiet unless there is one.
+func (mw *MainWindow) checkForUpdates(manual bool) {
+	if mw.updates == nil {
+		return
+	}
+	info, err := mw.updates.Check(context.Background())
+	if err != nil {
+		mw.logger.Error("Update check failed: %v", err)
+		if manual {
+			fyne.Do(func() { dialog.ShowError(err, mw.window) })
+		}
+		return
+	}
+
+	fyne.Do(func() {
+		if !info.Available {
+			if !manual {
+				return
+			}
+			msg := fmt.Sprintf("You're running the latest version (%s).", info.CurrentVersion)
+			if info.CurrentVersion == "" {
+				msg = fmt.Sprintf("This is a development build. The latest release is %s.", info.LatestVersion)
+			}
+			dialog.ShowInformation("No Updates", msg, mw.window)
+			return
+		}
+
+		msg := fmt.Sprintf("VibesAndFolders %s is available (you have %s).", info.LatestVersion, info.CurrentVersion)
+		if info.StagedPath != "" {
+			msg += fmt.Sprintf("\n\nIt has been downloaded to:\n%s\n\nQuit the app and install it from there.", info.StagedPath)
+		}
+		label := widget.NewLabel(msg)
+		label.Wrapping = fyne.TextWrapWord
+		dialog.ShowCustomConfirm("Update Available", "Open Release Page", "Later", label, func(open bool) {
+			if !open {
+				return
+			}
+			if releaseURL, err := url.Parse(info.ReleaseURL); err == nil {
+				mw.app.OpenURL(releaseURL)
+			}
+		}, mw.window)
+	})
+}
+
 func (mw *MainWindow) showAboutDialog() {
 	version := mw.app.Metadata().Version
 	if version == "" {
@@ -566,3 +2025,13 @@ func (mw *MainWindow) Show() {
 func (mw *MainWindow) ShowAndRun() {
 	mw.window.ShowAndRun()
 }
+
+// truncateMiddle shortens long paths while keeping both the top-level folder and the file name visible
+func truncateMiddle(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	half := (maxLen - 3) / 2
+	return string(runes[:half]) + "..." + string(runes[len(runes)-half:])
+}
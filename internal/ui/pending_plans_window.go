@@ -0,0 +1,311 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"io.github.sandwichdoge.vibesandfolders/internal/app"
+)
+
+// PendingPlansWindow lists plans from automated jobs that wait for review, so they can be
+// approved or rejected in batches
+type PendingPlansWindow struct {
+	app          fyne.App
+	window       fyne.Window
+	orchestrator *app.Orchestrator
+	config       *app.Config
+	logger       *app.Logger
+
+	listContainer *fyne.Container
+	statusLabel   *widget.Label
+	progressBar   *widget.ProgressBarInfinite
+	approveBtn    *widget.Button
+	rejectBtn     *widget.Button
+
+	plans     []app.PendingPlan
+	selected  map[string]bool
+	approvals map[string]string // Plan ID -> reviewer, from imported reports
+}
+
+func NewPendingPlansWindow(fyneApp fyne.App, orchestrator *app.Orchestrator, config *app.Config, logger *app.Logger) *PendingPlansWindow {
+	ppw := &PendingPlansWindow{
+		app:          fyneApp,
+		window:       fyneApp.NewWindow("Pending Plans"),
+		orchestrator: orchestrator,
+		config:       config,
+		logger:       logger,
+		selected:     make(map[string]bool),
+		approvals:    make(map[string]string),
+	}
+
+	ppw.initializeComponents()
+	ppw.setupLayout()
+	ppw.loadPlans()
+
+	return ppw
+}
+
+func (ppw *PendingPlansWindow) initializeComponents() {
+	ppw.listContainer = container.NewVBox()
+	ppw.statusLabel = widget.NewLabel("")
+	ppw.progressBar = widget.NewProgressBarInfinite()
+	ppw.progressBar.Hide()
+
+	ppw.approveBtn = widget.NewButton("Approve Selected", ppw.onApprove)
+	ppw.approveBtn.Importance = widget.HighImportance
+	ppw.rejectBtn = widget.NewButton("Reject Selected", ppw.onReject)
+	ppw.rejectBtn.Importance = widget.DangerImportance
+}
+
+func (ppw *PendingPlansWindow) setupLayout() {
+	selectAllBtn := widget.NewButton("Select All", func() {
+		for _, plan := range ppw.plans {
+			ppw.selected[plan.ID] = true
+		}
+		ppw.renderPlans()
+	})
+	selectNoneBtn := widget.NewButton("Select None", func() {
+		ppw.selected = make(map[string]bool)
+		ppw.renderPlans()
+	})
+
+	importBtn := widget.NewButton("Import Approval...", ppw.onImportApproval)
+
+	helpLabel := widget.NewLabel("Plans from folder watchers and scheduled jobs that were not applied automatically. Approved plans are executed right away.")
+	helpLabel.Wrapping = fyne.TextWrapWord
+
+	content := container.NewBorder(
+		container.NewVBox(
+			helpLabel,
+			container.NewBorder(nil, nil, container.NewHBox(selectAllBtn, selectNoneBtn), importBtn),
+			widget.NewSeparator(),
+		),
+		container.NewVBox(
+			widget.NewSeparator(),
+			ppw.progressBar,
+			container.NewBorder(nil, nil, nil, container.NewHBox(ppw.rejectBtn, ppw.approveBtn), ppw.statusLabel),
+		),
+		nil, nil,
+		container.NewScroll(ppw.listContainer),
+	)
+
+	ppw.window.SetContent(container.NewPadded(content))
+	ppw.window.Resize(fyne.NewSize(900, 600))
+}
+
+func (ppw *PendingPlansWindow) loadPlans() {
+	ppw.plans = ppw.orchestrator.PendingPlans()
+
+	// Forget selections of plans that are gone
+	current := make(map[string]bool)
+	for _, plan := range ppw.plans {
+		if ppw.selected[plan.ID] {
+			current[plan.ID] = true
+		}
+	}
+	ppw.selected = current
+
+	ppw.renderPlans()
+}
+
+func (ppw *PendingPlansWindow) renderPlans() {
+	ppw.listContainer.Objects = nil
+
+	if len(ppw.plans) == 0 {
+		emptyLabel := widget.NewLabel("No plans are waiting for review")
+		emptyLabel.Alignment = fyne.TextAlignCenter
+		ppw.listContainer.Add(emptyLabel)
+	}
+	for _, plan := range ppw.plans {
+		ppw.listContainer.Add(ppw.createPlanCard(plan))
+	}
+	ppw.listContainer.Refresh()
+	ppw.updateStatus()
+}
+
+func (ppw *PendingPlansWindow) createPlanCard(plan app.PendingPlan) fyne.CanvasObject {
+	title := fmt.Sprintf("%s: %d operations in %s", plan.JobName, len(plan.Operations), plan.BasePath)
+	selectCheck := widget.NewCheck(title, func(checked bool) {
+		ppw.selected[plan.ID] = checked
+		ppw.updateStatus()
+	})
+	selectCheck.SetChecked(ppw.selected[plan.ID])
+
+	meta := fmt.Sprintf("Queued %s  |  %s", formatTimestamp(plan.CreatedAt), plan.Reason)
+	if reviewer, ok := ppw.approvals[plan.ID]; ok {
+		meta += fmt.Sprintf("  |  Approved by %s", reviewer)
+	}
+	metaLabel := widget.NewLabel(meta)
+	metaLabel.TextStyle = fyne.TextStyle{Italic: true}
+	metaLabel.Wrapping = fyne.TextWrapWord
+
+	diffLabel := widget.NewLabelWithStyle(app.FormatPlanDiff(plan), fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	preview := widget.NewAccordion(widget.NewAccordionItem("Preview", diffLabel))
+
+	separator := canvas.NewLine(theme.ShadowColor())
+	separator.StrokeWidth = 1
+
+	exportBtn := widget.NewButton("Export Report...", func() {
+		showExportPlanReport(ppw.window, plan, ppw.logger)
+	})
+
+	card := container.NewVBox(container.NewBorder(nil, nil, nil, exportBtn, selectCheck), metaLabel)
+	if len(plan.Conflicts) > 0 {
+		conflictLines := make([]string, 0, len(plan.Conflicts))
+		for _, conflict := range plan.Conflicts {
+			conflictLines = append(conflictLines, "• "+conflict.String())
+		}
+		conflictLabel := widget.NewLabel(fmt.Sprintf("%d operations were dropped because other plans ran first:\n%s", len(plan.Conflicts), strings.Join(conflictLines, "\n")))
+		conflictLabel.Importance = widget.WarningImportance
+		conflictLabel.Wrapping = fyne.TextWrapWord
+		card.Add(conflictLabel)
+	}
+	card.Add(preview)
+	card.Add(separator)
+	return card
+}
+
+func (ppw *PendingPlansWindow) updateStatus() {
+	count := len(ppw.selectedIDs())
+	ppw.statusLabel.SetText(fmt.Sprintf("%d of %d plans selected", count, len(ppw.plans)))
+	if count == 0 {
+		ppw.approveBtn.Disable()
+		ppw.rejectBtn.Disable()
+	} else {
+		ppw.approveBtn.Enable()
+		ppw.rejectBtn.Enable()
+	}
+}
+
+// selectedIDs returns the selected plans in queue order
+func (ppw *PendingPlansWindow) selectedIDs() []string {
+	var ids []string
+	for _, plan := range ppw.plans {
+		if ppw.selected[plan.ID] {
+			ids = append(ids, plan.ID)
+		}
+	}
+	return ids
+}
+
+func (ppw *PendingPlansWindow) onApprove() {
+	ids := ppw.selectedIDs()
+	dialog.ShowConfirm("Approve Plans",
+		fmt.Sprintf("Execute %d selected plans now?", len(ids)),
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			ppw.setBusy(true, "Executing approved plans...")
+
+			go func() {
+				succeeded, failed := 0, 0
+				var firstErr error
+				for _, id := range ids {
+					var throttleDelay time.Duration
+					var throttleBatch int
+					for _, plan := range ppw.plans {
+						if plan.ID == id {
+							throttleDelay, throttleBatch = ppw.config.ThrottleFor(plan.BasePath)
+						}
+					}
+					result, err := ppw.orchestrator.ApprovePendingPlan(id, app.ExecutionRequest{
+						Parallelism: ppw.config.ParallelMoves,
+						Staged:      ppw.config.StagedExecution,
+
+						CheckOpenFiles:   ppw.config.CheckOpenFiles,
+						AuditPermissions: ppw.config.AuditPermissions,
+						RewriteShortcuts: ppw.config.RewriteShortcuts,
+						UpdateReferences: ppw.config.UpdateReferences,
+						OnLocked: func(files []app.LockedFile) app.LockedDecision {
+							return askLockedFiles(ppw.window, files)
+						},
+
+						ThrottleDelay: throttleDelay,
+						ThrottleBatch: throttleBatch,
+					})
+					if err != nil && firstErr == nil {
+						firstErr = err
+					}
+					succeeded += result.SuccessCount
+					failed += result.FailCount
+				}
+
+				fyne.Do(func() {
+					ppw.setBusy(false, "")
+					ppw.loadPlans()
+					if firstErr != nil {
+						dialog.ShowError(firstErr, ppw.window)
+						return
+					}
+					message := fmt.Sprintf("%d operations succeeded, %d failed.", succeeded, failed)
+					if failed > 0 {
+						message += " The operations that didn't run are back in the queue."
+					}
+					dialog.ShowInformation("Plans Executed", message, ppw.window)
+				})
+			}()
+		}, ppw.window)
+}
+
+func (ppw *PendingPlansWindow) onReject() {
+	ids := ppw.selectedIDs()
+	dialog.ShowConfirm("Reject Plans",
+		fmt.Sprintf("Discard %d selected plans? No files will be moved.", len(ids)),
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			for _, id := range ids {
+				if err := ppw.orchestrator.RejectPendingPlan(id); err != nil {
+					ppw.logger.Error("Failed to reject plan %s: %v", id, err)
+				}
+			}
+			ppw.loadPlans()
+		}, ppw.window)
+}
+
+// onImportApproval reads a reviewed report and selects the plan it approves
+func (ppw *PendingPlansWindow) onImportApproval() {
+	showImportPlanApproval(ppw.window, func(report []byte) {
+		approval, err := app.ReadPlanApproval(report)
+		if err != nil {
+			dialog.ShowError(err, ppw.window)
+			return
+		}
+		for _, plan := range ppw.plans {
+			if app.PlanFingerprint(plan.Operations) == approval.PlanID {
+				ppw.logger.Info("Plan %s from %s approved by %s", approval.PlanID, plan.JobName, approval.ApprovedBy)
+				ppw.approvals[plan.ID] = approval.ApprovedBy
+				ppw.selected[plan.ID] = true
+				ppw.renderPlans()
+				return
+			}
+		}
+		dialog.ShowError(fmt.Errorf("%w: no queued plan matches %s; it may have changed or already run", app.ErrApprovalMismatch, approval.PlanID), ppw.window)
+	})
+}
+
+func (ppw *PendingPlansWindow) setBusy(busy bool, status string) {
+	if busy {
+		ppw.progressBar.Show()
+		ppw.approveBtn.Disable()
+		ppw.rejectBtn.Disable()
+		ppw.statusLabel.SetText(status)
+		return
+	}
+	ppw.progressBar.Hide()
+	ppw.updateStatus()
+}
+
+func (ppw *PendingPlansWindow) Show() {
+	ppw.window.Show()
+}
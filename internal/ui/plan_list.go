@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"io.github.sandwichdoge.vibesandfolders/internal/app"
+)
+
+// operationRow is a plan list row that calls onDoubleTap when double-clicked
+type operationRow struct {
+	widget.BaseWidget
+	label       *widget.Label
+	onDoubleTap func()
+}
+
+func newOperationRow() *operationRow {
+	row := &operationRow{label: widget.NewLabel("")}
+	row.label.Truncation = fyne.TextTruncateEllipsis
+	row.ExtendBaseWidget(row)
+	return row
+}
+
+func (r *operationRow) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(r.label)
+}
+
+func (r *operationRow) DoubleTapped(*fyne.PointEvent) {
+	if r.onDoubleTap != nil {
+		r.onDoubleTap()
+	}
+}
+
+// newPlanList lists the operations waiting to be executed that match the filter; double-clicking
+// one edits its destination
+func (mw *MainWindow) newPlanList() *widget.List {
+	return widget.NewList(
+		func() int { return len(mw.planView) },
+		func() fyne.CanvasObject { return newOperationRow() },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			basePath := mw.dirEntry.Text
+			index := mw.planView[id]
+			op := mw.currentOperations[index]
+			row := item.(*operationRow)
+			row.label.Importance = widget.MediumImportance
+			if op.Flag != "" || app.EscapesBase(basePath, op.To) {
+				row.label.Importance = widget.WarningImportance
+			}
+			row.label.SetText(fmt.Sprintf("%s → %s", mw.getRelativePath(basePath, op.From), mw.getRelativePath(basePath, op.To)))
+			row.onDoubleTap = func() {
+				mw.editDestination(index)
+			}
+		},
+	)
+}
+
+// refreshPlanView applies the filter box to the current plan and redraws the list
+func (mw *MainWindow) refreshPlanView() {
+	filter := app.ParsePlanFilter(mw.planFilterEntry.Text)
+	mw.planView = filter.Indices(mw.dirEntry.Text, mw.currentOperations)
+	if filter.Empty() {
+		mw.planCountLabel.SetText(fmt.Sprintf("%d operations", len(mw.currentOperations)))
+	} else {
+		mw.planCountLabel.SetText(fmt.Sprintf("Showing %d of %d operations", len(mw.planView), len(mw.currentOperations)))
+	}
+	mw.planList.UnselectAll()
+	mw.planList.Refresh()
+}
+
+// setPlanEditable shows or hides the plan list and the form for adding operations
+func (mw *MainWindow) setPlanEditable(editable bool) {
+	if editable {
+		mw.planPanel.Show()
+		mw.addOperationForm.Show()
+		mw.refreshPlanView()
+	} else {
+		mw.planPanel.Hide()
+		mw.addOperationForm.Hide()
+	}
+}
+
+// editDestination lets the user change where one operation moves its file. The new destination is
+// checked on every keystroke and only accepted once it is valid.
+func (mw *MainWindow) editDestination(index int) {
+	if index >= len(mw.currentOperations) {
+		return
+	}
+	basePath := mw.dirEntry.Text
+	constraints := app.ParseConstraints(mw.config.ConstraintsFor(basePath))
+	original := mw.currentOperations[index]
+
+	entry := widget.NewSelectEntry(nil)
+	entry.SetText(filepath.ToSlash(mw.getRelativePath(basePath, original.To)))
+	entry.OnChanged = func(text string) {
+		mw.completeDestination(entry, text, index)
+	}
+	entry.Validator = func(text string) error {
+		_, err := app.EditDestination(basePath, mw.currentOperations, index, text, mw.currentGrounding, constraints)
+		return err
+	}
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Move", widget.NewLabel(mw.getRelativePath(basePath, original.From))),
+		widget.NewFormItem("To", entry),
+	}
+	d := dialog.NewForm("Edit Destination", "Save", "Cancel", items, func(save bool) {
+		if !save {
+			return
+		}
+		op, err := app.EditDestination(basePath, mw.currentOperations, index, entry.Text, mw.currentGrounding, constraints)
+		if err != nil {
+			dialog.ShowError(err, mw.window)
+			return
+		}
+		mw.currentOperations[index] = op
+		mw.refreshPlanView()
+		mw.appendManualChange(fmt.Sprintf("✎ %s → %s\n  (was %s)\n", mw.getRelativePath(basePath, op.From),
+			mw.getRelativePath(basePath, op.To), mw.getRelativePath(basePath, original.To)))
+		mw.statusLabel.SetText(fmt.Sprintf("Changed the destination of %s. Ready to execute %d operations",
+			mw.getRelativePath(basePath, op.From), len(mw.currentOperations)))
+	}, mw.window)
+	d.Resize(fyne.NewSize(600, 200))
+	d.Show()
+}
+
+// appendManualChange records an addition or edit below the AI's plan in the output
+func (mw *MainWindow) appendManualChange(text string) {
+	output := mw.lastOutputContent
+	if !strings.Contains(output, manualChangesHeader) {
+		output += manualChangesHeader
+	}
+	mw.setOutputText(output + text)
+}
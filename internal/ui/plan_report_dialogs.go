@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+
+	"io.github.sandwichdoge.vibesandfolders/internal/app"
+)
+
+// showExportPlanReport asks where to save a review report for plan; .html files get HTML, anything else Markdown
+func showExportPlanReport(window fyne.Window, plan app.PendingPlan, logger *app.Logger) {
+	d := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		report, err := app.RenderPlanReport(plan, writer.URI().Name())
+		if err == nil {
+			_, err = io.WriteString(writer, report)
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("failed to export plan: %w", err), window)
+			return
+		}
+		logger.Info("Exported plan %s for review to %s", app.PlanFingerprint(plan.Operations), writer.URI().Path())
+		dialog.ShowInformation("Plan Exported", "Send the report to a colleague. Once they've written their name after \"Approved by:\", import it back with Import Approval.", window)
+	}, window)
+	d.SetFileName(fmt.Sprintf("plan-%s.md", app.PlanFingerprint(plan.Operations)))
+	d.SetFilter(storage.NewExtensionFileFilter([]string{".md", ".html", ".htm"}))
+	d.Show()
+}
+
+// showImportPlanApproval lets the user pick a reviewed report and hands its contents to onLoaded
+func showImportPlanApproval(window fyne.Window, onLoaded func(report []byte)) {
+	d := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		report, err := io.ReadAll(reader)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("failed to read report: %w", err), window)
+			return
+		}
+		onLoaded(report)
+	}, window)
+	d.SetFilter(storage.NewExtensionFileFilter([]string{".md", ".html", ".htm"}))
+	d.Show()
+}
@@ -0,0 +1,202 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"io.github.sandwichdoge.vibesandfolders/internal/app"
+)
+
+// PromptComparisonWindow runs the same folder through two system prompts or models and shows
+// the plans side by side, for tuning custom prompts. Nothing is executed.
+type PromptComparisonWindow struct {
+	window       fyne.Window
+	orchestrator *app.Orchestrator
+	config       *app.Config
+	logger       *app.Logger
+	request      app.AnalysisRequest
+
+	modelA, modelB   *widget.Entry
+	promptA, promptB *widget.Entry
+	runBtn           *widget.Button
+	progressBar      *widget.ProgressBarInfinite
+	statusLabel      *widget.Label
+	stabilityLabel   *widget.Label
+	resultsList      *widget.List
+
+	rows []app.PlanComparisonRow
+}
+
+func NewPromptComparisonWindow(fyneApp fyne.App, orchestrator *app.Orchestrator, config *app.Config, logger *app.Logger, request app.AnalysisRequest) *PromptComparisonWindow {
+	pcw := &PromptComparisonWindow{
+		window:       fyneApp.NewWindow("Compare Prompts"),
+		orchestrator: orchestrator,
+		config:       config,
+		logger:       logger,
+		request:      request,
+	}
+
+	pcw.initializeComponents()
+	pcw.setupLayout()
+
+	return pcw
+}
+
+func (pcw *PromptComparisonWindow) initializeComponents() {
+	newModelEntry := func() *widget.Entry {
+		entry := widget.NewEntry()
+		entry.SetText(pcw.config.Model)
+		entry.SetPlaceHolder(pcw.config.Model)
+		return entry
+	}
+	newPromptEntry := func() *widget.Entry {
+		entry := widget.NewMultiLineEntry()
+		entry.SetText(pcw.config.SystemPrompt)
+		entry.Wrapping = fyne.TextWrapWord
+		entry.SetMinRowsVisible(10)
+		return entry
+	}
+	pcw.modelA, pcw.modelB = newModelEntry(), newModelEntry()
+	pcw.promptA, pcw.promptB = newPromptEntry(), newPromptEntry()
+
+	pcw.runBtn = widget.NewButton("Run Comparison", pcw.onRun)
+	pcw.runBtn.Importance = widget.HighImportance
+	pcw.progressBar = widget.NewProgressBarInfinite()
+	pcw.progressBar.Hide()
+	pcw.statusLabel = widget.NewLabel("Runs the same scan against both variants. Identical variants measure how stable a prompt is between runs.")
+	pcw.statusLabel.Wrapping = fyne.TextWrapWord
+	pcw.stabilityLabel = widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+
+	pcw.resultsList = widget.NewList(
+		func() int { return len(pcw.rows) },
+		func() fyne.CanvasObject {
+			return container.NewGridWithColumns(3, widget.NewLabel(""), widget.NewLabel(""), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			row := pcw.rows[id]
+			cells := item.(*fyne.Container).Objects
+			texts := []string{pcw.relative(row.From), pcw.destination(row.ToA), pcw.destination(row.ToB)}
+			for i, cell := range cells {
+				label := cell.(*widget.Label)
+				label.Importance = widget.MediumImportance
+				if !row.Agrees() {
+					label.Importance = widget.WarningImportance
+				}
+				label.SetText(texts[i])
+			}
+		},
+	)
+}
+
+func (pcw *PromptComparisonWindow) setupLayout() {
+	variant := func(title string, model, prompt *widget.Entry) fyne.CanvasObject {
+		return container.NewBorder(
+			container.NewVBox(
+				widget.NewLabelWithStyle(title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewForm(widget.NewFormItem("Model", model)),
+			),
+			nil, nil, nil,
+			container.NewScroll(prompt),
+		)
+	}
+	variants := container.NewHSplit(
+		variant("Variant A", pcw.modelA, pcw.promptA),
+		variant("Variant B", pcw.modelB, pcw.promptB),
+	)
+
+	header := container.NewGridWithColumns(3,
+		widget.NewLabelWithStyle("File", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Plan A", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Plan B", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+	)
+	results := container.NewBorder(container.NewVBox(pcw.stabilityLabel, header), nil, nil, nil, pcw.resultsList)
+
+	split := container.NewVSplit(variants, results)
+	split.Offset = 0.45
+
+	folderLabel := widget.NewLabel(fmt.Sprintf("Folder: %s\nInstructions: %s", pcw.request.DirectoryPath, pcw.request.UserPrompt))
+	folderLabel.Wrapping = fyne.TextWrapWord
+
+	content := container.NewBorder(
+		folderLabel,
+		container.NewVBox(
+			widget.NewSeparator(),
+			pcw.progressBar,
+			container.NewBorder(nil, nil, nil, pcw.runBtn, pcw.statusLabel),
+		),
+		nil, nil,
+		split,
+	)
+
+	pcw.window.SetContent(container.NewPadded(content))
+	pcw.window.Resize(fyne.NewSize(1100, 750))
+}
+
+func (pcw *PromptComparisonWindow) onRun() {
+	a := app.PromptVariant{SystemPrompt: pcw.promptA.Text, Model: pcw.modelA.Text}
+	b := app.PromptVariant{SystemPrompt: pcw.promptB.Text, Model: pcw.modelB.Text}
+
+	pcw.runBtn.Disable()
+	pcw.progressBar.Show()
+	pcw.statusLabel.SetText("Scanning and asking both variants...")
+
+	go func() {
+		comparison := pcw.orchestrator.ComparePrompts(pcw.request, a, b)
+
+		fyne.Do(func() {
+			pcw.runBtn.Enable()
+			pcw.progressBar.Hide()
+
+			if comparison.Error != nil {
+				pcw.statusLabel.SetText(fmt.Sprintf("Comparison failed: %v", comparison.Error))
+				return
+			}
+
+			pcw.rows = comparison.Rows
+			pcw.resultsList.Refresh()
+			pcw.stabilityLabel.SetText(fmt.Sprintf("Stability: %.0f%% (%d of %d files handled the same way)",
+				comparison.Stability*100, countAgreeing(comparison.Rows), len(comparison.Rows)))
+
+			status := fmt.Sprintf("Plan A: %d operations. Plan B: %d operations.", len(comparison.OperationsA), len(comparison.OperationsB))
+			if comparison.ErrorA != nil {
+				status += fmt.Sprintf(" Variant A failed: %v.", comparison.ErrorA)
+			}
+			if comparison.ErrorB != nil {
+				status += fmt.Sprintf(" Variant B failed: %v.", comparison.ErrorB)
+			}
+			pcw.statusLabel.SetText(status)
+		})
+	}()
+}
+
+func (pcw *PromptComparisonWindow) relative(path string) string {
+	if rel, err := filepath.Rel(pcw.request.DirectoryPath, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+func (pcw *PromptComparisonWindow) destination(path string) string {
+	if path == "" {
+		return "(not moved)"
+	}
+	return pcw.relative(path)
+}
+
+func countAgreeing(rows []app.PlanComparisonRow) int {
+	count := 0
+	for _, row := range rows {
+		if row.Agrees() {
+			count++
+		}
+	}
+	return count
+}
+
+func (pcw *PromptComparisonWindow) Show() {
+	pcw.window.Show()
+}
@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"io.github.sandwichdoge.vibesandfolders/internal/app"
+)
+
+// SnapshotsWindow compares recorded states of a folder, showing which files appeared,
+// disappeared, moved or changed between two dates
+type SnapshotsWindow struct {
+	window       fyne.Window
+	orchestrator *app.Orchestrator
+	logger       *app.Logger
+	dirPath      string
+
+	olderSelect *widget.Select
+	newerSelect *widget.Select
+	takeBtn     *widget.Button
+	result      *widget.Label
+	statusLabel *widget.Label
+
+	snapshots []app.DirectorySnapshot // Newest first
+}
+
+func NewSnapshotsWindow(fyneApp fyne.App, orchestrator *app.Orchestrator, logger *app.Logger, dirPath string) *SnapshotsWindow {
+	sw := &SnapshotsWindow{
+		window:       fyneApp.NewWindow("Folder Snapshots"),
+		orchestrator: orchestrator,
+		logger:       logger,
+		dirPath:      dirPath,
+	}
+
+	sw.olderSelect = widget.NewSelect(nil, func(string) { sw.compare() })
+	sw.newerSelect = widget.NewSelect(nil, func(string) { sw.compare() })
+	sw.takeBtn = widget.NewButton("Take Snapshot Now", sw.onTakeSnapshot)
+	sw.result = widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	sw.statusLabel = widget.NewLabel("")
+
+	helpLabel := widget.NewLabel(fmt.Sprintf("Snapshots of %s. Folders listed under Settings > Snapshot Folders are recorded automatically.", dirPath))
+	helpLabel.Wrapping = fyne.TextWrapWord
+
+	sw.window.SetContent(container.NewPadded(container.NewBorder(
+		container.NewVBox(
+			helpLabel,
+			widget.NewForm(
+				widget.NewFormItem("From", sw.olderSelect),
+				widget.NewFormItem("To", sw.newerSelect),
+			),
+			widget.NewSeparator(),
+		),
+		container.NewBorder(nil, nil, nil, sw.takeBtn, sw.statusLabel),
+		nil, nil,
+		container.NewScroll(sw.result),
+	)))
+	sw.window.Resize(fyne.NewSize(800, 600))
+
+	sw.loadSnapshots()
+	return sw
+}
+
+func (sw *SnapshotsWindow) Show() {
+	sw.window.Show()
+}
+
+func snapshotLabel(s app.DirectorySnapshot) string {
+	return fmt.Sprintf("%s (%d files, %s)", formatTimestamp(s.TakenAt), s.Files, formatFileSize(s.TotalSize))
+}
+
+func (sw *SnapshotsWindow) loadSnapshots() {
+	snapshots, err := sw.orchestrator.DirectorySnapshots(sw.dirPath)
+	if err != nil {
+		sw.statusLabel.SetText(err.Error())
+		sw.takeBtn.Disable()
+		return
+	}
+	sw.snapshots = snapshots
+
+	labels := make([]string, len(snapshots))
+	for i, s := range snapshots {
+		labels[i] = snapshotLabel(s)
+	}
+	sw.olderSelect.SetOptions(labels)
+	sw.newerSelect.SetOptions(labels)
+	sw.statusLabel.SetText(fmt.Sprintf("%d snapshots", len(snapshots)))
+	if len(snapshots) < 2 {
+		sw.result.SetText("Take at least two snapshots to compare them.")
+		return
+	}
+	// The two latest, so the view opens on the most recent changes
+	sw.olderSelect.SetSelectedIndex(1)
+	sw.newerSelect.SetSelectedIndex(0)
+}
+
+func (sw *SnapshotsWindow) compare() {
+	older, newer := sw.olderSelect.SelectedIndex(), sw.newerSelect.SelectedIndex()
+	if older < 0 || newer < 0 {
+		return
+	}
+	if older == newer {
+		sw.result.SetText("Pick two different snapshots.")
+		return
+	}
+	comparison, err := sw.orchestrator.CompareDirectorySnapshots(sw.snapshots[older], sw.snapshots[newer])
+	if err != nil {
+		sw.result.SetText(err.Error())
+		return
+	}
+	text := comparison.String()
+	if comparison.Empty() {
+		text += "\nNo changes."
+	}
+	sw.result.SetText(text)
+}
+
+func (sw *SnapshotsWindow) onTakeSnapshot() {
+	sw.takeBtn.Disable()
+	sw.statusLabel.SetText("Recording " + sw.dirPath + "...")
+	go func() {
+		snapshot, err := sw.orchestrator.TakeDirectorySnapshot(sw.dirPath)
+		fyne.Do(func() {
+			sw.takeBtn.Enable()
+			if err != nil {
+				sw.logger.Error("Snapshot failed: %v", err)
+				dialog.ShowError(err, sw.window)
+				sw.statusLabel.SetText("Snapshot failed")
+				return
+			}
+			sw.loadSnapshots()
+			sw.statusLabel.SetText("Recorded " + snapshotLabel(snapshot))
+		})
+	}()
+}
@@ -0,0 +1,163 @@
+// Package organizer embeds the VibesAndFolders organization engine in other Go programs. It
+// scans a folder, asks a language model for a plan of moves and executes the plan, optionally
+// keeping an index of AI-generated file descriptions. It has no GUI dependencies: settings are a
+// plain Config struct and progress is reported through callbacks.
+//
+//	config := organizer.DefaultConfig()
+//	config.APIKey = os.Getenv("OPENROUTER_API_KEY")
+//	org, err := organizer.New(organizer.Options{Config: config})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer org.Close()
+//
+//	plan := org.Analyze(organizer.AnalysisRequest{DirectoryPath: dir, UserPrompt: "Sort by year", MaxDepth: 1}, nil)
+//	if plan.Error != nil {
+//		log.Fatal(plan.Error)
+//	}
+//	result := org.Execute(organizer.ExecutionRequest{Operations: plan.Operations, BasePath: dir, PlannedAt: plan.PlannedAt})
+package organizer
+
+import (
+	"io.github.sandwichdoge.vibesandfolders/internal/app"
+)
+
+// The engine's types, so programs using this package never import internal packages
+type (
+	Config           = app.Config
+	Logger           = app.Logger
+	FileOperation    = app.FileOperation
+	AnalysisRequest  = app.AnalysisRequest
+	AnalysisResult   = app.AnalysisResult
+	ExecutionRequest = app.ExecutionRequest
+	ExecutionResult  = app.ExecutionResult
+	OperationResult  = app.OperationResult
+	ExecutionControl = app.ExecutionControl
+	ScanProgress     = app.ScanProgress
+	DirectoryChanges = app.DirectoryChanges
+	IndexedFile      = app.IndexedFile
+	FileSelection    = app.FileSelection
+	ShortcutFix      = app.ShortcutFix
+	ReferenceUpdate  = app.ReferenceUpdate
+)
+
+var (
+	ErrShuttingDown     = app.ErrShuttingDown
+	ErrExecutionStopped = app.ErrExecutionStopped
+	ErrNoNewFiles       = app.ErrNoNewFiles // Analyze with NewFilesOnly found nothing to plan
+)
+
+// DefaultConfig returns the settings the desktop app starts with; set at least APIKey
+func DefaultConfig() *Config {
+	return app.DefaultConfig()
+}
+
+// NewLogger logs to stderr at the info level, or the debug level too; see Logger.Configure
+func NewLogger(debug bool) *Logger {
+	return app.NewLogger(debug)
+}
+
+// NewExecutionControl lets another goroutine pause or stop an execution between operations
+func NewExecutionControl() *ExecutionControl {
+	return app.NewExecutionControl()
+}
+
+// Options configures an Organizer
+type Options struct {
+	Config *Config // Nil uses DefaultConfig
+
+	// Folder for the index database, its encryption key, the audit log and usage records. Empty
+	// runs without an index, so deep analysis is unavailable. Config.IndexDBPath and
+	// Config.AuditLogPath override where those are kept.
+	DataDir string
+
+	Logger *Logger // Nil is NewLogger(false)
+}
+
+// Organizer plans and executes the organization of folders. Its methods may be called from
+// several goroutines, but only one execution should run per folder at a time.
+type Organizer struct {
+	config       *Config
+	logger       *Logger
+	orchestrator *app.Orchestrator
+	shutdown     *app.ShutdownCoordinator
+}
+
+// New wires up the engine the same way the desktop app does. The config is read as the engine
+// runs, so changes to it apply to later calls. File type mappings and locale formatting are
+// process-wide and follow the config of the most recently created Organizer. New fails when the
+// index can't be opened, or can't be encrypted while Config.EncryptIndex is set.
+func New(opts Options) (*Organizer, error) {
+	config := opts.Config
+	if config == nil {
+		config = DefaultConfig()
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = app.NewLogger(false)
+	}
+
+	engine, err := app.NewEngine(app.EngineOptions{Config: config, Logger: logger, DataDir: opts.DataDir})
+	if err != nil {
+		return nil, err
+	}
+	return &Organizer{config: config, logger: logger, orchestrator: engine.Orchestrator, shutdown: engine.Shutdown}, nil
+}
+
+// Analyze scans req.DirectoryPath and asks the model for a plan. onOperation, which may be nil,
+// receives operations as they stream in; the result holds the checked plan.
+func (o *Organizer) Analyze(req AnalysisRequest, onOperation func(op FileOperation)) AnalysisResult {
+	if onOperation == nil {
+		onOperation = func(FileOperation) {}
+	}
+	return o.orchestrator.AnalyzeDirectory(req, onOperation)
+}
+
+// Select asks the model which files of req.DirectoryPath req.UserPrompt is about. Set
+// AnalysisRequest.OnlyFiles to the selection to plan just those files.
+func (o *Organizer) Select(req AnalysisRequest) FileSelection {
+	return o.orchestrator.SelectFiles(req)
+}
+
+// Execute performs the operations of a plan. Pass the plan's PlannedAt so operations that earlier
+// executions made impossible are skipped rather than failing.
+func (o *Organizer) Execute(req ExecutionRequest) ExecutionResult {
+	return o.orchestrator.ExecuteOrganization(req)
+}
+
+// Rollback undoes the successful operations of an earlier execution. Shortcuts and references it
+// rewrote are pointed back at the original paths.
+func (o *Organizer) Rollback(result ExecutionResult, basePath string) ExecutionResult {
+	var undo []FileOperation
+	for i := len(result.Operations) - 1; i >= 0; i-- {
+		if op := result.Operations[i]; op.Success {
+			undo = append(undo, FileOperation{From: op.Operation.To, To: op.Operation.From})
+		}
+	}
+	rewrite := false
+	for _, fix := range result.Shortcuts {
+		rewrite = rewrite || fix.Error == nil
+	}
+	return o.orchestrator.ExecuteOrganization(ExecutionRequest{
+		Operations:       undo,
+		BasePath:         basePath,
+		Rollback:         true,
+		RewriteShortcuts: rewrite,
+		UpdateReferences: len(result.References) > 0,
+	})
+}
+
+// Index describes the new and changed files under dirPath with the model. It fails without an index.
+func (o *Organizer) Index(dirPath string, maxDepth int, onProgress func(current, total int, filePath string)) error {
+	return o.orchestrator.IndexDirectory(dirPath, maxDepth, onProgress)
+}
+
+// IndexedFiles returns the index entries under dirPath
+func (o *Organizer) IndexedFiles(dirPath string) ([]IndexedFile, error) {
+	return o.orchestrator.GetIndexedFiles(dirPath)
+}
+
+// Close stops running indexing and executions at a safe point and closes the index
+func (o *Organizer) Close() error {
+	return o.shutdown.Shutdown(app.DefaultShutdownTimeout)
+}
@@ -0,0 +1,104 @@
+package organizer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"io.github.sandwichdoge.vibesandfolders/internal/app"
+)
+
+// streamPlan answers chat completion requests with a plan of one move, streamed like the real API
+func streamPlan(t *testing.T, plan string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", plan)
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOrganizerPlansExecutesAndRollsBack(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "report.pdf"), []byte("pdf"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	server := streamPlan(t, `{"from": "report.pdf", "to": "Documents/report.pdf"}`+"\n")
+
+	config := DefaultConfig()
+	config.Endpoint = server.URL
+	config.APIKey = "test"
+	org, err := New(Options{Config: config, DataDir: t.TempDir(), Logger: NewLogger(false)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer org.Close()
+
+	var streamed int
+	plan := org.Analyze(AnalysisRequest{DirectoryPath: dir, UserPrompt: "Sort", MaxDepth: 1}, func(FileOperation) { streamed++ })
+	if plan.Error != nil {
+		t.Fatal(plan.Error)
+	}
+	if len(plan.Operations) != 1 || streamed != 1 {
+		t.Fatalf("plan = %+v (%d streamed), want one move", plan.Operations, streamed)
+	}
+
+	result := org.Execute(ExecutionRequest{Operations: plan.Operations, BasePath: dir, PlannedAt: plan.PlannedAt})
+	if result.SuccessCount != 1 {
+		t.Fatalf("Execute() = %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Documents", "report.pdf")); err != nil {
+		t.Errorf("file wasn't moved: %v", err)
+	}
+
+	if undone := org.Rollback(result, dir); undone.SuccessCount != 1 {
+		t.Fatalf("Rollback() = %+v", undone)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "report.pdf")); err != nil {
+		t.Errorf("file wasn't moved back: %v", err)
+	}
+}
+
+func TestOrganizerWithoutIndex(t *testing.T) {
+	org, err := New(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := org.Index(t.TempDir(), 1, nil); err == nil {
+		t.Error("Index() without a data folder succeeded")
+	}
+	if err := org.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOrganizerEncryptsIndex(t *testing.T) {
+	dataDir := t.TempDir()
+	config := DefaultConfig()
+	config.EncryptIndex = true
+	org, err := New(Options{Config: config, DataDir: dataDir, Logger: NewLogger(false)})
+	if errors.Is(err, app.ErrIndexKeyUnavailable) {
+		return // No keychain here; refusing is right, a plaintext index wouldn't be
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := org.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	index := app.NewIndexService(NewLogger(false))
+	if err := index.Initialize(filepath.Join(dataDir, "index.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+	if encrypted, err := index.IsEncrypted(); err != nil || !encrypted {
+		t.Errorf("IsEncrypted() = %v, %v; want the index encrypted", encrypted, err)
+	}
+}